@@ -0,0 +1,133 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// adminCallerKey is the context key for the name of the token used to call the admin server.
+type adminCallerKey struct{}
+
+// adminMux holds the handlers for the admin server.
+var adminMux = http.NewServeMux()
+
+// registerAdminHandler registers a handler with the admin server.
+// Handlers registered after the admin server has started are served from the
+// point of registration.
+func registerAdminHandler(pattern string, handler http.HandlerFunc) {
+	adminMux.HandleFunc(pattern, handler)
+}
+
+// postOnly wraps a handler to only accept POST requests.
+func postOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// adminCaller returns the name of the token used to make an admin request.
+func adminCaller(ctx context.Context) string {
+	caller, ok := ctx.Value(adminCallerKey{}).(string)
+	if !ok {
+		return ""
+	}
+
+	return caller
+}
+
+// authenticate wraps the admin handlers to require a bearer token.
+// The name of the token is added to the request context, and every request is
+// logged and counted against it.
+func authenticate(handler *http.ServeMux, tokens map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, action := handler.Handler(r)
+		if action == "" {
+			action = "unknown"
+		}
+
+		caller := ""
+		if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+			for name, candidate := range tokens {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(candidate)) == 1 {
+					caller = name
+					break
+				}
+			}
+		}
+		if caller == "" {
+			log.Warn().Str("remote_address", r.RemoteAddr).Str("action", action).Msg("Unauthorised admin request")
+			monitorAdminRequest("unauthorised", action)
+			http.Error(w, "unauthorised", http.StatusUnauthorized)
+			return
+		}
+
+		log.Info().Str("caller", caller).Str("method", r.Method).Str("action", action).Str("query", r.URL.RawQuery).Msg("Admin request")
+		monitorAdminRequest(caller, action)
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), adminCallerKey{}, caller)))
+	})
+}
+
+// startAdmin starts the admin server, if configured.
+func startAdmin(ctx context.Context) error {
+	listenAddress := viper.GetString("admin.listen-address")
+	if listenAddress == "" {
+		return nil
+	}
+
+	tokens := make(map[string]string)
+	for name, token := range viper.GetStringMapString("admin.tokens") {
+		if token == "" {
+			return errors.Errorf("admin token %q is empty", name)
+		}
+		tokens[name] = token
+	}
+	if len(tokens) == 0 {
+		return errors.New("admin server requires at least one token")
+	}
+
+	registerAdminHandler("/status", handleStatus)
+	registerAdminHandler("/log-levels", handleLogLevels)
+	server := &http.Server{
+		Addr:              listenAddress,
+		Handler:           authenticate(adminMux, tokens),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Info().Str("admin_address", listenAddress).Msg("Starting admin server")
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Warn().Str("admin_address", listenAddress).Err(err).Msg("Failed to run admin server")
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		//nolint:contextcheck
+		if err := server.Shutdown(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("Failed to shut down admin server")
+		}
+	}()
+
+	return nil
+}
@@ -15,18 +15,36 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	autoclient "github.com/attestantio/go-eth2-client/auto"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/beaconfetcher"
+	standardbeaconfetcher "github.com/wealdtech/chaind/services/beaconfetcher/standard"
+	"github.com/wealdtech/chaind/services/chaintime"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/ratelimiter"
+	standardratelimiter "github.com/wealdtech/chaind/services/ratelimiter/standard"
 	"github.com/wealdtech/chaind/util"
 )
 
 var (
 	clients   map[string]eth2client.Service
 	clientsMu sync.Mutex
+
+	beaconFetchers   map[string]beaconfetcher.Service
+	beaconFetchersMu sync.Mutex
+
+	rateLimiters   map[string]*standardratelimiter.Service
+	rateLimitersMu sync.Mutex
+
+	// clientsMonitor is the monitor for metrics of the shared clients.
+	clientsMonitor metrics.Service
 )
 
 // fetchClient fetches a client service, instantiating it if required.
@@ -52,12 +70,100 @@ func fetchClient(ctx context.Context, address string) (eth2client.Service, error
 		if err := confirmClientInterfaces(client); err != nil {
 			return nil, errors.Wrap(err, "missing required interface")
 		}
+		if viper.GetFloat64("eth2client.rate-limit.budget") > 0 {
+			// The chain time service is not yet available, so create one for the limiter.
+			chainTime, err := standardchaintime.New(ctx,
+				standardchaintime.WithLogLevel(util.LogLevel("chaintime")),
+				standardchaintime.WithGenesisTimeProvider(client.(eth2client.GenesisTimeProvider)),
+				standardchaintime.WithSpecProvider(client.(eth2client.SpecProvider)),
+				standardchaintime.WithForkScheduleProvider(client.(eth2client.ForkScheduleProvider)),
+			)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to start chain time service for rate limiter")
+			}
+			rateLimiter, err := fetchRateLimiter(ctx, address, chainTime)
+			if err != nil {
+				return nil, err
+			}
+			client = rateLimiter.Client(client)
+		}
 		clients[address] = client
 	}
 
 	return client, nil
 }
 
+// fetchBeaconFetcher fetches a beacon fetcher for blocks and states, instantiating it if required.
+func fetchBeaconFetcher(ctx context.Context, address string, chainTime chaintime.Service) (beaconfetcher.Service, error) {
+	beaconFetchersMu.Lock()
+	defer beaconFetchersMu.Unlock()
+	if beaconFetchers == nil {
+		beaconFetchers = make(map[string]beaconfetcher.Service)
+	}
+
+	var fetcher beaconfetcher.Service
+	var exists bool
+	if fetcher, exists = beaconFetchers[address]; !exists {
+		encoding, err := standardbeaconfetcher.ParseEncoding(viper.GetString("eth2client.encoding"))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid beacon node encoding")
+		}
+		fetcher, err = standardbeaconfetcher.New(ctx,
+			standardbeaconfetcher.WithLogLevel(util.LogLevel("eth2client")),
+			standardbeaconfetcher.WithAddress(address),
+			standardbeaconfetcher.WithTimeout(viper.GetDuration("eth2client.timeout")),
+			standardbeaconfetcher.WithEncoding(encoding),
+			standardbeaconfetcher.WithChainTime(chainTime),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initiate beacon fetcher")
+		}
+		if viper.GetFloat64("eth2client.rate-limit.budget") > 0 {
+			rateLimiter, err := fetchRateLimiter(ctx, address, chainTime)
+			if err != nil {
+				return nil, err
+			}
+			fetcher = rateLimiter.BeaconFetcher(fetcher)
+		}
+		beaconFetchers[address] = fetcher
+	}
+
+	return fetcher, nil
+}
+
+// fetchRateLimiter fetches the rate limiter for requests to a beacon node, instantiating it if required.
+// The limiter is shared by the client and beacon fetcher for the node, so the budget covers both.
+func fetchRateLimiter(ctx context.Context, address string, chainTime chaintime.Service) (*standardratelimiter.Service, error) {
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+	if rateLimiters == nil {
+		rateLimiters = make(map[string]*standardratelimiter.Service)
+	}
+
+	rateLimiter, exists := rateLimiters[address]
+	if !exists {
+		weights := make(map[ratelimiter.Class]float64, len(ratelimiter.Classes))
+		for _, class := range ratelimiter.Classes {
+			weights[class] = viper.GetFloat64(fmt.Sprintf("eth2client.rate-limit.weights.%s", class))
+		}
+		var err error
+		rateLimiter, err = standardratelimiter.New(ctx,
+			standardratelimiter.WithLogLevel(util.LogLevel("eth2client")),
+			standardratelimiter.WithMonitor(clientsMonitor),
+			standardratelimiter.WithChainTime(chainTime),
+			standardratelimiter.WithBudget(viper.GetFloat64("eth2client.rate-limit.budget")),
+			standardratelimiter.WithWeights(weights),
+			standardratelimiter.WithHeadDistance(phase0.Slot(viper.GetUint64("eth2client.rate-limit.head-distance"))),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start rate limiter")
+		}
+		rateLimiters[address] = rateLimiter
+	}
+
+	return rateLimiter, nil
+}
+
 func confirmClientInterfaces(client eth2client.Service) error {
 	if _, isProvider := client.(eth2client.GenesisTimeProvider); !isProvider {
 		return errors.New("client is not a GenesisTimeProvider")
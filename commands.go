@@ -0,0 +1,61 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// subcommands are the commands that run in place of the daemon.
+var subcommands = map[string]func(ctx context.Context) error{
+	"version":             runVersion,
+	"verify":              runVerify,
+	"export-deposit-logs": runExportDepositLogs,
+}
+
+// runSubcommand runs the subcommand given as the first argument, if present.
+// Returns true if a subcommand was run.
+func runSubcommand(ctx context.Context) (bool, error) {
+	if len(os.Args) < 2 {
+		return false, nil
+	}
+	subcommand, exists := subcommands[os.Args[1]]
+	if !exists {
+		return false, nil
+	}
+
+	return true, subcommand(ctx)
+}
+
+// runVersion prints version information.
+func runVersion(_ context.Context) error {
+	commit := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				commit = setting.Value
+			}
+		}
+	}
+
+	fmt.Printf("version: %s\n", ReleaseVersion)
+	fmt.Printf("commit: %s\n", commit)
+	fmt.Printf("go: %s\n", runtime.Version())
+
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+// migrateCompactCommittees rewrites all beacon committees held in full form
+// in compact form, a batch at a time.
+func migrateCompactCommittees(ctx context.Context) error {
+	chainDB, err := startDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := chainDB.(*postgresqlchaindb.Service).Upgrade(ctx); err != nil {
+		return errors.Wrap(err, "failed to upgrade chain database")
+	}
+	compactor, isCompactor := chainDB.(chaindb.BeaconCommitteesCompactor)
+	if !isCompactor {
+		return errors.New("chain database does not support compact committees")
+	}
+
+	batchSize := viper.GetInt("chaindb.migrate-batch-size")
+	if batchSize <= 0 {
+		return errors.New("migration batch size must be greater than 0")
+	}
+
+	total := 0
+	for {
+		ctx, cancel, err := chainDB.BeginTx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction")
+		}
+		compacted, err := compactor.CompactBeaconCommittees(ctx, batchSize)
+		if err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to compact beacon committees")
+		}
+		if err := chainDB.CommitTx(ctx); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to commit transaction")
+		}
+		if compacted == 0 {
+			break
+		}
+		total += compacted
+		log.Info().Int("compacted", total).Msg("Compacted beacon committees")
+	}
+	log.Info().Int("compacted", total).Msg("All beacon committees compact")
+
+	return nil
+}
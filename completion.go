@@ -0,0 +1,216 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/notifications"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+// completionMetadataKey is the metadata key for the complete-up-to watermark.
+const completionMetadataKey = "completion"
+
+var (
+	completionProvidersMu sync.Mutex
+	completionProviders   = make(map[string]notifications.CompletionProvider)
+	// ingestionProviders are the names of the completion providers that ingest data from the
+	// beacon node, and so contribute to the complete-up-to watermark.
+	ingestionProviders = make(map[string]bool)
+)
+
+var (
+	latestCompletionMu sync.Mutex
+	latestCompletion   *completionMetadata
+)
+
+// completionMetadata is the complete-up-to watermark across ingesting services.
+type completionMetadata struct {
+	// CompleteSlot is the slot up to and including which all ingesting services have stored their data.
+	CompleteSlot phase0.Slot `json:"complete_slot"`
+	// Complete is false if any ingesting service has yet to complete a slot, in which case CompleteSlot is not set.
+	Complete bool `json:"complete"`
+	// Services are the latest complete slots of the individual ingesting services.
+	Services map[string]phase0.Slot `json:"services"`
+}
+
+// registerCompletionProvider registers a service to be consulted for the extent of its data,
+// if it is able to provide it.
+// If the service ingests data from the beacon node it also contributes to the complete-up-to watermark.
+func registerCompletionProvider(name string, service any, ingests bool) {
+	provider, isProvider := service.(notifications.CompletionProvider)
+	if !isProvider {
+		return
+	}
+
+	completionProvidersMu.Lock()
+	completionProviders[name] = provider
+	if ingests {
+		ingestionProviders[name] = true
+	}
+	completionProvidersMu.Unlock()
+}
+
+// completedServices returns the names of the registered services whose data for the given epoch is complete.
+func completedServices(ctx context.Context, epoch phase0.Epoch) []string {
+	completionProvidersMu.Lock()
+	defer completionProvidersMu.Unlock()
+
+	res := make([]string, 0, len(completionProviders))
+	for name, provider := range completionProviders {
+		latestEpoch, complete, err := provider.LatestCompleteEpoch(ctx)
+		if err != nil {
+			log.Warn().Str("service", name).Err(err).Msg("Failed to obtain latest complete epoch")
+			continue
+		}
+		if complete && latestEpoch >= epoch {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// latestCompleteSlot returns the latest slot for which a provider's data is complete.
+// Providers that only report at epoch granularity are complete up to the last slot of their epoch.
+func latestCompleteSlot(ctx context.Context,
+	chainTime chaintime.Service,
+	provider notifications.CompletionProvider,
+) (
+	phase0.Slot,
+	bool,
+	error,
+) {
+	if slotProvider, isProvider := provider.(notifications.SlotCompletionProvider); isProvider {
+		return slotProvider.LatestCompleteSlot(ctx)
+	}
+
+	epoch, complete, err := provider.LatestCompleteEpoch(ctx)
+	if err != nil || !complete {
+		return 0, false, err
+	}
+
+	return chainTime.FirstSlotOfEpoch(epoch+1) - 1, true, nil
+}
+
+// calculateCompletion calculates the complete-up-to watermark, being the minimum of the latest
+// complete slots of the ingesting services.
+func calculateCompletion(ctx context.Context, chainTime chaintime.Service) (*completionMetadata, error) {
+	completionProvidersMu.Lock()
+	defer completionProvidersMu.Unlock()
+
+	res := &completionMetadata{
+		Complete: len(ingestionProviders) > 0,
+		Services: make(map[string]phase0.Slot, len(ingestionProviders)),
+	}
+	for name := range ingestionProviders {
+		slot, complete, err := latestCompleteSlot(ctx, chainTime, completionProviders[name])
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to obtain latest complete slot for %s", name))
+		}
+		if !complete {
+			res.Complete = false
+			continue
+		}
+		res.Services[name] = slot
+		if len(res.Services) == 1 || slot < res.CompleteSlot {
+			res.CompleteSlot = slot
+		}
+	}
+	if !res.Complete {
+		res.CompleteSlot = 0
+	}
+
+	return res, nil
+}
+
+// updateCompletion recalculates the complete-up-to watermark, storing it in metadata if it has changed.
+func updateCompletion(ctx context.Context, chainDB chaindb.Service, chainTime chaintime.Service) error {
+	md, err := calculateCompletion(ctx, chainTime)
+	if err != nil {
+		return err
+	}
+
+	latestCompletionMu.Lock()
+	changed := latestCompletion == nil ||
+		latestCompletion.Complete != md.Complete ||
+		latestCompletion.CompleteSlot != md.CompleteSlot
+	latestCompletion = md
+	latestCompletionMu.Unlock()
+
+	if md.Complete {
+		monitorCompleteSlot(md.CompleteSlot)
+	}
+	if !changed {
+		return nil
+	}
+
+	data, err := json.Marshal(md)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal completion metadata")
+	}
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := chainDB.SetMetadata(ctx, completionMetadataKey, data); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set completion metadata")
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	log.Trace().Bool("complete", md.Complete).Uint64("complete_slot", uint64(md.CompleteSlot)).Msg("Updated completion")
+
+	return nil
+}
+
+// startCompletion maintains the complete-up-to watermark, updating it at the start of each slot.
+func startCompletion(ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	scheduler scheduler.Service,
+) error {
+	registerStatus("completion", func(_ context.Context) any {
+		latestCompletionMu.Lock()
+		defer latestCompletionMu.Unlock()
+
+		return latestCompletion
+	})
+
+	return scheduler.SchedulePeriodicJob(ctx,
+		"completion",
+		"completion",
+		func(_ context.Context, _ interface{}) (time.Time, error) {
+			return chainTime.StartOfSlot(chainTime.CurrentSlot() + 1), nil
+		},
+		nil,
+		func(ctx context.Context, _ interface{}) error {
+			return updateCompletion(ctx, chainDB, chainTime)
+		},
+		nil,
+	)
+}
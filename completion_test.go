@@ -0,0 +1,254 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/notifications"
+)
+
+// epochCompletionProvider reports completion at epoch granularity.
+type epochCompletionProvider struct {
+	epoch    phase0.Epoch
+	complete bool
+	err      error
+}
+
+func (p *epochCompletionProvider) LatestCompleteEpoch(_ context.Context) (phase0.Epoch, bool, error) {
+	return p.epoch, p.complete, p.err
+}
+
+// slotCompletionProvider reports completion at slot granularity.
+type slotCompletionProvider struct {
+	epochCompletionProvider
+	slot phase0.Slot
+}
+
+func (p *slotCompletionProvider) LatestCompleteSlot(_ context.Context) (phase0.Slot, bool, error) {
+	return p.slot, p.complete, p.err
+}
+
+// completionChainTime is a chain time with 32 slots per epoch.
+type completionChainTime struct {
+	chaintime.Service
+}
+
+func (*completionChainTime) FirstSlotOfEpoch(epoch phase0.Epoch) phase0.Slot {
+	return phase0.Slot(epoch) * 32
+}
+
+// completionChainDB is a chain database that records the metadata written to it.
+type completionChainDB struct {
+	chaindb.Service
+	writes [][]byte
+}
+
+func (*completionChainDB) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {}, nil
+}
+
+func (*completionChainDB) CommitTx(_ context.Context) error {
+	return nil
+}
+
+func (c *completionChainDB) SetMetadata(_ context.Context, key string, value []byte) error {
+	if key == completionMetadataKey {
+		c.writes = append(c.writes, value)
+	}
+	return nil
+}
+
+// resetCompletion clears the registered completion providers and latest completion for the duration of a test.
+func resetCompletion(t *testing.T) {
+	t.Helper()
+
+	completionProvidersMu.Lock()
+	savedProviders := completionProviders
+	savedIngestion := ingestionProviders
+	completionProviders = make(map[string]notifications.CompletionProvider)
+	ingestionProviders = make(map[string]bool)
+	completionProvidersMu.Unlock()
+
+	latestCompletionMu.Lock()
+	savedLatest := latestCompletion
+	latestCompletion = nil
+	latestCompletionMu.Unlock()
+
+	t.Cleanup(func() {
+		completionProvidersMu.Lock()
+		completionProviders = savedProviders
+		ingestionProviders = savedIngestion
+		completionProvidersMu.Unlock()
+
+		latestCompletionMu.Lock()
+		latestCompletion = savedLatest
+		latestCompletionMu.Unlock()
+	})
+}
+
+func TestCalculateCompletion(t *testing.T) {
+	ctx := context.Background()
+	chainTime := &completionChainTime{}
+
+	tests := []struct {
+		name      string
+		providers map[string]notifications.CompletionProvider
+		ingests   map[string]bool
+		expected  *completionMetadata
+		err       string
+	}{
+		{
+			name:     "None",
+			expected: &completionMetadata{Services: map[string]phase0.Slot{}},
+		},
+		{
+			name: "Single",
+			providers: map[string]notifications.CompletionProvider{
+				"blocks": &slotCompletionProvider{epochCompletionProvider: epochCompletionProvider{complete: true}, slot: 100},
+			},
+			ingests: map[string]bool{"blocks": true},
+			expected: &completionMetadata{
+				CompleteSlot: 100,
+				Complete:     true,
+				Services:     map[string]phase0.Slot{"blocks": 100},
+			},
+		},
+		{
+			name: "Minimum",
+			providers: map[string]notifications.CompletionProvider{
+				"blocks":          &slotCompletionProvider{epochCompletionProvider: epochCompletionProvider{complete: true}, slot: 100},
+				"proposer-duties": &slotCompletionProvider{epochCompletionProvider: epochCompletionProvider{complete: true}, slot: 90},
+				"validators":      &slotCompletionProvider{epochCompletionProvider: epochCompletionProvider{complete: true}, slot: 95},
+			},
+			ingests: map[string]bool{"blocks": true, "proposer-duties": true, "validators": true},
+			expected: &completionMetadata{
+				CompleteSlot: 90,
+				Complete:     true,
+				Services:     map[string]phase0.Slot{"blocks": 100, "proposer-duties": 90, "validators": 95},
+			},
+		},
+		{
+			name: "EpochGranularity",
+			providers: map[string]notifications.CompletionProvider{
+				"blocks":            &slotCompletionProvider{epochCompletionProvider: epochCompletionProvider{complete: true}, slot: 100},
+				"beacon-committees": &epochCompletionProvider{epoch: 2, complete: true},
+			},
+			ingests: map[string]bool{"blocks": true, "beacon-committees": true},
+			expected: &completionMetadata{
+				// Epoch 2 is complete up to the last slot of the epoch.
+				CompleteSlot: 95,
+				Complete:     true,
+				Services:     map[string]phase0.Slot{"blocks": 100, "beacon-committees": 95},
+			},
+		},
+		{
+			name: "NonIngestingIgnored",
+			providers: map[string]notifications.CompletionProvider{
+				"blocks":     &slotCompletionProvider{epochCompletionProvider: epochCompletionProvider{complete: true}, slot: 100},
+				"summarizer": &epochCompletionProvider{epoch: 0, complete: true},
+			},
+			ingests: map[string]bool{"blocks": true},
+			expected: &completionMetadata{
+				CompleteSlot: 100,
+				Complete:     true,
+				Services:     map[string]phase0.Slot{"blocks": 100},
+			},
+		},
+		{
+			name: "Incomplete",
+			providers: map[string]notifications.CompletionProvider{
+				"blocks":     &slotCompletionProvider{epochCompletionProvider: epochCompletionProvider{complete: true}, slot: 100},
+				"validators": &slotCompletionProvider{epochCompletionProvider: epochCompletionProvider{complete: false}},
+			},
+			ingests: map[string]bool{"blocks": true, "validators": true},
+			expected: &completionMetadata{
+				Complete: false,
+				Services: map[string]phase0.Slot{"blocks": 100},
+			},
+		},
+		{
+			name: "Error",
+			providers: map[string]notifications.CompletionProvider{
+				"blocks": &slotCompletionProvider{epochCompletionProvider: epochCompletionProvider{err: errors.New("mock error")}},
+			},
+			ingests: map[string]bool{"blocks": true},
+			err:     "failed to obtain latest complete slot for blocks: mock error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resetCompletion(t)
+			for name, provider := range test.providers {
+				registerCompletionProvider(name, provider, test.ingests[name])
+			}
+
+			md, err := calculateCompletion(ctx, chainTime)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, md)
+			}
+		})
+	}
+}
+
+func TestUpdateCompletion(t *testing.T) {
+	ctx := context.Background()
+	chainTime := &completionChainTime{}
+	chainDB := &completionChainDB{}
+	resetCompletion(t)
+
+	blocks := &slotCompletionProvider{epochCompletionProvider: epochCompletionProvider{complete: true}, slot: 100}
+	validators := &slotCompletionProvider{epochCompletionProvider: epochCompletionProvider{complete: true}, slot: 90}
+	registerCompletionProvider("blocks", blocks, true)
+	registerCompletionProvider("validators", validators, true)
+
+	// Initial calculation is persisted.
+	require.NoError(t, updateCompletion(ctx, chainDB, chainTime))
+	require.Len(t, chainDB.writes, 1)
+	require.JSONEq(t, `{"complete_slot":"90","complete":true,"services":{"blocks":"100","validators":"90"}}`, string(chainDB.writes[0]))
+
+	// Advancing a service that is not the minimum does not change the watermark, so is not persisted.
+	blocks.slot = 110
+	require.NoError(t, updateCompletion(ctx, chainDB, chainTime))
+	require.Len(t, chainDB.writes, 1)
+
+	// Advancing the minimum service moves the watermark.
+	validators.slot = 105
+	require.NoError(t, updateCompletion(ctx, chainDB, chainTime))
+	require.Len(t, chainDB.writes, 2)
+	md := &completionMetadata{}
+	require.NoError(t, json.Unmarshal(chainDB.writes[1], md))
+	require.Equal(t, &completionMetadata{
+		CompleteSlot: 105,
+		Complete:     true,
+		Services:     map[string]phase0.Slot{"blocks": 110, "validators": 105},
+	}, md)
+
+	// A service losing completion clears the watermark.
+	validators.complete = false
+	require.NoError(t, updateCompletion(ctx, chainDB, chainTime))
+	require.Len(t, chainDB.writes, 3)
+	require.JSONEq(t, `{"complete_slot":"0","complete":false,"services":{"blocks":"110"}}`, string(chainDB.writes[2]))
+}
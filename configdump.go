@@ -0,0 +1,160 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// envPrefix is the prefix for environment variables that override configuration.
+const envPrefix = "CHAIND"
+
+// envKeyReplacer maps configuration keys to environment variable names.
+var envKeyReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// structuredConfigKeys are configuration keys that hold lists or maps.  When
+// supplied through the environment their values are JSON.
+var structuredConfigKeys = []string{
+	"admin.tokens",
+	"notifications.webhooks",
+}
+
+// secretConfigKeys are configuration keys, or prefixes of keys, whose values
+// are secret and so are never logged.
+var secretConfigKeys = []string{
+	"admin.tokens",
+	"chaindb.url",
+	"eth1client.address",
+	"notifications.webhooks",
+	"tracing.client-key",
+}
+
+// envKey returns the name of the environment variable that overrides the given
+// configuration key, for example CHAIND_ETH1DEPOSITS_ENABLE for eth1deposits.enable.
+func envKey(key string) string {
+	return fmt.Sprintf("%s_%s", envPrefix, strings.ToUpper(envKeyReplacer.Replace(key)))
+}
+
+// envValue returns the value of the environment variable that overrides the
+// given configuration key, if it is set.
+func envValue(key string) (string, bool) {
+	value, exists := os.LookupEnv(envKey(key))
+	if !exists || value == "" {
+		// Viper ignores empty environment variables.
+		return "", false
+	}
+
+	return value, true
+}
+
+// decodeStructuredEnv decodes JSON values supplied through the environment for
+// structured configuration keys, so that they can be read in the same way as
+// values from the configuration file.
+func decodeStructuredEnv(v *viper.Viper, flags *pflag.FlagSet) error {
+	for _, key := range structuredConfigKeys {
+		if flags != nil && flags.Changed(key) {
+			// Command-line flags take precedence over the environment.
+			continue
+		}
+		value, exists := envValue(key)
+		if !exists {
+			continue
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("invalid JSON in %s for %s", envKey(key), key))
+		}
+		v.Set(key, decoded)
+	}
+
+	return nil
+}
+
+// isSecretConfigKey returns true if the value of the given key is secret.
+func isSecretConfigKey(key string) bool {
+	for _, secretKey := range secretConfigKeys {
+		if key == secretKey || strings.HasPrefix(key, fmt.Sprintf("%s.", secretKey)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// configDump returns the effective configuration as strings, keyed by
+// configuration key.  Values supplied through the environment are not
+// included, as the environment is commonly used to supply secrets, and nor are
+// values of secret keys.
+func configDump(v *viper.Viper, flags *pflag.FlagSet) map[string]string {
+	keys := make(map[string]struct{})
+	if flags != nil {
+		flags.VisitAll(func(flag *pflag.Flag) {
+			keys[flag.Name] = struct{}{}
+		})
+	}
+	for _, key := range configOnlyKeys {
+		keys[key] = struct{}{}
+	}
+	for _, key := range v.AllKeys() {
+		keys[key] = struct{}{}
+	}
+
+	dump := make(map[string]string, len(keys))
+	for key := range keys {
+		flagChanged := flags != nil && flags.Lookup(key) != nil && flags.Changed(key)
+		if _, exists := envValue(key); exists && !flagChanged {
+			dump[key] = "<from environment>"
+			continue
+		}
+		value := v.Get(key)
+		if value == nil {
+			continue
+		}
+		if isSecretConfigKey(key) {
+			dump[key] = "<redacted>"
+			continue
+		}
+		dump[key] = fmt.Sprintf("%v", value)
+	}
+
+	return dump
+}
+
+// logConfig logs the effective configuration at debug level.
+func logConfig(v *viper.Viper, flags *pflag.FlagSet) {
+	if log.GetLevel() > zerolog.DebugLevel {
+		return
+	}
+
+	dump := configDump(v, flags)
+	keys := make([]string, 0, len(dump))
+	for key := range dump {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	dict := zerolog.Dict()
+	for _, key := range keys {
+		dict = dict.Str(key, dump[key])
+	}
+	log.Debug().Dict("config", dict).Msg("Configuration")
+}
@@ -0,0 +1,58 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"time"
+
+	getlogseth1deposits "github.com/wealdtech/chaind/services/eth1deposits/getlogs"
+)
+
+// eth1Endpoint is the admin representation of the status of an Ethereum 1 endpoint.
+type eth1Endpoint struct {
+	URL           string `json:"url"`
+	Healthy       bool   `json:"healthy"`
+	LastError     string `json:"last_error,omitempty"`
+	LastErrorTime string `json:"last_error_time,omitempty"`
+	LastSuccess   string `json:"last_success,omitempty"`
+}
+
+// registerETH1DepositsAdmin registers admin handlers to inspect the Ethereum 1 deposits service.
+func registerETH1DepositsAdmin(s *getlogseth1deposits.Service) {
+	registerAdminHandler("/eth1deposits/endpoints", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		stats := s.EndpointStatus()
+		res := make([]*eth1Endpoint, 0, len(stats))
+		for _, stat := range stats {
+			endpoint := &eth1Endpoint{
+				URL:       stat.URL,
+				Healthy:   stat.Healthy,
+				LastError: stat.LastError,
+			}
+			if !stat.LastErrorTime.IsZero() {
+				endpoint.LastErrorTime = stat.LastErrorTime.Format(time.RFC3339Nano)
+			}
+			if !stat.LastSuccess.IsZero() {
+				endpoint.LastSuccess = stat.LastSuccess.Format(time.RFC3339Nano)
+			}
+			res = append(res, endpoint)
+		}
+		writeAdminJSON(w, res)
+	})
+}
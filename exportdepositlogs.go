@@ -0,0 +1,78 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	getlogseth1deposits "github.com/wealdtech/chaind/services/eth1deposits/getlogs"
+)
+
+// runExportDepositLogs writes the deposit logs for a range of Ethereum 1 blocks to
+// a file, to be served with eth1deposits.log-dump-file.
+func runExportDepositLogs(ctx context.Context) error {
+	pflag.Uint64("from-block", 0, "Ethereum 1 block from which to export deposit logs")
+	pflag.Uint64("to-block", 0, "Ethereum 1 block to which to export deposit logs, inclusive")
+	pflag.String("output", "", "File to which to write the deposit logs")
+	if err := fetchConfig(); err != nil {
+		return errors.Wrap(err, "failed to fetch configuration")
+	}
+	if err := initLogging(); err != nil {
+		return errors.Wrap(err, "failed to initialise logging")
+	}
+	if viper.GetString("output") == "" {
+		return errors.New("no output file specified")
+	}
+
+	// The deposit contract address is taken from the chain specification held by the database.
+	chainDB, err := startDatabase(ctx, postgresqlchaindb.WithReadOnly(true))
+	if err != nil {
+		return err
+	}
+	spec, err := chainDB.(chaindb.ChainSpecProvider).ChainSpec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain chain specification")
+	}
+	depositContractAddress, exists := spec["DEPOSIT_CONTRACT_ADDRESS"].([]byte)
+	if !exists {
+		return errors.New("failed to obtain deposit contract address")
+	}
+
+	f, err := os.Create(viper.GetString("output"))
+	if err != nil {
+		return errors.Wrap(err, "failed to create output file")
+	}
+	if err := getlogseth1deposits.ExportLogDump(ctx,
+		viper.GetString("eth1client.address"),
+		depositContractAddress,
+		viper.GetUint64("from-block"),
+		viper.GetUint64("to-block"),
+		f,
+	); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "failed to export deposit logs")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "failed to close output file")
+	}
+	log.Info().Str("output", viper.GetString("output")).Msg("Exported deposit logs")
+
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/notifications"
+)
+
+// progressFunc returns the latest marker processed by a service, and the marker it
+// is expected to have reached, in the same units.
+// It returns false if the service has yet to report its progress.
+type progressFunc func(ctx context.Context) (uint64, uint64, bool, error)
+
+var (
+	progressFuncsMu sync.Mutex
+	progressFuncs   = make(map[string]progressFunc)
+)
+
+// registerProgress registers a service to be checked for freshness.
+// Ingesting services that are completion providers are registered automatically
+// when freshness checks start, so only need to be registered if they are not.
+func registerProgress(name string, progress progressFunc) {
+	progressFuncsMu.Lock()
+	progressFuncs[name] = progress
+	progressFuncsMu.Unlock()
+}
+
+// freshnessChecker checks that a service is keeping up with the chain.
+type freshnessChecker struct {
+	name      string
+	progress  progressFunc
+	threshold uint64
+
+	mu     sync.Mutex
+	status *freshnessStatus
+}
+
+// freshnessStatus is the freshness of a service, as reported in the status.
+type freshnessStatus struct {
+	// Known is false if the service has yet to report its progress.
+	Known     bool   `json:"known"`
+	Latest    uint64 `json:"latest"`
+	Expected  uint64 `json:"expected"`
+	Lag       uint64 `json:"lag"`
+	Threshold uint64 `json:"threshold"`
+	Fresh     bool   `json:"fresh"`
+}
+
+// Ready returns true if the service is no further behind than its threshold.
+// A service that has yet to report its progress is not ready.
+func (c *freshnessChecker) Ready(ctx context.Context) bool {
+	status := &freshnessStatus{
+		Threshold: c.threshold,
+	}
+	latest, expected, known, err := c.progress(ctx)
+	if err != nil {
+		log.Warn().Str("service", c.name).Err(err).Msg("Failed to obtain progress")
+	}
+	if err == nil && known {
+		status.Known = true
+		status.Latest = latest
+		status.Expected = expected
+		if expected > latest {
+			status.Lag = expected - latest
+		}
+		status.Fresh = status.Lag <= c.threshold
+		monitorFreshnessLag(c.name, status.Lag)
+	}
+
+	c.mu.Lock()
+	c.status = status
+	c.mu.Unlock()
+
+	return status.Fresh
+}
+
+// slotProgress returns the progress of a completion provider, in slots.
+func slotProgress(chainTime chaintime.Service, provider notifications.CompletionProvider) progressFunc {
+	return func(ctx context.Context) (uint64, uint64, bool, error) {
+		slot, complete, err := latestCompleteSlot(ctx, chainTime, provider)
+		if err != nil || !complete {
+			return 0, 0, false, err
+		}
+
+		return uint64(slot), uint64(chainTime.CurrentSlot()), true, nil
+	}
+}
+
+// startFreshness starts checking the freshness of ingesting services, as part of readiness.
+func startFreshness(_ context.Context, chainTime chaintime.Service) error {
+	if !viper.GetBool("freshness.enable") {
+		return nil
+	}
+
+	completionProvidersMu.Lock()
+	for name := range ingestionProviders {
+		registerProgress(name, slotProgress(chainTime, completionProviders[name]))
+	}
+	completionProvidersMu.Unlock()
+
+	progressFuncsMu.Lock()
+	checkers := make(map[string]*freshnessChecker, len(progressFuncs))
+	for name, progress := range progressFuncs {
+		threshold := viper.GetUint64("freshness.threshold")
+		if viper.IsSet(fmt.Sprintf("freshness.thresholds.%s", name)) {
+			threshold = viper.GetUint64(fmt.Sprintf("freshness.thresholds.%s", name))
+		}
+		checkers[name] = &freshnessChecker{
+			name:      name,
+			progress:  progress,
+			threshold: threshold,
+		}
+		registerReadinessChecker(fmt.Sprintf("freshness-%s", name), checkers[name])
+		log.Trace().Str("service", name).Uint64("threshold", threshold).Msg("Checking freshness")
+	}
+	progressFuncsMu.Unlock()
+
+	registerStatus("freshness", func(_ context.Context) any {
+		res := make(map[string]*freshnessStatus, len(checkers))
+		for name, checker := range checkers {
+			checker.mu.Lock()
+			res[name] = checker.status
+			checker.mu.Unlock()
+		}
+
+		return res
+	})
+
+	return nil
+}
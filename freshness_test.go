@@ -0,0 +1,220 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// freshnessChainTime is a chain time with a settable current slot.
+type freshnessChainTime struct {
+	completionChainTime
+	currentSlot phase0.Slot
+}
+
+func (c *freshnessChainTime) CurrentSlot() phase0.Slot {
+	return c.currentSlot
+}
+
+// resetProgress clears the registered progress functions for the duration of a test.
+func resetProgress(t *testing.T) {
+	t.Helper()
+
+	progressFuncsMu.Lock()
+	saved := progressFuncs
+	progressFuncs = make(map[string]progressFunc)
+	progressFuncsMu.Unlock()
+
+	t.Cleanup(func() {
+		progressFuncsMu.Lock()
+		progressFuncs = saved
+		progressFuncsMu.Unlock()
+	})
+}
+
+func TestFreshnessCheckerReady(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		latest    uint64
+		expected  uint64
+		known     bool
+		err       error
+		threshold uint64
+		status    *freshnessStatus
+	}{
+		{
+			name:      "Unknown",
+			threshold: 10,
+			status:    &freshnessStatus{Threshold: 10},
+		},
+		{
+			name:      "Error",
+			latest:    100,
+			expected:  100,
+			known:     true,
+			err:       errors.New("mock error"),
+			threshold: 10,
+			status:    &freshnessStatus{Threshold: 10},
+		},
+		{
+			name:      "UpToDate",
+			latest:    100,
+			expected:  100,
+			known:     true,
+			threshold: 10,
+			status:    &freshnessStatus{Known: true, Latest: 100, Expected: 100, Threshold: 10, Fresh: true},
+		},
+		{
+			name:      "Ahead",
+			latest:    101,
+			expected:  100,
+			known:     true,
+			threshold: 10,
+			status:    &freshnessStatus{Known: true, Latest: 101, Expected: 100, Threshold: 10, Fresh: true},
+		},
+		{
+			name:      "WithinThreshold",
+			latest:    95,
+			expected:  100,
+			known:     true,
+			threshold: 10,
+			status:    &freshnessStatus{Known: true, Latest: 95, Expected: 100, Lag: 5, Threshold: 10, Fresh: true},
+		},
+		{
+			name:      "AtThreshold",
+			latest:    90,
+			expected:  100,
+			known:     true,
+			threshold: 10,
+			status:    &freshnessStatus{Known: true, Latest: 90, Expected: 100, Lag: 10, Threshold: 10, Fresh: true},
+		},
+		{
+			name:      "BeyondThreshold",
+			latest:    89,
+			expected:  100,
+			known:     true,
+			threshold: 10,
+			status:    &freshnessStatus{Known: true, Latest: 89, Expected: 100, Lag: 11, Threshold: 10},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checker := &freshnessChecker{
+				name: "test",
+				progress: func(_ context.Context) (uint64, uint64, bool, error) {
+					return test.latest, test.expected, test.known, test.err
+				},
+				threshold: test.threshold,
+			}
+			require.Equal(t, test.status.Fresh, checker.Ready(ctx))
+			require.Equal(t, test.status, checker.status)
+		})
+	}
+}
+
+func TestStartFreshnessThresholds(t *testing.T) {
+	ctx := context.Background()
+	resetCompletion(t)
+	resetProgress(t)
+	resetReadinessCheckers(t)
+	t.Cleanup(viper.Reset)
+
+	chainTime := &freshnessChainTime{}
+	registerCompletionProvider("blocks", &slotCompletionProvider{}, true)
+	registerCompletionProvider("validators", &slotCompletionProvider{}, true)
+	// Non-ingesting services are not checked unless they register their progress.
+	registerCompletionProvider("summarizer", &epochCompletionProvider{}, false)
+	registerProgress("eth1deposits", func(_ context.Context) (uint64, uint64, bool, error) {
+		return 0, 0, false, nil
+	})
+
+	viper.Set("freshness.enable", true)
+	viper.Set("freshness.threshold", 10)
+	viper.Set("freshness.thresholds.validators", 50)
+	viper.Set("freshness.thresholds.eth1deposits", 5)
+	require.NoError(t, startFreshness(ctx, chainTime))
+
+	thresholds := make(map[string]uint64)
+	readinessCheckersMu.Lock()
+	for name, checker := range readinessCheckers {
+		thresholds[name] = checker.(*freshnessChecker).threshold
+	}
+	readinessCheckersMu.Unlock()
+	require.Equal(t, map[string]uint64{
+		"freshness-blocks":       10,
+		"freshness-validators":   50,
+		"freshness-eth1deposits": 5,
+	}, thresholds)
+}
+
+func TestStartFreshnessDisabled(t *testing.T) {
+	ctx := context.Background()
+	resetCompletion(t)
+	resetProgress(t)
+	resetReadinessCheckers(t)
+	t.Cleanup(viper.Reset)
+
+	registerCompletionProvider("blocks", &slotCompletionProvider{}, true)
+	require.NoError(t, startFreshness(ctx, &freshnessChainTime{}))
+
+	readinessCheckersMu.Lock()
+	defer readinessCheckersMu.Unlock()
+	require.Empty(t, readinessCheckers)
+}
+
+func TestFreshnessReadiness(t *testing.T) {
+	ctx := context.Background()
+	resetCompletion(t)
+	resetProgress(t)
+	resetReadinessCheckers(t)
+	t.Cleanup(viper.Reset)
+
+	chainTime := &freshnessChainTime{currentSlot: 1000}
+	blocks := &slotCompletionProvider{}
+	committees := &epochCompletionProvider{}
+	registerCompletionProvider("blocks", blocks, true)
+	registerCompletionProvider("beacon-committees", committees, true)
+
+	viper.Set("freshness.enable", true)
+	viper.Set("freshness.threshold", 32)
+	require.NoError(t, startFreshness(ctx, chainTime))
+
+	// Services that have yet to report are not ready.
+	require.False(t, ready(ctx))
+
+	// Both services within the threshold.
+	blocks.complete = true
+	blocks.slot = 1000
+	committees.complete = true
+	committees.epoch = 30 // Complete to slot 991.
+	require.True(t, ready(ctx))
+
+	// The chain moves on, leaving the committees behind.
+	chainTime.currentSlot = 1030
+	blocks.slot = 1030
+	require.False(t, ready(ctx))
+
+	// The committees catch up.
+	committees.epoch = 31 // Complete to slot 1023.
+	require.True(t, ready(ctx))
+}
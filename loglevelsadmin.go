@@ -0,0 +1,80 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// handleLogLevels returns the log levels of services with a GET request, and
+// changes them with a POST request containing a JSON object of service names
+// to levels, for example {"scheduler":"trace"}.
+// Changes take effect immediately, and last until chaind restarts.
+func handleLogLevels(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		requested := make(map[string]string)
+		if err := json.NewDecoder(r.Body).Decode(&requested); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		// Check all levels before changing any, so that a bad request changes nothing.
+		levels := make(map[string]zerolog.Level, len(requested))
+		current := util.ServiceLogLevels()
+		for service, input := range requested {
+			if _, exists := current[service]; !exists {
+				http.Error(w, fmt.Sprintf("unknown service %q", service), http.StatusBadRequest)
+				return
+			}
+			level, err := util.ParseLogLevel(input)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			levels[service] = level
+		}
+		for service, level := range levels {
+			if err := util.SetServiceLogLevel(service, level); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Info().Str("caller", adminCaller(r.Context())).Str("service", service).Str("level", logLevelName(level)).Msg("Changed log level on admin request")
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	levels := util.ServiceLogLevels()
+	res := make(map[string]string, len(levels))
+	for service, level := range levels {
+		res[service] = logLevelName(level)
+	}
+	writeAdminJSON(w, res)
+}
+
+// logLevelName returns the name of a log level as used in configuration.
+func logLevelName(level zerolog.Level) string {
+	if level == zerolog.Disabled {
+		return "none"
+	}
+
+	return level.String()
+}
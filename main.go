@@ -15,6 +15,7 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 
@@ -30,31 +31,46 @@ import (
 	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"github.com/wealdtech/chaind/handlers"
+	standardbackfill "github.com/wealdtech/chaind/services/backfill/standard"
 	standardbeaconcommittees "github.com/wealdtech/chaind/services/beaconcommittees/standard"
 	"github.com/wealdtech/chaind/services/blocks"
 	standardblocks "github.com/wealdtech/chaind/services/blocks/standard"
 	"github.com/wealdtech/chaind/services/chaindb"
 	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	standardchainstats "github.com/wealdtech/chaind/services/chainstats/standard"
 	"github.com/wealdtech/chaind/services/chaintime"
 	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	standardconsistency "github.com/wealdtech/chaind/services/consistency/standard"
 	getlogseth1deposits "github.com/wealdtech/chaind/services/eth1deposits/getlogs"
 	standardfinalizer "github.com/wealdtech/chaind/services/finalizer/standard"
+	"github.com/wealdtech/chaind/services/leader"
+	standardleader "github.com/wealdtech/chaind/services/leader/standard"
 	"github.com/wealdtech/chaind/services/metrics"
 	nullmetrics "github.com/wealdtech/chaind/services/metrics/null"
 	prometheusmetrics "github.com/wealdtech/chaind/services/metrics/prometheus"
+	"github.com/wealdtech/chaind/services/notifications"
+	standardnotifications "github.com/wealdtech/chaind/services/notifications/standard"
 	standardproposerduties "github.com/wealdtech/chaind/services/proposerduties/standard"
+	standardretention "github.com/wealdtech/chaind/services/retention/standard"
+	"github.com/wealdtech/chaind/services/scheduler"
 	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
 	standardspec "github.com/wealdtech/chaind/services/spec/standard"
 	"github.com/wealdtech/chaind/services/summarizer"
 	standardsummarizer "github.com/wealdtech/chaind/services/summarizer/standard"
 	standardsynccommittees "github.com/wealdtech/chaind/services/synccommittees/standard"
+	"github.com/wealdtech/chaind/services/validators/snapshot"
 	standardvalidators "github.com/wealdtech/chaind/services/validators/standard"
+	"github.com/wealdtech/chaind/services/watchdog"
+	standardwatchdog "github.com/wealdtech/chaind/services/watchdog/standard"
+	"github.com/wealdtech/chaind/services/watchlist"
+	standardwatchlist "github.com/wealdtech/chaind/services/watchlist/standard"
 	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
@@ -97,8 +113,37 @@ func main2() int {
 		return 1
 	}
 
+	if viper.GetBool("check") {
+		if !runPreflight(ctx) {
+			return 1
+		}
+		return 0
+	}
+
+	dependencies, absent, err := applySingleService(viper.GetViper())
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid single service")
+		return 1
+	}
+
 	logModules()
 	log.Info().Str("version", ReleaseVersion).Msg("Starting chaind")
+	if viper.GetString("single-service") != "" {
+		log.Info().Str("service", viper.GetString("single-service")).Strs("dependencies", dependencies).Msg("Running a single service")
+		for _, service := range absent {
+			log.Info().Str("service", service).Msg("Service intentionally absent in single-service mode")
+		}
+	}
+
+	warnings, err := validateConfig(viper.GetViper(), pflag.CommandLine)
+	for _, warning := range warnings {
+		log.Warn().Msg(warning)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid configuration")
+		return 1
+	}
+	logConfig(viper.GetViper(), pflag.CommandLine)
 
 	if err := initTracing(ctx, majordomo); err != nil {
 		log.Error().Err(err).Msg("Failed to initialise tracing")
@@ -107,6 +152,22 @@ func main2() int {
 
 	initProfiling()
 
+	if viper.GetBool("migrate-only") {
+		if err := migrateSchema(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to migrate database schema")
+			return 1
+		}
+		return 0
+	}
+
+	if viper.GetBool("chaindb.migrate-compact-committees") {
+		if err := migrateCompactCommittees(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to migrate beacon committees")
+			return 1
+		}
+		return 0
+	}
+
 	runtime.GOMAXPROCS(runtime.NumCPU() * 8)
 
 	log.Trace().Msg("Starting metrics service")
@@ -119,29 +180,68 @@ func main2() int {
 		log.Error().Err(err).Msg("Failed to register metrics")
 		return 1
 	}
+	clientsMonitor = monitor
 	setRelease(ctx, ReleaseVersion)
 	setReady(ctx, false)
 
-	if err := startServices(ctx, monitor); err != nil {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+
+	// The admin server is started before the services so that a standby instance
+	// can report its status.
+	if err := startAdmin(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to start admin server")
+		return 1
+	}
+
+	var leaderSvc leader.Service
+	var services *shutdownServices
+	if viper.GetBool("read-only") {
+		services, err = startReadOnlyServices(ctx, monitor)
+	} else {
+		leaderSvc, err = startLeader(ctx, monitor)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to start leader election")
+			return 1
+		}
+		if leaderSvc != nil {
+			select {
+			case <-leaderSvc.Acquired():
+			case <-sigCh:
+				log.Info().Msg("Stopping chaind")
+				return 0
+			}
+		}
+		services, err = startServices(ctx, monitor, leaderSvc)
+	}
+	if err != nil {
 		log.Error().Err(err).Msg("Failed to initialise services")
 		return 1
 	}
-	setReady(ctx, true)
+	setReady(ctx, ready(ctx))
+	go monitorReadiness(ctx, 12*time.Second)
 
 	log.Info().Msg("All services operational")
 
-	// Wait for signal.
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
-	for {
-		sig := <-sigCh
-		if sig == syscall.SIGINT || sig == syscall.SIGTERM || sig == os.Interrupt || sig == os.Kill {
-			break
-		}
+	exitCode := 0
+	gracePeriod := viper.GetDuration("shutdown.grace-period")
+	select {
+	case <-sigCh:
+		log.Info().Msg("Stopping chaind")
+	case <-leadershipLost(leaderSvc):
+		// Another instance may now be writing, so running jobs are not drained.
+		log.Error().Msg("Leadership lost; stopping chaind")
+		gracePeriod = 0
+		exitCode = 1
 	}
-
-	log.Info().Msg("Stopping chaind")
-	return 0
+	go func() {
+		<-sigCh
+		log.Warn().Msg("Second signal received; exiting immediately")
+		os.Exit(1)
+	}()
+	shutdown(ctx, cancel, services, gracePeriod)
+
+	return exitCode
 }
 
 // fetchConfig fetches configuration from various sources.
@@ -152,28 +252,141 @@ func fetchConfig() error {
 	pflag.String("log-file", "", "redirect log output to a file")
 	pflag.String("profile-address", "", "Address on which to run Go profile server")
 	pflag.String("tracing-address", "", "Address to which to send tracing data")
+	pflag.Bool("read-only", false, "Serve queries from an existing database without writing to it")
+	pflag.Bool("migrate-only", false, "Upgrade the database schema, then exit")
+	pflag.String("single-service", "", "Run only the named service and those on which it relies, for debugging")
+	pflag.Bool("check", false, "Check configuration and connectivity to the beacon node, Ethereum 1 client and database, then exit")
 	pflag.String("eth2client.address", "", "Address for beacon node")
 	pflag.Duration("eth2client.timeout", 2*time.Minute, "Timeout for beacon node requests")
+	pflag.String("eth2client.encoding", "auto", "Encoding to request for blocks and states from the beacon node (auto, ssz or json)")
+	pflag.String("eth2client.genesis-validators-root", "", "Expected genesis validators root of the beacon node's network, confirmed by --check (defaults to that of the database)")
+	pflag.Float64("eth2client.rate-limit.budget", 0, "Total weight of requests per second to the beacon node (0 for no limit)")
+	pflag.Uint64("eth2client.rate-limit.head-distance", 64, "Number of slots behind the head within which requests to the beacon node have priority")
+	pflag.Float64("eth2client.rate-limit.weights.light", 1, "Weight of requests to the beacon node for headers, finality and sync state")
+	pflag.Float64("eth2client.rate-limit.weights.block", 1, "Weight of requests to the beacon node for blocks")
+	pflag.Float64("eth2client.rate-limit.weights.duties", 2, "Weight of requests to the beacon node for proposer duties")
+	pflag.Float64("eth2client.rate-limit.weights.committees", 4, "Weight of requests to the beacon node for beacon and sync committees")
+	pflag.Float64("eth2client.rate-limit.weights.validators", 16, "Weight of requests to the beacon node for validators and balances")
+	pflag.Float64("eth2client.rate-limit.weights.state", 32, "Weight of requests to the beacon node for beacon states")
+	pflag.String("ingestion.start", "", "Point from which to start ingesting data on an empty database (slot:N, epoch:N or a duration such as P30D)")
+	pflag.Bool("ingestion.allow-backfill", false, "Allow ingestion to start before the recorded origin")
 	pflag.Bool("blocks.enable", true, "Enable fetching of block-related information")
 	pflag.Int32("blocks.start-slot", -1, "Slot from which to start fetching blocks")
 	pflag.Bool("blocks.refetch", false, "Refetch all blocks even if they are already in the database")
+	pflag.Bool("blocks.store.attestations", true, "Store the attestations in blocks")
+	pflag.Bool("blocks.store.deposits", true, "Store the deposits in blocks")
+	pflag.Bool("blocks.store.exits", true, "Store the voluntary exits in blocks")
+	pflag.Bool("blocks.store.slashings", true, "Store the proposer and attester slashings in blocks")
+	pflag.Bool("blocks.store.sync-aggregates", true, "Store the sync aggregates in blocks")
+	pflag.Bool("blocks.store.withdrawals", true, "Store the withdrawals in blocks")
+	pflag.Bool("backfill.enable", false, "Enable backfilling of blocks from the ingestion origin towards genesis")
+	pflag.Int64("backfill.target-slot", 0, "Slot to which to backfill blocks")
+	pflag.Uint64("backfill.batch-epochs", 1, "Number of epochs to backfill in each batch")
+	pflag.Float64("backfill.rate", 8, "Maximum backfill rate in slots per second (0 for unlimited)")
+	pflag.Bool("backfill.paused", false, "Start the backfill paused")
+	pflag.String("admin.listen-address", "", "Address on which to run the admin server")
+	pflag.Bool("scheduler.lock-metrics", false, "Record time spent waiting for and holding the scheduler's jobs lock (diagnostic)")
+	pflag.Float64("scheduler.schedule-rate-limit", 0, "Maximum rate at which jobs are scheduled, in jobs per second (0 for unlimited)")
+	pflag.String("scheduler.expvar", "", "Name under which to publish job counts over expvar (empty to disable)")
+	pflag.String("scheduler.history-file", "", "File in which to persist job run history across restarts (empty to disable)")
+	pflag.Bool("watchdog.enable", false, "Enable recovery of ingesting services that stop making progress")
+	pflag.Duration("watchdog.interval", time.Minute, "Interval between checks of the progress of ingesting services")
+	pflag.Duration("shutdown.grace-period", 30*time.Second, "Time to wait for running jobs to finish when shutting down")
+	pflag.Bool("leader.enable", false, "Enable leader election, so that only one of a number of instances writes to the database")
+	pflag.String("leader.instance", "", "Name of this instance for leader election (defaults to hostname and process ID)")
+	pflag.Int64("leader.lock-id", standardleader.DefaultLockID, "ID of the database advisory lock used for leader election")
+	pflag.Duration("leader.poll-interval", 5*time.Second, "Interval at which a standby instance attempts to obtain leadership")
+	pflag.Duration("leader.heartbeat-interval", 5*time.Second, "Interval at which the leader confirms its leadership")
+	pflag.Duration("leader.heartbeat-timeout", 15*time.Second, "Time after which a leader that cannot confirm its leadership stops")
 	pflag.Bool("finalizer.enable", true, "Enable additional information on receipt of finality checkpoint")
+	pflag.Uint64("finalizer.max-lag", 0, "Number of epochs the finalizer can trail the chain's finalized epoch and remain ready (0 to ignore)")
 	pflag.Bool("summarizer.enable", true, "Enable summary information")
+	pflag.Bool("chainstats.enable", false, "Enable export of chain statistics as metrics (queries the database on scrape)")
+	pflag.Duration("chainstats.cache-duration", time.Minute, "Time for which chain statistics are cached between scrapes")
+	pflag.Bool("notifications.enable", false, "Enable webhook notifications when epochs are finalized")
+	pflag.Duration("notifications.timeout", 10*time.Second, "Timeout for each webhook notification attempt")
 	pflag.Bool("summarizer.epochs.enable", true, "Enable summary information for epochs")
 	pflag.Bool("summarizer.blocks.enable", true, "Enable summary information for blocks")
 	pflag.Bool("summarizer.validators.enable", false, "Enable summary information for validators (warning: creates a lot of data)")
 	pflag.Uint64("summarizer.max-days-per-run", 28, "Maximum number of days' of data to summarize in a single run (when pruning)")
+	pflag.Duration("summarizer.epochs.consistency-check-interval", time.Hour, "Interval between consistency checks of a random past epoch summary (0 to disable)")
+	pflag.Duration("summarizer.resummarize-interval", time.Second, "Interval between resummarizing each epoch or day when resummarizing")
+	pflag.Bool("summarizer.resummarize-outdated", false, "Resummarize summaries produced by earlier versions of the summarizer on startup")
+	pflag.Bool("freshness.enable", false, "Include the freshness of ingesting services in readiness")
+	pflag.Uint64("freshness.threshold", 128, "Default distance in slots (or blocks for Ethereum 1 deposits) a service can fall behind the chain and remain ready")
+	pflag.Bool("retention.enable", false, "Enable pruning of data according to retention policies")
+	pflag.Bool("retention.dry-run", false, "Report the data that retention policies would prune, without pruning it")
+	pflag.Duration("retention.interval", time.Hour, "Interval between runs of each retention policy")
+	pflag.Int("retention.batch-size", 1000, "Maximum number of rows to prune in each transaction")
+	pflag.Duration("retention.batch-interval", 100*time.Millisecond, "Interval between batches of pruned rows")
+	pflag.Bool("consistency.enable", false, "Enable continuous background checks of the consistency of the database")
+	pflag.StringSlice("consistency.checks", []string{
+		string(chaindb.ConsistencyCheckBlockParents),
+		string(chaindb.ConsistencyCheckBlockAttestations),
+		string(chaindb.ConsistencyCheckValidatorBalances),
+		string(chaindb.ConsistencyCheckDepositIndices),
+	}, "Consistency checks to run")
+	pflag.Duration("consistency.interval", 5*time.Minute, "Interval between runs of consistency checks")
+	pflag.Int("consistency.samples", 1, "Number of epochs sampled in each run of consistency checks")
+	pflag.Uint64("consistency.window", 1024, "Number of recent finalized epochs from which consistency checks sample")
+	pflag.Duration("consistency.check-interval", time.Second, "Interval between consistency checks within a run")
+	pflag.Int("consistency.max-active-queries", 16, "Number of active database queries above which consistency checks are deferred (0 for no limit)")
 	pflag.Bool("validators.enable", true, "Enable fetching of validator-related information")
 	pflag.Bool("validators.balances.enable", false, "Enable fetching of validator balances (warning: creates a lot of data)")
+	pflag.Uint64("validators.balances.batch-size", 1000, "Number of validators for which to fetch balances in each request (0 for a single request)")
+	pflag.Int("validators.balances.concurrency", 8, "Maximum number of concurrent requests when fetching validator balances")
+	pflag.Int("validators.balances.page-retries", 3, "Number of times to retry a failed request when fetching validator balances")
+	pflag.String("watchlist.file", "", "File containing the validators for which to store per-validator data (all validators if not supplied)")
+	pflag.Duration("watchlist.reload-interval", time.Minute, "Interval between reloads of the watchlist file")
 	pflag.Bool("beacon-committees.enable", true, "Enable fetching of beacon committee-related information")
 	pflag.Bool("proposer-duties.enable", true, "Enable fetching of proposer duty-related information")
 	pflag.Bool("sync-committees.enable", true, "Enable fetching of sync committee-related information")
 	pflag.Int32("sync-committees.start-period", -1, "Period from which to start fetching sync committees")
 	pflag.Bool("eth1deposits.enable", false, "Enable fetching of Ethereum 1 deposit information")
 	pflag.String("eth1deposits.start-block", "", "Ethereum 1 block from which to start fetching deposits")
+	pflag.String("eth1deposits.log-dump-file", "", "File of logs, written by export-deposit-logs, to serve in place of the Ethereum 1 client (for development and testing)")
+	pflag.Int("eth1deposits.log-cache-size", 0, "Number of Ethereum 1 log responses to cache (0 to disable)")
+	pflag.Duration("eth1deposits.log-cache-ttl", time.Hour, "Time for which Ethereum 1 log responses are cached")
+	pflag.Duration("eth1deposits.min-poll-interval", 12*time.Second, "Minimum interval between polls for new Ethereum 1 blocks")
+	pflag.Duration("eth1deposits.max-poll-interval", 2*time.Minute, "Maximum interval between polls for new Ethereum 1 blocks")
+	pflag.String("eth1deposits.idempotency-header", "", "Header carrying a key for each request to the Ethereum 1 client, stable across retries")
+	pflag.Int("eth1deposits.request-retries", 0, "Number of times to retry a failed request to the Ethereum 1 client")
+	pflag.Float64("eth1deposits.global-rate-limit", 0, "Maximum number of requests per second to the Ethereum 1 client, across all activity (0 for no limit)")
+	pflag.Uint64("eth1deposits.log-ranges-per-batch", 1, "Number of block ranges for which logs are requested in a single batch request when catching up (1 to disable batching)")
+	pflag.Bool("eth1deposits.verify-signatures", false, "Verify the signatures of Ethereum 1 deposits")
+	pflag.Int("eth1deposits.decode-concurrency", 1, "Number of workers that decode Ethereum 1 deposits")
+	pflag.String("eth1deposits.deposit-contract-code-hash", "", "Expected hex hash of the deposit contract code, if not known to chaind")
+	pflag.Bool("eth1deposits.skip-deposit-contract-code-check", false, "Skip the check of the deposit contract code at startup")
+	pflag.String("eth1deposits.deposit-contract-version", "", "Version of the deposit contract (\"current\" or \"legacy\"; detected from each log if not supplied)")
+	pflag.StringSlice("eth1deposits.allowed-log-addresses", nil, "Contract addresses from which Ethereum 1 logs are accepted (defaults to the deposit contract)")
+	pflag.String("eth1deposits.discovery.srv", "", "DNS SRV name used to discover a replacement Ethereum 1 client when requests fail persistently")
+	pflag.Int("eth1deposits.discovery.failure-threshold", 5, "Number of consecutive failed requests to the Ethereum 1 client that trigger discovery")
+	pflag.Duration("eth1deposits.discovery.cooldown", 5*time.Minute, "Minimum time between discoveries of Ethereum 1 clients")
+	pflag.Uint64("eth1deposits.anomalies.minimum-amount", 1000000000, "Amount in Gwei below which an Ethereum 1 deposit is anomalous (0 to disable)")
+	pflag.Uint64("eth1deposits.anomalies.maximum-amount", 0, "Amount in Gwei above which an Ethereum 1 deposit is anomalous (0 to disable)")
+	pflag.Uint64("eth1deposits.anomalies.amount-granularity", 0, "Amount in Gwei of which an Ethereum 1 deposit must be a multiple to not be anomalous (0 to disable)")
+	pflag.Duration("eth1deposits.reconcile-interval", time.Hour, "Interval between reconciliations of deposits with the beacon chain (0 to disable)")
 	pflag.String("eth1client.address", "", "Address for Ethereum 1 node")
 	pflag.String("chaindb.url", "", "URL for database")
 	pflag.Uint("chaindb.max-connections", 16, "maximum number of concurrent database connections")
+	pflag.Duration("chaindb.statement-timeout", 0, "Maximum time for which a database statement can run (0 for unlimited)")
+	pflag.Duration("chaindb.long-running-statement-timeout", 0, "Maximum time for which a database statement can run in known long-running operations (0 for unlimited)")
+	pflag.Bool("chaindb.compact-committees", false, "Store beacon committees in compact form")
+	pflag.Bool("chaindb.migrate-compact-committees", false, "Rewrite existing beacon committees in compact form, then exit")
+	pflag.Int("chaindb.migrate-batch-size", 1000, "Number of beacon committees to rewrite in each transaction when migrating")
+	pflag.Bool("chaindb.maintenance.enable", false, "Enable scheduled database maintenance")
+	pflag.StringSlice("chaindb.maintenance.statements", []string{"ANALYZE"}, "Statements to run for scheduled database maintenance")
+	pflag.Duration("chaindb.maintenance.interval", 24*time.Hour, "Interval between scheduled database maintenance runs")
+	pflag.String("chaindb.maintenance.window-start", "00:00", "Start of the daily window (UTC) for scheduled database maintenance")
+	pflag.String("chaindb.maintenance.window-end", "00:00", "End of the daily window (UTC) for scheduled database maintenance")
+	pflag.Duration("chaindb.maintenance.max-replication-lag", 0, "Replication lag above which scheduled database maintenance is skipped (0 to disable)")
+	pflag.Int("chaindb.maintenance.max-active-queries", 0, "Number of active queries above which scheduled database maintenance is skipped (0 to disable)")
+	pflag.String("chaindb.column-compression", "none", "Compression for large byte columns (none, deflate or snappy)")
+	pflag.String("chaindb.attestation-storage", "full", "Storage mode for attestations (full or aggregate); fixed when the database is created")
+	pflag.Uint64("chaindb.compression-migration.slots-per-batch", 0, "Number of slots of existing data to compress in each batch (0 to disable)")
+	pflag.Duration("chaindb.compression-migration.interval", time.Second, "Interval between batches when compressing existing data")
+	pflag.Uint64("chaindb.attestation-votes-migration.slots-per-batch", 0, "Number of slots of existing attestations to backfill votes for in each batch (0 to disable)")
+	pflag.Duration("chaindb.attestation-votes-migration.interval", time.Second, "Interval between batches when backfilling attestation votes")
 	pflag.Parse()
 	if err := viper.BindPFlags(pflag.CommandLine); err != nil {
 		return errors.Wrap(err, "failed to bind pflags to viper")
@@ -193,9 +406,10 @@ func fetchConfig() error {
 		viper.SetConfigName(".chaind")
 	}
 
-	// Environment settings.
-	viper.SetEnvPrefix("CHAIND")
-	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
+	// Environment settings.  These override values in the configuration file,
+	// and are themselves overridden by command-line flags.
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(envKeyReplacer)
 	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err != nil {
@@ -216,6 +430,10 @@ func fetchConfig() error {
 		}
 	}
 
+	if err := decodeStructuredEnv(viper.GetViper(), pflag.CommandLine); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -256,30 +474,111 @@ func startMonitor(ctx context.Context) (metrics.Service, error) {
 	return monitor, nil
 }
 
-func startDatabase(ctx context.Context) (chaindb.Service, error) {
+// startDatabase starts the chain database service, with any additional parameters supplied.
+func startDatabase(ctx context.Context, extraParams ...postgresqlchaindb.Parameter) (chaindb.Service, error) {
 	log.Trace().Msg("Starting chain database service")
-	chainDB, err := postgresqlchaindb.New(ctx,
+	columnCompression, err := util.ParseCompressionFormat(viper.GetString("chaindb.column-compression"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid column compression")
+	}
+	params := []postgresqlchaindb.Parameter{
 		postgresqlchaindb.WithLogLevel(util.LogLevel("chaindb")),
 		postgresqlchaindb.WithConnectionURL(viper.GetString("chaindb.url")),
 		postgresqlchaindb.WithMaxConnections(viper.GetUint("chaindb.max-connections")),
-	)
+		postgresqlchaindb.WithStatementTimeout(viper.GetDuration("chaindb.statement-timeout")),
+		postgresqlchaindb.WithLongRunningStatementTimeout(viper.GetDuration("chaindb.long-running-statement-timeout")),
+		postgresqlchaindb.WithCompactCommittees(viper.GetBool("chaindb.compact-committees")),
+		postgresqlchaindb.WithColumnCompression(columnCompression),
+		postgresqlchaindb.WithAttestationStorageMode(chaindb.AttestationStorageMode(viper.GetString("chaindb.attestation-storage"))),
+		postgresqlchaindb.WithRelease(ReleaseVersion),
+	}
+	params = append(params, extraParams...)
+	chainDB, err := postgresqlchaindb.New(ctx, params...)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to start chain database service")
 	}
 	return chainDB, err
 }
 
-func startServices(ctx context.Context, monitor metrics.Service) error {
+// maintenanceStatements returns the statements for scheduled database maintenance,
+// or nothing if maintenance is disabled.
+func maintenanceStatements() []string {
+	if !viper.GetBool("chaindb.maintenance.enable") {
+		return nil
+	}
+
+	return viper.GetStringSlice("chaindb.maintenance.statements")
+}
+
+// schedulerClassWarnThresholds returns the configured warn thresholds for scheduler job classes.
+func schedulerClassWarnThresholds() map[string]int {
+	thresholds := make(map[string]int)
+	for class := range viper.GetStringMap("scheduler.class-warn-thresholds") {
+		thresholds[class] = viper.GetInt(fmt.Sprintf("scheduler.class-warn-thresholds.%s", class))
+	}
+
+	return thresholds
+}
+
+func startServices(ctx context.Context, monitor metrics.Service, leaderSvc leader.Service) (*shutdownServices, error) {
+	log.Trace().Msg("Starting scheduler")
+	schedulerParams := []standardscheduler.Parameter{
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+		standardscheduler.WithMonitor(monitor),
+		standardscheduler.WithLockMetrics(viper.GetBool("scheduler.lock-metrics")),
+		standardscheduler.WithScheduleRateLimit(viper.GetFloat64("scheduler.schedule-rate-limit")),
+		standardscheduler.WithExpvar(viper.GetString("scheduler.expvar")),
+		standardscheduler.WithClassWarnThreshold(schedulerClassWarnThresholds()),
+	}
+	if viper.GetString("scheduler.history-file") != "" {
+		historyStore, err := standardscheduler.NewFileHistoryStore(viper.GetString("scheduler.history-file"), 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create scheduler history store")
+		}
+		schedulerParams = append(schedulerParams, standardscheduler.WithHistoryStore(historyStore))
+	}
+	if leaderSvc != nil {
+		schedulerParams = append(schedulerParams, standardscheduler.WithLeaderCheck(func(ctx context.Context) (bool, error) {
+			return leaderSvc.IsLeader(ctx), nil
+		}))
+	}
+	schedulerSvc, err := standardscheduler.New(ctx, schedulerParams...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialise scheduler")
+	}
+	registerSchedulerAdmin(schedulerSvc)
+
+	log.Trace().Msg("Starting watchdog service")
+	watchdogSvc, err := startWatchdog(ctx, schedulerSvc, monitor)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start watchdog service")
+	}
+
 	log.Trace().Msg("Checking for schema upgrades")
-	chainDB, err := startDatabase(ctx)
+	// The database is not tied to the root context, so that jobs draining on shutdown
+	// can continue to use it.  It is closed explicitly once they have finished.
+	//nolint:contextcheck
+	chainDB, err := startDatabase(context.Background(),
+		postgresqlchaindb.WithMonitor(monitor),
+		postgresqlchaindb.WithScheduler(schedulerSvc),
+		postgresqlchaindb.WithMaintenanceStatements(maintenanceStatements()),
+		postgresqlchaindb.WithMaintenanceInterval(viper.GetDuration("chaindb.maintenance.interval")),
+		postgresqlchaindb.WithMaintenanceWindow(viper.GetString("chaindb.maintenance.window-start"), viper.GetString("chaindb.maintenance.window-end")),
+		postgresqlchaindb.WithMaintenanceMaxReplicationLag(viper.GetDuration("chaindb.maintenance.max-replication-lag")),
+		postgresqlchaindb.WithMaintenanceMaxActiveQueries(viper.GetInt("chaindb.maintenance.max-active-queries")),
+		postgresqlchaindb.WithCompressionMigrationSlotsPerBatch(viper.GetUint64("chaindb.compression-migration.slots-per-batch")),
+		postgresqlchaindb.WithCompressionMigrationInterval(viper.GetDuration("chaindb.compression-migration.interval")),
+		postgresqlchaindb.WithAttestationVotesMigrationSlotsPerBatch(viper.GetUint64("chaindb.attestation-votes-migration.slots-per-batch")),
+		postgresqlchaindb.WithAttestationVotesMigrationInterval(viper.GetDuration("chaindb.attestation-votes-migration.interval")),
+	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if _, isUpgrader := chainDB.(*postgresqlchaindb.Service); isUpgrader {
 		requiresRefetch, err := chainDB.(*postgresqlchaindb.Service).Upgrade(ctx)
 		if err != nil {
-			return errors.Wrap(err, "failed to upgrade chain database")
+			return nil, errors.Wrap(err, "failed to upgrade chain database")
 		}
 		if requiresRefetch {
 			// The upgrade requires us to refetch blocks, so set up the options accordingly.
@@ -292,10 +591,10 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 	log.Trace().Msg("Starting Ethereum 2 client service")
 	eth2Client, err := fetchClient(ctx, viper.GetString("eth2client.address"))
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("eth2client.address")))
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("eth2client.address")))
 	}
 	if err != nil {
-		return errors.Wrap(err, "failed to start Ethereum 2 client service")
+		return nil, errors.Wrap(err, "failed to start Ethereum 2 client service")
 	}
 
 	log.Trace().Msg("Starting chain time service")
@@ -306,7 +605,7 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 		standardchaintime.WithForkScheduleProvider(eth2Client.(eth2client.ForkScheduleProvider)),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to start chain time service")
+		return nil, errors.Wrap(err, "failed to start chain time service")
 	}
 
 	// Wait for chainstart.
@@ -316,7 +615,7 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 		// See if we can obtain spec before the chain starts.  Not all beacon nodes support this,
 		// so don't worry if it fails but do note it so that the service can be started later.
 		log.Trace().Msg("Starting spec service (speculative pre-chain)")
-		if err := startSpec(ctx, eth2Client, chainDB, monitor); err == nil {
+		if err := startSpec(ctx, eth2Client, chainDB, schedulerSvc); err == nil {
 			specServiceStarted = true
 		}
 
@@ -330,32 +629,48 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 	// chaindb so it is accessible to other services.
 	if !specServiceStarted {
 		log.Trace().Msg("Starting spec service")
-		if err := startSpec(ctx, eth2Client, chainDB, monitor); err != nil {
-			return errors.Wrap(err, "failed to start spec service")
+		if err := startSpec(ctx, eth2Client, chainDB, schedulerSvc); err != nil {
+			return nil, errors.Wrap(err, "failed to start spec service")
 		}
 	}
 
+	// The ingestion origin must be known before any services that ingest data start.
+	if err := resolveOrigin(ctx, chainDB, chainTime); err != nil {
+		return nil, errors.Wrap(err, "failed to resolve ingestion origin")
+	}
+
+	// The watchlist is needed by the services that store per-validator data.
+	log.Trace().Msg("Starting watchlist service")
+	watchlistSvc, err := startWatchlist(ctx, chainDB, schedulerSvc, monitor)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start watchlist service")
+	}
+
 	// Sync committees service is needed by blocks service.
 	log.Trace().Msg("Starting sync committees service")
 	if err := startSyncCommittees(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
-		return errors.Wrap(err, "failed to start sync committees service")
+		return nil, errors.Wrap(err, "failed to start sync committees service")
 	}
 
 	// Shared activity semaphore for blocks and finalizer, to avoid potential deadlock.
 	activitySem := semaphore.NewWeighted(1)
 
 	log.Trace().Msg("Starting blocks service")
-	blocks, err := startBlocks(ctx, eth2Client, chainDB, chainTime, monitor, activitySem)
+	blocks, err := startBlocks(ctx, eth2Client, chainDB, chainTime, monitor, activitySem, watchdogSvc)
 	if err != nil {
-		return errors.Wrap(err, "failed to start blocks service")
+		return nil, errors.Wrap(err, "failed to start blocks service")
+	}
+	if checker, isChecker := blocks.(readinessChecker); isChecker {
+		registerReadinessChecker("blocks", checker)
 	}
+	registerCompletionProvider("blocks", blocks, true)
 
 	var summarizerSvc summarizer.Service
 	if blocks != nil {
 		log.Trace().Msg("Starting summarizer service")
-		summarizerSvc, err = startSummarizer(ctx, eth2Client, chainDB, chainTime, monitor)
+		summarizerSvc, err = startSummarizer(ctx, eth2Client, chainDB, chainTime, schedulerSvc, monitor, watchlistSvc)
 		if err != nil {
-			return errors.Wrap(err, "failed to start summarizer service")
+			return nil, errors.Wrap(err, "failed to start summarizer service")
 		}
 	}
 
@@ -364,31 +679,72 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 	if summarizerSvc != nil {
 		finalityHandlers = append(finalityHandlers, summarizerSvc.(handlers.FinalityHandler))
 	}
-	if err := startFinalizer(ctx, eth2Client, chainDB, chainTime, blocks, monitor, finalityHandlers, activitySem); err != nil {
-		return errors.Wrap(err, "failed to start finalizer service")
+	log.Trace().Msg("Starting notifications service")
+	notificationsSvc, err := startNotifications(ctx, chainDB, chainTime, monitor)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start notifications service")
+	}
+	if notificationsSvc != nil {
+		finalityHandlers = append(finalityHandlers, notificationsSvc.(handlers.FinalityHandler))
+	}
+	if err := startFinalizer(ctx, eth2Client, chainDB, chainTime, blocks, monitor, finalityHandlers, activitySem, watchdogSvc); err != nil {
+		return nil, errors.Wrap(err, "failed to start finalizer service")
+	}
+
+	log.Trace().Msg("Starting backfill service")
+	if err := startBackfill(ctx, eth2Client, chainDB, chainTime, blocks, monitor, activitySem); err != nil {
+		return nil, errors.Wrap(err, "failed to start backfill service")
 	}
 
 	log.Trace().Msg("Starting validators service")
-	if err := startValidators(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
-		return errors.Wrap(err, "failed to start validators service")
+	if err := startValidators(ctx, eth2Client, chainDB, chainTime, monitor, watchlistSvc); err != nil {
+		return nil, errors.Wrap(err, "failed to start validators service")
 	}
 
 	log.Trace().Msg("Starting beacon committees service")
 	if err := startBeaconCommittees(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
-		return errors.Wrap(err, "failed to start beacon committees service")
+		return nil, errors.Wrap(err, "failed to start beacon committees service")
 	}
 
 	log.Trace().Msg("Starting proposer duties service")
 	if err := startProposerDuties(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
-		return errors.Wrap(err, "failed to start proposer duties service")
+		return nil, errors.Wrap(err, "failed to start proposer duties service")
 	}
 
 	log.Trace().Msg("Starting Ethereum 1 deposits service")
-	if err := startETH1Deposits(ctx, chainDB, monitor); err != nil {
-		return errors.Wrap(err, "failed to start Ethereum 1 deposits service")
+	if err := startETH1Deposits(ctx, eth2Client, chainDB, chainTime, monitor, watchdogSvc); err != nil {
+		return nil, errors.Wrap(err, "failed to start Ethereum 1 deposits service")
 	}
 
-	return nil
+	log.Trace().Msg("Starting chain statistics service")
+	if err := startChainStats(ctx, chainDB, monitor); err != nil {
+		return nil, errors.Wrap(err, "failed to start chain statistics service")
+	}
+
+	log.Trace().Msg("Starting completion tracking")
+	if err := startCompletion(ctx, chainDB, chainTime, schedulerSvc); err != nil {
+		return nil, errors.Wrap(err, "failed to start completion tracking")
+	}
+
+	log.Trace().Msg("Starting freshness checks")
+	if err := startFreshness(ctx, chainTime); err != nil {
+		return nil, errors.Wrap(err, "failed to start freshness checks")
+	}
+
+	log.Trace().Msg("Starting retention service")
+	if err := startRetention(ctx, chainDB, chainTime, schedulerSvc, monitor); err != nil {
+		return nil, errors.Wrap(err, "failed to start retention service")
+	}
+
+	log.Trace().Msg("Starting consistency service")
+	if err := startConsistency(ctx, chainDB, chainTime, schedulerSvc, monitor); err != nil {
+		return nil, errors.Wrap(err, "failed to start consistency service")
+	}
+
+	return &shutdownServices{
+		scheduler: schedulerSvc,
+		chainDB:   chainDB,
+	}, nil
 }
 
 func waitForNodeSync(ctx context.Context, eth2Client eth2client.Service) {
@@ -445,11 +801,69 @@ func resolvePath(path string) string {
 	return filepath.Join(baseDir, path)
 }
 
+// startLeader starts leader election, if enabled.
+// It returns nil if leader election is not enabled.
+func startLeader(ctx context.Context, monitor metrics.Service) (leader.Service, error) {
+	if !viper.GetBool("leader.enable") {
+		return nil, nil
+	}
+
+	log.Trace().Msg("Starting leader election")
+	leaderSvc, err := standardleader.New(ctx,
+		standardleader.WithLogLevel(util.LogLevel("leader")),
+		standardleader.WithMonitor(monitor),
+		standardleader.WithConnectionURL(viper.GetString("chaindb.url")),
+		standardleader.WithLockID(viper.GetInt64("leader.lock-id")),
+		standardleader.WithInstance(viper.GetString("leader.instance")),
+		standardleader.WithPollInterval(viper.GetDuration("leader.poll-interval")),
+		standardleader.WithHeartbeatInterval(viper.GetDuration("leader.heartbeat-interval")),
+		standardleader.WithHeartbeatTimeout(viper.GetDuration("leader.heartbeat-timeout")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create leader election service")
+	}
+
+	registerStatus("leader", func(ctx context.Context) any {
+		state := leaderSvc.State(ctx)
+		res := &leaderStatus{
+			Instance: state.Instance,
+			Leader:   state.Leader,
+			Lost:     state.Lost,
+		}
+		if !state.Acquired.IsZero() {
+			res.Acquired = state.Acquired.Format(time.RFC3339)
+			res.LastHeartbeat = state.LastHeartbeat.Format(time.RFC3339)
+		}
+		return res
+	})
+
+	return leaderSvc, nil
+}
+
+// leaderStatus is the status of leader election.
+type leaderStatus struct {
+	Instance      string `json:"instance"`
+	Leader        bool   `json:"leader"`
+	Acquired      string `json:"acquired,omitempty"`
+	LastHeartbeat string `json:"last_heartbeat,omitempty"`
+	Lost          bool   `json:"lost,omitempty"`
+}
+
+// leadershipLost returns a channel that is closed when leadership is lost, or
+// nil if leader election is not enabled.
+func leadershipLost(leaderSvc leader.Service) <-chan struct{} {
+	if leaderSvc == nil {
+		return nil
+	}
+
+	return leaderSvc.Lost()
+}
+
 func startSpec(
 	ctx context.Context,
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
-	monitor metrics.Service,
+	scheduler scheduler.Service,
 ) error {
 	var err error
 	if viper.GetString("spec.address") != "" {
@@ -459,13 +873,6 @@ func startSpec(
 		}
 	}
 
-	scheduler, err := standardscheduler.New(ctx,
-		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
-		standardscheduler.WithMonitor(monitor))
-	if err != nil {
-		return errors.Wrap(err, "failed to initialise scheduler")
-	}
-
 	_, err = standardspec.New(ctx,
 		standardspec.WithLogLevel(util.LogLevel("spec")),
 		standardspec.WithETH2Client(eth2Client),
@@ -486,6 +893,7 @@ func startBlocks(
 	chainTime chaintime.Service,
 	monitor metrics.Service,
 	activitySem *semaphore.Weighted,
+	watchdog watchdog.Service,
 ) (
 	blocks.Service,
 	error,
@@ -495,22 +903,31 @@ func startBlocks(
 	}
 
 	var err error
+	address := viper.GetString("eth2client.address")
 	if viper.GetString("blocks.address") != "" {
-		eth2Client, err = fetchClient(ctx, viper.GetString("blocks.address"))
+		address = viper.GetString("blocks.address")
+		eth2Client, err = fetchClient(ctx, address)
 		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("blocks.address")))
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", address))
 		}
 	}
+	beaconFetcher, err := fetchBeaconFetcher(ctx, address, chainTime)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch beacon fetcher %q", address))
+	}
 
 	s, err := standardblocks.New(ctx,
 		standardblocks.WithLogLevel(util.LogLevel("blocks")),
 		standardblocks.WithMonitor(monitor),
 		standardblocks.WithETH2Client(eth2Client),
+		standardblocks.WithSignedBeaconBlockProvider(beaconFetcher),
 		standardblocks.WithChainTime(chainTime),
 		standardblocks.WithChainDB(chainDB),
 		standardblocks.WithStartSlot(viper.GetInt64("blocks.start-slot")),
 		standardblocks.WithRefetch(viper.GetBool("blocks.refetch")),
 		standardblocks.WithActivitySem(activitySem),
+		standardblocks.WithWatchdog(watchdog),
+		standardblocks.WithStoredComponents(storedBlockComponents()),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create blocks service")
@@ -528,42 +945,355 @@ func startFinalizer(
 	monitor metrics.Service,
 	finalityHandlers []handlers.FinalityHandler,
 	activitySem *semaphore.Weighted,
+	watchdog watchdog.Service,
 ) error {
 	if !viper.GetBool("finalizer.enable") {
 		return nil
 	}
 
 	var err error
+	address := viper.GetString("eth2client.address")
 	if viper.GetString("finalizer.address") != "" {
-		eth2Client, err = fetchClient(ctx, viper.GetString("finalizer.address"))
+		address = viper.GetString("finalizer.address")
+		eth2Client, err = fetchClient(ctx, address)
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("finalizer.address")))
+			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", address))
 		}
 	}
+	beaconFetcher, err := fetchBeaconFetcher(ctx, address, chainTime)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to fetch beacon fetcher %q", address))
+	}
 
-	_, err = standardfinalizer.New(ctx,
+	svc, err := standardfinalizer.New(ctx,
 		standardfinalizer.WithLogLevel(util.LogLevel("finalizer")),
 		standardfinalizer.WithMonitor(monitor),
 		standardfinalizer.WithETH2Client(eth2Client),
+		standardfinalizer.WithSignedBeaconBlockProvider(beaconFetcher),
 		standardfinalizer.WithChainTime(chainTime),
 		standardfinalizer.WithChainDB(chainDB),
 		standardfinalizer.WithBlocks(blocks),
 		standardfinalizer.WithFinalityHandlers(finalityHandlers),
 		standardfinalizer.WithActivitySem(activitySem),
+		standardfinalizer.WithWatchdog(watchdog),
+		standardfinalizer.WithMaxLag(viper.GetUint64("finalizer.max-lag")),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to create finalizer service")
 	}
+	registerCompletionProvider("finalizer", svc, false)
+	registerReadinessChecker("finalizer", svc)
+
+	return nil
+}
+
+func startBackfill(
+	ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	blocks blocks.Service,
+	monitor metrics.Service,
+	activitySem *semaphore.Weighted,
+) error {
+	if !viper.GetBool("backfill.enable") {
+		return nil
+	}
+	if blocks == nil {
+		return errors.New("backfill requires the blocks service")
+	}
+
+	var err error
+	address := viper.GetString("eth2client.address")
+	if viper.GetString("backfill.address") != "" {
+		address = viper.GetString("backfill.address")
+		eth2Client, err = fetchClient(ctx, address)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", address))
+		}
+	}
+	beaconFetcher, err := fetchBeaconFetcher(ctx, address, chainTime)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to fetch beacon fetcher %q", address))
+	}
+
+	s, err := standardbackfill.New(ctx,
+		standardbackfill.WithLogLevel(util.LogLevel("backfill")),
+		standardbackfill.WithMonitor(monitor),
+		standardbackfill.WithETH2Client(eth2Client),
+		standardbackfill.WithSignedBeaconBlockProvider(beaconFetcher),
+		standardbackfill.WithChainTime(chainTime),
+		standardbackfill.WithChainDB(chainDB),
+		standardbackfill.WithBlocks(blocks),
+		standardbackfill.WithActivitySem(activitySem),
+		standardbackfill.WithTargetSlot(phase0.Slot(viper.GetUint64("backfill.target-slot"))),
+		standardbackfill.WithBatchEpochs(viper.GetUint64("backfill.batch-epochs")),
+		standardbackfill.WithRate(viper.GetFloat64("backfill.rate")),
+		standardbackfill.WithPaused(viper.GetBool("backfill.paused")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create backfill service")
+	}
+
+	registerStatus("backfill", func(ctx context.Context) any {
+		progress := s.Progress(ctx)
+		return &backfillStatus{
+			OriginSlot:     uint64(progress.OriginSlot),
+			TargetSlot:     uint64(progress.TargetSlot),
+			SlotsRemaining: progress.SlotsRemaining,
+			Rate:           progress.Rate,
+			ETA:            progress.ETA.Round(time.Second).String(),
+			Paused:         progress.Paused,
+			Complete:       progress.Complete,
+		}
+	})
+	registerAdminHandler("/backfill/pause", postOnly(func(w http.ResponseWriter, r *http.Request) {
+		s.Pause(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	registerAdminHandler("/backfill/resume", postOnly(func(w http.ResponseWriter, r *http.Request) {
+		s.Resume(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
 
 	return nil
 }
 
+// backfillStatus is the status of the backfill service.
+type backfillStatus struct {
+	OriginSlot     uint64  `json:"origin_slot"`
+	TargetSlot     uint64  `json:"target_slot"`
+	SlotsRemaining uint64  `json:"slots_remaining"`
+	Rate           float64 `json:"rate"`
+	ETA            string  `json:"eta"`
+	Paused         bool    `json:"paused"`
+	Complete       bool    `json:"complete"`
+}
+
+func startNotifications(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) (
+	notifications.Service,
+	error,
+) {
+	if !viper.GetBool("notifications.enable") {
+		return nil, nil
+	}
+
+	webhookConfigs := make([]*webhookConfig, 0)
+	if err := viper.UnmarshalKey("notifications.webhooks", &webhookConfigs); err != nil {
+		return nil, errors.Wrap(err, "invalid webhook configuration")
+	}
+	webhooks := make([]*notifications.Webhook, 0, len(webhookConfigs))
+	for _, webhookConfig := range webhookConfigs {
+		webhook := &notifications.Webhook{
+			Name:           webhookConfig.Name,
+			URL:            webhookConfig.URL,
+			AuthHeader:     webhookConfig.AuthHeader,
+			MaxAttempts:    5,
+			InitialBackoff: time.Second,
+			MaxBackoff:     time.Minute,
+		}
+		if webhookConfig.MaxAttempts != 0 {
+			webhook.MaxAttempts = webhookConfig.MaxAttempts
+		}
+		if webhookConfig.InitialBackoff != 0 {
+			webhook.InitialBackoff = webhookConfig.InitialBackoff
+		}
+		if webhookConfig.MaxBackoff != 0 {
+			webhook.MaxBackoff = webhookConfig.MaxBackoff
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	s, err := standardnotifications.New(ctx,
+		standardnotifications.WithLogLevel(util.LogLevel("notifications")),
+		standardnotifications.WithMonitor(monitor),
+		standardnotifications.WithChainDB(chainDB),
+		standardnotifications.WithChainTime(chainTime),
+		standardnotifications.WithWebhooks(webhooks),
+		standardnotifications.WithCompletedServices(completedServices),
+		standardnotifications.WithTimeout(viper.GetDuration("notifications.timeout")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create notifications service")
+	}
+
+	return s, nil
+}
+
+// webhookConfig is the configuration for a notification webhook.
+type webhookConfig struct {
+	Name           string        `mapstructure:"name"`
+	URL            string        `mapstructure:"url"`
+	AuthHeader     string        `mapstructure:"auth-header"`
+	MaxAttempts    int           `mapstructure:"max-attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial-backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max-backoff"`
+}
+
+func startChainStats(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("chainstats.enable") {
+		return nil
+	}
+
+	_, err := standardchainstats.New(ctx,
+		standardchainstats.WithLogLevel(util.LogLevel("chainstats")),
+		standardchainstats.WithMonitor(monitor),
+		standardchainstats.WithChainDB(chainDB),
+		standardchainstats.WithCacheDuration(viper.GetDuration("chainstats.cache-duration")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create chain statistics service")
+	}
+
+	return nil
+}
+
+func startWatchdog(
+	ctx context.Context,
+	scheduler scheduler.Service,
+	monitor metrics.Service,
+) (
+	watchdog.Service,
+	error,
+) {
+	if !viper.GetBool("watchdog.enable") {
+		return nil, nil
+	}
+
+	s, err := standardwatchdog.New(ctx,
+		standardwatchdog.WithLogLevel(util.LogLevel("watchdog")),
+		standardwatchdog.WithMonitor(monitor),
+		standardwatchdog.WithScheduler(scheduler),
+		standardwatchdog.WithInterval(viper.GetDuration("watchdog.interval")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create watchdog service")
+	}
+
+	return s, nil
+}
+
+func startRetention(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	scheduler scheduler.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("retention.enable") {
+		return nil
+	}
+
+	// Policies are configured per dataset, for example:
+	//   retention:
+	//     policies:
+	//       validator-balances:
+	//         retention: P6M
+	policies := make([]*standardretention.Policy, 0)
+	for _, dataset := range []chaindb.RetentionDataset{
+		chaindb.RetentionDatasetValidatorBalances,
+		chaindb.RetentionDatasetValidatorEpochSummaries,
+		chaindb.RetentionDatasetAttestations,
+	} {
+		key := fmt.Sprintf("retention.policies.%s", dataset)
+		if viper.GetString(fmt.Sprintf("%s.retention", key)) == "" {
+			continue
+		}
+		retention, err := util.ParseCalendarDuration(viper.GetString(fmt.Sprintf("%s.retention", key)))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("invalid retention for %s", dataset))
+		}
+		policies = append(policies, &standardretention.Policy{
+			Dataset:   dataset,
+			Retention: retention,
+			Disabled:  viper.IsSet(fmt.Sprintf("%s.enable", key)) && !viper.GetBool(fmt.Sprintf("%s.enable", key)),
+		})
+	}
+
+	_, err := standardretention.New(ctx,
+		standardretention.WithLogLevel(util.LogLevel("retention")),
+		standardretention.WithMonitor(monitor),
+		standardretention.WithChainDB(chainDB),
+		standardretention.WithChainTime(chainTime),
+		standardretention.WithScheduler(scheduler),
+		standardretention.WithPolicies(policies),
+		standardretention.WithDryRun(viper.GetBool("retention.dry-run")),
+		standardretention.WithInterval(viper.GetDuration("retention.interval")),
+		standardretention.WithBatchSize(viper.GetInt("retention.batch-size")),
+		standardretention.WithBatchInterval(viper.GetDuration("retention.batch-interval")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create retention service")
+	}
+
+	return nil
+}
+
+func startConsistency(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	scheduler scheduler.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("consistency.enable") {
+		return nil
+	}
+
+	checks := make([]chaindb.ConsistencyCheck, 0)
+	for _, check := range viper.GetStringSlice("consistency.checks") {
+		checks = append(checks, chaindb.ConsistencyCheck(check))
+	}
+
+	_, err := standardconsistency.New(ctx,
+		standardconsistency.WithLogLevel(util.LogLevel("consistency")),
+		standardconsistency.WithMonitor(monitor),
+		standardconsistency.WithChainDB(chainDB),
+		standardconsistency.WithChainTime(chainTime),
+		standardconsistency.WithScheduler(scheduler),
+		standardconsistency.WithChecks(checks),
+		standardconsistency.WithInterval(viper.GetDuration("consistency.interval")),
+		standardconsistency.WithSamples(viper.GetInt("consistency.samples")),
+		standardconsistency.WithWindow(viper.GetUint64("consistency.window")),
+		standardconsistency.WithCheckInterval(viper.GetDuration("consistency.check-interval")),
+		standardconsistency.WithMaxActiveQueries(viper.GetInt("consistency.max-active-queries")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create consistency service")
+	}
+
+	return nil
+}
+
+// storedBlockComponents returns the components of blocks that are configured to be stored.
+func storedBlockComponents() blocks.StoredComponents {
+	return blocks.StoredComponents{
+		blocks.ComponentAttestations:   viper.GetBool("blocks.store.attestations"),
+		blocks.ComponentDeposits:       viper.GetBool("blocks.store.deposits"),
+		blocks.ComponentVoluntaryExits: viper.GetBool("blocks.store.exits"),
+		blocks.ComponentSlashings:      viper.GetBool("blocks.store.slashings"),
+		blocks.ComponentSyncAggregates: viper.GetBool("blocks.store.sync-aggregates"),
+		blocks.ComponentWithdrawals:    viper.GetBool("blocks.store.withdrawals"),
+	}
+}
+
 func startSummarizer(
 	ctx context.Context,
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
+	scheduler scheduler.Service,
 	monitor metrics.Service,
+	watchlist watchlist.Service,
 ) (
 	summarizer.Service,
 	error,
@@ -584,20 +1314,57 @@ func startSummarizer(
 		standardsummarizer.WithMaxDaysPerRun(viper.GetUint64("summarizer.max-days-per-run")),
 		standardsummarizer.WithValidatorEpochRetention(viper.GetString("summarizer.validators.epoch-retention")),
 		standardsummarizer.WithValidatorBalanceRetention(viper.GetString("summarizer.validators.balance-retention")),
+		standardsummarizer.WithScheduler(scheduler),
+		standardsummarizer.WithConsistencyCheckInterval(viper.GetDuration("summarizer.epochs.consistency-check-interval")),
+		standardsummarizer.WithStoredBlockComponents(storedBlockComponents()),
+		standardsummarizer.WithWatchlist(watchlist),
+		standardsummarizer.WithResummarizeInterval(viper.GetDuration("summarizer.resummarize-interval")),
+		standardsummarizer.WithResummarizeOutdated(viper.GetBool("summarizer.resummarize-outdated")),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create summarizer service")
 	}
+	registerCompletionProvider("summarizer", standardSummarizer, false)
+	registerSummarizerAdmin(standardSummarizer)
 
 	return standardSummarizer, nil
 }
 
+func startWatchlist(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	scheduler scheduler.Service,
+	monitor metrics.Service,
+) (
+	watchlist.Service,
+	error,
+) {
+	if viper.GetString("watchlist.file") == "" {
+		return nil, nil
+	}
+
+	s, err := standardwatchlist.New(ctx,
+		standardwatchlist.WithLogLevel(util.LogLevel("watchlist")),
+		standardwatchlist.WithMonitor(monitor),
+		standardwatchlist.WithChainDB(chainDB),
+		standardwatchlist.WithScheduler(scheduler),
+		standardwatchlist.WithFile(viper.GetString("watchlist.file")),
+		standardwatchlist.WithReloadInterval(viper.GetDuration("watchlist.reload-interval")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create watchlist service")
+	}
+
+	return s, nil
+}
+
 func startValidators(
 	ctx context.Context,
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
 	monitor metrics.Service,
+	watchlist watchlist.Service,
 ) error {
 	if !viper.GetBool("validators.enable") {
 		return nil
@@ -611,17 +1378,32 @@ func startValidators(
 		}
 	}
 
-	_, err = standardvalidators.New(ctx,
+	svc, err := standardvalidators.New(ctx,
 		standardvalidators.WithLogLevel(util.LogLevel("validators")),
 		standardvalidators.WithMonitor(monitor),
 		standardvalidators.WithETH2Client(eth2Client),
 		standardvalidators.WithChainTime(chainTime),
 		standardvalidators.WithChainDB(chainDB),
 		standardvalidators.WithBalances(viper.GetBool("validators.balances.enable")),
+		standardvalidators.WithBalancesBatchSize(viper.GetUint64("validators.balances.batch-size")),
+		standardvalidators.WithBalancesConcurrency(viper.GetInt("validators.balances.concurrency")),
+		standardvalidators.WithBalancesPageRetries(viper.GetInt("validators.balances.page-retries")),
+		standardvalidators.WithWatchlist(watchlist),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to create validators service")
 	}
+	registerCompletionProvider("validators", svc, true)
+
+	snapshotSvc, err := snapshot.New(ctx,
+		snapshot.WithLogLevel(util.LogLevel("validators")),
+		snapshot.WithChainDB(chainDB),
+		snapshot.WithChainTime(chainTime),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create validator snapshot service")
+	}
+	registerValidatorsAdmin(snapshotSvc)
 
 	return nil
 }
@@ -645,7 +1427,7 @@ func startBeaconCommittees(
 		}
 	}
 
-	_, err = standardbeaconcommittees.New(ctx,
+	svc, err := standardbeaconcommittees.New(ctx,
 		standardbeaconcommittees.WithLogLevel(util.LogLevel("beacon-committees")),
 		standardbeaconcommittees.WithMonitor(monitor),
 		standardbeaconcommittees.WithETH2Client(eth2Client),
@@ -655,6 +1437,7 @@ func startBeaconCommittees(
 	if err != nil {
 		return errors.Wrap(err, "failed to create beacon committees service")
 	}
+	registerCompletionProvider("beacon-committees", svc, true)
 
 	return nil
 }
@@ -678,7 +1461,7 @@ func startProposerDuties(
 		}
 	}
 
-	_, err = standardproposerduties.New(ctx,
+	svc, err := standardproposerduties.New(ctx,
 		standardproposerduties.WithLogLevel(util.LogLevel("proposer-duties")),
 		standardproposerduties.WithMonitor(monitor),
 		standardproposerduties.WithETH2Client(eth2Client),
@@ -688,32 +1471,87 @@ func startProposerDuties(
 	if err != nil {
 		return errors.Wrap(err, "failed to create proposer duties service")
 	}
+	registerCompletionProvider("proposer-duties", svc, true)
 
 	return nil
 }
 
 func startETH1Deposits(
 	ctx context.Context,
+	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
+	chainTime chaintime.Service,
 	monitor metrics.Service,
+	watchdog watchdog.Service,
 ) error {
 	if !viper.GetBool("eth1deposits.enable") {
 		return nil
 	}
 
+	beaconFetcher, err := fetchBeaconFetcher(ctx, viper.GetString("eth2client.address"), chainTime)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch beacon fetcher")
+	}
+
+	depositContractCodeHash, err := hex.DecodeString(strings.TrimPrefix(viper.GetString("eth1deposits.deposit-contract-code-hash"), "0x"))
+	if err != nil {
+		return errors.Wrap(err, "invalid deposit contract code hash")
+	}
+
+	allowedLogAddresses := make([][]byte, 0)
+	for _, address := range viper.GetStringSlice("eth1deposits.allowed-log-addresses") {
+		allowedLogAddress, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("invalid allowed log address %q", address))
+		}
+		allowedLogAddresses = append(allowedLogAddresses, allowedLogAddress)
+	}
+
+	var discovery getlogseth1deposits.Discovery
+	if viper.GetString("eth1deposits.discovery.srv") != "" {
+		discovery = getlogseth1deposits.SRVDiscovery(viper.GetString("eth1deposits.discovery.srv"))
+	}
+
 	log.Trace().Msg("Starting Ethereum 1 deposits service")
-	_, err := getlogseth1deposits.New(ctx,
+	svc, err := getlogseth1deposits.New(ctx,
 		getlogseth1deposits.WithLogLevel(util.LogLevel("eth1deposits.log-level")),
 		getlogseth1deposits.WithMonitor(monitor),
 		getlogseth1deposits.WithChainDB(chainDB),
 		getlogseth1deposits.WithConnectionURL(viper.GetString("eth1client.address")),
+		getlogseth1deposits.WithLogDumpFile(viper.GetString("eth1deposits.log-dump-file")),
 		getlogseth1deposits.WithStartBlock(viper.GetString("eth1deposits.start-block")),
 		getlogseth1deposits.WithETH1DepositsSetter(chainDB.(chaindb.ETH1DepositsSetter)),
 		getlogseth1deposits.WithETH1Confirmations(viper.GetUint64("eth1deposits.confirmations")),
+		getlogseth1deposits.WithDepositCacheSize(viper.GetInt("eth1deposits.deposit-cache-size")),
+		getlogseth1deposits.WithLogCacheSize(viper.GetInt("eth1deposits.log-cache-size")),
+		getlogseth1deposits.WithLogCacheTTL(viper.GetDuration("eth1deposits.log-cache-ttl")),
+		getlogseth1deposits.WithMinPollInterval(viper.GetDuration("eth1deposits.min-poll-interval")),
+		getlogseth1deposits.WithMaxPollInterval(viper.GetDuration("eth1deposits.max-poll-interval")),
+		getlogseth1deposits.WithETH2Client(eth2Client),
+		getlogseth1deposits.WithBeaconStateProvider(beaconFetcher),
+		getlogseth1deposits.WithIdempotencyHeader(viper.GetString("eth1deposits.idempotency-header")),
+		getlogseth1deposits.WithRequestRetries(viper.GetInt("eth1deposits.request-retries")),
+		getlogseth1deposits.WithGlobalRateLimit(viper.GetFloat64("eth1deposits.global-rate-limit")),
+		getlogseth1deposits.WithDiscovery(discovery),
+		getlogseth1deposits.WithRediscoveryThreshold(viper.GetInt("eth1deposits.discovery.failure-threshold")),
+		getlogseth1deposits.WithRediscoveryCooldown(viper.GetDuration("eth1deposits.discovery.cooldown")),
+		getlogseth1deposits.WithLogRangesPerBatch(viper.GetUint64("eth1deposits.log-ranges-per-batch")),
+		getlogseth1deposits.WithVerifySignatures(viper.GetBool("eth1deposits.verify-signatures")),
+		getlogseth1deposits.WithDecodeConcurrency(viper.GetInt("eth1deposits.decode-concurrency")),
+		getlogseth1deposits.WithDepositContractCodeHash(depositContractCodeHash),
+		getlogseth1deposits.WithSkipDepositContractCodeCheck(viper.GetBool("eth1deposits.skip-deposit-contract-code-check")),
+		getlogseth1deposits.WithDepositContractVersion(viper.GetString("eth1deposits.deposit-contract-version")),
+		getlogseth1deposits.WithAllowedLogAddresses(allowedLogAddresses),
+		getlogseth1deposits.WithDepositAmountThresholds(phase0.Gwei(viper.GetUint64("eth1deposits.anomalies.minimum-amount")), phase0.Gwei(viper.GetUint64("eth1deposits.anomalies.maximum-amount"))),
+		getlogseth1deposits.WithDepositAmountGranularity(phase0.Gwei(viper.GetUint64("eth1deposits.anomalies.amount-granularity"))),
+		getlogseth1deposits.WithReconcileInterval(viper.GetDuration("eth1deposits.reconcile-interval")),
+		getlogseth1deposits.WithWatchdog(watchdog),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to start Ethereum 1 deposits service")
 	}
+	registerETH1DepositsAdmin(svc)
+	registerProgress("eth1deposits", svc.BlockProgress)
 
 	return nil
 }
@@ -755,9 +1593,11 @@ func startSyncCommittees(
 
 // runCommands runs commands if required.
 // Returns true if an exit is required.
-//
-//nolint:unparam
-func runCommands(_ context.Context) (bool, error) {
+func runCommands(ctx context.Context) (bool, error) {
+	if exit, err := runSubcommand(ctx); exit {
+		return true, err
+	}
+
 	if viper.GetBool("version") {
 		fmt.Printf("%s\n", ReleaseVersion)
 		return true, nil
@@ -16,6 +16,7 @@ package main
 import (
 	"context"
 
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/wealdtech/chaind/services/metrics"
@@ -26,6 +27,9 @@ var metricsNamespace = "chaind"
 var (
 	releaseMetric *prometheus.GaugeVec
 	readyMetric   prometheus.Gauge
+	adminRequests *prometheus.CounterVec
+	completeSlot  prometheus.Gauge
+	freshnessLag  *prometheus.GaugeVec
 )
 
 func registerMetrics(_ context.Context, monitor metrics.Service) error {
@@ -75,6 +79,36 @@ func registerPrometheusMetrics() error {
 		return errors.Wrap(err, "failed to regsiter ready")
 	}
 
+	adminRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "admin",
+		Name:      "requests_total",
+		Help:      "The number of requests made to the admin server.",
+	}, []string{"caller", "action"})
+	if err := prometheus.Register(adminRequests); err != nil {
+		return errors.Wrap(err, "failed to regsiter admin_requests_total")
+	}
+
+	completeSlot = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "completion",
+		Name:      "complete_slot",
+		Help:      "The slot up to which all ingesting services have stored their data.",
+	})
+	if err := prometheus.Register(completeSlot); err != nil {
+		return errors.Wrap(err, "failed to register completion_complete_slot")
+	}
+
+	freshnessLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "freshness",
+		Name:      "lag",
+		Help:      "The distance of a service behind the chain, in slots or Ethereum 1 blocks.",
+	}, []string{"service"})
+	if err := prometheus.Register(freshnessLag); err != nil {
+		return errors.Wrap(err, "failed to register freshness_lag")
+	}
+
 	return nil
 }
 
@@ -98,3 +132,30 @@ func setReady(_ context.Context, ready bool) {
 		readyMetric.Set(0)
 	}
 }
+
+// monitorAdminRequest is called when a request is made to the admin server.
+func monitorAdminRequest(caller string, action string) {
+	if adminRequests == nil {
+		return
+	}
+
+	adminRequests.WithLabelValues(caller, action).Inc()
+}
+
+// monitorCompleteSlot is called when the complete-up-to watermark is calculated.
+func monitorCompleteSlot(slot phase0.Slot) {
+	if completeSlot == nil {
+		return
+	}
+
+	completeSlot.Set(float64(slot))
+}
+
+// monitorFreshnessLag is called when the freshness of a service is checked.
+func monitorFreshnessLag(service string, lag uint64) {
+	if freshnessLag == nil {
+		return
+	}
+
+	freshnessLag.WithLabelValues(service).Set(float64(lag))
+}
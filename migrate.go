@@ -0,0 +1,45 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+// migrateSchema upgrades the database schema to that supported by this release.
+func migrateSchema(ctx context.Context) error {
+	chainDB, err := startDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	upgrader, isUpgrader := chainDB.(*postgresqlchaindb.Service)
+	if !isUpgrader {
+		return errors.New("chain database does not support upgrades")
+	}
+	requiresRefetch, err := upgrader.Upgrade(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to upgrade chain database")
+	}
+	if requiresRefetch {
+		// The refetch is normally arranged when the upgrade runs at startup, so
+		// the operator needs to arrange it for the next start.
+		log.Warn().Msg("Upgrade requires blocks to be refetched; start chaind with --blocks.start-slot=0 --blocks.refetch")
+	}
+	log.Info().Msg("Database schema is up to date")
+
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// resolveOrigin resolves the point from which data is ingested, recording it
+// in the database when it is first chosen.
+// Services read the recorded origin when they start, so this must be called
+// before they are created.
+func resolveOrigin(ctx context.Context, chainDB chaindb.Service, chainTime chaintime.Service) error {
+	originProvider, isOriginProvider := chainDB.(chaindb.OriginProvider)
+	originSetter, isOriginSetter := chainDB.(chaindb.OriginSetter)
+	if !isOriginProvider || !isOriginSetter {
+		if viper.GetString("ingestion.start") != "" {
+			return errors.New("chain DB does not support an ingestion origin")
+		}
+		return nil
+	}
+
+	origin, err := originProvider.Origin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain origin")
+	}
+	requested, err := parseIngestionStart(viper.GetString("ingestion.start"), chainTime)
+	if err != nil {
+		return errors.Wrap(err, "invalid ingestion start")
+	}
+	allowBackfill := viper.GetBool("ingestion.allow-backfill")
+
+	switch {
+	case requested == nil:
+		// Nothing requested; continue with whatever we have.
+	case origin == nil:
+		latestBlocks, err := chainDB.(chaindb.BlocksProvider).LatestBlocks(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain latest blocks")
+		}
+		if len(latestBlocks) > 0 {
+			log.Warn().Msg("Database already contains data from genesis; ignoring ingestion start")
+			break
+		}
+		origin = requested
+		if err := setOrigin(ctx, chainDB, originSetter, origin); err != nil {
+			return err
+		}
+	case requested.Slot < origin.Slot && allowBackfill:
+		log.Info().Uint64("old_slot", uint64(origin.Slot)).Uint64("new_slot", uint64(requested.Slot)).Msg("Moving ingestion origin back")
+		origin = requested
+		if err := setOrigin(ctx, chainDB, originSetter, origin); err != nil {
+			return err
+		}
+	case requested.Slot < origin.Slot:
+		log.Warn().Uint64("origin_slot", uint64(origin.Slot)).Uint64("requested_slot", uint64(requested.Slot)).Msg("Ingestion start is before the recorded origin; set ingestion.allow-backfill to backfill")
+	case requested.Slot > origin.Slot:
+		log.Warn().Uint64("origin_slot", uint64(origin.Slot)).Uint64("requested_slot", uint64(requested.Slot)).Msg("Ingestion start is after the recorded origin; ignoring")
+	}
+
+	if origin == nil {
+		return nil
+	}
+	log.Info().Uint64("slot", uint64(origin.Slot)).Uint64("epoch", uint64(origin.Epoch)).Msg("Ingesting data from origin")
+
+	// An explicit start slot for blocks must not take us below the origin unless backfill is allowed.
+	startSlot := viper.GetInt64("blocks.start-slot")
+	if startSlot >= 0 && phase0.Slot(startSlot) < origin.Slot {
+		if !allowBackfill {
+			log.Warn().Int64("start_slot", startSlot).Uint64("origin_slot", uint64(origin.Slot)).Msg("Blocks start slot is before the recorded origin; starting at origin")
+			viper.Set("blocks.start-slot", int64(origin.Slot))
+			return nil
+		}
+		epoch := chainTime.SlotToEpoch(phase0.Slot(startSlot))
+		origin = &chaindb.Origin{
+			Slot:  chainTime.FirstSlotOfEpoch(epoch),
+			Epoch: epoch,
+		}
+		log.Info().Uint64("slot", uint64(origin.Slot)).Msg("Moving ingestion origin back to blocks start slot")
+		if err := setOrigin(ctx, chainDB, originSetter, origin); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setOrigin records the origin in the database.
+func setOrigin(ctx context.Context, chainDB chaindb.Service, originSetter chaindb.OriginSetter, origin *chaindb.Origin) error {
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set origin")
+	}
+	if err := originSetter.SetOrigin(ctx, origin); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set origin")
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction to set origin")
+	}
+
+	return nil
+}
+
+// parseIngestionStart parses the ingestion start, which can be one of:
+//   - slot:N for a slot
+//   - epoch:N for an epoch
+//   - an ISO-8601 duration, for example P30D for 30 days ago
+//
+// The origin is always at the start of an epoch, so a slot in the middle of an epoch
+// is moved back to the start of that epoch.
+// It returns nil if no ingestion start is supplied.
+func parseIngestionStart(input string, chainTime chaintime.Service) (*chaindb.Origin, error) {
+	if input == "" {
+		return nil, nil
+	}
+
+	var epoch phase0.Epoch
+	switch {
+	case strings.HasPrefix(input, "slot:"):
+		slot, err := strconv.ParseUint(strings.TrimPrefix(input, "slot:"), 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid slot")
+		}
+		epoch = chainTime.SlotToEpoch(phase0.Slot(slot))
+	case strings.HasPrefix(input, "epoch:"):
+		tmp, err := strconv.ParseUint(strings.TrimPrefix(input, "epoch:"), 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid epoch")
+		}
+		epoch = phase0.Epoch(tmp)
+	case strings.HasPrefix(input, "P"):
+		duration, err := util.ParseCalendarDuration(input)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid duration")
+		}
+		timestamp := duration.Decrement(time.Now())
+		if timestamp.Before(chainTime.GenesisTime()) {
+			timestamp = chainTime.GenesisTime()
+		}
+		epoch = chainTime.TimestampToEpoch(timestamp)
+	default:
+		return nil, fmt.Errorf("unrecognised ingestion start %q", input)
+	}
+
+	if epoch > chainTime.CurrentEpoch() {
+		return nil, fmt.Errorf("ingestion start %q is in the future", input)
+	}
+
+	return &chaindb.Origin{
+		Slot:  chainTime.FirstSlotOfEpoch(epoch),
+		Epoch: epoch,
+	}, nil
+}
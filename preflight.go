@@ -0,0 +1,387 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	"golang.org/x/crypto/sha3"
+)
+
+// errPreflightSkipped is returned by preflight checks that do not apply to the configuration.
+var errPreflightSkipped = errors.New("skipped")
+
+// preflightCheck is a single check carried out by --check.
+type preflightCheck struct {
+	name string
+	// required checks must pass for the preflight to pass.
+	required bool
+	// run carries out the check, returning details of the result.
+	run func(ctx context.Context) (string, error)
+}
+
+// preflight holds the state shared between preflight checks, as later checks
+// rely on connections made by earlier ones.
+type preflight struct {
+	v     *viper.Viper
+	flags *pflag.FlagSet
+
+	chainDB           chaindb.Service
+	schemaInitialised bool
+	spec              map[string]interface{}
+}
+
+// runPreflight checks the configuration and the connectivity of chaind's dependencies,
+// printing the result of each check.  It returns true if all required checks pass.
+func runPreflight(ctx context.Context) bool {
+	p := &preflight{
+		v:     viper.GetViper(),
+		flags: pflag.CommandLine,
+	}
+
+	return runPreflightChecks(ctx, os.Stdout, p.checks())
+}
+
+// checks returns the preflight checks, in the order in which they are to be run.
+func (p *preflight) checks() []*preflightCheck {
+	return []*preflightCheck{
+		{
+			name:     "configuration",
+			required: true,
+			run:      p.checkConfiguration,
+		},
+		{
+			name:     "database",
+			required: true,
+			run:      p.checkDatabase,
+		},
+		{
+			name:     "beacon node",
+			required: true,
+			run:      p.checkBeaconNode,
+		},
+		{
+			name:     "Ethereum 1 client",
+			required: p.v.GetBool("eth1deposits.enable"),
+			run:      p.checkETH1Client,
+		},
+		{
+			name:     "metrics",
+			required: true,
+			run:      p.checkMetrics,
+		},
+	}
+}
+
+// runPreflightChecks runs the checks in turn, writing the result of each to w.
+// Failures of checks that are not required are reported as warnings.
+// It returns true if all required checks pass.
+func runPreflightChecks(ctx context.Context, w io.Writer, checks []*preflightCheck) bool {
+	passed := true
+	for _, check := range checks {
+		details, err := check.run(ctx)
+		switch {
+		case errors.Is(err, errPreflightSkipped):
+			fmt.Fprintf(w, "SKIP %s: %s\n", check.name, details)
+		case err != nil && check.required:
+			fmt.Fprintf(w, "FAIL %s: %v\n", check.name, err)
+			passed = false
+		case err != nil:
+			fmt.Fprintf(w, "WARN %s: %v\n", check.name, err)
+		default:
+			fmt.Fprintf(w, "PASS %s: %s\n", check.name, details)
+		}
+	}
+
+	return passed
+}
+
+// checkConfiguration validates the configuration.
+func (p *preflight) checkConfiguration(_ context.Context) (string, error) {
+	warnings, err := validateConfig(p.v, p.flags)
+	if err != nil {
+		return "", err
+	}
+	if len(warnings) > 0 {
+		return fmt.Sprintf("valid, with warnings: %s", strings.Join(warnings, "; ")), nil
+	}
+
+	return "valid", nil
+}
+
+// checkDatabase confirms that the database is reachable, that its user has sufficient
+// privileges and that its schema can be used by this release.  The database is not altered.
+func (p *preflight) checkDatabase(ctx context.Context) (string, error) {
+	chainDB, err := startDatabase(ctx, postgresqlchaindb.WithReadOnly(true))
+	if err != nil {
+		return "", err
+	}
+	p.chainDB = chainDB
+
+	svc, isService := chainDB.(*postgresqlchaindb.Service)
+	if !isService {
+		return "reachable", nil
+	}
+
+	readOnly := p.v.GetBool("read-only")
+	missing, err := svc.MissingPrivileges(ctx, !readOnly)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to check privileges")
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("user lacks privileges: %s", strings.Join(missing, ", "))
+	}
+
+	status, err := svc.SchemaStatus(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain schema status")
+	}
+	p.schemaInitialised = status.Initialised
+
+	switch {
+	case !status.Initialised && readOnly:
+		return "", errors.New("database has not been initialised, so cannot be used in read-only mode")
+	case !status.Initialised:
+		return "reachable; schema will be initialised on first start", nil
+	case status.Version > status.SupportedVersion:
+		return "", fmt.Errorf("schema version %d, written by release %s, is newer than version %d supported by this release", status.Version, status.Release, status.SupportedVersion)
+	}
+
+	mode := chaindb.AttestationStorageMode(p.v.GetString("chaindb.attestation-storage"))
+	if mode != "" && svc.AttestationStorageMode() != mode {
+		return "", fmt.Errorf("database stores attestations in %s mode but %s mode is configured", svc.AttestationStorageMode(), mode)
+	}
+
+	if status.Version < status.SupportedVersion {
+		if readOnly {
+			return "", fmt.Errorf("schema version %d requires upgrade to %d, so cannot be used in read-only mode", status.Version, status.SupportedVersion)
+		}
+		return fmt.Sprintf("reachable; schema version %d will be upgraded to %d on start", status.Version, status.SupportedVersion), nil
+	}
+
+	return fmt.Sprintf("reachable; schema version %d is current", status.Version), nil
+}
+
+// checkBeaconNode confirms that the beacon node is reachable and on the expected network.
+// The network is expected to be that given by eth2client.genesis-validators-root or, if that
+// is not set, that of an initialised database.
+func (p *preflight) checkBeaconNode(ctx context.Context) (string, error) {
+	address := p.v.GetString("eth2client.address")
+	client, err := fetchClient(ctx, address)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("failed to connect to %s", address))
+	}
+
+	genesisProvider, isProvider := client.(eth2client.GenesisProvider)
+	if !isProvider {
+		return "", errors.New("client does not provide genesis information")
+	}
+	genesis, err := genesisProvider.Genesis(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain genesis")
+	}
+	root := genesis.GenesisValidatorsRoot[:]
+
+	specProvider, isProvider := client.(eth2client.SpecProvider)
+	if !isProvider {
+		return "", errors.New("client does not provide the chain specification")
+	}
+	p.spec, err = specProvider.Spec(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain chain specification")
+	}
+
+	expected, source, err := p.expectedGenesisValidatorsRoot(ctx)
+	if err != nil {
+		return "", err
+	}
+	if expected == nil {
+		return fmt.Sprintf("%s reachable with genesis validators root %#x", address, root), nil
+	}
+	if !bytes.Equal(root, expected) {
+		return "", fmt.Errorf("%s has genesis validators root %#x but %s has %#x", address, root, source, expected)
+	}
+
+	return fmt.Sprintf("%s reachable with genesis validators root %#x, matching %s", address, root, source), nil
+}
+
+// expectedGenesisValidatorsRoot returns the genesis validators root of the expected network,
+// and its source, or nil if there is no expectation.
+func (p *preflight) expectedGenesisValidatorsRoot(ctx context.Context) ([]byte, string, error) {
+	if configured := p.v.GetString("eth2client.genesis-validators-root"); configured != "" {
+		root, err := hex.DecodeString(strings.TrimPrefix(configured, "0x"))
+		if err != nil || len(root) != 32 {
+			return nil, "", fmt.Errorf("invalid eth2client.genesis-validators-root %q", configured)
+		}
+		return root, "configuration", nil
+	}
+
+	if p.chainDB == nil || !p.schemaInitialised {
+		return nil, "", nil
+	}
+	genesisProvider, isProvider := p.chainDB.(chaindb.GenesisProvider)
+	if !isProvider {
+		return nil, "", nil
+	}
+	genesis, err := genesisProvider.Genesis(ctx)
+	if err != nil {
+		// The database may have been initialised but not yet populated.
+		log.Debug().Err(err).Msg("No genesis in database; cannot confirm network")
+		return nil, "", nil
+	}
+
+	return genesis.GenesisValidatorsRoot[:], "database", nil
+}
+
+// checkETH1Client confirms that the Ethereum 1 client is reachable, is on the chain of the
+// deposit contract and has the deposit contract at the address given by the chain specification.
+func (p *preflight) checkETH1Client(ctx context.Context) (string, error) {
+	address := p.v.GetString("eth1client.address")
+	if address == "" {
+		return "eth1client.address is not set", errPreflightSkipped
+	}
+	if p.spec == nil {
+		return "", errors.New("chain specification unavailable from beacon node")
+	}
+	depositContractAddress, exists := p.spec["DEPOSIT_CONTRACT_ADDRESS"].([]byte)
+	if !exists {
+		return "", errors.New("chain specification does not provide the deposit contract address")
+	}
+	depositChainID, exists := p.spec["DEPOSIT_CHAIN_ID"].(uint64)
+	if !exists {
+		return "", errors.New("chain specification does not provide the deposit chain ID")
+	}
+
+	var chainIDStr string
+	if err := eth1Call(ctx, address, "eth_chainId", nil, &chainIDStr); err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("failed to obtain chain ID from %s", address))
+	}
+	chainID, err := strconv.ParseUint(strings.TrimPrefix(chainIDStr, "0x"), 16, 64)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid chain ID")
+	}
+	if chainID != depositChainID {
+		return "", fmt.Errorf("%s has chain ID %d but the deposit contract is on chain %d", address, chainID, depositChainID)
+	}
+
+	var codeStr string
+	if err := eth1Call(ctx, address, "eth_getCode", []interface{}{fmt.Sprintf("%#x", depositContractAddress), "latest"}, &codeStr); err != nil {
+		return "", errors.Wrap(err, "failed to obtain deposit contract code")
+	}
+	code, err := hex.DecodeString(strings.TrimPrefix(codeStr, "0x"))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid deposit contract code")
+	}
+	if len(code) == 0 {
+		return "", fmt.Errorf("no code at deposit contract address %#x", depositContractAddress)
+	}
+
+	if configured := p.v.GetString("eth1deposits.deposit-contract-code-hash"); configured != "" {
+		expected, err := hex.DecodeString(strings.TrimPrefix(configured, "0x"))
+		if err != nil {
+			return "", errors.Wrap(err, "invalid eth1deposits.deposit-contract-code-hash")
+		}
+		hash := sha3.NewLegacyKeccak256()
+		hash.Write(code)
+		codeHash := hash.Sum(nil)
+		if !bytes.Equal(codeHash, expected) {
+			return "", fmt.Errorf("deposit contract at %#x has code hash %#x but expected %#x", depositContractAddress, codeHash, expected)
+		}
+	}
+
+	return fmt.Sprintf("%s reachable on chain %d with deposit contract at %#x", address, chainID, depositContractAddress), nil
+}
+
+// eth1Call makes a JSON-RPC call to the Ethereum 1 client, decoding its result in to result.
+func eth1Call(ctx context.Context, address string, method string, params []interface{}, result interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal request")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "request failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return errors.Wrap(err, "failed to decode response")
+	}
+	if response.Error != nil {
+		return fmt.Errorf("call failed with code %d: %s", response.Error.Code, response.Error.Message)
+	}
+
+	return json.Unmarshal(response.Result, result)
+}
+
+// checkMetrics confirms that the metrics listen address can be bound.
+func (p *preflight) checkMetrics(_ context.Context) (string, error) {
+	address := p.v.GetString("metrics.prometheus.listen-address")
+	if address == "" {
+		return "metrics.prometheus.listen-address is not set", errPreflightSkipped
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to bind listen address")
+	}
+	if err := listener.Close(); err != nil {
+		return "", errors.Wrap(err, "failed to release listen address")
+	}
+
+	return fmt.Sprintf("%s can be bound", address), nil
+}
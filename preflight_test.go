@@ -0,0 +1,187 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPreflightChecks(t *testing.T) {
+	pass := func(_ context.Context) (string, error) { return "fine", nil }
+	fail := func(_ context.Context) (string, error) { return "", errors.New("broken") }
+	skip := func(_ context.Context) (string, error) { return "not configured", errPreflightSkipped }
+
+	tests := []struct {
+		name   string
+		checks []*preflightCheck
+		passed bool
+		output string
+	}{
+		{
+			name: "Pass",
+			checks: []*preflightCheck{
+				{name: "a", required: true, run: pass},
+				{name: "b", required: true, run: skip},
+			},
+			passed: true,
+			output: "PASS a: fine\nSKIP b: not configured\n",
+		},
+		{
+			name: "OptionalFail",
+			checks: []*preflightCheck{
+				{name: "a", required: true, run: pass},
+				{name: "b", required: false, run: fail},
+			},
+			passed: true,
+			output: "PASS a: fine\nWARN b: broken\n",
+		},
+		{
+			name: "RequiredFail",
+			checks: []*preflightCheck{
+				{name: "a", required: true, run: fail},
+				{name: "b", required: true, run: pass},
+			},
+			passed: false,
+			output: "FAIL a: broken\nPASS b: fine\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+			require.Equal(t, test.passed, runPreflightChecks(context.Background(), &output, test.checks))
+			require.Equal(t, test.output, output.String())
+		})
+	}
+}
+
+func TestPreflightMetrics(t *testing.T) {
+	ctx := context.Background()
+	v := viper.New()
+	p := &preflight{v: v}
+
+	_, err := p.checkMetrics(ctx)
+	require.ErrorIs(t, err, errPreflightSkipped)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+
+	// The address is in use.
+	v.Set("metrics.prometheus.listen-address", address)
+	_, err = p.checkMetrics(ctx)
+	require.Error(t, err)
+
+	require.NoError(t, listener.Close())
+	_, err = p.checkMetrics(ctx)
+	require.NoError(t, err)
+}
+
+// newETH1Stub returns a server that answers eth_chainId and eth_getCode.
+func newETH1Stub(t *testing.T, chainID string, code string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var request struct {
+			Method string `json:"method"`
+		}
+		require.NoError(t, json.Unmarshal(body, &request))
+		result := chainID
+		if request.Method == "eth_getCode" {
+			result = code
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result":  result,
+		}))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestPreflightETH1Client(t *testing.T) {
+	ctx := context.Background()
+	spec := map[string]interface{}{
+		"DEPOSIT_CONTRACT_ADDRESS": []byte{0x00, 0x00, 0x00, 0x00, 0x21, 0x9a, 0xb5, 0x40, 0x35, 0x6c, 0xbb, 0x83, 0x9c, 0xbe, 0x05, 0x30, 0x3d, 0x77, 0x05, 0xfa},
+		"DEPOSIT_CHAIN_ID":         uint64(1),
+	}
+
+	tests := []struct {
+		name    string
+		chainID string
+		code    string
+		spec    map[string]interface{}
+		err     string
+	}{
+		{
+			name:    "Good",
+			chainID: "0x1",
+			code:    "0x6080",
+			spec:    spec,
+		},
+		{
+			name:    "SpecMissing",
+			chainID: "0x1",
+			code:    "0x6080",
+			err:     "chain specification unavailable from beacon node",
+		},
+		{
+			name:    "WrongChain",
+			chainID: "0x5",
+			code:    "0x6080",
+			spec:    spec,
+			err:     "has chain ID 5 but the deposit contract is on chain 1",
+		},
+		{
+			name:    "NoCode",
+			chainID: "0x1",
+			code:    "0x",
+			spec:    spec,
+			err:     "no code at deposit contract address 0x00000000219ab540356cbb839cbe05303d7705fa",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newETH1Stub(t, test.chainID, test.code)
+			v := viper.New()
+			v.Set("eth1client.address", server.URL)
+			p := &preflight{
+				v:    v,
+				spec: test.spec,
+			}
+			_, err := p.checkETH1Client(ctx)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// readinessChecker is implemented by services that can report on their own health.
+type readinessChecker interface {
+	// Ready returns true if the service is operating normally.
+	Ready(ctx context.Context) bool
+}
+
+var (
+	readinessCheckersMu sync.Mutex
+	readinessCheckers   = make(map[string]readinessChecker)
+)
+
+// registerReadinessChecker registers a service to be consulted for readiness.
+func registerReadinessChecker(name string, checker readinessChecker) {
+	readinessCheckersMu.Lock()
+	readinessCheckers[name] = checker
+	readinessCheckersMu.Unlock()
+}
+
+// ready returns true if all registered services are ready.
+func ready(ctx context.Context) bool {
+	readinessCheckersMu.Lock()
+	defer readinessCheckersMu.Unlock()
+
+	res := true
+	for name, checker := range readinessCheckers {
+		if !checker.Ready(ctx) {
+			log.Debug().Str("service", name).Msg("Service not ready")
+			res = false
+		}
+	}
+
+	return res
+}
+
+// monitorReadiness periodically updates the readiness metric from the registered services.
+func monitorReadiness(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+			setReady(ctx, ready(ctx))
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// staticReadinessChecker is a readiness checker with a settable state.
+type staticReadinessChecker struct {
+	ready atomic.Bool
+}
+
+func (c *staticReadinessChecker) Ready(_ context.Context) bool {
+	return c.ready.Load()
+}
+
+// resetReadinessCheckers clears the registered readiness checkers for the duration of a test.
+func resetReadinessCheckers(t *testing.T) {
+	t.Helper()
+
+	readinessCheckersMu.Lock()
+	saved := readinessCheckers
+	readinessCheckers = make(map[string]readinessChecker)
+	readinessCheckersMu.Unlock()
+
+	t.Cleanup(func() {
+		readinessCheckersMu.Lock()
+		readinessCheckers = saved
+		readinessCheckersMu.Unlock()
+	})
+}
+
+func TestReady(t *testing.T) {
+	ctx := context.Background()
+	resetReadinessCheckers(t)
+
+	// Nothing registered.
+	require.True(t, ready(ctx))
+
+	blocks := &staticReadinessChecker{}
+	finalizer := &staticReadinessChecker{}
+	registerReadinessChecker("blocks", blocks)
+	registerReadinessChecker("finalizer", finalizer)
+	require.False(t, ready(ctx))
+
+	// Any service not ready makes the whole not ready.
+	blocks.ready.Store(true)
+	require.False(t, ready(ctx))
+
+	finalizer.ready.Store(true)
+	require.True(t, ready(ctx))
+
+	blocks.ready.Store(false)
+	require.False(t, ready(ctx))
+
+	// Registering under an existing name replaces the checker.
+	replacement := &staticReadinessChecker{}
+	replacement.ready.Store(true)
+	registerReadinessChecker("blocks", replacement)
+	require.True(t, ready(ctx))
+}
@@ -0,0 +1,111 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/services/metrics"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+	"github.com/wealdtech/chaind/services/validators/snapshot"
+	"github.com/wealdtech/chaind/util"
+)
+
+// readOnlyWriters are the modules that write to the database, so which cannot be
+// enabled in read-only mode.
+var readOnlyWriters = []string{
+	"blocks",
+	"consistency",
+	"eth1deposits",
+	"finalizer",
+}
+
+// startReadOnlyServices starts the services that serve queries from an existing
+// database without writing to it.
+// Services that write to the database are not started; those that cannot be
+// enabled in read-only mode are rejected when the configuration is validated.
+func startReadOnlyServices(ctx context.Context, monitor metrics.Service) (*shutdownServices, error) {
+	log.Info().Msg("Running in read-only mode; services that write to the database will not start")
+
+	log.Trace().Msg("Starting scheduler")
+	schedulerSvc, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+		standardscheduler.WithMonitor(monitor),
+		standardscheduler.WithExpvar(viper.GetString("scheduler.expvar")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialise scheduler")
+	}
+	registerSchedulerAdmin(schedulerSvc)
+
+	log.Trace().Msg("Checking database schema")
+	//nolint:contextcheck
+	chainDB, err := startDatabase(context.Background(),
+		postgresqlchaindb.WithMonitor(monitor),
+		postgresqlchaindb.WithReadOnly(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if upgrader, isUpgrader := chainDB.(*postgresqlchaindb.Service); isUpgrader {
+		// A read-only database is not upgraded, but this confirms that it does not need to be.
+		if _, err := upgrader.Upgrade(ctx); err != nil {
+			return nil, errors.Wrap(err, "database schema is not usable in read-only mode")
+		}
+	}
+
+	log.Trace().Msg("Starting Ethereum 2 client service")
+	eth2Client, err := fetchClient(ctx, viper.GetString("eth2client.address"))
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("eth2client.address")))
+	}
+
+	log.Trace().Msg("Starting chain time service")
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(util.LogLevel("chaintime")),
+		standardchaintime.WithGenesisTimeProvider(eth2Client.(eth2client.GenesisTimeProvider)),
+		standardchaintime.WithSpecProvider(eth2Client.(eth2client.SpecProvider)),
+		standardchaintime.WithForkScheduleProvider(eth2Client.(eth2client.ForkScheduleProvider)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start chain time service")
+	}
+
+	log.Trace().Msg("Starting validator snapshot service")
+	snapshotSvc, err := snapshot.New(ctx,
+		snapshot.WithLogLevel(util.LogLevel("validators")),
+		snapshot.WithChainDB(chainDB),
+		snapshot.WithChainTime(chainTime),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create validator snapshot service")
+	}
+	registerValidatorsAdmin(snapshotSvc)
+
+	log.Trace().Msg("Starting chain statistics service")
+	if err := startChainStats(ctx, chainDB, monitor); err != nil {
+		return nil, errors.Wrap(err, "failed to start chain statistics service")
+	}
+
+	return &shutdownServices{
+		scheduler: schedulerSvc,
+		chainDB:   chainDB,
+	}, nil
+}
@@ -0,0 +1,252 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+// schedulerJob is the admin representation of a scheduler job.
+type schedulerJob struct {
+	Name      string `json:"name"`
+	Class     string `json:"class"`
+	Periodic  bool   `json:"periodic"`
+	Pinned    bool   `json:"pinned"`
+	Active    bool   `json:"active"`
+	NextRun   string `json:"next_run,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// schedulerDriftStats is the admin representation of scheduler drift statistics.
+type schedulerDriftStats struct {
+	Samples      int    `json:"samples"`
+	MeanAbsDrift string `json:"mean_abs_drift"`
+	MaxAbsDrift  string `json:"max_abs_drift"`
+}
+
+// schedulerSnapshot is the admin representation of a scheduler snapshot.
+type schedulerSnapshot struct {
+	Time       string                          `json:"time"`
+	Jobs       []*schedulerJob                 `json:"jobs"`
+	DriftStats map[string]*schedulerDriftStats `json:"drift_stats"`
+}
+
+// schedulerIdle is the admin representation of the scheduler's idleness.
+type schedulerIdle struct {
+	Idle bool `json:"idle"`
+}
+
+// schedulerReplay is the admin representation of the result of replaying a job.
+type schedulerReplay struct {
+	Error string `json:"error,omitempty"`
+}
+
+// registerSchedulerAdmin registers admin handlers to inspect and control the scheduler.
+func registerSchedulerAdmin(s scheduler.Service) {
+	if provider, isProvider := s.(scheduler.JobInfoProvider); isProvider {
+		registerAdminHandler("/scheduler/jobs", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			writeAdminJSON(w, schedulerJobs(provider.Jobs(r.Context())))
+		})
+		registerAdminHandler("/scheduler/snapshot", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			snapshot := provider.Snapshot(r.Context())
+			res := &schedulerSnapshot{
+				Time:       snapshot.Time.Format(time.RFC3339Nano),
+				Jobs:       schedulerJobs(snapshot.Jobs),
+				DriftStats: make(map[string]*schedulerDriftStats, len(snapshot.DriftStats)),
+			}
+			for class, stats := range snapshot.DriftStats {
+				res.DriftStats[class] = &schedulerDriftStats{
+					Samples:      stats.Samples,
+					MeanAbsDrift: stats.MeanAbsDrift.String(),
+					MaxAbsDrift:  stats.MaxAbsDrift.String(),
+				}
+			}
+			writeAdminJSON(w, res)
+		})
+	}
+
+	if provider, isProvider := s.(scheduler.IdleProvider); isProvider {
+		registerAdminHandler("/scheduler/idle", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			within := time.Duration(0)
+			if r.FormValue("within") != "" {
+				var err error
+				within, err = time.ParseDuration(r.FormValue("within"))
+				if err != nil || within < 0 {
+					http.Error(w, "invalid within", http.StatusBadRequest)
+					return
+				}
+			}
+			writeAdminJSON(w, &schedulerIdle{
+				Idle: provider.IsIdle(r.Context(), within),
+			})
+		})
+	}
+
+	registerAdminHandler("/scheduler/run", postOnly(func(w http.ResponseWriter, r *http.Request) {
+		name := r.FormValue("name")
+		if name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		log.Info().Str("caller", adminCaller(r.Context())).Str("job", name).Msg("Running job on admin request")
+		if err := s.RunJob(r.Context(), name); err != nil {
+			writeSchedulerError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	if replayer, isReplayer := s.(scheduler.Replayer); isReplayer {
+		registerAdminHandler("/scheduler/replay", postOnly(func(w http.ResponseWriter, r *http.Request) {
+			name := r.FormValue("name")
+			if name == "" {
+				http.Error(w, "name is required", http.StatusBadRequest)
+				return
+			}
+			log.Info().Str("caller", adminCaller(r.Context())).Str("job", name).Msg("Replaying job on admin request")
+			res := &schedulerReplay{}
+			if err := replayer.ReplayLastRun(r.Context(), name); err != nil {
+				switch {
+				case errors.Is(err, scheduler.ErrNoSuchJob),
+					errors.Is(err, scheduler.ErrNoPriorRun),
+					errors.Is(err, scheduler.ErrJobRunning),
+					errors.Is(err, scheduler.ErrJobFinalised):
+					writeSchedulerError(w, err)
+					return
+				default:
+					// The replay ran, and the job returned an error.
+					res.Error = err.Error()
+				}
+			}
+			writeAdminJSON(w, res)
+		}))
+	}
+
+	if advancer, isAdvancer := s.(scheduler.NextRunAdvancer); isAdvancer {
+		registerAdminHandler("/scheduler/advance", postOnly(func(w http.ResponseWriter, r *http.Request) {
+			name := r.FormValue("name")
+			if name == "" {
+				http.Error(w, "name is required", http.StatusBadRequest)
+				return
+			}
+			to := time.Now()
+			if r.FormValue("to") != "" {
+				var err error
+				to, err = time.Parse(time.RFC3339, r.FormValue("to"))
+				if err != nil {
+					http.Error(w, "to must be an RFC 3339 time", http.StatusBadRequest)
+					return
+				}
+			}
+			log.Info().Str("caller", adminCaller(r.Context())).Str("job", name).Time("to", to).Msg("Advancing job on admin request")
+			if err := advancer.AdvanceNextRun(r.Context(), name, to); err != nil {
+				writeSchedulerError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+
+	registerAdminHandler("/scheduler/cancel", postOnly(func(w http.ResponseWriter, r *http.Request) {
+		name := r.FormValue("name")
+		class := r.FormValue("class")
+		prefix := r.FormValue("prefix")
+		log := log.With().Str("caller", adminCaller(r.Context())).Logger()
+		switch {
+		case name != "" && class == "" && prefix == "":
+			log.Info().Str("job", name).Msg("Cancelling job on admin request")
+			if err := s.CancelJob(r.Context(), name); err != nil {
+				writeSchedulerError(w, err)
+				return
+			}
+		case class != "" && name == "" && prefix == "":
+			canceller, isCanceller := s.(scheduler.ClassCanceller)
+			if !isCanceller {
+				http.Error(w, "scheduler cannot cancel by class", http.StatusNotImplemented)
+				return
+			}
+			log.Info().Str("class", class).Msg("Cancelling jobs in class on admin request")
+			canceller.CancelJobsInClass(r.Context(), class)
+		case prefix != "" && name == "" && class == "":
+			log.Info().Str("prefix", prefix).Msg("Cancelling jobs with prefix on admin request")
+			s.CancelJobs(r.Context(), prefix)
+		default:
+			http.Error(w, "exactly one of name, class or prefix is required", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+// schedulerJobs converts job information to its admin representation.
+func schedulerJobs(infos []scheduler.JobInfo) []*schedulerJob {
+	res := make([]*schedulerJob, 0, len(infos))
+	for _, info := range infos {
+		job := &schedulerJob{
+			Name:     info.Name,
+			Class:    info.Class,
+			Periodic: info.Periodic,
+			Pinned:   info.Pinned,
+			Active:   info.Active,
+		}
+		if !info.NextRun.IsZero() {
+			job.NextRun = info.NextRun.Format(time.RFC3339Nano)
+		}
+		if info.LastErr != nil {
+			job.LastError = info.LastErr.Error()
+		}
+		res = append(res, job)
+	}
+
+	return res
+}
+
+// writeSchedulerError writes an error from the scheduler with an appropriate status code.
+func writeSchedulerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, scheduler.ErrNoSuchJob):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, scheduler.ErrJobRunning), errors.Is(err, scheduler.ErrJobFinalised), errors.Is(err, scheduler.ErrNoPriorRun):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, scheduler.ErrJobNotPeriodic):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// writeAdminJSON writes a JSON response from the admin server.
+func writeAdminJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Warn().Err(err).Msg("Failed to encode admin response")
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backfill
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Progress is the progress of a backfill.
+type Progress struct {
+	// OriginSlot is the current ingestion origin.
+	OriginSlot phase0.Slot
+	// TargetSlot is the slot to which the backfill is working.
+	TargetSlot phase0.Slot
+	// SlotsRemaining is the number of slots between the target and the origin.
+	SlotsRemaining uint64
+	// Rate is the recent rate of the backfill, in slots per second.
+	Rate float64
+	// ETA is the estimated time for the backfill to complete.
+	// It is 0 if there is no current estimate.
+	ETA time.Duration
+	// Paused is true if the backfill is paused.
+	Paused bool
+	// Complete is true if the backfill has reached its target.
+	Complete bool
+}
+
+// Service defines a backfill service.
+type Service interface {
+	// Pause pauses the backfill once its current batch is complete.
+	Pause(ctx context.Context)
+
+	// Resume resumes a paused backfill.
+	Resume(ctx context.Context)
+
+	// Progress provides the progress of the backfill.
+	Progress(ctx context.Context) *Progress
+}
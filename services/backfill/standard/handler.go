@@ -0,0 +1,222 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// retryInterval is the time to wait after a failed batch before trying again.
+var retryInterval = time.Minute
+
+// run backfills from the origin to the target in batches until complete.
+func (s *Service) run(ctx context.Context) {
+	for {
+		if !s.waitWhilePaused(ctx) {
+			return
+		}
+
+		origin, err := s.originProvider.Origin(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to obtain origin")
+			if !s.sleep(ctx, retryInterval) {
+				return
+			}
+			continue
+		}
+		if origin == nil || origin.Epoch <= s.targetEpoch {
+			log.Info().Msg("Backfill complete")
+			s.updateProgress(origin, 0)
+			return
+		}
+
+		batchEpochs := s.batchEpochs
+		if uint64(origin.Epoch-s.targetEpoch) < batchEpochs {
+			batchEpochs = uint64(origin.Epoch - s.targetEpoch)
+		}
+		newOriginEpoch := origin.Epoch - phase0.Epoch(batchEpochs)
+		newOrigin := &chaindb.Origin{
+			Slot:  s.chainTime.FirstSlotOfEpoch(newOriginEpoch),
+			Epoch: newOriginEpoch,
+		}
+
+		// Only backfill finalized slots, so that the blocks can be marked as canonical.
+		finality, err := s.eth2Client.(eth2client.FinalityProvider).Finality(ctx, "head")
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to obtain finality")
+			if !s.sleep(ctx, retryInterval) {
+				return
+			}
+			continue
+		}
+		if origin.Slot > s.chainTime.FirstSlotOfEpoch(finality.Finalized.Epoch) {
+			log.Trace().Uint64("finalized_epoch", uint64(finality.Finalized.Epoch)).Msg("Origin not yet finalized; waiting")
+			if !s.sleep(ctx, time.Until(s.chainTime.StartOfEpoch(s.chainTime.CurrentEpoch()+1))) {
+				return
+			}
+			continue
+		}
+
+		started := time.Now()
+		if err := s.backfillBatch(ctx, origin, newOrigin); err != nil {
+			log.Warn().Uint64("start_slot", uint64(newOrigin.Slot)).Uint64("end_slot", uint64(origin.Slot)).Err(err).Msg("Failed to backfill batch; will retry")
+			if !s.sleep(ctx, retryInterval) {
+				return
+			}
+			continue
+		}
+		slots := uint64(origin.Slot - newOrigin.Slot)
+		log.Trace().Uint64("slot", uint64(newOrigin.Slot)).Msg("Backfilled to slot")
+
+		// Throttle to the configured rate.
+		if s.rate > 0 {
+			minDuration := time.Duration(float64(slots) / s.rate * float64(time.Second))
+			if !s.sleep(ctx, minDuration-time.Since(started)) {
+				return
+			}
+		}
+		s.updateProgress(newOrigin, float64(slots)/time.Since(started).Seconds())
+	}
+}
+
+// backfillBatch fetches and stores the blocks between the new origin and the
+// current origin, and records the new origin.
+func (s *Service) backfillBatch(ctx context.Context, origin *chaindb.Origin, newOrigin *chaindb.Origin) error {
+	// Fetch the blocks before taking the semaphore, to keep the time that the
+	// other services are blocked to a minimum.
+	signedBlocks := make([]*spec.VersionedSignedBeaconBlock, 0, origin.Slot-newOrigin.Slot)
+	for slot := newOrigin.Slot; slot < origin.Slot; slot++ {
+		signedBlock, err := s.signedBeaconBlockProvider.SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain beacon block for slot")
+		}
+		if signedBlock == nil {
+			// Empty slot.
+			continue
+		}
+		signedBlocks = append(signedBlocks, signedBlock)
+	}
+
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		return errors.Wrap(err, "failed to acquire semaphore")
+	}
+	defer s.activitySem.Release(1)
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	for _, signedBlock := range signedBlocks {
+		if err := s.blocks.OnBlock(ctx, signedBlock); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to store block")
+		}
+	}
+
+	// The blocks are finalized, so mark them as canonical.
+	dbBlocks, err := s.blocksProvider.BlocksForSlotRange(ctx, newOrigin.Slot, origin.Slot)
+	if err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to obtain stored blocks")
+	}
+	for _, dbBlock := range dbBlocks {
+		canonical := true
+		dbBlock.Canonical = &canonical
+		if err := s.blocksSetter.SetBlock(ctx, dbBlock); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set block to canonical")
+		}
+	}
+
+	if err := s.originSetter.SetOrigin(ctx, newOrigin); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set origin")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// waitWhilePaused waits until the backfill is not paused.
+// It returns false if the context is done.
+func (s *Service) waitWhilePaused(ctx context.Context) bool {
+	s.pauseMu.Lock()
+	paused := s.paused
+	resumeCh := s.resumeCh
+	s.pauseMu.Unlock()
+
+	if !paused {
+		return true
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-resumeCh:
+		return true
+	}
+}
+
+// sleep sleeps for the given duration.
+// It returns false if the context is done.
+func (*Service) sleep(ctx context.Context, duration time.Duration) bool {
+	if duration <= 0 {
+		return ctx.Err() == nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
+
+// updateProgress updates the progress given the current origin and recent rate.
+func (s *Service) updateProgress(origin *chaindb.Origin, rate float64) {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	s.progress.Rate = rate
+	s.progress.ETA = 0
+	if origin == nil || origin.Slot <= s.progress.TargetSlot {
+		if origin != nil {
+			s.progress.OriginSlot = origin.Slot
+		}
+		s.progress.SlotsRemaining = 0
+		s.progress.Complete = true
+		monitorProgress(s.progress.OriginSlot, 0, rate, 0)
+		return
+	}
+
+	s.progress.OriginSlot = origin.Slot
+	s.progress.SlotsRemaining = uint64(origin.Slot - s.progress.TargetSlot)
+	if rate > 0 {
+		s.progress.ETA = time.Duration(float64(s.progress.SlotsRemaining) / rate * float64(time.Second))
+	}
+	monitorProgress(s.progress.OriginSlot, s.progress.SlotsRemaining, rate, s.progress.ETA)
+}
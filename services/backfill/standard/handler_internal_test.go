@@ -0,0 +1,84 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/backfill"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestProgress(t *testing.T) {
+	ctx := context.Background()
+
+	s := &Service{
+		resumeCh: make(chan struct{}),
+		progress: &backfill.Progress{
+			TargetSlot: 320,
+		},
+	}
+
+	s.updateProgress(&chaindb.Origin{Slot: 3520, Epoch: 110}, 0)
+	progress := s.Progress(ctx)
+	require.Equal(t, uint64(3200), progress.SlotsRemaining)
+	require.Equal(t, time.Duration(0), progress.ETA)
+	require.False(t, progress.Complete)
+
+	s.updateProgress(&chaindb.Origin{Slot: 3200, Epoch: 100}, 32)
+	progress = s.Progress(ctx)
+	require.Equal(t, uint64(2880), progress.SlotsRemaining)
+	require.Equal(t, 90*time.Second, progress.ETA)
+
+	s.updateProgress(&chaindb.Origin{Slot: 320, Epoch: 10}, 32)
+	progress = s.Progress(ctx)
+	require.Equal(t, uint64(0), progress.SlotsRemaining)
+	require.True(t, progress.Complete)
+}
+
+func TestPauseResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := &Service{
+		resumeCh: make(chan struct{}),
+		progress: &backfill.Progress{},
+	}
+	require.True(t, s.waitWhilePaused(ctx))
+
+	s.Pause(ctx)
+	require.True(t, s.Progress(ctx).Paused)
+
+	resumed := make(chan bool)
+	go func() {
+		resumed <- s.waitWhilePaused(ctx)
+	}()
+	select {
+	case <-resumed:
+		require.Fail(t, "wait returned while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.Resume(ctx)
+	require.True(t, <-resumed)
+	require.False(t, s.Progress(ctx).Paused)
+
+	// Pausing again and cancelling the context should stop the wait.
+	s.Pause(ctx)
+	cancel()
+	require.False(t, s.waitWhilePaused(ctx))
+}
@@ -0,0 +1,117 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_backfill"
+
+var (
+	originSlot     prometheus.Gauge
+	slotsRemaining prometheus.Gauge
+	rateMetric     prometheus.Gauge
+	etaMetric      prometheus.Gauge
+	pausedMetric   prometheus.Gauge
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if originSlot != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	originSlot = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "origin_slot",
+		Help:      "Slot of the current ingestion origin",
+	})
+	if err := prometheus.Register(originSlot); err != nil {
+		return errors.Wrap(err, "failed to register origin_slot")
+	}
+
+	slotsRemaining = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "slots_remaining",
+		Help:      "Number of slots remaining to backfill",
+	})
+	if err := prometheus.Register(slotsRemaining); err != nil {
+		return errors.Wrap(err, "failed to register slots_remaining")
+	}
+
+	rateMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "rate",
+		Help:      "Recent rate of the backfill, in slots per second",
+	})
+	if err := prometheus.Register(rateMetric); err != nil {
+		return errors.Wrap(err, "failed to register rate")
+	}
+
+	etaMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "eta_seconds",
+		Help:      "Estimated time for the backfill to complete",
+	})
+	if err := prometheus.Register(etaMetric); err != nil {
+		return errors.Wrap(err, "failed to register eta_seconds")
+	}
+
+	pausedMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "paused",
+		Help:      "1 if the backfill is paused, otherwise 0",
+	})
+	if err := prometheus.Register(pausedMetric); err != nil {
+		return errors.Wrap(err, "failed to register paused")
+	}
+
+	return nil
+}
+
+func monitorProgress(origin phase0.Slot, remaining uint64, rate float64, eta time.Duration) {
+	if originSlot != nil {
+		originSlot.Set(float64(origin))
+		slotsRemaining.Set(float64(remaining))
+		rateMetric.Set(rate)
+		etaMetric.Set(eta.Seconds())
+	}
+}
+
+func monitorPaused(paused bool) {
+	if pausedMetric != nil {
+		if paused {
+			pausedMetric.Set(1)
+		} else {
+			pausedMetric.Set(0)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/blocks"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+	"golang.org/x/sync/semaphore"
+)
+
+type parameters struct {
+	logLevel                  zerolog.Level
+	monitor                   metrics.Service
+	eth2Client                eth2client.Service
+	signedBeaconBlockProvider eth2client.SignedBeaconBlockProvider
+	chainDB                   chaindb.Service
+	chainTime                 chaintime.Service
+	blocks                    blocks.Service
+	activitySem               *semaphore.Weighted
+	targetSlot                phase0.Slot
+	batchEpochs               uint64
+	rate                      float64
+	paused                    bool
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithETH2Client sets the Ethereum 2 client for this module.
+func WithETH2Client(eth2Client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eth2Client = eth2Client
+	})
+}
+
+// WithSignedBeaconBlockProvider sets the signed beacon block provider for this module.
+// If not supplied, blocks are fetched using the Ethereum 2 client.
+func WithSignedBeaconBlockProvider(provider eth2client.SignedBeaconBlockProvider) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.signedBeaconBlockProvider = provider
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithBlocks sets the blocks service for this module.
+func WithBlocks(blocks blocks.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.blocks = blocks
+	})
+}
+
+// WithActivitySem sets the activity semaphore for this module.
+func WithActivitySem(sem *semaphore.Weighted) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.activitySem = sem
+	})
+}
+
+// WithTargetSlot sets the slot to which to backfill.
+func WithTargetSlot(slot phase0.Slot) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.targetSlot = slot
+	})
+}
+
+// WithBatchEpochs sets the number of epochs to backfill in each batch.
+func WithBatchEpochs(epochs uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.batchEpochs = epochs
+	})
+}
+
+// WithRate sets the maximum rate of the backfill, in slots per second.
+// A rate of 0 means that the rate is unlimited.
+func WithRate(rate float64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rate = rate
+	})
+}
+
+// WithPaused states if the backfill should start paused.
+func WithPaused(paused bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.paused = paused
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:    zerolog.GlobalLevel(),
+		batchEpochs: 1,
+		rate:        8,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.eth2Client == nil {
+		return nil, errors.New("no Ethereum 2 client specified")
+	}
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.blocks == nil {
+		return nil, errors.New("no blocks specified")
+	}
+	if parameters.activitySem == nil {
+		return nil, errors.New("no activity semaphore specified")
+	}
+	if parameters.batchEpochs == 0 {
+		return nil, errors.New("batch epochs must be at least 1")
+	}
+	if parameters.rate < 0 {
+		return nil, errors.New("rate cannot be negative")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,181 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/backfill"
+	"github.com/wealdtech/chaind/services/blocks"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is a backfill service.
+// It walks backwards from the ingestion origin towards a target slot, storing blocks
+// in the same fashion as the blocks service and moving the origin back as it goes.
+type Service struct {
+	eth2Client                eth2client.Service
+	signedBeaconBlockProvider eth2client.SignedBeaconBlockProvider
+	chainDB                   chaindb.Service
+	originProvider            chaindb.OriginProvider
+	originSetter              chaindb.OriginSetter
+	blocksProvider            chaindb.BlocksProvider
+	blocksSetter              chaindb.BlocksSetter
+	chainTime                 chaintime.Service
+	blocks                    blocks.Service
+	activitySem               *semaphore.Weighted
+	targetEpoch               phase0.Epoch
+	batchEpochs               uint64
+	rate                      float64
+
+	pauseMu  sync.Mutex
+	paused   bool
+	resumeCh chan struct{}
+
+	progressMu sync.RWMutex
+	progress   *backfill.Progress
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger(zerologger.With().Str("service", "backfill").Str("impl", "standard").Logger(), "backfill", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	originProvider, isOriginProvider := parameters.chainDB.(chaindb.OriginProvider)
+	if !isOriginProvider {
+		return nil, errors.New("chain DB does not support origin providing")
+	}
+
+	originSetter, isOriginSetter := parameters.chainDB.(chaindb.OriginSetter)
+	if !isOriginSetter {
+		return nil, errors.New("chain DB does not support origin setting")
+	}
+
+	blocksProvider, isBlocksProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isBlocksProvider {
+		return nil, errors.New("chain DB does not support block providing")
+	}
+
+	blocksSetter, isBlocksSetter := parameters.chainDB.(chaindb.BlocksSetter)
+	if !isBlocksSetter {
+		return nil, errors.New("chain DB does not support block setting")
+	}
+
+	signedBeaconBlockProvider := parameters.signedBeaconBlockProvider
+	if signedBeaconBlockProvider == nil {
+		var isProvider bool
+		signedBeaconBlockProvider, isProvider = parameters.eth2Client.(eth2client.SignedBeaconBlockProvider)
+		if !isProvider {
+			return nil, errors.New("client does not provide signed beacon blocks")
+		}
+	}
+
+	if _, isProvider := parameters.eth2Client.(eth2client.FinalityProvider); !isProvider {
+		return nil, errors.New("client does not provide finality")
+	}
+
+	s := &Service{
+		eth2Client:                parameters.eth2Client,
+		signedBeaconBlockProvider: signedBeaconBlockProvider,
+		chainDB:                   parameters.chainDB,
+		originProvider:            originProvider,
+		originSetter:              originSetter,
+		blocksProvider:            blocksProvider,
+		blocksSetter:              blocksSetter,
+		chainTime:                 parameters.chainTime,
+		blocks:                    parameters.blocks,
+		activitySem:               parameters.activitySem,
+		targetEpoch:               parameters.chainTime.SlotToEpoch(parameters.targetSlot),
+		batchEpochs:               parameters.batchEpochs,
+		rate:                      parameters.rate,
+		paused:                    parameters.paused,
+		resumeCh:                  make(chan struct{}),
+		progress: &backfill.Progress{
+			TargetSlot: parameters.chainTime.FirstSlotOfEpoch(parameters.chainTime.SlotToEpoch(parameters.targetSlot)),
+		},
+	}
+	monitorPaused(s.paused)
+
+	origin, err := originProvider.Origin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain origin")
+	}
+	s.updateProgress(origin, 0)
+
+	go s.run(ctx)
+
+	return s, nil
+}
+
+// Pause pauses the backfill once its current batch is complete.
+func (s *Service) Pause(_ context.Context) {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+
+	if s.paused {
+		return
+	}
+	s.paused = true
+	s.resumeCh = make(chan struct{})
+	log.Info().Msg("Backfill paused")
+	monitorPaused(true)
+}
+
+// Resume resumes a paused backfill.
+func (s *Service) Resume(_ context.Context) {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+
+	if !s.paused {
+		return
+	}
+	s.paused = false
+	close(s.resumeCh)
+	log.Info().Msg("Backfill resumed")
+	monitorPaused(false)
+}
+
+// Progress provides the progress of the backfill.
+func (s *Service) Progress(_ context.Context) *backfill.Progress {
+	s.progressMu.RLock()
+	progress := *s.progress
+	s.progressMu.RUnlock()
+
+	s.pauseMu.Lock()
+	progress.Paused = s.paused
+	s.pauseMu.Unlock()
+
+	return &progress
+}
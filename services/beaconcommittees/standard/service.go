@@ -23,6 +23,7 @@ import (
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -33,6 +34,7 @@ type Service struct {
 	beaconCommitteesSetter chaindb.BeaconCommitteesSetter
 	chainTime              chaintime.Service
 	activitySem            *semaphore.Weighted
+	origin                 *chaindb.Origin
 }
 
 // module-wide log.
@@ -46,7 +48,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "beaconcommittees").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger(zerologger.With().Str("service", "beaconcommittees").Str("impl", "standard").Logger(), "beaconcommittees", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -56,12 +58,21 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	if !isBeaconCommitteesSetter {
 		return nil, errors.New("chain DB does not support beacon committee setting")
 	}
+	var origin *chaindb.Origin
+	if originProvider, isOriginProvider := parameters.chainDB.(chaindb.OriginProvider); isOriginProvider {
+		origin, err = originProvider.Origin(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain origin")
+		}
+	}
+
 	s := &Service{
 		eth2Client:             parameters.eth2Client,
 		chainDB:                parameters.chainDB,
 		beaconCommitteesSetter: beaconCommitteesSetter,
 		chainTime:              parameters.chainTime,
 		activitySem:            semaphore.NewWeighted(1),
+		origin:                 origin,
 	}
 
 	// Update to current epoch before starting (in the background).
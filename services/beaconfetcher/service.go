@@ -0,0 +1,26 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beaconfetcher
+
+import (
+	eth2client "github.com/attestantio/go-eth2-client"
+)
+
+// Service is the beacon fetcher service.
+// It fetches large objects from the beacon node, preferring SSZ encoding
+// where the node supports it.
+type Service interface {
+	eth2client.SignedBeaconBlockProvider
+	eth2client.BeaconStateProvider
+}
@@ -0,0 +1,85 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+// denseEpoch returns the SSZ and JSON responses for an epoch of full
+// blocks, approximating a busy mainnet epoch: every slot has a block with
+// the maximum 128 attestations, a full set of withdrawals and a couple of
+// hundred transactions.
+func denseEpoch(b *testing.B) ([]*response, []*response) {
+	b.Helper()
+	sszResponses := make([]*response, 32)
+	jsonResponses := make([]*response, 32)
+	for i := range sszResponses {
+		block := testCapellaBlock(phase0.Slot(6000000+i), 128, 200)
+		sszData, err := block.MarshalSSZ()
+		if err != nil {
+			b.Fatal(err)
+		}
+		sszResponses[i] = &response{
+			contentType:      contentTypeSSZ,
+			consensusVersion: "capella",
+			body:             sszData,
+		}
+		blockJSON, err := json.Marshal(block)
+		if err != nil {
+			b.Fatal(err)
+		}
+		jsonResponses[i] = &response{
+			contentType: contentTypeJSON,
+			body:        []byte(fmt.Sprintf(`{"version":"capella","data":%s}`, string(blockJSON))),
+		}
+	}
+
+	return sszResponses, jsonResponses
+}
+
+func BenchmarkDecodeDenseEpoch(b *testing.B) {
+	s := &Service{chainTime: &forkChainTime{Service: mockchaintime.New()}}
+	sszResponses, jsonResponses := denseEpoch(b)
+
+	for _, bench := range []struct {
+		name      string
+		responses []*response
+	}{
+		{name: "SSZ", responses: sszResponses},
+		{name: "JSON", responses: jsonResponses},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			size := 0
+			for _, res := range bench.responses {
+				size += len(res.body)
+			}
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, res := range bench.responses {
+					if _, err := s.decodeSignedBeaconBlock(res); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
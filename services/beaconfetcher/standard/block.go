@@ -0,0 +1,120 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// decodable is an object that can be decoded from either SSZ or JSON.
+type decodable interface {
+	UnmarshalSSZ(buf []byte) error
+	UnmarshalJSON(input []byte) error
+}
+
+// SignedBeaconBlock fetches a signed beacon block given a block ID.
+// N.B if a signed beacon block for the block ID is not available this will return nil without an error.
+func (s *Service) SignedBeaconBlock(ctx context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.beaconfetcher.standard").Start(ctx, "SignedBeaconBlock",
+		trace.WithAttributes(
+			attribute.String("block_id", blockID),
+		))
+	defer span.End()
+
+	res, err := s.fetch(ctx, fmt.Sprintf("/eth/v2/beacon/blocks/%s", blockID))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to request signed beacon block")
+	}
+	if res.statusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	return s.decodeSignedBeaconBlock(res)
+}
+
+func (s *Service) decodeSignedBeaconBlock(res *response) (*spec.VersionedSignedBeaconBlock, error) {
+	var version spec.DataVersion
+	var data []byte
+	var err error
+	switch res.contentType {
+	case contentTypeSSZ:
+		version, err = s.sszVersion(res, sszBlockSlotOffset)
+		data = res.body
+	case contentTypeJSON:
+		envelope := &jsonEnvelope{}
+		if err := json.Unmarshal(res.body, envelope); err != nil {
+			return nil, errors.Wrap(err, "failed to parse JSON response")
+		}
+		version, err = s.jsonVersion(res, envelope, jsonBlockSlot)
+		data = envelope.Data
+	default:
+		return nil, unsupportedContentType(res)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to establish block version")
+	}
+
+	block := &spec.VersionedSignedBeaconBlock{
+		Version: version,
+	}
+	var target decodable
+	switch version {
+	case spec.DataVersionPhase0:
+		block.Phase0 = &phase0.SignedBeaconBlock{}
+		target = block.Phase0
+	case spec.DataVersionAltair:
+		block.Altair = &altair.SignedBeaconBlock{}
+		target = block.Altair
+	case spec.DataVersionBellatrix:
+		block.Bellatrix = &bellatrix.SignedBeaconBlock{}
+		target = block.Bellatrix
+	case spec.DataVersionCapella:
+		block.Capella = &capella.SignedBeaconBlock{}
+		target = block.Capella
+	case spec.DataVersionDeneb:
+		block.Deneb = &deneb.SignedBeaconBlock{}
+		target = block.Deneb
+	default:
+		return nil, fmt.Errorf("unhandled block version %v", version)
+	}
+
+	if err := decode(res.contentType, data, target); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to decode %v signed beacon block", version))
+	}
+
+	return block, nil
+}
+
+// decode decodes data in the given content type in to the target.
+func decode(contentType string, data []byte, target decodable) error {
+	if contentType == contentTypeSSZ {
+		return target.UnmarshalSSZ(data)
+	}
+
+	return target.UnmarshalJSON(data)
+}
@@ -0,0 +1,145 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaintime"
+)
+
+// Encoding is the encoding requested from the beacon node.
+type Encoding int
+
+const (
+	// EncodingAuto requests SSZ, falling back to JSON if the node does not support it.
+	EncodingAuto Encoding = iota
+	// EncodingSSZ requests SSZ only.
+	EncodingSSZ
+	// EncodingJSON requests JSON only.
+	EncodingJSON
+)
+
+var encodingStrings = [...]string{
+	"auto",
+	"ssz",
+	"json",
+}
+
+// String returns a string representation of the encoding.
+func (e Encoding) String() string {
+	if int(e) >= len(encodingStrings) || e < 0 {
+		return "unknown"
+	}
+
+	return encodingStrings[e]
+}
+
+// ParseEncoding parses an encoding from a string.
+func ParseEncoding(input string) (Encoding, error) {
+	for i, str := range encodingStrings {
+		if strings.EqualFold(input, str) {
+			return Encoding(i), nil
+		}
+	}
+
+	return EncodingAuto, fmt.Errorf("unrecognised encoding %q", input)
+}
+
+type parameters struct {
+	logLevel  zerolog.Level
+	address   string
+	timeout   time.Duration
+	encoding  Encoding
+	chainTime chaintime.Service
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithAddress sets the address of the beacon node.
+func WithAddress(address string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.address = address
+	})
+}
+
+// WithTimeout sets the timeout for requests to the beacon node.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithEncoding sets the encoding to request from the beacon node.
+func WithEncoding(encoding Encoding) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.encoding = encoding
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		timeout:  2 * time.Minute,
+		encoding: EncodingAuto,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.address == "" {
+		return nil, errors.New("no address specified")
+	}
+	if parameters.timeout == 0 {
+		return nil, errors.New("no timeout specified")
+	}
+	if parameters.encoding.String() == "unknown" {
+		return nil, errors.New("invalid encoding specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+
+	return &parameters, nil
+}
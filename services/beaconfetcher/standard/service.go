@@ -0,0 +1,191 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/atomic"
+)
+
+const (
+	contentTypeSSZ  = "application/octet-stream"
+	contentTypeJSON = "application/json"
+
+	acceptSSZ  = contentTypeSSZ
+	acceptJSON = contentTypeJSON
+	acceptAuto = "application/octet-stream;q=1,application/json;q=0.9"
+)
+
+// Service fetches blocks and states from a beacon node.
+type Service struct {
+	base      *url.URL
+	client    *http.Client
+	timeout   time.Duration
+	encoding  Encoding
+	chainTime chaintime.Service
+	// sszUnsupported is set in auto mode once the node has shown that it
+	// does not serve SSZ, after which only JSON is requested.
+	sszUnsupported atomic.Bool
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger(zerologger.With().Str("service", "beaconfetcher").Str("impl", "standard").Logger(), "beaconfetcher", parameters.logLevel)
+
+	address := parameters.address
+	if !strings.HasPrefix(address, "http") {
+		address = fmt.Sprintf("http://%s", address)
+	}
+	base, err := url.Parse(address)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid address")
+	}
+
+	s := &Service{
+		base:      base,
+		client:    &http.Client{},
+		timeout:   parameters.timeout,
+		encoding:  parameters.encoding,
+		chainTime: parameters.chainTime,
+	}
+	log.Trace().Stringer("encoding", s.encoding).Msg("Created beacon fetcher")
+
+	return s, nil
+}
+
+// response is the relevant information from a response from the beacon node.
+type response struct {
+	statusCode       int
+	contentType      string
+	consensusVersion string
+	body             []byte
+}
+
+// fetch fetches the given endpoint from the beacon node, negotiating the
+// encoding as per the service configuration.
+func (s *Service) fetch(ctx context.Context, endpoint string) (*response, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.beaconfetcher.standard").Start(ctx, "fetch")
+	defer span.End()
+
+	accept := s.accept()
+	res, err := s.get(ctx, endpoint, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.encoding == EncodingAuto && accept != acceptJSON {
+		switch {
+		case res.statusCode == http.StatusNotAcceptable:
+			// Node refuses SSZ outright; ask again for JSON.
+			s.markSSZUnsupported()
+			res, err = s.get(ctx, endpoint, acceptJSON)
+			if err != nil {
+				return nil, err
+			}
+		case res.contentType == contentTypeJSON:
+			// Node ignored our preference.
+			s.markSSZUnsupported()
+		}
+	}
+
+	if res.statusCode == http.StatusNotFound {
+		return res, nil
+	}
+	if res.statusCode/100 != 2 {
+		return nil, fmt.Errorf("GET %s failed with status %d: %s", endpoint, res.statusCode, string(bytes.TrimSpace(res.body)))
+	}
+
+	return res, nil
+}
+
+// accept returns the accept header for the next request.
+func (s *Service) accept() string {
+	switch s.encoding {
+	case EncodingSSZ:
+		return acceptSSZ
+	case EncodingJSON:
+		return acceptJSON
+	default:
+		if s.sszUnsupported.Load() {
+			return acceptJSON
+		}
+		return acceptAuto
+	}
+}
+
+func (s *Service) markSSZUnsupported() {
+	if s.sszUnsupported.CompareAndSwap(false, true) {
+		log.Info().Msg("Beacon node does not provide SSZ; falling back to JSON")
+	}
+}
+
+func (s *Service) get(ctx context.Context, endpoint string, accept string) (*response, error) {
+	reqURL, err := url.Parse(fmt.Sprintf("%s%s", strings.TrimSuffix(s.base.String(), "/"), endpoint))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid endpoint")
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(opCtx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create GET request")
+	}
+	req.Header.Set("Accept", accept)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call GET endpoint")
+	}
+	defer resp.Body.Close()
+
+	res := &response{
+		statusCode:       resp.StatusCode,
+		consensusVersion: resp.Header.Get("Eth-Consensus-Version"),
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		res.contentType = mediaType
+	}
+	res.body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read body")
+	}
+	log.Trace().Str("endpoint", endpoint).Str("accept", accept).Int("status_code", res.statusCode).Str("content_type", res.contentType).Int("bytes", len(res.body)).Msg("Fetched")
+
+	return res, nil
+}
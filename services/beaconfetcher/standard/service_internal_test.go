@@ -0,0 +1,299 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaintime"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+// forkChainTime is a chain time service with a simple fork schedule.
+type forkChainTime struct {
+	chaintime.Service
+}
+
+func (*forkChainTime) SlotToEpoch(slot phase0.Slot) phase0.Epoch {
+	return phase0.Epoch(slot / 32)
+}
+
+func (*forkChainTime) AltairInitialEpoch() phase0.Epoch    { return 1 }
+func (*forkChainTime) BellatrixInitialEpoch() phase0.Epoch { return 2 }
+func (*forkChainTime) CapellaInitialEpoch() phase0.Epoch   { return 3 }
+func (*forkChainTime) DenebInitialEpoch() phase0.Epoch     { return 0xffffffffffffffff }
+
+// testCapellaBlock creates a Capella block with the given number of
+// attestations and transactions.
+func testCapellaBlock(slot phase0.Slot, attestations int, transactions int) *capella.SignedBeaconBlock {
+	block := &capella.SignedBeaconBlock{
+		Message: &capella.BeaconBlock{
+			Slot:          slot,
+			ProposerIndex: 12345,
+			Body: &capella.BeaconBlockBody{
+				ETH1Data: &phase0.ETH1Data{
+					DepositRoot: phase0.Root{0x01},
+					BlockHash:   make([]byte, 32),
+				},
+				ProposerSlashings: []*phase0.ProposerSlashing{},
+				AttesterSlashings: []*phase0.AttesterSlashing{},
+				Attestations:      make([]*phase0.Attestation, attestations),
+				Deposits:          []*phase0.Deposit{},
+				VoluntaryExits:    []*phase0.SignedVoluntaryExit{},
+				SyncAggregate: &altair.SyncAggregate{
+					SyncCommitteeBits: bitfield.NewBitvector512(),
+				},
+				ExecutionPayload: &capella.ExecutionPayload{
+					BlockNumber:  17000000,
+					GasLimit:     30000000,
+					GasUsed:      15000000,
+					Timestamp:    1680000000,
+					ExtraData:    []byte("chaind"),
+					Transactions: make([]bellatrix.Transaction, transactions),
+					Withdrawals:  make([]*capella.Withdrawal, 16),
+				},
+				BLSToExecutionChanges: []*capella.SignedBLSToExecutionChange{},
+			},
+		},
+	}
+	for i := range block.Message.Body.Attestations {
+		bits := bitfield.NewBitlist(400)
+		for j := uint64(0); j < 400; j += 2 {
+			bits.SetBitAt(j, true)
+		}
+		block.Message.Body.Attestations[i] = &phase0.Attestation{
+			AggregationBits: bits,
+			Data: &phase0.AttestationData{
+				Slot:            slot - 1,
+				Index:           phase0.CommitteeIndex(i % 64),
+				BeaconBlockRoot: phase0.Root{byte(i)},
+				Source:          &phase0.Checkpoint{Epoch: 1},
+				Target:          &phase0.Checkpoint{Epoch: 2},
+			},
+		}
+	}
+	for i := range block.Message.Body.ExecutionPayload.Transactions {
+		tx := make([]byte, 500)
+		tx[0] = byte(i)
+		block.Message.Body.ExecutionPayload.Transactions[i] = tx
+	}
+	for i := range block.Message.Body.ExecutionPayload.Withdrawals {
+		block.Message.Body.ExecutionPayload.Withdrawals[i] = &capella.Withdrawal{
+			Index:          capella.WithdrawalIndex(i),
+			ValidatorIndex: phase0.ValidatorIndex(i),
+			Amount:         12345678,
+		}
+	}
+
+	return block
+}
+
+// testPhase0State creates a minimal phase 0 state at the given slot.
+func testPhase0State(slot phase0.Slot) *phase0.BeaconState {
+	return &phase0.BeaconState{
+		Slot:                        slot,
+		Fork:                        &phase0.Fork{},
+		LatestBlockHeader:           &phase0.BeaconBlockHeader{},
+		BlockRoots:                  make([]phase0.Root, 8192),
+		StateRoots:                  make([]phase0.Root, 8192),
+		HistoricalRoots:             []phase0.Root{},
+		ETH1Data:                    &phase0.ETH1Data{BlockHash: make([]byte, 32)},
+		ETH1DataVotes:               []*phase0.ETH1Data{},
+		Validators:                  []*phase0.Validator{},
+		Balances:                    []phase0.Gwei{},
+		RANDAOMixes:                 make([]phase0.Root, 65536),
+		Slashings:                   make([]phase0.Gwei, 8192),
+		PreviousEpochAttestations:   []*phase0.PendingAttestation{},
+		CurrentEpochAttestations:    []*phase0.PendingAttestation{},
+		JustificationBits:           bitfield.NewBitvector4(),
+		PreviousJustifiedCheckpoint: &phase0.Checkpoint{},
+		CurrentJustifiedCheckpoint:  &phase0.Checkpoint{},
+		FinalizedCheckpoint:         &phase0.Checkpoint{},
+	}
+}
+
+// testNode is a beacon node that serves a single object.
+type testNode struct {
+	ssz      []byte
+	json     []byte
+	version  string
+	sszOK    bool
+	strict   bool
+	accepted []string
+}
+
+func (n *testNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	accept := r.Header.Get("Accept")
+	n.accepted = append(n.accepted, accept)
+	if strings.HasSuffix(r.URL.Path, "/missing") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if n.version != "" {
+		w.Header().Set("Eth-Consensus-Version", n.version)
+	}
+	if n.sszOK && strings.HasPrefix(accept, contentTypeSSZ) {
+		w.Header().Set("Content-Type", contentTypeSSZ)
+		_, _ = w.Write(n.ssz)
+		return
+	}
+	if n.strict && strings.Contains(accept, contentTypeSSZ) {
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(n.json)
+}
+
+func newTestService(t *testing.T, node *testNode, encoding Encoding) *Service {
+	t.Helper()
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	s, err := New(context.Background(),
+		WithLogLevel(zerolog.Disabled),
+		WithAddress(server.URL),
+		WithEncoding(encoding),
+		WithChainTime(&forkChainTime{Service: mockchaintime.New()}),
+	)
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestParseEncoding(t *testing.T) {
+	for _, input := range []string{"auto", "SSZ", "json"} {
+		encoding, err := ParseEncoding(input)
+		require.NoError(t, err)
+		require.Equal(t, strings.ToLower(input), encoding.String())
+	}
+	_, err := ParseEncoding("yaml")
+	require.EqualError(t, err, `unrecognised encoding "yaml"`)
+}
+
+func TestVersionAtSlot(t *testing.T) {
+	s := &Service{chainTime: &forkChainTime{Service: mockchaintime.New()}}
+	require.Equal(t, spec.DataVersionPhase0, s.versionAtSlot(31))
+	require.Equal(t, spec.DataVersionAltair, s.versionAtSlot(32))
+	require.Equal(t, spec.DataVersionBellatrix, s.versionAtSlot(64))
+	require.Equal(t, spec.DataVersionCapella, s.versionAtSlot(96))
+	require.Equal(t, spec.DataVersionCapella, s.versionAtSlot(1000000))
+}
+
+func TestSignedBeaconBlock(t *testing.T) {
+	block := testCapellaBlock(100, 4, 4)
+	sszData, err := block.MarshalSSZ()
+	require.NoError(t, err)
+	blockJSON, err := json.Marshal(block)
+	require.NoError(t, err)
+	envelope := []byte(fmt.Sprintf(`{"version":"capella","data":%s}`, string(blockJSON)))
+	bareEnvelope := []byte(fmt.Sprintf(`{"data":%s}`, string(blockJSON)))
+
+	tests := []struct {
+		name     string
+		node     *testNode
+		encoding Encoding
+		accepts  []string
+	}{
+		{
+			name:     "SSZWithHeader",
+			node:     &testNode{ssz: sszData, version: "capella", sszOK: true},
+			encoding: EncodingAuto,
+			accepts:  []string{acceptAuto, acceptAuto},
+		},
+		{
+			name:     "SSZWithoutHeader",
+			node:     &testNode{ssz: sszData, sszOK: true},
+			encoding: EncodingSSZ,
+			accepts:  []string{acceptSSZ, acceptSSZ},
+		},
+		{
+			name:     "JSONFallback",
+			node:     &testNode{json: envelope},
+			encoding: EncodingAuto,
+			accepts:  []string{acceptAuto, acceptJSON},
+		},
+		{
+			name:     "JSONFallbackNotAcceptable",
+			node:     &testNode{json: envelope, strict: true},
+			encoding: EncodingAuto,
+			accepts:  []string{acceptAuto, acceptJSON, acceptJSON},
+		},
+		{
+			name:     "JSONWithoutVersion",
+			node:     &testNode{json: bareEnvelope},
+			encoding: EncodingJSON,
+			accepts:  []string{acceptJSON, acceptJSON},
+		},
+		{
+			name:     "JSONOverride",
+			node:     &testNode{ssz: sszData, json: envelope, sszOK: true},
+			encoding: EncodingJSON,
+			accepts:  []string{acceptJSON, acceptJSON},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestService(t, test.node, test.encoding)
+			for i := 0; i < 2; i++ {
+				res, err := s.SignedBeaconBlock(context.Background(), "100")
+				require.NoError(t, err)
+				require.Equal(t, spec.DataVersionCapella, res.Version)
+				require.Equal(t, block, res.Capella)
+			}
+			require.Equal(t, test.accepts, test.node.accepted)
+		})
+	}
+}
+
+func TestSignedBeaconBlockMissing(t *testing.T) {
+	s := newTestService(t, &testNode{}, EncodingAuto)
+	res, err := s.SignedBeaconBlock(context.Background(), "missing")
+	require.NoError(t, err)
+	require.Nil(t, res)
+}
+
+func TestSignedBeaconBlockSSZRefused(t *testing.T) {
+	s := newTestService(t, &testNode{strict: true}, EncodingSSZ)
+	_, err := s.SignedBeaconBlock(context.Background(), "100")
+	require.ErrorContains(t, err, "status 406")
+}
+
+func TestBeaconState(t *testing.T) {
+	state := testPhase0State(20)
+	sszData, err := state.MarshalSSZ()
+	require.NoError(t, err)
+
+	s := newTestService(t, &testNode{ssz: sszData, sszOK: true}, EncodingAuto)
+	res, err := s.BeaconState(context.Background(), "head")
+	require.NoError(t, err)
+	require.Equal(t, spec.DataVersionPhase0, res.Version)
+	require.Equal(t, state.Slot, res.Phase0.Slot)
+	require.Len(t, res.Phase0.RANDAOMixes, 65536)
+}
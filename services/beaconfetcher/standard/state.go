@@ -0,0 +1,105 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BeaconState fetches a beacon state given a state ID.
+// N.B if a beacon state for the state ID is not available this will return nil without an error.
+func (s *Service) BeaconState(ctx context.Context, stateID string) (*spec.VersionedBeaconState, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.beaconfetcher.standard").Start(ctx, "BeaconState",
+		trace.WithAttributes(
+			attribute.String("state_id", stateID),
+		))
+	defer span.End()
+
+	res, err := s.fetch(ctx, fmt.Sprintf("/eth/v2/debug/beacon/states/%s", stateID))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to request beacon state")
+	}
+	if res.statusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	return s.decodeBeaconState(res)
+}
+
+func (s *Service) decodeBeaconState(res *response) (*spec.VersionedBeaconState, error) {
+	var version spec.DataVersion
+	var data []byte
+	var err error
+	switch res.contentType {
+	case contentTypeSSZ:
+		version, err = s.sszVersion(res, sszStateSlotOffset)
+		data = res.body
+	case contentTypeJSON:
+		envelope := &jsonEnvelope{}
+		if err := json.Unmarshal(res.body, envelope); err != nil {
+			return nil, errors.Wrap(err, "failed to parse JSON response")
+		}
+		version, err = s.jsonVersion(res, envelope, jsonStateSlot)
+		data = envelope.Data
+	default:
+		return nil, unsupportedContentType(res)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to establish state version")
+	}
+
+	state := &spec.VersionedBeaconState{
+		Version: version,
+	}
+	var target decodable
+	switch version {
+	case spec.DataVersionPhase0:
+		state.Phase0 = &phase0.BeaconState{}
+		target = state.Phase0
+	case spec.DataVersionAltair:
+		state.Altair = &altair.BeaconState{}
+		target = state.Altair
+	case spec.DataVersionBellatrix:
+		state.Bellatrix = &bellatrix.BeaconState{}
+		target = state.Bellatrix
+	case spec.DataVersionCapella:
+		state.Capella = &capella.BeaconState{}
+		target = state.Capella
+	case spec.DataVersionDeneb:
+		state.Deneb = &deneb.BeaconState{}
+		target = state.Deneb
+	default:
+		return nil, fmt.Errorf("unhandled state version %v", version)
+	}
+
+	if err := decode(res.contentType, data, target); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to decode %v beacon state", version))
+	}
+
+	return state, nil
+}
@@ -0,0 +1,138 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+const (
+	// sszBlockSlotOffset is the offset of the slot in an SSZ-encoded signed
+	// beacon block: a 4-byte offset to the message and the 96-byte signature
+	// precede it, and the slot is the first field of the message.
+	sszBlockSlotOffset = 4 + 96
+	// sszStateSlotOffset is the offset of the slot in an SSZ-encoded beacon
+	// state, following the genesis time and genesis validators root.
+	sszStateSlotOffset = 8 + 32
+)
+
+// jsonEnvelope is the envelope around versioned JSON responses.
+type jsonEnvelope struct {
+	Version string          `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// versionAtSlot returns the fork version in force at the given slot.
+func (s *Service) versionAtSlot(slot phase0.Slot) spec.DataVersion {
+	epoch := s.chainTime.SlotToEpoch(slot)
+	switch {
+	case epoch >= s.chainTime.DenebInitialEpoch():
+		return spec.DataVersionDeneb
+	case epoch >= s.chainTime.CapellaInitialEpoch():
+		return spec.DataVersionCapella
+	case epoch >= s.chainTime.BellatrixInitialEpoch():
+		return spec.DataVersionBellatrix
+	case epoch >= s.chainTime.AltairInitialEpoch():
+		return spec.DataVersionAltair
+	default:
+		return spec.DataVersionPhase0
+	}
+}
+
+// parseVersion parses a fork version name, as supplied by the beacon node.
+func parseVersion(input string) (spec.DataVersion, error) {
+	var version spec.DataVersion
+	if err := version.UnmarshalJSON([]byte(strconv.Quote(input))); err != nil {
+		return spec.DataVersionUnknown, err
+	}
+
+	return version, nil
+}
+
+// sszVersion returns the version of an SSZ response, using the consensus
+// version header if present or else the fork in force at the slot found at
+// the given offset in the body.
+func (s *Service) sszVersion(res *response, slotOffset int) (spec.DataVersion, error) {
+	if res.consensusVersion != "" {
+		return parseVersion(res.consensusVersion)
+	}
+	if len(res.body) < slotOffset+8 {
+		return spec.DataVersionUnknown, errors.New("response too short to contain slot")
+	}
+	slot := phase0.Slot(binary.LittleEndian.Uint64(res.body[slotOffset : slotOffset+8]))
+
+	return s.versionAtSlot(slot), nil
+}
+
+// jsonVersion returns the version of a JSON response, using the version in
+// the envelope or the consensus version header if present, or else the
+// fork in force at the slot obtained from the data.
+func (s *Service) jsonVersion(res *response, envelope *jsonEnvelope, slotFunc func(json.RawMessage) (phase0.Slot, error)) (spec.DataVersion, error) {
+	if envelope.Version != "" {
+		return parseVersion(envelope.Version)
+	}
+	if res.consensusVersion != "" {
+		return parseVersion(res.consensusVersion)
+	}
+	slot, err := slotFunc(envelope.Data)
+	if err != nil {
+		return spec.DataVersionUnknown, err
+	}
+
+	return s.versionAtSlot(slot), nil
+}
+
+func jsonBlockSlot(data json.RawMessage) (phase0.Slot, error) {
+	var block struct {
+		Message struct {
+			Slot string `json:"slot"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(data, &block); err != nil {
+		return 0, errors.Wrap(err, "failed to parse block slot")
+	}
+	slot, err := strconv.ParseUint(block.Message.Slot, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid block slot")
+	}
+
+	return phase0.Slot(slot), nil
+}
+
+func jsonStateSlot(data json.RawMessage) (phase0.Slot, error) {
+	var state struct {
+		Slot string `json:"slot"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return 0, errors.Wrap(err, "failed to parse state slot")
+	}
+	slot, err := strconv.ParseUint(state.Slot, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid state slot")
+	}
+
+	return phase0.Slot(slot), nil
+}
+
+// unsupportedContentType returns an error for a response in an unexpected encoding.
+func unsupportedContentType(res *response) error {
+	return fmt.Errorf("unhandled content type %q", res.contentType)
+}
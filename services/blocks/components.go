@@ -0,0 +1,61 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks
+
+// Component is a component of a block that is stored separately from the block itself.
+type Component int
+
+const (
+	// ComponentAttestations is the attestations in a block.
+	ComponentAttestations Component = iota + 1
+	// ComponentDeposits is the deposits in a block.
+	ComponentDeposits
+	// ComponentVoluntaryExits is the voluntary exits in a block.
+	ComponentVoluntaryExits
+	// ComponentSlashings is the proposer and attester slashings in a block.
+	ComponentSlashings
+	// ComponentSyncAggregates is the sync aggregate in a block.
+	ComponentSyncAggregates
+	// ComponentWithdrawals is the withdrawals in a block's execution payload.
+	ComponentWithdrawals
+)
+
+var componentStrings = map[Component]string{
+	ComponentAttestations:   "attestations",
+	ComponentDeposits:       "deposits",
+	ComponentVoluntaryExits: "voluntary exits",
+	ComponentSlashings:      "slashings",
+	ComponentSyncAggregates: "sync aggregates",
+	ComponentWithdrawals:    "withdrawals",
+}
+
+// String returns a string representation of the component.
+func (c Component) String() string {
+	if str, exists := componentStrings[c]; exists {
+		return str
+	}
+
+	return "unknown"
+}
+
+// StoredComponents states which components of a block are stored.
+// Components that are not present in the map are stored.
+type StoredComponents map[Component]bool
+
+// Stored returns true if the component is stored.
+func (s StoredComponents) Stored(component Component) bool {
+	stored, exists := s[component]
+
+	return !exists || stored
+}
@@ -0,0 +1,78 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// arrivalRetentionSlots is the number of slots for which block arrival times
+// are held awaiting their block being stored.
+const arrivalRetentionSlots = 64
+
+// blockArrival is the time at which a block was first seen.
+type blockArrival struct {
+	slot phase0.Slot
+	seen time.Time
+}
+
+// blockArrivals tracks the times at which blocks were first seen on the event stream.
+// The zero value is ready to use.
+type blockArrivals struct {
+	mu       sync.Mutex
+	arrivals map[phase0.Root]*blockArrival
+}
+
+// record records the time at which a block was seen, if it has not already been seen.
+func (b *blockArrivals) record(slot phase0.Slot, root phase0.Root, seen time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.arrivals == nil {
+		b.arrivals = make(map[phase0.Root]*blockArrival)
+	}
+	if _, exists := b.arrivals[root]; exists {
+		return
+	}
+	b.arrivals[root] = &blockArrival{
+		slot: slot,
+		seen: seen,
+	}
+
+	// Remove arrivals for blocks that have not been stored in a reasonable time.
+	if slot > arrivalRetentionSlots {
+		for arrivalRoot, arrival := range b.arrivals {
+			if arrival.slot < slot-arrivalRetentionSlots {
+				delete(b.arrivals, arrivalRoot)
+			}
+		}
+	}
+}
+
+// take returns the time at which a block was first seen, removing it from the tracker.
+func (b *blockArrivals) take(root phase0.Root) (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	arrival, exists := b.arrivals[root]
+	if !exists {
+		return time.Time{}, false
+	}
+	delete(b.arrivals, root)
+
+	return arrival.seen, true
+}
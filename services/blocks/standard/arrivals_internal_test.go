@@ -0,0 +1,54 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockArrivals(t *testing.T) {
+	arrivals := &blockArrivals{}
+	now := time.Now()
+
+	// Unknown block.
+	_, exists := arrivals.take(phase0.Root{0x01})
+	require.False(t, exists)
+
+	// Only the first sighting is recorded.
+	arrivals.record(100, phase0.Root{0x01}, now)
+	arrivals.record(100, phase0.Root{0x01}, now.Add(time.Second))
+	seen, exists := arrivals.take(phase0.Root{0x01})
+	require.True(t, exists)
+	require.Equal(t, now, seen)
+
+	// Taken blocks are removed.
+	_, exists = arrivals.take(phase0.Root{0x01})
+	require.False(t, exists)
+
+	// Old arrivals are pruned.
+	arrivals.record(100, phase0.Root{0x02}, now)
+	arrivals.record(100+arrivalRetentionSlots, phase0.Root{0x03}, now)
+	_, exists = arrivals.take(phase0.Root{0x02})
+	require.True(t, exists)
+	arrivals.record(100, phase0.Root{0x02}, now)
+	arrivals.record(101+arrivalRetentionSlots, phase0.Root{0x04}, now)
+	_, exists = arrivals.take(phase0.Root{0x02})
+	require.False(t, exists)
+	_, exists = arrivals.take(phase0.Root{0x03})
+	require.True(t, exists)
+}
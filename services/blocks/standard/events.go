@@ -0,0 +1,211 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// eventTopics are the beacon node event topics consumed by the service.
+var eventTopics = []string{"head", "block", "chain_reorg", "finalized_checkpoint"}
+
+// streamStaleSlots is the number of slots without an event after which
+// the event stream is considered to be disconnected.
+const streamStaleSlots = 2
+
+// handleEvent handles an event from the beacon node's event stream.
+func (s *Service) handleEvent(ctx context.Context, event *api.Event) {
+	if event.Data == nil {
+		// Happens when the channel shuts down, nothing to worry about.
+		return
+	}
+
+	received := time.Now()
+	lastEvent := time.Unix(0, s.lastEventTime.Swap(received.UnixNano()))
+	monitorEventReceived(event.Topic)
+	if !s.streamConnected.Swap(true) {
+		log.Info().Time("last_event", lastEvent).Msg("Event stream connected")
+		monitorEventStreamConnected(true)
+	}
+
+	switch event.Topic {
+	case "head":
+		eventData := event.Data.(*api.HeadEvent)
+		s.arrivals.record(eventData.Slot, eventData.Block, received)
+		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
+	case "block":
+		eventData := event.Data.(*api.BlockEvent)
+		s.arrivals.record(eventData.Slot, eventData.Block, received)
+		s.OnBlockEvent(ctx, eventData.Slot, eventData.Block)
+	case "chain_reorg":
+		eventData := event.Data.(*api.ChainReorgEvent)
+		s.OnChainReorg(ctx, eventData.Slot, eventData.Depth)
+	case "finalized_checkpoint":
+		// Finality is handled by the finalizer; this event only informs stream health.
+		log.Trace().Msg("Received finalized checkpoint event")
+	default:
+		log.Debug().Str("topic", event.Topic).Msg("Unhandled event topic")
+	}
+}
+
+// OnBlockEvent receives block notifications.
+func (s *Service) OnBlockEvent(ctx context.Context, slot phase0.Slot, blockRoot phase0.Root) {
+	log := log.With().Uint64("slot", uint64(slot)).Str("block_root", fmt.Sprintf("%#x", blockRoot)).Logger()
+
+	// Only allow 1 handler to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another handler (either blocks or finalizer) running")
+		return
+	}
+	defer s.activitySem.Release(1)
+	log.Trace().Msg("Handler called")
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+
+	s.catchup(ctx, md)
+}
+
+// OnChainReorg receives chain reorganisation notifications.
+// Slots affected by the reorganisation are refetched, to pick up any new blocks.
+func (s *Service) OnChainReorg(ctx context.Context, slot phase0.Slot, depth uint64) {
+	log := log.With().Uint64("slot", uint64(slot)).Uint64("depth", depth).Logger()
+
+	// Only allow 1 handler to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another handler (either blocks or finalizer) running")
+		return
+	}
+	defer s.activitySem.Release(1)
+	log.Debug().Msg("Chain reorganisation; refetching affected slots")
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+
+	startSlot := phase0.Slot(0)
+	if uint64(slot) >= depth {
+		startSlot = slot - phase0.Slot(depth)
+	}
+	for refetchSlot := startSlot; refetchSlot <= slot && int64(refetchSlot) <= md.LatestSlot; refetchSlot++ {
+		if err := s.refetchSlot(ctx, refetchSlot); err != nil {
+			log.Error().Uint64("refetch_slot", uint64(refetchSlot)).Err(err).Msg("Failed to refetch slot")
+			return
+		}
+	}
+
+	// Fetch anything beyond our latest slot in the usual fashion.
+	s.catchup(ctx, md)
+}
+
+// refetchSlot fetches and stores the block for the given slot, regardless of
+// whether a block for the slot is already present.
+func (s *Service) refetchSlot(ctx context.Context, slot phase0.Slot) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.fetchBlockForSlot(ctx, slot); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to update block")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// subscribeEvents subscribes to the beacon node's event stream, and starts
+// polling for blocks whenever the stream goes quiet.
+func (s *Service) subscribeEvents(ctx context.Context) error {
+	s.lastEventTime.Store(time.Now().UnixNano())
+	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, eventTopics, func(event *api.Event) {
+		s.handleEvent(ctx, event)
+	}); err != nil {
+		return err
+	}
+
+	go s.pollWhileStreamStale(ctx)
+
+	return nil
+}
+
+// pollWhileStreamStale polls for blocks each slot for as long as the event
+// stream is not providing events.
+// Once the stream returns, catchup from the stored metadata fills any gap.
+func (s *Service) pollWhileStreamStale(ctx context.Context) {
+	slotDuration := s.chainTime.SlotDuration()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debug().Msg("Context done")
+			return
+		case <-time.After(time.Until(s.chainTime.StartOfSlot(s.chainTime.CurrentSlot() + 1))):
+		}
+
+		age := time.Since(time.Unix(0, s.lastEventTime.Load()))
+		monitorEventStreamAge(age)
+		if age < streamStaleSlots*slotDuration {
+			continue
+		}
+
+		if s.streamConnected.Swap(false) {
+			log.Warn().Dur("last_event_age", age).Msg("Event stream stale; falling back to polling")
+			monitorEventStreamConnected(false)
+		}
+		s.poll(ctx)
+	}
+}
+
+// poll fetches any blocks we do not yet have.
+func (s *Service) poll(ctx context.Context) {
+	// Only allow 1 handler to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another handler (either blocks or finalizer) running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+
+	s.catchup(ctx, md)
+}
+
+// Ready returns true if the service is receiving events from the beacon node.
+func (s *Service) Ready(_ context.Context) bool {
+	return s.streamConnected.Load()
+}
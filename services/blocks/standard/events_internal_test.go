@@ -0,0 +1,289 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"golang.org/x/sync/semaphore"
+)
+
+// eventSource is a beacon node that hands out blocks for no slot, recording
+// the slots requested and providing events on demand.
+type eventSource struct {
+	eth2client.Service
+	mu      sync.Mutex
+	handler eth2client.EventHandlerFunc
+	fetched []phase0.Slot
+}
+
+func (e *eventSource) Events(_ context.Context, _ []string, handler eth2client.EventHandlerFunc) error {
+	e.mu.Lock()
+	e.handler = handler
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *eventSource) SignedBeaconBlock(_ context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	slot, err := strconv.ParseUint(blockID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	e.fetched = append(e.fetched, phase0.Slot(slot))
+	e.mu.Unlock()
+	return nil, nil
+}
+
+func (e *eventSource) send(event *api.Event) {
+	e.mu.Lock()
+	handler := e.handler
+	e.mu.Unlock()
+	handler(event)
+}
+
+func (e *eventSource) fetchedSlots() []phase0.Slot {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]phase0.Slot{}, e.fetched...)
+}
+
+// eventsChainDB is a chain database holding no blocks and in-memory metadata.
+type eventsChainDB struct {
+	chaindb.Service
+	chaindb.BlocksProvider
+	mu       sync.Mutex
+	metadata map[string][]byte
+}
+
+func (c *eventsChainDB) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {}, nil
+}
+
+func (*eventsChainDB) CommitTx(_ context.Context) error {
+	return nil
+}
+
+func (c *eventsChainDB) Metadata(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.metadata[key], nil
+}
+
+func (c *eventsChainDB) SetMetadata(_ context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.metadata[key] = value
+	return nil
+}
+
+func (*eventsChainDB) BlocksBySlot(_ context.Context, _ phase0.Slot) ([]*chaindb.Block, error) {
+	return nil, nil
+}
+
+// eventsChainTime is a chain time with short slots and a settable current slot.
+type eventsChainTime struct {
+	chaintime.Service
+	mu          sync.Mutex
+	currentSlot phase0.Slot
+}
+
+func (*eventsChainTime) SlotDuration() time.Duration {
+	return 10 * time.Millisecond
+}
+
+func (*eventsChainTime) StartOfSlot(_ phase0.Slot) time.Time {
+	// Always the next slot boundary, so the poller wakes once per slot.
+	return time.Now().Add(10 * time.Millisecond)
+}
+
+func (c *eventsChainTime) CurrentSlot() phase0.Slot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.currentSlot
+}
+
+func (c *eventsChainTime) setCurrentSlot(slot phase0.Slot) {
+	c.mu.Lock()
+	c.currentSlot = slot
+	c.mu.Unlock()
+}
+
+func newEventsService(t *testing.T, latestSlot int64, currentSlot phase0.Slot) (*Service, *eventSource, *eventsChainTime) {
+	t.Helper()
+
+	source := &eventSource{}
+	chainDB := &eventsChainDB{
+		metadata: map[string][]byte{
+			metadataKey: []byte(fmt.Sprintf(`{"latest_slot":%d}`, latestSlot)),
+		},
+	}
+	chainTime := &eventsChainTime{currentSlot: currentSlot}
+
+	return &Service{
+		eth2Client:                source,
+		signedBeaconBlockProvider: source,
+		chainDB:                   chainDB,
+		chainTime:                 chainTime,
+		activitySem:               semaphore.NewWeighted(1),
+	}, source, chainTime
+}
+
+func storedLatestSlot(ctx context.Context, t *testing.T, s *Service) int64 {
+	t.Helper()
+	md, err := s.getMetadata(ctx)
+	require.NoError(t, err)
+	return md.LatestSlot
+}
+
+func TestHandleEventConnectsStream(t *testing.T) {
+	ctx := context.Background()
+	s, source, _ := newEventsService(t, 10, 12)
+	require.False(t, s.Ready(ctx))
+
+	// Empty events do not count towards stream health.
+	s.handleEvent(ctx, &api.Event{Topic: "head"})
+	require.False(t, s.Ready(ctx))
+
+	s.handleEvent(ctx, &api.Event{
+		Topic: "head",
+		Data: &api.HeadEvent{
+			Slot:  12,
+			Block: phase0.Root{0x01},
+		},
+	})
+	require.True(t, s.Ready(ctx))
+
+	// Arrival of the block is recorded.
+	_, exists := s.arrivals.take(phase0.Root{0x01})
+	require.True(t, exists)
+
+	// Catchup fetches the slots since the stored latest slot.
+	require.Equal(t, []phase0.Slot{11, 12}, source.fetchedSlots())
+	require.Equal(t, int64(12), storedLatestSlot(ctx, t, s))
+}
+
+func TestPollWhileStreamStale(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, source, chainTime := newEventsService(t, 10, 10)
+	require.NoError(t, s.subscribeEvents(ctx))
+	source.send(&api.Event{
+		Topic: "block",
+		Data: &api.BlockEvent{
+			Slot:  10,
+			Block: phase0.Root{0x01},
+		},
+	})
+	require.True(t, s.Ready(ctx))
+
+	// The stream goes quiet for more than the stale period whilst the chain moves on.
+	s.lastEventTime.Store(time.Now().Add(-streamStaleSlots * s.chainTime.SlotDuration()).UnixNano())
+	chainTime.setCurrentSlot(13)
+	require.Eventually(t, func() bool {
+		return !s.Ready(ctx)
+	}, time.Second, 5*time.Millisecond)
+
+	// Polling fetches the slots that were missed.
+	require.Eventually(t, func() bool {
+		return storedLatestSlot(ctx, t, s) == 13
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, []phase0.Slot{11, 12, 13}, source.fetchedSlots())
+}
+
+func TestGapFilledOnReconnect(t *testing.T) {
+	ctx := context.Background()
+
+	// The stream has been down, and the service is behind the chain.
+	s, source, _ := newEventsService(t, 10, 15)
+	require.False(t, s.Ready(ctx))
+
+	s.handleEvent(ctx, &api.Event{
+		Topic: "block",
+		Data: &api.BlockEvent{
+			Slot:  15,
+			Block: phase0.Root{0x01},
+		},
+	})
+	require.True(t, s.Ready(ctx))
+	require.Equal(t, []phase0.Slot{11, 12, 13, 14, 15}, source.fetchedSlots())
+	require.Equal(t, int64(15), storedLatestSlot(ctx, t, s))
+}
+
+func TestChainReorg(t *testing.T) {
+	tests := []struct {
+		name        string
+		latestSlot  int64
+		currentSlot phase0.Slot
+		slot        phase0.Slot
+		depth       uint64
+		fetched     []phase0.Slot
+	}{
+		{
+			name:        "Refetch",
+			latestSlot:  20,
+			currentSlot: 20,
+			slot:        20,
+			depth:       2,
+			fetched:     []phase0.Slot{18, 19, 20},
+		},
+		{
+			name:        "RefetchAndCatchup",
+			latestSlot:  19,
+			currentSlot: 21,
+			slot:        20,
+			depth:       2,
+			fetched:     []phase0.Slot{18, 19, 20, 21},
+		},
+		{
+			name:        "DepthBeyondGenesis",
+			latestSlot:  2,
+			currentSlot: 2,
+			slot:        2,
+			depth:       5,
+			fetched:     []phase0.Slot{0, 1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, source, _ := newEventsService(t, test.latestSlot, test.currentSlot)
+
+			s.handleEvent(ctx, &api.Event{
+				Topic: "chain_reorg",
+				Data: &api.ChainReorgEvent{
+					Slot:  test.slot,
+					Depth: test.depth,
+				},
+			})
+			require.True(t, s.Ready(ctx))
+			require.Equal(t, test.fetched, source.fetchedSlots())
+			require.Equal(t, int64(test.currentSlot), storedLatestSlot(ctx, t, s))
+		})
+	}
+}
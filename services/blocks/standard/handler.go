@@ -27,6 +27,7 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/deneb"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/blocks"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -144,8 +145,15 @@ func (s *Service) updateBlockForSlot(ctx context.Context, slot phase0.Slot) erro
 	}
 	span.AddEvent("Checked for block")
 
+	return s.fetchBlockForSlot(ctx, slot)
+}
+
+// fetchBlockForSlot fetches the block for the given slot from the beacon node and stores it.
+func (s *Service) fetchBlockForSlot(ctx context.Context, slot phase0.Slot) error {
+	log := log.With().Uint64("slot", uint64(slot)).Logger()
+
 	log.Trace().Msg("Updating block for slot")
-	signedBlock, err := s.eth2Client.(eth2client.SignedBeaconBlockProvider).SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
+	signedBlock, err := s.signedBeaconBlockProvider.SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain beacon block for slot")
 	}
@@ -153,7 +161,6 @@ func (s *Service) updateBlockForSlot(ctx context.Context, slot phase0.Slot) erro
 		log.Debug().Msg("No beacon block obtained for slot")
 		return nil
 	}
-	span.AddEvent("Obtained block")
 
 	return s.OnBlock(ctx, signedBlock)
 }
@@ -169,6 +176,18 @@ func (s *Service) OnBlock(ctx context.Context, signedBlock *spec.VersionedSigned
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain database block")
 	}
+	sszSize, err := blockSSZSize(signedBlock)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain block size")
+	}
+	dbBlock.SSZSize = &sszSize
+	if seen, exists := s.arrivals.take(dbBlock.Root); exists {
+		arrivalDelay := seen.Sub(s.chainTime.StartOfSlot(dbBlock.Slot))
+		dbBlock.ArrivalDelay = &arrivalDelay
+	}
+	if dbBlock.ExecutionPayload != nil && !s.storedComponents.Stored(blocks.ComponentWithdrawals) {
+		dbBlock.ExecutionPayload.Withdrawals = nil
+	}
 	if err := s.blocksSetter.SetBlock(ctx, dbBlock); err != nil {
 		return errors.Wrap(err, "failed to set block")
 	}
@@ -407,6 +426,10 @@ func (s *Service) updateAttestationsForBlock(ctx context.Context,
 	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateAttestationsForBlock")
 	defer span.End()
 
+	if !s.storedComponents.Stored(blocks.ComponentAttestations) {
+		return nil
+	}
+
 	var err error
 	// Fetch all of the beacon committees we commonly need up front.
 	// Others are fetched as required.
@@ -455,6 +478,10 @@ func (s *Service) updateProposerSlashingsForBlock(ctx context.Context,
 	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateProposerSlashingsForBlock")
 	defer span.End()
 
+	if !s.storedComponents.Stored(blocks.ComponentSlashings) {
+		return nil
+	}
+
 	for i, proposerSlashing := range proposerSlashings {
 		dbProposerSlashing, err := s.dbProposerSlashing(ctx, slot, blockRoot, uint64(i), proposerSlashing)
 		if err != nil {
@@ -475,6 +502,10 @@ func (s *Service) updateAttesterSlashingsForBlock(ctx context.Context,
 	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateAttesterSlashingsForBlock")
 	defer span.End()
 
+	if !s.storedComponents.Stored(blocks.ComponentSlashings) {
+		return nil
+	}
+
 	for i, attesterSlashing := range attesterSlashings {
 		dbAttesterSlashing := s.dbAttesterSlashing(ctx, slot, blockRoot, uint64(i), attesterSlashing)
 		if err := s.attesterSlashingsSetter.SetAttesterSlashing(ctx, dbAttesterSlashing); err != nil {
@@ -492,6 +523,10 @@ func (s *Service) updateDepositsForBlock(ctx context.Context,
 	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateDepositssForBlock")
 	defer span.End()
 
+	if !s.storedComponents.Stored(blocks.ComponentDeposits) {
+		return nil
+	}
+
 	for i, deposit := range deposits {
 		dbDeposit := s.dbDeposit(ctx, slot, blockRoot, uint64(i), deposit)
 		if err := s.depositsSetter.SetDeposit(ctx, dbDeposit); err != nil {
@@ -509,6 +544,10 @@ func (s *Service) updateVoluntaryExitsForBlock(ctx context.Context,
 	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateVoluntaryExitsForBlock")
 	defer span.End()
 
+	if !s.storedComponents.Stored(blocks.ComponentVoluntaryExits) {
+		return nil
+	}
+
 	for i, voluntaryExit := range voluntaryExits {
 		dbVoluntaryExit := s.dbVoluntaryExit(ctx, slot, blockRoot, uint64(i), voluntaryExit)
 		if err := s.voluntaryExitsSetter.SetVoluntaryExit(ctx, dbVoluntaryExit); err != nil {
@@ -526,6 +565,10 @@ func (s *Service) updateSyncAggregateForBlock(ctx context.Context,
 	ctx, span := otel.Tracer("wealdtech.chaind.services.blocks.standard").Start(ctx, "updateSyncAggregateForBlock")
 	defer span.End()
 
+	if !s.storedComponents.Stored(blocks.ComponentSyncAggregates) {
+		return nil
+	}
+
 	dbSyncAggregate, err := s.dbSyncAggregate(ctx, slot, blockRoot, syncAggregate)
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain database sync aggregate")
@@ -537,6 +580,26 @@ func (s *Service) updateSyncAggregateForBlock(ctx context.Context,
 	return nil
 }
 
+// blockSSZSize returns the size of the SSZ-encoded signed block.
+func blockSSZSize(block *spec.VersionedSignedBeaconBlock) (uint64, error) {
+	switch block.Version {
+	case spec.DataVersionPhase0:
+		return uint64(block.Phase0.SizeSSZ()), nil
+	case spec.DataVersionAltair:
+		return uint64(block.Altair.SizeSSZ()), nil
+	case spec.DataVersionBellatrix:
+		return uint64(block.Bellatrix.SizeSSZ()), nil
+	case spec.DataVersionCapella:
+		return uint64(block.Capella.SizeSSZ()), nil
+	case spec.DataVersionDeneb:
+		return uint64(block.Deneb.SizeSSZ()), nil
+	case spec.DataVersionUnknown:
+		return 0, errors.New("unknown block version")
+	default:
+		return 0, fmt.Errorf("unhandled block version %v", block.Version)
+	}
+}
+
 func (s *Service) dbBlock(
 	ctx context.Context,
 	block *spec.VersionedSignedBeaconBlock,
@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 )
 
@@ -33,6 +34,10 @@ func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	md := &metadata{
 		LatestSlot: -1,
 	}
+	if s.origin != nil {
+		// Do not fetch blocks from before the ingestion origin.
+		md.LatestSlot = int64(s.origin.Slot) - 1
+	}
 	mdJSON, err := s.chainDB.Metadata(ctx, metadataKey)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to fetch metadata")
@@ -57,3 +62,30 @@ func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
 	}
 	return nil
 }
+
+// LatestCompleteEpoch returns the latest epoch for which all blocks have been processed.
+func (s *Service) LatestCompleteEpoch(ctx context.Context) (phase0.Epoch, bool, error) {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	nextEpoch := s.chainTime.SlotToEpoch(phase0.Slot(md.LatestSlot + 1))
+	if md.LatestSlot < 0 || nextEpoch == 0 {
+		return 0, false, nil
+	}
+
+	return nextEpoch - 1, true, nil
+}
+
+// LatestCompleteSlot returns the latest slot for which blocks have been processed.
+func (s *Service) LatestCompleteSlot(ctx context.Context) (phase0.Slot, bool, error) {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if md.LatestSlot < 0 {
+		return 0, false, nil
+	}
+
+	return phase0.Slot(md.LatestSlot), true, nil
+}
@@ -15,6 +15,7 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
@@ -28,6 +29,10 @@ var (
 	highestSlot    phase0.Slot
 	latestSlot     prometheus.Gauge
 	slotsProcessed prometheus.Gauge
+
+	eventsReceived       *prometheus.CounterVec
+	eventStreamConnected prometheus.Gauge
+	eventStreamAge       prometheus.Gauge
 )
 
 func registerMetrics(_ context.Context, monitor metrics.Service) error {
@@ -65,6 +70,37 @@ func registerPrometheusMetrics() error {
 		return errors.Wrap(err, "failed to register slots_processed")
 	}
 
+	eventsReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "event_stream",
+		Name:      "events_total",
+		Help:      "Number of events received from the beacon node event stream",
+	}, []string{"topic"})
+	if err := prometheus.Register(eventsReceived); err != nil {
+		return errors.Wrap(err, "failed to register event_stream_events_total")
+	}
+
+	eventStreamConnected = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "event_stream",
+		Name:      "connected",
+		Help:      "1 if the beacon node event stream is providing events, otherwise 0",
+	})
+	if err := prometheus.Register(eventStreamConnected); err != nil {
+		return errors.Wrap(err, "failed to register event_stream_connected")
+	}
+	eventStreamConnected.Set(1)
+
+	eventStreamAge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "event_stream",
+		Name:      "last_event_age_seconds",
+		Help:      "Time since the last event was received from the beacon node event stream",
+	})
+	if err := prometheus.Register(eventStreamAge); err != nil {
+		return errors.Wrap(err, "failed to register event_stream_last_event_age_seconds")
+	}
+
 	return nil
 }
 
@@ -86,3 +122,28 @@ func monitorSlotProcessed(slot phase0.Slot) {
 		}
 	}
 }
+
+func monitorEventReceived(topic string) {
+	if eventsReceived != nil {
+		eventsReceived.WithLabelValues(topic).Inc()
+	}
+	if eventStreamAge != nil {
+		eventStreamAge.Set(0)
+	}
+}
+
+func monitorEventStreamConnected(connected bool) {
+	if eventStreamConnected != nil {
+		if connected {
+			eventStreamConnected.Set(1)
+		} else {
+			eventStreamConnected.Set(0)
+		}
+	}
+}
+
+func monitorEventStreamAge(age time.Duration) {
+	if eventStreamAge != nil {
+		eventStreamAge.Set(age.Seconds())
+	}
+}
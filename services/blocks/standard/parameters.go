@@ -18,21 +18,26 @@ import (
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/blocks"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/watchdog"
 	"golang.org/x/sync/semaphore"
 )
 
 type parameters struct {
-	logLevel    zerolog.Level
-	monitor     metrics.Service
-	eth2Client  eth2client.Service
-	chainDB     chaindb.Service
-	chainTime   chaintime.Service
-	startSlot   int64
-	refetch     bool
-	activitySem *semaphore.Weighted
+	logLevel                  zerolog.Level
+	monitor                   metrics.Service
+	eth2Client                eth2client.Service
+	signedBeaconBlockProvider eth2client.SignedBeaconBlockProvider
+	chainDB                   chaindb.Service
+	chainTime                 chaintime.Service
+	startSlot                 int64
+	refetch                   bool
+	activitySem               *semaphore.Weighted
+	watchdog                  watchdog.Service
+	storedComponents          blocks.StoredComponents
 }
 
 // Parameter is the interface for service parameters.
@@ -67,6 +72,14 @@ func WithETH2Client(eth2Client eth2client.Service) Parameter {
 	})
 }
 
+// WithSignedBeaconBlockProvider sets the signed beacon block provider for this module.
+// If not supplied, blocks are fetched using the Ethereum 2 client.
+func WithSignedBeaconBlockProvider(provider eth2client.SignedBeaconBlockProvider) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.signedBeaconBlockProvider = provider
+	})
+}
+
 // WithChainDB sets the chain database for this module.
 func WithChainDB(chainDB chaindb.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -102,6 +115,21 @@ func WithActivitySem(sem *semaphore.Weighted) Parameter {
 	})
 }
 
+// WithWatchdog sets the watchdog with which the service registers its progress.
+func WithWatchdog(watchdog watchdog.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.watchdog = watchdog
+	})
+}
+
+// WithStoredComponents sets the components of blocks that are stored in the database.
+// Components that are not present are stored.
+func WithStoredComponents(components blocks.StoredComponents) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.storedComponents = components
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
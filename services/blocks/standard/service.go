@@ -17,34 +17,42 @@ import (
 	"context"
 
 	eth2client "github.com/attestantio/go-eth2-client"
-	api "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/blocks"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"go.uber.org/atomic"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a chain database service.
 type Service struct {
-	eth2Client               eth2client.Service
-	chainDB                  chaindb.Service
-	blocksSetter             chaindb.BlocksSetter
-	attestationsSetter       chaindb.AttestationsSetter
-	attesterSlashingsSetter  chaindb.AttesterSlashingsSetter
-	proposerSlashingsSetter  chaindb.ProposerSlashingsSetter
-	syncAggregateSetter      chaindb.SyncAggregateSetter
-	depositsSetter           chaindb.DepositsSetter
-	voluntaryExitsSetter     chaindb.VoluntaryExitsSetter
-	beaconCommitteesProvider chaindb.BeaconCommitteesProvider
-	syncCommitteesProvider   chaindb.SyncCommitteesProvider
-	chainTime                chaintime.Service
-	refetch                  bool
-	lastHandledBlockRoot     phase0.Root
-	activitySem              *semaphore.Weighted
-	syncCommittees           map[uint64]*chaindb.SyncCommittee
+	eth2Client                eth2client.Service
+	signedBeaconBlockProvider eth2client.SignedBeaconBlockProvider
+	chainDB                   chaindb.Service
+	blocksSetter              chaindb.BlocksSetter
+	attestationsSetter        chaindb.AttestationsSetter
+	attesterSlashingsSetter   chaindb.AttesterSlashingsSetter
+	proposerSlashingsSetter   chaindb.ProposerSlashingsSetter
+	syncAggregateSetter       chaindb.SyncAggregateSetter
+	depositsSetter            chaindb.DepositsSetter
+	voluntaryExitsSetter      chaindb.VoluntaryExitsSetter
+	beaconCommitteesProvider  chaindb.BeaconCommitteesProvider
+	syncCommitteesProvider    chaindb.SyncCommitteesProvider
+	chainTime                 chaintime.Service
+	refetch                   bool
+	lastHandledBlockRoot      phase0.Root
+	activitySem               *semaphore.Weighted
+	syncCommittees            map[uint64]*chaindb.SyncCommittee
+	lastEventTime             atomic.Int64
+	streamConnected           atomic.Bool
+	origin                    *chaindb.Origin
+	arrivals                  blockArrivals
+	storedComponents          blocks.StoredComponents
 }
 
 // module-wide log.
@@ -58,7 +66,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "blocks").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger(zerologger.With().Str("service", "blocks").Str("impl", "standard").Logger(), "blocks", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -109,24 +117,46 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support sync committee providing")
 	}
 
-	s := &Service{
-		eth2Client:               parameters.eth2Client,
-		chainDB:                  parameters.chainDB,
-		blocksSetter:             blocksSetter,
-		attestationsSetter:       attestationsSetter,
-		attesterSlashingsSetter:  attesterSlashingsSetter,
-		proposerSlashingsSetter:  proposerSlashingsSetter,
-		syncAggregateSetter:      syncAggregateSetter,
-		depositsSetter:           depositsSetter,
-		voluntaryExitsSetter:     voluntaryExitsSetter,
-		beaconCommitteesProvider: beaconCommitteesProvider,
-		syncCommitteesProvider:   syncCommitteesProvider,
-		chainTime:                parameters.chainTime,
-		refetch:                  parameters.refetch,
-		activitySem:              parameters.activitySem,
-		syncCommittees:           make(map[uint64]*chaindb.SyncCommittee),
+	var origin *chaindb.Origin
+	if originProvider, isOriginProvider := parameters.chainDB.(chaindb.OriginProvider); isOriginProvider {
+		origin, err = originProvider.Origin(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain origin")
+		}
+	}
+
+	signedBeaconBlockProvider := parameters.signedBeaconBlockProvider
+	if signedBeaconBlockProvider == nil {
+		var isProvider bool
+		signedBeaconBlockProvider, isProvider = parameters.eth2Client.(eth2client.SignedBeaconBlockProvider)
+		if !isProvider {
+			return nil, errors.New("client does not provide signed beacon blocks")
+		}
 	}
 
+	s := &Service{
+		eth2Client:                parameters.eth2Client,
+		signedBeaconBlockProvider: signedBeaconBlockProvider,
+		chainDB:                   parameters.chainDB,
+		blocksSetter:              blocksSetter,
+		attestationsSetter:        attestationsSetter,
+		attesterSlashingsSetter:   attesterSlashingsSetter,
+		proposerSlashingsSetter:   proposerSlashingsSetter,
+		syncAggregateSetter:       syncAggregateSetter,
+		depositsSetter:            depositsSetter,
+		voluntaryExitsSetter:      voluntaryExitsSetter,
+		beaconCommitteesProvider:  beaconCommitteesProvider,
+		syncCommitteesProvider:    syncCommitteesProvider,
+		chainTime:                 parameters.chainTime,
+		refetch:                   parameters.refetch,
+		activitySem:               parameters.activitySem,
+		syncCommittees:            make(map[uint64]*chaindb.SyncCommittee),
+		origin:                    origin,
+		storedComponents:          parameters.storedComponents,
+	}
+	// Assume the event stream is healthy until shown otherwise.
+	s.streamConnected.Store(true)
+
 	// Note the current highest processed block for the monitor.
 	md, err := s.getMetadata(ctx)
 	if err != nil {
@@ -137,6 +167,12 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	// Update to current epoch before starting (in the background).
 	go s.updateAfterRestart(ctx, parameters.startSlot)
 
+	if parameters.watchdog != nil {
+		if err := s.registerWatchdog(ctx, parameters.watchdog); err != nil {
+			return nil, errors.Wrap(err, "failed to register with watchdog")
+		}
+	}
+
 	return s, nil
 }
 
@@ -165,15 +201,8 @@ func (s *Service) updateAfterRestart(ctx context.Context, startSlot int64) {
 	s.catchup(ctx, md)
 	log.Info().Msg("Caught up")
 
-	// Set up the handler for new chain head updates.
-	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"head"}, func(event *api.Event) {
-		if event.Data == nil {
-			// Happens when the channel shuts down, nothing to worry about.
-			return
-		}
-		eventData := event.Data.(*api.HeadEvent)
-		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
-	}); err != nil {
-		log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
+	// Set up the handler for beacon node events.
+	if err := s.subscribeEvents(ctx); err != nil {
+		log.Fatal().Err(err).Msg("Failed to add beacon node event handler")
 	}
 }
@@ -0,0 +1,53 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/watchdog"
+)
+
+// watchdogThreshold is the number of slots the blocks service can fall behind
+// the chain before the watchdog considers it stale.
+const watchdogThreshold = 16
+
+// registerWatchdog registers the blocks service with the watchdog.
+func (s *Service) registerWatchdog(ctx context.Context, wd watchdog.Service) error {
+	return wd.Register(ctx, &watchdog.Dataset{
+		Name:      "blocks",
+		Progress:  s.slotProgress,
+		Cadence:   s.chainTime.SlotDuration(),
+		Threshold: watchdogThreshold,
+		Recover: func(_ context.Context) error {
+			// Catch up in the background using the service's context.
+			go s.poll(ctx)
+			return nil
+		},
+	})
+}
+
+// slotProgress returns the latest slot processed by the service, and the current slot.
+func (s *Service) slotProgress(ctx context.Context) (uint64, uint64, bool, error) {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return 0, 0, false, errors.Wrap(err, "failed to obtain metadata")
+	}
+	if md.LatestSlot < 0 {
+		return 0, 0, false, nil
+	}
+
+	return uint64(md.LatestSlot), uint64(s.chainTime.CurrentSlot()), true, nil
+}
@@ -173,6 +173,11 @@ type SyncAggregateFilter struct {
 	// This relates to the inclusion slot.
 	// If nil then there is no latest slot.
 	To *phase0.Slot
+
+	// Canonical will return only sync aggregates from canonical or non-canonical blocks.
+	// Note that neither true nor false will return sync aggregates from indeterminate blocks.
+	// If nil then no filter is applied.
+	Canonical *bool
 }
 
 // BLSToExecutionChangeFilter defines a filter for fetching BLS to execution changes.
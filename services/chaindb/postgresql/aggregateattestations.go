@@ -0,0 +1,443 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// attestationStorageMetadataKey is the metadata key for the attestation storage mode.
+var attestationStorageMetadataKey = "chaind.attestation_storage"
+
+// ErrAttestationInclusionNotStored is returned when attestations are requested by
+// inclusion from a database that stores attestations in aggregate mode.
+var ErrAttestationInclusionNotStored = errors.New("attestation inclusions are not stored in aggregate mode")
+
+type attestationStorageJSON struct {
+	Mode string `json:"mode"`
+}
+
+// AttestationStorageMode returns the mode in which attestations are stored.
+func (s *Service) AttestationStorageMode() chaindb.AttestationStorageMode {
+	return s.attestationStorageMode
+}
+
+// setAttestationStorageMode records the mode in which attestations are stored.
+func (s *Service) setAttestationStorageMode(ctx context.Context, mode chaindb.AttestationStorageMode) error {
+	data, err := json.Marshal(&attestationStorageJSON{
+		Mode: string(mode),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal attestation storage mode")
+	}
+
+	return s.SetMetadata(ctx, attestationStorageMetadataKey, data)
+}
+
+// storedAttestationStorageMode returns the mode in which the database stores attestations.
+// Databases created before the mode was recorded store attestations in full.
+// It returns an empty mode if the database has yet to be initialised.
+func (s *Service) storedAttestationStorageMode(ctx context.Context) (chaindb.AttestationStorageMode, error) {
+	initialised, err := s.tableExists(ctx, "t_metadata")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to check presence of tables")
+	}
+	if !initialised {
+		return "", nil
+	}
+
+	data, err := s.Metadata(ctx, attestationStorageMetadataKey)
+	if err != nil {
+		return "", err
+	}
+	if data == nil {
+		return chaindb.AttestationStorageFull, nil
+	}
+
+	storage := &attestationStorageJSON{}
+	if err := json.Unmarshal(data, storage); err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal attestation storage mode")
+	}
+
+	return chaindb.AttestationStorageMode(storage.Mode), nil
+}
+
+// checkAttestationStorageMode ensures that the configured attestation storage mode
+// matches that of the database, as the modes cannot be mixed.  A read-only service
+// adopts the mode of the database.
+func (s *Service) checkAttestationStorageMode(ctx context.Context) error {
+	mode, err := s.storedAttestationStorageMode(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain attestation storage mode")
+	}
+	if mode == "" || mode == s.attestationStorageMode {
+		return nil
+	}
+	if s.readOnly {
+		log.Debug().Str("mode", string(mode)).Msg("Using attestation storage mode of database")
+		s.attestationStorageMode = mode
+		return nil
+	}
+
+	return fmt.Errorf("database stores attestations in %s mode but %s mode is configured; the mode cannot be changed after the database is created", mode, s.attestationStorageMode)
+}
+
+// aggregateAttestations returns true if attestations are stored in aggregate mode.
+func (s *Service) aggregateAttestations() bool {
+	return s.attestationStorageMode == chaindb.AttestationStorageAggregate
+}
+
+// aggregateAttestationKey identifies an attestation stored in aggregate mode.
+type aggregateAttestationKey struct {
+	slot     phase0.Slot
+	dataRoot phase0.Root
+}
+
+// setAggregateAttestations merges the attestations in to those stored in aggregate mode.
+// Vote correctness and canonical status are only updated if they are set.
+func (s *Service) setAggregateAttestations(ctx context.Context, attestations []*chaindb.Attestation) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Merge the attestations with each other.
+	merged := make(map[aggregateAttestationKey]*chaindb.Attestation)
+	keys := make([]aggregateAttestationKey, 0, len(attestations))
+	for _, attestation := range attestations {
+		key, err := aggregateKey(attestation)
+		if err != nil {
+			return err
+		}
+		existing, exists := merged[key]
+		if !exists {
+			merged[key] = copyAttestation(attestation)
+			keys = append(keys, key)
+			continue
+		}
+		if err := mergeAttestation(existing, attestation); err != nil {
+			return err
+		}
+	}
+
+	// Merge the attestations with those already stored.
+	slots := make([]phase0.Slot, len(keys))
+	dataRoots := make([][]byte, len(keys))
+	for i := range keys {
+		slots[i] = keys[i].slot
+		dataRoots[i] = keys[i].dataRoot[:]
+	}
+	rows, err := tx.Query(ctx, `
+      SELECT f_slot
+            ,f_data_root
+            ,f_aggregation_bits
+            ,f_aggregation_indices
+      FROM t_aggregate_attestations
+      WHERE (f_slot,f_data_root) IN (SELECT * FROM UNNEST($1::BIGINT[],$2::BYTEA[]))
+      FOR UPDATE`,
+		slots,
+		dataRoots,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain stored aggregate attestations")
+	}
+	for rows.Next() {
+		var key aggregateAttestationKey
+		var dataRoot []byte
+		var aggregationBits []byte
+		var aggregationIndices []uint64
+		if err := rows.Scan(&key.slot, &dataRoot, &aggregationBits, &aggregationIndices); err != nil {
+			rows.Close()
+			return errors.Wrap(err, "failed to scan row")
+		}
+		copy(key.dataRoot[:], dataRoot)
+		stored := &chaindb.Attestation{
+			AggregationIndices: make([]phase0.ValidatorIndex, len(aggregationIndices)),
+		}
+		stored.AggregationBits, err = util.DecompressColumn(aggregationBits, 0)
+		if err != nil {
+			rows.Close()
+			return errors.Wrap(err, "failed to decompress aggregation bits")
+		}
+		for i := range aggregationIndices {
+			stored.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
+		}
+		if err := mergeAttestation(merged[key], stored); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to obtain stored aggregate attestations")
+	}
+
+	batch := &pgx.Batch{}
+	for _, key := range keys {
+		attestation := merged[key]
+		aggregationBits, err := util.CompressColumn(attestation.AggregationBits, 0, s.columnCompression)
+		if err != nil {
+			return errors.Wrap(err, "failed to compress aggregation bits")
+		}
+		batch.Queue(`
+      INSERT INTO t_aggregate_attestations(f_slot
+                                          ,f_data_root
+                                          ,f_committee_index
+                                          ,f_aggregation_bits
+                                          ,f_aggregation_indices
+                                          ,f_beacon_block_root
+                                          ,f_source_epoch
+                                          ,f_source_root
+                                          ,f_target_epoch
+                                          ,f_target_root
+                                          ,f_canonical
+                                          ,f_target_correct
+                                          ,f_head_correct
+                                          ,f_source_correct
+                                          )
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
+      ON CONFLICT (f_slot,f_data_root) DO
+      UPDATE
+      SET f_aggregation_bits = excluded.f_aggregation_bits
+         ,f_aggregation_indices = excluded.f_aggregation_indices
+         ,f_canonical = COALESCE(excluded.f_canonical, t_aggregate_attestations.f_canonical)
+         ,f_target_correct = COALESCE(excluded.f_target_correct, t_aggregate_attestations.f_target_correct)
+         ,f_head_correct = COALESCE(excluded.f_head_correct, t_aggregate_attestations.f_head_correct)
+         ,f_source_correct = COALESCE(excluded.f_source_correct, t_aggregate_attestations.f_source_correct)`,
+			attestation.Slot,
+			key.dataRoot[:],
+			attestation.CommitteeIndex,
+			aggregationBits,
+			attestation.AggregationIndices,
+			attestation.BeaconBlockRoot[:],
+			attestation.SourceEpoch,
+			attestation.SourceRoot[:],
+			attestation.TargetEpoch,
+			attestation.TargetRoot[:],
+			nullBool(attestation.Canonical),
+			nullBool(attestation.TargetCorrect),
+			nullBool(attestation.HeadCorrect),
+			nullBool(attestation.SourceCorrect),
+		)
+	}
+	results := tx.SendBatch(ctx, batch)
+	for range keys {
+		if _, err := results.Exec(); err != nil {
+			results.Close()
+			return errors.Wrap(err, "failed to set aggregate attestation")
+		}
+	}
+
+	return results.Close()
+}
+
+// aggregateAttestationsWhere fetches the attestations stored in aggregate mode that
+// match the condition.
+func (s *Service) aggregateAttestationsWhere(ctx context.Context, condition string, args ...interface{}) ([]*chaindb.Attestation, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// The condition is fixed by the caller, so safe to include in the query.
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+      SELECT f_slot
+            ,f_committee_index
+            ,f_aggregation_bits
+            ,f_aggregation_indices
+            ,f_beacon_block_root
+            ,f_source_epoch
+            ,f_source_root
+            ,f_target_epoch
+            ,f_target_root
+            ,f_canonical
+            ,f_target_correct
+            ,f_head_correct
+            ,f_source_correct
+      FROM t_aggregate_attestations
+      WHERE %s
+      ORDER BY f_slot
+              ,f_committee_index
+              ,f_data_root`, condition),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attestations := make([]*chaindb.Attestation, 0)
+
+	for rows.Next() {
+		attestation := &chaindb.Attestation{}
+		var aggregationBits []byte
+		var aggregationIndices []uint64
+		var beaconBlockRoot []byte
+		var sourceRoot []byte
+		var targetRoot []byte
+		var canonical sql.NullBool
+		var targetCorrect sql.NullBool
+		var headCorrect sql.NullBool
+		var sourceCorrect sql.NullBool
+		err := rows.Scan(
+			&attestation.Slot,
+			&attestation.CommitteeIndex,
+			&aggregationBits,
+			&aggregationIndices,
+			&beaconBlockRoot,
+			&attestation.SourceEpoch,
+			&sourceRoot,
+			&attestation.TargetEpoch,
+			&targetRoot,
+			&canonical,
+			&targetCorrect,
+			&headCorrect,
+			&sourceCorrect,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		attestation.AggregationBits, err = util.DecompressColumn(aggregationBits, 0)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress aggregation bits")
+		}
+		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
+		for i := range aggregationIndices {
+			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
+		}
+		copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
+		copy(attestation.SourceRoot[:], sourceRoot)
+		copy(attestation.TargetRoot[:], targetRoot)
+		attestation.Canonical = boolPtr(canonical)
+		attestation.TargetCorrect = boolPtr(targetCorrect)
+		attestation.HeadCorrect = boolPtr(headCorrect)
+		attestation.SourceCorrect = boolPtr(sourceCorrect)
+		attestations = append(attestations, attestation)
+	}
+
+	return attestations, nil
+}
+
+// aggregateKey returns the key under which an attestation is stored in aggregate mode.
+func aggregateKey(attestation *chaindb.Attestation) (aggregateAttestationKey, error) {
+	data := &phase0.AttestationData{
+		Slot:            attestation.Slot,
+		Index:           attestation.CommitteeIndex,
+		BeaconBlockRoot: attestation.BeaconBlockRoot,
+		Source: &phase0.Checkpoint{
+			Epoch: attestation.SourceEpoch,
+			Root:  attestation.SourceRoot,
+		},
+		Target: &phase0.Checkpoint{
+			Epoch: attestation.TargetEpoch,
+			Root:  attestation.TargetRoot,
+		},
+	}
+	dataRoot, err := data.HashTreeRoot()
+	if err != nil {
+		return aggregateAttestationKey{}, errors.Wrap(err, "failed to calculate attestation data root")
+	}
+
+	return aggregateAttestationKey{
+		slot:     attestation.Slot,
+		dataRoot: dataRoot,
+	}, nil
+}
+
+// copyAttestation returns a copy of the attestation that can be merged without altering the original.
+func copyAttestation(attestation *chaindb.Attestation) *chaindb.Attestation {
+	res := *attestation
+	res.AggregationBits = make([]byte, len(attestation.AggregationBits))
+	copy(res.AggregationBits, attestation.AggregationBits)
+	res.AggregationIndices = make([]phase0.ValidatorIndex, len(attestation.AggregationIndices))
+	copy(res.AggregationIndices, attestation.AggregationIndices)
+
+	return &res
+}
+
+// mergeAttestation merges the aggregation bits and indices of the other attestation, which
+// must have the same attestation data, in to the attestation.
+// Vote correctness and canonical status are taken from the other attestation if not already set.
+func mergeAttestation(attestation *chaindb.Attestation, other *chaindb.Attestation) error {
+	if len(attestation.AggregationBits) != len(other.AggregationBits) {
+		return fmt.Errorf("aggregation bits of length %d cannot be merged with aggregation bits of length %d", len(other.AggregationBits), len(attestation.AggregationBits))
+	}
+	for i := range other.AggregationBits {
+		attestation.AggregationBits[i] |= other.AggregationBits[i]
+	}
+
+	indices := make(map[phase0.ValidatorIndex]struct{}, len(attestation.AggregationIndices)+len(other.AggregationIndices))
+	for _, index := range attestation.AggregationIndices {
+		indices[index] = struct{}{}
+	}
+	for _, index := range other.AggregationIndices {
+		indices[index] = struct{}{}
+	}
+	attestation.AggregationIndices = make([]phase0.ValidatorIndex, 0, len(indices))
+	for index := range indices {
+		attestation.AggregationIndices = append(attestation.AggregationIndices, index)
+	}
+	sort.Slice(attestation.AggregationIndices, func(i int, j int) bool {
+		return attestation.AggregationIndices[i] < attestation.AggregationIndices[j]
+	})
+
+	if attestation.Canonical == nil {
+		attestation.Canonical = other.Canonical
+	}
+	if attestation.TargetCorrect == nil {
+		attestation.TargetCorrect = other.TargetCorrect
+	}
+	if attestation.HeadCorrect == nil {
+		attestation.HeadCorrect = other.HeadCorrect
+	}
+	if attestation.SourceCorrect == nil {
+		attestation.SourceCorrect = other.SourceCorrect
+	}
+
+	return nil
+}
+
+// nullBool returns the SQL value of an optional boolean.
+func nullBool(val *bool) sql.NullBool {
+	if val == nil {
+		return sql.NullBool{}
+	}
+
+	return sql.NullBool{Valid: true, Bool: *val}
+}
+
+// boolPtr returns the optional boolean for an SQL value.
+func boolPtr(val sql.NullBool) *bool {
+	if !val.Valid {
+		return nil
+	}
+	res := val.Bool
+
+	return &res
+}
@@ -0,0 +1,78 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestMergeAttestation(t *testing.T) {
+	canonical := true
+	attestation := &chaindb.Attestation{
+		AggregationBits:    []byte{0x05, 0x01},
+		AggregationIndices: []phase0.ValidatorIndex{10, 30},
+	}
+	other := &chaindb.Attestation{
+		AggregationBits:    []byte{0x06, 0x01},
+		AggregationIndices: []phase0.ValidatorIndex{20, 30},
+		Canonical:          &canonical,
+	}
+
+	merged := copyAttestation(attestation)
+	require.NoError(t, mergeAttestation(merged, other))
+	require.Equal(t, []byte{0x07, 0x01}, merged.AggregationBits)
+	require.Equal(t, []phase0.ValidatorIndex{10, 20, 30}, merged.AggregationIndices)
+	require.Equal(t, &canonical, merged.Canonical)
+	require.Nil(t, merged.TargetCorrect)
+
+	// The original attestation is unaltered.
+	require.Equal(t, []byte{0x05, 0x01}, attestation.AggregationBits)
+	require.Equal(t, []phase0.ValidatorIndex{10, 30}, attestation.AggregationIndices)
+
+	// Aggregation bits for different committees cannot be merged.
+	require.EqualError(t, mergeAttestation(merged, &chaindb.Attestation{AggregationBits: []byte{0x01}}),
+		"aggregation bits of length 1 cannot be merged with aggregation bits of length 2")
+}
+
+func TestAggregateKey(t *testing.T) {
+	attestation := &chaindb.Attestation{
+		InclusionSlot:   101,
+		Slot:            100,
+		CommitteeIndex:  1,
+		BeaconBlockRoot: phase0.Root{0x01},
+		TargetEpoch:     3,
+		TargetRoot:      phase0.Root{0x02},
+	}
+	key, err := aggregateKey(attestation)
+	require.NoError(t, err)
+	require.Equal(t, phase0.Slot(100), key.slot)
+
+	// Inclusion does not alter the key.
+	included := *attestation
+	included.InclusionSlot = 102
+	includedKey, err := aggregateKey(&included)
+	require.NoError(t, err)
+	require.Equal(t, key, includedKey)
+
+	// Attestation data does.
+	different := *attestation
+	different.BeaconBlockRoot = phase0.Root{0x03}
+	differentKey, err := aggregateKey(&different)
+	require.NoError(t, err)
+	require.NotEqual(t, key, differentKey)
+}
@@ -16,11 +16,13 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 	"go.opentelemetry.io/otel"
 )
 
@@ -29,6 +31,10 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
 	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetAttestation")
 	defer span.End()
 
+	if s.aggregateAttestations() {
+		return s.setAggregateAttestations(ctx, []*chaindb.Attestation{attestation})
+	}
+
 	tx := s.tx(ctx)
 	if tx == nil {
 		return ErrNoTransaction
@@ -49,7 +55,16 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
 		headCorrect.Valid = true
 		headCorrect.Bool = *attestation.HeadCorrect
 	}
-	_, err := tx.Exec(ctx, `
+	var sourceCorrect sql.NullBool
+	if attestation.SourceCorrect != nil {
+		sourceCorrect.Valid = true
+		sourceCorrect.Bool = *attestation.SourceCorrect
+	}
+	aggregationBits, err := util.CompressColumn(attestation.AggregationBits, 0, s.columnCompression)
+	if err != nil {
+		return errors.Wrap(err, "failed to compress aggregation bits")
+	}
+	_, err = tx.Exec(ctx, `
       INSERT INTO t_attestations(f_inclusion_slot
                                 ,f_inclusion_block_root
                                 ,f_inclusion_index
@@ -65,8 +80,9 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
                                 ,f_canonical
                                 ,f_target_correct
                                 ,f_head_correct
+                                ,f_source_correct
 						  )
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)
       ON CONFLICT (f_inclusion_slot,f_inclusion_block_root,f_inclusion_index) DO
       UPDATE
       SET f_slot = excluded.f_slot
@@ -81,13 +97,14 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
          ,f_canonical = excluded.f_canonical
          ,f_target_correct = excluded.f_target_correct
          ,f_head_correct = excluded.f_head_correct
+         ,f_source_correct = excluded.f_source_correct
 	  `,
 		attestation.InclusionSlot,
 		attestation.InclusionBlockRoot[:],
 		attestation.InclusionIndex,
 		attestation.Slot,
 		attestation.CommitteeIndex,
-		attestation.AggregationBits,
+		aggregationBits,
 		attestation.AggregationIndices,
 		attestation.BeaconBlockRoot[:],
 		attestation.SourceEpoch,
@@ -97,6 +114,7 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
 		canonical,
 		targetCorrect,
 		headCorrect,
+		sourceCorrect,
 	)
 
 	return err
@@ -107,6 +125,10 @@ func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.A
 	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetAttestations")
 	defer span.End()
 
+	if s.aggregateAttestations() {
+		return s.setAggregateAttestations(ctx, attestations)
+	}
+
 	tx := s.tx(ctx)
 	if tx == nil {
 		return ErrNoTransaction
@@ -130,6 +152,7 @@ func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.A
 			"f_canonical",
 			"f_target_correct",
 			"f_head_correct",
+			"f_source_correct",
 		},
 		pgx.CopyFromSlice(len(attestations), func(i int) ([]interface{}, error) {
 			var canonical sql.NullBool
@@ -147,13 +170,22 @@ func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.A
 				headCorrect.Valid = true
 				headCorrect.Bool = *attestations[i].HeadCorrect
 			}
+			var sourceCorrect sql.NullBool
+			if attestations[i].SourceCorrect != nil {
+				sourceCorrect.Valid = true
+				sourceCorrect.Bool = *attestations[i].SourceCorrect
+			}
+			aggregationBits, err := util.CompressColumn(attestations[i].AggregationBits, 0, s.columnCompression)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to compress aggregation bits")
+			}
 			return []interface{}{
 				attestations[i].InclusionSlot,
 				attestations[i].InclusionBlockRoot[:],
 				attestations[i].InclusionIndex,
 				attestations[i].Slot,
 				attestations[i].CommitteeIndex,
-				attestations[i].AggregationBits,
+				aggregationBits,
 				attestations[i].AggregationIndices,
 				attestations[i].BeaconBlockRoot[:],
 				attestations[i].SourceEpoch,
@@ -163,6 +195,7 @@ func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.A
 				canonical,
 				targetCorrect,
 				headCorrect,
+				sourceCorrect,
 			}, nil
 		}))
 	return err
@@ -173,6 +206,10 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AttestationsForBlock")
 	defer span.End()
 
+	if s.aggregateAttestations() {
+		return s.aggregateAttestationsWhere(ctx, "f_beacon_block_root = $1", blockRoot[:])
+	}
+
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, err := s.BeginROTx(ctx)
@@ -199,6 +236,7 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
             ,f_canonical
             ,f_target_correct
             ,f_head_correct
+            ,f_source_correct
       FROM t_attestations
       WHERE f_beacon_block_root = $1
       ORDER BY f_inclusion_slot
@@ -215,6 +253,7 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 	for rows.Next() {
 		attestation := &chaindb.Attestation{}
 		var inclusionBlockRoot []byte
+		var aggregationBits []byte
 		var aggregationIndices []uint64
 		var beaconBlockRoot []byte
 		var sourceRoot []byte
@@ -222,13 +261,14 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 		var canonical sql.NullBool
 		var targetCorrect sql.NullBool
 		var headCorrect sql.NullBool
+		var sourceCorrect sql.NullBool
 		err := rows.Scan(
 			&attestation.InclusionSlot,
 			&inclusionBlockRoot,
 			&attestation.InclusionIndex,
 			&attestation.Slot,
 			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
+			&aggregationBits,
 			&aggregationIndices,
 			&beaconBlockRoot,
 			&attestation.SourceEpoch,
@@ -238,11 +278,16 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 			&canonical,
 			&targetCorrect,
 			&headCorrect,
+			&sourceCorrect,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
+		attestation.AggregationBits, err = util.DecompressColumn(aggregationBits, 0)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress aggregation bits")
+		}
 		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
 		for i := range aggregationIndices {
 			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
@@ -262,6 +307,10 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 			val := headCorrect.Bool
 			attestation.HeadCorrect = &val
 		}
+		if sourceCorrect.Valid {
+			val := sourceCorrect.Bool
+			attestation.SourceCorrect = &val
+		}
 		attestations = append(attestations, attestation)
 	}
 
@@ -273,6 +322,10 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AttestationsInBlock")
 	defer span.End()
 
+	if s.aggregateAttestations() {
+		return nil, ErrAttestationInclusionNotStored
+	}
+
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, err := s.BeginROTx(ctx)
@@ -299,6 +352,7 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
             ,f_canonical
             ,f_target_correct
             ,f_head_correct
+            ,f_source_correct
       FROM t_attestations
       WHERE f_inclusion_block_root = $1
       ORDER BY f_inclusion_slot
@@ -315,6 +369,7 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 	for rows.Next() {
 		attestation := &chaindb.Attestation{}
 		var inclusionBlockRoot []byte
+		var aggregationBits []byte
 		var aggregationIndices []uint64
 		var beaconBlockRoot []byte
 		var sourceRoot []byte
@@ -322,13 +377,14 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 		var canonical sql.NullBool
 		var targetCorrect sql.NullBool
 		var headCorrect sql.NullBool
+		var sourceCorrect sql.NullBool
 		err := rows.Scan(
 			&attestation.InclusionSlot,
 			&inclusionBlockRoot,
 			&attestation.InclusionIndex,
 			&attestation.Slot,
 			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
+			&aggregationBits,
 			&aggregationIndices,
 			&beaconBlockRoot,
 			&attestation.SourceEpoch,
@@ -338,11 +394,16 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 			&canonical,
 			&targetCorrect,
 			&headCorrect,
+			&sourceCorrect,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
+		attestation.AggregationBits, err = util.DecompressColumn(aggregationBits, 0)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress aggregation bits")
+		}
 		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
 		for i := range aggregationIndices {
 			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
@@ -362,6 +423,10 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 			val := headCorrect.Bool
 			attestation.HeadCorrect = &val
 		}
+		if sourceCorrect.Valid {
+			val := sourceCorrect.Bool
+			attestation.SourceCorrect = &val
+		}
 		attestations = append(attestations, attestation)
 	}
 
@@ -375,6 +440,10 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AttestationsForSlotRange")
 	defer span.End()
 
+	if s.aggregateAttestations() {
+		return s.aggregateAttestationsWhere(ctx, "f_slot >= $1 AND f_slot < $2", startSlot, endSlot)
+	}
+
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, err := s.BeginROTx(ctx)
@@ -401,6 +470,7 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
             ,f_canonical
             ,f_target_correct
             ,f_head_correct
+            ,f_source_correct
       FROM t_attestations
       WHERE f_slot >= $1
         AND f_slot < $2
@@ -419,6 +489,7 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 	for rows.Next() {
 		attestation := &chaindb.Attestation{}
 		var inclusionBlockRoot []byte
+		var aggregationBits []byte
 		var aggregationIndices []uint64
 		var beaconBlockRoot []byte
 		var sourceRoot []byte
@@ -426,13 +497,14 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 		var canonical sql.NullBool
 		var targetCorrect sql.NullBool
 		var headCorrect sql.NullBool
+		var sourceCorrect sql.NullBool
 		err := rows.Scan(
 			&attestation.InclusionSlot,
 			&inclusionBlockRoot,
 			&attestation.InclusionIndex,
 			&attestation.Slot,
 			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
+			&aggregationBits,
 			&aggregationIndices,
 			&beaconBlockRoot,
 			&attestation.SourceEpoch,
@@ -442,11 +514,16 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 			&canonical,
 			&targetCorrect,
 			&headCorrect,
+			&sourceCorrect,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
+		attestation.AggregationBits, err = util.DecompressColumn(aggregationBits, 0)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress aggregation bits")
+		}
 		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
 		for i := range aggregationIndices {
 			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
@@ -466,6 +543,10 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 			val := headCorrect.Bool
 			attestation.HeadCorrect = &val
 		}
+		if sourceCorrect.Valid {
+			val := sourceCorrect.Bool
+			attestation.SourceCorrect = &val
+		}
 		attestations = append(attestations, attestation)
 	}
 
@@ -479,6 +560,10 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "AttestationsInSlotRange")
 	defer span.End()
 
+	if s.aggregateAttestations() {
+		return nil, ErrAttestationInclusionNotStored
+	}
+
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, err := s.BeginROTx(ctx)
@@ -505,6 +590,7 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
             ,f_canonical
             ,f_target_correct
             ,f_head_correct
+            ,f_source_correct
       FROM t_attestations
       WHERE f_inclusion_slot >= $1
         AND f_inclusion_slot < $2
@@ -523,6 +609,7 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 	for rows.Next() {
 		attestation := &chaindb.Attestation{}
 		var inclusionBlockRoot []byte
+		var aggregationBits []byte
 		var aggregationIndices []uint64
 		var beaconBlockRoot []byte
 		var sourceRoot []byte
@@ -530,13 +617,14 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 		var canonical sql.NullBool
 		var targetCorrect sql.NullBool
 		var headCorrect sql.NullBool
+		var sourceCorrect sql.NullBool
 		err := rows.Scan(
 			&attestation.InclusionSlot,
 			&inclusionBlockRoot,
 			&attestation.InclusionIndex,
 			&attestation.Slot,
 			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
+			&aggregationBits,
 			&aggregationIndices,
 			&beaconBlockRoot,
 			&attestation.SourceEpoch,
@@ -546,11 +634,16 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 			&canonical,
 			&targetCorrect,
 			&headCorrect,
+			&sourceCorrect,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
+		attestation.AggregationBits, err = util.DecompressColumn(aggregationBits, 0)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress aggregation bits")
+		}
 		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
 		for i := range aggregationIndices {
 			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
@@ -570,6 +663,10 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 			val := headCorrect.Bool
 			attestation.HeadCorrect = &val
 		}
+		if sourceCorrect.Valid {
+			val := sourceCorrect.Bool
+			attestation.SourceCorrect = &val
+		}
 		attestations = append(attestations, attestation)
 	}
 
@@ -591,13 +688,17 @@ func (s *Service) IndeterminateAttestationSlots(ctx context.Context, minSlot pha
 		tx = s.tx(ctx)
 	}
 
-	rows, err := tx.Query(ctx, `
+	table := "t_attestations"
+	if s.aggregateAttestations() {
+		table = "t_aggregate_attestations"
+	}
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
       SELECT DISTINCT f_slot
-      FROM t_attestations
+      FROM %s
       WHERE f_slot >= $1
         AND f_slot < $2
         AND f_canonical IS NULL
-      ORDER BY f_slot`,
+      ORDER BY f_slot`, table),
 		minSlot,
 		maxSlot,
 	)
@@ -619,3 +720,89 @@ func (s *Service) IndeterminateAttestationSlots(ctx context.Context, minSlot pha
 
 	return slots, nil
 }
+
+// backfillAttestationVotesForSlotRange sets the vote correctness of attestations
+// included in blocks in the given slot range, using the slots per epoch of the
+// stored chain specification.
+func (s *Service) backfillAttestationVotesForSlotRange(ctx context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	int,
+	error,
+) {
+	slotsPerEpoch, err := s.ChainSpecValue(ctx, "SLOTS_PER_EPOCH")
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain slots per epoch")
+	}
+	val, isVal := slotsPerEpoch.(uint64)
+	if !isVal || val == 0 {
+		return 0, errors.New("invalid slots per epoch")
+	}
+
+	return s.UpdateAttestationVotesForSlotRange(ctx, startSlot, endSlot, val)
+}
+
+// UpdateAttestationVotesForSlotRange sets the head, target and source vote correctness of
+// attestations included in blocks in the given slot range that have a canonical status but
+// are missing any of them, returning the number of attestations updated.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) UpdateAttestationVotesForSlotRange(ctx context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+	slotsPerEpoch uint64,
+) (
+	int,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "UpdateAttestationVotesForSlotRange")
+	defer span.End()
+
+	if s.aggregateAttestations() {
+		// Attestations stored in aggregate mode postdate vote correctness, so have none missing.
+		return 0, nil
+	}
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return 0, ErrNoTransaction
+	}
+
+	// Each vote is correct if it matches the latest canonical block at or before the relevant slot,
+	// as per the finalizer.  Until the chain first justifies an epoch the source vote is for epoch 0
+	// with a zero root.
+	res, err := tx.Exec(ctx, `
+      UPDATE t_attestations
+      SET f_head_correct = COALESCE(f_head_correct, f_beacon_block_root = (
+            SELECT f_root FROM t_blocks
+            WHERE f_canonical = true
+              AND f_slot <= t_attestations.f_slot
+            ORDER BY f_slot DESC
+            LIMIT 1))
+         ,f_target_correct = COALESCE(f_target_correct, f_target_root = (
+            SELECT f_root FROM t_blocks
+            WHERE f_canonical = true
+              AND f_slot <= t_attestations.f_target_epoch * $3
+            ORDER BY f_slot DESC
+            LIMIT 1))
+         ,f_source_correct = COALESCE(f_source_correct, (f_source_epoch = 0 AND f_source_root = $4) OR f_source_root = (
+            SELECT f_root FROM t_blocks
+            WHERE f_canonical = true
+              AND f_slot <= t_attestations.f_source_epoch * $3
+            ORDER BY f_slot DESC
+            LIMIT 1))
+      WHERE f_inclusion_slot >= $1
+        AND f_inclusion_slot < $2
+        AND f_canonical IS NOT NULL
+        AND (f_head_correct IS NULL OR f_target_correct IS NULL OR f_source_correct IS NULL)`,
+		startSlot,
+		endSlot,
+		slotsPerEpoch,
+		make([]byte, phase0.RootLength),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to update attestation votes")
+	}
+
+	return int(res.RowsAffected()), nil
+}
@@ -0,0 +1,117 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestUpdateAttestationVotesForSlotRange(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+	_, err = s.Upgrade(ctx)
+	require.NoError(t, err)
+
+	// Try to update outside of a transaction; should fail.
+	_, err = s.UpdateAttestationVotesForSlotRange(ctx, 0, 32, 32)
+	require.EqualError(t, err, postgresql.ErrNoTransaction.Error())
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	// Far in the future, so no data.
+	updated, err := s.UpdateAttestationVotesForSlotRange(ctx, 32_000_000_000, 32_000_000_032, 32)
+	require.NoError(t, err)
+	require.Zero(t, updated)
+
+	// An attestation with canonical status but no votes has them set.
+	canonical := true
+	root := phase0.Root{0x01}
+	require.NoError(t, s.SetBlock(ctx, &chaindb.Block{
+		Slot:          32_000_000_000,
+		Root:          root,
+		Canonical:     &canonical,
+		Graffiti:      []byte{},
+		ETH1BlockHash: []byte{},
+	}))
+	require.NoError(t, s.SetAttestation(ctx, &chaindb.Attestation{
+		InclusionSlot:      32_000_000_001,
+		InclusionBlockRoot: root,
+		Slot:               32_000_000_000,
+		AggregationBits:    []byte{0x01},
+		BeaconBlockRoot:    root,
+		SourceEpoch:        1_000_000_000,
+		SourceRoot:         phase0.Root{0x02},
+		TargetEpoch:        1_000_000_000,
+		TargetRoot:         root,
+		Canonical:          &canonical,
+	}))
+	updated, err = s.UpdateAttestationVotesForSlotRange(ctx, 32_000_000_000, 32_000_000_032, 32)
+	require.NoError(t, err)
+	require.Equal(t, 1, updated)
+	attestations, err := s.AttestationsInBlock(ctx, root)
+	require.NoError(t, err)
+	require.Len(t, attestations, 1)
+	require.True(t, *attestations[0].HeadCorrect)
+	require.True(t, *attestations[0].TargetCorrect)
+	require.False(t, *attestations[0].SourceCorrect)
+
+	// Attestations that already have votes are not updated again.
+	updated, err = s.UpdateAttestationVotesForSlotRange(ctx, 32_000_000_000, 32_000_000_032, 32)
+	require.NoError(t, err)
+	require.Zero(t, updated)
+}
+
+func TestAttestationStorageModeMismatch(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+	_, err = s.Upgrade(ctx)
+	require.NoError(t, err)
+	require.Equal(t, chaindb.AttestationStorageFull, s.AttestationStorageMode())
+
+	// The test database stores attestations in full, so cannot be used in aggregate mode.
+	_, err = postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+		postgresql.WithAttestationStorageMode(chaindb.AttestationStorageAggregate),
+	)
+	require.ErrorContains(t, err, "database stores attestations in full mode but aggregate mode is configured")
+
+	// A read-only service adopts the mode of the database.
+	s, err = postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+		postgresql.WithAttestationStorageMode(chaindb.AttestationStorageAggregate),
+		postgresql.WithReadOnly(true),
+	)
+	require.NoError(t, err)
+	require.Equal(t, chaindb.AttestationStorageFull, s.AttestationStorageMode())
+}
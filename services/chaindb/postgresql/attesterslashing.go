@@ -19,6 +19,7 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 	"go.opentelemetry.io/otel"
 )
 
@@ -32,7 +33,16 @@ func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *cha
 		return ErrNoTransaction
 	}
 
-	_, err := tx.Exec(ctx, `
+	attestation1Signature, err := util.CompressColumn(attesterSlashing.Attestation1Signature[:], signatureLength, s.columnCompression)
+	if err != nil {
+		return errors.Wrap(err, "failed to compress attestation 1 signature")
+	}
+	attestation2Signature, err := util.CompressColumn(attesterSlashing.Attestation2Signature[:], signatureLength, s.columnCompression)
+	if err != nil {
+		return errors.Wrap(err, "failed to compress attestation 2 signature")
+	}
+
+	_, err = tx.Exec(ctx, `
       INSERT INTO t_attester_slashings(f_inclusion_slot
                                       ,f_inclusion_block_root
                                       ,f_inclusion_index
@@ -88,7 +98,7 @@ func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *cha
 		attesterSlashing.Attestation1SourceRoot[:],
 		attesterSlashing.Attestation1TargetEpoch,
 		attesterSlashing.Attestation1TargetRoot[:],
-		attesterSlashing.Attestation1Signature[:],
+		attestation1Signature,
 		attesterSlashing.Attestation2Indices,
 		attesterSlashing.Attestation2Slot,
 		attesterSlashing.Attestation2CommitteeIndex,
@@ -97,7 +107,7 @@ func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *cha
 		attesterSlashing.Attestation2SourceRoot[:],
 		attesterSlashing.Attestation2TargetEpoch,
 		attesterSlashing.Attestation2TargetRoot[:],
-		attesterSlashing.Attestation2Signature[:],
+		attestation2Signature,
 	)
 
 	return err
@@ -204,6 +214,10 @@ func (s *Service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot pha
 		copy(attesterSlashing.Attestation1BeaconBlockRoot[:], attestation1BeaconBlockRoot)
 		copy(attesterSlashing.Attestation1SourceRoot[:], attestation1SourceRoot)
 		copy(attesterSlashing.Attestation1TargetRoot[:], attestation1TargetRoot)
+		attestation1Signature, err = util.DecompressColumn(attestation1Signature, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress attestation 1 signature")
+		}
 		copy(attesterSlashing.Attestation1Signature[:], attestation1Signature)
 		attesterSlashing.Attestation2Indices = make([]phase0.ValidatorIndex, len(attestation2Indices))
 		for i := range attestation2Indices {
@@ -212,6 +226,10 @@ func (s *Service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot pha
 		copy(attesterSlashing.Attestation2BeaconBlockRoot[:], attestation2BeaconBlockRoot)
 		copy(attesterSlashing.Attestation2SourceRoot[:], attestation2SourceRoot)
 		copy(attesterSlashing.Attestation2TargetRoot[:], attestation2TargetRoot)
+		attestation2Signature, err = util.DecompressColumn(attestation2Signature, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress attestation 2 signature")
+		}
 		copy(attesterSlashing.Attestation2Signature[:], attestation2Signature)
 		attesterSlashings = append(attesterSlashings, attesterSlashing)
 	}
@@ -318,6 +336,10 @@ func (s *Service) AttesterSlashingsForValidator(ctx context.Context, index phase
 		copy(attesterSlashing.Attestation1BeaconBlockRoot[:], attestation1BeaconBlockRoot)
 		copy(attesterSlashing.Attestation1SourceRoot[:], attestation1SourceRoot)
 		copy(attesterSlashing.Attestation1TargetRoot[:], attestation1TargetRoot)
+		attestation1Signature, err = util.DecompressColumn(attestation1Signature, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress attestation 1 signature")
+		}
 		copy(attesterSlashing.Attestation1Signature[:], attestation1Signature)
 		attesterSlashing.Attestation2Indices = make([]phase0.ValidatorIndex, len(attestation2Indices))
 		for i := range attestation2Indices {
@@ -326,6 +348,10 @@ func (s *Service) AttesterSlashingsForValidator(ctx context.Context, index phase
 		copy(attesterSlashing.Attestation2BeaconBlockRoot[:], attestation2BeaconBlockRoot)
 		copy(attesterSlashing.Attestation2SourceRoot[:], attestation2SourceRoot)
 		copy(attesterSlashing.Attestation2TargetRoot[:], attestation2TargetRoot)
+		attestation2Signature, err = util.DecompressColumn(attestation2Signature, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress attestation 2 signature")
+		}
 		copy(attesterSlashing.Attestation2Signature[:], attestation2Signature)
 		attesterSlashings = append(attesterSlashings, attesterSlashing)
 	}
@@ -22,6 +22,7 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -37,18 +38,34 @@ func (s *Service) SetBeaconCommittee(ctx context.Context, beaconCommittee *chain
 		return ErrNoTransaction
 	}
 
+	// Committees are stored in either full or compact form, but not both.
+	var committee interface{}
+	var compactCommittee interface{}
+	if s.compactCommittees {
+		var err error
+		compactCommittee, err = util.EncodeCommittee(beaconCommittee.Committee)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode committee")
+		}
+	} else {
+		committee = beaconCommittee.Committee
+	}
+
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_beacon_committees(f_slot
                                      ,f_index
-                                     ,f_committee)
-      VALUES($1,$2,$3)
+                                     ,f_committee
+                                     ,f_committee_compact)
+      VALUES($1,$2,$3,$4)
       ON CONFLICT (f_slot,f_index) DO
       UPDATE
       SET f_committee = excluded.f_committee
+         ,f_committee_compact = excluded.f_committee_compact
 		 `,
 		beaconCommittee.Slot,
 		beaconCommittee.Index,
-		beaconCommittee.Committee,
+		committee,
+		compactCommittee,
 	)
 
 	return err
@@ -82,6 +99,7 @@ func (s *Service) BeaconCommittees(ctx context.Context,
 SELECT f_slot
       ,f_index
       ,f_committee
+      ,f_committee_compact
 FROM t_beacon_committees`)
 
 	wherestr := "WHERE"
@@ -142,20 +160,22 @@ LIMIT $%d`, len(queryVals)))
 	span.AddEvent("Ran query")
 
 	committees := make([]*chaindb.BeaconCommittee, 0)
-	var committeeMembers []uint64
 	for rows.Next() {
 		committee := &chaindb.BeaconCommittee{}
+		var committeeMembers []uint64
+		var compactCommittee []byte
 		err := rows.Scan(
 			&committee.Slot,
 			&committee.Index,
 			&committeeMembers,
+			&compactCommittee,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
-		committee.Committee = make([]phase0.ValidatorIndex, len(committeeMembers))
-		for i := range committeeMembers {
-			committee.Committee[i] = phase0.ValidatorIndex(committeeMembers[i])
+		committee.Committee, err = committeeFromRow(committeeMembers, compactCommittee)
+		if err != nil {
+			return nil, err
 		}
 		committees = append(committees, committee)
 	}
@@ -188,11 +208,13 @@ func (s *Service) BeaconCommitteeBySlotAndIndex(ctx context.Context, slot phase0
 
 	committee := &chaindb.BeaconCommittee{}
 	var committeeMembers []uint64
+	var compactCommittee []byte
 
 	err := tx.QueryRow(ctx, `
       SELECT f_slot
             ,f_index
             ,f_committee
+            ,f_committee_compact
       FROM t_beacon_committees
       WHERE f_slot = $1
         AND f_index = $2`,
@@ -202,13 +224,14 @@ func (s *Service) BeaconCommitteeBySlotAndIndex(ctx context.Context, slot phase0
 		&committee.Slot,
 		&committee.Index,
 		&committeeMembers,
+		&compactCommittee,
 	)
 	if err != nil {
 		return nil, err
 	}
-	committee.Committee = make([]phase0.ValidatorIndex, len(committeeMembers))
-	for i := range committeeMembers {
-		committee.Committee[i] = phase0.ValidatorIndex(committeeMembers[i])
+	committee.Committee, err = committeeFromRow(committeeMembers, compactCommittee)
+	if err != nil {
+		return nil, err
 	}
 	return committee, nil
 }
@@ -228,14 +251,17 @@ func (s *Service) AttesterDuties(ctx context.Context, startSlot phase0.Slot, end
 		tx = s.tx(ctx)
 	}
 
+	// Compact committees cannot be searched by the database, so they are
+	// all returned and decoded here.
 	rows, err := tx.Query(ctx, `
       SELECT f_slot
             ,f_index
             ,f_committee
+            ,f_committee_compact
       FROM t_beacon_committees
       WHERE f_slot >= $1
         AND f_slot < $2
-        AND $3 && f_committee
+        AND ($3 && f_committee OR f_committee_compact IS NOT NULL)
         ORDER BY f_slot, f_index`,
 		startSlot,
 		endSlot,
@@ -257,22 +283,28 @@ func (s *Service) AttesterDuties(ctx context.Context, startSlot phase0.Slot, end
 	for rows.Next() {
 		var slot uint64
 		var index uint64
-		var committee []uint64
+		var committeeMembers []uint64
+		var compactCommittee []byte
 		err := rows.Scan(
 			&slot,
 			&index,
-			&committee,
+			&committeeMembers,
+			&compactCommittee,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
+		committee, err := committeeFromRow(committeeMembers, compactCommittee)
+		if err != nil {
+			return nil, err
+		}
 
 		for i, validatorIndex := range committee {
-			if validatorIndicesMap[phase0.ValidatorIndex(validatorIndex)] {
+			if validatorIndicesMap[validatorIndex] {
 				res = append(res, &chaindb.AttesterDuty{
 					Slot:           phase0.Slot(slot),
 					Committee:      phase0.CommitteeIndex(index),
-					ValidatorIndex: phase0.ValidatorIndex(validatorIndex),
+					ValidatorIndex: validatorIndex,
 					CommitteeIndex: uint64(i),
 				})
 			}
@@ -281,3 +313,94 @@ func (s *Service) AttesterDuties(ctx context.Context, startSlot phase0.Slot, end
 
 	return res, nil
 }
+
+// CompactBeaconCommittees rewrites up to batchSize beacon committees held in
+// full form in compact form.
+// It returns the number of committees rewritten, so a return of 0 means that
+// all committees are compact.
+func (s *Service) CompactBeaconCommittees(ctx context.Context, batchSize int) (int, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "CompactBeaconCommittees")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return 0, ErrNoTransaction
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_slot
+            ,f_index
+            ,f_committee
+      FROM t_beacon_committees
+      WHERE f_committee IS NOT NULL
+      ORDER BY f_slot, f_index
+      LIMIT $1
+      FOR UPDATE`,
+		batchSize,
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain committees")
+	}
+	committees := make([]*chaindb.BeaconCommittee, 0, batchSize)
+	for rows.Next() {
+		committee := &chaindb.BeaconCommittee{}
+		var committeeMembers []uint64
+		if err := rows.Scan(
+			&committee.Slot,
+			&committee.Index,
+			&committeeMembers,
+		); err != nil {
+			rows.Close()
+			return 0, errors.Wrap(err, "failed to scan row")
+		}
+		committee.Committee, err = committeeFromRow(committeeMembers, nil)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		committees = append(committees, committee)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, errors.Wrap(err, "failed to read committees")
+	}
+
+	for _, committee := range committees {
+		compactCommittee, err := util.EncodeCommittee(committee.Committee)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to encode committee")
+		}
+		if _, err := tx.Exec(ctx, `
+      UPDATE t_beacon_committees
+      SET f_committee = NULL
+         ,f_committee_compact = $3
+      WHERE f_slot = $1
+        AND f_index = $2`,
+			committee.Slot,
+			committee.Index,
+			compactCommittee,
+		); err != nil {
+			return 0, errors.Wrap(err, "failed to update committee")
+		}
+	}
+	span.AddEvent("Compacted committees", trace.WithAttributes(attribute.Int("entries", len(committees))))
+
+	return len(committees), nil
+}
+
+// committeeFromRow returns the committee from a row, decoding it if it is held in compact form.
+func committeeFromRow(committeeMembers []uint64, compactCommittee []byte) ([]phase0.ValidatorIndex, error) {
+	if compactCommittee != nil {
+		committee, err := util.DecodeCommittee(compactCommittee)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode committee")
+		}
+		return committee, nil
+	}
+
+	committee := make([]phase0.ValidatorIndex, len(committeeMembers))
+	for i := range committeeMembers {
+		committee[i] = phase0.ValidatorIndex(committeeMembers[i])
+	}
+	return committee, nil
+}
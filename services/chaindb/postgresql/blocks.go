@@ -20,10 +20,12 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 	"go.opentelemetry.io/otel"
 )
 
@@ -42,6 +44,20 @@ func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
 		canonical.Valid = true
 		canonical.Bool = *block.Canonical
 	}
+	var sszSize sql.NullInt64
+	if block.SSZSize != nil {
+		sszSize.Valid = true
+		sszSize.Int64 = int64(*block.SSZSize)
+	}
+	var arrivalDelay sql.NullInt64
+	if block.ArrivalDelay != nil {
+		arrivalDelay.Valid = true
+		arrivalDelay.Int64 = block.ArrivalDelay.Milliseconds()
+	}
+	randaoReveal, err := util.CompressColumn(block.RANDAOReveal[:], signatureLength, s.columnCompression)
+	if err != nil {
+		return errors.Wrap(err, "failed to compress RANDAO reveal")
+	}
 	if _, err := tx.Exec(ctx, `
       INSERT INTO t_blocks(f_slot
                           ,f_proposer_index
@@ -55,8 +71,10 @@ func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
                           ,f_eth1_block_hash
                           ,f_eth1_deposit_count
                           ,f_eth1_deposit_root
+                          ,f_ssz_size
+                          ,f_arrival_delay
 						  )
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
       ON CONFLICT (f_root) DO
       UPDATE
       SET f_slot = excluded.f_slot
@@ -70,12 +88,14 @@ func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
          ,f_eth1_block_hash = excluded.f_eth1_block_hash
          ,f_eth1_deposit_count = excluded.f_eth1_deposit_count
          ,f_eth1_deposit_root = excluded.f_eth1_deposit_root
+         ,f_ssz_size = COALESCE(excluded.f_ssz_size, t_blocks.f_ssz_size)
+         ,f_arrival_delay = COALESCE(t_blocks.f_arrival_delay, excluded.f_arrival_delay)
 	  `,
 		block.Slot,
 		block.ProposerIndex,
 		block.Root[:],
 		block.Graffiti,
-		block.RANDAOReveal[:],
+		randaoReveal,
 		block.BodyRoot[:],
 		block.ParentRoot[:],
 		block.StateRoot[:],
@@ -83,10 +103,19 @@ func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
 		block.ETH1BlockHash,
 		block.ETH1DepositCount,
 		block.ETH1DepositRoot[:],
+		sszSize,
+		arrivalDelay,
 	); err != nil {
 		return err
 	}
 
+	// A canonical block means that the slot was not skipped.
+	if canonical.Valid && canonical.Bool {
+		if _, err := tx.Exec(ctx, "DELETE FROM t_skipped_slots WHERE f_slot = $1", block.Slot); err != nil {
+			return errors.Wrap(err, "failed to remove skipped slot")
+		}
+	}
+
 	// Set execution payload (will return without error if payload is not present).
 	if err := s.setExecutionPayload(ctx, block); err != nil {
 		return errors.Wrap(err, "failed to set execution payload")
@@ -132,6 +161,8 @@ SELECT f_slot
       ,f_eth1_block_hash
       ,f_eth1_deposit_count
       ,f_eth1_deposit_root
+      ,f_ssz_size
+      ,f_arrival_delay
 FROM t_blocks`)
 
 	wherestr := "WHERE"
@@ -199,6 +230,8 @@ LIMIT $%d`, len(queryVals)))
 		var stateRoot []byte
 		var canonical sql.NullBool
 		var eth1DepositRoot []byte
+		var sszSize sql.NullInt64
+		var arrivalDelay sql.NullInt64
 		err := rows.Scan(
 			&block.Slot,
 			&block.ProposerIndex,
@@ -212,11 +245,17 @@ LIMIT $%d`, len(queryVals)))
 			&block.ETH1BlockHash,
 			&block.ETH1DepositCount,
 			&eth1DepositRoot,
+			&sszSize,
+			&arrivalDelay,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		copy(block.Root[:], blockRoot)
+		randaoReveal, err = util.DecompressColumn(randaoReveal, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress RANDAO reveal")
+		}
 		copy(block.RANDAOReveal[:], randaoReveal)
 		copy(block.BodyRoot[:], bodyRoot)
 		copy(block.ParentRoot[:], parentRoot)
@@ -226,6 +265,14 @@ LIMIT $%d`, len(queryVals)))
 			block.Canonical = &val
 		}
 		copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+		if sszSize.Valid {
+			val := uint64(sszSize.Int64)
+			block.SSZSize = &val
+		}
+		if arrivalDelay.Valid {
+			val := time.Duration(arrivalDelay.Int64) * time.Millisecond
+			block.ArrivalDelay = &val
+		}
 		blocks = append(blocks, block)
 	}
 
@@ -285,6 +332,8 @@ func (s *Service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaind
             ,f_eth1_block_hash
             ,f_eth1_deposit_count
             ,f_eth1_deposit_root
+            ,f_ssz_size
+            ,f_arrival_delay
       FROM t_blocks
       WHERE f_slot = $1`,
 		slot,
@@ -305,6 +354,8 @@ func (s *Service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaind
 		var stateRoot []byte
 		var canonical sql.NullBool
 		var eth1DepositRoot []byte
+		var sszSize sql.NullInt64
+		var arrivalDelay sql.NullInt64
 		err := rows.Scan(
 			&block.Slot,
 			&block.ProposerIndex,
@@ -318,11 +369,17 @@ func (s *Service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaind
 			&block.ETH1BlockHash,
 			&block.ETH1DepositCount,
 			&eth1DepositRoot,
+			&sszSize,
+			&arrivalDelay,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		copy(block.Root[:], blockRoot)
+		randaoReveal, err = util.DecompressColumn(randaoReveal, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress RANDAO reveal")
+		}
 		copy(block.RANDAOReveal[:], randaoReveal)
 		copy(block.BodyRoot[:], bodyRoot)
 		copy(block.ParentRoot[:], parentRoot)
@@ -332,6 +389,14 @@ func (s *Service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaind
 			block.Canonical = &val
 		}
 		copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+		if sszSize.Valid {
+			val := uint64(sszSize.Int64)
+			block.SSZSize = &val
+		}
+		if arrivalDelay.Valid {
+			val := time.Duration(arrivalDelay.Int64) * time.Millisecond
+			block.ArrivalDelay = &val
+		}
 		blocks = append(blocks, block)
 	}
 
@@ -378,6 +443,8 @@ func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot,
             ,f_eth1_block_hash
             ,f_eth1_deposit_count
             ,f_eth1_deposit_root
+            ,f_ssz_size
+            ,f_arrival_delay
       FROM t_blocks
       WHERE f_slot >= $1
         AND f_slot < $2
@@ -400,6 +467,8 @@ func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot,
 		var stateRoot []byte
 		var canonical sql.NullBool
 		var eth1DepositRoot []byte
+		var sszSize sql.NullInt64
+		var arrivalDelay sql.NullInt64
 		err := rows.Scan(
 			&block.Slot,
 			&block.ProposerIndex,
@@ -413,11 +482,17 @@ func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot,
 			&block.ETH1BlockHash,
 			&block.ETH1DepositCount,
 			&eth1DepositRoot,
+			&sszSize,
+			&arrivalDelay,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		copy(block.Root[:], blockRoot)
+		randaoReveal, err = util.DecompressColumn(randaoReveal, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress RANDAO reveal")
+		}
 		copy(block.RANDAOReveal[:], randaoReveal)
 		copy(block.BodyRoot[:], bodyRoot)
 		copy(block.ParentRoot[:], parentRoot)
@@ -427,6 +502,14 @@ func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot,
 			block.Canonical = &val
 		}
 		copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+		if sszSize.Valid {
+			val := uint64(sszSize.Int64)
+			block.SSZSize = &val
+		}
+		if arrivalDelay.Valid {
+			val := time.Duration(arrivalDelay.Int64) * time.Millisecond
+			block.ArrivalDelay = &val
+		}
 		blocks = append(blocks, block)
 	}
 
@@ -466,6 +549,8 @@ func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.B
 	var stateRoot []byte
 	var canonical sql.NullBool
 	var eth1DepositRoot []byte
+	var sszSize sql.NullInt64
+	var arrivalDelay sql.NullInt64
 
 	err = tx.QueryRow(ctx, `
       SELECT f_slot
@@ -480,6 +565,8 @@ func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.B
             ,f_eth1_block_hash
             ,f_eth1_deposit_count
             ,f_eth1_deposit_root
+            ,f_ssz_size
+            ,f_arrival_delay
       FROM t_blocks
       WHERE f_root = $1`,
 		root[:],
@@ -496,11 +583,17 @@ func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.B
 		&block.ETH1BlockHash,
 		&block.ETH1DepositCount,
 		&eth1DepositRoot,
+		&sszSize,
+		&arrivalDelay,
 	)
 	if err != nil {
 		return nil, err
 	}
 	copy(block.Root[:], blockRoot)
+	randaoReveal, err = util.DecompressColumn(randaoReveal, signatureLength)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decompress RANDAO reveal")
+	}
 	copy(block.RANDAOReveal[:], randaoReveal)
 	copy(block.BodyRoot[:], bodyRoot)
 	copy(block.ParentRoot[:], parentRoot)
@@ -510,6 +603,14 @@ func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.B
 		block.Canonical = &val
 	}
 	copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+	if sszSize.Valid {
+		val := uint64(sszSize.Int64)
+		block.SSZSize = &val
+	}
+	if arrivalDelay.Valid {
+		val := time.Duration(arrivalDelay.Int64) * time.Millisecond
+		block.ArrivalDelay = &val
+	}
 
 	// Add execution payload to the block if available.
 	block.ExecutionPayload, err = s.executionPayload(ctx, tx, block.Root)
@@ -600,6 +701,8 @@ func (s *Service) BlocksByParentRoot(ctx context.Context, parentRoot phase0.Root
             ,f_eth1_block_hash
             ,f_eth1_deposit_count
             ,f_eth1_deposit_root
+            ,f_ssz_size
+            ,f_arrival_delay
       FROM t_blocks
       WHERE f_parent_root = $1`,
 		parentRoot[:],
@@ -619,6 +722,8 @@ func (s *Service) BlocksByParentRoot(ctx context.Context, parentRoot phase0.Root
 		var stateRoot []byte
 		var canonical sql.NullBool
 		var eth1DepositRoot []byte
+		var sszSize sql.NullInt64
+		var arrivalDelay sql.NullInt64
 		err := rows.Scan(
 			&block.Slot,
 			&block.ProposerIndex,
@@ -632,11 +737,17 @@ func (s *Service) BlocksByParentRoot(ctx context.Context, parentRoot phase0.Root
 			&block.ETH1BlockHash,
 			&block.ETH1DepositCount,
 			&eth1DepositRoot,
+			&sszSize,
+			&arrivalDelay,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		copy(block.Root[:], blockRoot)
+		randaoReveal, err = util.DecompressColumn(randaoReveal, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress RANDAO reveal")
+		}
 		copy(block.RANDAOReveal[:], randaoReveal)
 		copy(block.BodyRoot[:], bodyRoot)
 		copy(block.ParentRoot[:], parentRoot)
@@ -646,6 +757,14 @@ func (s *Service) BlocksByParentRoot(ctx context.Context, parentRoot phase0.Root
 			block.Canonical = &val
 		}
 		copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+		if sszSize.Valid {
+			val := uint64(sszSize.Int64)
+			block.SSZSize = &val
+		}
+		if arrivalDelay.Valid {
+			val := time.Duration(arrivalDelay.Int64) * time.Millisecond
+			block.ArrivalDelay = &val
+		}
 		blocks = append(blocks, block)
 	}
 
@@ -781,6 +900,8 @@ func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
             ,f_eth1_block_hash
             ,f_eth1_deposit_count
             ,f_eth1_deposit_root
+            ,f_ssz_size
+            ,f_arrival_delay
       FROM t_blocks
       WHERE f_slot = (SELECT MAX(f_slot) FROM t_blocks)`)
 	if err != nil {
@@ -799,6 +920,8 @@ func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
 		var stateRoot []byte
 		var canonical sql.NullBool
 		var eth1DepositRoot []byte
+		var sszSize sql.NullInt64
+		var arrivalDelay sql.NullInt64
 		err := rows.Scan(
 			&block.Slot,
 			&block.ProposerIndex,
@@ -812,11 +935,17 @@ func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
 			&block.ETH1BlockHash,
 			&block.ETH1DepositCount,
 			&eth1DepositRoot,
+			&sszSize,
+			&arrivalDelay,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		copy(block.Root[:], blockRoot)
+		randaoReveal, err = util.DecompressColumn(randaoReveal, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress RANDAO reveal")
+		}
 		copy(block.RANDAOReveal[:], randaoReveal)
 		copy(block.BodyRoot[:], bodyRoot)
 		copy(block.ParentRoot[:], parentRoot)
@@ -826,6 +955,14 @@ func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
 			block.Canonical = &val
 		}
 		copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+		if sszSize.Valid {
+			val := uint64(sszSize.Int64)
+			block.SSZSize = &val
+		}
+		if arrivalDelay.Valid {
+			val := time.Duration(arrivalDelay.Int64) * time.Millisecond
+			block.ArrivalDelay = &val
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/rs/zerolog"
@@ -109,3 +110,62 @@ func TestIndeterminateBlocks(t *testing.T) {
 	require.NotNil(t, dbBlock.Canonical)
 	require.True(t, *dbBlock.Canonical)
 }
+
+func TestBlockSizeAndArrivalDelay(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	block := &chaindb.Block{
+		Slot:          2,
+		ProposerIndex: 3,
+		Root: phase0.Root{
+			0x70, 0x71, 0x72, 0x73, 0x74, 0x74, 0x76, 0x77, 0x78, 0x79, 0x7a, 0x7b, 0x7c, 0x7d, 0x7e, 0x7f,
+			0x70, 0x71, 0x72, 0x73, 0x74, 0x74, 0x76, 0x77, 0x78, 0x79, 0x7a, 0x7b, 0x7c, 0x7d, 0x7e, 0x7f,
+		},
+		Graffiti:      []byte{},
+		ETH1BlockHash: []byte{},
+	}
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	// Set the block without size or arrival delay.
+	require.NoError(t, s.SetBlock(ctx, block))
+	dbBlock, err := s.BlockByRoot(ctx, block.Root)
+	require.NoError(t, err)
+	require.Nil(t, dbBlock.SSZSize)
+	require.Nil(t, dbBlock.ArrivalDelay)
+
+	// Set the block with size and arrival delay.
+	sszSize := uint64(123456)
+	arrivalDelay := 1500 * time.Millisecond
+	block.SSZSize = &sszSize
+	block.ArrivalDelay = &arrivalDelay
+	require.NoError(t, s.SetBlock(ctx, block))
+	dbBlock, err = s.BlockByRoot(ctx, block.Root)
+	require.NoError(t, err)
+	require.Equal(t, sszSize, *dbBlock.SSZSize)
+	require.Equal(t, arrivalDelay, *dbBlock.ArrivalDelay)
+
+	// Set the block again without them, as when refetched; ensure they are retained.
+	block.SSZSize = nil
+	block.ArrivalDelay = nil
+	require.NoError(t, s.SetBlock(ctx, block))
+	dbBlock, err = s.BlockByRoot(ctx, block.Root)
+	require.NoError(t, err)
+	require.Equal(t, sszSize, *dbBlock.SSZSize)
+	require.Equal(t, arrivalDelay, *dbBlock.ArrivalDelay)
+
+	// Set a later arrival delay; ensure the first is retained.
+	laterArrivalDelay := 3 * time.Second
+	block.ArrivalDelay = &laterArrivalDelay
+	require.NoError(t, s.SetBlock(ctx, block))
+	dbBlock, err = s.BlockByRoot(ctx, block.Root)
+	require.NoError(t, err)
+	require.Equal(t, arrivalDelay, *dbBlock.ArrivalDelay)
+}
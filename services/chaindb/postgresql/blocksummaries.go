@@ -39,20 +39,23 @@ func (s *Service) SetBlockSummary(ctx context.Context, summary *chaindb.BlockSum
                                    ,f_attestations_for_block
                                    ,f_duplicate_attestations_for_block
                                    ,f_votes_for_block
-                                   ,f_parent_distance)
-      VALUES($1,$2,$3,$4,$5)
+                                   ,f_parent_distance
+                                   ,f_summarizer_version)
+      VALUES($1,$2,$3,$4,$5,$6)
       ON CONFLICT (f_slot) DO
       UPDATE
       SET f_attestations_for_block = excluded.f_attestations_for_block
          ,f_duplicate_attestations_for_block = excluded.f_duplicate_attestations_for_block
          ,f_votes_for_block = excluded.f_votes_for_block
          ,f_parent_distance = excluded.f_parent_distance
+         ,f_summarizer_version = excluded.f_summarizer_version
 		 `,
 		summary.Slot,
 		summary.AttestationsForBlock,
 		summary.DuplicateAttestationsForBlock,
 		summary.VotesForBlock,
 		summary.ParentDistance,
+		summary.SummarizerVersion,
 	)
 
 	return err
@@ -82,6 +85,7 @@ SELECT f_slot
       ,f_duplicate_attestations_for_block
       ,f_votes_for_block
       ,f_parent_distance
+      ,f_summarizer_version
 FROM t_block_summaries`)
 
 	conditions := make([]string, 0)
@@ -145,6 +149,7 @@ LIMIT $%d`, len(queryVals)))
 			&summary.DuplicateAttestationsForBlock,
 			&summary.VotesForBlock,
 			&summary.ParentDistance,
+			&summary.SummarizerVersion,
 		); err != nil {
 			return nil, err
 		}
@@ -177,6 +182,7 @@ SELECT f_attestations_for_block
       ,f_duplicate_attestations_for_block
       ,f_votes_for_block
       ,f_parent_distance
+      ,f_summarizer_version
 FROM t_block_summaries
 WHERE f_slot = $1
 `,
@@ -186,6 +192,7 @@ WHERE f_slot = $1
 		&summary.DuplicateAttestationsForBlock,
 		&summary.VotesForBlock,
 		&summary.ParentDistance,
+		&summary.SummarizerVersion,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to scan row")
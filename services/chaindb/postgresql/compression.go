@@ -0,0 +1,193 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// signatureLength is the length of an uncompressed signature.
+const signatureLength = phase0.SignatureLength
+
+// compressedColumn is a large byte column that is compressed.
+type compressedColumn struct {
+	table  string
+	column string
+	// rawLength is the length of uncompressed values, or 0 if they vary in length.
+	rawLength int
+	// slotRange selects the rows for data included in blocks from slot $1 up to, but not including, slot $2.
+	slotRange string
+}
+
+// compressedColumns are the columns that are compressed.
+var compressedColumns = []*compressedColumn{
+	{
+		table:     "t_attestations",
+		column:    "f_aggregation_bits",
+		slotRange: "f_inclusion_slot >= $1 AND f_inclusion_slot < $2",
+	},
+	{
+		table:     "t_aggregate_attestations",
+		column:    "f_aggregation_bits",
+		slotRange: "f_slot >= $1 AND f_slot < $2",
+	},
+	{
+		table:     "t_block_execution_payloads",
+		column:    "f_logs_bloom",
+		rawLength: logsBloomLength,
+		slotRange: "f_block_root IN (SELECT f_root FROM t_blocks WHERE f_slot >= $1 AND f_slot < $2)",
+	},
+	{
+		table:     "t_blocks",
+		column:    "f_randao_reveal",
+		rawLength: signatureLength,
+		slotRange: "f_slot >= $1 AND f_slot < $2",
+	},
+	{
+		table:     "t_proposer_slashings",
+		column:    "f_header_1_signature",
+		rawLength: signatureLength,
+		slotRange: "f_inclusion_slot >= $1 AND f_inclusion_slot < $2",
+	},
+	{
+		table:     "t_proposer_slashings",
+		column:    "f_header_2_signature",
+		rawLength: signatureLength,
+		slotRange: "f_inclusion_slot >= $1 AND f_inclusion_slot < $2",
+	},
+	{
+		table:     "t_attester_slashings",
+		column:    "f_attestation_1_signature",
+		rawLength: signatureLength,
+		slotRange: "f_inclusion_slot >= $1 AND f_inclusion_slot < $2",
+	},
+	{
+		table:     "t_attester_slashings",
+		column:    "f_attestation_2_signature",
+		rawLength: signatureLength,
+		slotRange: "f_inclusion_slot >= $1 AND f_inclusion_slot < $2",
+	},
+}
+
+// CompressColumnsForSlotRange compresses the large byte columns of data
+// included in blocks in the given slot range that are stored uncompressed,
+// using the configured column compression format.
+// Ranges are inclusive of start and exclusive of end.
+// It returns the number of values rewritten.
+func (s *Service) CompressColumnsForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) (int, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "CompressColumnsForSlotRange")
+	defer span.End()
+
+	if s.tx(ctx) == nil {
+		return 0, ErrNoTransaction
+	}
+	if s.columnCompression == util.CompressionNone {
+		return 0, nil
+	}
+
+	compressed := 0
+	for _, column := range compressedColumns {
+		columnCompressed, err := s.compressColumnForSlotRange(ctx, column, startSlot, endSlot)
+		if err != nil {
+			return 0, errors.Wrap(err, fmt.Sprintf("failed to compress %s.%s", column.table, column.column))
+		}
+		compressed += columnCompressed
+	}
+	span.AddEvent("Compressed columns", trace.WithAttributes(attribute.Int("entries", compressed)))
+
+	return compressed, nil
+}
+
+// compressColumnForSlotRange compresses a single column for the given slot range.
+func (s *Service) compressColumnForSlotRange(ctx context.Context,
+	column *compressedColumn,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	int,
+	error,
+) {
+	tx := s.tx(ctx)
+
+	// Rows are identified by their physical location, which is stable whilst they are locked.
+	// Table and column names are from compressedColumns, so safe to include.
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+      SELECT ctid::TEXT
+            ,%s
+      FROM %s
+      WHERE %s
+      FOR UPDATE`, column.column, column.table, column.slotRange),
+		startSlot,
+		endSlot,
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain values")
+	}
+	type rowValue struct {
+		id    string
+		value []byte
+	}
+	values := make([]*rowValue, 0)
+	for rows.Next() {
+		value := &rowValue{}
+		if err := rows.Scan(
+			&value.id,
+			&value.value,
+		); err != nil {
+			rows.Close()
+			return 0, errors.Wrap(err, "failed to scan value")
+		}
+		if util.IsCompressedColumn(value.value, column.rawLength) {
+			continue
+		}
+		values = append(values, value)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, errors.Wrap(err, "failed to read values")
+	}
+
+	compressed := 0
+	for _, value := range values {
+		compressedValue, err := util.CompressColumn(value.value, column.rawLength, s.columnCompression)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to compress value")
+		}
+		if bytes.Equal(compressedValue, value.value) {
+			// Not compressible.
+			continue
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+      UPDATE %s
+      SET %s = $2
+      WHERE ctid = $1::TID`, column.table, column.column),
+			value.id,
+			compressedValue,
+		); err != nil {
+			return 0, errors.Wrap(err, "failed to update value")
+		}
+		compressed++
+	}
+
+	return compressed, nil
+}
@@ -0,0 +1,91 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestCompressColumnsForSlotRange(t *testing.T) {
+	ctx := context.Background()
+	raw, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+	_, err = raw.Upgrade(ctx)
+	require.NoError(t, err)
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+		postgresql.WithColumnCompression(util.CompressionSnappy),
+	)
+	require.NoError(t, err)
+
+	// Try to compress outside of a transaction; should fail.
+	_, err = s.CompressColumnsForSlotRange(ctx, 0, 32)
+	require.EqualError(t, err, postgresql.ErrNoTransaction.Error())
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	// Far in the future, so no data.
+	compressed, err := s.CompressColumnsForSlotRange(ctx, 32_000_000_000, 32_000_000_032)
+	require.NoError(t, err)
+	require.Zero(t, compressed)
+
+	// Values written uncompressed are compressed, and read back unaltered.
+	root := phase0.Root{0x01}
+	block := &chaindb.Block{
+		Slot:          32_000_000_000,
+		Root:          root,
+		RANDAOReveal:  phase0.BLSSignature{},
+		Graffiti:      []byte{},
+		ETH1BlockHash: []byte{},
+	}
+	require.NoError(t, raw.SetBlock(ctx, block))
+	proposerSlashing := &chaindb.ProposerSlashing{
+		InclusionSlot:      32_000_000_000,
+		InclusionBlockRoot: root,
+		Header1Signature:   phase0.BLSSignature{0x01},
+		Header2Signature:   phase0.BLSSignature{0x02},
+	}
+	require.NoError(t, raw.SetProposerSlashing(ctx, proposerSlashing))
+	compressed, err = s.CompressColumnsForSlotRange(ctx, 32_000_000_000, 32_000_000_032)
+	require.NoError(t, err)
+	require.Equal(t, 3, compressed)
+	stored, err := s.BlockByRoot(ctx, root)
+	require.NoError(t, err)
+	require.Equal(t, block.RANDAOReveal, stored.RANDAOReveal)
+	storedSlashings, err := s.ProposerSlashingsForSlotRange(ctx, 32_000_000_000, 32_000_000_032)
+	require.NoError(t, err)
+	require.Len(t, storedSlashings, 1)
+	require.Equal(t, proposerSlashing.Header1Signature, storedSlashings[0].Header1Signature)
+	require.Equal(t, proposerSlashing.Header2Signature, storedSlashings[0].Header2Signature)
+
+	// Values that are already compressed are left alone.
+	compressed, err = s.CompressColumnsForSlotRange(ctx, 32_000_000_000, 32_000_000_032)
+	require.NoError(t, err)
+	require.Zero(t, compressed)
+}
@@ -0,0 +1,177 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+)
+
+// consistencyIssuesLimit is the maximum number of issues returned by a single check.
+const consistencyIssuesLimit = 100
+
+// CheckConsistency runs a consistency check over an epoch, returning a description of each issue found.
+// The slot range is that of the epoch, inclusive of start and exclusive of end.
+func (s *Service) CheckConsistency(ctx context.Context,
+	check chaindb.ConsistencyCheck,
+	epoch phase0.Epoch,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]string,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "CheckConsistency")
+	defer span.End()
+
+	var query string
+	var args []interface{}
+	switch check {
+	case chaindb.ConsistencyCheckBlockParents:
+		// The earliest block has no parent in the database, either because it is the
+		// genesis block or because data before the origin was not ingested.
+		query = `
+      SELECT format('canonical block %s at slot %s has %s parent %s'
+                   ,'0x' || encode(b.f_root, 'hex')
+                   ,b.f_slot
+                   ,CASE WHEN p.f_root IS NULL THEN 'missing' ELSE 'non-canonical' END
+                   ,'0x' || encode(b.f_parent_root, 'hex'))
+      FROM t_blocks b
+      LEFT JOIN t_blocks p ON p.f_root = b.f_parent_root
+      WHERE b.f_slot >= $1
+        AND b.f_slot < $2
+        AND b.f_canonical
+        AND b.f_slot > (SELECT MIN(f_slot) FROM t_blocks)
+        AND (p.f_root IS NULL OR p.f_canonical IS DISTINCT FROM true)
+      ORDER BY b.f_slot
+      LIMIT $3`
+		args = []interface{}{startSlot, endSlot, consistencyIssuesLimit}
+	case chaindb.ConsistencyCheckBlockAttestations:
+		// Summaries count attestations, including duplicates, that are not known to be non-canonical.
+		table := "t_attestations"
+		if s.aggregateAttestations() {
+			table = "t_aggregate_attestations"
+		}
+		query = fmt.Sprintf(`
+      SELECT format('block summary for slot %%s has %%s attestations but %%s are stored'
+                   ,s.f_slot
+                   ,s.f_attestations_for_block + s.f_duplicate_attestations_for_block
+                   ,COUNT(a.f_beacon_block_root))
+      FROM t_block_summaries s
+      JOIN t_blocks b ON b.f_slot = s.f_slot AND b.f_canonical
+      LEFT JOIN %s a ON a.f_beacon_block_root = b.f_root AND (a.f_canonical IS NULL OR a.f_canonical)
+      WHERE s.f_slot >= $1
+        AND s.f_slot < $2
+      GROUP BY s.f_slot, s.f_attestations_for_block, s.f_duplicate_attestations_for_block
+      HAVING s.f_attestations_for_block + s.f_duplicate_attestations_for_block <> COUNT(a.f_beacon_block_root)
+      ORDER BY s.f_slot
+      LIMIT $3`, table)
+		args = []interface{}{startSlot, endSlot, consistencyIssuesLimit}
+	case chaindb.ConsistencyCheckValidatorBalances:
+		// Balances are only checked for epochs for which any balances are stored.
+		query = `
+      SELECT format('validator %s active in epoch %s has no balance', v.f_index, $1::BIGINT)
+      FROM t_validators v
+      WHERE v.f_activation_epoch <= $1
+        AND (v.f_exit_epoch IS NULL OR v.f_exit_epoch > $1)
+        AND EXISTS (SELECT 1 FROM t_validator_balances WHERE f_epoch = $1)
+        AND NOT EXISTS (SELECT 1 FROM t_validator_balances b WHERE b.f_validator_index = v.f_index AND b.f_epoch = $1)
+      ORDER BY v.f_index
+      LIMIT $2`
+		args = []interface{}{epoch, consistencyIssuesLimit}
+	case chaindb.ConsistencyCheckDepositIndices:
+		// Deposits seen by canonical blocks in the range must be present, within the
+		// range of deposits that have been fetched.
+		query = `
+      WITH bounds AS (
+        SELECT GREATEST(COALESCE((SELECT f_eth1_deposit_count FROM t_blocks WHERE f_slot < $1 AND f_canonical ORDER BY f_slot DESC LIMIT 1), 0)
+                       ,(SELECT MIN(f_deposit_index) FROM t_eth1_deposits)) AS f_low
+              ,LEAST(COALESCE((SELECT MAX(f_eth1_deposit_count) FROM t_blocks WHERE f_slot >= $1 AND f_slot < $2 AND f_canonical), 0)
+                    ,(SELECT MAX(f_deposit_index) + 1 FROM t_eth1_deposits)) AS f_high
+              ,(SELECT COUNT(*) FROM t_eth1_deposits) AS f_deposits
+      )
+      SELECT format('deposit %s seen by the chain is missing', i)
+      FROM bounds, generate_series(bounds.f_low, bounds.f_high - 1) AS i
+      WHERE bounds.f_deposits > 0
+        AND NOT EXISTS (SELECT 1 FROM t_eth1_deposits WHERE f_deposit_index = i)
+      ORDER BY i
+      LIMIT $3`
+		args = []interface{}{startSlot, endSlot, consistencyIssuesLimit}
+	default:
+		return nil, fmt.Errorf("unknown consistency check %q", check)
+	}
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to run consistency check")
+	}
+	defer rows.Close()
+
+	issues := make([]string, 0)
+	for rows.Next() {
+		var issue string
+		if err := rows.Scan(&issue); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, nil
+}
+
+// SetConsistencyIssue records a consistency issue.
+// An issue that has already been recorded has its timestamp updated.
+func (s *Service) SetConsistencyIssue(ctx context.Context, issue *chaindb.ConsistencyIssue) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetConsistencyIssue")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+      INSERT INTO t_consistency_issues(f_check
+                                      ,f_epoch
+                                      ,f_detail
+                                      ,f_first_seen
+                                      ,f_last_seen)
+      VALUES($1,$2,$3,$4,$4)
+      ON CONFLICT (f_check,f_epoch,f_detail) DO
+      UPDATE
+      SET f_last_seen = excluded.f_last_seen
+		 `,
+		string(issue.Check),
+		issue.Epoch,
+		issue.Detail,
+		issue.Timestamp,
+	)
+
+	return err
+}
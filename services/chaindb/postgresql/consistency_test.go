@@ -0,0 +1,84 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestCheckConsistency(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+	_, err = s.Upgrade(ctx)
+	require.NoError(t, err)
+
+	for _, check := range []chaindb.ConsistencyCheck{
+		chaindb.ConsistencyCheckBlockParents,
+		chaindb.ConsistencyCheckBlockAttestations,
+		chaindb.ConsistencyCheckValidatorBalances,
+		chaindb.ConsistencyCheckDepositIndices,
+	} {
+		t.Run(string(check), func(t *testing.T) {
+			// Far in the future, so no data.
+			issues, err := s.CheckConsistency(ctx, check, 1_000_000_000, 32_000_000_000, 32_000_000_032)
+			require.NoError(t, err)
+			require.Empty(t, issues)
+		})
+	}
+
+	_, err = s.CheckConsistency(ctx, "unknown", 0, 0, 32)
+	require.EqualError(t, err, `unknown consistency check "unknown"`)
+}
+
+func TestSetConsistencyIssue(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+	_, err = s.Upgrade(ctx)
+	require.NoError(t, err)
+
+	issue := &chaindb.ConsistencyIssue{
+		Check:     chaindb.ConsistencyCheckBlockParents,
+		Epoch:     1_000_000_000,
+		Detail:    "test issue",
+		Timestamp: time.Now(),
+	}
+
+	// Try to set outside of a transaction; should fail.
+	require.EqualError(t, s.SetConsistencyIssue(ctx, issue), postgresql.ErrNoTransaction.Error())
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	// Setting the same issue twice updates it.
+	require.NoError(t, s.SetConsistencyIssue(ctx, issue))
+	issue.Timestamp = issue.Timestamp.Add(time.Minute)
+	require.NoError(t, s.SetConsistencyIssue(ctx, issue))
+}
@@ -0,0 +1,242 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+)
+
+// SetEpochDepositSummaries sets multiple epoch deposit summaries, replacing any existing summaries for their epochs.
+func (s *Service) SetEpochDepositSummaries(ctx context.Context, summaries []*chaindb.EpochDepositSummary) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetEpochDepositSummaries")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Create a savepoint in case the copy fails.
+	nestedTx, err := tx.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create nested transaction")
+	}
+
+	_, err = nestedTx.CopyFrom(ctx,
+		pgx.Identifier{"t_epoch_deposit_summaries"},
+		[]string{
+			"f_epoch",
+			"f_deposits",
+			"f_amount",
+			"f_new_validators",
+			"f_new_validators_amount",
+			"f_top_ups",
+			"f_top_ups_amount",
+			"f_invalid_deposits",
+			"f_invalid_deposits_amount",
+			"f_eth1_tx_hashes",
+		},
+		pgx.CopyFromSlice(len(summaries), func(i int) ([]interface{}, error) {
+			return []interface{}{
+				summaries[i].Epoch,
+				summaries[i].Deposits,
+				summaries[i].Amount,
+				summaries[i].NewValidators,
+				summaries[i].NewValidatorsAmount,
+				summaries[i].TopUps,
+				summaries[i].TopUpsAmount,
+				summaries[i].InvalidDeposits,
+				summaries[i].InvalidDepositsAmount,
+				summaries[i].ETH1TxHashes,
+			}, nil
+		}))
+
+	if err == nil {
+		if err := nestedTx.Commit(ctx); err != nil {
+			return errors.Wrap(err, "failed to commit nested transaction")
+		}
+	} else {
+		if err := nestedTx.Rollback(ctx); err != nil {
+			return errors.Wrap(err, "failed to roll back nested transaction")
+		}
+
+		log.Debug().Err(err).Msg("Failed to copy insert epoch deposit summaries; applying one at a time")
+		for _, summary := range summaries {
+			if err := s.setEpochDepositSummary(ctx, tx, summary); err != nil {
+				log.Error().Err(err).Msg("Failure to insert individual summary")
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setEpochDepositSummary sets an epoch deposit summary, replacing any existing summary for the epoch.
+func (*Service) setEpochDepositSummary(ctx context.Context, tx pgx.Tx, summary *chaindb.EpochDepositSummary) error {
+	_, err := tx.Exec(ctx, `
+      INSERT INTO t_epoch_deposit_summaries(f_epoch
+                                           ,f_deposits
+                                           ,f_amount
+                                           ,f_new_validators
+                                           ,f_new_validators_amount
+                                           ,f_top_ups
+                                           ,f_top_ups_amount
+                                           ,f_invalid_deposits
+                                           ,f_invalid_deposits_amount
+                                           ,f_eth1_tx_hashes)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+      ON CONFLICT (f_epoch) DO
+      UPDATE
+      SET f_deposits = excluded.f_deposits
+         ,f_amount = excluded.f_amount
+         ,f_new_validators = excluded.f_new_validators
+         ,f_new_validators_amount = excluded.f_new_validators_amount
+         ,f_top_ups = excluded.f_top_ups
+         ,f_top_ups_amount = excluded.f_top_ups_amount
+         ,f_invalid_deposits = excluded.f_invalid_deposits
+         ,f_invalid_deposits_amount = excluded.f_invalid_deposits_amount
+         ,f_eth1_tx_hashes = excluded.f_eth1_tx_hashes
+		 `,
+		summary.Epoch,
+		summary.Deposits,
+		summary.Amount,
+		summary.NewValidators,
+		summary.NewValidatorsAmount,
+		summary.TopUps,
+		summary.TopUpsAmount,
+		summary.InvalidDeposits,
+		summary.InvalidDepositsAmount,
+		summary.ETH1TxHashes,
+	)
+
+	return err
+}
+
+// EpochDepositSummaries provides deposit summaries according to the filter.
+func (s *Service) EpochDepositSummaries(ctx context.Context, filter *chaindb.EpochSummaryFilter) ([]*chaindb.EpochDepositSummary, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "EpochDepositSummaries")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]interface{}, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_epoch
+      ,f_deposits
+      ,f_amount
+      ,f_new_validators
+      ,f_new_validators_amount
+      ,f_top_ups
+      ,f_top_ups_amount
+      ,f_invalid_deposits
+      ,f_invalid_deposits_amount
+      ,f_eth1_tx_hashes
+FROM t_epoch_deposit_summaries`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]*chaindb.EpochDepositSummary, 0)
+	for rows.Next() {
+		summary := &chaindb.EpochDepositSummary{}
+		err := rows.Scan(
+			&summary.Epoch,
+			&summary.Deposits,
+			&summary.Amount,
+			&summary.NewValidators,
+			&summary.NewValidatorsAmount,
+			&summary.TopUps,
+			&summary.TopUpsAmount,
+			&summary.InvalidDeposits,
+			&summary.InvalidDepositsAmount,
+			&summary.ETH1TxHashes,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		summaries = append(summaries, summary)
+	}
+
+	// Always return order of epoch.
+	sort.Slice(summaries, func(i int, j int) bool {
+		return summaries[i].Epoch < summaries[j].Epoch
+	})
+	return summaries, nil
+}
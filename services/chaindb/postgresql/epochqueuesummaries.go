@@ -0,0 +1,252 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+)
+
+// SetEpochQueueSummaries sets multiple epoch queue summaries.
+func (s *Service) SetEpochQueueSummaries(ctx context.Context, summaries []*chaindb.EpochQueueSummary) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetEpochQueueSummaries")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Create a savepoint in case the copy fails.
+	nestedTx, err := tx.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to create nested transaction")
+	}
+
+	_, err = nestedTx.CopyFrom(ctx,
+		pgx.Identifier{"t_epoch_queue_summaries"},
+		[]string{
+			"f_epoch",
+			"f_activation_queue_length",
+			"f_exit_queue_length",
+			"f_churn_limit",
+			"f_activation_churn_limit",
+			"f_activation_wait",
+			"f_exit_wait",
+		},
+		pgx.CopyFromSlice(len(summaries), func(i int) ([]interface{}, error) {
+			exitQueueLength, exitWait := exitQueueValues(summaries[i])
+			return []interface{}{
+				summaries[i].Epoch,
+				summaries[i].ActivationQueueLength,
+				exitQueueLength,
+				summaries[i].ChurnLimit,
+				summaries[i].ActivationChurnLimit,
+				summaries[i].ActivationWait,
+				exitWait,
+			}, nil
+		}))
+
+	if err == nil {
+		if err := nestedTx.Commit(ctx); err != nil {
+			return errors.Wrap(err, "failed to commit nested transaction")
+		}
+	} else {
+		if err := nestedTx.Rollback(ctx); err != nil {
+			return errors.Wrap(err, "failed to roll back nested transaction")
+		}
+
+		log.Debug().Err(err).Msg("Failed to copy insert epoch queue summaries; applying one at a time")
+		for _, summary := range summaries {
+			if err := s.setEpochQueueSummary(ctx, tx, summary); err != nil {
+				log.Error().Err(err).Msg("Failure to insert individual summary")
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setEpochQueueSummary sets an epoch queue summary, replacing any existing summary for the epoch.
+func (*Service) setEpochQueueSummary(ctx context.Context, tx pgx.Tx, summary *chaindb.EpochQueueSummary) error {
+	exitQueueLength, exitWait := exitQueueValues(summary)
+	_, err := tx.Exec(ctx, `
+      INSERT INTO t_epoch_queue_summaries(f_epoch
+                                         ,f_activation_queue_length
+                                         ,f_exit_queue_length
+                                         ,f_churn_limit
+                                         ,f_activation_churn_limit
+                                         ,f_activation_wait
+                                         ,f_exit_wait)
+      VALUES($1,$2,$3,$4,$5,$6,$7)
+      ON CONFLICT (f_epoch) DO
+      UPDATE
+      SET f_activation_queue_length = excluded.f_activation_queue_length
+         ,f_exit_queue_length = excluded.f_exit_queue_length
+         ,f_churn_limit = excluded.f_churn_limit
+         ,f_activation_churn_limit = excluded.f_activation_churn_limit
+         ,f_activation_wait = excluded.f_activation_wait
+         ,f_exit_wait = excluded.f_exit_wait
+		 `,
+		summary.Epoch,
+		summary.ActivationQueueLength,
+		exitQueueLength,
+		summary.ChurnLimit,
+		summary.ActivationChurnLimit,
+		summary.ActivationWait,
+		exitWait,
+	)
+
+	return err
+}
+
+// exitQueueValues returns the exit queue values of a summary, which are NULL if exit
+// queues were not tracked at the epoch.
+func exitQueueValues(summary *chaindb.EpochQueueSummary) (sql.NullInt64, sql.NullInt64) {
+	var exitQueueLength sql.NullInt64
+	if summary.ExitQueueLength != nil {
+		exitQueueLength.Valid = true
+		exitQueueLength.Int64 = int64(*summary.ExitQueueLength)
+	}
+	var exitWait sql.NullInt64
+	if summary.ExitWait != nil {
+		exitWait.Valid = true
+		exitWait.Int64 = int64(*summary.ExitWait)
+	}
+
+	return exitQueueLength, exitWait
+}
+
+// EpochQueueSummaries provides queue summaries according to the filter.
+func (s *Service) EpochQueueSummaries(ctx context.Context, filter *chaindb.EpochSummaryFilter) ([]*chaindb.EpochQueueSummary, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "EpochQueueSummaries")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]interface{}, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_epoch
+      ,f_activation_queue_length
+      ,f_exit_queue_length
+      ,f_churn_limit
+      ,f_activation_churn_limit
+      ,f_activation_wait
+      ,f_exit_wait
+FROM t_epoch_queue_summaries`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_epoch <= $%d`, wherestr, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_epoch DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	if e := log.Trace(); e.Enabled() {
+		params := make([]string, len(queryVals))
+		for i := range queryVals {
+			params[i] = fmt.Sprintf("%v", queryVals[i])
+		}
+		e.Str("query", strings.ReplaceAll(queryBuilder.String(), "\n", " ")).Strs("params", params).Msg("SQL query")
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]*chaindb.EpochQueueSummary, 0)
+	for rows.Next() {
+		summary := &chaindb.EpochQueueSummary{}
+		var exitQueueLength sql.NullInt64
+		var exitWait sql.NullInt64
+		err := rows.Scan(
+			&summary.Epoch,
+			&summary.ActivationQueueLength,
+			&exitQueueLength,
+			&summary.ChurnLimit,
+			&summary.ActivationChurnLimit,
+			&summary.ActivationWait,
+			&exitWait,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if exitQueueLength.Valid {
+			val := int(exitQueueLength.Int64)
+			summary.ExitQueueLength = &val
+		}
+		if exitWait.Valid {
+			val := phase0.Epoch(exitWait.Int64)
+			summary.ExitWait = &val
+		}
+		summaries = append(summaries, summary)
+	}
+
+	// Always return order of epoch.
+	sort.Slice(summaries, func(i int, j int) bool {
+		return summaries[i].Epoch < summaries[j].Epoch
+	})
+	return summaries, nil
+}
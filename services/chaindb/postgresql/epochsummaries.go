@@ -55,8 +55,10 @@ func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSum
                                    ,f_deposits
                                    ,f_exiting_validators
                                    ,f_canonical_blocks
-                                   ,f_withdrawals)
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21)
+                                   ,f_withdrawals
+                                   ,f_skipped_slots
+                                   ,f_summarizer_version)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23)
       ON CONFLICT (f_epoch) DO
       UPDATE
       SET f_activation_queue_length = excluded.f_activation_queue_length
@@ -79,6 +81,8 @@ func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSum
          ,f_exiting_validators = excluded.f_exiting_validators
          ,f_canonical_blocks = excluded.f_canonical_blocks
          ,f_withdrawals = excluded.f_withdrawals
+         ,f_skipped_slots = excluded.f_skipped_slots
+         ,f_summarizer_version = excluded.f_summarizer_version
 		 `,
 		summary.Epoch,
 		summary.ActivationQueueLength,
@@ -101,6 +105,8 @@ func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSum
 		summary.ExitingValidators,
 		summary.CanonicalBlocks,
 		summary.Withdrawals,
+		summary.SkippedSlots,
+		summary.SummarizerVersion,
 	)
 
 	return err
@@ -147,6 +153,8 @@ SELECT f_epoch
       ,f_exiting_validators
       ,f_canonical_blocks
       ,f_withdrawals
+      ,f_skipped_slots
+      ,f_summarizer_version
 FROM t_epoch_summaries`)
 
 	wherestr := "WHERE"
@@ -223,6 +231,8 @@ LIMIT $%d`, len(queryVals)))
 			&summary.ExitingValidators,
 			&summary.CanonicalBlocks,
 			&summary.Withdrawals,
+			&summary.SkippedSlots,
+			&summary.SummarizerVersion,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -46,8 +46,9 @@ func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Depos
                                  ,f_validator_pubkey
                                  ,f_withdrawal_credentials
                                  ,f_signature
-                                 ,f_amount)
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
+                                 ,f_amount
+                                 ,f_signature_valid)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)
       ON CONFLICT (f_deposit_index) DO
       UPDATE
       SET f_eth1_block_number = excluded.f_eth1_block_number
@@ -62,6 +63,7 @@ func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Depos
          ,f_withdrawal_credentials = excluded.f_withdrawal_credentials
          ,f_signature = excluded.f_signature
          ,f_amount = excluded.f_amount
+         ,f_signature_valid = excluded.f_signature_valid
       `,
 		deposit.ETH1BlockNumber,
 		deposit.ETH1BlockHash,
@@ -77,6 +79,7 @@ func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Depos
 		deposit.WithdrawalCredentials,
 		deposit.Signature[:],
 		deposit.Amount,
+		deposit.SignatureValid,
 	)
 
 	return err
@@ -116,6 +119,7 @@ func (s *Service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.
             ,f_withdrawal_credentials
             ,f_signature
             ,f_amount
+            ,f_signature_valid
       FROM t_eth1_deposits
       WHERE f_validator_pubkey = ANY($1)
       ORDER BY f_eth1_block_number
@@ -148,6 +152,7 @@ func (s *Service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.
 			&deposit.WithdrawalCredentials,
 			&signature,
 			&deposit.Amount,
+			&deposit.SignatureValid,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -159,3 +164,34 @@ func (s *Service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.
 
 	return deposits, nil
 }
+
+// ETH1DepositsCountToBlock counts the Ethereum 1 deposits in blocks up to and including the given block number.
+func (s *Service) ETH1DepositsCountToBlock(ctx context.Context, blockNumber uint64) (uint64, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ETH1DepositsCountToBlock")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	var count uint64
+	err := tx.QueryRow(ctx, `
+      SELECT COUNT(*)
+      FROM t_eth1_deposits
+      WHERE f_eth1_block_number <= $1`,
+		blockNumber,
+	).Scan(
+		&count,
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to count deposits")
+	}
+
+	return count, nil
+}
@@ -21,9 +21,13 @@ import (
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 	"go.opentelemetry.io/otel"
 )
 
+// logsBloomLength is the length of an uncompressed logs bloom.
+const logsBloomLength = 256
+
 // setExecutionPayload sets the execution payload of a block.
 func (s *Service) setExecutionPayload(ctx context.Context, block *chaindb.Block) error {
 	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "setExecutionPayload")
@@ -54,7 +58,12 @@ func (s *Service) setExecutionPayload(ctx context.Context, block *chaindb.Block)
 		extraData = &block.ExecutionPayload.ExtraData
 	}
 
-	_, err := tx.Exec(ctx, `
+	logsBloom, err := util.CompressColumn(block.ExecutionPayload.LogsBloom[:], logsBloomLength, s.columnCompression)
+	if err != nil {
+		return errors.Wrap(err, "failed to compress logs bloom")
+	}
+
+	_, err = tx.Exec(ctx, `
 INSERT INTO t_block_execution_payloads(f_block_root
                                       ,f_block_number
                                       ,f_block_hash
@@ -96,7 +105,7 @@ SET f_block_number = excluded.f_block_number
 		block.ExecutionPayload.FeeRecipient[:],
 		block.ExecutionPayload.StateRoot[:],
 		block.ExecutionPayload.ReceiptsRoot[:],
-		block.ExecutionPayload.LogsBloom[:],
+		logsBloom,
 		block.ExecutionPayload.PrevRandao[:],
 		block.ExecutionPayload.GasLimit,
 		block.ExecutionPayload.GasUsed,
@@ -183,6 +192,10 @@ WHERE f_block_root = $1`,
 	copy(payload.FeeRecipient[:], feeRecipient)
 	copy(payload.StateRoot[:], stateRoot)
 	copy(payload.ReceiptsRoot[:], receiptsRoot)
+	logsBloom, err = util.DecompressColumn(logsBloom, logsBloomLength)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decompress logs bloom")
+	}
 	copy(payload.LogsBloom[:], logsBloom)
 	copy(payload.PrevRandao[:], prevRandao)
 	payload.BaseFeePerGas = baseFeePerGas.BigInt()
@@ -267,6 +280,10 @@ WHERE f_block_root = ANY($1)`,
 		copy(payload.FeeRecipient[:], feeRecipient)
 		copy(payload.StateRoot[:], stateRoot)
 		copy(payload.ReceiptsRoot[:], receiptsRoot)
+		logsBloom, err = util.DecompressColumn(logsBloom, logsBloomLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress logs bloom")
+		}
 		copy(payload.LogsBloom[:], logsBloom)
 		copy(payload.PrevRandao[:], prevRandao)
 		payload.BaseFeePerGas = baseFeePerGas.BigInt()
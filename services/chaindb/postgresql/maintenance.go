@@ -0,0 +1,163 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
+)
+
+// maintenanceJobClass is the scheduler class of the maintenance job.
+const maintenanceJobClass = "dbmaint"
+
+// maintenance runs configured statements against the database on a schedule.
+type maintenance struct {
+	statements        []string
+	interval          time.Duration
+	window            *util.DailyWindow
+	maxReplicationLag time.Duration
+	maxActiveQueries  int
+	// running ensures that only a single maintenance run takes place at a time.
+	running sync.Mutex
+}
+
+// scheduleMaintenance schedules the periodic maintenance job.
+func (s *Service) scheduleMaintenance(ctx context.Context, scheduler scheduler.Service) error {
+	log.Info().
+		Strs("statements", s.maintenance.statements).
+		Dur("interval", s.maintenance.interval).
+		Str("window", s.maintenance.window.String()).
+		Msg("Scheduling database maintenance")
+
+	return scheduler.SchedulePeriodicJob(ctx,
+		maintenanceJobClass,
+		"dbmaint",
+		s.nextMaintenance,
+		nil,
+		s.maintain,
+		nil,
+	)
+}
+
+// nextMaintenance returns the time of the next maintenance run.
+func (s *Service) nextMaintenance(_ context.Context, _ interface{}) (time.Time, error) {
+	return s.maintenance.window.Next(time.Now().Add(s.maintenance.interval)), nil
+}
+
+// maintain runs the maintenance statements, unless the database is too busy.
+func (s *Service) maintain(ctx context.Context, _ interface{}) error {
+	if !s.maintenance.running.TryLock() {
+		log.Debug().Msg("Maintenance already running; skipping")
+		monitorMaintenanceSkipped("running")
+		return nil
+	}
+	defer s.maintenance.running.Unlock()
+
+	if !s.maintenance.window.Contains(time.Now()) {
+		log.Debug().Msg("Outside maintenance window; skipping")
+		monitorMaintenanceSkipped("window")
+		return nil
+	}
+
+	if s.maintenance.maxReplicationLag > 0 {
+		lag, err := s.replicationLag(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain replication lag")
+		}
+		if lag > s.maintenance.maxReplicationLag {
+			log.Info().Dur("replication_lag", lag).Msg("Replication lag too high; skipping maintenance")
+			monitorMaintenanceSkipped("replication_lag")
+			return nil
+		}
+	}
+
+	if s.maintenance.maxActiveQueries > 0 {
+		queries, err := s.ActiveQueries(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain active queries")
+		}
+		if queries > s.maintenance.maxActiveQueries {
+			log.Info().Int("active_queries", queries).Msg("Too many active queries; skipping maintenance")
+			monitorMaintenanceSkipped("active_queries")
+			return nil
+		}
+	}
+
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to acquire connection for maintenance")
+	}
+	defer conn.Release()
+	if s.longRunningStatementTimeout != s.statementTimeout {
+		// SET does not accept parameters, but the value is a formatted integer so safe to include.
+		if _, err := conn.Exec(ctx, fmt.Sprintf("SET statement_timeout = %s", statementTimeoutMS(s.longRunningStatementTimeout))); err != nil {
+			return errors.Wrap(err, "failed to set statement timeout for maintenance")
+		}
+		defer func() {
+			// Return the connection to the pool with its default timeout.
+			if _, err := conn.Exec(ctx, "RESET statement_timeout"); err != nil {
+				log.Warn().Err(err).Msg("Failed to reset statement timeout after maintenance")
+			}
+		}()
+	}
+
+	started := time.Now()
+	for _, statement := range s.maintenance.statements {
+		// Statements such as VACUUM cannot run inside a transaction, so run them directly.
+		statementStarted := time.Now()
+		if _, err := conn.Exec(ctx, statement); err != nil {
+			return errors.Wrap(wrapStatementTimeout(err), "failed to run maintenance statement")
+		}
+		log.Info().Str("statement", statement).Dur("duration", time.Since(statementStarted)).Msg("Ran maintenance statement")
+	}
+	log.Info().Dur("duration", time.Since(started)).Msg("Maintenance complete")
+	monitorMaintenanceRun()
+
+	return nil
+}
+
+// replicationLag returns the highest replay lag of the database's replicas.
+func (s *Service) replicationLag(ctx context.Context) (time.Duration, error) {
+	var seconds float64
+	if err := s.pool.QueryRow(ctx, `
+SELECT COALESCE(MAX(EXTRACT(EPOCH FROM replay_lag)), 0)
+FROM pg_stat_replication`,
+	).Scan(&seconds); err != nil {
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// ActiveQueries returns the number of queries, other than our own, active in the database.
+func (s *Service) ActiveQueries(ctx context.Context) (int, error) {
+	var queries int
+	if err := s.pool.QueryRow(ctx, `
+SELECT COUNT(*)
+FROM pg_stat_activity
+WHERE datname = current_database()
+  AND state = 'active'
+  AND pid <> pg_backend_pid()`,
+	).Scan(&queries); err != nil {
+		return 0, err
+	}
+
+	return queries, nil
+}
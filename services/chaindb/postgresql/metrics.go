@@ -0,0 +1,110 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_chaindb"
+
+var (
+	maintenanceLastRun prometheus.Gauge
+	maintenanceSkipped *prometheus.CounterVec
+	migrationRemaining *prometheus.GaugeVec
+	statementTimeouts  prometheus.Counter
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if maintenanceLastRun != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	maintenanceLastRun = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "maintenance_ts",
+		Help:      "Timestamp of last completed maintenance run",
+	})
+	if err := prometheus.Register(maintenanceLastRun); err != nil {
+		return errors.Wrap(err, "failed to register maintenance_ts")
+	}
+
+	maintenanceSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "maintenance_skipped_total",
+		Help:      "Number of maintenance runs skipped",
+	}, []string{"reason"})
+	if err := prometheus.Register(maintenanceSkipped); err != nil {
+		return errors.Wrap(err, "failed to register maintenance_skipped_total")
+	}
+
+	migrationRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "migration_remaining_slots",
+		Help:      "Number of slots remaining to be processed by a background migration",
+	}, []string{"migration"})
+	if err := prometheus.Register(migrationRemaining); err != nil {
+		return errors.Wrap(err, "failed to register migration_remaining_slots")
+	}
+
+	statementTimeouts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "statement_timeouts_total",
+		Help:      "Number of statements cancelled by statement timeout",
+	})
+	if err := prometheus.Register(statementTimeouts); err != nil {
+		return errors.Wrap(err, "failed to register statement_timeouts_total")
+	}
+
+	return nil
+}
+
+func monitorMaintenanceRun() {
+	if maintenanceLastRun != nil {
+		maintenanceLastRun.SetToCurrentTime()
+	}
+}
+
+func monitorMaintenanceSkipped(reason string) {
+	if maintenanceSkipped != nil {
+		maintenanceSkipped.WithLabelValues(reason).Inc()
+	}
+}
+
+func monitorMigrationRemaining(migration string, slots uint64) {
+	if migrationRemaining != nil {
+		migrationRemaining.WithLabelValues(migration).Set(float64(slots))
+	}
+}
+
+func monitorStatementTimeout() {
+	if statementTimeouts != nil {
+		statementTimeouts.Inc()
+	}
+}
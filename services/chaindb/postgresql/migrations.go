@@ -0,0 +1,200 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+// migrationJobClass is the scheduler class of the background migration jobs.
+const migrationJobClass = "dbmigrate"
+
+// batchMigrationMetadata holds the progress of a batch migration.
+type batchMigrationMetadata struct {
+	NextSlot phase0.Slot `json:"next_slot"`
+	// TargetSlot is the slot at which the migration completes, fixed on its first run.
+	TargetSlot *phase0.Slot `json:"target_slot,omitempty"`
+}
+
+// batchMigration rewrites existing data in the background, a batch of slots at a time.
+// Each batch is committed along with the progress of the migration, so a migration
+// can be cancelled at any point and resumes from where it left off when next run.
+type batchMigration struct {
+	name          string
+	metadataKey   string
+	slotsPerBatch uint64
+	interval      time.Duration
+	// migrate migrates the data for a slot range, inclusive of start and exclusive of end,
+	// returning the number of rows rewritten.  It must be safe to run more than once for
+	// the same range.
+	migrate func(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) (int, error)
+	// running ensures that only a single batch runs at a time.
+	running sync.Mutex
+	// complete is set once the migration has reached its target slot.
+	complete atomic.Bool
+}
+
+// scheduleBatchMigration schedules the periodic job that runs a batch migration.
+func (s *Service) scheduleBatchMigration(ctx context.Context, scheduler scheduler.Service, migration *batchMigration) error {
+	log.Info().
+		Str("migration", migration.name).
+		Uint64("slots_per_batch", migration.slotsPerBatch).
+		Dur("interval", migration.interval).
+		Msg("Scheduling database migration")
+
+	return scheduler.SchedulePeriodicJob(ctx,
+		migrationJobClass,
+		migration.name,
+		s.nextMigrationBatch,
+		migration,
+		s.migrateBatch,
+		migration,
+	)
+}
+
+// nextMigrationBatch returns the time of the next batch of a migration.
+func (*Service) nextMigrationBatch(_ context.Context, data interface{}) (time.Time, error) {
+	migration := data.(*batchMigration)
+	if migration.complete.Load() {
+		return time.Time{}, scheduler.ErrNoMoreInstances
+	}
+
+	return time.Now().Add(migration.interval), nil
+}
+
+// migrateBatch migrates the next batch of a migration.
+func (s *Service) migrateBatch(ctx context.Context, data interface{}) error {
+	migration := data.(*batchMigration)
+	if !migration.running.TryLock() {
+		log.Debug().Str("migration", migration.name).Msg("Migration batch already running; skipping")
+		return nil
+	}
+	defer migration.running.Unlock()
+
+	// The schema is upgraded after the service starts, so wait for it to be current.
+	schema, err := s.schema(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain schema")
+	}
+	if schema.Version != currentVersion {
+		log.Debug().Str("migration", migration.name).Msg("Schema not yet upgraded; skipping migration batch")
+		return nil
+	}
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	md, err := s.batchMigrationMetadata(ctx, migration)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	startSlot := md.NextSlot
+	endSlot := md.NextSlot + phase0.Slot(migration.slotsPerBatch)
+	if endSlot > *md.TargetSlot {
+		endSlot = *md.TargetSlot
+	}
+	migrated := 0
+	if startSlot < endSlot {
+		migrated, err = migration.migrate(ctx, startSlot, endSlot)
+		if err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to migrate batch")
+		}
+		md.NextSlot = endSlot
+	}
+
+	if err := s.setBatchMigrationMetadata(ctx, migration, md); err != nil {
+		cancel()
+		return err
+	}
+	if err := s.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	monitorMigrationRemaining(migration.name, uint64(*md.TargetSlot-md.NextSlot))
+
+	if startSlot < endSlot {
+		log.Trace().
+			Str("migration", migration.name).
+			Uint64("start_slot", uint64(startSlot)).
+			Uint64("end_slot", uint64(endSlot)).
+			Int("migrated", migrated).
+			Msg("Migrated batch")
+	}
+	if md.NextSlot >= *md.TargetSlot {
+		log.Info().Str("migration", migration.name).Msg("Migration complete")
+		migration.complete.Store(true)
+	}
+
+	return nil
+}
+
+// batchMigrationMetadata obtains the progress of a migration, fixing its
+// target at the slot after the latest stored block if this is its first run.
+// Data for later blocks is written in its migrated form, so does not need migrating.
+func (s *Service) batchMigrationMetadata(ctx context.Context, migration *batchMigration) (*batchMigrationMetadata, error) {
+	md := &batchMigrationMetadata{}
+	data, err := s.Metadata(ctx, migration.metadataKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain migration metadata")
+	}
+	if data != nil {
+		if err := json.Unmarshal(data, md); err != nil {
+			return nil, errors.Wrap(err, "failed to parse migration metadata")
+		}
+	}
+
+	if md.TargetSlot == nil {
+		var targetSlot phase0.Slot
+		if err := s.tx(ctx).QueryRow(ctx, `
+SELECT COALESCE(MAX(f_slot) + 1, 0)
+FROM t_blocks`,
+		).Scan(&targetSlot); err != nil {
+			return nil, errors.Wrap(err, "failed to obtain migration target slot")
+		}
+		md.TargetSlot = &targetSlot
+		log.Info().
+			Str("migration", migration.name).
+			Uint64("next_slot", uint64(md.NextSlot)).
+			Uint64("target_slot", uint64(targetSlot)).
+			Msg("Starting migration")
+	}
+
+	return md, nil
+}
+
+// setBatchMigrationMetadata records the progress of a migration.
+func (s *Service) setBatchMigrationMetadata(ctx context.Context, migration *batchMigration, md *batchMigrationMetadata) error {
+	data, err := json.Marshal(md)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal migration metadata")
+	}
+	if err := s.SetMetadata(ctx, migration.metadataKey, data); err != nil {
+		return errors.Wrap(err, "failed to set migration metadata")
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+)
+
+// originMetadataKey is the metadata key for the ingestion origin.
+var originMetadataKey = "chaind.origin"
+
+type originJSON struct {
+	Slot  uint64 `json:"slot"`
+	Epoch uint64 `json:"epoch"`
+}
+
+// SetOrigin sets the point from which data has been ingested.
+func (s *Service) SetOrigin(ctx context.Context, origin *chaindb.Origin) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetOrigin")
+	defer span.End()
+
+	if origin == nil {
+		return errors.New("origin nil")
+	}
+
+	data, err := json.Marshal(&originJSON{
+		Slot:  uint64(origin.Slot),
+		Epoch: uint64(origin.Epoch),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal origin")
+	}
+
+	return s.SetMetadata(ctx, originMetadataKey, data)
+}
+
+// Origin provides the point from which data has been ingested.
+// It returns nil if data has been ingested from genesis.
+func (s *Service) Origin(ctx context.Context) (*chaindb.Origin, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "Origin")
+	defer span.End()
+
+	data, err := s.Metadata(ctx, originMetadataKey)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	origin := &originJSON{}
+	if err := json.Unmarshal(data, origin); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal origin")
+	}
+
+	return &chaindb.Origin{
+		Slot:  phase0.Slot(origin.Slot),
+		Epoch: phase0.Epoch(origin.Epoch),
+	}, nil
+}
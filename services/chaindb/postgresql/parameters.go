@@ -15,21 +15,60 @@ package postgresql
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
 )
 
 type parameters struct {
-	logLevel       zerolog.Level
-	connectionURL  string
-	server         string
-	port           int32
-	user           string
-	password       string
-	clientCert     []byte
-	clientKey      []byte
-	caCert         []byte
-	maxConnections uint
+	logLevel          zerolog.Level
+	connectionURL     string
+	server            string
+	port              int32
+	user              string
+	password          string
+	clientCert        []byte
+	clientKey         []byte
+	caCert            []byte
+	maxConnections    uint
+	compactCommittees bool
+	columnCompression util.CompressionFormat
+	// attestationStorageMode is the mode in which attestations are stored.
+	attestationStorageMode    chaindb.AttestationStorageMode
+	monitor                   metrics.Service
+	scheduler                 scheduler.Service
+	maintenance               *maintenanceParameters
+	compressionMigration      *migrationParameters
+	attestationVotesMigration *migrationParameters
+	readOnly                  bool
+	release                   string
+	// statementTimeout is the default statement timeout; 0 disables it.
+	statementTimeout time.Duration
+	// longRunningStatementTimeout is the statement timeout for known long-running
+	// operations; 0 disables it.
+	longRunningStatementTimeout time.Duration
+}
+
+// maintenanceParameters are the parameters for scheduled database maintenance.
+type maintenanceParameters struct {
+	statements        []string
+	interval          time.Duration
+	windowStart       string
+	windowEnd         string
+	maxReplicationLag time.Duration
+	maxActiveQueries  int
+}
+
+// migrationParameters are the parameters for a background migration of existing data.
+type migrationParameters struct {
+	// slotsPerBatch is the number of slots processed in each batch; 0 disables the migration.
+	slotsPerBatch uint64
+	interval      time.Duration
 }
 
 // Parameter is the interface for service parameters.
@@ -114,11 +153,169 @@ func WithMaxConnections(maxConnections uint) Parameter {
 	})
 }
 
+// WithCompactCommittees stores new beacon committees in compact form.
+func WithCompactCommittees(compactCommittees bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.compactCommittees = compactCommittees
+	})
+}
+
+// WithColumnCompression sets the compression format for newly-written large byte columns.
+func WithColumnCompression(format util.CompressionFormat) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.columnCompression = format
+	})
+}
+
+// WithAttestationStorageMode sets the mode in which attestations are stored.  The mode
+// is recorded when the database is created, and cannot be changed afterwards.
+func WithAttestationStorageMode(mode chaindb.AttestationStorageMode) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.attestationStorageMode = mode
+	})
+}
+
+// WithMonitor sets the monitor for this module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithScheduler sets the scheduler for this module, used to run maintenance and migrations.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithCompressionMigrationSlotsPerBatch sets the number of slots of existing data
+// compressed in each batch of the background compression migration.
+// The migration only runs if this is greater than 0, column compression is enabled
+// and a scheduler is available.
+func WithCompressionMigrationSlotsPerBatch(slots uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.compressionMigration.slotsPerBatch = slots
+	})
+}
+
+// WithCompressionMigrationInterval sets the interval between batches of the background compression migration.
+func WithCompressionMigrationInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.compressionMigration.interval = interval
+	})
+}
+
+// WithAttestationVotesMigrationSlotsPerBatch sets the number of slots of existing
+// attestations for which votes are backfilled in each batch of the background
+// attestation votes migration.
+// The migration only runs if this is greater than 0 and a scheduler is available.
+func WithAttestationVotesMigrationSlotsPerBatch(slots uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.attestationVotesMigration.slotsPerBatch = slots
+	})
+}
+
+// WithAttestationVotesMigrationInterval sets the interval between batches of the background attestation votes migration.
+func WithAttestationVotesMigrationInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.attestationVotesMigration.interval = interval
+	})
+}
+
+// WithMaintenanceStatements sets the statements run by scheduled maintenance, in order.
+// Maintenance only runs if statements are supplied and a scheduler is available.
+func WithMaintenanceStatements(statements []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maintenance.statements = statements
+	})
+}
+
+// WithMaintenanceInterval sets the interval between maintenance runs.
+func WithMaintenanceInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maintenance.interval = interval
+	})
+}
+
+// WithMaintenanceWindow sets the daily window, in UTC, within which maintenance runs.
+// The start and end are times of day in the form HH:MM; if they are the same
+// maintenance can run at any time.
+func WithMaintenanceWindow(start string, end string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maintenance.windowStart = start
+		p.maintenance.windowEnd = end
+	})
+}
+
+// WithMaintenanceMaxReplicationLag sets the replication lag above which maintenance is skipped.
+// 0 disables the check.
+func WithMaintenanceMaxReplicationLag(lag time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maintenance.maxReplicationLag = lag
+	})
+}
+
+// WithMaintenanceMaxActiveQueries sets the number of other active queries above which maintenance is skipped.
+// 0 disables the check.
+func WithMaintenanceMaxActiveQueries(queries int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maintenance.maxActiveQueries = queries
+	})
+}
+
+// WithReadOnly sets the service to be read-only.  Connections are opened with
+// read-only transactions, attempts to begin a writing transaction return
+// ErrReadOnly, and neither maintenance nor schema upgrades are carried out.
+func WithReadOnly(readOnly bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.readOnly = readOnly
+	})
+}
+
+// WithRelease sets the release of chaind, recorded when the schema is written and
+// reported if the schema is too new for this release.
+func WithRelease(release string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.release = release
+	})
+}
+
+// WithStatementTimeout sets the default maximum time for which a statement can run
+// before it is cancelled, so that a runaway query cannot hold locks indefinitely.
+// 0 disables the timeout.
+func WithStatementTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.statementTimeout = timeout
+	})
+}
+
+// WithLongRunningStatementTimeout sets the maximum time for which a statement can
+// run in known long-running operations, such as schema upgrades, maintenance and
+// transactions marked with chaindb.WithLongRunning.  0 disables the timeout.
+func WithLongRunningStatementTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.longRunningStatementTimeout = timeout
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel:       zerolog.GlobalLevel(),
-		maxConnections: 16,
+		logLevel:               zerolog.GlobalLevel(),
+		maxConnections:         16,
+		attestationStorageMode: chaindb.AttestationStorageFull,
+		maintenance: &maintenanceParameters{
+			interval:    24 * time.Hour,
+			windowStart: "00:00",
+			windowEnd:   "00:00",
+		},
+		compressionMigration: &migrationParameters{
+			interval: time.Second,
+		},
+		attestationVotesMigration: &migrationParameters{
+			interval: time.Second,
+		},
 	}
 	for _, p := range params {
 		if params != nil {
@@ -126,6 +323,40 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 		}
 	}
 
+	switch parameters.columnCompression {
+	case util.CompressionNone, util.CompressionDeflate, util.CompressionSnappy:
+	default:
+		return nil, errors.New("unsupported column compression format")
+	}
+	if parameters.attestationStorageMode != chaindb.AttestationStorageFull && parameters.attestationStorageMode != chaindb.AttestationStorageAggregate {
+		return nil, fmt.Errorf("unsupported attestation storage mode %q", parameters.attestationStorageMode)
+	}
+	if parameters.maintenance.interval <= 0 {
+		return nil, errors.New("maintenance interval must be greater than 0")
+	}
+	if _, err := util.ParseDailyWindow(parameters.maintenance.windowStart, parameters.maintenance.windowEnd); err != nil {
+		return nil, fmt.Errorf("invalid maintenance window: %w", err)
+	}
+	if parameters.maintenance.maxReplicationLag < 0 {
+		return nil, errors.New("maintenance maximum replication lag cannot be negative")
+	}
+	if parameters.maintenance.maxActiveQueries < 0 {
+		return nil, errors.New("maintenance maximum active queries cannot be negative")
+	}
+	if parameters.compressionMigration.interval <= 0 {
+		return nil, errors.New("compression migration interval must be greater than 0")
+	}
+	if parameters.attestationVotesMigration.interval <= 0 {
+		return nil, errors.New("attestation votes migration interval must be greater than 0")
+	}
+
+	if parameters.statementTimeout < 0 {
+		return nil, errors.New("statement timeout cannot be negative")
+	}
+	if parameters.longRunningStatementTimeout < 0 {
+		return nil, errors.New("long-running statement timeout cannot be negative")
+	}
+
 	if parameters.connectionURL != "" {
 		// Allow deprecated connection URL.
 		return &parameters, nil
@@ -0,0 +1,125 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// SchemaStatus is the state of the database schema relative to that supported by this release.
+type SchemaStatus struct {
+	// Initialised is true if the database has been initialised by chaind.
+	Initialised bool
+	// Version is the version of the schema in the database.
+	Version uint64
+	// Release is the release of chaind that last wrote the schema, if known.
+	Release string
+	// SupportedVersion is the version of the schema supported by this release.
+	SupportedVersion uint64
+}
+
+// SchemaStatus returns the state of the database schema, without altering it.
+func (s *Service) SchemaStatus(ctx context.Context) (*SchemaStatus, error) {
+	status := &SchemaStatus{
+		SupportedVersion: currentVersion,
+	}
+
+	tableExists, err := s.tableExists(ctx, "t_metadata")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check presence of tables")
+	}
+	if !tableExists {
+		return status, nil
+	}
+
+	schema, err := s.schema(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain version")
+	}
+	status.Initialised = true
+	status.Version = schema.Version
+	status.Release = schema.Release
+
+	return status, nil
+}
+
+// MissingPrivileges returns the privileges that chaind requires but the database user lacks.
+// Reading requires SELECT on the existing tables; writing additionally requires INSERT, UPDATE
+// and DELETE on them, and CREATE on the schema to initialise and upgrade it.
+func (s *Service) MissingPrivileges(ctx context.Context, write bool) ([]string, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.CommitROTx(ctx)
+	}
+
+	missing := make([]string, 0)
+
+	tablePrivileges := []string{"SELECT"}
+	if write {
+		tablePrivileges = append(tablePrivileges, "INSERT", "UPDATE", "DELETE")
+
+		var canCreate bool
+		if err := tx.QueryRow(ctx, `
+SELECT COALESCE(has_schema_privilege(current_schema(), 'CREATE'), false)
+`).Scan(&canCreate); err != nil {
+			return nil, errors.Wrap(err, "failed to obtain schema privileges")
+		}
+		if !canCreate {
+			missing = append(missing, "CREATE on schema")
+		}
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT table_name
+      ,privilege
+FROM information_schema.tables
+CROSS JOIN UNNEST($1::TEXT[]) AS privilege
+WHERE table_schema = (SELECT current_schema())
+  AND table_type = 'BASE TABLE'
+  AND table_name LIKE 't\_%'
+  AND NOT has_table_privilege(quote_ident(table_schema) || '.' || quote_ident(table_name), privilege)
+ORDER BY table_name
+        ,privilege
+`,
+		tablePrivileges,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain table privileges")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table string
+		var privilege string
+		if err := rows.Scan(
+			&table,
+			&privilege,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		missing = append(missing, fmt.Sprintf("%s on %s", privilege, table))
+	}
+
+	return missing, rows.Err()
+}
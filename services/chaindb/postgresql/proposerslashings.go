@@ -19,6 +19,7 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 	"go.opentelemetry.io/otel"
 )
 
@@ -32,7 +33,16 @@ func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *cha
 		return ErrNoTransaction
 	}
 
-	_, err := tx.Exec(ctx, `
+	header1Signature, err := util.CompressColumn(proposerSlashing.Header1Signature[:], signatureLength, s.columnCompression)
+	if err != nil {
+		return errors.Wrap(err, "failed to compress header 1 signature")
+	}
+	header2Signature, err := util.CompressColumn(proposerSlashing.Header2Signature[:], signatureLength, s.columnCompression)
+	if err != nil {
+		return errors.Wrap(err, "failed to compress header 2 signature")
+	}
+
+	_, err = tx.Exec(ctx, `
       INSERT INTO t_proposer_slashings(f_inclusion_slot
                                       ,f_inclusion_block_root
                                       ,f_inclusion_index
@@ -78,14 +88,14 @@ func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *cha
 		proposerSlashing.Header1ParentRoot[:],
 		proposerSlashing.Header1StateRoot[:],
 		proposerSlashing.Header1BodyRoot[:],
-		proposerSlashing.Header1Signature[:],
+		header1Signature,
 		proposerSlashing.Block2Root[:],
 		proposerSlashing.Header2Slot,
 		proposerSlashing.Header2ProposerIndex,
 		proposerSlashing.Header2ParentRoot[:],
 		proposerSlashing.Header2StateRoot[:],
 		proposerSlashing.Header2BodyRoot[:],
-		proposerSlashing.Header2Signature[:],
+		header2Signature,
 	)
 
 	return err
@@ -181,11 +191,19 @@ func (s *Service) ProposerSlashingsForSlotRange(ctx context.Context, minSlot pha
 		copy(proposerSlashing.Header1ParentRoot[:], header1ParentRoot)
 		copy(proposerSlashing.Header1StateRoot[:], header1StateRoot)
 		copy(proposerSlashing.Header1BodyRoot[:], header1BodyRoot)
+		header1Signature, err = util.DecompressColumn(header1Signature, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress header 1 signature")
+		}
 		copy(proposerSlashing.Header1Signature[:], header1Signature)
 		copy(proposerSlashing.Block2Root[:], block2Root)
 		copy(proposerSlashing.Header2ParentRoot[:], header2ParentRoot)
 		copy(proposerSlashing.Header2StateRoot[:], header2StateRoot)
 		copy(proposerSlashing.Header2BodyRoot[:], header2BodyRoot)
+		header2Signature, err = util.DecompressColumn(header2Signature, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress header 2 signature")
+		}
 		copy(proposerSlashing.Header2Signature[:], header2Signature)
 		proposerSlashings = append(proposerSlashings, proposerSlashing)
 	}
@@ -280,11 +298,19 @@ func (s *Service) ProposerSlashingsForValidator(ctx context.Context, index phase
 		copy(proposerSlashing.Header1ParentRoot[:], header1ParentRoot)
 		copy(proposerSlashing.Header1StateRoot[:], header1StateRoot)
 		copy(proposerSlashing.Header1BodyRoot[:], header1BodyRoot)
+		header1Signature, err = util.DecompressColumn(header1Signature, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress header 1 signature")
+		}
 		copy(proposerSlashing.Header1Signature[:], header1Signature)
 		copy(proposerSlashing.Block2Root[:], block2Root)
 		copy(proposerSlashing.Header2ParentRoot[:], header2ParentRoot)
 		copy(proposerSlashing.Header2StateRoot[:], header2StateRoot)
 		copy(proposerSlashing.Header2BodyRoot[:], header2BodyRoot)
+		header2Signature, err = util.DecompressColumn(header2Signature, signatureLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress header 2 signature")
+		}
 		copy(proposerSlashing.Header2Signature[:], header2Signature)
 		proposerSlashings = append(proposerSlashings, proposerSlashing)
 	}
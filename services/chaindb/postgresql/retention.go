@@ -0,0 +1,143 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+)
+
+// retentionDataset is the location of a dataset to which retention applies.
+type retentionDataset struct {
+	table  string
+	column string
+}
+
+// retentionDatasets are the datasets to which retention applies.
+var retentionDatasets = map[chaindb.RetentionDataset]*retentionDataset{
+	chaindb.RetentionDatasetValidatorBalances: {
+		table:  "t_validator_balances",
+		column: "f_epoch",
+	},
+	chaindb.RetentionDatasetValidatorEpochSummaries: {
+		table:  "t_validator_epoch_summaries",
+		column: "f_epoch",
+	},
+	chaindb.RetentionDatasetAttestations: {
+		table:  "t_attestations",
+		column: "f_inclusion_slot",
+	},
+}
+
+// aggregateAttestationsRetentionDataset is the location of attestations stored in
+// aggregate mode, which have no inclusion slot so are pruned by slot.
+var aggregateAttestationsRetentionDataset = &retentionDataset{
+	table:  "t_aggregate_attestations",
+	column: "f_slot",
+}
+
+// retentionDataset returns the location of the dataset.
+func (s *Service) retentionDataset(dataset chaindb.RetentionDataset) (*retentionDataset, bool) {
+	if dataset == chaindb.RetentionDatasetAttestations && s.aggregateAttestations() {
+		return aggregateAttestationsRetentionDataset, true
+	}
+	location, exists := retentionDatasets[dataset]
+
+	return location, exists
+}
+
+// PruneRetentionDataset removes up to limit rows of the dataset from before the given point,
+// returning the number of rows removed.
+func (s *Service) PruneRetentionDataset(ctx context.Context,
+	dataset chaindb.RetentionDataset,
+	before uint64,
+	limit int,
+) (
+	int64,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "PruneRetentionDataset")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return 0, ErrNoTransaction
+	}
+
+	location, exists := s.retentionDataset(dataset)
+	if !exists {
+		return 0, fmt.Errorf("unknown dataset %q", dataset)
+	}
+
+	// Table and column names are fixed above, so safe to include in the query.
+	res, err := tx.Exec(ctx, fmt.Sprintf(`
+      DELETE FROM %[1]s
+      WHERE ctid IN (
+        SELECT ctid
+        FROM %[1]s
+        WHERE %[2]s < $1
+        LIMIT $2
+      )`, location.table, location.column),
+		before,
+		limit,
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to prune dataset")
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// PrunableRetentionDatasetRows returns the number of rows of the dataset from before the given point.
+func (s *Service) PrunableRetentionDatasetRows(ctx context.Context,
+	dataset chaindb.RetentionDataset,
+	before uint64,
+) (
+	int64,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "PrunableRetentionDatasetRows")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	location, exists := s.retentionDataset(dataset)
+	if !exists {
+		return 0, fmt.Errorf("unknown dataset %q", dataset)
+	}
+
+	var rows int64
+	if err := tx.QueryRow(ctx, fmt.Sprintf(`
+      SELECT COUNT(*)
+      FROM %s
+      WHERE %s < $1`, location.table, location.column),
+		before,
+	).Scan(&rows); err != nil {
+		return 0, errors.Wrap(err, "failed to count prunable rows")
+	}
+
+	return rows, nil
+}
@@ -19,6 +19,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgtype"
 	shopspring "github.com/jackc/pgtype/ext/shopspring-numeric"
@@ -27,11 +28,26 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 )
 
 // Service is a chain database service.
 type Service struct {
-	pool *pgxpool.Pool
+	pool              *pgxpool.Pool
+	compactCommittees bool
+	columnCompression util.CompressionFormat
+	// attestationStorageMode is the mode in which attestations are stored.
+	attestationStorageMode    chaindb.AttestationStorageMode
+	maintenance               *maintenance
+	compressionMigration      *batchMigration
+	attestationVotesMigration *batchMigration
+	readOnly                  bool
+	release                   string
+	// statementTimeout is the default statement timeout; 0 disables it.
+	statementTimeout time.Duration
+	// longRunningStatementTimeout is the statement timeout for long-running operations.
+	longRunningStatementTimeout time.Duration
 }
 
 // module-wide log.
@@ -45,7 +61,11 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "chaindb").Str("impl", "postgresql").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger(zerologger.With().Str("service", "chaindb").Str("impl", "postgresql").Logger(), "chaindb", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
 
 	var pool *pgxpool.Pool
 	if parameters.connectionURL != "" {
@@ -64,12 +84,72 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}()
 
 	s := &Service{
-		pool: pool,
+		pool:              pool,
+		compactCommittees: parameters.compactCommittees,
+		columnCompression: parameters.columnCompression,
+		readOnly:          parameters.readOnly,
+		release:           parameters.release,
+
+		attestationStorageMode: parameters.attestationStorageMode,
+
+		statementTimeout:            parameters.statementTimeout,
+		longRunningStatementTimeout: parameters.longRunningStatementTimeout,
+	}
+
+	if err := s.checkAttestationStorageMode(ctx); err != nil {
+		return nil, err
+	}
+
+	if parameters.scheduler != nil && len(parameters.maintenance.statements) > 0 && !parameters.readOnly {
+		// Window has already been validated by parameter checks.
+		window, _ := util.ParseDailyWindow(parameters.maintenance.windowStart, parameters.maintenance.windowEnd)
+		s.maintenance = &maintenance{
+			statements:        parameters.maintenance.statements,
+			interval:          parameters.maintenance.interval,
+			window:            window,
+			maxReplicationLag: parameters.maintenance.maxReplicationLag,
+			maxActiveQueries:  parameters.maintenance.maxActiveQueries,
+		}
+		if err := s.scheduleMaintenance(ctx, parameters.scheduler); err != nil {
+			return nil, errors.Wrap(err, "failed to schedule maintenance")
+		}
+	}
+
+	if parameters.scheduler != nil && parameters.compressionMigration.slotsPerBatch > 0 && s.columnCompression != util.CompressionNone && !parameters.readOnly {
+		s.compressionMigration = &batchMigration{
+			name:          "compress columns",
+			metadataKey:   "chaindb.compression",
+			slotsPerBatch: parameters.compressionMigration.slotsPerBatch,
+			interval:      parameters.compressionMigration.interval,
+			migrate:       s.CompressColumnsForSlotRange,
+		}
+		if err := s.scheduleBatchMigration(ctx, parameters.scheduler, s.compressionMigration); err != nil {
+			return nil, errors.Wrap(err, "failed to schedule compression migration")
+		}
+	}
+
+	if parameters.scheduler != nil && parameters.attestationVotesMigration.slotsPerBatch > 0 && !parameters.readOnly {
+		s.attestationVotesMigration = &batchMigration{
+			name:          "backfill attestation votes",
+			metadataKey:   "chaindb.attestation-votes",
+			slotsPerBatch: parameters.attestationVotesMigration.slotsPerBatch,
+			interval:      parameters.attestationVotesMigration.interval,
+			migrate:       s.backfillAttestationVotesForSlotRange,
+		}
+		if err := s.scheduleBatchMigration(ctx, parameters.scheduler, s.attestationVotesMigration); err != nil {
+			return nil, errors.Wrap(err, "failed to schedule attestation votes migration")
+		}
 	}
 
 	return s, nil
 }
 
+// Close closes the connection pool, waiting for connections in use to be released.
+// The pool is also closed when the context passed to New is done.
+func (s *Service) Close() {
+	s.pool.Close()
+}
+
 func newFromURL(ctx context.Context,
 	parameters *parameters) (
 	*pgxpool.Pool,
@@ -81,6 +161,12 @@ func newFromURL(ctx context.Context,
 		return nil, errors.Wrap(err, "invalid connection URL")
 	}
 	config.MaxConns = int32(parameters.maxConnections)
+	if parameters.readOnly {
+		config.ConnConfig.RuntimeParams["default_transaction_read_only"] = "on"
+	}
+	if parameters.statementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = statementTimeoutMS(parameters.statementTimeout)
+	}
 	pool, err := pgxpool.ConnectConfig(ctx, config)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to connect to database")
@@ -137,6 +223,12 @@ func newFromComponents(ctx context.Context,
 	}
 	config.AfterConnect = registerCustomTypes
 	config.ConnConfig.TLSConfig = tlsConfig
+	if parameters.readOnly {
+		config.ConnConfig.RuntimeParams["default_transaction_read_only"] = "on"
+	}
+	if parameters.statementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = statementTimeoutMS(parameters.statementTimeout)
+	}
 
 	pool, err := pgxpool.ConnectConfig(ctx, config)
 	if err != nil {
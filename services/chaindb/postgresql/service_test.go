@@ -74,3 +74,56 @@ func TestInterfaces(t *testing.T) {
 	require.Implements(t, (*chaindb.ValidatorsSetter)(nil), s)
 	require.Implements(t, (*chaindb.VoluntaryExitsSetter)(nil), s)
 }
+
+func TestReadOnly(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+		postgresql.WithReadOnly(true),
+	)
+	require.NoError(t, err)
+
+	// Writing transactions are refused.
+	_, _, err = s.BeginTx(ctx)
+	require.ErrorIs(t, err, postgresql.ErrReadOnly)
+
+	// Reads are allowed.
+	ctx, err = s.BeginROTx(ctx)
+	require.NoError(t, err)
+	_, err = s.Metadata(ctx, "schema")
+	require.NoError(t, err)
+	s.CommitROTx(ctx)
+}
+
+func TestSchemaTooNew(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+		postgresql.WithRelease("0.0.1"),
+	)
+	require.NoError(t, err)
+	_, err = s.Upgrade(ctx)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+	original, err := s.Metadata(ctx, "schema")
+	require.NoError(t, err)
+	require.NoError(t, s.SetMetadata(ctx, "schema", []byte(`{"version":999999,"release":"99.0.0"}`)))
+	require.NoError(t, s.CommitTx(ctx))
+
+	_, err = s.Upgrade(context.Background())
+	var tooNew *postgresql.SchemaTooNewError
+	require.ErrorAs(t, err, &tooNew)
+	require.Equal(t, uint64(999999), tooNew.Version)
+	require.Equal(t, "99.0.0", tooNew.Release)
+	require.Equal(t, "0.0.1", tooNew.SupportedRelease)
+	require.Contains(t, err.Error(), "upgrade chaind to 99.0.0 or later")
+
+	ctx, cancel, err = s.BeginTx(context.Background())
+	require.NoError(t, err)
+	defer cancel()
+	require.NoError(t, s.SetMetadata(ctx, "schema", original))
+	require.NoError(t, s.CommitTx(ctx))
+}
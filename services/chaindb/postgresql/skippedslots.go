@@ -0,0 +1,114 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+)
+
+// SetSkippedSlot sets a skipped slot.
+func (s *Service) SetSkippedSlot(ctx context.Context, skippedSlot *chaindb.SkippedSlot) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetSkippedSlot")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	var proposerIndex sql.NullInt64
+	if skippedSlot.ProposerIndex != nil {
+		proposerIndex.Valid = true
+		proposerIndex.Int64 = int64(*skippedSlot.ProposerIndex)
+	}
+
+	_, err := tx.Exec(ctx, `
+      INSERT INTO t_skipped_slots(f_slot
+                                 ,f_proposer_index)
+      VALUES($1,$2)
+      ON CONFLICT (f_slot) DO
+      UPDATE
+      SET f_proposer_index = excluded.f_proposer_index
+		 `,
+		skippedSlot.Slot,
+		proposerIndex,
+	)
+
+	return err
+}
+
+// SkippedSlotsForSlotRange fetches all skipped slots for the given slot range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// skipped slots for slots 2 and 3.
+func (s *Service) SkippedSlotsForSlotRange(ctx context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]*chaindb.SkippedSlot,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SkippedSlotsForSlotRange")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_slot
+            ,f_proposer_index
+      FROM t_skipped_slots
+      WHERE f_slot >= $1
+        AND f_slot < $2
+      ORDER BY f_slot`,
+		startSlot,
+		endSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	skippedSlots := make([]*chaindb.SkippedSlot, 0)
+	for rows.Next() {
+		skippedSlot := &chaindb.SkippedSlot{}
+		var proposerIndex sql.NullInt64
+		err := rows.Scan(
+			&skippedSlot.Slot,
+			&proposerIndex,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if proposerIndex.Valid {
+			tmp := phase0.ValidatorIndex(proposerIndex.Int64)
+			skippedSlot.ProposerIndex = &tmp
+		}
+		skippedSlots = append(skippedSlots, skippedSlot)
+	}
+
+	return skippedSlots, nil
+}
@@ -0,0 +1,123 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestSkippedSlots(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	// Record the slot as skipped on finality.
+	proposerIndex := phase0.ValidatorIndex(3)
+	require.NoError(t, s.SetSkippedSlot(ctx, &chaindb.SkippedSlot{
+		Slot:          2,
+		ProposerIndex: &proposerIndex,
+	}))
+	skippedSlots, err := s.SkippedSlotsForSlotRange(ctx, 2, 3)
+	require.NoError(t, err)
+	require.Len(t, skippedSlots, 1)
+	require.Equal(t, phase0.Slot(2), skippedSlots[0].Slot)
+	require.NotNil(t, skippedSlots[0].ProposerIndex)
+	require.Equal(t, proposerIndex, *skippedSlots[0].ProposerIndex)
+
+	// Recording the slot again updates it in place.
+	require.NoError(t, s.SetSkippedSlot(ctx, &chaindb.SkippedSlot{
+		Slot: 2,
+	}))
+	skippedSlots, err = s.SkippedSlotsForSlotRange(ctx, 2, 3)
+	require.NoError(t, err)
+	require.Len(t, skippedSlots, 1)
+	require.Nil(t, skippedSlots[0].ProposerIndex)
+
+	// A late block for the slot, with indeterminate or non-canonical status, leaves the slot skipped.
+	block := &chaindb.Block{
+		Slot:          2,
+		ProposerIndex: 3,
+		Root: phase0.Root{
+			0xa0, 0x01, 0x02, 0x03, 0x04, 0x04, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+			0x00, 0x01, 0x02, 0x03, 0x04, 0x04, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+		},
+		Graffiti: []byte{
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		},
+		RANDAOReveal: phase0.BLSSignature{
+			0x10, 0x11, 0x12, 0x13, 0x14, 0x14, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+			0x10, 0x11, 0x12, 0x13, 0x14, 0x14, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+			0x10, 0x11, 0x12, 0x13, 0x14, 0x14, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+			0x10, 0x11, 0x12, 0x13, 0x14, 0x14, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+			0x10, 0x11, 0x12, 0x13, 0x14, 0x14, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+			0x10, 0x11, 0x12, 0x13, 0x14, 0x14, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+		},
+		BodyRoot: phase0.Root{
+			0x20, 0x21, 0x22, 0x23, 0x24, 0x24, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+			0x20, 0x21, 0x22, 0x23, 0x24, 0x24, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+		},
+		ParentRoot: phase0.Root{
+			0x30, 0x31, 0x32, 0x33, 0x34, 0x34, 0x36, 0x37, 0x38, 0x39, 0x3a, 0x3b, 0x3c, 0x3d, 0x3e, 0x3f,
+			0x30, 0x31, 0x32, 0x33, 0x34, 0x34, 0x36, 0x37, 0x38, 0x39, 0x3a, 0x3b, 0x3c, 0x3d, 0x3e, 0x3f,
+		},
+		StateRoot: phase0.Root{
+			0x40, 0x41, 0x42, 0x43, 0x44, 0x44, 0x46, 0x47, 0x48, 0x49, 0x4a, 0x4b, 0x4c, 0x4d, 0x4e, 0x4f,
+			0x40, 0x41, 0x42, 0x43, 0x44, 0x44, 0x46, 0x47, 0x48, 0x49, 0x4a, 0x4b, 0x4c, 0x4d, 0x4e, 0x4f,
+		},
+		ETH1BlockHash: []byte{
+			0x50, 0x51, 0x52, 0x53, 0x54, 0x54, 0x56, 0x57, 0x58, 0x59, 0x5a, 0x5b, 0x5c, 0x5d, 0x5e, 0x5f,
+			0x50, 0x51, 0x52, 0x53, 0x54, 0x54, 0x56, 0x57, 0x58, 0x59, 0x5a, 0x5b, 0x5c, 0x5d, 0x5e, 0x5f,
+		},
+		ETH1DepositRoot: phase0.Root{
+			0x60, 0x61, 0x62, 0x63, 0x64, 0x64, 0x66, 0x67, 0x68, 0x69, 0x6a, 0x6b, 0x6c, 0x6d, 0x6e, 0x6f,
+			0x60, 0x61, 0x62, 0x63, 0x64, 0x64, 0x66, 0x67, 0x68, 0x69, 0x6a, 0x6b, 0x6c, 0x6d, 0x6e, 0x6f,
+		},
+	}
+
+	require.NoError(t, s.SetBlock(ctx, block))
+	skippedSlots, err = s.SkippedSlotsForSlotRange(ctx, 2, 3)
+	require.NoError(t, err)
+	require.Len(t, skippedSlots, 1)
+
+	canonical := false
+	block.Canonical = &canonical
+	require.NoError(t, s.SetBlock(ctx, block))
+	skippedSlots, err = s.SkippedSlotsForSlotRange(ctx, 2, 3)
+	require.NoError(t, err)
+	require.Len(t, skippedSlots, 1)
+
+	// The block becoming canonical, for example after a reorg, removes the skipped slot.
+	canonical = true
+	block.Canonical = &canonical
+	require.NoError(t, s.SetBlock(ctx, block))
+	skippedSlots, err = s.SkippedSlotsForSlotRange(ctx, 2, 3)
+	require.NoError(t, err)
+	require.Empty(t, skippedSlots)
+}
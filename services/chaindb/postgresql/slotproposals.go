@@ -0,0 +1,114 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+)
+
+// SetSlotProposals sets multiple slot proposals.
+// Existing proposals for the same slots are replaced, so proposals can be recalculated.
+func (s *Service) SetSlotProposals(ctx context.Context, proposals []*chaindb.SlotProposal) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetSlotProposals")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, proposal := range proposals {
+		if _, err := tx.Exec(ctx, `
+      INSERT INTO t_slot_proposals(f_slot
+                                  ,f_proposer_index
+                                  ,f_outcome)
+      VALUES($1,$2,$3)
+      ON CONFLICT (f_slot) DO
+      UPDATE
+      SET f_proposer_index = excluded.f_proposer_index
+         ,f_outcome = excluded.f_outcome
+		 `,
+			proposal.Slot,
+			proposal.ProposerIndex,
+			int16(proposal.Outcome),
+		); err != nil {
+			return errors.Wrapf(err, "failed to set proposal for slot %d", proposal.Slot)
+		}
+	}
+
+	return nil
+}
+
+// SlotProposalsForSlotRange fetches all slot proposals for the given slot range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// slot proposals for slots 2 and 3.
+func (s *Service) SlotProposalsForSlotRange(ctx context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]*chaindb.SlotProposal,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SlotProposalsForSlotRange")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_slot
+            ,f_proposer_index
+            ,f_outcome
+      FROM t_slot_proposals
+      WHERE f_slot >= $1
+        AND f_slot < $2
+      ORDER BY f_slot`,
+		startSlot,
+		endSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	proposals := make([]*chaindb.SlotProposal, 0)
+	for rows.Next() {
+		proposal := &chaindb.SlotProposal{}
+		var outcome int16
+		err := rows.Scan(
+			&proposal.Slot,
+			&proposal.ProposerIndex,
+			&outcome,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		proposal.Outcome = chaindb.ProposalOutcome(outcome)
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals, nil
+}
@@ -0,0 +1,200 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+)
+
+// DeleteEpochSummaries deletes the epoch summaries for the given range of epochs, inclusive.
+func (s *Service) DeleteEpochSummaries(ctx context.Context, from phase0.Epoch, to phase0.Epoch) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "DeleteEpochSummaries")
+	defer span.End()
+
+	return s.deleteSummaries(ctx, "t_epoch_summaries", "f_epoch", from, to)
+}
+
+// DeleteBlockSummaries deletes the block summaries for the given range of slots, inclusive.
+func (s *Service) DeleteBlockSummaries(ctx context.Context, from phase0.Slot, to phase0.Slot) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "DeleteBlockSummaries")
+	defer span.End()
+
+	return s.deleteSummaries(ctx, "t_block_summaries", "f_slot", from, to)
+}
+
+// DeleteValidatorEpochSummaries deletes the validator epoch summaries for the given range of epochs, inclusive.
+func (s *Service) DeleteValidatorEpochSummaries(ctx context.Context, from phase0.Epoch, to phase0.Epoch) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "DeleteValidatorEpochSummaries")
+	defer span.End()
+
+	return s.deleteSummaries(ctx, "t_validator_epoch_summaries", "f_epoch", from, to)
+}
+
+// DeleteValidatorDaySummaries deletes the validator day summaries for days starting within
+// the given range of times, inclusive.
+func (s *Service) DeleteValidatorDaySummaries(ctx context.Context, from time.Time, to time.Time) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "DeleteValidatorDaySummaries")
+	defer span.End()
+
+	return s.deleteSummaries(ctx, "t_validator_day_summaries", "f_start_timestamp", from, to)
+}
+
+// deleteSummaries deletes the rows of a summary table within the given range, inclusive.
+func (s *Service) deleteSummaries(ctx context.Context, table string, column string, from interface{}, to interface{}) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`
+DELETE FROM %s
+WHERE %s >= $1
+  AND %s <= $2
+`, table, column, column),
+		from,
+		to,
+	); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to delete from %s", table))
+	}
+
+	return nil
+}
+
+// OutdatedEpochSummaries returns the range of epochs with epoch summaries produced by
+// a version of the summarizer before that supplied.
+func (s *Service) OutdatedEpochSummaries(ctx context.Context, version int) (phase0.Epoch, phase0.Epoch, bool, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "OutdatedEpochSummaries")
+	defer span.End()
+
+	from, to, err := s.outdatedSummaries(ctx, "t_epoch_summaries", "f_epoch", version)
+	if err != nil || !from.Valid {
+		return 0, 0, false, err
+	}
+
+	return phase0.Epoch(from.Int64), phase0.Epoch(to.Int64), true, nil
+}
+
+// OutdatedBlockSummaries returns the range of slots with block summaries produced by
+// a version of the summarizer before that supplied.
+func (s *Service) OutdatedBlockSummaries(ctx context.Context, version int) (phase0.Slot, phase0.Slot, bool, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "OutdatedBlockSummaries")
+	defer span.End()
+
+	from, to, err := s.outdatedSummaries(ctx, "t_block_summaries", "f_slot", version)
+	if err != nil || !from.Valid {
+		return 0, 0, false, err
+	}
+
+	return phase0.Slot(from.Int64), phase0.Slot(to.Int64), true, nil
+}
+
+// OutdatedValidatorEpochSummaries returns the range of epochs with validator epoch summaries
+// produced by a version of the summarizer before that supplied.
+func (s *Service) OutdatedValidatorEpochSummaries(ctx context.Context, version int) (phase0.Epoch, phase0.Epoch, bool, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "OutdatedValidatorEpochSummaries")
+	defer span.End()
+
+	from, to, err := s.outdatedSummaries(ctx, "t_validator_epoch_summaries", "f_epoch", version)
+	if err != nil || !from.Valid {
+		return 0, 0, false, err
+	}
+
+	return phase0.Epoch(from.Int64), phase0.Epoch(to.Int64), true, nil
+}
+
+// OutdatedValidatorDaySummaries returns the range of day start times with validator day
+// summaries produced by a version of the summarizer before that supplied.
+func (s *Service) OutdatedValidatorDaySummaries(ctx context.Context, version int) (time.Time, time.Time, bool, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "OutdatedValidatorDaySummaries")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return time.Time{}, time.Time{}, false, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	var from sql.NullTime
+	var to sql.NullTime
+	if err := tx.QueryRow(ctx, `
+SELECT MIN(f_start_timestamp)
+      ,MAX(f_start_timestamp)
+FROM t_validator_day_summaries
+WHERE f_summarizer_version < $1
+`,
+		version,
+	).Scan(
+		&from,
+		&to,
+	); err != nil {
+		return time.Time{}, time.Time{}, false, errors.Wrap(err, "failed to obtain outdated validator day summaries")
+	}
+	if !from.Valid {
+		return time.Time{}, time.Time{}, false, nil
+	}
+
+	return from.Time, to.Time, true, nil
+}
+
+// outdatedSummaries returns the range of a summary table with rows produced by a version
+// of the summarizer before that supplied.  The range is not valid if there are no such rows.
+func (s *Service) outdatedSummaries(ctx context.Context,
+	table string,
+	column string,
+	version int,
+) (
+	sql.NullInt64,
+	sql.NullInt64,
+	error,
+) {
+	var from sql.NullInt64
+	var to sql.NullInt64
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return from, to, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	if err := tx.QueryRow(ctx, fmt.Sprintf(`
+SELECT MIN(%s)
+      ,MAX(%s)
+FROM %s
+WHERE f_summarizer_version < $1
+`, column, column, table),
+		version,
+	).Scan(
+		&from,
+		&to,
+	); err != nil {
+		return from, to, errors.Wrap(err, fmt.Sprintf("failed to obtain outdated summaries from %s", table))
+	}
+
+	return from, to, nil
+}
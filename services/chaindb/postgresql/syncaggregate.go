@@ -84,6 +84,14 @@ FROM t_sync_aggregates`)
 
 	wherestr := "WHERE"
 
+	if filter.Canonical != nil {
+		queryVals = append(queryVals, *filter.Canonical)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LEFT JOIN t_blocks ON f_root = f_inclusion_block_root
+%s f_canonical = $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
 	if filter.From != nil {
 		queryVals = append(queryVals, *filter.From)
 		queryBuilder.WriteString(fmt.Sprintf(`
@@ -0,0 +1,140 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// queryCanceledCode is the SQLSTATE for a statement cancelled by the server,
+// including by statement timeout.
+const queryCanceledCode = "57014"
+
+// statementTimeoutMS returns the value of statement_timeout for the given timeout.
+// 0 disables the timeout.
+func statementTimeoutMS(timeout time.Duration) string {
+	return fmt.Sprintf("%d", timeout.Milliseconds())
+}
+
+// wrapStatementTimeout wraps err with chaindb.ErrStatementTimeout if it was caused
+// by a statement timeout, and otherwise returns it unchanged.
+func wrapStatementTimeout(err error) error {
+	if err == nil {
+		return nil
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != queryCanceledCode || !strings.Contains(pgErr.Message, "statement timeout") {
+		return err
+	}
+	monitorStatementTimeout()
+
+	return fmt.Errorf("%w: %w", chaindb.ErrStatementTimeout, err)
+}
+
+// timeoutTx is a transaction that wraps errors caused by statement timeouts.
+type timeoutTx struct {
+	pgx.Tx
+}
+
+// Begin starts a nested transaction.
+func (t *timeoutTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := t.Tx.Begin(ctx)
+	if err != nil {
+		return nil, wrapStatementTimeout(err)
+	}
+
+	return &timeoutTx{Tx: tx}, nil
+}
+
+// Exec executes a statement.
+func (t *timeoutTx) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	tag, err := t.Tx.Exec(ctx, sql, arguments...)
+
+	return tag, wrapStatementTimeout(err)
+}
+
+// Query executes a query returning rows.
+func (t *timeoutTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := t.Tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, wrapStatementTimeout(err)
+	}
+
+	return &timeoutRows{Rows: rows}, nil
+}
+
+// QueryRow executes a query returning at most one row.
+func (t *timeoutTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return &timeoutRow{Row: t.Tx.QueryRow(ctx, sql, args...)}
+}
+
+// CopyFrom copies rows in to a table.
+func (t *timeoutTx) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	rows, err := t.Tx.CopyFrom(ctx, tableName, columnNames, rowSrc)
+
+	return rows, wrapStatementTimeout(err)
+}
+
+// Commit commits the transaction.
+func (t *timeoutTx) Commit(ctx context.Context) error {
+	return wrapStatementTimeout(t.Tx.Commit(ctx))
+}
+
+// timeoutRows are rows that wrap errors caused by statement timeouts.
+type timeoutRows struct {
+	pgx.Rows
+}
+
+// Err returns any error that occurred while reading the rows.
+func (r *timeoutRows) Err() error {
+	return wrapStatementTimeout(r.Rows.Err())
+}
+
+// Scan reads the values of the current row.
+func (r *timeoutRows) Scan(dest ...interface{}) error {
+	return wrapStatementTimeout(r.Rows.Scan(dest...))
+}
+
+// timeoutRow is a row that wraps errors caused by statement timeouts.
+type timeoutRow struct {
+	pgx.Row
+}
+
+// Scan reads the values of the row.
+func (r *timeoutRow) Scan(dest ...interface{}) error {
+	return wrapStatementTimeout(r.Row.Scan(dest...))
+}
+
+// setLongRunningStatementTimeout applies the long-running statement timeout to the
+// transaction if the context is marked as long-running.
+func (s *Service) setLongRunningStatementTimeout(ctx context.Context, tx pgx.Tx) error {
+	if !chaindb.IsLongRunning(ctx) || s.longRunningStatementTimeout == s.statementTimeout {
+		return nil
+	}
+
+	// SET does not accept parameters, but the value is a formatted integer so safe to include.
+	if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %s", statementTimeoutMS(s.longRunningStatementTimeout))); err != nil {
+		return err
+	}
+
+	return nil
+}
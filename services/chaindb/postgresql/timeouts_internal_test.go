@@ -0,0 +1,65 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestWrapStatementTimeout(t *testing.T) {
+	require.NoError(t, wrapStatementTimeout(nil))
+
+	other := errors.New("other")
+	require.Equal(t, other, wrapStatementTimeout(other))
+
+	cancelled := &pgconn.PgError{Code: queryCanceledCode, Message: "canceling statement due to user request"}
+	require.NotErrorIs(t, wrapStatementTimeout(cancelled), chaindb.ErrStatementTimeout)
+
+	timeout := &pgconn.PgError{Code: queryCanceledCode, Message: "canceling statement due to statement timeout"}
+	err := wrapStatementTimeout(timeout)
+	require.ErrorIs(t, err, chaindb.ErrStatementTimeout)
+	require.ErrorIs(t, err, timeout)
+}
+
+func TestStatementTimeout(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx,
+		WithConnectionURL(os.Getenv("CHAINDB_URL")),
+		WithStatementTimeout(100*time.Millisecond),
+		WithLongRunningStatementTimeout(0),
+	)
+	require.NoError(t, err)
+
+	// The default timeout cancels a slow statement.
+	rctx, err := s.BeginROTx(ctx)
+	require.NoError(t, err)
+	_, err = s.tx(rctx).Exec(rctx, "SELECT pg_sleep(0.5)")
+	require.ErrorIs(t, err, chaindb.ErrStatementTimeout)
+	s.CommitROTx(rctx)
+
+	// A long-running transaction is not subject to the default timeout.
+	rctx, err = s.BeginROTx(chaindb.WithLongRunning(ctx))
+	require.NoError(t, err)
+	_, err = s.tx(rctx).Exec(rctx, "SELECT pg_sleep(0.5)")
+	require.NoError(t, err)
+	s.CommitROTx(rctx)
+}
@@ -26,6 +26,9 @@ import (
 // is not inside a transaction.
 var ErrNoTransaction = errors.New("no transaction for action")
 
+// ErrReadOnly is returned when an attempt is made to write to a read-only database.
+var ErrReadOnly = errors.New("database is read-only")
+
 // Tx is a context tag for the database transaction.
 type Tx struct{}
 
@@ -34,7 +37,14 @@ type TxID struct{}
 
 // BeginTx begins a transaction on the database.
 // The transaction can be rolled back by invoking the cancel function.
+// If the context is marked with chaindb.WithLongRunning the transaction uses the
+// long-running statement timeout.
+// It returns ErrReadOnly if the service is read-only.
 func (s *Service) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if s.readOnly {
+		return nil, nil, ErrReadOnly
+	}
+
 	// #nosec G404
 	id := fmt.Sprintf("%02x", rand.Int31())
 	log := log.With().Str("id", id).Logger()
@@ -46,6 +56,15 @@ func (s *Service) BeginTx(ctx context.Context) (context.Context, context.CancelF
 		cancel()
 		return nil, nil, errors.Wrap(err, "failed to begin transaction")
 	}
+	if err := s.setLongRunningStatementTimeout(ctx, tx); err != nil {
+		log.Trace().Err(err).Msg("Failed to set statement timeout")
+		if err := tx.Rollback(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to rollback transaction")
+		}
+		cancel()
+		return nil, nil, errors.Wrap(err, "failed to set statement timeout")
+	}
+	tx = &timeoutTx{Tx: tx}
 
 	ctx = context.WithValue(ctx, &Tx{}, tx)
 	ctx = context.WithValue(ctx, &TxID{}, id)
@@ -73,6 +92,14 @@ func (s *Service) BeginROTx(ctx context.Context) (context.Context, error) {
 		log.Trace().Err(err).Str("trace", fmt.Sprintf("+%v", errors.Wrap(err, "stack"))).Msg("Failed to begin read-only transaction")
 		return nil, errors.Wrap(err, "failed to begin read-only transaction")
 	}
+	if err := s.setLongRunningStatementTimeout(ctx, tx); err != nil {
+		log.Trace().Err(err).Msg("Failed to set statement timeout")
+		if err := tx.Rollback(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to rollback read-only transaction")
+		}
+		return nil, errors.Wrap(err, "failed to set statement timeout")
+	}
+	tx = &timeoutTx{Tx: tx}
 
 	ctx = context.WithValue(ctx, &Tx{}, tx)
 	ctx = context.WithValue(ctx, &TxID{}, id)
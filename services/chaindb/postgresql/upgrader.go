@@ -21,13 +21,47 @@ import (
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
 )
 
 type schemaMetadata struct {
 	Version uint64 `json:"version"`
+	// Release is the release of chaind that last wrote the schema.
+	Release string `json:"release,omitempty"`
 }
 
-var currentVersion = uint64(13)
+// SchemaTooNewError is returned when the database schema is newer than the
+// schema supported by this release of chaind, as happens when running an older
+// release against a database upgraded by a newer one.
+type SchemaTooNewError struct {
+	// Version is the version of the schema in the database.
+	Version uint64
+	// Release is the release of chaind that wrote the schema, if known.
+	Release string
+	// SupportedVersion is the latest schema version supported by this release.
+	SupportedVersion uint64
+	// SupportedRelease is this release of chaind, if known.
+	SupportedRelease string
+}
+
+// Error implements the error interface.
+func (e *SchemaTooNewError) Error() string {
+	writer := "an unknown release of chaind"
+	remedy := "upgrade chaind to the release that wrote the schema or later"
+	if e.Release != "" {
+		writer = fmt.Sprintf("chaind %s", e.Release)
+		remedy = fmt.Sprintf("upgrade chaind to %s or later", e.Release)
+	}
+	running := "this release of chaind"
+	if e.SupportedRelease != "" {
+		running = fmt.Sprintf("chaind %s", e.SupportedRelease)
+	}
+
+	return fmt.Sprintf("database schema version %d, written by %s, is newer than version %d supported by %s; %s",
+		e.Version, writer, e.SupportedVersion, running, remedy)
+}
+
+var currentVersion = uint64(27)
 
 type upgrade struct {
 	requiresRefetch bool
@@ -118,10 +152,86 @@ var upgrades = map[uint64]*upgrade{
 			addExcessDataGas,
 		},
 	},
+	14: {
+		funcs: []func(context.Context, *Service) error{
+			createSkippedSlots,
+			addEpochSkippedSlots,
+		},
+	},
+	15: {
+		funcs: []func(context.Context, *Service) error{
+			addCompactBeaconCommittees,
+		},
+	},
+	16: {
+		funcs: []func(context.Context, *Service) error{
+			createEpochQueueSummaries,
+		},
+	},
+	17: {
+		funcs: []func(context.Context, *Service) error{
+			addETH1DepositSignatureValid,
+		},
+	},
+	18: {
+		funcs: []func(context.Context, *Service) error{
+			createSlotProposals,
+			addValidatorEpochProposalOutcomes,
+		},
+	},
+	19: {
+		funcs: []func(context.Context, *Service) error{
+			addValidatorEpochAttesterDuty,
+		},
+	},
+	20: {
+		funcs: []func(context.Context, *Service) error{
+			addSyncCommitteeParticipation,
+		},
+	},
+	21: {
+		funcs: []func(context.Context, *Service) error{
+			createConsistencyIssues,
+		},
+	},
+	22: {
+		funcs: []func(context.Context, *Service) error{
+			addAttestationSourceCorrect,
+			createAttestationIncorrectVoteIndices,
+		},
+	},
+	23: {
+		funcs: []func(context.Context, *Service) error{
+			createEpochDepositSummaries,
+		},
+	},
+	24: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorSlashings,
+		},
+	},
+	25: {
+		funcs: []func(context.Context, *Service) error{
+			addBlockSSZSizeAndArrivalDelay,
+		},
+	},
+	26: {
+		funcs: []func(context.Context, *Service) error{
+			addSummarizerVersions,
+		},
+	},
+	27: {
+		funcs: []func(context.Context, *Service) error{
+			createAggregateAttestations,
+			recordFullAttestationStorage,
+		},
+	},
 }
 
 // Upgrade upgrades the database.
 // Returns true if the upgrade requires blocks to be refetched.
+// A read-only service returns an error wrapping ErrReadOnly if an upgrade is required.
+// If the schema is newer than this release supports it returns a *SchemaTooNewError.
 func (s *Service) Upgrade(ctx context.Context) (bool, error) {
 	// See if we have anything at all.
 	tableExists, err := s.tableExists(ctx, "t_metadata")
@@ -129,25 +239,38 @@ func (s *Service) Upgrade(ctx context.Context) (bool, error) {
 		return false, errors.Wrap(err, "failed to check presence of tables")
 	}
 	if !tableExists {
+		if s.readOnly {
+			return false, errors.Wrap(ErrReadOnly, "database has not been initialised")
+		}
 		return false, s.Init(ctx)
 	}
 
-	version, err := s.version(ctx)
+	schema, err := s.schema(ctx)
 	if err != nil {
 		return false, errors.Wrap(err, "failed to obtain version")
 	}
+	version := schema.Version
 
-	log.Trace().Uint64("current_version", version).Uint64("required_version", currentVersion).Msg("Checking if database upgrade is required")
+	log.Trace().Uint64("current_version", version).Str("release", schema.Release).Uint64("required_version", currentVersion).Msg("Checking if database upgrade is required")
 	if version == currentVersion {
 		// Nothing to do.
 		return false, nil
 	}
 	if version > currentVersion {
-		log.Warn().Msg("This release is running an older version than that in the database, please upgrade to the latest release")
-		return false, nil
+		return false, &SchemaTooNewError{
+			Version:          version,
+			Release:          schema.Release,
+			SupportedVersion: currentVersion,
+			SupportedRelease: s.release,
+		}
 	}
 
-	ctx, cancel, err := s.BeginTx(ctx)
+	if s.readOnly {
+		return false, errors.Wrap(ErrReadOnly, fmt.Sprintf("database requires upgrade from version %d to %d", version, currentVersion))
+	}
+
+	// Upgrades can rewrite large tables, so are not subject to the default statement timeout.
+	ctx, cancel, err := s.BeginTx(chaindb.WithLongRunning(ctx))
 	if err != nil {
 		return false, errors.Wrap(err, "failed to begin upgrade transaction")
 	}
@@ -551,27 +674,27 @@ WHERE table_schema = (SELECT current_schema())
 	return found, nil
 }
 
-// version obtains the version of the schema.
-func (s *Service) version(ctx context.Context) (uint64, error) {
+// schema obtains the metadata of the schema.
+func (s *Service) schema(ctx context.Context) (*schemaMetadata, error) {
 	data, err := s.Metadata(ctx, "schema")
 	if err != nil {
-		return 0, errors.Wrap(err, "failed to obtain schema metadata")
+		return nil, errors.Wrap(err, "failed to obtain schema metadata")
 	}
 
 	// No data means it's version 0 of the schema.
 	if len(data) == 0 {
-		return 0, nil
+		return &schemaMetadata{}, nil
 	}
 
 	var metadata schemaMetadata
 	if err := json.Unmarshal(data, &metadata); err != nil {
-		return 0, errors.Wrap(err, "failed to unmarshal metadata JSON")
+		return nil, errors.Wrap(err, "failed to unmarshal metadata JSON")
 	}
 
-	return metadata.Version, nil
+	return &metadata, nil
 }
 
-// setVersion sets the version of the schema.
+// setVersion sets the version of the schema, recording this release as its writer.
 func (s *Service) setVersion(ctx context.Context, version uint64) error {
 	if tx := s.tx(ctx); tx == nil {
 		return ErrNoTransaction
@@ -579,6 +702,7 @@ func (s *Service) setVersion(ctx context.Context, version uint64) error {
 
 	metadata := &schemaMetadata{
 		Version: version,
+		Release: s.release,
 	}
 	data, err := json.Marshal(metadata)
 	if err != nil {
@@ -820,7 +944,7 @@ CREATE UNIQUE INDEX i_sync_aggregates_1 ON t_sync_aggregates(f_inclusion_slot, f
 
 // Init initialises the database.
 func (s *Service) Init(ctx context.Context) error {
-	ctx, cancel, err := s.BeginTx(ctx)
+	ctx, cancel, err := s.BeginTx(chaindb.WithLongRunning(ctx))
 	if err != nil {
 		return errors.Wrap(err, "failed to begin initial tables transaction")
 	}
@@ -890,6 +1014,9 @@ CREATE TABLE t_blocks (
  ,f_eth1_block_hash    BYTEA NOT NULL
  ,f_eth1_deposit_count BIGINT NOT NULL
  ,f_eth1_deposit_root  BYTEA NOT NULL
+  -- f_arrival_delay is in milliseconds.
+ ,f_ssz_size           BIGINT
+ ,f_arrival_delay      BIGINT
 );
 CREATE UNIQUE INDEX i_blocks_1 ON t_blocks(f_slot,f_root);
 CREATE UNIQUE INDEX i_blocks_2 ON t_blocks(f_root);
@@ -916,10 +1043,12 @@ CREATE TABLE t_block_execution_payloads (
 
 -- t_beacon_committees contains all beacon committees.
 -- N.B. in the case of a chain re-org the committees can alter.
+-- Exactly one of f_committee and f_committee_compact is set.
 CREATE TABLE t_beacon_committees (
   f_slot BIGINT NOT NULL
  ,f_index BIGINT NOT NULL
- ,f_committee BIGINT[] NOT NULL -- REFERENCES t_validators(f_index)
+ ,f_committee BIGINT[] -- REFERENCES t_validators(f_index)
+ ,f_committee_compact BYTEA
 );
 CREATE UNIQUE INDEX i_beacon_committees_1 ON t_beacon_committees(f_slot, f_index);
 
@@ -948,10 +1077,14 @@ CREATE TABLE t_attestations (
  ,f_canonical            BOOL
  ,f_target_correct       BOOL
  ,f_head_correct         BOOL
+ ,f_source_correct       BOOL
 );
 CREATE UNIQUE INDEX i_attestations_1 ON t_attestations(f_inclusion_slot,f_inclusion_block_root,f_inclusion_index);
 CREATE INDEX i_attestations_2 ON t_attestations(f_slot);
 CREATE INDEX i_attestations_3 ON t_attestations(f_beacon_block_root);
+CREATE INDEX i_attestations_4 ON t_attestations(f_target_epoch) WHERE f_head_correct = false;
+CREATE INDEX i_attestations_5 ON t_attestations(f_target_epoch) WHERE f_target_correct = false;
+CREATE INDEX i_attestations_6 ON t_attestations(f_target_epoch) WHERE f_source_correct = false;
 
 -- t_sync_aggregates contains the sync committee aggregates included in blocks.
 CREATE TABLE t_sync_aggregates (
@@ -1048,6 +1181,7 @@ CREATE TABLE t_eth1_deposits (
  ,f_withdrawal_credentials BYTEA NOT NULL
  ,f_signature              BYTEA NOT NULL
  ,f_amount                 BIGINT NOT NULL
+ ,f_signature_valid        BOOL
 );
 CREATE UNIQUE INDEX i_eth1_deposits_1 ON t_eth1_deposits(f_eth1_block_hash, f_eth1_tx_hash, f_eth1_log_index);
 CREATE INDEX i_eth1_deposits_2 ON t_eth1_deposits(f_validator_pubkey);
@@ -1070,6 +1204,9 @@ CREATE TABLE t_validator_epoch_summaries (
  ,f_epoch                       BIGINT NOT NULL
  ,f_proposer_duties             INTEGER NOT NULL
  ,f_proposals_included          INTEGER NOT NULL
+ ,f_proposals_orphaned          INTEGER NOT NULL DEFAULT 0
+ ,f_proposals_missed            INTEGER NOT NULL DEFAULT 0
+ ,f_attester_duty               BOOL NOT NULL DEFAULT true
  ,f_attestation_included        BOOL NOT NULL
  ,f_attestation_source_timely   BOOL
  ,f_attestation_target_correct  BOOL
@@ -1077,6 +1214,9 @@ CREATE TABLE t_validator_epoch_summaries (
  ,f_attestation_head_correct    BOOL
  ,f_attestation_head_timely     BOOL
  ,f_attestation_inclusion_delay INTEGER
+ ,f_attestation_orphaned        BOOL
+ ,f_sync_committee_participation FLOAT(8)
+ ,f_summarizer_version          INTEGER NOT NULL DEFAULT 0
 );
 CREATE UNIQUE INDEX IF NOT EXISTS i_validator_epoch_summaries_1 ON t_validator_epoch_summaries(f_validator_index, f_epoch);
 
@@ -1086,6 +1226,7 @@ CREATE TABLE t_block_summaries (
  ,f_duplicate_attestations_for_block INTEGER NOT NULL
  ,f_votes_for_block                  INTEGER NOT NULL
  ,f_parent_distance                  INTEGER NOT NULL
+ ,f_summarizer_version               INTEGER NOT NULL DEFAULT 0
 );
 CREATE UNIQUE INDEX IF NOT EXISTS i_block_summaries_1 ON t_block_summaries(f_slot);
 
@@ -1111,6 +1252,35 @@ CREATE TABLE t_epoch_summaries (
  ,f_exiting_validators               BIGINT NOT NULL
  ,f_canonical_blocks                 BIGINT NOT NULL
  ,f_withdrawals                      BIGINT NOT NULL
+ ,f_skipped_slots                    BIGINT NOT NULL DEFAULT 0
+ ,f_summarizer_version               INTEGER NOT NULL DEFAULT 0
+);
+
+-- t_epoch_queue_summaries contains the validator activation and exit queues at each epoch.
+-- The epoch at which an exit was initiated is not stored, so exit figures cannot be calculated
+-- for past epochs; f_exit_queue_length and f_exit_wait are NULL for epochs before exit queues
+-- were tracked.
+CREATE TABLE t_epoch_queue_summaries (
+  f_epoch                   BIGINT UNIQUE NOT NULL
+ ,f_activation_queue_length BIGINT NOT NULL
+ ,f_exit_queue_length       BIGINT
+ ,f_churn_limit             BIGINT NOT NULL
+ ,f_activation_churn_limit  BIGINT NOT NULL
+ ,f_activation_wait         BIGINT NOT NULL
+ ,f_exit_wait               BIGINT
+);
+
+CREATE TABLE t_epoch_deposit_summaries (
+  f_epoch                   BIGINT UNIQUE NOT NULL
+ ,f_deposits                BIGINT NOT NULL
+ ,f_amount                  BIGINT NOT NULL
+ ,f_new_validators          BIGINT NOT NULL
+ ,f_new_validators_amount   BIGINT NOT NULL
+ ,f_top_ups                 BIGINT NOT NULL
+ ,f_top_ups_amount          BIGINT NOT NULL
+ ,f_invalid_deposits        BIGINT NOT NULL
+ ,f_invalid_deposits_amount BIGINT NOT NULL
+ ,f_eth1_tx_hashes          BYTEA[]
 );
 
 CREATE TABLE t_fork_schedule (
@@ -1145,6 +1315,8 @@ CREATE TABLE t_validator_day_summaries (
  ,f_attestations_inclusion_delay     FLOAT(4) NOT NULL
  ,f_sync_committee_messages          INTEGER NOT NULL
  ,f_sync_committee_messages_included INTEGER NOT NULL
+ ,f_sync_committee_lifetime_participation FLOAT(8)
+ ,f_summarizer_version               INTEGER NOT NULL DEFAULT 0
 );
 CREATE UNIQUE INDEX IF NOT EXISTS i_validator_day_summaries_1 ON t_validator_day_summaries(f_validator_index, f_start_timestamp);
 CREATE INDEX IF NOT EXISTS i_validator_day_summaries_2 ON t_validator_day_summaries(f_start_timestamp);
@@ -1176,11 +1348,75 @@ CREATE UNIQUE INDEX IF NOT EXISTS i_block_withdrawals_1 ON t_block_withdrawals(f
 CREATE INDEX IF NOT EXISTS i_block_withdrawals_2 ON t_block_withdrawals(f_block_number);
 CREATE INDEX IF NOT EXISTS i_block_withdrawals_3 ON t_block_withdrawals(f_validator_index);
 CREATE INDEX IF NOT EXISTS i_block_withdrawals_4 ON t_block_withdrawals(f_address);
+
+-- t_skipped_slots contains finalized slots without a canonical block.
+CREATE TABLE t_skipped_slots (
+  f_slot           BIGINT UNIQUE NOT NULL
+ ,f_proposer_index BIGINT
+);
+CREATE INDEX IF NOT EXISTS i_skipped_slots_1 ON t_skipped_slots(f_proposer_index);
+
+-- t_slot_proposals contains the outcome of the proposer duty for each finalized slot.
+CREATE TABLE t_slot_proposals (
+  f_slot           BIGINT UNIQUE NOT NULL
+ ,f_proposer_index BIGINT NOT NULL
+ ,f_outcome        SMALLINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS i_slot_proposals_1 ON t_slot_proposals(f_proposer_index);
+
+-- t_validator_slashings contains the slashing of each slashed validator, referencing
+-- the slashing operation that caused it if known.
+CREATE TABLE t_validator_slashings (
+  f_validator_index      BIGINT UNIQUE NOT NULL
+ ,f_epoch                BIGINT NOT NULL
+ ,f_type                 SMALLINT NOT NULL
+ ,f_inclusion_slot       BIGINT
+ ,f_inclusion_block_root BYTEA
+ ,f_inclusion_index      BIGINT
+);
+CREATE INDEX IF NOT EXISTS i_validator_slashings_1 ON t_validator_slashings(f_epoch);
+
+-- t_consistency_issues contains inconsistencies found in the database.
+CREATE TABLE t_consistency_issues (
+  f_check      TEXT NOT NULL
+ ,f_epoch      BIGINT NOT NULL
+ ,f_detail     TEXT NOT NULL
+ ,f_first_seen TIMESTAMPTZ NOT NULL
+ ,f_last_seen  TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_consistency_issues_1 ON t_consistency_issues(f_check,f_epoch,f_detail);
+
+-- t_aggregate_attestations contains attestations stored in aggregate mode, with
+-- one row for each unique attestation data and the aggregation bits of all of
+-- its inclusions merged.
+CREATE TABLE t_aggregate_attestations (
+  f_slot                BIGINT NOT NULL
+ ,f_data_root           BYTEA NOT NULL
+ ,f_committee_index     BIGINT NOT NULL
+ ,f_aggregation_bits    BYTEA NOT NULL
+ ,f_aggregation_indices BIGINT[]
+ ,f_beacon_block_root   BYTEA NOT NULL
+ ,f_source_epoch        BIGINT NOT NULL
+ ,f_source_root         BYTEA NOT NULL
+ ,f_target_epoch        BIGINT NOT NULL
+ ,f_target_root         BYTEA NOT NULL
+ ,f_canonical           BOOL
+ ,f_target_correct      BOOL
+ ,f_head_correct        BOOL
+ ,f_source_correct      BOOL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_aggregate_attestations_1 ON t_aggregate_attestations(f_slot,f_data_root);
+CREATE INDEX IF NOT EXISTS i_aggregate_attestations_2 ON t_aggregate_attestations(f_beacon_block_root);
 `); err != nil {
 		cancel()
 		return errors.Wrap(err, "failed to create initial tables")
 	}
 
+	if err := s.setAttestationStorageMode(ctx, s.attestationStorageMode); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set attestation storage mode")
+	}
+
 	if err := s.setVersion(ctx, currentVersion); err != nil {
 		cancel()
 		return errors.Wrap(err, "failed to set initial schema version")
@@ -1725,3 +1961,425 @@ ADD COLUMN f_excess_data_gas BIGINT NOT NULL DEFAULT 0
 
 	return nil
 }
+
+// createSkippedSlots creates the t_skipped_slots table.
+func createSkippedSlots(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_skipped_slots (
+  f_slot           BIGINT UNIQUE NOT NULL
+ ,f_proposer_index BIGINT
+)`); err != nil {
+		return errors.Wrap(err, "failed to create skipped slots table")
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_skipped_slots_1 ON t_skipped_slots(f_proposer_index)"); err != nil {
+		return errors.Wrap(err, "failed to create skipped slots index 1")
+	}
+
+	return nil
+}
+
+// addEpochSkippedSlots adds the f_skipped_slots column to t_epoch_summaries.
+// Existing summaries have a value of 0, as skipped slots were not recorded when they were created.
+func addEpochSkippedSlots(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_skipped_slots BIGINT NOT NULL DEFAULT 0
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_skipped_slots to t_epoch_summaries")
+	}
+
+	return nil
+}
+
+// addCompactBeaconCommittees allows beacon committees to be stored in compact form.
+func addCompactBeaconCommittees(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_beacon_committees
+ADD COLUMN IF NOT EXISTS f_committee_compact BYTEA
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_committee_compact to t_beacon_committees")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_beacon_committees
+ALTER COLUMN f_committee DROP NOT NULL
+`); err != nil {
+		return errors.Wrap(err, "failed to drop not null constraint on f_committee")
+	}
+
+	return nil
+}
+
+// createEpochQueueSummaries creates the t_epoch_queue_summaries table.
+func createEpochQueueSummaries(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_epoch_queue_summaries (
+  f_epoch                   BIGINT UNIQUE NOT NULL
+ ,f_activation_queue_length BIGINT NOT NULL
+ ,f_exit_queue_length       BIGINT
+ ,f_churn_limit             BIGINT NOT NULL
+ ,f_activation_churn_limit  BIGINT NOT NULL
+ ,f_activation_wait         BIGINT NOT NULL
+ ,f_exit_wait               BIGINT
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create epoch queue summaries table")
+	}
+
+	return nil
+}
+
+// addBlockSSZSizeAndArrivalDelay adds the SSZ size and arrival delay to the t_blocks table.
+func addBlockSSZSizeAndArrivalDelay(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_blocks
+ADD COLUMN IF NOT EXISTS f_ssz_size BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_ssz_size to t_blocks")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_blocks
+ADD COLUMN IF NOT EXISTS f_arrival_delay BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_arrival_delay to t_blocks")
+	}
+
+	return nil
+}
+
+// createValidatorSlashings creates the t_validator_slashings table.
+func createValidatorSlashings(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_validator_slashings (
+  f_validator_index      BIGINT UNIQUE NOT NULL
+ ,f_epoch                BIGINT NOT NULL
+ ,f_type                 SMALLINT NOT NULL
+ ,f_inclusion_slot       BIGINT
+ ,f_inclusion_block_root BYTEA
+ ,f_inclusion_index      BIGINT
+)`); err != nil {
+		return errors.Wrap(err, "failed to create validator slashings table")
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_validator_slashings_1 ON t_validator_slashings(f_epoch)"); err != nil {
+		return errors.Wrap(err, "failed to create validator slashings index 1")
+	}
+
+	return nil
+}
+
+// createEpochDepositSummaries creates the t_epoch_deposit_summaries table.
+func createEpochDepositSummaries(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_epoch_deposit_summaries (
+  f_epoch                   BIGINT UNIQUE NOT NULL
+ ,f_deposits                BIGINT NOT NULL
+ ,f_amount                  BIGINT NOT NULL
+ ,f_new_validators          BIGINT NOT NULL
+ ,f_new_validators_amount   BIGINT NOT NULL
+ ,f_top_ups                 BIGINT NOT NULL
+ ,f_top_ups_amount          BIGINT NOT NULL
+ ,f_invalid_deposits        BIGINT NOT NULL
+ ,f_invalid_deposits_amount BIGINT NOT NULL
+ ,f_eth1_tx_hashes          BYTEA[]
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create epoch deposit summaries table")
+	}
+
+	return nil
+}
+
+// addETH1DepositSignatureValid adds the signature validity flag to the t_eth1_deposits table.
+func addETH1DepositSignatureValid(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_eth1_deposits
+ADD COLUMN IF NOT EXISTS f_signature_valid BOOL
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_signature_valid to t_eth1_deposits")
+	}
+
+	return nil
+}
+
+// createSlotProposals creates the t_slot_proposals table.
+func createSlotProposals(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_slot_proposals (
+  f_slot           BIGINT UNIQUE NOT NULL
+ ,f_proposer_index BIGINT NOT NULL
+ ,f_outcome        SMALLINT NOT NULL
+)`); err != nil {
+		return errors.Wrap(err, "failed to create slot proposals table")
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_slot_proposals_1 ON t_slot_proposals(f_proposer_index)"); err != nil {
+		return errors.Wrap(err, "failed to create slot proposals index 1")
+	}
+
+	return nil
+}
+
+// addValidatorEpochProposalOutcomes adds orphaned and missed proposals to the t_validator_epoch_summaries table.
+func addValidatorEpochProposalOutcomes(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_validator_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_proposals_orphaned INTEGER NOT NULL DEFAULT 0
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_proposals_orphaned to t_validator_epoch_summaries")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_validator_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_proposals_missed INTEGER NOT NULL DEFAULT 0
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_proposals_missed to t_validator_epoch_summaries")
+	}
+
+	return nil
+}
+
+// addValidatorEpochAttesterDuty adds attester duty and orphaned attestation information
+// to the t_validator_epoch_summaries table.
+func addValidatorEpochAttesterDuty(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Summaries prior to this upgrade were only written for active validators, all of
+	// which had an attester duty, hence the default.
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_validator_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_attester_duty BOOL NOT NULL DEFAULT true
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_attester_duty to t_validator_epoch_summaries")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_validator_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_attestation_orphaned BOOL
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_attestation_orphaned to t_validator_epoch_summaries")
+	}
+
+	return nil
+}
+
+// addSyncCommitteeParticipation adds sync committee participation to the validator
+// epoch and day summary tables.
+func addSyncCommitteeParticipation(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_validator_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_sync_committee_participation FLOAT(8)
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_sync_committee_participation to t_validator_epoch_summaries")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_validator_day_summaries
+ADD COLUMN IF NOT EXISTS f_sync_committee_lifetime_participation FLOAT(8)
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_sync_committee_lifetime_participation to t_validator_day_summaries")
+	}
+
+	return nil
+}
+
+// createConsistencyIssues creates the t_consistency_issues table.
+func createConsistencyIssues(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_consistency_issues (
+  f_check      TEXT NOT NULL
+ ,f_epoch      BIGINT NOT NULL
+ ,f_detail     TEXT NOT NULL
+ ,f_first_seen TIMESTAMPTZ NOT NULL
+ ,f_last_seen  TIMESTAMPTZ NOT NULL
+)`); err != nil {
+		return errors.Wrap(err, "failed to create consistency issues table")
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE UNIQUE INDEX IF NOT EXISTS i_consistency_issues_1 ON t_consistency_issues(f_check,f_epoch,f_detail)"); err != nil {
+		return errors.Wrap(err, "failed to create consistency issues index 1")
+	}
+
+	return nil
+}
+
+// addAttestationSourceCorrect adds source vote correctness to the t_attestations table.
+func addAttestationSourceCorrect(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	alreadyPresent, err := s.columnExists(ctx, "t_attestations", "f_source_correct")
+	if err != nil {
+		return errors.Wrap(err, "failed to check if f_source_correct is present in t_attestations")
+	}
+	if alreadyPresent {
+		// Nothing more to do.
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_attestations
+ADD COLUMN f_source_correct BOOL
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_source_correct to attestations table")
+	}
+
+	return nil
+}
+
+// createAttestationIncorrectVoteIndices creates partial indices on the t_attestations table
+// to support queries for incorrect votes by epoch.
+func createAttestationIncorrectVoteIndices(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_attestations_4 ON t_attestations(f_target_epoch) WHERE f_head_correct = false"); err != nil {
+		return errors.Wrap(err, "failed to create attestations index 4")
+	}
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_attestations_5 ON t_attestations(f_target_epoch) WHERE f_target_correct = false"); err != nil {
+		return errors.Wrap(err, "failed to create attestations index 5")
+	}
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_attestations_6 ON t_attestations(f_target_epoch) WHERE f_source_correct = false"); err != nil {
+		return errors.Wrap(err, "failed to create attestations index 6")
+	}
+
+	return nil
+}
+
+// addSummarizerVersions adds the version of the summarizer that produced each summary
+// to the summary tables.  Existing summaries are given version 0.
+func addSummarizerVersions(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, table := range []string{
+		"t_epoch_summaries",
+		"t_block_summaries",
+		"t_validator_epoch_summaries",
+		"t_validator_day_summaries",
+	} {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+ALTER TABLE %s
+ADD COLUMN IF NOT EXISTS f_summarizer_version INTEGER NOT NULL DEFAULT 0
+`, table)); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to add f_summarizer_version to %s", table))
+		}
+	}
+
+	return nil
+}
+
+// createAggregateAttestations creates the t_aggregate_attestations table.
+func createAggregateAttestations(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_aggregate_attestations (
+  f_slot                BIGINT NOT NULL
+ ,f_data_root           BYTEA NOT NULL
+ ,f_committee_index     BIGINT NOT NULL
+ ,f_aggregation_bits    BYTEA NOT NULL
+ ,f_aggregation_indices BIGINT[]
+ ,f_beacon_block_root   BYTEA NOT NULL
+ ,f_source_epoch        BIGINT NOT NULL
+ ,f_source_root         BYTEA NOT NULL
+ ,f_target_epoch        BIGINT NOT NULL
+ ,f_target_root         BYTEA NOT NULL
+ ,f_canonical           BOOL
+ ,f_target_correct      BOOL
+ ,f_head_correct        BOOL
+ ,f_source_correct      BOOL
+)`); err != nil {
+		return errors.Wrap(err, "failed to create aggregate attestations table")
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE UNIQUE INDEX IF NOT EXISTS i_aggregate_attestations_1 ON t_aggregate_attestations(f_slot,f_data_root)"); err != nil {
+		return errors.Wrap(err, "failed to create aggregate attestations index 1")
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_aggregate_attestations_2 ON t_aggregate_attestations(f_beacon_block_root)"); err != nil {
+		return errors.Wrap(err, "failed to create aggregate attestations index 2")
+	}
+
+	return nil
+}
+
+// recordFullAttestationStorage records that attestations are stored in full, as they
+// were in all databases created before the storage mode could be chosen.
+func recordFullAttestationStorage(ctx context.Context, s *Service) error {
+	return s.setAttestationStorageMode(ctx, chaindb.AttestationStorageFull)
+}
@@ -15,6 +15,7 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"sort"
 	"strings"
@@ -64,6 +65,8 @@ func (s *Service) SetValidatorDaySummaries(ctx context.Context, summaries []*cha
 			"f_attestations_inclusion_delay",
 			"f_sync_committee_messages",
 			"f_sync_committee_messages_included",
+			"f_sync_committee_lifetime_participation",
+			"f_summarizer_version",
 		},
 		pgx.CopyFromSlice(len(summaries), func(i int) ([]interface{}, error) {
 			return []interface{}{
@@ -87,6 +90,8 @@ func (s *Service) SetValidatorDaySummaries(ctx context.Context, summaries []*cha
 				summaries[i].AttestationsInclusionDelay,
 				summaries[i].SyncCommitteeMessages,
 				summaries[i].SyncCommitteeMessagesIncluded,
+				summaries[i].SyncCommitteeLifetimeParticipation,
+				summaries[i].SummarizerVersion,
 			}, nil
 		}))
 
@@ -98,6 +103,10 @@ func (s *Service) SetValidatorDaySummaries(ctx context.Context, summaries []*cha
 		if err := nestedTx.Rollback(ctx); err != nil {
 			return errors.Wrap(err, "failed to roll back nested transaction")
 		}
+		if errors.Is(err, chaindb.ErrStatementTimeout) {
+			// Applying one at a time would take longer still; leave the caller to use smaller batches.
+			return err
+		}
 
 		log.Debug().Err(err).Msg("Failed to copy insert validator day summaries; applying one at a time")
 		for _, summary := range summaries {
@@ -144,8 +153,10 @@ INSERT INTO t_validator_day_summaries(f_validator_index
                                      ,f_attestations_head_timely
                                      ,f_attestations_inclusion_delay
                                      ,f_sync_committee_messages
-                                     ,f_sync_committee_messages_included)
-VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20)
+                                     ,f_sync_committee_messages_included
+                                     ,f_sync_committee_lifetime_participation
+                                     ,f_summarizer_version)
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22)
 ON CONFLICT (f_validator_index,f_start_timestamp) DO
 UPDATE
 SET f_start_balance = excluded.f_start_balance
@@ -166,6 +177,8 @@ SET f_start_balance = excluded.f_start_balance
    ,f_attestations_inclusion_delay = excluded.f_attestations_inclusion_delay
    ,f_sync_committee_messages = excluded.f_sync_committee_messages
    ,f_sync_committee_messages_included = excluded.f_sync_committee_messages_included
+   ,f_sync_committee_lifetime_participation = excluded.f_sync_committee_lifetime_participation
+   ,f_summarizer_version = excluded.f_summarizer_version
      `,
 		summary.Index,
 		summary.StartTimestamp,
@@ -187,6 +200,8 @@ SET f_start_balance = excluded.f_start_balance
 		summary.AttestationsInclusionDelay,
 		summary.SyncCommitteeMessages,
 		summary.SyncCommitteeMessagesIncluded,
+		summary.SyncCommitteeLifetimeParticipation,
+		summary.SummarizerVersion,
 	)
 
 	return err
@@ -232,6 +247,8 @@ SELECT f_validator_index
       ,f_attestations_inclusion_delay
       ,f_sync_committee_messages
       ,f_sync_committee_messages_included
+      ,f_sync_committee_lifetime_participation
+      ,f_summarizer_version
 FROM t_validator_day_summaries`)
 
 	wherestr := "WHERE"
@@ -292,6 +309,7 @@ LIMIT $%d`, len(queryVals)))
 	summaries := make([]*chaindb.ValidatorDaySummary, 0)
 	for rows.Next() {
 		summary := &chaindb.ValidatorDaySummary{}
+		var syncCommitteeLifetimeParticipation sql.NullFloat64
 		err := rows.Scan(
 			&summary.Index,
 			&summary.StartTimestamp,
@@ -313,10 +331,16 @@ LIMIT $%d`, len(queryVals)))
 			&summary.AttestationsInclusionDelay,
 			&summary.SyncCommitteeMessages,
 			&summary.SyncCommitteeMessagesIncluded,
+			&syncCommitteeLifetimeParticipation,
+			&summary.SummarizerVersion,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
+		if syncCommitteeLifetimeParticipation.Valid {
+			val := syncCommitteeLifetimeParticipation.Float64
+			summary.SyncCommitteeLifetimeParticipation = &val
+		}
 		summaries = append(summaries, summary)
 	}
 
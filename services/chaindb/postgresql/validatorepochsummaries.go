@@ -50,6 +50,9 @@ func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*c
 			"f_epoch",
 			"f_proposer_duties",
 			"f_proposals_included",
+			"f_proposals_orphaned",
+			"f_proposals_missed",
+			"f_attester_duty",
 			"f_attestation_included",
 			"f_attestation_target_correct",
 			"f_attestation_head_correct",
@@ -57,6 +60,9 @@ func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*c
 			"f_attestation_source_timely",
 			"f_attestation_target_timely",
 			"f_attestation_head_timely",
+			"f_attestation_orphaned",
+			"f_sync_committee_participation",
+			"f_summarizer_version",
 		},
 		pgx.CopyFromSlice(len(summaries), func(i int) ([]interface{}, error) {
 			return []interface{}{
@@ -64,6 +70,9 @@ func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*c
 				summaries[i].Epoch,
 				summaries[i].ProposerDuties,
 				summaries[i].ProposalsIncluded,
+				summaries[i].ProposalsOrphaned,
+				summaries[i].ProposalsMissed,
+				summaries[i].AttesterDuty,
 				summaries[i].AttestationIncluded,
 				summaries[i].AttestationTargetCorrect,
 				summaries[i].AttestationHeadCorrect,
@@ -71,6 +80,9 @@ func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*c
 				summaries[i].AttestationSourceTimely,
 				summaries[i].AttestationTargetTimely,
 				summaries[i].AttestationHeadTimely,
+				summaries[i].AttestationOrphaned,
+				summaries[i].SyncCommitteeParticipation,
+				summaries[i].SummarizerVersion,
 			}, nil
 		}))
 
@@ -114,6 +126,8 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
 	var attestationSourceTimely sql.NullBool
 	var attestationTargetTimely sql.NullBool
 	var attestationHeadTimely sql.NullBool
+	var attestationOrphaned sql.NullBool
+	var syncCommitteeParticipation sql.NullFloat64
 
 	if summary.AttestationTargetCorrect != nil {
 		attestationTargetCorrect.Valid = true
@@ -139,24 +153,41 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
 		attestationHeadTimely.Valid = true
 		attestationHeadTimely.Bool = *summary.AttestationHeadTimely
 	}
+	if summary.AttestationOrphaned != nil {
+		attestationOrphaned.Valid = true
+		attestationOrphaned.Bool = *summary.AttestationOrphaned
+	}
+	if summary.SyncCommitteeParticipation != nil {
+		syncCommitteeParticipation.Valid = true
+		syncCommitteeParticipation.Float64 = *summary.SyncCommitteeParticipation
+	}
 
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_validator_epoch_summaries(f_validator_index
                               ,f_epoch
                               ,f_proposer_duties
                               ,f_proposals_included
+                              ,f_proposals_orphaned
+                              ,f_proposals_missed
+                              ,f_attester_duty
                               ,f_attestation_included
                               ,f_attestation_target_correct
                               ,f_attestation_head_correct
                               ,f_attestation_inclusion_delay
                               ,f_attestation_source_timely
                               ,f_attestation_target_timely
-                              ,f_attestation_head_timely)
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+                              ,f_attestation_head_timely
+                              ,f_attestation_orphaned
+                              ,f_sync_committee_participation
+                              ,f_summarizer_version)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17)
       ON CONFLICT (f_validator_index,f_epoch) DO
       UPDATE
       SET f_proposer_duties = excluded.f_proposer_duties
          ,f_proposals_included = excluded.f_proposals_included
+         ,f_proposals_orphaned = excluded.f_proposals_orphaned
+         ,f_proposals_missed = excluded.f_proposals_missed
+         ,f_attester_duty = excluded.f_attester_duty
          ,f_attestation_included = excluded.f_attestation_included
          ,f_attestation_target_correct = excluded.f_attestation_target_correct
          ,f_attestation_head_correct = excluded.f_attestation_head_correct
@@ -164,11 +195,17 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
          ,f_attestation_source_timely = excluded.f_attestation_source_timely
          ,f_attestation_target_timely = excluded.f_attestation_target_timely
          ,f_attestation_head_timely = excluded.f_attestation_head_timely
+         ,f_attestation_orphaned = excluded.f_attestation_orphaned
+         ,f_sync_committee_participation = excluded.f_sync_committee_participation
+         ,f_summarizer_version = excluded.f_summarizer_version
 		 `,
 		summary.Index,
 		summary.Epoch,
 		summary.ProposerDuties,
 		summary.ProposalsIncluded,
+		summary.ProposalsOrphaned,
+		summary.ProposalsMissed,
+		summary.AttesterDuty,
 		summary.AttestationIncluded,
 		attestationTargetCorrect,
 		attestationHeadCorrect,
@@ -176,6 +213,9 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
 		attestationSourceTimely,
 		attestationTargetTimely,
 		attestationHeadTimely,
+		attestationOrphaned,
+		syncCommitteeParticipation,
+		summary.SummarizerVersion,
 	)
 
 	return err
@@ -205,6 +245,9 @@ SELECT f_validator_index
       ,f_epoch
       ,f_proposer_duties
       ,f_proposals_included
+      ,f_proposals_orphaned
+      ,f_proposals_missed
+      ,f_attester_duty
       ,f_attestation_included
       ,f_attestation_target_correct
       ,f_attestation_head_correct
@@ -212,6 +255,9 @@ SELECT f_validator_index
       ,f_attestation_source_timely
       ,f_attestation_target_timely
       ,f_attestation_head_timely
+      ,f_attestation_orphaned
+      ,f_sync_committee_participation
+      ,f_summarizer_version
 FROM t_validator_epoch_summaries`)
 
 	wherestr := "WHERE"
@@ -278,11 +324,16 @@ LIMIT $%d`, len(queryVals)))
 		var attestationSourceTimely sql.NullBool
 		var attestationTargetTimely sql.NullBool
 		var attestationHeadTimely sql.NullBool
+		var attestationOrphaned sql.NullBool
+		var syncCommitteeParticipation sql.NullFloat64
 		err := rows.Scan(
 			&summary.Index,
 			&summary.Epoch,
 			&summary.ProposerDuties,
 			&summary.ProposalsIncluded,
+			&summary.ProposalsOrphaned,
+			&summary.ProposalsMissed,
+			&summary.AttesterDuty,
 			&summary.AttestationIncluded,
 			&attestationTargetCorrect,
 			&attestationHeadCorrect,
@@ -290,6 +341,9 @@ LIMIT $%d`, len(queryVals)))
 			&attestationSourceTimely,
 			&attestationTargetTimely,
 			&attestationHeadTimely,
+			&attestationOrphaned,
+			&syncCommitteeParticipation,
+			&summary.SummarizerVersion,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -318,6 +372,14 @@ LIMIT $%d`, len(queryVals)))
 			val := attestationHeadTimely.Bool
 			summary.AttestationHeadTimely = &val
 		}
+		if attestationOrphaned.Valid {
+			val := attestationOrphaned.Bool
+			summary.AttestationOrphaned = &val
+		}
+		if syncCommitteeParticipation.Valid {
+			val := syncCommitteeParticipation.Float64
+			summary.SyncCommitteeParticipation = &val
+		}
 		summaries = append(summaries, summary)
 	}
 
@@ -351,6 +413,9 @@ SELECT f_validator_index
       ,f_epoch
       ,f_proposer_duties
       ,f_proposals_included
+      ,f_proposals_orphaned
+      ,f_proposals_missed
+      ,f_attester_duty
       ,f_attestation_included
       ,f_attestation_target_correct
       ,f_attestation_head_correct
@@ -358,6 +423,9 @@ SELECT f_validator_index
       ,f_attestation_source_timely
       ,f_attestation_target_timely
       ,f_attestation_head_timely
+      ,f_attestation_orphaned
+      ,f_sync_committee_participation
+      ,f_summarizer_version
 FROM t_validator_epoch_summaries
 WHERE f_epoch = $1
 ORDER BY f_validator_index
@@ -379,11 +447,16 @@ ORDER BY f_validator_index
 		var attestationSourceTimely sql.NullBool
 		var attestationTargetTimely sql.NullBool
 		var attestationHeadTimely sql.NullBool
+		var attestationOrphaned sql.NullBool
+		var syncCommitteeParticipation sql.NullFloat64
 		err := rows.Scan(
 			&summary.Index,
 			&summary.Epoch,
 			&summary.ProposerDuties,
 			&summary.ProposalsIncluded,
+			&summary.ProposalsOrphaned,
+			&summary.ProposalsMissed,
+			&summary.AttesterDuty,
 			&summary.AttestationIncluded,
 			&attestationTargetCorrect,
 			&attestationHeadCorrect,
@@ -391,6 +464,9 @@ ORDER BY f_validator_index
 			&attestationSourceTimely,
 			&attestationTargetTimely,
 			&attestationHeadTimely,
+			&attestationOrphaned,
+			&syncCommitteeParticipation,
+			&summary.SummarizerVersion,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -419,6 +495,14 @@ ORDER BY f_validator_index
 			val := attestationHeadTimely.Bool
 			summary.AttestationHeadTimely = &val
 		}
+		if attestationOrphaned.Valid {
+			val := attestationOrphaned.Bool
+			summary.AttestationOrphaned = &val
+		}
+		if syncCommitteeParticipation.Valid {
+			val := syncCommitteeParticipation.Float64
+			summary.SyncCommitteeParticipation = &val
+		}
 		summaries = append(summaries, summary)
 	}
 
@@ -453,12 +537,17 @@ func (s *Service) ValidatorSummaryForEpoch(ctx context.Context,
 	var attestationSourceTimely sql.NullBool
 	var attestationTargetTimely sql.NullBool
 	var attestationHeadTimely sql.NullBool
+	var attestationOrphaned sql.NullBool
+	var syncCommitteeParticipation sql.NullFloat64
 
 	err := tx.QueryRow(ctx, `
 SELECT f_validator_index
       ,f_epoch
       ,f_proposer_duties
       ,f_proposals_included
+      ,f_proposals_orphaned
+      ,f_proposals_missed
+      ,f_attester_duty
       ,f_attestation_included
       ,f_attestation_target_correct
       ,f_attestation_head_correct
@@ -466,6 +555,9 @@ SELECT f_validator_index
       ,f_attestation_source_timely
       ,f_attestation_target_timely
       ,f_attestation_head_timely
+      ,f_attestation_orphaned
+      ,f_sync_committee_participation
+      ,f_summarizer_version
 FROM t_validator_epoch_summaries
 WHERE f_validator_index = $1
   AND f_epoch = $2
@@ -477,6 +569,9 @@ WHERE f_validator_index = $1
 		&summary.Epoch,
 		&summary.ProposerDuties,
 		&summary.ProposalsIncluded,
+		&summary.ProposalsOrphaned,
+		&summary.ProposalsMissed,
+		&summary.AttesterDuty,
 		&summary.AttestationIncluded,
 		&attestationTargetCorrect,
 		&attestationHeadCorrect,
@@ -484,6 +579,9 @@ WHERE f_validator_index = $1
 		&attestationSourceTimely,
 		&attestationTargetTimely,
 		&attestationHeadTimely,
+		&attestationOrphaned,
+		&syncCommitteeParticipation,
+		&summary.SummarizerVersion,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to scan row")
@@ -513,6 +611,14 @@ WHERE f_validator_index = $1
 		val := attestationHeadTimely.Bool
 		summary.AttestationHeadTimely = &val
 	}
+	if attestationOrphaned.Valid {
+		val := attestationOrphaned.Bool
+		summary.AttestationOrphaned = &val
+	}
+	if syncCommitteeParticipation.Valid {
+		val := syncCommitteeParticipation.Float64
+		summary.SyncCommitteeParticipation = &val
+	}
 
 	return summary, nil
 }
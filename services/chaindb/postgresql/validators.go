@@ -496,6 +496,18 @@ func (s *Service) ValidatorBalancesByIndexAndEpochRange(
 		tx = s.tx(ctx)
 	}
 
+	// Balances are not present before the ingestion origin, so do not pad them out with zeros.
+	origin, err := s.Origin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain origin")
+	}
+	if origin != nil && startEpoch < origin.Epoch {
+		startEpoch = origin.Epoch
+	}
+	if startEpoch >= endEpoch {
+		return map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance{}, nil
+	}
+
 	// Sort the validator indices.
 	sort.Slice(validatorIndices, func(i, j int) bool {
 		return validatorIndices[i] < validatorIndices[j]
@@ -757,3 +769,54 @@ AND  f_validator_index NOT IN($2)
 
 	return err
 }
+
+// NeighbouringValidatorBalanceEpochs provides the latest epoch at or before, and the earliest epoch at or after,
+// the given epoch for which validator balances are held.
+// Either may be nil if no such epoch is held.
+func (s *Service) NeighbouringValidatorBalanceEpochs(ctx context.Context,
+	epoch phase0.Epoch,
+) (
+	*phase0.Epoch,
+	*phase0.Epoch,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "NeighbouringValidatorBalanceEpochs")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	var before sql.NullInt64
+	var after sql.NullInt64
+	err := tx.QueryRow(ctx, `
+      SELECT (SELECT MAX(f_epoch) FROM t_validator_balances WHERE f_epoch <= $1::BIGINT)
+            ,(SELECT MIN(f_epoch) FROM t_validator_balances WHERE f_epoch >= $1::BIGINT)`,
+		uint64(epoch),
+	).Scan(
+		&before,
+		&after,
+	)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to obtain neighbouring balance epochs")
+	}
+
+	var beforeEpoch *phase0.Epoch
+	if before.Valid {
+		tmp := phase0.Epoch(before.Int64)
+		beforeEpoch = &tmp
+	}
+	var afterEpoch *phase0.Epoch
+	if after.Valid {
+		tmp := phase0.Epoch(after.Int64)
+		afterEpoch = &tmp
+	}
+
+	return beforeEpoch, afterEpoch, nil
+}
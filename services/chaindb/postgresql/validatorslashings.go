@@ -0,0 +1,163 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+)
+
+// SetValidatorSlashing sets a validator slashing.
+func (s *Service) SetValidatorSlashing(ctx context.Context, slashing *chaindb.ValidatorSlashing) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "SetValidatorSlashing")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	var inclusionSlot sql.NullInt64
+	if slashing.InclusionSlot != nil {
+		inclusionSlot.Valid = true
+		inclusionSlot.Int64 = int64(*slashing.InclusionSlot)
+	}
+	var inclusionBlockRoot []byte
+	if slashing.InclusionBlockRoot != nil {
+		inclusionBlockRoot = slashing.InclusionBlockRoot[:]
+	}
+	var inclusionIndex sql.NullInt64
+	if slashing.InclusionIndex != nil {
+		inclusionIndex.Valid = true
+		inclusionIndex.Int64 = int64(*slashing.InclusionIndex)
+	}
+
+	_, err := tx.Exec(ctx, `
+      INSERT INTO t_validator_slashings(f_validator_index
+                                       ,f_epoch
+                                       ,f_type
+                                       ,f_inclusion_slot
+                                       ,f_inclusion_block_root
+                                       ,f_inclusion_index)
+      VALUES($1,$2,$3,$4,$5,$6)
+      ON CONFLICT (f_validator_index) DO
+      UPDATE
+      SET f_epoch = excluded.f_epoch
+         ,f_type = excluded.f_type
+         ,f_inclusion_slot = excluded.f_inclusion_slot
+         ,f_inclusion_block_root = excluded.f_inclusion_block_root
+         ,f_inclusion_index = excluded.f_inclusion_index
+		 `,
+		slashing.Index,
+		slashing.Epoch,
+		int16(slashing.Type),
+		inclusionSlot,
+		inclusionBlockRoot,
+		inclusionIndex,
+	)
+
+	return err
+}
+
+// ValidatorSlashings fetches the slashings for the given validators.
+// If no indices are supplied then slashings for all validators are returned.
+func (s *Service) ValidatorSlashings(ctx context.Context, indices []phase0.ValidatorIndex) ([]*chaindb.ValidatorSlashing, error) {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.chaindb.postgresql").Start(ctx, "ValidatorSlashings")
+	defer span.End()
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err := s.BeginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		defer s.CommitROTx(ctx)
+		tx = s.tx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]interface{}, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_validator_index
+      ,f_epoch
+      ,f_type
+      ,f_inclusion_slot
+      ,f_inclusion_block_root
+      ,f_inclusion_index
+FROM t_validator_slashings`)
+
+	if len(indices) > 0 {
+		queryVals = append(queryVals, indices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+WHERE f_validator_index = ANY($%d)`, len(queryVals)))
+	}
+
+	queryBuilder.WriteString(`
+ORDER BY f_validator_index`)
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slashings := make([]*chaindb.ValidatorSlashing, 0)
+	for rows.Next() {
+		slashing := &chaindb.ValidatorSlashing{}
+		var slashingType int16
+		var inclusionSlot sql.NullInt64
+		var inclusionBlockRoot []byte
+		var inclusionIndex sql.NullInt64
+		err := rows.Scan(
+			&slashing.Index,
+			&slashing.Epoch,
+			&slashingType,
+			&inclusionSlot,
+			&inclusionBlockRoot,
+			&inclusionIndex,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		slashing.Type = chaindb.SlashingType(slashingType)
+		if inclusionSlot.Valid {
+			tmp := phase0.Slot(inclusionSlot.Int64)
+			slashing.InclusionSlot = &tmp
+		}
+		if inclusionBlockRoot != nil {
+			tmp := phase0.Root{}
+			copy(tmp[:], inclusionBlockRoot)
+			slashing.InclusionBlockRoot = &tmp
+		}
+		if inclusionIndex.Valid {
+			tmp := uint64(inclusionIndex.Int64)
+			slashing.InclusionIndex = &tmp
+		}
+		slashings = append(slashings, slashing)
+	}
+
+	return slashings, nil
+}
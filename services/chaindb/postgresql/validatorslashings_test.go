@@ -0,0 +1,83 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestValidatorSlashings(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	inclusionSlot := phase0.Slot(1000)
+	inclusionBlockRoot := phase0.Root{
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x04, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x04, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+	}
+	inclusionIndex := uint64(2)
+	slashing1 := &chaindb.ValidatorSlashing{
+		Index:              0xfffffff0,
+		Epoch:              31,
+		Type:               chaindb.SlashingTypeAttester,
+		InclusionSlot:      &inclusionSlot,
+		InclusionBlockRoot: &inclusionBlockRoot,
+		InclusionIndex:     &inclusionIndex,
+	}
+	slashing2 := &chaindb.ValidatorSlashing{
+		Index: 0xfffffff1,
+		Epoch: 40,
+		Type:  chaindb.SlashingTypeUnknown,
+	}
+
+	// Try to set outside of a transaction; should fail.
+	require.EqualError(t, s.SetValidatorSlashing(ctx, slashing1), postgresql.ErrNoTransaction.Error())
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	// Set.
+	require.NoError(t, s.SetValidatorSlashing(ctx, slashing1))
+	require.NoError(t, s.SetValidatorSlashing(ctx, slashing2))
+
+	// Attempt to set the same again; should succeed.
+	require.NoError(t, s.SetValidatorSlashing(ctx, slashing1))
+
+	slashings, err := s.ValidatorSlashings(ctx, []phase0.ValidatorIndex{slashing1.Index, slashing2.Index})
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.ValidatorSlashing{slashing1, slashing2}, slashings)
+
+	// Update the slashing without evidence now that the operation is known.
+	slashing2.Type = chaindb.SlashingTypeProposer
+	slashing2.InclusionSlot = &inclusionSlot
+	slashing2.InclusionBlockRoot = &inclusionBlockRoot
+	slashing2.InclusionIndex = &inclusionIndex
+	require.NoError(t, s.SetValidatorSlashing(ctx, slashing2))
+	slashings, err = s.ValidatorSlashings(ctx, []phase0.ValidatorIndex{slashing2.Index})
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.ValidatorSlashing{slashing2}, slashings)
+}
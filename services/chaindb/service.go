@@ -15,6 +15,7 @@ package chaindb
 
 import (
 	"context"
+	"time"
 
 	api "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -42,6 +43,12 @@ type AttestationsProvider interface {
 	IndeterminateAttestationSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error)
 }
 
+// AttestationStorageModeProvider defines functions to obtain the mode in which attestations are stored.
+type AttestationStorageModeProvider interface {
+	// AttestationStorageMode returns the mode in which attestations are stored.
+	AttestationStorageMode() AttestationStorageMode
+}
+
 // AttestationsSetter defines functions to create and update attestations.
 type AttestationsSetter interface {
 	// SetAttestation sets an attestation.
@@ -81,6 +88,13 @@ type BeaconCommitteesProvider interface {
 	AttesterDuties(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot, validatorIndices []phase0.ValidatorIndex) ([]*AttesterDuty, error)
 }
 
+// BeaconCommitteesCompactor defines functions to store existing beacon committees in compact form.
+type BeaconCommitteesCompactor interface {
+	// CompactBeaconCommittees rewrites up to batchSize beacon committees held in
+	// full form in compact form, returning the number rewritten.
+	CompactBeaconCommittees(ctx context.Context, batchSize int) (int, error)
+}
+
 // BeaconCommitteesSetter defines functions to create and update beacon committee information.
 type BeaconCommitteesSetter interface {
 	// SetBeaconCommittee sets a beacon committee.
@@ -176,6 +190,12 @@ type ETH1DepositsProvider interface {
 	ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) ([]*ETH1Deposit, error)
 }
 
+// ETH1DepositsCountProvider defines functions to count Ethereum 1 deposits.
+type ETH1DepositsCountProvider interface {
+	// ETH1DepositsCountToBlock counts the Ethereum 1 deposits in blocks up to and including the given block number.
+	ETH1DepositsCountToBlock(ctx context.Context, blockNumber uint64) (uint64, error)
+}
+
 // ETH1DepositsSetter defines functions to create and update Ethereum 1 deposits.
 type ETH1DepositsSetter interface {
 	// SetETH1Deposit sets an Ethereum 1 deposit.
@@ -199,6 +219,34 @@ type ProposerDutiesSetter interface {
 	SetProposerDuty(ctx context.Context, proposerDuty *ProposerDuty) error
 }
 
+// SkippedSlotsProvider defines functions to access skipped slots.
+type SkippedSlotsProvider interface {
+	// SkippedSlotsForSlotRange fetches all skipped slots for the given slot range.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+	// skipped slots for slots 2 and 3.
+	SkippedSlotsForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*SkippedSlot, error)
+}
+
+// SkippedSlotsSetter defines functions to create and update skipped slots.
+type SkippedSlotsSetter interface {
+	// SetSkippedSlot sets a skipped slot.
+	SetSkippedSlot(ctx context.Context, skippedSlot *SkippedSlot) error
+}
+
+// SlotProposalsProvider defines functions to access slot proposals.
+type SlotProposalsProvider interface {
+	// SlotProposalsForSlotRange fetches all slot proposals for the given slot range.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+	// slot proposals for slots 2 and 3.
+	SlotProposalsForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*SlotProposal, error)
+}
+
+// SlotProposalsSetter defines functions to create and update slot proposals.
+type SlotProposalsSetter interface {
+	// SetSlotProposals sets multiple slot proposals.
+	SetSlotProposals(ctx context.Context, proposals []*SlotProposal) error
+}
+
 // ProposerSlashingsProvider defines functions to access proposer slashings.
 type ProposerSlashingsProvider interface {
 	// ProposerSlashingsForSlotRange fetches all proposer slashings made for the given slot range.
@@ -263,6 +311,7 @@ type ValidatorsProvider interface {
 	// ValidatorBalancesByIndexAndEpochRange fetches the validator balances for the given validators and epoch range.
 	// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
 	// balances for epochs 2 and 3.
+	// Epochs before the ingestion origin are not returned.
 	ValidatorBalancesByIndexAndEpochRange(
 		ctx context.Context,
 		indices []phase0.ValidatorIndex,
@@ -321,6 +370,14 @@ type AggregateValidatorBalancesProvider interface {
 	)
 }
 
+// ValidatorBalanceEpochsProvider defines functions to find the epochs for which validator balances are held.
+type ValidatorBalanceEpochsProvider interface {
+	// NeighbouringValidatorBalanceEpochs provides the latest epoch at or before, and the earliest epoch at or after,
+	// the given epoch for which validator balances are held.
+	// Either may be nil if no such epoch is held.
+	NeighbouringValidatorBalanceEpochs(ctx context.Context, epoch phase0.Epoch) (*phase0.Epoch, *phase0.Epoch, error)
+}
+
 // ValidatorBalancesPruner defines functions to prune validator balances.
 type ValidatorBalancesPruner interface {
 	// PruneValidatorBalances prunes validator balances up to (but not including) the given epoch.
@@ -339,6 +396,19 @@ type ValidatorsSetter interface {
 	SetValidatorBalances(ctx context.Context, validatorBalances []*ValidatorBalance) error
 }
 
+// ValidatorSlashingsProvider defines functions to access validator slashings.
+type ValidatorSlashingsProvider interface {
+	// ValidatorSlashings fetches the slashings for the given validators.
+	// If no indices are supplied then slashings for all validators are returned.
+	ValidatorSlashings(ctx context.Context, indices []phase0.ValidatorIndex) ([]*ValidatorSlashing, error)
+}
+
+// ValidatorSlashingsSetter defines functions to create and update validator slashings.
+type ValidatorSlashingsSetter interface {
+	// SetValidatorSlashing sets a validator slashing.
+	SetValidatorSlashing(ctx context.Context, slashing *ValidatorSlashing) error
+}
+
 // DepositsProvider defines functions to access deposits.
 type DepositsProvider interface {
 	// DepositsByPublicKey fetches deposits for a given set of validator public keys.
@@ -427,6 +497,63 @@ type EpochSummariesSetter interface {
 	SetEpochSummary(ctx context.Context, summary *EpochSummary) error
 }
 
+// EpochQueueSummariesProvider defines functions to fetch epoch queue summaries.
+type EpochQueueSummariesProvider interface {
+	// EpochQueueSummaries provides queue summaries according to the filter.
+	EpochQueueSummaries(ctx context.Context, filter *EpochSummaryFilter) ([]*EpochQueueSummary, error)
+}
+
+// EpochQueueSummariesSetter defines functions to create and update epoch queue summaries.
+type EpochQueueSummariesSetter interface {
+	// SetEpochQueueSummaries sets multiple epoch queue summaries.
+	SetEpochQueueSummaries(ctx context.Context, summaries []*EpochQueueSummary) error
+}
+
+// EpochDepositSummariesProvider defines functions to fetch epoch deposit summaries.
+type EpochDepositSummariesProvider interface {
+	// EpochDepositSummaries provides deposit summaries according to the filter.
+	EpochDepositSummaries(ctx context.Context, filter *EpochSummaryFilter) ([]*EpochDepositSummary, error)
+}
+
+// EpochDepositSummariesSetter defines functions to create and update epoch deposit summaries.
+type EpochDepositSummariesSetter interface {
+	// SetEpochDepositSummaries sets multiple epoch deposit summaries, replacing any existing summaries for their epochs.
+	SetEpochDepositSummaries(ctx context.Context, summaries []*EpochDepositSummary) error
+}
+
+// SummariesDeleter defines functions to delete summaries, so that they can be recalculated.
+type SummariesDeleter interface {
+	// DeleteEpochSummaries deletes the epoch summaries for the given range of epochs, inclusive.
+	DeleteEpochSummaries(ctx context.Context, from phase0.Epoch, to phase0.Epoch) error
+
+	// DeleteBlockSummaries deletes the block summaries for the given range of slots, inclusive.
+	DeleteBlockSummaries(ctx context.Context, from phase0.Slot, to phase0.Slot) error
+
+	// DeleteValidatorEpochSummaries deletes the validator epoch summaries for the given range of epochs, inclusive.
+	DeleteValidatorEpochSummaries(ctx context.Context, from phase0.Epoch, to phase0.Epoch) error
+
+	// DeleteValidatorDaySummaries deletes the validator day summaries for days starting within
+	// the given range of times, inclusive.
+	DeleteValidatorDaySummaries(ctx context.Context, from time.Time, to time.Time) error
+}
+
+// OutdatedSummariesProvider defines functions to find summaries produced by earlier versions of the summarizer.
+// Each function returns the earliest and latest points with a summary produced by a version of the
+// summarizer before that supplied, or false if there are none.
+type OutdatedSummariesProvider interface {
+	// OutdatedEpochSummaries returns the range of epochs with outdated epoch summaries.
+	OutdatedEpochSummaries(ctx context.Context, version int) (phase0.Epoch, phase0.Epoch, bool, error)
+
+	// OutdatedBlockSummaries returns the range of slots with outdated block summaries.
+	OutdatedBlockSummaries(ctx context.Context, version int) (phase0.Slot, phase0.Slot, bool, error)
+
+	// OutdatedValidatorEpochSummaries returns the range of epochs with outdated validator epoch summaries.
+	OutdatedValidatorEpochSummaries(ctx context.Context, version int) (phase0.Epoch, phase0.Epoch, bool, error)
+
+	// OutdatedValidatorDaySummaries returns the range of day start times with outdated validator day summaries.
+	OutdatedValidatorDaySummaries(ctx context.Context, version int) (time.Time, time.Time, bool, error)
+}
+
 // SyncCommitteesProvider defines functions to obtain sync committee information.
 type SyncCommitteesProvider interface {
 	// SyncCommittee provides a sync committee for the given sync committee period.
@@ -451,6 +578,19 @@ type BLSToExecutionChangesProvider interface {
 	BLSToExecutionChanges(ctx context.Context, filter *BLSToExecutionChangeFilter) ([]*BLSToExecutionChange, error)
 }
 
+// OriginProvider defines functions to obtain the ingestion origin.
+type OriginProvider interface {
+	// Origin provides the point from which data has been ingested.
+	// It returns nil if data has been ingested from genesis.
+	Origin(ctx context.Context) (*Origin, error)
+}
+
+// OriginSetter defines functions to set the ingestion origin.
+type OriginSetter interface {
+	// SetOrigin sets the point from which data has been ingested.
+	SetOrigin(ctx context.Context, origin *Origin) error
+}
+
 // Service defines a minimal chain database service.
 type Service interface {
 	// BeginTx begins a transaction.
@@ -472,3 +612,45 @@ type Service interface {
 	// Metadata obtains the JSON value from a metadata key.
 	Metadata(ctx context.Context, key string) ([]byte, error)
 }
+
+// Closer defines functions to close the database.
+type Closer interface {
+	// Close closes the database, waiting for connections in use to be released.
+	Close()
+}
+
+// RetentionPruner defines functions to prune datasets in bounded batches.
+// Points are epochs or slots, according to the dataset.
+type RetentionPruner interface {
+	// PruneRetentionDataset removes up to limit rows of the dataset from before the given point,
+	// returning the number of rows removed.
+	PruneRetentionDataset(ctx context.Context, dataset RetentionDataset, before uint64, limit int) (int64, error)
+
+	// PrunableRetentionDatasetRows returns the number of rows of the dataset from before the given point.
+	PrunableRetentionDatasetRows(ctx context.Context, dataset RetentionDataset, before uint64) (int64, error)
+}
+
+// ConsistencyChecker defines functions to check the internal consistency of the database.
+type ConsistencyChecker interface {
+	// CheckConsistency runs a consistency check over an epoch, returning a description of each issue found.
+	// The slot range is that of the epoch, inclusive of start and exclusive of end.
+	CheckConsistency(ctx context.Context,
+		check ConsistencyCheck,
+		epoch phase0.Epoch,
+		startSlot phase0.Slot,
+		endSlot phase0.Slot,
+	) ([]string, error)
+}
+
+// ConsistencyIssuesSetter defines functions to record consistency issues.
+type ConsistencyIssuesSetter interface {
+	// SetConsistencyIssue records a consistency issue.
+	// An issue that has already been recorded has its timestamp updated.
+	SetConsistencyIssue(ctx context.Context, issue *ConsistencyIssue) error
+}
+
+// LoadProvider defines functions to obtain the load on the database.
+type LoadProvider interface {
+	// ActiveQueries returns the number of queries, other than our own, active in the database.
+	ActiveQueries(ctx context.Context) (int, error)
+}
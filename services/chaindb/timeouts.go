@@ -0,0 +1,45 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrStatementTimeout is returned, wrapped, when a database statement is cancelled
+// because it ran for longer than the statement timeout.  Callers that carry out
+// work in batches can catch it and retry with smaller batches.
+var ErrStatementTimeout = errors.New("statement timeout")
+
+// longRunning is a context tag for operations that are known to be long-running.
+type longRunning struct{}
+
+// WithLongRunning marks transactions begun with the returned context as containing
+// known long-running operations, such as summary rollups or pruning batches.
+// The database applies its long-running statement timeout to these transactions
+// in place of the default.
+func WithLongRunning(ctx context.Context) context.Context {
+	return context.WithValue(ctx, longRunning{}, true)
+}
+
+// IsLongRunning returns true if the context has been marked as long-running.
+func IsLongRunning(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	longRunning, ok := ctx.Value(longRunning{}).(bool)
+
+	return ok && longRunning
+}
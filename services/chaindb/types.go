@@ -39,6 +39,11 @@ type Block struct {
 	ExecutionPayload *ExecutionPayload
 	// Information only available from Capella onwards.
 	BLSToExecutionChanges []*BLSToExecutionChange
+	// SSZSize is the size of the SSZ-encoded signed block, if known.
+	SSZSize *uint64
+	// ArrivalDelay is the time after the start of the slot at which the
+	// block was first seen, if known.
+	ArrivalDelay *time.Duration
 }
 
 // Validator holds information about a validator.
@@ -54,6 +59,31 @@ type Validator struct {
 	WithdrawalCredentials      [32]byte
 }
 
+// SlashingType is the type of slashing operation that slashed a validator.
+type SlashingType uint8
+
+const (
+	// SlashingTypeUnknown is a slashing for which the operation has not been found.
+	SlashingTypeUnknown SlashingType = iota
+	// SlashingTypeProposer is a slashing caused by a proposer slashing.
+	SlashingTypeProposer
+	// SlashingTypeAttester is a slashing caused by an attester slashing.
+	SlashingTypeAttester
+)
+
+// ValidatorSlashing holds information about the slashing of a validator.
+type ValidatorSlashing struct {
+	Index phase0.ValidatorIndex
+	// Epoch is the epoch at which the slashing took effect.
+	Epoch phase0.Epoch
+	Type  SlashingType
+	// InclusionSlot, InclusionBlockRoot and InclusionIndex reference the slashing operation
+	// that slashed the validator, and are nil if the operation has not been found.
+	InclusionSlot      *phase0.Slot
+	InclusionBlockRoot *phase0.Root
+	InclusionIndex     *uint64
+}
+
 // ValidatorBalance holds information about a validator's balance at a given epoch.
 type ValidatorBalance struct {
 	Index            phase0.ValidatorIndex
@@ -82,6 +112,32 @@ type ProposerDuty struct {
 	ValidatorIndex phase0.ValidatorIndex
 }
 
+// SkippedSlot holds information about a finalized slot without a canonical block.
+type SkippedSlot struct {
+	Slot phase0.Slot
+	// ProposerIndex is the validator that was due to propose, if known.
+	ProposerIndex *phase0.ValidatorIndex
+}
+
+// ProposalOutcome is the outcome of a proposer duty.
+type ProposalOutcome uint8
+
+const (
+	// ProposalOutcomeMissed is a duty for which no block was seen.
+	ProposalOutcomeMissed ProposalOutcome = iota
+	// ProposalOutcomeCanonical is a duty for which the proposer produced a canonical block.
+	ProposalOutcomeCanonical
+	// ProposalOutcomeOrphaned is a duty for which the proposer produced a block that is not canonical.
+	ProposalOutcomeOrphaned
+)
+
+// SlotProposal holds the outcome of the proposer duty for a finalized slot.
+type SlotProposal struct {
+	Slot          phase0.Slot
+	ProposerIndex phase0.ValidatorIndex
+	Outcome       ProposalOutcome
+}
+
 // AttesterDuty holds information for attester duties.
 type AttesterDuty struct {
 	Slot           phase0.Slot
@@ -108,8 +164,21 @@ type Attestation struct {
 	Canonical          *bool
 	TargetCorrect      *bool
 	HeadCorrect        *bool
+	SourceCorrect      *bool
 }
 
+// AttestationStorageMode is the mode in which attestations are stored.
+type AttestationStorageMode string
+
+const (
+	// AttestationStorageFull stores each attestation included in a block, with the details of its inclusion.
+	AttestationStorageFull AttestationStorageMode = "full"
+	// AttestationStorageAggregate stores one attestation for each unique attestation data, with the
+	// aggregation bits of all of its inclusions merged.  The details of inclusions are not stored, so
+	// attestations have no inclusion slot, block root or index.
+	AttestationStorageAggregate AttestationStorageMode = "aggregate"
+)
+
 // SyncAggregate holds information about a sync aggregate included in a block.
 type SyncAggregate struct {
 	InclusionSlot      phase0.Slot
@@ -144,6 +213,8 @@ type ETH1Deposit struct {
 	WithdrawalCredentials []byte
 	Signature             phase0.BLSSignature
 	Amount                phase0.Gwei
+	// SignatureValid is nil if the signature has not been verified.
+	SignatureValid *bool
 }
 
 // VoluntaryExit holds information about a voluntary exit included in a block.
@@ -207,6 +278,9 @@ type ValidatorEpochSummary struct {
 	Epoch                     phase0.Epoch
 	ProposerDuties            int
 	ProposalsIncluded         int
+	ProposalsOrphaned         int
+	ProposalsMissed           int
+	AttesterDuty              bool
 	AttestationIncluded       bool
 	AttestationTargetCorrect  *bool
 	AttestationHeadCorrect    *bool
@@ -214,6 +288,16 @@ type ValidatorEpochSummary struct {
 	AttestationSourceTimely   *bool
 	AttestationTargetTimely   *bool
 	AttestationHeadTimely     *bool
+	// AttestationOrphaned is set if the attestation was not included, and is true if
+	// an attestation from the validator was seen in a non-canonical block.
+	AttestationOrphaned *bool
+	// SyncCommitteeParticipation is the percentage of canonical blocks in the epoch that
+	// included the validator's sync committee message.  It is only set if the validator
+	// was in the sync committee and there was at least one canonical block.
+	SyncCommitteeParticipation *float64
+	// SummarizerVersion is the version of the summarizer that produced the summary.
+	// Summaries produced before versions were recorded have version 0.
+	SummarizerVersion int
 }
 
 // ValidatorDaySummary provides a summary of a validator's operations for a day.
@@ -238,6 +322,13 @@ type ValidatorDaySummary struct {
 	AttestationsInclusionDelay    float64
 	SyncCommitteeMessages         int
 	SyncCommitteeMessagesIncluded int
+	// SyncCommitteeLifetimeParticipation is the percentage of sync committee messages included
+	// over all days up to and including this one.  It is only set if the validator had sync
+	// committee messages in the day.
+	SyncCommitteeLifetimeParticipation *float64
+	// SummarizerVersion is the version of the summarizer that produced the summary.
+	// Summaries produced before versions were recorded have version 0.
+	SummarizerVersion int
 }
 
 // BlockSummary provides a summary of an epoch.
@@ -247,6 +338,9 @@ type BlockSummary struct {
 	DuplicateAttestationsForBlock int
 	VotesForBlock                 int
 	ParentDistance                int
+	// SummarizerVersion is the version of the summarizer that produced the summary.
+	// Summaries produced before versions were recorded have version 0.
+	SummarizerVersion int
 }
 
 // EpochSummary provides a summary of an epoch.
@@ -272,6 +366,52 @@ type EpochSummary struct {
 	ExitingValidators             int
 	CanonicalBlocks               int
 	Withdrawals                   phase0.Gwei
+	SkippedSlots                  int
+	// SummarizerVersion is the version of the summarizer that produced the summary.
+	// Summaries produced before versions were recorded have version 0.
+	SummarizerVersion int
+}
+
+// EpochQueueSummary provides a summary of the validator activation and exit queues at an epoch.
+type EpochQueueSummary struct {
+	Epoch phase0.Epoch
+	// ActivationQueueLength is the number of validators eligible for, but awaiting, activation.
+	ActivationQueueLength int
+	// ExitQueueLength is the number of validators that have initiated, but not completed, exit.
+	// It is nil for epochs before exit queues were tracked.
+	ExitQueueLength *int
+	// ChurnLimit is the number of validators that can exit in the epoch.
+	ChurnLimit uint64
+	// ActivationChurnLimit is the number of validators that can be activated in the epoch.
+	ActivationChurnLimit uint64
+	// ActivationWait is the estimated number of epochs a validator joining the activation queue waits to be activated.
+	ActivationWait phase0.Epoch
+	// ExitWait is the estimated number of epochs a validator initiating exit waits to exit.
+	// It is nil for epochs before exit queues were tracked.
+	ExitWait *phase0.Epoch
+}
+
+// EpochDepositSummary provides a summary of the deposits processed in to the beacon state in an epoch.
+type EpochDepositSummary struct {
+	Epoch phase0.Epoch
+	// Deposits is the number of deposits processed.
+	Deposits int
+	// Amount is the total amount of the deposits processed.
+	Amount phase0.Gwei
+	// NewValidators is the number of deposits that created a new validator.
+	NewValidators int
+	// NewValidatorsAmount is the total amount of the deposits that created a new validator.
+	NewValidatorsAmount phase0.Gwei
+	// TopUps is the number of deposits to existing validators.
+	TopUps int
+	// TopUpsAmount is the total amount of the deposits to existing validators.
+	TopUpsAmount phase0.Gwei
+	// InvalidDeposits is the number of deposits that did not create a validator, due to an invalid signature.
+	InvalidDeposits int
+	// InvalidDepositsAmount is the total amount of the deposits that did not create a validator.
+	InvalidDepositsAmount phase0.Gwei
+	// ETH1TxHashes are the hashes of the Ethereum 1 transactions that made the deposits, where known.
+	ETH1TxHashes [][]byte
 }
 
 // SyncCommittee holds information for sync committees.
@@ -320,3 +460,44 @@ type Withdrawal struct {
 	Address            [20]byte
 	Amount             phase0.Gwei
 }
+
+// Origin is the point from which data has been ingested.
+// Data for slots and epochs before the origin is not present in the database.
+type Origin struct {
+	Slot  phase0.Slot
+	Epoch phase0.Epoch
+}
+
+// RetentionDataset is a dataset to which a retention policy can apply.
+type RetentionDataset string
+
+const (
+	// RetentionDatasetValidatorBalances is validator balances, pruned by epoch.
+	RetentionDatasetValidatorBalances RetentionDataset = "validator-balances"
+	// RetentionDatasetValidatorEpochSummaries is validator epoch summaries, pruned by epoch.
+	RetentionDatasetValidatorEpochSummaries RetentionDataset = "validator-epoch-summaries"
+	// RetentionDatasetAttestations is attestations, pruned by inclusion slot.
+	RetentionDatasetAttestations RetentionDataset = "attestations"
+)
+
+// ConsistencyCheck is a check of the internal consistency of the database.
+type ConsistencyCheck string
+
+const (
+	// ConsistencyCheckBlockParents checks that the parent of every canonical block is present and canonical.
+	ConsistencyCheckBlockParents ConsistencyCheck = "block-parents"
+	// ConsistencyCheckBlockAttestations checks that the attestation counts in block summaries match the attestations.
+	ConsistencyCheckBlockAttestations ConsistencyCheck = "block-attestations"
+	// ConsistencyCheckValidatorBalances checks that every active validator has a balance.
+	ConsistencyCheckValidatorBalances ConsistencyCheck = "validator-balances"
+	// ConsistencyCheckDepositIndices checks that the Ethereum 1 deposits seen by canonical blocks are contiguous.
+	ConsistencyCheckDepositIndices ConsistencyCheck = "deposit-indices"
+)
+
+// ConsistencyIssue is an inconsistency found in the database.
+type ConsistencyIssue struct {
+	Check     ConsistencyCheck
+	Epoch     phase0.Epoch
+	Detail    string
+	Timestamp time.Time
+}
@@ -0,0 +1,158 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+var metricsNamespace = "chaind_chain"
+
+var (
+	epochDesc = prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "summary_epoch"),
+		"Epoch of the latest summary from which chain statistics are obtained", nil, nil)
+	participationRateDesc = prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "participation_rate"),
+		"Proportion of active balance that attested", nil, nil)
+	activeValidatorsDesc = prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "active_validators"),
+		"Number of active validators", nil, nil)
+	activeBalanceDesc = prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "active_balance_gwei"),
+		"Total balance of active validators", nil, nil)
+	inclusionDistanceDesc = prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "inclusion_distance_average"),
+		"Average inclusion distance of attestations", nil, nil)
+	activationQueueDesc = prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "activation_queue_length"),
+		"Number of validators waiting to be activated", nil, nil)
+	exitQueueDesc = prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "exit_queue_length"),
+		"Number of validators waiting to exit", nil, nil)
+)
+
+// chainStats are statistics about the chain.
+type chainStats struct {
+	epoch             phase0.Epoch
+	participationRate float64
+	activeValidators  int
+	activeBalance     phase0.Gwei
+	// inclusionDistance is nil if validator summaries are not available.
+	inclusionDistance *float64
+	activationQueue   int
+	exitQueue         int
+}
+
+// Describe implements prometheus.Collector.
+func (*Service) Describe(ch chan<- *prometheus.Desc) {
+	ch <- epochDesc
+	ch <- participationRateDesc
+	ch <- activeValidatorsDesc
+	ch <- activeBalanceDesc
+	ch <- inclusionDistanceDesc
+	ch <- activationQueueDesc
+	ch <- exitQueueDesc
+}
+
+// Collect implements prometheus.Collector.
+func (s *Service) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	stats, err := s.chainStats(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain chain statistics")
+		return
+	}
+	if stats == nil {
+		// No summaries yet.
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(epochDesc, prometheus.GaugeValue, float64(stats.epoch))
+	ch <- prometheus.MustNewConstMetric(participationRateDesc, prometheus.GaugeValue, stats.participationRate)
+	ch <- prometheus.MustNewConstMetric(activeValidatorsDesc, prometheus.GaugeValue, float64(stats.activeValidators))
+	ch <- prometheus.MustNewConstMetric(activeBalanceDesc, prometheus.GaugeValue, float64(stats.activeBalance))
+	if stats.inclusionDistance != nil {
+		ch <- prometheus.MustNewConstMetric(inclusionDistanceDesc, prometheus.GaugeValue, *stats.inclusionDistance)
+	}
+	ch <- prometheus.MustNewConstMetric(activationQueueDesc, prometheus.GaugeValue, float64(stats.activationQueue))
+	ch <- prometheus.MustNewConstMetric(exitQueueDesc, prometheus.GaugeValue, float64(stats.exitQueue))
+}
+
+// chainStats returns the chain statistics, refreshing them from the database if the cached values are stale.
+func (s *Service) chainStats(ctx context.Context) (*chainStats, error) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	if s.stats != nil && time.Since(s.updated) < s.cacheDuration {
+		return s.stats, nil
+	}
+
+	stats, err := s.fetchChainStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.stats = stats
+	s.updated = time.Now()
+
+	return stats, nil
+}
+
+// fetchChainStats fetches the chain statistics from the database.
+func (s *Service) fetchChainStats(ctx context.Context) (*chainStats, error) {
+	summaries, err := s.epochSummariesProvider.EpochSummaries(ctx, &chaindb.EpochSummaryFilter{
+		Limit: 1,
+		Order: chaindb.OrderLatest,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain latest epoch summary")
+	}
+	if len(summaries) == 0 {
+		return nil, nil
+	}
+	summary := summaries[0]
+
+	stats := &chainStats{
+		epoch:            summary.Epoch,
+		activeValidators: summary.ActiveValidators,
+		activeBalance:    summary.ActiveBalance,
+		activationQueue:  summary.ActivationQueueLength,
+		exitQueue:        summary.ExitingValidators,
+	}
+	if summary.ActiveBalance > 0 {
+		stats.participationRate = float64(summary.AttestingBalance) / float64(summary.ActiveBalance)
+	}
+
+	if s.validatorEpochSummariesProvider != nil {
+		validatorSummaries, err := s.validatorEpochSummariesProvider.ValidatorSummariesForEpoch(ctx, summary.Epoch)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validator summaries")
+		}
+		total := 0
+		count := 0
+		for _, validatorSummary := range validatorSummaries {
+			if validatorSummary.AttestationInclusionDelay != nil {
+				total += *validatorSummary.AttestationInclusionDelay
+				count++
+			}
+		}
+		if count > 0 {
+			inclusionDistance := float64(total) / float64(count)
+			stats.inclusionDistance = &inclusionDistance
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,110 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// summariesProvider provides fixed epoch and validator summaries.
+type summariesProvider struct {
+	epochSummaries     []*chaindb.EpochSummary
+	validatorSummaries []*chaindb.ValidatorEpochSummary
+	calls              int
+}
+
+func (p *summariesProvider) EpochSummaries(_ context.Context, _ *chaindb.EpochSummaryFilter) ([]*chaindb.EpochSummary, error) {
+	p.calls++
+	return p.epochSummaries, nil
+}
+
+func (p *summariesProvider) ValidatorSummaries(_ context.Context, _ *chaindb.ValidatorSummaryFilter) ([]*chaindb.ValidatorEpochSummary, error) {
+	return p.validatorSummaries, nil
+}
+
+func (p *summariesProvider) ValidatorSummariesForEpoch(_ context.Context, _ phase0.Epoch) ([]*chaindb.ValidatorEpochSummary, error) {
+	return p.validatorSummaries, nil
+}
+
+func (*summariesProvider) ValidatorSummaryForEpoch(_ context.Context, _ phase0.ValidatorIndex, _ phase0.Epoch) (*chaindb.ValidatorEpochSummary, error) {
+	return nil, nil
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestChainStats(t *testing.T) {
+	ctx := context.Background()
+
+	provider := &summariesProvider{}
+	s := &Service{
+		epochSummariesProvider:          provider,
+		validatorEpochSummariesProvider: provider,
+		cacheDuration:                   time.Hour,
+		timeout:                         time.Second,
+	}
+
+	// No summaries.
+	stats, err := s.chainStats(ctx)
+	require.NoError(t, err)
+	require.Nil(t, stats)
+
+	provider.epochSummaries = []*chaindb.EpochSummary{
+		{
+			Epoch:                 100,
+			ActivationQueueLength: 5,
+			ActiveValidators:      4,
+			ActiveBalance:         128000000000,
+			AttestingBalance:      96000000000,
+			ExitingValidators:     2,
+		},
+	}
+	provider.validatorSummaries = []*chaindb.ValidatorEpochSummary{
+		{AttestationInclusionDelay: intPtr(1)},
+		{AttestationInclusionDelay: intPtr(1)},
+		{AttestationInclusionDelay: intPtr(4)},
+		{},
+	}
+	stats, err = s.chainStats(ctx)
+	require.NoError(t, err)
+	inclusionDistance := 2.0
+	require.Equal(t, &chainStats{
+		epoch:             100,
+		participationRate: 0.75,
+		activeValidators:  4,
+		activeBalance:     128000000000,
+		inclusionDistance: &inclusionDistance,
+		activationQueue:   5,
+		exitQueue:         2,
+	}, stats)
+	require.Equal(t, 2, provider.calls)
+
+	// Cached.
+	_, err = s.chainStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, provider.calls)
+
+	// Stale.
+	s.cacheDuration = 0
+	_, err = s.chainStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, provider.calls)
+}
@@ -0,0 +1,79 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a chain statistics service.
+// It exports statistics about the chain, read from the database when metrics are scraped.
+type Service struct {
+	epochSummariesProvider          chaindb.EpochSummariesProvider
+	validatorEpochSummariesProvider chaindb.ValidatorEpochSummariesProvider
+	cacheDuration                   time.Duration
+	timeout                         time.Duration
+
+	statsMu sync.Mutex
+	stats   *chainStats
+	updated time.Time
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger(zerologger.With().Str("service", "chainstats").Str("impl", "standard").Logger(), "chainstats", parameters.logLevel)
+
+	epochSummariesProvider, isProvider := parameters.chainDB.(chaindb.EpochSummariesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not support epoch summary providing")
+	}
+
+	s := &Service{
+		epochSummariesProvider: epochSummariesProvider,
+		cacheDuration:          parameters.cacheDuration,
+		timeout:                parameters.timeout,
+	}
+	// Validator summaries are optional, as they are only present if enabled in the summarizer.
+	if provider, isProvider := parameters.chainDB.(chaindb.ValidatorEpochSummariesProvider); isProvider {
+		s.validatorEpochSummariesProvider = provider
+	}
+
+	if parameters.monitor.Presenter() != "prometheus" {
+		log.Debug().Msg("No prometheus monitor; chain statistics will not be exported")
+		return s, nil
+	}
+	if err := prometheus.Register(s); err != nil {
+		return nil, errors.Wrap(err, "failed to register chain statistics collector")
+	}
+
+	return s, nil
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/util"
 )
 
 // Service provides chain time services.
@@ -33,6 +34,7 @@ type Service struct {
 	altairForkEpoch              phase0.Epoch
 	bellatrixForkEpoch           phase0.Epoch
 	capellaForkEpoch             phase0.Epoch
+	denebForkEpoch               phase0.Epoch
 }
 
 // module-wide log.
@@ -46,7 +48,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "chaintime").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger(zerologger.With().Str("service", "chaintime").Str("impl", "standard").Logger(), "chaintime", parameters.logLevel)
 
 	genesisTime, err := parameters.genesisTimeProvider.GenesisTime(ctx)
 	if err != nil {
@@ -104,6 +106,12 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		capellaForkEpoch = 0xffffffffffffffff
 	}
 	log.Trace().Uint64("epoch", uint64(capellaForkEpoch)).Msg("Obtained Capella fork epoch")
+	denebForkEpoch, err := fetchDenebForkEpoch(ctx, parameters.specProvider)
+	if err != nil {
+		// Set to far future epoch.
+		denebForkEpoch = 0xffffffffffffffff
+	}
+	log.Trace().Uint64("epoch", uint64(denebForkEpoch)).Msg("Obtained Deneb fork epoch")
 
 	s := &Service{
 		genesisTime:                  genesisTime,
@@ -113,6 +121,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		altairForkEpoch:              altairForkEpoch,
 		bellatrixForkEpoch:           bellatrixForkEpoch,
 		capellaForkEpoch:             capellaForkEpoch,
+		denebForkEpoch:               denebForkEpoch,
 	}
 
 	return s, nil
@@ -305,3 +314,32 @@ func fetchCapellaForkEpoch(ctx context.Context,
 
 	return phase0.Epoch(epoch), nil
 }
+
+// DenebInitialEpoch provides the epoch at which the Deneb hard fork takes place.
+func (s *Service) DenebInitialEpoch() phase0.Epoch {
+	return s.denebForkEpoch
+}
+
+func fetchDenebForkEpoch(ctx context.Context,
+	specProvider eth2client.SpecProvider,
+) (
+	phase0.Epoch,
+	error,
+) {
+	// Fetch the fork version.
+	spec, err := specProvider.Spec(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain spec")
+	}
+	tmp, exists := spec["DENEB_FORK_EPOCH"]
+	if !exists {
+		return 0, errors.New("deneb fork version not known by chain")
+	}
+	epoch, isEpoch := tmp.(uint64)
+	if !isEpoch {
+		//nolint:revive
+		return 0, errors.New("DENEB_FORK_EPOCH is not a uint64!")
+	}
+
+	return phase0.Epoch(epoch), nil
+}
@@ -0,0 +1,191 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	finalizer "github.com/wealdtech/chaind/services/finalizer/standard"
+)
+
+// checkJob is the scheduler job to run consistency checks.
+func (s *Service) checkJob(ctx context.Context, _ interface{}) error {
+	return s.run(ctx)
+}
+
+// run runs the consistency checks over a sample of recent finalized epochs.
+// If the database is busy the run is abandoned and subsequent runs are delayed.
+func (s *Service) run(ctx context.Context) error {
+	finalizedEpoch, known, err := s.finalizedEpoch(ctx)
+	if err != nil {
+		return err
+	}
+	if !known {
+		log.Trace().Msg("No finalized epoch; nothing to check")
+		return nil
+	}
+
+	first := true
+	for _, epoch := range s.sampleEpochs(finalizedEpoch) {
+		for _, check := range s.checks {
+			if !first {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(s.checkInterval):
+				}
+			}
+			first = false
+
+			busy, err := s.busy(ctx)
+			if err != nil {
+				return err
+			}
+			if busy {
+				backoff := s.backoff.Load() * 2
+				if backoff > maxBackoff {
+					backoff = maxBackoff
+				}
+				s.backoff.Store(backoff)
+				log.Debug().Int64("backoff", backoff).Msg("Database busy; deferring consistency checks")
+				monitorDeferred()
+				return nil
+			}
+
+			if err := s.check(ctx, check, epoch); err != nil {
+				return errors.Wrapf(err, "failed to run %s check for epoch %d", check, epoch)
+			}
+		}
+	}
+	s.backoff.Store(1)
+
+	return nil
+}
+
+// finalizedEpoch returns the latest epoch finalized in the database, if any.
+func (s *Service) finalizedEpoch(ctx context.Context) (phase0.Epoch, bool, error) {
+	md := struct {
+		LastFinalizedEpoch int64 `json:"latest_epoch"`
+	}{
+		LastFinalizedEpoch: -1,
+	}
+	mdJSON, err := s.chainDB.Metadata(ctx, finalizer.MetadataKey)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to obtain finalizer metadata")
+	}
+	if mdJSON != nil {
+		if err := json.Unmarshal(mdJSON, &md); err != nil {
+			return 0, false, errors.Wrap(err, "failed to unmarshal finalizer metadata")
+		}
+	}
+	if md.LastFinalizedEpoch < 0 {
+		return 0, false, nil
+	}
+
+	return phase0.Epoch(md.LastFinalizedEpoch), true, nil
+}
+
+// sampleEpochs returns distinct epochs, in order, sampled from the window ending at the finalized epoch.
+func (s *Service) sampleEpochs(finalizedEpoch phase0.Epoch) []phase0.Epoch {
+	lowest := phase0.Epoch(0)
+	if uint64(finalizedEpoch) >= s.window {
+		lowest = finalizedEpoch - phase0.Epoch(s.window) + 1
+	}
+	span := uint64(finalizedEpoch-lowest) + 1
+	samples := uint64(s.samples)
+	if samples > span {
+		samples = span
+	}
+
+	sampled := make(map[phase0.Epoch]bool, samples)
+	for uint64(len(sampled)) < samples {
+		// #nosec G404
+		sampled[lowest+phase0.Epoch(rand.Int63n(int64(span)))] = true
+	}
+	epochs := make([]phase0.Epoch, 0, len(sampled))
+	for epoch := range sampled {
+		epochs = append(epochs, epoch)
+	}
+	sort.Slice(epochs, func(i int, j int) bool {
+		return epochs[i] < epochs[j]
+	})
+
+	return epochs
+}
+
+// busy returns true if the database is too busy for checks to run.
+func (s *Service) busy(ctx context.Context) (bool, error) {
+	if s.loadProvider == nil || s.maxActiveQueries == 0 {
+		return false, nil
+	}
+
+	queries, err := s.loadProvider.ActiveQueries(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to obtain active queries")
+	}
+
+	return queries > s.maxActiveQueries, nil
+}
+
+// check runs a consistency check over an epoch, recording any issues found.
+func (s *Service) check(ctx context.Context, check chaindb.ConsistencyCheck, epoch phase0.Epoch) error {
+	log := log.With().Str("check", string(check)).Uint64("epoch", uint64(epoch)).Logger()
+
+	started := time.Now()
+	details, err := s.checker.CheckConsistency(ctx,
+		check,
+		epoch,
+		s.chainTime.FirstSlotOfEpoch(epoch),
+		s.chainTime.FirstSlotOfEpoch(epoch+1),
+	)
+	if err != nil {
+		return err
+	}
+	monitorCheck(check, time.Since(started))
+	log.Trace().Int("issues", len(details)).Dur("duration", time.Since(started)).Msg("Ran check")
+	if len(details) == 0 {
+		return nil
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	for _, detail := range details {
+		log.Warn().Str("detail", detail).Msg("Consistency issue found")
+		if err := s.issuesSetter.SetConsistencyIssue(ctx, &chaindb.ConsistencyIssue{
+			Check:     check,
+			Epoch:     epoch,
+			Detail:    detail,
+			Timestamp: started,
+		}); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set consistency issue")
+		}
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	monitorIssues(check, len(details))
+
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_consistency"
+
+var (
+	checkDuration *prometheus.HistogramVec
+	issues        *prometheus.CounterVec
+	deferred      prometheus.Counter
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if checkDuration != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	checkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "check_duration_seconds",
+		Help:      "Time taken to run a consistency check over an epoch",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 8),
+	}, []string{"check"})
+	if err := prometheus.Register(checkDuration); err != nil {
+		return errors.Wrap(err, "failed to register check_duration_seconds")
+	}
+
+	issues = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "issues_total",
+		Help:      "Number of consistency issues found",
+	}, []string{"check"})
+	if err := prometheus.Register(issues); err != nil {
+		return errors.Wrap(err, "failed to register issues_total")
+	}
+
+	deferred = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "deferred_total",
+		Help:      "Number of runs of consistency checks deferred because the database was busy",
+	})
+	if err := prometheus.Register(deferred); err != nil {
+		return errors.Wrap(err, "failed to register deferred_total")
+	}
+
+	return nil
+}
+
+func monitorCheck(check chaindb.ConsistencyCheck, duration time.Duration) {
+	if checkDuration != nil {
+		checkDuration.WithLabelValues(string(check)).Observe(duration.Seconds())
+	}
+}
+
+func monitorIssues(check chaindb.ConsistencyCheck, count int) {
+	if issues != nil {
+		issues.WithLabelValues(string(check)).Add(float64(count))
+	}
+}
+
+func monitorDeferred() {
+	if deferred != nil {
+		deferred.Inc()
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+type parameters struct {
+	logLevel         zerolog.Level
+	monitor          metrics.Service
+	chainDB          chaindb.Service
+	chainTime        chaintime.Service
+	scheduler        scheduler.Service
+	checks           []chaindb.ConsistencyCheck
+	interval         time.Duration
+	samples          int
+	window           uint64
+	checkInterval    time.Duration
+	maxActiveQueries int
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithScheduler sets the scheduler for this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithChecks sets the consistency checks to run.
+func WithChecks(checks []chaindb.ConsistencyCheck) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.checks = checks
+	})
+}
+
+// WithInterval sets the interval between runs.
+func WithInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interval = interval
+	})
+}
+
+// WithSamples sets the number of epochs sampled in each run.
+func WithSamples(samples int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.samples = samples
+	})
+}
+
+// WithWindow sets the number of recent finalized epochs from which samples are taken.
+func WithWindow(window uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.window = window
+	})
+}
+
+// WithCheckInterval sets the interval between checks within a run, to limit the load on the database.
+func WithCheckInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.checkInterval = interval
+	})
+}
+
+// WithMaxActiveQueries sets the number of active database queries above which checks are
+// deferred.  0 disables the limit.
+func WithMaxActiveQueries(queries int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxActiveQueries = queries
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		checks: []chaindb.ConsistencyCheck{
+			chaindb.ConsistencyCheckBlockParents,
+			chaindb.ConsistencyCheckBlockAttestations,
+			chaindb.ConsistencyCheckValidatorBalances,
+			chaindb.ConsistencyCheckDepositIndices,
+		},
+		interval:         5 * time.Minute,
+		samples:          1,
+		window:           1024,
+		checkInterval:    time.Second,
+		maxActiveQueries: 16,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+	if len(parameters.checks) == 0 {
+		return nil, errors.New("no checks specified")
+	}
+	for _, check := range parameters.checks {
+		switch check {
+		case chaindb.ConsistencyCheckBlockParents,
+			chaindb.ConsistencyCheckBlockAttestations,
+			chaindb.ConsistencyCheckValidatorBalances,
+			chaindb.ConsistencyCheckDepositIndices:
+		default:
+			return nil, fmt.Errorf("unknown check %q", check)
+		}
+	}
+	if parameters.interval <= 0 {
+		return nil, errors.New("interval must be greater than 0")
+	}
+	if parameters.samples <= 0 {
+		return nil, errors.New("samples must be greater than 0")
+	}
+	if parameters.window == 0 {
+		return nil, errors.New("window must be greater than 0")
+	}
+	if parameters.checkInterval < 0 {
+		return nil, errors.New("check interval cannot be negative")
+	}
+	if parameters.maxActiveQueries < 0 {
+		return nil, errors.New("max active queries cannot be negative")
+	}
+
+	return &parameters, nil
+}
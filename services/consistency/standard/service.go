@@ -0,0 +1,118 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
+	"go.uber.org/atomic"
+)
+
+// jobClass is the scheduler class of the consistency job.
+const jobClass = "consistency"
+
+// jobName is the name of the consistency job.
+const jobName = "consistency"
+
+// maxBackoff is the maximum multiple of the interval by which runs are delayed when the database is busy.
+const maxBackoff = 8
+
+// Service is a consistency verification service.
+type Service struct {
+	chainDB          chaindb.Service
+	checker          chaindb.ConsistencyChecker
+	issuesSetter     chaindb.ConsistencyIssuesSetter
+	loadProvider     chaindb.LoadProvider
+	chainTime        chaintime.Service
+	scheduler        scheduler.Service
+	checks           []chaindb.ConsistencyCheck
+	interval         time.Duration
+	samples          int
+	window           uint64
+	checkInterval    time.Duration
+	maxActiveQueries int
+	// backoff is the multiple of the interval until the next run.
+	backoff atomic.Int64
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger(zerologger.With().Str("service", "consistency").Str("impl", "standard").Logger(), "consistency", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	checker, isChecker := parameters.chainDB.(chaindb.ConsistencyChecker)
+	if !isChecker {
+		return nil, errors.New("chain DB does not support consistency checks")
+	}
+	issuesSetter, isSetter := parameters.chainDB.(chaindb.ConsistencyIssuesSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support setting consistency issues")
+	}
+	// The load provider is optional; without it checks are not throttled.
+	loadProvider, _ := parameters.chainDB.(chaindb.LoadProvider)
+
+	s := &Service{
+		chainDB:          parameters.chainDB,
+		checker:          checker,
+		issuesSetter:     issuesSetter,
+		loadProvider:     loadProvider,
+		chainTime:        parameters.chainTime,
+		scheduler:        parameters.scheduler,
+		checks:           parameters.checks,
+		interval:         parameters.interval,
+		samples:          parameters.samples,
+		window:           parameters.window,
+		checkInterval:    parameters.checkInterval,
+		maxActiveQueries: parameters.maxActiveQueries,
+	}
+	s.backoff.Store(1)
+
+	if err := s.scheduler.SchedulePeriodicJob(ctx,
+		jobClass,
+		jobName,
+		s.nextRuntime,
+		nil,
+		s.checkJob,
+		nil,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to schedule consistency job")
+	}
+
+	return s, nil
+}
+
+// nextRuntime returns the time at which the consistency job next runs.
+func (s *Service) nextRuntime(_ context.Context, _ interface{}) (time.Time, error) {
+	return time.Now().Add(s.interval * time.Duration(s.backoff.Load())), nil
+}
@@ -0,0 +1,360 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chaintime"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	finalizer "github.com/wealdtech/chaind/services/finalizer/standard"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+)
+
+// checkerChainDB is a chain database returning fixed consistency issues.
+type checkerChainDB struct {
+	chaindb.Service
+	mu            sync.Mutex
+	finalized     []byte
+	details       map[chaindb.ConsistencyCheck][]string
+	activeQueries int
+	ranges        map[chaindb.ConsistencyCheck][]phase0.Slot
+	issues        []*chaindb.ConsistencyIssue
+}
+
+func newCheckerChainDB(finalized string, details map[chaindb.ConsistencyCheck][]string) *checkerChainDB {
+	c := &checkerChainDB{
+		Service: mockchaindb.New(),
+		details: details,
+		ranges:  make(map[chaindb.ConsistencyCheck][]phase0.Slot),
+	}
+	if finalized != "" {
+		c.finalized = []byte(finalized)
+	}
+
+	return c
+}
+
+func (c *checkerChainDB) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {}, nil
+}
+
+func (c *checkerChainDB) Metadata(_ context.Context, key string) ([]byte, error) {
+	if key != finalizer.MetadataKey {
+		return nil, nil
+	}
+
+	return c.finalized, nil
+}
+
+func (c *checkerChainDB) CheckConsistency(_ context.Context,
+	check chaindb.ConsistencyCheck,
+	_ phase0.Epoch,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]string,
+	error,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ranges[check] = []phase0.Slot{startSlot, endSlot}
+
+	return c.details[check], nil
+}
+
+func (c *checkerChainDB) SetConsistencyIssue(_ context.Context, issue *chaindb.ConsistencyIssue) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.issues = append(c.issues, issue)
+
+	return nil
+}
+
+func (c *checkerChainDB) ActiveQueries(_ context.Context) (int, error) {
+	return c.activeQueries, nil
+}
+
+// epochChainTime is a chain time with 32 slots per epoch.
+type epochChainTime struct {
+	chaintime.Service
+}
+
+func (*epochChainTime) FirstSlotOfEpoch(epoch phase0.Epoch) phase0.Slot {
+	return phase0.Slot(epoch * 32)
+}
+
+func TestParameters(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := newCheckerChainDB("", nil)
+	chainTime := &epochChainTime{Service: mockchaintime.New()}
+	scheduler, err := standardscheduler.New(ctx, standardscheduler.WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ChainDBNotChecker",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(mockchaindb.New()),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+			},
+			err: "chain DB does not support consistency checks",
+		},
+		{
+			name: "ChecksEmpty",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(chainDB),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+				WithChecks([]chaindb.ConsistencyCheck{}),
+			},
+			err: "problem with parameters: no checks specified",
+		},
+		{
+			name: "CheckUnknown",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(chainDB),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+				WithChecks([]chaindb.ConsistencyCheck{"unknown"}),
+			},
+			err: `problem with parameters: unknown check "unknown"`,
+		},
+		{
+			name: "SamplesZero",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(chainDB),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+				WithSamples(0),
+			},
+			err: "problem with parameters: samples must be greater than 0",
+		},
+		{
+			name: "WindowZero",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(chainDB),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+				WithWindow(0),
+			},
+			err: "problem with parameters: window must be greater than 0",
+		},
+		{
+			name: "MaxActiveQueriesNegative",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(chainDB),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+				WithMaxActiveQueries(-1),
+			},
+			err: "problem with parameters: max active queries cannot be negative",
+		},
+		{
+			name: "Good",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(chainDB),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := newCheckerChainDB(`{"latest_epoch":10}`, map[chaindb.ConsistencyCheck][]string{
+		chaindb.ConsistencyCheckBlockParents: {"issue 1", "issue 2"},
+	})
+	scheduler, err := standardscheduler.New(ctx, standardscheduler.WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(chainDB),
+		WithChainTime(&epochChainTime{Service: mockchaintime.New()}),
+		WithScheduler(scheduler),
+		WithWindow(1),
+		WithCheckInterval(0),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, s.run(ctx))
+
+	// The window of 1 means that only the finalized epoch is checked, by all checks.
+	require.Len(t, chainDB.ranges, 4)
+	for _, slots := range chainDB.ranges {
+		require.Equal(t, []phase0.Slot{320, 352}, slots)
+	}
+	require.Len(t, chainDB.issues, 2)
+	for i, issue := range chainDB.issues {
+		require.Equal(t, chaindb.ConsistencyCheckBlockParents, issue.Check)
+		require.Equal(t, phase0.Epoch(10), issue.Epoch)
+		require.Equal(t, []string{"issue 1", "issue 2"}[i], issue.Detail)
+	}
+}
+
+func TestRunNotFinalized(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := newCheckerChainDB("", nil)
+	scheduler, err := standardscheduler.New(ctx, standardscheduler.WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(chainDB),
+		WithChainTime(&epochChainTime{Service: mockchaintime.New()}),
+		WithScheduler(scheduler),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, s.run(ctx))
+	require.Empty(t, chainDB.ranges)
+}
+
+func TestRunBusy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := newCheckerChainDB(`{"latest_epoch":10}`, nil)
+	chainDB.activeQueries = 5
+	scheduler, err := standardscheduler.New(ctx, standardscheduler.WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(chainDB),
+		WithChainTime(&epochChainTime{Service: mockchaintime.New()}),
+		WithScheduler(scheduler),
+		WithCheckInterval(0),
+		WithMaxActiveQueries(4),
+	)
+	require.NoError(t, err)
+
+	// Busy runs are deferred, backing off to the maximum.
+	for _, backoff := range []int64{2, 4, 8, 8} {
+		require.NoError(t, s.run(ctx))
+		require.Empty(t, chainDB.ranges)
+		require.Equal(t, backoff, s.backoff.Load())
+	}
+
+	// Once the database is no longer busy the checks run and the backoff is reset.
+	chainDB.activeQueries = 4
+	require.NoError(t, s.run(ctx))
+	require.Len(t, chainDB.ranges, 4)
+	require.Equal(t, int64(1), s.backoff.Load())
+}
+
+func TestSampleEpochs(t *testing.T) {
+	tests := []struct {
+		name           string
+		samples        int
+		window         uint64
+		finalizedEpoch phase0.Epoch
+		lowest         phase0.Epoch
+		expected       int
+	}{
+		{
+			name:           "Single",
+			samples:        1,
+			window:         10,
+			finalizedEpoch: 100,
+			lowest:         91,
+			expected:       1,
+		},
+		{
+			name:           "Multiple",
+			samples:        5,
+			window:         10,
+			finalizedEpoch: 100,
+			lowest:         91,
+			expected:       5,
+		},
+		{
+			name:           "WholeWindow",
+			samples:        20,
+			window:         10,
+			finalizedEpoch: 100,
+			lowest:         91,
+			expected:       10,
+		},
+		{
+			name:           "EarlyChain",
+			samples:        20,
+			window:         10,
+			finalizedEpoch: 2,
+			lowest:         0,
+			expected:       3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Service{
+				samples: test.samples,
+				window:  test.window,
+			}
+			epochs := s.sampleEpochs(test.finalizedEpoch)
+			require.Len(t, epochs, test.expected)
+			for i, epoch := range epochs {
+				require.GreaterOrEqual(t, epoch, test.lowest)
+				require.LessOrEqual(t, epoch, test.finalizedEpoch)
+				if i > 0 {
+					require.Greater(t, epoch, epochs[i-1])
+				}
+			}
+		})
+	}
+}
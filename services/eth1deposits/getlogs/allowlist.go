@@ -0,0 +1,58 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"fmt"
+)
+
+// logAddressAllowlist is the set of contract addresses from which logs are accepted.
+// A nil allowlist accepts logs from any address.
+type logAddressAllowlist map[string]struct{}
+
+// newLogAddressAllowlist creates an allowlist of the given addresses.
+func newLogAddressAllowlist(addresses [][]byte) logAddressAllowlist {
+	allowlist := make(logAddressAllowlist, len(addresses))
+	for _, address := range addresses {
+		allowlist[string(address)] = struct{}{}
+	}
+
+	return allowlist
+}
+
+// dropDisallowedLogs removes logs from addresses that are not in the allowlist.
+// Providers have been known to return logs from contracts other than those requested,
+// which would otherwise be decoded as deposits.
+func (s *Service) dropDisallowedLogs(logs []*logResponse) []*logResponse {
+	if s.logAddressAllowlist == nil {
+		return logs
+	}
+
+	res := logs[:0]
+	for _, entry := range logs {
+		if _, allowed := s.logAddressAllowlist[string(entry.Address)]; !allowed {
+			log.Debug().
+				Str("address", fmt.Sprintf("%#x", entry.Address)).
+				Uint64("block", entry.BlockNumber).
+				Str("transaction_hash", fmt.Sprintf("%#x", entry.TransactionHash)).
+				Uint64("log_index", entry.LogIndex).
+				Msg("Dropping log from address not in allowlist")
+			monitorDroppedLog()
+			continue
+		}
+		res = append(res, entry)
+	}
+
+	return res
+}
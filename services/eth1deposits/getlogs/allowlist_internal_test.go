@@ -0,0 +1,58 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogAddressAllowlist(t *testing.T) {
+	ctx := context.Background()
+
+	// The stub returns a deposit log alongside an identical log from another contract.
+	offContractLog := strings.Replace(testDepositLog,
+		`"address":"0x8c5fecdc472e27bc447696f431e425d02dd46a8c"`,
+		`"address":"0x0102030405060708090a0b0c0d0e0f1011121314"`,
+		1)
+	offContractLog = strings.Replace(offContractLog, `"logIndex":"0x0"`, `"logIndex":"0x1"`, 1)
+	stub := newRPCStub(t, map[string]string{
+		"eth_getLogs": `[` + testDepositLog + `,` + offContractLog + `]`,
+	})
+
+	s := newTestService(t, stub.server.URL)
+	s.logAddressAllowlist = newLogAddressAllowlist([][]byte{s.depositContractAddress})
+	logs, err := s.getLogs(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, s.depositContractAddress, logs[0].Address)
+
+	// Allowing the other contract retains its log.
+	s.logAddressAllowlist = newLogAddressAllowlist([][]byte{
+		s.depositContractAddress,
+		{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14},
+	})
+	logs, err = s.getLogs(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+
+	// Without an allowlist all logs are retained.
+	s.logAddressAllowlist = nil
+	logs, err = s.getLogs(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+}
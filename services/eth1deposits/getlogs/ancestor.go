@@ -0,0 +1,66 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// maxAncestorSearchDepth is the maximum number of blocks walked back when
+// searching for a common ancestor.
+const maxAncestorSearchDepth = 1024
+
+// ErrNoCommonAncestor is returned when no common ancestor can be found within
+// the maximum search depth.
+var ErrNoCommonAncestor = errors.New("no common ancestor found")
+
+// FindCommonAncestor finds the highest block, at or below the given block, for
+// which the hash reported by the Ethereum 1 client matches the hash known to the
+// caller.  Blocks above the returned block must be re-indexed.
+// Blocks for which the caller has no hash are passed over, but count towards
+// the search depth.
+func (s *Service) FindCommonAncestor(ctx context.Context,
+	fromBlock uint64,
+	knownHashes map[uint64][32]byte,
+) (
+	uint64,
+	error,
+) {
+	if len(knownHashes) == 0 {
+		return 0, errors.New("no known hashes")
+	}
+
+	for depth := uint64(0); depth < maxAncestorSearchDepth && depth <= fromBlock; depth++ {
+		blockNumber := fromBlock - depth
+		knownHash, exists := knownHashes[blockNumber]
+		if !exists {
+			continue
+		}
+
+		hash, err := s.blockHashByNumber(ctx, blockNumber)
+		if err != nil {
+			return 0, errors.Wrap(err, fmt.Sprintf("failed to obtain hash for block %d", blockNumber))
+		}
+		if hash == knownHash {
+			log.Trace().Uint64("from_block", fromBlock).Uint64("ancestor", blockNumber).Msg("Found common ancestor")
+			return blockNumber, nil
+		}
+		log.Trace().Uint64("block", blockNumber).Str("known_hash", fmt.Sprintf("%#x", knownHash)).Str("hash", fmt.Sprintf("%#x", hash)).Msg("Hash mismatch")
+	}
+
+	return 0, ErrNoCommonAncestor
+}
@@ -0,0 +1,131 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testBlockHash returns a hash for a block on the given fork.
+func testBlockHash(fork byte, blockNumber uint64) [32]byte {
+	var hash [32]byte
+	hash[0] = fork
+	copy(hash[24:], []byte(strconv.FormatUint(blockNumber, 16)))
+
+	return hash
+}
+
+func TestFindCommonAncestor(t *testing.T) {
+	ctx := context.Background()
+
+	// The node has reorganised to fork 2 from block 1001.
+	forkBlock := uint64(1001)
+	stub := newRPCStub(t, map[string]string{})
+	stub.setResultFunc("eth_getBlockByNumber", func(params []json.RawMessage) string {
+		var blockNumberStr string
+		if err := json.Unmarshal(params[0], &blockNumberStr); err != nil {
+			return "null"
+		}
+		blockNumber, err := strconv.ParseUint(strings.TrimPrefix(blockNumberStr, "0x"), 16, 64)
+		if err != nil {
+			return "null"
+		}
+		fork := byte(1)
+		if blockNumber >= forkBlock {
+			fork = 2
+		}
+
+		return fmt.Sprintf(`{"hash":"%#x"}`, testBlockHash(fork, blockNumber))
+	})
+	s := newTestService(t, stub.server.URL)
+
+	// The caller knows of blocks 900 to 1010 on fork 1.
+	knownHashes := make(map[uint64][32]byte)
+	for i := uint64(900); i <= 1010; i++ {
+		knownHashes[i] = testBlockHash(1, i)
+	}
+
+	tests := []struct {
+		name        string
+		fromBlock   uint64
+		knownHashes map[uint64][32]byte
+		err         string
+		ancestor    uint64
+	}{
+		{
+			name:      "NoKnownHashes",
+			fromBlock: 1010,
+			err:       "no known hashes",
+		},
+		{
+			name:        "NoReorg",
+			fromBlock:   1000,
+			knownHashes: knownHashes,
+			ancestor:    1000,
+		},
+		{
+			name:        "Reorg",
+			fromBlock:   1010,
+			knownHashes: knownHashes,
+			ancestor:    1000,
+		},
+		{
+			name:      "Gaps",
+			fromBlock: 1010,
+			knownHashes: map[uint64][32]byte{
+				1010: testBlockHash(1, 1010),
+				1005: testBlockHash(1, 1005),
+				995:  testBlockHash(1, 995),
+			},
+			ancestor: 995,
+		},
+		{
+			name:      "TooDeep",
+			fromBlock: 5000,
+			knownHashes: map[uint64][32]byte{
+				5000: testBlockHash(1, 5000),
+				500:  testBlockHash(1, 500),
+			},
+			err: "no common ancestor found",
+		},
+		{
+			name:      "Genesis",
+			fromBlock: 1010,
+			knownHashes: map[uint64][32]byte{
+				1010: testBlockHash(1, 1010),
+				1009: testBlockHash(1, 1009),
+			},
+			err: "no common ancestor found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ancestor, err := s.FindCommonAncestor(ctx, test.fromBlock, test.knownHashes)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.ancestor, ancestor)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Reasons for which a deposit is flagged as anomalous.
+const (
+	// DepositAnomalyBelowMinimum is a deposit with an amount below the minimum threshold.
+	DepositAnomalyBelowMinimum = "below_minimum"
+	// DepositAnomalyAboveMaximum is a deposit with an amount above the maximum threshold.
+	DepositAnomalyAboveMaximum = "above_maximum"
+	// DepositAnomalyGranularity is a deposit with an amount that is not a multiple of the granularity.
+	DepositAnomalyGranularity = "granularity"
+)
+
+// DepositAnomalyHook is called for each reason a decoded deposit is anomalous.
+// The deposit is recorded regardless.
+type DepositAnomalyHook func(deposit *chaindb.ETH1Deposit, reason string)
+
+// depositThresholds are the thresholds against which deposit amounts are checked.
+// A value of 0 disables the relevant check.
+type depositThresholds struct {
+	minimum     phase0.Gwei
+	maximum     phase0.Gwei
+	granularity phase0.Gwei
+}
+
+// depositAnomalies returns the reasons for which the deposit is anomalous, if any.
+func depositAnomalies(deposit *chaindb.ETH1Deposit, thresholds depositThresholds) []string {
+	reasons := make([]string, 0)
+	if thresholds.minimum > 0 && deposit.Amount < thresholds.minimum {
+		reasons = append(reasons, DepositAnomalyBelowMinimum)
+	}
+	if thresholds.maximum > 0 && deposit.Amount > thresholds.maximum {
+		reasons = append(reasons, DepositAnomalyAboveMaximum)
+	}
+	if thresholds.granularity > 0 && deposit.Amount%thresholds.granularity != 0 {
+		reasons = append(reasons, DepositAnomalyGranularity)
+	}
+
+	return reasons
+}
+
+// checkDepositAnomalies reports the deposit if it is anomalous.
+func (s *Service) checkDepositAnomalies(deposit *chaindb.ETH1Deposit) {
+	for _, reason := range depositAnomalies(deposit, s.depositThresholds) {
+		log.Warn().Uint64("deposit_index", deposit.DepositIndex).Uint64("amount", uint64(deposit.Amount)).Str("reason", reason).Msg("Anomalous deposit")
+		monitorDepositAnomaly(reason)
+		if s.depositAnomalyHook != nil {
+			s.depositAnomalyHook(deposit, reason)
+		}
+	}
+}
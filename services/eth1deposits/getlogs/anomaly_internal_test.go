@@ -0,0 +1,99 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestDepositAnomalies(t *testing.T) {
+	thresholds := depositThresholds{
+		minimum:     1000000000,
+		maximum:     2048000000000,
+		granularity: 1000000000,
+	}
+
+	tests := []struct {
+		name       string
+		amount     phase0.Gwei
+		thresholds depositThresholds
+		reasons    []string
+	}{
+		{
+			name:       "Good",
+			amount:     32000000000,
+			thresholds: thresholds,
+			reasons:    []string{},
+		},
+		{
+			name:       "BelowMinimum",
+			amount:     999999999,
+			thresholds: thresholds,
+			reasons:    []string{DepositAnomalyBelowMinimum, DepositAnomalyGranularity},
+		},
+		{
+			name:       "AboveMaximum",
+			amount:     2049000000000,
+			thresholds: thresholds,
+			reasons:    []string{DepositAnomalyAboveMaximum},
+		},
+		{
+			name:       "Granularity",
+			amount:     32500000000,
+			thresholds: thresholds,
+			reasons:    []string{DepositAnomalyGranularity},
+		},
+		{
+			name:    "Disabled",
+			amount:  1,
+			reasons: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reasons := depositAnomalies(&chaindb.ETH1Deposit{Amount: test.amount}, test.thresholds)
+			require.Equal(t, test.reasons, reasons)
+		})
+	}
+}
+
+func TestDepositAnomalyHook(t *testing.T) {
+	reasons := make(map[uint64][]string)
+	s := &Service{
+		depositThresholds: depositThresholds{
+			minimum:     1000000000,
+			maximum:     32000000000,
+			granularity: 1000000000,
+		},
+		depositAnomalyHook: func(deposit *chaindb.ETH1Deposit, reason string) {
+			reasons[deposit.DepositIndex] = append(reasons[deposit.DepositIndex], reason)
+		},
+	}
+
+	s.checkDepositAnomalies(&chaindb.ETH1Deposit{DepositIndex: 1, Amount: 32000000000})
+	s.checkDepositAnomalies(&chaindb.ETH1Deposit{DepositIndex: 2, Amount: 500000000})
+	s.checkDepositAnomalies(&chaindb.ETH1Deposit{DepositIndex: 3, Amount: 64000000000})
+	s.checkDepositAnomalies(&chaindb.ETH1Deposit{DepositIndex: 4, Amount: 1500000000})
+
+	require.Equal(t, map[uint64][]string{
+		2: {DepositAnomalyBelowMinimum, DepositAnomalyGranularity},
+		3: {DepositAnomalyAboveMaximum},
+		4: {DepositAnomalyGranularity},
+	}, reasons)
+}
@@ -0,0 +1,41 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// archive checks if the Ethereum 1 client holds historical state, by requesting
+// the balance of the deposit contract at block 1.  Non-archive clients return an
+// error for such requests, as they have pruned the state.
+func (s *Service) archive(ctx context.Context) (bool, error) {
+	_, err := call[hexUint64](ctx, s, "eth_getBalance", []interface{}{
+		fmt.Sprintf("%#x", s.depositContractAddress),
+		"0x1",
+	})
+	if err != nil {
+		var rpcErr *rpcError
+		if errors.As(err, &rpcErr) {
+			// The client responded, but without the state.
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,132 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// logBatch holds logs fetched ahead of time in a batch request, keyed by block range.
+type logBatch struct {
+	mu   sync.Mutex
+	logs map[blockRange][]*logResponse
+}
+
+// take removes and returns the logs for the given range, if present.
+func (b *logBatch) take(startBlock uint64, endBlock uint64) ([]*logResponse, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := blockRange{startBlock: startBlock, endBlock: endBlock}
+	logs, exists := b.logs[key]
+	if exists {
+		delete(b.logs, key)
+	}
+
+	return logs, exists
+}
+
+// set replaces the logs held with those supplied.
+func (b *logBatch) set(ranges []blockRange, logs [][]*logResponse) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.logs = make(map[blockRange][]*logResponse, len(ranges))
+	for i := range ranges {
+		b.logs[ranges[i]] = logs[i]
+	}
+}
+
+// getLogsForRanges gets the deposit logs for a number of ranges of blocks, returning
+// the logs for each range in the order of the ranges.
+// If the Ethereum 1 client supports batch requests all ranges are fetched in a single
+// request, otherwise they are fetched one at a time.
+func (s *Service) getLogsForRanges(ctx context.Context, ranges []blockRange) ([][]*logResponse, error) {
+	filter := s.depositsFilter()
+	if len(ranges) > 1 && !s.batchUnsupported.Load() {
+		params := make([][]interface{}, len(ranges))
+		for i := range ranges {
+			params[i] = []interface{}{filter.params(ranges[i].startBlock, ranges[i].endBlock)}
+		}
+		res, errs, err := callBatch[[]*logResponse](ctx, s, "eth_getLogs", params)
+		switch {
+		case errors.Is(err, errBatchUnsupported):
+			log.Info().Err(err).Msg("Ethereum 1 client does not support batch requests; fetching logs sequentially")
+			s.batchUnsupported.Store(true)
+		case err != nil:
+			return nil, err
+		default:
+			for i := range ranges {
+				if errs[i] == nil {
+					if res[i], err = s.processLogs(res[i]); err != nil {
+						return nil, errors.Wrap(err, fmt.Sprintf("failed to process logs for blocks %d-%d", ranges[i].startBlock, ranges[i].endBlock))
+					}
+					continue
+				}
+				// Fetch any range that failed within the batch on its own.
+				log.Debug().Uint64("start_block", ranges[i].startBlock).Uint64("end_block", ranges[i].endBlock).Err(errs[i]).Msg("Batched request failed; refetching")
+				res[i], err = s.getFilteredLogs(ctx, filter, ranges[i].startBlock, ranges[i].endBlock)
+				if err != nil {
+					return nil, errors.Wrap(err, fmt.Sprintf("failed to obtain logs for blocks %d-%d", ranges[i].startBlock, ranges[i].endBlock))
+				}
+			}
+			log.Trace().Int("ranges", len(ranges)).Msg("Obtained logs in batch")
+			return res, nil
+		}
+	}
+
+	res := make([][]*logResponse, len(ranges))
+	for i := range ranges {
+		logs, err := s.getFilteredLogs(ctx, filter, ranges[i].startBlock, ranges[i].endBlock)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to obtain logs for blocks %d-%d", ranges[i].startBlock, ranges[i].endBlock))
+		}
+		res[i] = logs
+	}
+
+	return res, nil
+}
+
+// batchLogs fetches the logs for the ranges of blocks that will be handled next,
+// to be picked up as each range is handled.
+// Ranges already in the deposit cache are not fetched.
+func (s *Service) batchLogs(ctx context.Context, startBlock uint64, endBlock uint64) {
+	ranges := make([]blockRange, 0, s.logRangesPerBatch)
+	block := startBlock
+	for i := uint64(0); i < s.logRangesPerBatch && block <= endBlock; i, block = i+1, block+s.blocksPerRequest {
+		rangeEnd := block + s.blocksPerRequest - 1
+		if rangeEnd > endBlock {
+			rangeEnd = endBlock
+		}
+		if s.depositCache.contains(block, rangeEnd) {
+			continue
+		}
+		ranges = append(ranges, blockRange{startBlock: block, endBlock: rangeEnd})
+	}
+	if len(ranges) < 2 {
+		return
+	}
+
+	logs, err := s.getLogsForRanges(ctx, ranges)
+	if err != nil {
+		// Ranges will be fetched individually as they are handled.
+		log.Debug().Err(err).Msg("Failed to obtain logs in batch")
+		return
+	}
+	s.logBatch.set(ranges, logs)
+}
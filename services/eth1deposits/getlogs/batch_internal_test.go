@@ -0,0 +1,147 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rangeLogsFunc returns a result function for eth_getLogs that returns a log for
+// each block in the range divisible by 16, failing the first request for the given
+// start block.
+func rangeLogsFunc(t *testing.T, failStart string) func(params []json.RawMessage) string {
+	t.Helper()
+
+	failed := false
+	return func(params []json.RawMessage) string {
+		var filter getLogsParams
+		if err := json.Unmarshal(params[0], &filter); err != nil {
+			return `"bad params"`
+		}
+		if filter.FromBlock == failStart && !failed {
+			failed = true
+			return "error:query timeout exceeded"
+		}
+		from, err := strconv.ParseUint(strings.TrimPrefix(filter.FromBlock, "0x"), 16, 64)
+		if err != nil {
+			return `"bad from block"`
+		}
+		to, err := strconv.ParseUint(strings.TrimPrefix(filter.ToBlock, "0x"), 16, 64)
+		if err != nil {
+			return `"bad to block"`
+		}
+		logs := make([]string, 0)
+		for block := from; block <= to; block++ {
+			if block%16 == 0 {
+				logs = append(logs, strings.Replace(testDepositLog, `"blockNumber":"0x39e9b3"`, `"blockNumber":"`+strconv.FormatUint(block, 16)+`"`, 1))
+			}
+		}
+		return `[` + strings.Join(logs, ",") + `]`
+	}
+}
+
+func TestGetLogsForRanges(t *testing.T) {
+	ctx := context.Background()
+
+	ranges := []blockRange{
+		{startBlock: 0x00, endBlock: 0x1f},
+		{startBlock: 0x20, endBlock: 0x3f},
+		{startBlock: 0x40, endBlock: 0x4f},
+	}
+
+	tests := []struct {
+		name          string
+		rejectBatches bool
+		failStart     string
+		requests      int
+		unsupported   bool
+	}{
+		{
+			name:     "Batched",
+			requests: 1,
+		},
+		{
+			name:      "BatchedPartialFailure",
+			failStart: "0x20",
+			// The failed range is refetched on its own.
+			requests: 2,
+		},
+		{
+			name:          "Unsupported",
+			rejectBatches: true,
+			// The rejected batch, followed by each range in turn.
+			requests:    4,
+			unsupported: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := newRPCStub(t, map[string]string{})
+			stub.rejectBatches = test.rejectBatches
+			stub.setResultFunc("eth_getLogs", rangeLogsFunc(t, test.failStart))
+			s := newTestService(t, stub.server.URL)
+
+			res, err := s.getLogsForRanges(ctx, ranges)
+			require.NoError(t, err)
+			require.Equal(t, test.requests, stub.requestCount())
+			require.Equal(t, test.unsupported, s.batchUnsupported.Load())
+
+			// Logs are returned in range order.
+			require.Len(t, res, 3)
+			blocks := make([][]uint64, len(res))
+			for i := range res {
+				blocks[i] = make([]uint64, len(res[i]))
+				for j := range res[i] {
+					blocks[i][j] = res[i][j].BlockNumber
+				}
+			}
+			require.Equal(t, [][]uint64{{0x00, 0x10}, {0x20, 0x30}, {0x40}}, blocks)
+
+			if test.unsupported {
+				// Later requests go straight to sequential fetching.
+				_, err := s.getLogsForRanges(ctx, ranges)
+				require.NoError(t, err)
+				require.Equal(t, test.requests+3, stub.requestCount())
+			}
+		})
+	}
+}
+
+func TestBatchLogs(t *testing.T) {
+	ctx := context.Background()
+	stub := newRPCStub(t, testRPCResults)
+	stub.setResultFunc("eth_getLogs", rangeLogsFunc(t, ""))
+	s := newTestService(t, stub.server.URL)
+	s.blocksPerRequest = 16
+	s.logRangesPerBatch = 4
+
+	s.batchLogs(ctx, 0x00, 0x3f)
+	require.Equal(t, 1, stub.requestCount())
+	require.Equal(t, 4, stub.callCount("eth_getLogs"))
+
+	// Each range is picked up once from the batch.
+	logs, batched := s.logBatch.take(0x10, 0x1f)
+	require.True(t, batched)
+	require.Len(t, logs, 1)
+	require.Equal(t, uint64(0x10), logs[0].BlockNumber)
+	_, batched = s.logBatch.take(0x10, 0x1f)
+	require.False(t, batched)
+}
@@ -0,0 +1,51 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type blockByNumberBlockResponse struct {
+	Hash string `json:"hash"`
+}
+
+// blockHashByNumber fetches the hash of a block given its number.
+func (s *Service) blockHashByNumber(ctx context.Context, blockNumber uint64) ([32]byte, error) {
+	block, err := call[*blockByNumberBlockResponse](ctx, s, "eth_getBlockByNumber", []interface{}{fmt.Sprintf("%#x", blockNumber), false})
+	if err != nil {
+		return [32]byte{}, err
+	}
+	if block == nil {
+		return [32]byte{}, errors.New("empty response")
+	}
+
+	hash, err := hex.DecodeString(strings.TrimPrefix(block.Hash, "0x"))
+	if err != nil {
+		return [32]byte{}, errors.Wrap(err, "invalid hash")
+	}
+	if len(hash) != 32 {
+		return [32]byte{}, errors.New("incorrect hash length")
+	}
+
+	var res [32]byte
+	copy(res[:], hash)
+
+	return res, nil
+}
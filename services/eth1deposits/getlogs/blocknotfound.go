@@ -0,0 +1,47 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// blockNotFoundMessages are fragments of error messages returned by Ethereum 1
+// clients when a requested block is not yet available.
+var blockNotFoundMessages = []string{
+	"block not found",
+	"unknown block",
+	"header not found",
+}
+
+// isBlockNotFoundError returns true if the error is returned by the Ethereum 1
+// client because a block in the request is not yet available.  This happens
+// when a request reaches the head of the chain before the client has finished
+// indexing it, and is expected to clear shortly.
+func isBlockNotFoundError(err error) bool {
+	var rpcErr *rpcError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	message := strings.ToLower(rpcErr.Message)
+	for _, fragment := range blockNotFoundMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,132 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBlockNotFoundError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name: "Nil",
+		},
+		{
+			name: "Other",
+			err:  errors.New("block not found"),
+		},
+		{
+			name: "RPCOther",
+			err:  &rpcError{Code: -32000, Message: "query timeout exceeded"},
+		},
+		{
+			name:     "BlockNotFound",
+			err:      &rpcError{Code: -32000, Message: "block not found"},
+			expected: true,
+		},
+		{
+			name:     "UnknownBlock",
+			err:      &rpcError{Code: -32000, Message: "Unknown block"},
+			expected: true,
+		},
+		{
+			name:     "HeaderNotFound",
+			err:      &rpcError{Code: -32000, Message: "header not found"},
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, isBlockNotFoundError(test.err))
+		})
+	}
+}
+
+func TestGetFilteredLogsBlockNotFound(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		failures int
+		retries  int
+		err      string
+		calls    int
+	}{
+		{
+			name:  "NoFailures",
+			calls: 1,
+		},
+		{
+			name:     "TransientFailure",
+			failures: 2,
+			retries:  3,
+			calls:    3,
+		},
+		{
+			name:     "PersistentFailure",
+			failures: 10,
+			retries:  3,
+			err:      "eth_getLogs returned an error: -32000: unknown block",
+			calls:    4,
+		},
+		{
+			name:     "OtherError",
+			failures: -1,
+			retries:  3,
+			err:      "eth_getLogs returned an error: -32000: query timeout exceeded",
+			calls:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := newRPCStub(t, testRPCResults)
+			calls := 0
+			stub.setResultFunc("eth_getLogs", func(_ []json.RawMessage) string {
+				calls++
+				switch {
+				case test.failures < 0:
+					return "error:query timeout exceeded"
+				case calls <= test.failures:
+					return "error:unknown block"
+				default:
+					return testRPCResults["eth_getLogs"]
+				}
+			})
+			s := newTestService(t, stub.server.URL)
+			s.blockNotFoundRetries = test.retries
+			s.blockNotFoundBackoff = time.Millisecond
+
+			logs, err := s.getLogs(ctx, 0x39e9b0, 0x39e9c0)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, logs, 1)
+			}
+			require.Equal(t, test.calls, stub.callCount("eth_getLogs"))
+		})
+	}
+}
@@ -14,47 +14,15 @@
 package getlogs
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"net/url"
-	"strconv"
-	"strings"
-
-	"github.com/pkg/errors"
 )
 
-type blockNumberResponse struct {
-	Result string `json:"result"`
-}
-
 // blockNumber fetches the current block number from an Ethereum 1 client.
 func (s *Service) blockNumber(ctx context.Context) (uint64, error) {
-	reference, err := url.Parse("")
-	if err != nil {
-		return 0, errors.Wrap(err, "invalid endpoint")
-	}
-	url := s.base.ResolveReference(reference).String()
-
-	reqBody := bytes.NewBufferString(`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1901}`)
-	respBodyReader, err := s.post(ctx, url, reqBody)
-	if err != nil {
-		log.Trace().Str("url", url).Err(err).Msg("Request failed")
-		return 0, errors.Wrap(err, "request failed")
-	}
-	if respBodyReader == nil {
-		return 0, errors.New("empty response")
-	}
-
-	var response blockNumberResponse
-	if err := json.NewDecoder(respBodyReader).Decode(&response); err != nil {
-		return 0, errors.Wrap(err, "invalid response")
-	}
-
-	blockNumber, err := strconv.ParseUint(strings.TrimPrefix(response.Result, "0x"), 16, 64)
+	blockNumber, err := call[hexUint64](ctx, s, "eth_blockNumber", nil)
 	if err != nil {
-		return 0, errors.Wrap(err, "invalid block number")
+		return 0, err
 	}
 
-	return blockNumber, nil
+	return uint64(blockNumber), nil
 }
@@ -0,0 +1,34 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// blockNumberByHash fetches the number of a block given its hash.
+func (s *Service) blockNumberByHash(ctx context.Context, blockHash []byte) (uint64, error) {
+	block, err := call[*blockByHashBlockResponse](ctx, s, "eth_getBlockByHash", []interface{}{fmt.Sprintf("%#x", blockHash), false})
+	if err != nil {
+		return 0, err
+	}
+	if block == nil {
+		return 0, errors.New("empty response")
+	}
+
+	return uint64(block.Number), nil
+}
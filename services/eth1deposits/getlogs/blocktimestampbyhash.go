@@ -14,55 +14,27 @@
 package getlogs
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/url"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
-type blockByHashResponse struct {
-	Result *blockByHashBlockResponse `json:"result"`
-}
 type blockByHashBlockResponse struct {
-	Timestamp string `json:"timestamp"`
+	Number    hexUint64 `json:"number"`
+	Timestamp hexUint64 `json:"timestamp"`
 }
 
 // blockTimestampByHash fetches the timestamp of a block given its hash.
 func (s *Service) blockTimestampByHash(ctx context.Context, blockHash []byte) (time.Time, error) {
-	reference, err := url.Parse("")
+	block, err := call[*blockByHashBlockResponse](ctx, s, "eth_getBlockByHash", []interface{}{fmt.Sprintf("%#x", blockHash), false})
 	if err != nil {
-		return time.Time{}, errors.Wrap(err, "invalid endpoint")
+		return time.Time{}, err
 	}
-	url := s.base.ResolveReference(reference).String()
-
-	reqBody := bytes.NewBufferString(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getBlockByHash","params":["%#x",false],"id":1901}`, blockHash))
-	respBodyReader, err := s.post(ctx, url, reqBody)
-	if err != nil {
-		log.Trace().Str("url", url).Err(err).Msg("Request failed")
-		return time.Time{}, errors.Wrap(err, "request failed")
-	}
-	if respBodyReader == nil {
+	if block == nil {
 		return time.Time{}, errors.New("empty response")
 	}
 
-	var response blockByHashResponse
-	if err := json.NewDecoder(respBodyReader).Decode(&response); err != nil {
-		return time.Time{}, errors.Wrap(err, "invalid response")
-	}
-	if response.Result == nil {
-		return time.Time{}, errors.Wrap(err, "empty response")
-	}
-
-	timestamp, err := strconv.ParseInt(strings.TrimPrefix(response.Result.Timestamp, "0x"), 16, 64)
-	if err != nil {
-		return time.Time{}, errors.Wrap(err, "invalid timestamp")
-	}
-
-	return time.Unix(timestamp, 0), nil
+	return time.Unix(int64(block.Timestamp), 0), nil
 }
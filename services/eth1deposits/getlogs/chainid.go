@@ -14,47 +14,15 @@
 package getlogs
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"net/url"
-	"strconv"
-	"strings"
-
-	"github.com/pkg/errors"
 )
 
-type chainIDResponse struct {
-	Result string `json:"result"`
-}
-
-// chainID fetches the current block number from an Ethereum 1 client.
+// chainID fetches the chain ID from an Ethereum 1 client.
 func (s *Service) chainID(ctx context.Context) (uint64, error) {
-	reference, err := url.Parse("")
-	if err != nil {
-		return 0, errors.Wrap(err, "invalid endpoint")
-	}
-	url := s.base.ResolveReference(reference).String()
-
-	reqBody := bytes.NewBufferString(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1901}`)
-	respBodyReader, err := s.post(ctx, url, reqBody)
-	if err != nil {
-		log.Trace().Str("url", url).Err(err).Msg("Request failed")
-		return 0, errors.Wrap(err, "failed to request chain ID")
-	}
-	if respBodyReader == nil {
-		return 0, errors.New("no response for chain ID")
-	}
-
-	var response chainIDResponse
-	if err := json.NewDecoder(respBodyReader).Decode(&response); err != nil {
-		return 0, errors.Wrap(err, "failed to parse newFilter response")
-	}
-
-	chainID, err := strconv.ParseUint(strings.TrimPrefix(response.Result, "0x"), 16, 64)
+	chainID, err := call[hexUint64](ctx, s, "eth_chainId", nil)
 	if err != nil {
-		return 0, errors.Wrap(err, "failed to parse result")
+		return 0, err
 	}
 
-	return chainID, nil
+	return uint64(chainID), nil
 }
@@ -0,0 +1,165 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// depositSource is the information from which a deposit is decoded.
+type depositSource struct {
+	logEntry  *logResponse
+	tx        *transaction
+	receipt   *transactionReceipt
+	timestamp time.Time
+}
+
+// indexedDepositSource is a deposit source with its position in the fetched logs.
+type indexedDepositSource struct {
+	index  int
+	source *depositSource
+}
+
+// depositDecoder decodes deposits across a pool of workers.
+// Sources are decoded as they are added, so decoding overlaps with the
+// fetching of subsequent sources, and deposits are returned in the order
+// of their indices regardless of the order in which they were decoded.
+type depositDecoder struct {
+	s       *Service
+	sources chan *indexedDepositSource
+	wg      sync.WaitGroup
+	results []*chaindb.ETH1Deposit
+	added   int
+}
+
+// newDepositDecoder creates a decoder for up to the given number of deposits.
+func (s *Service) newDepositDecoder(capacity int) *depositDecoder {
+	d := &depositDecoder{
+		s:       s,
+		results: make([]*chaindb.ETH1Deposit, capacity),
+	}
+	if s.decodeConcurrency > 1 {
+		d.sources = make(chan *indexedDepositSource, capacity)
+		d.wg.Add(s.decodeConcurrency)
+		for i := 0; i < s.decodeConcurrency; i++ {
+			go d.work()
+		}
+	}
+
+	return d
+}
+
+// work decodes sources until there are no more.
+func (d *depositDecoder) work() {
+	defer d.wg.Done()
+	for source := range d.sources {
+		// Each index is written by a single worker, so no lock is required.
+		d.results[source.index] = d.s.decodeDeposit(source.source)
+	}
+}
+
+// add adds a source to be decoded in to the given position.
+func (d *depositDecoder) add(index int, source *depositSource) {
+	d.added++
+	if d.sources == nil {
+		d.results[index] = d.s.decodeDeposit(source)
+		return
+	}
+	d.sources <- &indexedDepositSource{index: index, source: source}
+}
+
+// wait waits for all added sources to be decoded, and returns the deposits in order.
+func (d *depositDecoder) wait() []*chaindb.ETH1Deposit {
+	d.close()
+
+	deposits := make([]*chaindb.ETH1Deposit, 0, d.added)
+	for _, deposit := range d.results {
+		if deposit != nil {
+			deposits = append(deposits, deposit)
+		}
+	}
+
+	return deposits
+}
+
+// close stops the workers once they have decoded the sources added so far.
+func (d *depositDecoder) close() {
+	if d.sources != nil {
+		close(d.sources)
+		d.wg.Wait()
+		d.sources = nil
+	}
+}
+
+// decodeDeposit decodes a deposit from its source, verifying its signature if required.
+// The log is decoded with the ABI of the deposit contract version that emitted it.
+// If the log cannot be decoded it is logged and nil is returned.
+// This is CPU-bound, and safe to call concurrently.
+func (s *Service) decodeDeposit(source *depositSource) *chaindb.ETH1Deposit {
+	started := time.Now()
+	defer func() {
+		monitorDepositDecoded(time.Since(started))
+	}()
+
+	logEntry := source.logEntry
+	abi, err := s.depositEventABIForLog(logEntry)
+	if err != nil {
+		log.Error().Uint64("block", logEntry.BlockNumber).Uint64("log_index", logEntry.LogIndex).Err(err).Msg("Failed to decode deposit")
+		return nil
+	}
+	fields, err := abi.decode(logEntry.Data)
+	if err != nil {
+		log.Error().Uint64("block", logEntry.BlockNumber).Uint64("log_index", logEntry.LogIndex).Str("version", abi.version).Err(err).Msg("Failed to decode deposit")
+		return nil
+	}
+
+	deposit := &chaindb.ETH1Deposit{}
+	deposit.ETH1BlockHash = logEntry.BlockHash
+	deposit.ETH1BlockNumber = logEntry.BlockNumber
+	deposit.ETH1BlockTimestamp = source.timestamp
+	deposit.ETH1TxHash = logEntry.TransactionHash
+	deposit.ETH1LogIndex = logEntry.LogIndex
+	deposit.ETH1Sender = source.tx.From
+	if source.receipt != nil {
+		if len(deposit.ETH1Sender) == 0 {
+			deposit.ETH1Sender = source.receipt.From
+		}
+		deposit.ETH1Recipient = source.receipt.To
+		deposit.ETH1GasUsed = source.receipt.GasUsed
+	}
+	deposit.ETH1GasPrice = source.tx.GasPrice
+	deposit.DepositIndex = binary.LittleEndian.Uint64(fields[abi.indexField])
+	copy(deposit.ValidatorPubKey[:], fields["pubkey"])
+	deposit.WithdrawalCredentials = fields["withdrawal_credentials"]
+	copy(deposit.Signature[:], fields["signature"])
+	deposit.Amount = phase0.Gwei(binary.LittleEndian.Uint64(fields["amount"]))
+	if s.depositDomain != nil {
+		valid, err := VerifyDeposit(deposit, *s.depositDomain)
+		if err != nil {
+			log.Warn().Uint64("deposit_index", deposit.DepositIndex).Err(err).Msg("Failed to verify deposit signature")
+		} else {
+			if !valid {
+				log.Debug().Uint64("deposit_index", deposit.DepositIndex).Msg("Deposit has invalid signature")
+			}
+			deposit.SignatureValid = &valid
+		}
+	}
+
+	return deposit
+}
@@ -0,0 +1,183 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+// testDepositSource creates a deposit source signed by the first interop validator key,
+// with the given deposit index.
+func testDepositSource(tb testing.TB, index uint64) *depositSource {
+	tb.Helper()
+
+	pubKey, err := hex.DecodeString("a99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c")
+	require.NoError(tb, err)
+	withdrawalCredentials, err := hex.DecodeString("0100000000000000000000000c0d0e0f101112131415161718191a1b1c1d1e1f")
+	require.NoError(tb, err)
+	signature, err := hex.DecodeString("b5cac37c3b79a514fa3cf0c50fa749c3f9150a609fc0305ff670fd9fca30fd919130e273f61513136f83d23728c0184f138bf615e8421bd6ab51ffdffcaf5b8bbeb914953b153a362de7f5a06de60f2f2717e965aa50068a0e08e59bf828a95f")
+	require.NoError(tb, err)
+
+	data := make([]byte, 576)
+	// Offsets and lengths of the fields, as encoded by the deposit contract.
+	for i, field := range [][2]uint64{{0xa0, 48}, {0x100, 32}, {0x140, 8}, {0x180, 96}, {0x200, 8}} {
+		binary.BigEndian.PutUint64(data[i*32+24:i*32+32], field[0])
+		binary.BigEndian.PutUint64(data[field[0]+24:field[0]+32], field[1])
+	}
+	copy(data[192:240], pubKey)
+	copy(data[288:320], withdrawalCredentials)
+	binary.LittleEndian.PutUint64(data[352:360], 32000000000)
+	copy(data[416:512], signature)
+	binary.LittleEndian.PutUint64(data[544:552], index)
+
+	return &depositSource{
+		logEntry: &logResponse{
+			Topics:   [][]byte{depositEventTopic},
+			Data:     data,
+			LogIndex: index,
+		},
+		tx: &transaction{},
+	}
+}
+
+func testDecodeService(tb testing.TB, concurrency int) *Service {
+	tb.Helper()
+
+	domain, err := DepositDomain(phase0.DomainType{0x03, 0x00, 0x00, 0x00}, phase0.Version{0x00, 0x00, 0x00, 0x00})
+	require.NoError(tb, err)
+
+	return &Service{
+		decodeConcurrency: concurrency,
+		depositDomain:     &domain,
+	}
+}
+
+func TestDepositDecoder(t *testing.T) {
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprintf("Concurrency%d", concurrency), func(t *testing.T) {
+			s := testDecodeService(t, concurrency)
+
+			// Leave gaps, as for removed logs, and add out of order.
+			decoder := s.newDepositDecoder(64)
+			for i := 63; i >= 0; i-- {
+				if i%3 == 0 {
+					continue
+				}
+				decoder.add(i, testDepositSource(t, uint64(i)))
+			}
+			deposits := decoder.wait()
+
+			require.Len(t, deposits, 42)
+			prev := uint64(0)
+			for _, deposit := range deposits {
+				require.Greater(t, deposit.DepositIndex, prev)
+				require.NotNil(t, deposit.SignatureValid)
+				require.True(t, *deposit.SignatureValid)
+				prev = deposit.DepositIndex
+			}
+		})
+	}
+}
+
+func TestDepositsForBlocksConcurrent(t *testing.T) {
+	ctx := context.Background()
+
+	stub := newRPCStub(t, testRPCResults)
+	// Return a number of deposits, each with its own log index.
+	stub.setResultFunc("eth_getLogs", func(_ []json.RawMessage) string {
+		logs := make([]string, 16)
+		for i := range logs {
+			logs[i] = strings.Replace(testDepositLog, `"logIndex":"0x0"`, fmt.Sprintf(`"logIndex":"%#x"`, i), 1)
+		}
+		return "[" + strings.Join(logs, ",") + "]"
+	})
+
+	s := newTestService(t, stub.server.URL)
+	expected, err := s.depositsForBlocks(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Len(t, expected, 16)
+
+	s.decodeConcurrency = 4
+	deposits, err := s.depositsForBlocks(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Equal(t, expected, deposits)
+	for i := range deposits {
+		require.Equal(t, uint64(i), deposits[i].ETH1LogIndex)
+	}
+}
+
+func TestLogPrefetch(t *testing.T) {
+	ctx := context.Background()
+
+	stub := newRPCStub(t, testRPCResults)
+	s := newTestService(t, stub.server.URL)
+
+	// Nothing prefetched.
+	_, exists := s.logPrefetch.take(ctx, 0x39e9b0, 0x39e9bf)
+	require.False(t, exists)
+
+	// Prefetched range.
+	s.logPrefetch.start(ctx, s, 0x39e9b0, 0x39e9bf)
+	logs, exists := s.logPrefetch.take(ctx, 0x39e9b0, 0x39e9bf)
+	require.True(t, exists)
+	require.Len(t, logs, 1)
+	require.Equal(t, 1, stub.callCount("eth_getLogs"))
+
+	// Prefetched logs are only returned once.
+	_, exists = s.logPrefetch.take(ctx, 0x39e9b0, 0x39e9bf)
+	require.False(t, exists)
+
+	// Different range.
+	s.logPrefetch.start(ctx, s, 0x39e9b0, 0x39e9bf)
+	_, exists = s.logPrefetch.take(ctx, 0x39e9c0, 0x39e9cf)
+	require.False(t, exists)
+
+	// Failed prefetch.
+	stub.setResultFunc("eth_getLogs", func(_ []json.RawMessage) string {
+		return "error:unavailable"
+	})
+	s.logPrefetch.start(ctx, s, 0x39e9c0, 0x39e9cf)
+	_, exists = s.logPrefetch.take(ctx, 0x39e9c0, 0x39e9cf)
+	require.False(t, exists)
+}
+
+// BenchmarkDecodeDeposits measures decoding deposits with signature verification.
+func BenchmarkDecodeDeposits(b *testing.B) {
+	sources := make([]*depositSource, 256)
+	for i := range sources {
+		sources[i] = testDepositSource(b, uint64(i))
+	}
+
+	for _, concurrency := range []int{1, 2, 4, 8} {
+		s := testDecodeService(b, concurrency)
+		b.Run(fmt.Sprintf("Concurrency%d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				decoder := s.newDepositDecoder(len(sources))
+				for j := range sources {
+					decoder.add(j, sources[j])
+				}
+				decoder.wait()
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// blockRange is a range of blocks, inclusive.
+type blockRange struct {
+	startBlock uint64
+	endBlock   uint64
+}
+
+// overlaps returns true if the range overlaps the given range.
+func (r blockRange) overlaps(startBlock uint64, endBlock uint64) bool {
+	return r.startBlock <= endBlock && startBlock <= r.endBlock
+}
+
+// depositCache is a least-recently-used cache of decoded deposits keyed by block range.
+// A nil cache is valid, and caches nothing.
+type depositCache struct {
+	entries *lru[blockRange, []*chaindb.ETH1Deposit]
+}
+
+// newDepositCache creates a new deposit cache holding up to size ranges.
+// If size is 0 no cache is created.
+func newDepositCache(size int) *depositCache {
+	if size <= 0 {
+		return nil
+	}
+
+	return &depositCache{
+		entries: newLRU[blockRange, []*chaindb.ETH1Deposit](size),
+	}
+}
+
+// get returns the cached deposits for the given range, if present.
+func (c *depositCache) get(startBlock uint64, endBlock uint64) ([]*chaindb.ETH1Deposit, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	deposits, exists := c.entries.get(blockRange{startBlock: startBlock, endBlock: endBlock})
+	if !exists {
+		monitorDepositCacheMiss()
+		return nil, false
+	}
+	monitorDepositCacheHit()
+
+	return deposits, true
+}
+
+// contains returns true if the cache holds deposits for the given range.
+// Unlike get, this does not affect the cache's ordering or metrics.
+func (c *depositCache) contains(startBlock uint64, endBlock uint64) bool {
+	if c == nil {
+		return false
+	}
+
+	return c.entries.contains(blockRange{startBlock: startBlock, endBlock: endBlock})
+}
+
+// set caches the deposits for the given range.
+func (c *depositCache) set(startBlock uint64, endBlock uint64, deposits []*chaindb.ETH1Deposit) {
+	if c == nil {
+		return
+	}
+
+	c.entries.set(blockRange{startBlock: startBlock, endBlock: endBlock}, deposits)
+}
+
+// invalidate removes all cached ranges that overlap the given range.
+func (c *depositCache) invalidate(startBlock uint64, endBlock uint64) {
+	if c == nil {
+		return
+	}
+
+	c.entries.removeIf(func(key blockRange, _ []*chaindb.ETH1Deposit) bool {
+		return key.overlaps(startBlock, endBlock)
+	})
+}
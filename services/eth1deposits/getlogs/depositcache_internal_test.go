@@ -0,0 +1,79 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestDepositCache(t *testing.T) {
+	ctx := context.Background()
+
+	stub := newRPCStub(t, testRPCResults)
+	s := newTestService(t, stub.server.URL)
+	s.depositCache = newDepositCache(4)
+
+	deposits, err := s.depositsForBlocks(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Len(t, deposits, 1)
+	require.Equal(t, "388ea662ef2c223ec0b047d41bf3c0f362142ad5", hex.EncodeToString(deposits[0].ETH1Sender))
+	require.Equal(t, 1, stub.callCount("eth_getLogs"))
+	require.Equal(t, 1, stub.callCount("eth_getTransactionByHash"))
+
+	// Second identical query should be served from the cache.
+	cachedDeposits, err := s.depositsForBlocks(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Equal(t, deposits, cachedDeposits)
+	require.Equal(t, 1, stub.callCount("eth_getLogs"))
+	require.Equal(t, 1, stub.callCount("eth_getTransactionByHash"))
+
+	// Invalidating an overlapping range should force a refetch.
+	s.depositCache.invalidate(0x39e9b3, 0x39e9b3)
+	_, err = s.depositsForBlocks(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Equal(t, 2, stub.callCount("eth_getLogs"))
+}
+
+func TestDepositCacheEviction(t *testing.T) {
+	cache := newDepositCache(2)
+	deposits := []*chaindb.ETH1Deposit{{DepositIndex: 1}}
+
+	cache.set(1, 10, deposits)
+	cache.set(11, 20, deposits)
+	_, exists := cache.get(1, 10)
+	require.True(t, exists)
+
+	// Adding a third range should evict the least-recently used (11-20).
+	cache.set(21, 30, deposits)
+	_, exists = cache.get(11, 20)
+	require.False(t, exists)
+	_, exists = cache.get(1, 10)
+	require.True(t, exists)
+	_, exists = cache.get(21, 30)
+	require.True(t, exists)
+}
+
+func TestDepositCacheDisabled(t *testing.T) {
+	cache := newDepositCache(0)
+	require.Nil(t, cache)
+
+	cache.set(1, 10, []*chaindb.ETH1Deposit{{DepositIndex: 1}})
+	_, exists := cache.get(1, 10)
+	require.False(t, exists)
+}
@@ -0,0 +1,91 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+// depositContractCodeHashes are the keccak-256 hashes of the deployed code of the
+// deposit contract, keyed by the chain ID of the network.  Entries must be taken
+// from the code deployed at the deposit contract address of the network, as
+// reported by eth_getCode.  Networks without an entry fail verification unless a hash is supplied with
+// WithDepositContractCodeHash, or the check is explicitly skipped with
+// WithSkipDepositContractCodeCheck.
+var depositContractCodeHashes = map[uint64][]byte{}
+
+// depositContractCode fetches the code of the deposit contract as of the latest block.
+func (s *Service) depositContractCode(ctx context.Context) ([]byte, error) {
+	result, err := call[*string](ctx, s, "eth_getCode", []interface{}{
+		fmt.Sprintf("%#x", s.depositContractAddress),
+		"latest",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, errors.New("empty response")
+	}
+
+	code, err := hex.DecodeString(strings.TrimPrefix(*result, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid code")
+	}
+
+	return code, nil
+}
+
+// verifyDepositContractCode confirms that the code at the deposit contract address
+// is that of the deposit contract for the chain, guarding against a wrong address.
+func (s *Service) verifyDepositContractCode(ctx context.Context, chainID uint64) error {
+	if s.skipCodeHashCheck {
+		log.Warn().Uint64("chain_id", chainID).Str("address", fmt.Sprintf("%#x", s.depositContractAddress)).Msg("Deposit contract code check skipped by configuration")
+		return nil
+	}
+
+	code, err := s.depositContractCode(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain deposit contract code")
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("no code at deposit contract address %#x", s.depositContractAddress)
+	}
+
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(code)
+	codeHash := hash.Sum(nil)
+
+	expected := s.depositContractCodeHash
+	if len(expected) == 0 {
+		expected = depositContractCodeHashes[chainID]
+	}
+	if len(expected) == 0 {
+		// Report the hash of the code found, so that it can be confirmed against the
+		// network's published deposit contract and supplied.
+		return fmt.Errorf("no known code hash for the deposit contract on chain %d; code at %#x has hash %#x, supply it with eth1deposits.deposit-contract-code-hash once confirmed", chainID, s.depositContractAddress, codeHash)
+	}
+	if !bytes.Equal(codeHash, expected) {
+		return fmt.Errorf("deposit contract at %#x has code hash %#x but expected %#x", s.depositContractAddress, codeHash, expected)
+	}
+	log.Info().Str("address", fmt.Sprintf("%#x", s.depositContractAddress)).Str("code_hash", fmt.Sprintf("%#x", codeHash)).Msg("Verified deposit contract code")
+
+	return nil
+}
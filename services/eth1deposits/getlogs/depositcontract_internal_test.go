@@ -0,0 +1,135 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+)
+
+func TestVerifyDepositContractCode(t *testing.T) {
+	code := []byte{0x60, 0x80, 0x60, 0x40, 0x52}
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(code)
+	codeHash := hash.Sum(nil)
+	otherHash := make([]byte, 32)
+
+	tests := []struct {
+		name      string
+		chainID   uint64
+		known     []byte
+		override  []byte
+		skip      bool
+		result    string
+		err       string
+		fetchCode bool
+	}{
+		{
+			name:      "NoKnownHash",
+			chainID:   5,
+			result:    `"0x6080604052"`,
+			err:       "no known code hash for the deposit contract on chain 5; code at 0x8c5fecdc472e27bc447696f431e425d02dd46a8c has hash 0x1c3374235d773b2189aed115aa13143020fcdbbe86e38f358cf3e4771b2f0244",
+			fetchCode: true,
+		},
+		{
+			name:    "NoKnownHashSkipped",
+			chainID: 5,
+			skip:    true,
+			result:  `"0x6080604052"`,
+		},
+		{
+			name:    "KnownMismatchSkipped",
+			chainID: 5,
+			known:   otherHash,
+			skip:    true,
+			result:  `"0x6080604052"`,
+		},
+		{
+			name:      "KnownMatch",
+			chainID:   5,
+			known:     codeHash,
+			result:    `"0x6080604052"`,
+			fetchCode: true,
+		},
+		{
+			name:      "KnownMismatch",
+			chainID:   5,
+			known:     otherHash,
+			result:    `"0x6080604052"`,
+			err:       "deposit contract at 0x8c5fecdc472e27bc447696f431e425d02dd46a8c has code hash 0x1c3374235d773b2189aed115aa13143020fcdbbe86e38f358cf3e4771b2f0244 but expected 0x0000000000000000000000000000000000000000000000000000000000000000",
+			fetchCode: true,
+		},
+		{
+			name:      "OverrideMatch",
+			chainID:   5,
+			known:     otherHash,
+			override:  codeHash,
+			result:    `"0x6080604052"`,
+			fetchCode: true,
+		},
+		{
+			name:      "NoCode",
+			chainID:   5,
+			known:     codeHash,
+			result:    `"0x"`,
+			err:       "no code at deposit contract address 0x8c5fecdc472e27bc447696f431e425d02dd46a8c",
+			fetchCode: true,
+		},
+		{
+			name:      "RequestFailed",
+			chainID:   5,
+			known:     codeHash,
+			result:    `error:header not found`,
+			err:       "failed to obtain deposit contract code: eth_getCode returned an error: -32000: header not found",
+			fetchCode: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.known != nil {
+				depositContractCodeHashes[test.chainID] = test.known
+				defer delete(depositContractCodeHashes, test.chainID)
+			}
+			stub := newRPCStub(t, map[string]string{})
+			var params []json.RawMessage
+			stub.setResultFunc("eth_getCode", func(p []json.RawMessage) string {
+				params = p
+				return test.result
+			})
+			s := newTestService(t, stub.server.URL)
+			s.depositContractCodeHash = test.override
+			s.skipCodeHashCheck = test.skip
+
+			err := s.verifyDepositContractCode(context.Background(), test.chainID)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+			if test.fetchCode {
+				require.Equal(t, 1, stub.callCount("eth_getCode"))
+				require.Len(t, params, 2)
+				require.JSONEq(t, `"0x8c5fecdc472e27bc447696f431e425d02dd46a8c"`, string(params[0]))
+				require.JSONEq(t, `"latest"`, string(params[1]))
+			} else {
+				require.Equal(t, 0, stub.callCount("eth_getCode"))
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+// Versions of the deposit contract.
+const (
+	// DepositContractVersionLegacy is the contract deployed to early testnets, which
+	// emits a Deposit event with a merkle_tree_index field.
+	DepositContractVersionLegacy = "legacy"
+	// DepositContractVersionCurrent is the contract deployed to mainnet and later
+	// testnets, which emits a DepositEvent event with an index field.
+	DepositContractVersionCurrent = "current"
+)
+
+// depositEventABI is the ABI of the deposit event of a version of the deposit contract.
+// All fields of the event are of type bytes.
+type depositEventABI struct {
+	version   string
+	signature string
+	topic     []byte
+	// fields are the names of the fields of the event, in order.
+	fields []string
+	// indexField is the name of the field holding the index of the deposit.
+	indexField string
+}
+
+// depositEventABIs are the ABIs of the known versions of the deposit contract.
+var depositEventABIs = []*depositEventABI{
+	newDepositEventABI(DepositContractVersionCurrent, "DepositEvent", []string{"pubkey", "withdrawal_credentials", "amount", "signature", "index"}, "index"),
+	newDepositEventABI(DepositContractVersionLegacy, "Deposit", []string{"pubkey", "withdrawal_credentials", "amount", "signature", "merkle_tree_index"}, "merkle_tree_index"),
+}
+
+// depositEventFieldLengths are the lengths of the fields of deposit events.
+var depositEventFieldLengths = map[string]int{
+	"pubkey":                 48,
+	"withdrawal_credentials": 32,
+	"amount":                 8,
+	"signature":              96,
+	"index":                  8,
+	"merkle_tree_index":      8,
+}
+
+// newDepositEventABI creates the ABI for an event whose fields are all of type bytes.
+func newDepositEventABI(version string, name string, fields []string, indexField string) *depositEventABI {
+	signature := name + "("
+	for i := range fields {
+		if i > 0 {
+			signature += ","
+		}
+		signature += "bytes"
+	}
+	signature += ")"
+
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(signature))
+
+	return &depositEventABI{
+		version:    version,
+		signature:  signature,
+		topic:      hash.Sum(nil),
+		fields:     fields,
+		indexField: indexField,
+	}
+}
+
+// depositEventABIForVersion returns the ABI of the given version of the deposit contract.
+func depositEventABIForVersion(version string) (*depositEventABI, error) {
+	for _, abi := range depositEventABIs {
+		if abi.version == version {
+			return abi, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unrecognised deposit contract version %q", version)
+}
+
+// depositEventTopics returns the topics of the deposit events that are accepted.
+func (s *Service) depositEventTopics() [][]byte {
+	if s.depositEventABI != nil {
+		return [][]byte{s.depositEventABI.topic}
+	}
+
+	topics := make([][]byte, 0, len(depositEventABIs))
+	for _, abi := range depositEventABIs {
+		topics = append(topics, abi.topic)
+	}
+
+	return topics
+}
+
+// depositEventABIForLog returns the ABI with which to decode a log.
+// If a version of the deposit contract is configured only logs of that version
+// are accepted, otherwise the version is detected from the event signature.
+func (s *Service) depositEventABIForLog(logEntry *logResponse) (*depositEventABI, error) {
+	if len(logEntry.Topics) == 0 {
+		return nil, errors.New("log has no event signature")
+	}
+	if s.depositEventABI != nil {
+		if !bytes.Equal(logEntry.Topics[0], s.depositEventABI.topic) {
+			return nil, fmt.Errorf("log has event signature %#x, not that of deposit contract version %s", logEntry.Topics[0], s.depositEventABI.version)
+		}
+		return s.depositEventABI, nil
+	}
+	for _, abi := range depositEventABIs {
+		if bytes.Equal(logEntry.Topics[0], abi.topic) {
+			return abi, nil
+		}
+	}
+
+	return nil, fmt.Errorf("log has unrecognised event signature %#x", logEntry.Topics[0])
+}
+
+// decode decodes the data of a deposit event, returning its fields by name.
+func (abi *depositEventABI) decode(data []byte) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(abi.fields))
+	for i, field := range abi.fields {
+		offset, err := abiWord(data, uint64(i*32))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid offset of %s", field))
+		}
+		length, err := abiWord(data, offset)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid length of %s", field))
+		}
+		if length != uint64(depositEventFieldLengths[field]) {
+			return nil, fmt.Errorf("%s has length %d but expected %d", field, length, depositEventFieldLengths[field])
+		}
+		start := offset + 32
+		if start+length > uint64(len(data)) {
+			return nil, fmt.Errorf("%s extends beyond end of data", field)
+		}
+		values[field] = data[start : start+length]
+	}
+
+	return values, nil
+}
+
+// abiWord returns the 32-byte word at the given position of ABI-encoded data as a number.
+func abiWord(data []byte, pos uint64) (uint64, error) {
+	if pos > math.MaxUint32 || pos+32 > uint64(len(data)) {
+		return 0, errors.New("beyond end of data")
+	}
+	word := data[pos : pos+32]
+	for _, b := range word[:24] {
+		if b != 0 {
+			return 0, errors.New("value too large")
+		}
+	}
+
+	return binary.BigEndian.Uint64(word[24:]), nil
+}
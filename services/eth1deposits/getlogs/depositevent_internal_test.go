@@ -0,0 +1,172 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testLegacyDepositLog is a deposit log from the legacy deposit contract, which differs
+// from testDepositLog in its event signature.
+var testLegacyDepositLog = strings.Replace(testDepositLog,
+	"0x649bbc62d0e31342afea4e5cd82d4049e7e1ee912fc0889aa790803be39038c5",
+	"0xdc5fc95703516abd38fa03c3737ff3b52dc52347055c8028460fdf5bbe2f12ce",
+	1)
+
+func TestDepositEventABIs(t *testing.T) {
+	current, err := depositEventABIForVersion(DepositContractVersionCurrent)
+	require.NoError(t, err)
+	require.Equal(t, "DepositEvent(bytes,bytes,bytes,bytes,bytes)", current.signature)
+	require.Equal(t, depositEventTopic, current.topic)
+
+	legacy, err := depositEventABIForVersion(DepositContractVersionLegacy)
+	require.NoError(t, err)
+	require.Equal(t, "Deposit(bytes,bytes,bytes,bytes,bytes)", legacy.signature)
+
+	_, err = depositEventABIForVersion("v0.5")
+	require.EqualError(t, err, `unrecognised deposit contract version "v0.5"`)
+}
+
+func TestDecodeDepositVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		log     string
+		decoded bool
+	}{
+		{
+			name:    "CurrentDetected",
+			log:     testDepositLog,
+			decoded: true,
+		},
+		{
+			name:    "LegacyDetected",
+			log:     testLegacyDepositLog,
+			decoded: true,
+		},
+		{
+			name:    "CurrentConfigured",
+			version: DepositContractVersionCurrent,
+			log:     testDepositLog,
+			decoded: true,
+		},
+		{
+			name:    "LegacyConfigured",
+			version: DepositContractVersionLegacy,
+			log:     testLegacyDepositLog,
+			decoded: true,
+		},
+		{
+			name:    "VersionMismatch",
+			version: DepositContractVersionCurrent,
+			log:     testLegacyDepositLog,
+		},
+		{
+			name: "Unrecognised",
+			log:  strings.Replace(testDepositLog, "0x649bbc62", "0x00000000", 1),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Service{}
+			if test.version != "" {
+				abi, err := depositEventABIForVersion(test.version)
+				require.NoError(t, err)
+				s.depositEventABI = abi
+			}
+
+			var logEntry logResponse
+			require.NoError(t, json.Unmarshal([]byte(test.log), &logEntry))
+			deposit := s.decodeDeposit(&depositSource{
+				logEntry: &logEntry,
+				tx:       &transaction{},
+			})
+			if !test.decoded {
+				require.Nil(t, deposit)
+				return
+			}
+			require.NotNil(t, deposit)
+			require.Equal(t, uint64(102341), deposit.DepositIndex)
+			require.Equal(t, "0xb55446978b2d229265caceb97cb4d59c0187ba91fcf11675330c1a373f137fa3fb553acb663a0d83f5dbcdc17c9f4f92", fmt.Sprintf("%#x", deposit.ValidatorPubKey[:]))
+			require.Equal(t, uint64(32000000000), uint64(deposit.Amount))
+		})
+	}
+}
+
+func TestDepositEventDecode(t *testing.T) {
+	abi, err := depositEventABIForVersion(DepositContractVersionCurrent)
+	require.NoError(t, err)
+
+	var logEntry logResponse
+	require.NoError(t, json.Unmarshal([]byte(testDepositLog), &logEntry))
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{
+			name: "Empty",
+			data: []byte{},
+			err:  "invalid offset of pubkey: beyond end of data",
+		},
+		{
+			name: "Truncated",
+			data: logEntry.Data[:540],
+			err:  "invalid length of index: beyond end of data",
+		},
+		{
+			name: "BadLength",
+			data: func() []byte {
+				data := append([]byte{}, logEntry.Data...)
+				data[0xa0+31] = 47
+				return data
+			}(),
+			err: "pubkey has length 47 but expected 48",
+		},
+		{
+			name: "Good",
+			data: logEntry.Data,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fields, err := abi.decode(test.data)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, fields["pubkey"], 48)
+			require.Len(t, fields["index"], 8)
+		})
+	}
+}
+
+func TestDepositEventTopics(t *testing.T) {
+	s := &Service{}
+	require.Len(t, s.depositEventTopics(), 2)
+
+	abi, err := depositEventABIForVersion(DepositContractVersionLegacy)
+	require.NoError(t, err)
+	s.depositEventABI = abi
+	require.Equal(t, [][]byte{abi.topic}, s.depositEventTopics())
+}
@@ -0,0 +1,82 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// getDepositRootSelector is the function selector for get_deposit_root() on the deposit contract.
+const getDepositRootSelector = "0xc5f2892f"
+
+// callParams are the parameters of an eth_call request.
+type callParams struct {
+	To   string `json:"to"`
+	Data string `json:"data"`
+}
+
+// depositRootAtBlock fetches the root of the deposit contract as of the given block.
+// This requires the Ethereum 1 client to hold state for the block, which may
+// not be the case for non-archive nodes.
+func (s *Service) depositRootAtBlock(ctx context.Context, blockHash []byte) (phase0.Root, error) {
+	data, err := s.callDepositContract(ctx, getDepositRootSelector, map[string]string{"blockHash": fmt.Sprintf("%#x", blockHash)})
+	if err != nil {
+		return phase0.Root{}, err
+	}
+
+	return decodeDepositRoot(data)
+}
+
+// callDepositContract calls a function of the deposit contract without arguments
+// as of the given block, returning the data returned by the function.
+func (s *Service) callDepositContract(ctx context.Context, selector string, block interface{}) ([]byte, error) {
+	result, err := call[*string](ctx, s, "eth_call", []interface{}{
+		&callParams{
+			To:   fmt.Sprintf("%#x", s.depositContractAddress),
+			Data: selector,
+		},
+		block,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, errors.New("empty response")
+	}
+
+	data, err := hex.DecodeString(strings.TrimPrefix(*result, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid response")
+	}
+
+	return data, nil
+}
+
+// decodeDepositRoot decodes the result of get_deposit_root().
+func decodeDepositRoot(data []byte) (phase0.Root, error) {
+	if len(data) != 32 {
+		return phase0.Root{}, errors.New("incorrect root length")
+	}
+
+	var root phase0.Root
+	copy(root[:], data)
+
+	return root, nil
+}
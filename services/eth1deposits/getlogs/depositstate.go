@@ -0,0 +1,137 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// getDepositCountSelector is the function selector for get_deposit_count() on the deposit contract.
+const getDepositCountSelector = "0x621fd130"
+
+// depositStateCacheSize is the number of blocks for which deposit contract state is cached.
+const depositStateCacheSize = 256
+
+// DepositContractState is the state of the deposit contract as of a block.
+type DepositContractState struct {
+	BlockNumber  uint64
+	DepositCount uint64
+	DepositRoot  phase0.Root
+}
+
+// DepositContractState returns the deposit count and root of the deposit contract as of
+// the given Ethereum 1 block, as voted for in the Ethereum 1 data of beacon blocks.
+// This requires the Ethereum 1 client to hold state for the block, which may not be
+// the case for non-archive nodes.  Results are cached by block, and invalidated if the
+// block is reorganised away.
+func (s *Service) DepositContractState(ctx context.Context, blockNumber uint64) (*DepositContractState, error) {
+	if state, exists := s.depositStates.get(blockNumber); exists {
+		return state, nil
+	}
+
+	block := fmt.Sprintf("%#x", blockNumber)
+	countData, err := s.callDepositContract(ctx, getDepositCountSelector, block)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain deposit count")
+	}
+	count, err := decodeDepositCount(countData)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode deposit count")
+	}
+	rootData, err := s.callDepositContract(ctx, getDepositRootSelector, block)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain deposit root")
+	}
+	root, err := decodeDepositRoot(rootData)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode deposit root")
+	}
+
+	state := &DepositContractState{
+		BlockNumber:  blockNumber,
+		DepositCount: count,
+		DepositRoot:  root,
+	}
+	s.depositStates.set(state)
+
+	return state, nil
+}
+
+// decodeDepositCount decodes the result of get_deposit_count(), which is the
+// count as 8 little-endian bytes, ABI-encoded as bytes.
+func decodeDepositCount(data []byte) (uint64, error) {
+	offset, err := abiWord(data, 0)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid offset")
+	}
+	length, err := abiWord(data, offset)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid length")
+	}
+	if length != 8 {
+		return 0, fmt.Errorf("count has length %d but expected 8", length)
+	}
+	if offset+32+8 > uint64(len(data)) {
+		return 0, errors.New("count extends beyond end of data")
+	}
+
+	return binary.LittleEndian.Uint64(data[offset+32 : offset+32+8]), nil
+}
+
+// depositStateCache is a least-recently-used cache of deposit contract state keyed by block number.
+// A nil cache is valid, and caches nothing.
+type depositStateCache struct {
+	entries *lru[uint64, *DepositContractState]
+}
+
+// newDepositStateCache creates a new deposit state cache holding up to size blocks.
+func newDepositStateCache(size int) *depositStateCache {
+	return &depositStateCache{
+		entries: newLRU[uint64, *DepositContractState](size),
+	}
+}
+
+// get returns the cached state for the given block, if present.
+func (c *depositStateCache) get(blockNumber uint64) (*DepositContractState, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	return c.entries.get(blockNumber)
+}
+
+// set caches the state for its block.
+func (c *depositStateCache) set(state *DepositContractState) {
+	if c == nil {
+		return
+	}
+
+	c.entries.set(state.BlockNumber, state)
+}
+
+// invalidate removes cached state for blocks in the given range.
+func (c *depositStateCache) invalidate(startBlock uint64, endBlock uint64) {
+	if c == nil {
+		return
+	}
+
+	c.entries.removeIf(func(blockNumber uint64, _ *DepositContractState) bool {
+		return blockNumber >= startBlock && blockNumber <= endBlock
+	})
+}
@@ -0,0 +1,178 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeDepositCount encodes a deposit count as returned by get_deposit_count().
+func encodeDepositCount(count uint64) string {
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, count)
+
+	return fmt.Sprintf(`"0x%064x%064x%x%s"`, 0x20, 8, data, strings.Repeat("0", 48))
+}
+
+func TestDepositContractState(t *testing.T) {
+	ctx := context.Background()
+
+	// States of the deposit contract by block, and function selector.
+	states := map[string]map[string]string{
+		// Before any deposits the root is that of an empty tree.
+		"0x0": {
+			getDepositCountSelector: encodeDepositCount(0),
+			getDepositRootSelector:  `"0xd70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e"`,
+		},
+		// Values as served by the stub, rather than those of a real network.
+		"0x39e9b3": {
+			getDepositCountSelector: encodeDepositCount(102342),
+			getDepositRootSelector:  `"0x4f1c8b1c5d20f2e0f5f8e1d7c0e2a2a2b6f6e3c1d9a8b7c6d5e4f3a2b1c0d9e8"`,
+		},
+	}
+	stub := newRPCStub(t, map[string]string{})
+	stub.setResultFunc("eth_call", func(params []json.RawMessage) string {
+		var callParams callParams
+		if err := json.Unmarshal(params[0], &callParams); err != nil {
+			return "error:" + err.Error()
+		}
+		var block string
+		if err := json.Unmarshal(params[1], &block); err != nil {
+			return "error:" + err.Error()
+		}
+		result, exists := states[block][callParams.Data]
+		if !exists {
+			return "error:missing trie node"
+		}
+		return result
+	})
+	s := newTestService(t, stub.server.URL)
+	s.depositStates = newDepositStateCache(depositStateCacheSize)
+
+	tests := []struct {
+		name        string
+		blockNumber uint64
+		count       uint64
+		root        string
+		err         string
+	}{
+		{
+			name:        "Empty",
+			blockNumber: 0,
+			count:       0,
+			root:        "d70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e",
+		},
+		{
+			name:        "Deposits",
+			blockNumber: 0x39e9b3,
+			count:       102342,
+			root:        "4f1c8b1c5d20f2e0f5f8e1d7c0e2a2a2b6f6e3c1d9a8b7c6d5e4f3a2b1c0d9e8",
+		},
+		{
+			name:        "NoState",
+			blockNumber: 0x39e9b4,
+			err:         "failed to obtain deposit count: eth_call returned an error: -32000: missing trie node",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state, err := s.DepositContractState(ctx, test.blockNumber)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.blockNumber, state.BlockNumber)
+			require.Equal(t, test.count, state.DepositCount)
+			root, err := hex.DecodeString(test.root)
+			require.NoError(t, err)
+			require.Equal(t, phase0.Root(root), state.DepositRoot)
+		})
+	}
+
+	// Results are cached.
+	calls := stub.callCount("eth_call")
+	_, err := s.DepositContractState(ctx, 0x39e9b3)
+	require.NoError(t, err)
+	require.Equal(t, calls, stub.callCount("eth_call"))
+
+	// A reorganisation invalidates state from the reorganised block onwards.
+	s.depositStates.invalidate(0x39e9b0, math.MaxUint64)
+	_, err = s.DepositContractState(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, calls, stub.callCount("eth_call"))
+	_, err = s.DepositContractState(ctx, 0x39e9b3)
+	require.NoError(t, err)
+	require.Equal(t, calls+2, stub.callCount("eth_call"))
+}
+
+func TestDecodeDepositCount(t *testing.T) {
+	good, err := hex.DecodeString(strings.TrimPrefix(strings.Trim(encodeDepositCount(0x0102), `"`), "0x"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		data  []byte
+		count uint64
+		err   string
+	}{
+		{
+			name: "Empty",
+			data: []byte{},
+			err:  "invalid offset: beyond end of data",
+		},
+		{
+			name: "Truncated",
+			data: good[:70],
+			err:  "count extends beyond end of data",
+		},
+		{
+			name: "BadLength",
+			data: func() []byte {
+				data := append([]byte{}, good...)
+				data[63] = 4
+				return data
+			}(),
+			err: "count has length 4 but expected 8",
+		},
+		{
+			name:  "Good",
+			data:  good,
+			count: 0x0102,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			count, err := decodeDepositCount(test.data)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.count, count)
+		})
+	}
+}
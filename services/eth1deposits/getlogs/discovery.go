@@ -0,0 +1,158 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Discovery returns the URLs of Ethereum 1 clients, in order of preference.
+type Discovery func(ctx context.Context) ([]string, error)
+
+// SRVDiscovery returns a discovery function that looks up the DNS SRV records
+// of the given name, returning an HTTP URL for each target in the order
+// given by the priorities and weights of the records.
+func SRVDiscovery(name string) Discovery {
+	return func(ctx context.Context) ([]string, error) {
+		_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to look up SRV records")
+		}
+		urls := make([]string, 0, len(records))
+		for _, record := range records {
+			host := strings.TrimSuffix(record.Target, ".")
+			urls = append(urls, fmt.Sprintf("http://%s", net.JoinHostPort(host, strconv.FormatUint(uint64(record.Port), 10))))
+		}
+
+		return urls, nil
+	}
+}
+
+// rediscovery replaces the Ethereum 1 client when requests to it fail persistently.
+// A nil rediscovery is valid, and never replaces the client.
+type rediscovery struct {
+	discovery Discovery
+	// threshold is the number of consecutive failed requests that trigger discovery.
+	threshold int
+	// cooldown is the minimum time between discoveries.
+	cooldown time.Duration
+
+	mu       sync.Mutex
+	failures int
+	last     time.Time
+}
+
+// newRediscovery creates a new rediscovery.
+// If discovery is nil no rediscovery is created.
+func newRediscovery(discovery Discovery, threshold int, cooldown time.Duration) *rediscovery {
+	if discovery == nil {
+		return nil
+	}
+
+	return &rediscovery{
+		discovery: discovery,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// endpointBase returns the base URL of the Ethereum 1 client.
+func (s *Service) endpointBase() *url.URL {
+	s.baseMu.RLock()
+	defer s.baseMu.RUnlock()
+
+	return s.base
+}
+
+// recordRequestSuccess notes that a request to the Ethereum 1 client succeeded.
+func (s *Service) recordRequestSuccess() {
+	r := s.rediscovery
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.failures = 0
+	r.mu.Unlock()
+}
+
+// rediscover notes that a request to the Ethereum 1 client at base failed, after
+// any retries, and replaces the client through discovery if it has failed
+// persistently.  It returns true if the client is no longer base, in which case
+// the request can be tried again.
+func (s *Service) rediscover(ctx context.Context, base *url.URL) bool {
+	r := s.rediscovery
+	if r == nil {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s.endpointBase() != base {
+		// Replaced whilst the request was in progress.
+		return true
+	}
+
+	r.failures++
+	if r.failures < r.threshold {
+		return false
+	}
+	if !r.last.IsZero() && time.Since(r.last) < r.cooldown {
+		return false
+	}
+	r.last = time.Now()
+
+	log.Warn().Int("failures", r.failures).Str("endpoint", base.Redacted()).Msg("Ethereum 1 client failing persistently; rediscovering")
+	urls, err := r.discovery(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to discover Ethereum 1 clients")
+		return false
+	}
+
+	var replacement *url.URL
+	discovered := make([]string, 0, len(urls))
+	for _, candidate := range urls {
+		candidateURL, err := parseConnectionURL(candidate)
+		if err != nil {
+			log.Warn().Str("url", candidate).Err(err).Msg("Ignoring invalid discovered Ethereum 1 client")
+			continue
+		}
+		discovered = append(discovered, candidateURL.Redacted())
+		if replacement == nil && candidateURL.String() != base.String() {
+			replacement = candidateURL
+		}
+	}
+	if replacement == nil {
+		log.Warn().Strs("endpoints", discovered).Msg("Discovery found no alternative Ethereum 1 client")
+		return false
+	}
+
+	s.baseMu.Lock()
+	s.base = replacement
+	s.baseMu.Unlock()
+	r.failures = 0
+	log.Info().Strs("endpoints", discovered).Str("previous", base.Redacted()).Str("endpoint", replacement.Redacted()).Msg("Replaced Ethereum 1 client following discovery")
+
+	return true
+}
@@ -0,0 +1,84 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRediscovery(t *testing.T) {
+	ctx := context.Background()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+	stub := newRPCStub(t, testRPCResults)
+
+	var discoveries atomic.Int32
+	s := newTestService(t, failing.URL)
+	s.rediscovery = newRediscovery(func(_ context.Context) ([]string, error) {
+		discoveries.Add(1)
+		return []string{failing.URL, stub.server.URL}, nil
+	}, 2, time.Minute)
+
+	// The first failure is below the threshold.
+	_, err := s.getLogs(ctx, 0x39e9b3, 0x39e9b3)
+	require.Error(t, err)
+	require.Equal(t, int32(0), discoveries.Load())
+
+	// The second failure triggers discovery, and the request recovers.
+	logs, err := s.getLogs(ctx, 0x39e9b3, 0x39e9b3)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, int32(1), discoveries.Load())
+	require.Equal(t, stub.server.URL, s.endpointBase().String())
+
+	// Subsequent requests use the discovered client.
+	_, err = s.getLogs(ctx, 0x39e9b3, 0x39e9b3)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), discoveries.Load())
+}
+
+func TestRediscoveryCooldown(t *testing.T) {
+	ctx := context.Background()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+
+	var discoveries atomic.Int32
+	s := newTestService(t, failing.URL)
+	s.rediscovery = newRediscovery(func(_ context.Context) ([]string, error) {
+		discoveries.Add(1)
+		return nil, errors.New("no records")
+	}, 1, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		_, err := s.getLogs(ctx, 0x39e9b3, 0x39e9b3)
+		require.Error(t, err)
+	}
+	// Failed discovery is not retried until the cooldown has passed.
+	require.Equal(t, int32(1), discoveries.Load())
+	require.Equal(t, failing.URL, s.endpointBase().String())
+}
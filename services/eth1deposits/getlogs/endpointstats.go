@@ -0,0 +1,95 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// EndpointStat is the status of an Ethereum 1 client endpoint.
+type EndpointStat struct {
+	// URL is the URL of the endpoint, with any password redacted.
+	URL string
+	// Healthy is true if the most recent request to the endpoint succeeded.
+	Healthy bool
+	// LastError is the error returned by the most recent failed request to the endpoint.
+	LastError string
+	// LastErrorTime is the time of the most recent failed request to the endpoint.
+	LastErrorTime time.Time
+	// LastSuccess is the time of the most recent successful request to the endpoint.
+	LastSuccess time.Time
+}
+
+// endpointStats tracks the status of each endpoint.
+// The zero value is ready to use.
+type endpointStats struct {
+	mu    sync.Mutex
+	stats map[string]*EndpointStat
+}
+
+// stat returns the status for an endpoint, creating it if required.
+// This must be called with the lock held.
+func (e *endpointStats) stat(endpoint string) *EndpointStat {
+	if e.stats == nil {
+		e.stats = make(map[string]*EndpointStat)
+	}
+	stat, exists := e.stats[endpoint]
+	if !exists {
+		stat = &EndpointStat{URL: endpoint}
+		e.stats[endpoint] = stat
+	}
+
+	return stat
+}
+
+// recordSuccess records a successful request to an endpoint.
+func (e *endpointStats) recordSuccess(endpoint string) {
+	e.mu.Lock()
+	stat := e.stat(endpoint)
+	stat.Healthy = true
+	stat.LastSuccess = time.Now()
+	e.mu.Unlock()
+
+	monitorEndpointHealthy(endpoint, true)
+}
+
+// recordError records a failed request to an endpoint.
+func (e *endpointStats) recordError(endpoint string, err error) {
+	e.mu.Lock()
+	stat := e.stat(endpoint)
+	stat.Healthy = false
+	stat.LastError = err.Error()
+	stat.LastErrorTime = time.Now()
+	e.mu.Unlock()
+
+	monitorEndpointHealthy(endpoint, false)
+}
+
+// EndpointStatus returns the status of each endpoint to which requests have been made, ordered by URL.
+func (s *Service) EndpointStatus() []EndpointStat {
+	s.endpoints.mu.Lock()
+	defer s.endpoints.mu.Unlock()
+
+	res := make([]EndpointStat, 0, len(s.endpoints.stats))
+	for _, stat := range s.endpoints.stats {
+		res = append(res, *stat)
+	}
+	sort.Slice(res, func(i int, j int) bool {
+		return res[i].URL < res[j].URL
+	})
+
+	return res
+}
@@ -0,0 +1,128 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// estimateSamples is the number of ranges sampled when estimating a backfill.
+const estimateSamples = 5
+
+// rpcCallsPerLog is the number of RPC calls required to process each log:
+// the transaction, its receipt, and the timestamp of its block.
+const rpcCallsPerLog = 3
+
+// EstimateResult is an estimate of the work involved in a backfill.
+type EstimateResult struct {
+	// StartBlock is the first block of the backfill.
+	StartBlock uint64
+	// EndBlock is the last block of the backfill.
+	EndBlock uint64
+	// Blocks is the number of blocks in the backfill.
+	Blocks uint64
+	// SampledBlocks is the number of blocks sampled to create the estimate.
+	SampledBlocks uint64
+	// SampledLogs is the number of logs found in the sampled blocks.
+	SampledLogs uint64
+	// Logs is the estimated number of logs in the backfill.
+	Logs uint64
+	// RPCCalls is the estimated number of RPC calls required for the backfill.
+	RPCCalls uint64
+	// Exact is true if all blocks were sampled, in which case the estimate is exact.
+	Exact bool
+	// Confidence is a human-readable note on the confidence of the estimate.
+	Confidence string
+}
+
+// EstimateBackfill estimates the number of logs and RPC calls involved in
+// backfilling deposits between the two blocks (inclusive).
+// A few ranges are sampled to obtain the density of logs, which is then
+// extrapolated across the full range.
+func (s *Service) EstimateBackfill(ctx context.Context, from uint64, to uint64) (*EstimateResult, error) {
+	if from > to {
+		return nil, errors.New("start block after end block")
+	}
+
+	res := &EstimateResult{
+		StartBlock: from,
+		EndBlock:   to,
+		Blocks:     to - from + 1,
+	}
+
+	// If the range is small enough sample all of it, otherwise spread the
+	// samples evenly across the range.
+	sampleSize := s.blocksPerRequest
+	samples := uint64(estimateSamples)
+	if res.Blocks <= samples*sampleSize {
+		samples = (res.Blocks + sampleSize - 1) / sampleSize
+		res.Exact = true
+	}
+	stride := res.Blocks / samples
+
+	minDensity := math.MaxFloat64
+	maxDensity := 0.0
+	for i := uint64(0); i < samples; i++ {
+		startBlock := from + i*stride
+		if res.Exact {
+			startBlock = from + i*sampleSize
+		}
+		endBlock := startBlock + sampleSize - 1
+		if endBlock > to {
+			endBlock = to
+		}
+
+		logs, err := s.getLogs(ctx, startBlock, endBlock)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain logs for sample")
+		}
+		sampleLogs := uint64(0)
+		for _, logEntry := range logs {
+			if !logEntry.Removed {
+				sampleLogs++
+			}
+		}
+		log.Trace().Uint64("start_block", startBlock).Uint64("end_block", endBlock).Uint64("logs", sampleLogs).Msg("Sampled range")
+
+		sampleBlocks := endBlock - startBlock + 1
+		density := float64(sampleLogs) / float64(sampleBlocks)
+		minDensity = math.Min(minDensity, density)
+		maxDensity = math.Max(maxDensity, density)
+		res.SampledBlocks += sampleBlocks
+		res.SampledLogs += sampleLogs
+	}
+
+	if res.Exact {
+		res.Logs = res.SampledLogs
+	} else {
+		res.Logs = uint64(math.Round(float64(res.SampledLogs) * float64(res.Blocks) / float64(res.SampledBlocks)))
+	}
+	requests := (res.Blocks + s.blocksPerRequest - 1) / s.blocksPerRequest
+	res.RPCCalls = requests + res.Logs*rpcCallsPerLog
+
+	switch {
+	case res.Exact:
+		res.Confidence = fmt.Sprintf("exact; all %d blocks sampled", res.Blocks)
+	case minDensity == maxDensity:
+		res.Confidence = fmt.Sprintf("sampled %d of %d blocks in %d ranges with a consistent density of %.4f logs per block", res.SampledBlocks, res.Blocks, samples, minDensity)
+	default:
+		res.Confidence = fmt.Sprintf("sampled %d of %d blocks in %d ranges with density varying between %.4f and %.4f logs per block; actual figures may differ significantly", res.SampledBlocks, res.Blocks, samples, minDensity, maxDensity)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,107 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateBackfill(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		logsPerSample int
+		from          uint64
+		to            uint64
+		err           string
+		samples       int
+		logs          uint64
+		rpcCalls      uint64
+		exact         bool
+	}{
+		{
+			name: "Inverted",
+			from: 100,
+			to:   99,
+			err:  "start block after end block",
+		},
+		{
+			name:          "Empty",
+			logsPerSample: 0,
+			from:          0,
+			to:            63999,
+			samples:       5,
+			logs:          0,
+			rpcCalls:      1000,
+		},
+		{
+			name:          "Sparse",
+			logsPerSample: 1,
+			from:          0,
+			to:            63999,
+			samples:       5,
+			logs:          1000,
+			rpcCalls:      1000 + 3000,
+		},
+		{
+			name:          "Dense",
+			logsPerSample: 4,
+			from:          0,
+			to:            63999,
+			samples:       5,
+			logs:          4000,
+			rpcCalls:      1000 + 12000,
+		},
+		{
+			name:          "Small",
+			logsPerSample: 2,
+			from:          0,
+			to:            99,
+			samples:       2,
+			logs:          4,
+			rpcCalls:      2 + 12,
+			exact:         true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logs := make([]string, test.logsPerSample)
+			for i := range logs {
+				logs[i] = testDepositLog
+			}
+			stub := newRPCStub(t, map[string]string{
+				"eth_getLogs": `[` + strings.Join(logs, ",") + `]`,
+			})
+			s := newTestService(t, stub.server.URL)
+
+			res, err := s.EstimateBackfill(ctx, test.from, test.to)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.samples, stub.callCount("eth_getLogs"))
+			require.Equal(t, test.logs, res.Logs)
+			require.Equal(t, test.rpcCalls, res.RPCCalls)
+			require.Equal(t, test.exact, res.Exact)
+			require.NotEmpty(t, res.Confidence)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+// depositEventTopic is the topic of the deposit contract's DepositEvent.
+var depositEventTopic = []byte{
+	0x64, 0x9b, 0xbc, 0x62, 0xd0, 0xe3, 0x13, 0x42, 0xaf, 0xea, 0x4e, 0x5c, 0xd8, 0x2d, 0x40, 0x49,
+	0xe7, 0xe1, 0xee, 0x91, 0x2f, 0xc0, 0x88, 0x9a, 0xa7, 0x90, 0x80, 0x3b, 0xe3, 0x90, 0x38, 0xc5,
+}
+
+// logFilter is a named filter for logs.
+type logFilter struct {
+	name string
+	// addresses are the contract addresses from which to obtain logs.
+	addresses [][]byte
+	// topics are the alternative values for the first topic of matching logs.
+	topics [][]byte
+}
+
+type getLogsParams struct {
+	Address   []string `json:"address"`
+	Topics    []any    `json:"topics"`
+	FromBlock string   `json:"fromBlock"`
+	ToBlock   string   `json:"toBlock"`
+}
+
+// getFilteredLogs gets the logs matching a filter for a range of blocks.
+// If the log cache holds the response for the filter over the range it is used
+// without contacting the client.
+func (s *Service) getFilteredLogs(ctx context.Context, filter *logFilter, startBlock uint64, endBlock uint64) ([]*logResponse, error) {
+	raw, cached := s.logCache.get(filter, startBlock, endBlock)
+	if !cached {
+		var err error
+		raw, err = s.fetchFilteredLogs(ctx, filter, startBlock, endBlock)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var logs []*logResponse
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &logs); err != nil {
+			return nil, errors.Wrap(err, "invalid response")
+		}
+	}
+	if !cached && !containsRemovedLogs(logs) {
+		// Responses containing removed logs are in the middle of a reorganisation, so are not cached.
+		s.logCache.set(filter, startBlock, endBlock, raw)
+	}
+	log.Trace().Str("filter", filter.name).Uint64("start_block", startBlock).Uint64("end_block", endBlock).Int("logs", len(logs)).Bool("cached", cached).Msg("Obtained logs")
+
+	return s.processLogs(logs)
+}
+
+// fetchFilteredLogs fetches the raw response to eth_getLogs for a filter over a range of blocks.
+// If the client reports that a block in the range is not found, as happens when
+// the range reaches a head block that the client has yet to index, the request
+// is retried after a short backoff up to a limited number of times.
+func (s *Service) fetchFilteredLogs(ctx context.Context, filter *logFilter, startBlock uint64, endBlock uint64) (json.RawMessage, error) {
+	for attempt := 0; ; attempt++ {
+		raw, err := call[json.RawMessage](ctx, s, "eth_getLogs", []interface{}{filter.params(startBlock, endBlock)})
+		if err == nil {
+			return raw, nil
+		}
+		if !isBlockNotFoundError(err) || attempt >= s.blockNotFoundRetries {
+			return nil, err
+		}
+		// The client has yet to index a block in the range; it should be available shortly.
+		log.Debug().Str("filter", filter.name).Uint64("start_block", startBlock).Uint64("end_block", endBlock).Int("attempt", attempt+1).Err(err).Msg("Block not found; retrying")
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "context done whilst waiting to retry")
+		case <-time.After(s.blockNotFoundBackoff * time.Duration(attempt+1)):
+		}
+	}
+}
+
+// containsRemovedLogs returns true if any of the logs have been removed by a reorganisation.
+func containsRemovedLogs(logs []*logResponse) bool {
+	for _, logEntry := range logs {
+		if logEntry.Removed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// params returns the eth_getLogs parameters for the filter over a range of blocks.
+func (f *logFilter) params(startBlock uint64, endBlock uint64) *getLogsParams {
+	params := &getLogsParams{
+		Address:   make([]string, len(f.addresses)),
+		FromBlock: fmt.Sprintf("%#x", startBlock),
+		ToBlock:   fmt.Sprintf("%#x", endBlock),
+	}
+	for i := range f.addresses {
+		params.Address[i] = fmt.Sprintf("%#x", f.addresses[i])
+	}
+	topics := make([]string, len(f.topics))
+	for i := range f.topics {
+		topics[i] = fmt.Sprintf("%#x", f.topics[i])
+	}
+	params.Topics = []any{topics}
+
+	return params
+}
+
+// getLogsForFilters gets the logs matching each of the filters for a range
+// of blocks, returning them keyed by filter name.
+// Filters are fetched concurrently; if any fetch fails an error is returned.
+func (s *Service) getLogsForFilters(ctx context.Context, filters []*logFilter, startBlock uint64, endBlock uint64) (map[string][]*logResponse, error) {
+	names := make(map[string]bool, len(filters))
+	for _, filter := range filters {
+		if names[filter.name] {
+			return nil, fmt.Errorf("duplicate filter name %q", filter.name)
+		}
+		names[filter.name] = true
+	}
+
+	res := make(map[string][]*logResponse, len(filters))
+	var mu sync.Mutex
+	g, ctx := errgroup.WithContext(ctx)
+	for _, filter := range filters {
+		filter := filter
+		g.Go(func() error {
+			logs, err := s.getFilteredLogs(ctx, filter, startBlock, endBlock)
+			if err != nil {
+				return errors.Wrapf(err, "failed to obtain logs for filter %s", filter.name)
+			}
+			mu.Lock()
+			res[filter.name] = logs
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,99 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLogsForFilters(t *testing.T) {
+	ctx := context.Background()
+	stub := newRPCStub(t, map[string]string{})
+	s := newTestService(t, stub.server.URL)
+
+	// Each request waits for the other to arrive, so the test only
+	// completes if the filters are fetched concurrently.
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	stub.setResultFunc("eth_getLogs", func(params []json.RawMessage) string {
+		arrived.Done()
+		waited := make(chan struct{})
+		go func() {
+			arrived.Wait()
+			close(waited)
+		}()
+		select {
+		case <-waited:
+		case <-time.After(2 * time.Second):
+			return `"timed out"`
+		}
+
+		var filter getLogsParams
+		if err := json.Unmarshal(params[0], &filter); err != nil {
+			return `"bad params"`
+		}
+		topics, ok := filter.Topics[0].([]any)
+		if !ok || len(topics) != 1 {
+			return `"bad topics"`
+		}
+		if topics[0] == "0x0101010101010101010101010101010101010101010101010101010101010101" {
+			return `[]`
+		}
+		return `[` + testDepositLog + `]`
+	})
+
+	filters := []*logFilter{
+		{
+			name:      "deposits",
+			addresses: [][]byte{s.depositContractAddress},
+			topics:    [][]byte{depositEventTopic},
+		},
+		{
+			name:      "other",
+			addresses: [][]byte{s.depositContractAddress},
+			topics: [][]byte{{
+				0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01,
+				0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01,
+			}},
+		},
+	}
+
+	res, err := s.getLogsForFilters(ctx, filters, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Len(t, res["deposits"], 1)
+	require.Equal(t, uint64(0x39e9b3), res["deposits"][0].BlockNumber)
+	require.Len(t, res["other"], 0)
+	require.Equal(t, 2, stub.callCount("eth_getLogs"))
+}
+
+func TestGetLogsForFiltersDuplicateName(t *testing.T) {
+	ctx := context.Background()
+	stub := newRPCStub(t, testRPCResults)
+	s := newTestService(t, stub.server.URL)
+
+	filters := []*logFilter{
+		{name: "deposits", topics: [][]byte{depositEventTopic}},
+		{name: "deposits", topics: [][]byte{depositEventTopic}},
+	}
+	_, err := s.getLogsForFilters(ctx, filters, 0, 1)
+	require.EqualError(t, err, `duplicate filter name "deposits"`)
+	require.Equal(t, 0, stub.callCount("eth_getLogs"))
+}
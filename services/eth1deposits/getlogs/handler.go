@@ -15,20 +15,18 @@ package getlogs
 
 import (
 	"context"
-	"encoding/binary"
-	"fmt"
+	"math"
 	"time"
 
-	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
 )
 
 // handleBlocks handles a range of blocks.
 func (s *Service) handleBlocks(ctx context.Context, startBlock uint64, endBlock uint64) error {
-	logs, err := s.getLogs(ctx, startBlock, endBlock)
+	deposits, err := s.depositsForBlocks(ctx, startBlock, endBlock)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain logs")
+		return err
 	}
 
 	ctx, cancel, err := s.eth1DepositsSetter.(chaindb.Service).BeginTx(ctx)
@@ -36,32 +34,7 @@ func (s *Service) handleBlocks(ctx context.Context, startBlock uint64, endBlock
 		return errors.Wrap(err, "failed to begin transaction")
 	}
 
-	for _, logEntry := range logs {
-		if len(logEntry.Data) == 0 {
-			continue
-		}
-
-		tx, err := s.transactionByHash(ctx, logEntry.TransactionHash)
-		if err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to obtain transaction from transaction hash")
-		}
-		if tx == nil {
-			cancel()
-			return fmt.Errorf("no transaction returned for hash %#x", logEntry.TransactionHash)
-		}
-		receipt, err := s.transactionReceiptByHash(ctx, logEntry.TransactionHash)
-		if err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to obtain transaction receipt from transaction hash")
-		}
-
-		deposit, err := s.depositFromLogEntry(ctx, logEntry, tx, receipt)
-		if err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to obtain ETH1 deposit from log entry")
-		}
-
+	for _, deposit := range deposits {
 		if err := s.eth1DepositsSetter.SetETH1Deposit(ctx, deposit); err != nil {
 			cancel()
 			return errors.Wrap(err, "failed to set ETH1 deposit")
@@ -81,6 +54,87 @@ func (s *Service) handleBlocks(ctx context.Context, startBlock uint64, endBlock
 	return nil
 }
 
+// depositsForBlocks obtains the decoded deposits for a range of blocks,
+// using the deposit cache where possible.
+func (s *Service) depositsForBlocks(ctx context.Context, startBlock uint64, endBlock uint64) ([]*chaindb.ETH1Deposit, error) {
+	if deposits, exists := s.depositCache.get(startBlock, endBlock); exists {
+		return deposits, nil
+	}
+
+	logs, batched := s.logBatch.take(startBlock, endBlock)
+	if !batched {
+		logs, batched = s.logPrefetch.take(ctx, startBlock, endBlock)
+	}
+	if !batched {
+		var err error
+		logs, err = s.getLogs(ctx, startBlock, endBlock)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain logs")
+		}
+	}
+
+	txHashes := make([][]byte, 0, len(logs))
+	for _, logEntry := range logs {
+		if !logEntry.Removed && len(logEntry.Data) > 0 {
+			txHashes = append(txHashes, logEntry.TransactionHash)
+		}
+	}
+	txs, err := s.transactionsByHash(ctx, txHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := s.newDepositDecoder(len(logs))
+	removed := make([]*logResponse, 0)
+	for i, logEntry := range logs {
+		if logEntry.Removed {
+			// The block containing this log has been reorganised away, so any
+			// cached deposits or logs for it are no longer valid.
+			log.Debug().Uint64("block", logEntry.BlockNumber).Msg("Removed log; invalidating cached deposits")
+			s.depositCache.invalidate(logEntry.BlockNumber, logEntry.BlockNumber)
+			s.logCache.invalidate(logEntry.BlockNumber, logEntry.BlockNumber)
+			// The state of the deposit contract changes from the block onwards.
+			s.depositStates.invalidate(logEntry.BlockNumber, math.MaxUint64)
+			removed = append(removed, logEntry)
+			continue
+		}
+		if len(logEntry.Data) == 0 {
+			continue
+		}
+
+		var txHash [32]byte
+		copy(txHash[:], logEntry.TransactionHash)
+		receipt, err := s.transactionReceiptByHash(ctx, logEntry.TransactionHash)
+		if err != nil {
+			decoder.close()
+			return nil, errors.Wrap(err, "failed to obtain transaction receipt from transaction hash")
+		}
+		timestamp, err := s.blockHashToTime(ctx, logEntry.BlockHash)
+		if err != nil {
+			decoder.close()
+			return nil, errors.Wrap(err, "failed to obtain ETH1 deposit from log entry")
+		}
+
+		decoder.add(i, &depositSource{
+			logEntry:  logEntry,
+			tx:        txs[txHash],
+			receipt:   receipt,
+			timestamp: timestamp,
+		})
+	}
+	deposits := decoder.wait()
+	s.reportReorg(ctx, removed)
+	// Anomalies are checked once decoding is complete, so that they are reported in order.
+	for _, deposit := range deposits {
+		s.checkDepositAnomalies(deposit)
+		s.blockHashes.add(deposit.ETH1BlockNumber, deposit.ETH1BlockHash)
+	}
+
+	s.depositCache.set(startBlock, endBlock, deposits)
+
+	return deposits, nil
+}
+
 func (s *Service) handleMissed(ctx context.Context, md *metadata) {
 	failed := 0
 	for i := 0; i < len(md.MissedBlocks); i++ {
@@ -121,31 +175,6 @@ func (s *Service) handleMissed(ctx context.Context, md *metadata) {
 	}
 }
 
-func (s *Service) depositFromLogEntry(ctx context.Context, logEntry *logResponse, tx *transaction, receipt *transactionReceipt) (*chaindb.ETH1Deposit, error) {
-	deposit := &chaindb.ETH1Deposit{}
-	deposit.ETH1BlockHash = logEntry.BlockHash
-	deposit.ETH1BlockNumber = logEntry.BlockNumber
-	eth1BlockTimestamp, err := s.blockHashToTime(ctx, logEntry.BlockHash)
-	if err != nil {
-		return nil, err
-	}
-	deposit.ETH1BlockTimestamp = eth1BlockTimestamp
-	deposit.ETH1TxHash = logEntry.TransactionHash
-	deposit.ETH1LogIndex = logEntry.LogIndex
-	if receipt != nil {
-		deposit.ETH1Sender = receipt.From
-		deposit.ETH1Recipient = receipt.To
-		deposit.ETH1GasUsed = receipt.GasUsed
-	}
-	deposit.ETH1GasPrice = tx.GasPrice
-	deposit.DepositIndex = binary.LittleEndian.Uint64(logEntry.Data[544:552])
-	copy(deposit.ValidatorPubKey[:], logEntry.Data[192:240])
-	deposit.WithdrawalCredentials = logEntry.Data[288:320]
-	copy(deposit.Signature[:], logEntry.Data[416:512])
-	deposit.Amount = phase0.Gwei(binary.LittleEndian.Uint64(logEntry.Data[352:360]))
-	return deposit, nil
-}
-
 func (s *Service) blockHashToTime(ctx context.Context, blockHash []byte) (time.Time, error) {
 	var hash [32]byte
 	copy(hash[:], blockHash)
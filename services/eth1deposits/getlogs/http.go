@@ -16,26 +16,34 @@ package getlogs
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
-	"math/rand"
+	mrand "math/rand"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
 )
 
 // post sends an HTTP post request and returns the body.
+// Requests that fail with a transport error or a retryable status are retried
+// up to the configured number of times.  Each attempt is subject to the global
+// rate limit, if configured.  If an idempotency header is
+// configured, all attempts of the request carry the same key.
+// If discovery is configured and the request fails persistently, the
+// request is tried again against the client found by discovery.
 func (s *Service) post(ctx context.Context, endpoint string, body io.Reader) (io.Reader, error) {
 	// #nosec G404
-	log := log.With().Str("id", fmt.Sprintf("%02x", rand.Int31())).Logger()
+	log := log.With().Str("id", fmt.Sprintf("%02x", mrand.Int31())).Logger()
+	bodyBytes, err := io.ReadAll(body)
+	if err != nil {
+		return nil, errors.New("failed to read request body")
+	}
 	if e := log.Trace(); e.Enabled() {
-		bodyBytes, err := io.ReadAll(body)
-		if err != nil {
-			return nil, errors.New("failed to read request body")
-		}
-		body = bytes.NewReader(bodyBytes)
-
 		e.Str("endpoint", endpoint).Str("body", string(bodyBytes)).Msg("POST request")
 	}
 
@@ -43,38 +51,122 @@ func (s *Service) post(ctx context.Context, endpoint string, body io.Reader) (io
 	if err != nil {
 		return nil, errors.Wrap(err, "invalid endpoint")
 	}
-	url := s.base.ResolveReference(reference).String()
 
+	idempotencyKey := ""
+	if s.idempotencyHeader != "" {
+		idempotencyKey, err = newIdempotencyKey()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	base := s.endpointBase()
+	res, err := s.postWithRetries(ctx, log, base.ResolveReference(reference), bodyBytes, idempotencyKey)
+	if err == nil {
+		s.recordRequestSuccess()
+		return res, nil
+	}
+	if ctx.Err() != nil || !s.rediscover(ctx, base) {
+		return nil, err
+	}
+
+	log.Trace().Err(err).Msg("POST failed; trying discovered endpoint")
+	res, err = s.postWithRetries(ctx, log, s.endpointBase().ResolveReference(reference), bodyBytes, idempotencyKey)
+	if err == nil {
+		s.recordRequestSuccess()
+	}
+
+	return res, err
+}
+
+// postWithRetries sends an HTTP post request to the given URL, retrying as configured.
+func (s *Service) postWithRetries(ctx context.Context,
+	log zerolog.Logger,
+	resolved *url.URL,
+	bodyBytes []byte,
+	idempotencyKey string,
+) (
+	io.Reader,
+	error,
+) {
+	url := resolved.String()
+	redactedURL := resolved.Redacted()
+
+	for attempt := 0; ; attempt++ {
+		if _, err := s.rateLimiter.wait(ctx); err != nil {
+			return nil, errors.Wrap(err, "context done whilst waiting for rate limit")
+		}
+		data, retryable, err := s.postOnce(ctx, url, bodyBytes, idempotencyKey)
+		if err == nil {
+			s.endpoints.recordSuccess(redactedURL)
+			log.Trace().Str("response", string(data)).Msg("POST response")
+			return bytes.NewReader(data), nil
+		}
+		s.endpoints.recordError(redactedURL, err)
+		if !retryable || attempt >= s.requestRetries {
+			return nil, err
+		}
+		log.Trace().Int("attempt", attempt+1).Err(err).Msg("POST failed; retrying")
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "context done whilst waiting to retry")
+		case <-time.After(s.retryBackoff * time.Duration(attempt+1)):
+		}
+	}
+}
+
+// postOnce sends a single HTTP post request and returns the body.
+// It also returns true if a failed request can be retried.
+func (s *Service) postOnce(ctx context.Context, url string, body []byte, idempotencyKey string) ([]byte, bool, error) {
 	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
-	req, err := http.NewRequestWithContext(opCtx, http.MethodPost, url, body)
+	defer cancel()
+	req, err := http.NewRequestWithContext(opCtx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		cancel()
-		return nil, errors.Wrap(err, "failed to create POST request")
+		return nil, false, errors.Wrap(err, "failed to create POST request")
 	}
 	req.Header.Set("Content-type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set(s.idempotencyHeader, idempotencyKey)
+	}
 	resp, err := s.client.Do(req)
 	if err != nil {
-		cancel()
-		return nil, errors.Wrap(err, "failed to call POST endpoint")
+		return nil, true, errors.Wrap(err, "failed to call POST endpoint")
 	}
 	// skipcq:GO-S2307
 	defer resp.Body.Close()
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		cancel()
-		return nil, errors.Wrap(err, "failed to read POST response")
+		return nil, true, errors.Wrap(err, "failed to read POST response")
 	}
 
 	statusFamily := resp.StatusCode / 100
 	if statusFamily != 2 {
-		cancel()
-		return nil, fmt.Errorf("POST failed with status %d: %s", resp.StatusCode, string(data))
+		retryable := statusFamily == 5 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retryable, &httpStatusError{statusCode: resp.StatusCode, body: string(data)}
 	}
-	cancel()
 
-	log.Trace().Str("response", string(data)).Msg("POST response")
+	return data, false, nil
+}
+
+// httpStatusError is returned when a request fails with a non-2xx status.
+type httpStatusError struct {
+	statusCode int
+	body       string
+}
+
+// Error implements the error interface.
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("POST failed with status %d: %s", e.statusCode, e.body)
+}
+
+// newIdempotencyKey creates a random key for a logical request.
+func newIdempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", errors.Wrap(err, "failed to generate idempotency key")
+	}
 
-	return bytes.NewReader(data), nil
+	return hex.EncodeToString(key), nil
 }
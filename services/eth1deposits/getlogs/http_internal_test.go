@@ -0,0 +1,136 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// keyRecorder is a server that records idempotency keys, failing the first
+// attempts of each request.
+type keyRecorder struct {
+	mu       sync.Mutex
+	keys     []string
+	failures int
+	failed   int
+}
+
+func (r *keyRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys = append(r.keys, req.Header.Get("Idempotency-Key"))
+	if r.failed < r.failures {
+		r.failed++
+		http.Error(w, "busy", http.StatusTooManyRequests)
+		return
+	}
+	r.failed = 0
+	fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x10"}`)
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+
+	recorder := &keyRecorder{failures: 2}
+	server := httptest.NewServer(recorder)
+	t.Cleanup(server.Close)
+	s := newTestService(t, server.URL)
+	s.idempotencyHeader = "Idempotency-Key"
+	s.requestRetries = 2
+	s.retryBackoff = time.Millisecond
+
+	// First logical request is retried twice, with the same key each time.
+	blockNumber, err := s.blockNumber(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0x10), blockNumber)
+	require.Len(t, recorder.keys, 3)
+	require.NotEmpty(t, recorder.keys[0])
+	require.Equal(t, recorder.keys[0], recorder.keys[1])
+	require.Equal(t, recorder.keys[0], recorder.keys[2])
+
+	// Second logical request has a different key.
+	_, err = s.blockNumber(ctx)
+	require.NoError(t, err)
+	require.Len(t, recorder.keys, 6)
+	require.NotEqual(t, recorder.keys[0], recorder.keys[3])
+	require.Equal(t, recorder.keys[3], recorder.keys[5])
+
+	// Retries are limited.
+	recorder.failures = 3
+	_, err = s.blockNumber(ctx)
+	require.Error(t, err)
+	require.Len(t, recorder.keys, 9)
+
+	// No header if not configured.
+	recorder.failures = 0
+	s.idempotencyHeader = ""
+	_, err = s.blockNumber(ctx)
+	require.NoError(t, err)
+	require.Empty(t, recorder.keys[len(recorder.keys)-1])
+}
+
+func TestEndpointStatus(t *testing.T) {
+	ctx := context.Background()
+
+	recorder := &keyRecorder{failures: 1}
+	server := httptest.NewServer(recorder)
+	t.Cleanup(server.Close)
+	s := newTestService(t, server.URL)
+	s.retryBackoff = time.Millisecond
+	require.Empty(t, s.EndpointStatus())
+
+	// Failing endpoint has its error recorded.
+	_, err := s.blockNumber(ctx)
+	require.Error(t, err)
+	status := s.EndpointStatus()
+	require.Len(t, status, 1)
+	require.Equal(t, server.URL, status[0].URL)
+	require.False(t, status[0].Healthy)
+	require.Contains(t, status[0].LastError, "POST failed with status 429")
+	require.False(t, status[0].LastErrorTime.IsZero())
+	require.True(t, status[0].LastSuccess.IsZero())
+
+	// Endpoint recovers, retaining its last error.
+	_, err = s.blockNumber(ctx)
+	require.NoError(t, err)
+	status = s.EndpointStatus()
+	require.Len(t, status, 1)
+	require.True(t, status[0].Healthy)
+	require.Contains(t, status[0].LastError, "POST failed with status 429")
+	require.False(t, status[0].LastSuccess.IsZero())
+}
+
+func TestEndpointStatusRedacted(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(&keyRecorder{})
+	t.Cleanup(server.Close)
+	s := newTestService(t, strings.Replace(server.URL, "http://", "http://user:secret@", 1))
+
+	_, err := s.blockNumber(ctx)
+	require.NoError(t, err)
+	status := s.EndpointStatus()
+	require.Len(t, status, 1)
+	require.NotContains(t, status[0].URL, "secret")
+}
@@ -0,0 +1,115 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"time"
+)
+
+// logCacheKey is the key for a cached log response.
+type logCacheKey struct {
+	blockRange
+	filterHash [32]byte
+}
+
+// logCacheEntry is a cached log response.
+type logCacheEntry struct {
+	raw     json.RawMessage
+	expires time.Time
+}
+
+// logCache is a least-recently-used cache of raw eth_getLogs responses keyed by
+// block range and filter.  Unlike the deposit cache it holds responses for any filter.
+// A nil cache is valid, and caches nothing.
+type logCache struct {
+	ttl     time.Duration
+	entries *lru[logCacheKey, *logCacheEntry]
+}
+
+// newLogCache creates a new log cache holding up to size responses, each for up to ttl.
+// If size is 0 no cache is created.
+func newLogCache(size int, ttl time.Duration) *logCache {
+	if size <= 0 {
+		return nil
+	}
+
+	return &logCache{
+		ttl:     ttl,
+		entries: newLRU[logCacheKey, *logCacheEntry](size),
+	}
+}
+
+// filterHash returns a hash identifying the logs matched by the filter.
+func (f *logFilter) filterHash() [32]byte {
+	params := f.params(0, 0)
+	params.FromBlock = ""
+	params.ToBlock = ""
+	// Marshalling the parameters cannot fail.
+	data, _ := json.Marshal(params)
+
+	return sha256.Sum256(data)
+}
+
+// get returns the cached response for the filter over the given range, if present.
+func (c *logCache) get(filter *logFilter, startBlock uint64, endBlock uint64) (json.RawMessage, bool) {
+	if c == nil {
+		return nil, false
+	}
+	key := logCacheKey{
+		blockRange: blockRange{startBlock: startBlock, endBlock: endBlock},
+		filterHash: filter.filterHash(),
+	}
+
+	entry, exists := c.entries.get(key)
+	if exists && time.Now().After(entry.expires) {
+		c.entries.remove(key)
+		exists = false
+	}
+	if !exists {
+		monitorLogCacheMiss()
+		return nil, false
+	}
+	monitorLogCacheHit()
+
+	return entry.raw, true
+}
+
+// set caches the response for the filter over the given range.
+func (c *logCache) set(filter *logFilter, startBlock uint64, endBlock uint64, raw json.RawMessage) {
+	if c == nil {
+		return
+	}
+	key := logCacheKey{
+		blockRange: blockRange{startBlock: startBlock, endBlock: endBlock},
+		filterHash: filter.filterHash(),
+	}
+
+	c.entries.set(key, &logCacheEntry{
+		raw:     raw,
+		expires: time.Now().Add(c.ttl),
+	})
+}
+
+// invalidate removes all cached responses, for any filter, whose ranges overlap the given range.
+func (c *logCache) invalidate(startBlock uint64, endBlock uint64) {
+	if c == nil {
+		return
+	}
+
+	c.entries.removeIf(func(key logCacheKey, _ *logCacheEntry) bool {
+		return key.overlaps(startBlock, endBlock)
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogCache(t *testing.T) {
+	ctx := context.Background()
+
+	stub := newRPCStub(t, testRPCResults)
+	s := newTestService(t, stub.server.URL)
+	s.logCache = newLogCache(4, time.Hour)
+
+	logs, err := s.getLogs(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, 1, stub.callCount("eth_getLogs"))
+
+	// Second identical query should be served from the cache.
+	cachedLogs, err := s.getLogs(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Equal(t, logs, cachedLogs)
+	require.Equal(t, 1, stub.callCount("eth_getLogs"))
+
+	// A different filter over the same range should not be served from the cache.
+	_, err = s.getFilteredLogs(ctx, &logFilter{
+		name:      "other",
+		addresses: [][]byte{s.depositContractAddress},
+		topics:    [][]byte{{0x01}},
+	}, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Equal(t, 2, stub.callCount("eth_getLogs"))
+
+	// Invalidating an overlapping range should force a refetch.
+	s.logCache.invalidate(0x39e9bf, 0x39e9c0)
+	_, err = s.getLogs(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Equal(t, 3, stub.callCount("eth_getLogs"))
+}
+
+func TestLogCacheExpiry(t *testing.T) {
+	filter := &logFilter{name: "test"}
+	cache := newLogCache(2, time.Millisecond)
+
+	cache.set(filter, 1, 10, []byte("[]"))
+	_, exists := cache.get(filter, 1, 10)
+	require.True(t, exists)
+
+	time.Sleep(5 * time.Millisecond)
+	_, exists = cache.get(filter, 1, 10)
+	require.False(t, exists)
+}
+
+func TestLogCacheEviction(t *testing.T) {
+	filter := &logFilter{name: "test"}
+	cache := newLogCache(2, time.Hour)
+
+	cache.set(filter, 1, 10, []byte("[]"))
+	cache.set(filter, 11, 20, []byte("[]"))
+	_, exists := cache.get(filter, 1, 10)
+	require.True(t, exists)
+
+	// Adding a third range should evict the least recently used, 11-20.
+	cache.set(filter, 21, 30, []byte("[]"))
+	_, exists = cache.get(filter, 11, 20)
+	require.False(t, exists)
+	_, exists = cache.get(filter, 1, 10)
+	require.True(t, exists)
+	_, exists = cache.get(filter, 21, 30)
+	require.True(t, exists)
+}
+
+func TestLogCacheDisabled(t *testing.T) {
+	filter := &logFilter{name: "test"}
+	cache := newLogCache(0, time.Hour)
+	require.Nil(t, cache)
+
+	// A nil cache should be usable, and hold nothing.
+	cache.set(filter, 1, 10, []byte("[]"))
+	_, exists := cache.get(filter, 1, 10)
+	require.False(t, exists)
+	cache.invalidate(1, 10)
+}
@@ -0,0 +1,412 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A log dump is a newline-delimited JSON file holding the logs for a range of
+// blocks, along with the data required to index them, so that the service can
+// run without an Ethereum 1 client.  The first line is a header:
+//
+//	{"chainId":5,"startBlock":3795379,"endBlock":3795392}
+//
+// and each following line is a log, with its transaction, receipt and block
+// as returned by the client:
+//
+//	{"log":{...},"transaction":{...},"receipt":{...},"block":{"hash":...,"number":...,"timestamp":...}}
+
+// logDumpHeader is the first line of a log dump.
+type logDumpHeader struct {
+	ChainID    uint64 `json:"chainId"`
+	StartBlock uint64 `json:"startBlock"`
+	EndBlock   uint64 `json:"endBlock"`
+}
+
+// logDumpEntry is a log in a log dump.
+type logDumpEntry struct {
+	Log         json.RawMessage `json:"log"`
+	Transaction json.RawMessage `json:"transaction"`
+	Receipt     json.RawMessage `json:"receipt"`
+	Block       *logDumpBlock   `json:"block"`
+}
+
+// logDumpBlock is the part of a block held in a log dump.
+type logDumpBlock struct {
+	Hash      string `json:"hash"`
+	Number    string `json:"number"`
+	Timestamp string `json:"timestamp"`
+}
+
+// logDump is a log dump loaded for serving.
+type logDump struct {
+	header  *logDumpHeader
+	entries []*logDumpEntry
+	// logs are the decoded logs, in the order of entries.
+	logs []*logResponse
+	// Lookups by lower-case hex hash, and by block number.
+	transactions map[string]json.RawMessage
+	receipts     map[string]json.RawMessage
+	blocks       map[string]*logDumpBlock
+	blockNumbers map[uint64]*logDumpBlock
+}
+
+// maxLogDumpLineSize is the maximum size of a single line of a log dump.
+const maxLogDumpLineSize = 16 * 1024 * 1024
+
+// loadLogDump loads a log dump from a file.
+func loadLogDump(path string) (*logDump, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open log dump")
+	}
+	defer f.Close()
+
+	return readLogDump(f)
+}
+
+// readLogDump reads a log dump.
+func readLogDump(r io.Reader) (*logDump, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogDumpLineSize)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to read log dump header")
+		}
+		return nil, errors.New("log dump is empty")
+	}
+	header := &logDumpHeader{}
+	if err := json.Unmarshal(scanner.Bytes(), header); err != nil {
+		return nil, errors.Wrap(err, "invalid log dump header")
+	}
+	if header.EndBlock < header.StartBlock {
+		return nil, errors.New("log dump end block before start block")
+	}
+
+	dump := &logDump{
+		header:       header,
+		entries:      make([]*logDumpEntry, 0),
+		logs:         make([]*logResponse, 0),
+		transactions: make(map[string]json.RawMessage),
+		receipts:     make(map[string]json.RawMessage),
+		blocks:       make(map[string]*logDumpBlock),
+		blockNumbers: make(map[uint64]*logDumpBlock),
+	}
+	for line := 2; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		entry := &logDumpEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			return nil, errors.Wrapf(err, "invalid log dump entry at line %d", line)
+		}
+		logEntry := &logResponse{}
+		if err := json.Unmarshal(entry.Log, logEntry); err != nil {
+			return nil, errors.Wrapf(err, "invalid log at line %d", line)
+		}
+		if logEntry.BlockNumber < header.StartBlock || logEntry.BlockNumber > header.EndBlock {
+			return nil, fmt.Errorf("log at line %d for block %d outside of dump range", line, logEntry.BlockNumber)
+		}
+		dump.entries = append(dump.entries, entry)
+		dump.logs = append(dump.logs, logEntry)
+
+		txHash := fmt.Sprintf("%#x", logEntry.TransactionHash)
+		if len(entry.Transaction) > 0 {
+			dump.transactions[txHash] = entry.Transaction
+		}
+		if len(entry.Receipt) > 0 {
+			dump.receipts[txHash] = entry.Receipt
+		}
+		if entry.Block != nil {
+			dump.blocks[strings.ToLower(entry.Block.Hash)] = entry.Block
+			dump.blockNumbers[logEntry.BlockNumber] = entry.Block
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read log dump")
+	}
+
+	return dump, nil
+}
+
+// logDumpTransport is an HTTP transport that answers the JSON-RPC requests of the
+// service from a log dump, in place of an Ethereum 1 client.
+type logDumpTransport struct {
+	dump *logDump
+	// head is the block number reported as the head of the chain.
+	head uint64
+}
+
+// newLogDumpTransport creates a transport serving the given log dump.  The head
+// of the chain is reported such that all blocks in the dump are confirmed.
+func newLogDumpTransport(dump *logDump, confirmations uint64) *logDumpTransport {
+	return &logDumpTransport{
+		dump: dump,
+		head: dump.header.EndBlock + confirmations,
+	}
+}
+
+type logDumpRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type logDumpResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *logDumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read request")
+	}
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	var respBody []byte
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var requests []*logDumpRequest
+		if err := json.Unmarshal(trimmed, &requests); err != nil {
+			return nil, errors.Wrap(err, "invalid batch request")
+		}
+		responses := make([]*logDumpResponse, len(requests))
+		for i := range requests {
+			responses[i] = t.respond(requests[i])
+		}
+		respBody, err = json.Marshal(responses)
+	} else {
+		request := &logDumpRequest{}
+		if err := json.Unmarshal(trimmed, request); err != nil {
+			return nil, errors.Wrap(err, "invalid request")
+		}
+		respBody, err = json.Marshal(t.respond(request))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create response")
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// respond returns the response to a single JSON-RPC request.
+func (t *logDumpTransport) respond(req *logDumpRequest) *logDumpResponse {
+	res := &logDumpResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+	}
+
+	var result any
+	var err error
+	switch req.Method {
+	case "eth_chainId":
+		result = fmt.Sprintf("%#x", t.dump.header.ChainID)
+	case "eth_blockNumber":
+		result = fmt.Sprintf("%#x", t.head)
+	case "eth_syncing":
+		result = false
+	case "eth_getBalance":
+		// The dump holds no state; report a balance so the client appears to be an archive node.
+		result = "0x0"
+	case "web3_clientVersion":
+		result = "chaind/logdump"
+	case "eth_getLogs":
+		result, err = t.logs(req.Params)
+	case "eth_getTransactionByHash":
+		result, err = t.byHash(req.Params, t.dump.transactions)
+	case "eth_getTransactionReceipt":
+		result, err = t.byHash(req.Params, t.dump.receipts)
+	case "eth_getBlockByHash":
+		result, err = t.blockByHash(req.Params)
+	case "eth_getBlockByNumber":
+		result, err = t.blockByNumber(req.Params)
+	default:
+		res.Error = &rpcError{Code: -32601, Message: fmt.Sprintf("method %s not available from log dump", req.Method)}
+		return res
+	}
+	if err != nil {
+		res.Error = &rpcError{Code: -32602, Message: err.Error()}
+		return res
+	}
+
+	res.Result, err = json.Marshal(result)
+	if err != nil {
+		res.Error = &rpcError{Code: -32603, Message: err.Error()}
+	}
+
+	return res
+}
+
+// logs returns the logs matching eth_getLogs parameters.
+func (t *logDumpTransport) logs(params []json.RawMessage) ([]json.RawMessage, error) {
+	if len(params) != 1 {
+		return nil, errors.New("expected one parameter")
+	}
+	filter := &getLogsParams{}
+	if err := json.Unmarshal(params[0], filter); err != nil {
+		return nil, errors.Wrap(err, "invalid filter")
+	}
+	fromBlock, err := parseDumpQuantity(filter.FromBlock)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid from block")
+	}
+	toBlock, err := parseDumpQuantity(filter.ToBlock)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid to block")
+	}
+	if fromBlock < t.dump.header.StartBlock || toBlock > t.dump.header.EndBlock {
+		return nil, fmt.Errorf("block range %d-%d outside of log dump range %d-%d", fromBlock, toBlock, t.dump.header.StartBlock, t.dump.header.EndBlock)
+	}
+
+	addresses := make(map[string]bool, len(filter.Address))
+	for _, address := range filter.Address {
+		addresses[strings.ToLower(address)] = true
+	}
+	topics, err := firstTopics(filter.Topics)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]json.RawMessage, 0)
+	for i, logEntry := range t.dump.logs {
+		if logEntry.BlockNumber < fromBlock || logEntry.BlockNumber > toBlock {
+			continue
+		}
+		if len(addresses) > 0 && !addresses[fmt.Sprintf("%#x", logEntry.Address)] {
+			continue
+		}
+		if len(topics) > 0 && (len(logEntry.Topics) == 0 || !topics[fmt.Sprintf("%#x", logEntry.Topics[0])]) {
+			continue
+		}
+		res = append(res, t.dump.entries[i].Log)
+	}
+
+	return res, nil
+}
+
+// firstTopics returns the alternative values for the first topic of a filter.
+// No values means any first topic matches.
+func firstTopics(topics []any) (map[string]bool, error) {
+	res := make(map[string]bool)
+	if len(topics) == 0 || topics[0] == nil {
+		return res, nil
+	}
+	switch first := topics[0].(type) {
+	case string:
+		res[strings.ToLower(first)] = true
+	case []any:
+		for _, topic := range first {
+			topicStr, ok := topic.(string)
+			if !ok {
+				return nil, errors.New("invalid topic")
+			}
+			res[strings.ToLower(topicStr)] = true
+		}
+	default:
+		return nil, errors.New("invalid topics")
+	}
+
+	return res, nil
+}
+
+// byHash returns the item with the hash given in the first parameter, or null if not present.
+func (*logDumpTransport) byHash(params []json.RawMessage, items map[string]json.RawMessage) (json.RawMessage, error) {
+	hash, err := hashParam(params)
+	if err != nil {
+		return nil, err
+	}
+	item, exists := items[hash]
+	if !exists {
+		return json.RawMessage("null"), nil
+	}
+
+	return item, nil
+}
+
+// blockByHash returns the block with the hash given in the first parameter, or nil if not present.
+func (t *logDumpTransport) blockByHash(params []json.RawMessage) (*logDumpBlock, error) {
+	hash, err := hashParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.dump.blocks[hash], nil
+}
+
+// blockByNumber returns the block with the number given in the first parameter, or nil if not present.
+// Only blocks containing logs are present in the dump.
+func (t *logDumpTransport) blockByNumber(params []json.RawMessage) (*logDumpBlock, error) {
+	if len(params) == 0 {
+		return nil, errors.New("no block number")
+	}
+	var number string
+	if err := json.Unmarshal(params[0], &number); err != nil {
+		return nil, errors.Wrap(err, "invalid block number")
+	}
+	blockNumber, err := parseDumpQuantity(number)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid block number")
+	}
+
+	return t.dump.blockNumbers[blockNumber], nil
+}
+
+// hashParam returns the hash in the first parameter, in lower-case hex.
+func hashParam(params []json.RawMessage) (string, error) {
+	if len(params) == 0 {
+		return "", errors.New("no hash")
+	}
+	var hash string
+	if err := json.Unmarshal(params[0], &hash); err != nil {
+		return "", errors.Wrap(err, "invalid hash")
+	}
+	if _, err := hex.DecodeString(strings.TrimPrefix(hash, "0x")); err != nil {
+		return "", errors.Wrap(err, "invalid hash")
+	}
+
+	return strings.ToLower(hash), nil
+}
+
+// parseDumpQuantity parses a hex quantity.
+func parseDumpQuantity(input string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(input, "0x"), 16, 64)
+}
@@ -0,0 +1,95 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogDumpRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	stub := newRPCStub(t, testRPCResults)
+	s := newTestService(t, stub.server.URL)
+	expected, err := s.depositsForBlocks(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Len(t, expected, 1)
+
+	// Export the range to a file.
+	var buf bytes.Buffer
+	require.NoError(t, s.exportLogDump(ctx, 0x39e9b0, 0x39e9bf, &buf))
+	path := filepath.Join(t.TempDir(), "logs.ndjson")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+	// Serve the same range from the file, without the client.
+	dump, err := loadLogDump(path)
+	require.NoError(t, err)
+	require.Equal(t, &logDumpHeader{ChainID: 5, StartBlock: 0x39e9b0, EndBlock: 0x39e9bf}, dump.header)
+	require.Len(t, dump.logs, 1)
+
+	offline := newTestService(t, "http://logdump")
+	offline.client = &http.Client{Transport: newLogDumpTransport(dump, 12)}
+	deposits, err := offline.depositsForBlocks(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Equal(t, expected, deposits)
+
+	// The requested block range is honoured.
+	deposits, err = offline.depositsForBlocks(ctx, 0x39e9b4, 0x39e9bf)
+	require.NoError(t, err)
+	require.Empty(t, deposits)
+	_, err = offline.depositsForBlocks(ctx, 0x39e9b0, 0x39e9c0)
+	require.ErrorContains(t, err, "outside of log dump range")
+
+	// The head is reported such that the whole dump is confirmed.
+	head, err := offline.blockNumber(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0x39e9bf+12), head)
+}
+
+func TestLogDumpInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		dump string
+		err  string
+	}{
+		{
+			name: "Empty",
+			err:  "log dump is empty",
+		},
+		{
+			name: "BadRange",
+			dump: `{"chainId":5,"startBlock":10,"endBlock":9}`,
+			err:  "log dump end block before start block",
+		},
+		{
+			name: "LogOutsideRange",
+			dump: `{"chainId":5,"startBlock":1,"endBlock":2}` + "\n" + `{"log":` + testDepositLog + `}`,
+			err:  "log at line 2 for block 3795379 outside of dump range",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := readLogDump(bytes.NewReader([]byte(test.dump)))
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ExportLogDump writes a log dump of the deposit logs for blocks startBlock to
+// endBlock inclusive, fetched from the Ethereum 1 client at the given address, in
+// the format served by WithLogDumpFile.
+func ExportLogDump(ctx context.Context,
+	connectionURL string,
+	depositContractAddress []byte,
+	startBlock uint64,
+	endBlock uint64,
+	w io.Writer,
+) error {
+	if endBlock < startBlock {
+		return errors.New("end block before start block")
+	}
+	base, err := parseConnectionURL(connectionURL)
+	if err != nil {
+		return err
+	}
+
+	s := &Service{
+		timeout:                30 * time.Second,
+		base:                   base,
+		client:                 &http.Client{},
+		blocksPerRequest:       64,
+		depositContractAddress: depositContractAddress,
+	}
+
+	return s.exportLogDump(ctx, startBlock, endBlock, w)
+}
+
+// exportLogDump writes a log dump of the deposit logs for a range of blocks.
+func (s *Service) exportLogDump(ctx context.Context, startBlock uint64, endBlock uint64, w io.Writer) error {
+	chainID, err := s.chainID(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain chain ID")
+	}
+
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	if err := encoder.Encode(&logDumpHeader{
+		ChainID:    chainID,
+		StartBlock: startBlock,
+		EndBlock:   endBlock,
+	}); err != nil {
+		return errors.Wrap(err, "failed to write header")
+	}
+
+	filter := s.depositsFilter()
+	entries := 0
+	for from := startBlock; from <= endBlock; from += s.blocksPerRequest {
+		to := from + s.blocksPerRequest - 1
+		if to > endBlock {
+			to = endBlock
+		}
+		logs, err := call[[]json.RawMessage](ctx, s, "eth_getLogs", []interface{}{filter.params(from, to)})
+		if err != nil {
+			return errors.Wrapf(err, "failed to obtain logs for blocks %d-%d", from, to)
+		}
+		for _, rawLog := range logs {
+			entry, err := s.logDumpEntry(ctx, rawLog)
+			if err != nil {
+				return err
+			}
+			if err := encoder.Encode(entry); err != nil {
+				return errors.Wrap(err, "failed to write entry")
+			}
+			entries++
+		}
+		log.Trace().Uint64("from", from).Uint64("to", to).Int("logs", len(logs)).Msg("Exported logs")
+	}
+
+	if err := bw.Flush(); err != nil {
+		return errors.Wrap(err, "failed to write log dump")
+	}
+	log.Debug().Uint64("start_block", startBlock).Uint64("end_block", endBlock).Int("logs", entries).Msg("Exported log dump")
+
+	return nil
+}
+
+// logDumpEntry fetches the data required to index a log, returning it as a log dump entry.
+func (s *Service) logDumpEntry(ctx context.Context, rawLog json.RawMessage) (*logDumpEntry, error) {
+	logEntry := &logResponse{}
+	if err := json.Unmarshal(rawLog, logEntry); err != nil {
+		return nil, errors.Wrap(err, "invalid log")
+	}
+	entry := &logDumpEntry{
+		Log: rawLog,
+	}
+	if logEntry.Removed {
+		return entry, nil
+	}
+
+	txHash := fmt.Sprintf("%#x", logEntry.TransactionHash)
+	var err error
+	entry.Transaction, err = call[json.RawMessage](ctx, s, "eth_getTransactionByHash", []interface{}{txHash})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to obtain transaction %s", txHash)
+	}
+	entry.Receipt, err = call[json.RawMessage](ctx, s, "eth_getTransactionReceipt", []interface{}{txHash})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to obtain receipt for transaction %s", txHash)
+	}
+	blockHash := fmt.Sprintf("%#x", logEntry.BlockHash)
+	block, err := call[*logDumpBlock](ctx, s, "eth_getBlockByHash", []interface{}{blockHash, false})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to obtain block %s", blockHash)
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %s not found", blockHash)
+	}
+	// Not all clients return the hash and number when asked for them by hash.
+	block.Hash = blockHash
+	block.Number = fmt.Sprintf("%#x", logEntry.BlockNumber)
+	entry.Block = block
+
+	return entry, nil
+}
@@ -0,0 +1,78 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Log is a log returned by the Ethereum 1 client.
+type Log = logResponse
+
+// LogProcessor processes the logs fetched for a range of blocks before they are
+// used.  It can transform the logs, for example to remove duplicates, or validate
+// them, returning an error to fail the fetch.
+type LogProcessor func(logs []*Log) ([]*Log, error)
+
+// processLogs drops logs from addresses that are not allowed, then applies the
+// service's log processors to the remaining logs in order.
+func (s *Service) processLogs(logs []*logResponse) ([]*logResponse, error) {
+	logs = s.dropDisallowedLogs(logs)
+	for i, processor := range s.logProcessors {
+		var err error
+		logs, err = processor(logs)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("log processor %d failed", i))
+		}
+	}
+
+	return logs, nil
+}
+
+// DedupeLogs is a log processor that removes duplicate logs, identified by their
+// block hash and log index, keeping the first of each.
+func DedupeLogs(logs []*Log) ([]*Log, error) {
+	type logKey struct {
+		blockHash string
+		logIndex  uint64
+	}
+	seen := make(map[logKey]struct{}, len(logs))
+	res := make([]*Log, 0, len(logs))
+	for _, entry := range logs {
+		key := logKey{blockHash: string(entry.BlockHash), logIndex: entry.LogIndex}
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		res = append(res, entry)
+	}
+
+	return res, nil
+}
+
+// SortLogs is a log processor that sorts logs by block number and log index.
+// Logs with the same block number and log index keep their relative order.
+func SortLogs(logs []*Log) ([]*Log, error) {
+	sort.SliceStable(logs, func(i int, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].LogIndex < logs[j].LogIndex
+	})
+
+	return logs, nil
+}
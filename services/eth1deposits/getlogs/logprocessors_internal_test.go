@@ -0,0 +1,102 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testLog returns a deposit log for the given block and log index.
+func testLog(block uint64, logIndex uint64) string {
+	res := strings.Replace(testDepositLog, `"blockNumber":"0x39e9b3"`, `"blockNumber":"0x`+strconv.FormatUint(block, 16)+`"`, 1)
+	res = strings.Replace(res, `"blockHash":"0xfa3a6f5e2f5781bbdd4c68aa6ddd9ac3de8523188a9f8a71451007ad7f2c33c4"`, `"blockHash":"0x`+strings.Repeat(strconv.FormatUint(block%10, 10), 64)+`"`, 1)
+	return strings.Replace(res, `"logIndex":"0x0"`, `"logIndex":"0x`+strconv.FormatUint(logIndex, 16)+`"`, 1)
+}
+
+func TestLogProcessors(t *testing.T) {
+	ctx := context.Background()
+
+	// Out of order, with a duplicate as returned by some clients across a reorg boundary.
+	logs := `[` + strings.Join([]string{
+		testLog(3, 1),
+		testLog(2, 4),
+		testLog(3, 0),
+		testLog(2, 4),
+		testLog(1, 7),
+	}, ",") + `]`
+
+	failing := func(logs []*Log) ([]*Log, error) {
+		return nil, errors.New("bad logs")
+	}
+
+	tests := []struct {
+		name       string
+		processors []LogProcessor
+		blocks     []uint64
+		indices    []uint64
+		err        string
+	}{
+		{
+			name:    "None",
+			blocks:  []uint64{3, 2, 3, 2, 1},
+			indices: []uint64{1, 4, 0, 4, 7},
+		},
+		{
+			name:       "Dedupe",
+			processors: []LogProcessor{DedupeLogs},
+			blocks:     []uint64{3, 2, 3, 1},
+			indices:    []uint64{1, 4, 0, 7},
+		},
+		{
+			name:       "DedupeAndSort",
+			processors: []LogProcessor{DedupeLogs, SortLogs},
+			blocks:     []uint64{1, 2, 3, 3},
+			indices:    []uint64{7, 4, 0, 1},
+		},
+		{
+			name:       "Error",
+			processors: []LogProcessor{DedupeLogs, failing, SortLogs},
+			err:        "log processor 1 failed: bad logs",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := newRPCStub(t, map[string]string{"eth_getLogs": logs})
+			s := newTestService(t, stub.server.URL)
+			s.logProcessors = test.processors
+
+			res, err := s.getLogs(ctx, 0, 10)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			blocks := make([]uint64, len(res))
+			indices := make([]uint64, len(res))
+			for i := range res {
+				blocks[i] = res[i].BlockNumber
+				indices[i] = res[i].LogIndex
+			}
+			require.Equal(t, test.blocks, blocks)
+			require.Equal(t, test.indices, indices)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruEntry is an entry in a least-recently-used cache.
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// lru is a least-recently-used cache holding up to a fixed number of entries.
+// It is safe for concurrent use.
+type lru[K comparable, V any] struct {
+	mu      sync.Mutex
+	size    int
+	entries map[K]*list.Element
+	order   *list.List
+}
+
+// newLRU creates a new least-recently-used cache holding up to size entries.
+func newLRU[K comparable, V any](size int) *lru[K, V] {
+	return &lru[K, V]{
+		size:    size,
+		entries: make(map[K]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the value for the key, if present, marking it as recently used.
+func (c *lru[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		var empty V
+		return empty, false
+	}
+	c.order.MoveToFront(element)
+
+	return element.Value.(*lruEntry[K, V]).value, true
+}
+
+// contains returns true if the key is present, without marking it as recently used.
+func (c *lru[K, V]) contains(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, exists := c.entries[key]
+
+	return exists
+}
+
+// set sets the value for the key, marking it as recently used and evicting the
+// least recently used entries if the cache is over its size.
+func (c *lru[K, V]) set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.entries[key]; exists {
+		element.Value.(*lruEntry[K, V]).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{
+		key:   key,
+		value: value,
+	})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+// remove removes the entry for the key, if present.
+func (c *lru[K, V]) remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.entries[key]; exists {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// removeIf removes the entries whose key and value match the given function.
+func (c *lru[K, V]) removeIf(match func(key K, value V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, element := range c.entries {
+		if match(key, element.Value.(*lruEntry[K, V]).value) {
+			c.order.Remove(element)
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	cache := newLRU[string, int](2)
+
+	_, exists := cache.get("a")
+	require.False(t, exists)
+
+	cache.set("a", 1)
+	cache.set("b", 2)
+	value, exists := cache.get("a")
+	require.True(t, exists)
+	require.Equal(t, 1, value)
+
+	// Checking for presence does not mark an entry as recently used, so "b"
+	// remains the least recently used and is evicted by a third entry.
+	require.True(t, cache.contains("b"))
+	cache.set("c", 3)
+	require.False(t, cache.contains("b"))
+	require.True(t, cache.contains("a"))
+	require.True(t, cache.contains("c"))
+
+	// Setting an existing entry replaces its value and marks it as recently used.
+	cache.set("a", 10)
+	cache.set("d", 4)
+	value, exists = cache.get("a")
+	require.True(t, exists)
+	require.Equal(t, 10, value)
+	require.False(t, cache.contains("c"))
+
+	cache.remove("a")
+	require.False(t, cache.contains("a"))
+	cache.remove("a")
+
+	cache.set("e", 5)
+	cache.removeIf(func(_ string, value int) bool {
+		return value > 4
+	})
+	require.False(t, cache.contains("e"))
+	require.True(t, cache.contains("d"))
+}
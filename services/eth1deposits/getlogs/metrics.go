@@ -15,6 +15,7 @@ package getlogs
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
@@ -27,23 +28,65 @@ var (
 	highestBlock    uint64
 	latestBlock     prometheus.Gauge
 	blocksProcessed prometheus.Gauge
+
+	depositCacheHits   prometheus.Counter
+	depositCacheMisses prometheus.Counter
+	logCacheHits       prometheus.Counter
+	logCacheMisses     prometheus.Counter
+
+	pollInterval prometheus.Gauge
+
+	depositCountDifference prometheus.Gauge
+	depositRootMatches     prometheus.Gauge
+
+	endpointHealthy *prometheus.GaugeVec
+
+	rateLimitTokens prometheus.Gauge
+	rateLimitWait   prometheus.Counter
+
+	depositAnomalyCount *prometheus.CounterVec
+
+	reorgs prometheus.Counter
+
+	droppedLogs prometheus.Counter
+
+	depositsDecoded prometheus.Counter
+	decodeTime      prometheus.Counter
 )
 
+// recorder is the module-wide metrics recorder; metrics are not recorded if it is nil.
+var recorder MetricsRecorder
+
 func registerMetrics(_ context.Context, monitor metrics.Service) error {
-	if latestBlock != nil {
-		// Already registered.
-		return nil
-	}
 	if monitor == nil {
 		// No monitor.
 		return nil
 	}
 	if monitor.Presenter() == "prometheus" {
-		return registerPrometheusMetrics()
+		prometheusRecorder, err := NewPrometheusRecorder()
+		if err != nil {
+			return err
+		}
+		recorder = prometheusRecorder
 	}
 	return nil
 }
 
+// prometheusRecorder records metrics with Prometheus.
+type prometheusRecorder struct{}
+
+// NewPrometheusRecorder creates a metrics recorder that registers its metrics
+// with the default Prometheus registry.
+func NewPrometheusRecorder() (MetricsRecorder, error) {
+	if latestBlock == nil {
+		if err := registerPrometheusMetrics(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &prometheusRecorder{}, nil
+}
+
 func registerPrometheusMetrics() error {
 	latestBlock = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
@@ -63,15 +106,316 @@ func registerPrometheusMetrics() error {
 		return errors.Wrap(err, "failed to register blocks_processed")
 	}
 
+	depositCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "deposit_cache_hits_total",
+		Help:      "Number of block ranges whose deposits were served from the cache",
+	})
+	if err := prometheus.Register(depositCacheHits); err != nil {
+		return errors.Wrap(err, "failed to register deposit_cache_hits_total")
+	}
+
+	depositCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "deposit_cache_misses_total",
+		Help:      "Number of block ranges whose deposits were not in the cache",
+	})
+	if err := prometheus.Register(depositCacheMisses); err != nil {
+		return errors.Wrap(err, "failed to register deposit_cache_misses_total")
+	}
+
+	logCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "log_cache_hits_total",
+		Help:      "Number of log requests served from the cache",
+	})
+	if err := prometheus.Register(logCacheHits); err != nil {
+		return errors.Wrap(err, "failed to register log_cache_hits_total")
+	}
+
+	logCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "log_cache_misses_total",
+		Help:      "Number of log requests not in the cache",
+	})
+	if err := prometheus.Register(logCacheMisses); err != nil {
+		return errors.Wrap(err, "failed to register log_cache_misses_total")
+	}
+
+	pollInterval = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "poll_interval_seconds",
+		Help:      "Current interval between polls for new Ethereum 1 blocks",
+	})
+	if err := prometheus.Register(pollInterval); err != nil {
+		return errors.Wrap(err, "failed to register poll_interval_seconds")
+	}
+
+	depositCountDifference = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconciliation_count_difference",
+		Help:      "Number of deposits held above those in the beacon chain's Ethereum 1 data",
+	})
+	if err := prometheus.Register(depositCountDifference); err != nil {
+		return errors.Wrap(err, "failed to register reconciliation_count_difference")
+	}
+
+	depositRootMatches = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconciliation_root_matches",
+		Help:      "1 if the deposit contract root matches the beacon chain's Ethereum 1 data, otherwise 0",
+	})
+	if err := prometheus.Register(depositRootMatches); err != nil {
+		return errors.Wrap(err, "failed to register reconciliation_root_matches")
+	}
+
+	endpointHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "endpoint_healthy",
+		Help:      "1 if the most recent request to the Ethereum 1 endpoint succeeded, otherwise 0",
+	}, []string{"endpoint"})
+	if err := prometheus.Register(endpointHealthy); err != nil {
+		return errors.Wrap(err, "failed to register endpoint_healthy")
+	}
+
+	rateLimitTokens = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "rate_limit_tokens",
+		Help:      "Number of requests that can be made immediately under the global rate limit",
+	})
+	if err := prometheus.Register(rateLimitTokens); err != nil {
+		return errors.Wrap(err, "failed to register rate_limit_tokens")
+	}
+
+	rateLimitWait = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rate_limit_wait_seconds_total",
+		Help:      "Total time requests have waited for the global rate limit",
+	})
+	if err := prometheus.Register(rateLimitWait); err != nil {
+		return errors.Wrap(err, "failed to register rate_limit_wait_seconds_total")
+	}
+
+	depositAnomalyCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "deposit_anomalies_total",
+		Help:      "Number of anomalous deposits, by reason",
+	}, []string{"reason"})
+	if err := prometheus.Register(depositAnomalyCount); err != nil {
+		return errors.Wrap(err, "failed to register deposit_anomalies_total")
+	}
+
+	reorgs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reorgs_total",
+		Help:      "Number of Ethereum 1 chain reorganisations detected",
+	})
+	if err := prometheus.Register(reorgs); err != nil {
+		return errors.Wrap(err, "failed to register reorgs_total")
+	}
+
+	droppedLogs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "dropped_logs_total",
+		Help:      "Number of logs dropped as they came from an address not in the allowlist",
+	})
+	if err := prometheus.Register(droppedLogs); err != nil {
+		return errors.Wrap(err, "failed to register dropped_logs_total")
+	}
+
+	depositsDecoded = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "deposits_decoded_total",
+		Help:      "Number of deposits decoded from Ethereum 1 logs",
+	})
+	if err := prometheus.Register(depositsDecoded); err != nil {
+		return errors.Wrap(err, "failed to register deposits_decoded_total")
+	}
+
+	decodeTime = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "decode_seconds_total",
+		Help:      "Total time spent decoding deposits, summed across decode workers",
+	})
+	if err := prometheus.Register(decodeTime); err != nil {
+		return errors.Wrap(err, "failed to register decode_seconds_total")
+	}
+
 	return nil
 }
 
+// BlockProcessed records that an Ethereum 1 block has been processed.
+func (*prometheusRecorder) BlockProcessed(block uint64) {
+	blocksProcessed.Inc()
+	if block > highestBlock {
+		latestBlock.Set(float64(block))
+		highestBlock = block
+	}
+}
+
+// RecordLatency records the time taken by an operation.
+func (*prometheusRecorder) RecordLatency(operation string, duration time.Duration) {
+	switch operation {
+	case OperationDecode:
+		depositsDecoded.Inc()
+		decodeTime.Add(duration.Seconds())
+	case OperationRateLimitWait:
+		rateLimitWait.Add(duration.Seconds())
+	}
+}
+
+// IncCacheLookup records a lookup in the deposit cache.
+func (*prometheusRecorder) IncCacheLookup(hit bool) {
+	if hit {
+		depositCacheHits.Inc()
+	} else {
+		depositCacheMisses.Inc()
+	}
+}
+
+// IncLogCacheLookup records a lookup in the log cache.
+func (*prometheusRecorder) IncLogCacheLookup(hit bool) {
+	if hit {
+		logCacheHits.Inc()
+	} else {
+		logCacheMisses.Inc()
+	}
+}
+
+// IncAnomaly records an anomalous deposit.
+func (*prometheusRecorder) IncAnomaly(reason string) {
+	depositAnomalyCount.WithLabelValues(reason).Inc()
+}
+
+// IncReorg records a reorganisation of the Ethereum 1 chain.
+func (*prometheusRecorder) IncReorg() {
+	reorgs.Inc()
+}
+
+// IncDroppedLog records a log dropped as it came from an address not in the allowlist.
+func (*prometheusRecorder) IncDroppedLog() {
+	droppedLogs.Inc()
+}
+
+// SetEndpointHealthy records if the most recent request to an endpoint succeeded.
+func (*prometheusRecorder) SetEndpointHealthy(endpoint string, healthy bool) {
+	if healthy {
+		endpointHealthy.WithLabelValues(endpoint).Set(1)
+	} else {
+		endpointHealthy.WithLabelValues(endpoint).Set(0)
+	}
+}
+
+// SetPollInterval records the interval between polls for new blocks.
+func (*prometheusRecorder) SetPollInterval(interval time.Duration) {
+	pollInterval.Set(interval.Seconds())
+}
+
+// SetDepositCountDifference records the number of deposits held above those in the beacon chain.
+func (*prometheusRecorder) SetDepositCountDifference(difference int64) {
+	depositCountDifference.Set(float64(difference))
+}
+
+// SetDepositRootMatches records if the deposit contract root matches the beacon chain.
+func (*prometheusRecorder) SetDepositRootMatches(matches bool) {
+	if matches {
+		depositRootMatches.Set(1)
+	} else {
+		depositRootMatches.Set(0)
+	}
+}
+
+// SetRateLimitTokens records the number of requests that can be made immediately.
+func (*prometheusRecorder) SetRateLimitTokens(tokens float64) {
+	rateLimitTokens.Set(tokens)
+}
+
 func monitorBlockProcessed(block uint64) {
-	if blocksProcessed != nil {
-		blocksProcessed.Inc()
-		if block > highestBlock {
-			latestBlock.Set(float64(block))
-			highestBlock = block
-		}
+	if recorder != nil {
+		recorder.BlockProcessed(block)
+	}
+}
+
+func monitorDepositCacheHit() {
+	if recorder != nil {
+		recorder.IncCacheLookup(true)
+	}
+}
+
+func monitorDepositCacheMiss() {
+	if recorder != nil {
+		recorder.IncCacheLookup(false)
+	}
+}
+
+func monitorLogCacheHit() {
+	if recorder != nil {
+		recorder.IncLogCacheLookup(true)
+	}
+}
+
+func monitorLogCacheMiss() {
+	if recorder != nil {
+		recorder.IncLogCacheLookup(false)
+	}
+}
+
+func monitorPollInterval(interval time.Duration) {
+	if recorder != nil {
+		recorder.SetPollInterval(interval)
+	}
+}
+
+func monitorDepositCountDifference(difference int64) {
+	if recorder != nil {
+		recorder.SetDepositCountDifference(difference)
+	}
+}
+
+func monitorDepositRootMatches(matches bool) {
+	if recorder != nil {
+		recorder.SetDepositRootMatches(matches)
+	}
+}
+
+func monitorEndpointHealthy(endpoint string, healthy bool) {
+	if recorder != nil {
+		recorder.SetEndpointHealthy(endpoint, healthy)
+	}
+}
+
+func monitorRateLimitTokens(tokens float64) {
+	if recorder != nil {
+		recorder.SetRateLimitTokens(tokens)
+	}
+}
+
+func monitorRateLimitWait(wait time.Duration) {
+	if recorder != nil {
+		recorder.RecordLatency(OperationRateLimitWait, wait)
+	}
+}
+
+func monitorDepositAnomaly(reason string) {
+	if recorder != nil {
+		recorder.IncAnomaly(reason)
+	}
+}
+
+func monitorReorg() {
+	if recorder != nil {
+		recorder.IncReorg()
+	}
+}
+
+func monitorDroppedLog() {
+	if recorder != nil {
+		recorder.IncDroppedLog()
+	}
+}
+
+func monitorDepositDecoded(duration time.Duration) {
+	if recorder != nil {
+		recorder.RecordLatency(OperationDecode, duration)
 	}
 }
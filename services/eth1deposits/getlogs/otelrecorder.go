@@ -0,0 +1,266 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// otelRecorder records metrics with OpenTelemetry.
+// OpenTelemetry has no synchronous gauges, so gauge values are held here and
+// reported when the meter collects them.
+type otelRecorder struct {
+	blocksProcessed metric.Int64Counter
+	latency         metric.Float64Histogram
+	cacheLookups    metric.Int64Counter
+	logCacheLookups metric.Int64Counter
+	anomalies       metric.Int64Counter
+	reorgs          metric.Int64Counter
+	droppedLogs     metric.Int64Counter
+
+	mu                     sync.Mutex
+	latestBlock            *int64
+	endpointHealthy        map[string]int64
+	pollInterval           *float64
+	depositCountDifference *int64
+	depositRootMatches     *int64
+	rateLimitTokens        *float64
+}
+
+// NewOTelRecorder creates a metrics recorder that records metrics with the given OpenTelemetry meter.
+func NewOTelRecorder(meter metric.Meter) (MetricsRecorder, error) {
+	r := &otelRecorder{
+		endpointHealthy: make(map[string]int64),
+	}
+
+	var err error
+	r.blocksProcessed, err = meter.Int64Counter("chaind.eth1deposits.blocks_processed",
+		metric.WithDescription("Number of Ethereum 1 blocks processed"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create blocks_processed")
+	}
+
+	r.latency, err = meter.Float64Histogram("chaind.eth1deposits.operation.duration",
+		metric.WithDescription("Time taken by operations, by operation"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create operation.duration")
+	}
+
+	r.cacheLookups, err = meter.Int64Counter("chaind.eth1deposits.deposit_cache.lookups",
+		metric.WithDescription("Number of lookups in the deposit cache, by hit"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create deposit_cache.lookups")
+	}
+
+	r.logCacheLookups, err = meter.Int64Counter("chaind.eth1deposits.log_cache.lookups",
+		metric.WithDescription("Number of lookups in the log cache, by hit"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create log_cache.lookups")
+	}
+
+	r.anomalies, err = meter.Int64Counter("chaind.eth1deposits.deposit_anomalies",
+		metric.WithDescription("Number of anomalous deposits, by reason"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create deposit_anomalies")
+	}
+
+	r.reorgs, err = meter.Int64Counter("chaind.eth1deposits.reorgs",
+		metric.WithDescription("Number of Ethereum 1 chain reorganisations detected"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create reorgs")
+	}
+
+	r.droppedLogs, err = meter.Int64Counter("chaind.eth1deposits.dropped_logs",
+		metric.WithDescription("Number of logs dropped as they came from an address not in the allowlist"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create dropped_logs")
+	}
+
+	if _, err := meter.Int64ObservableGauge("chaind.eth1deposits.latest_block",
+		metric.WithDescription("Latest Ethereum 1 block processed"),
+		metric.WithInt64Callback(r.observeInt64(&r.latestBlock)),
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to create latest_block")
+	}
+
+	if _, err := meter.Int64ObservableGauge("chaind.eth1deposits.endpoint_healthy",
+		metric.WithDescription("1 if the most recent request to the Ethereum 1 endpoint succeeded, otherwise 0"),
+		metric.WithInt64Callback(r.observeEndpointHealthy),
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to create endpoint_healthy")
+	}
+
+	if _, err := meter.Float64ObservableGauge("chaind.eth1deposits.poll_interval",
+		metric.WithDescription("Current interval between polls for new Ethereum 1 blocks"),
+		metric.WithUnit("s"),
+		metric.WithFloat64Callback(r.observeFloat64(&r.pollInterval)),
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to create poll_interval")
+	}
+
+	if _, err := meter.Int64ObservableGauge("chaind.eth1deposits.reconciliation.count_difference",
+		metric.WithDescription("Number of deposits held above those in the beacon chain's Ethereum 1 data"),
+		metric.WithInt64Callback(r.observeInt64(&r.depositCountDifference)),
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to create reconciliation.count_difference")
+	}
+
+	if _, err := meter.Int64ObservableGauge("chaind.eth1deposits.reconciliation.root_matches",
+		metric.WithDescription("1 if the deposit contract root matches the beacon chain's Ethereum 1 data, otherwise 0"),
+		metric.WithInt64Callback(r.observeInt64(&r.depositRootMatches)),
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to create reconciliation.root_matches")
+	}
+
+	if _, err := meter.Float64ObservableGauge("chaind.eth1deposits.rate_limit.tokens",
+		metric.WithDescription("Number of requests that can be made immediately under the global rate limit"),
+		metric.WithFloat64Callback(r.observeFloat64(&r.rateLimitTokens)),
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to create rate_limit.tokens")
+	}
+
+	return r, nil
+}
+
+// observeInt64 returns a callback that observes the given value, if it has been set.
+func (r *otelRecorder) observeInt64(value **int64) metric.Int64Callback {
+	return func(_ context.Context, observer metric.Int64Observer) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if *value != nil {
+			observer.Observe(**value)
+		}
+		return nil
+	}
+}
+
+// observeFloat64 returns a callback that observes the given value, if it has been set.
+func (r *otelRecorder) observeFloat64(value **float64) metric.Float64Callback {
+	return func(_ context.Context, observer metric.Float64Observer) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if *value != nil {
+			observer.Observe(**value)
+		}
+		return nil
+	}
+}
+
+func (r *otelRecorder) observeEndpointHealthy(_ context.Context, observer metric.Int64Observer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for endpoint, healthy := range r.endpointHealthy {
+		observer.Observe(healthy, metric.WithAttributes(attribute.String("endpoint", endpoint)))
+	}
+	return nil
+}
+
+// BlockProcessed records that an Ethereum 1 block has been processed.
+func (r *otelRecorder) BlockProcessed(block uint64) {
+	r.blocksProcessed.Add(context.Background(), 1)
+	r.mu.Lock()
+	if r.latestBlock == nil || int64(block) > *r.latestBlock {
+		latestBlock := int64(block)
+		r.latestBlock = &latestBlock
+	}
+	r.mu.Unlock()
+}
+
+// RecordLatency records the time taken by an operation.
+func (r *otelRecorder) RecordLatency(operation string, duration time.Duration) {
+	r.latency.Record(context.Background(), duration.Seconds(), metric.WithAttributes(attribute.String("operation", operation)))
+}
+
+// IncCacheLookup records a lookup in the deposit cache.
+func (r *otelRecorder) IncCacheLookup(hit bool) {
+	r.cacheLookups.Add(context.Background(), 1, metric.WithAttributes(attribute.Bool("hit", hit)))
+}
+
+// IncLogCacheLookup records a lookup in the log cache.
+func (r *otelRecorder) IncLogCacheLookup(hit bool) {
+	r.logCacheLookups.Add(context.Background(), 1, metric.WithAttributes(attribute.Bool("hit", hit)))
+}
+
+// IncAnomaly records an anomalous deposit.
+func (r *otelRecorder) IncAnomaly(reason string) {
+	r.anomalies.Add(context.Background(), 1, metric.WithAttributes(attribute.String("reason", reason)))
+}
+
+// IncReorg records a reorganisation of the Ethereum 1 chain.
+func (r *otelRecorder) IncReorg() {
+	r.reorgs.Add(context.Background(), 1)
+}
+
+// IncDroppedLog records a log dropped as it came from an address not in the allowlist.
+func (r *otelRecorder) IncDroppedLog() {
+	r.droppedLogs.Add(context.Background(), 1)
+}
+
+// SetEndpointHealthy records if the most recent request to an endpoint succeeded.
+func (r *otelRecorder) SetEndpointHealthy(endpoint string, healthy bool) {
+	r.mu.Lock()
+	r.endpointHealthy[endpoint] = boolToInt64(healthy)
+	r.mu.Unlock()
+}
+
+// SetPollInterval records the interval between polls for new blocks.
+func (r *otelRecorder) SetPollInterval(interval time.Duration) {
+	seconds := interval.Seconds()
+	r.mu.Lock()
+	r.pollInterval = &seconds
+	r.mu.Unlock()
+}
+
+// SetDepositCountDifference records the number of deposits held above those in the beacon chain.
+func (r *otelRecorder) SetDepositCountDifference(difference int64) {
+	r.mu.Lock()
+	r.depositCountDifference = &difference
+	r.mu.Unlock()
+}
+
+// SetDepositRootMatches records if the deposit contract root matches the beacon chain.
+func (r *otelRecorder) SetDepositRootMatches(matches bool) {
+	value := boolToInt64(matches)
+	r.mu.Lock()
+	r.depositRootMatches = &value
+	r.mu.Unlock()
+}
+
+// SetRateLimitTokens records the number of requests that can be made immediately.
+func (r *otelRecorder) SetRateLimitTokens(tokens float64) {
+	r.mu.Lock()
+	r.rateLimitTokens = &tokens
+	r.mu.Unlock()
+}
+
+func boolToInt64(value bool) int64 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -15,21 +15,53 @@ package getlogs
 
 import (
 	"strconv"
+	"time"
 
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/watchdog"
 )
 
 type parameters struct {
-	logLevel           zerolog.Level
-	monitor            metrics.Service
-	connectionURL      string
-	chainDB            chaindb.Service
-	eth1DepositsSetter chaindb.ETH1DepositsSetter
-	eth1Confirmations  uint64
-	startBlock         string
+	logLevel                zerolog.Level
+	monitor                 metrics.Service
+	metricsRecorder         MetricsRecorder
+	connectionURL           string
+	logDumpFile             string
+	chainDB                 chaindb.Service
+	eth1DepositsSetter      chaindb.ETH1DepositsSetter
+	eth1Confirmations       uint64
+	startBlock              string
+	depositCacheSize        int
+	logCacheSize            int
+	logCacheTTL             time.Duration
+	minPollInterval         time.Duration
+	maxPollInterval         time.Duration
+	eth2Client              eth2client.Service
+	beaconStateProvider     eth2client.BeaconStateProvider
+	reconcileInterval       time.Duration
+	idempotencyHeader       string
+	requestRetries          int
+	globalRateLimit         float64
+	verifySignatures        bool
+	depositThresholds       depositThresholds
+	depositAnomalyHook      DepositAnomalyHook
+	reorgHook               ReorgHook
+	watchdog                watchdog.Service
+	logRangesPerBatch       uint64
+	logProcessors           []LogProcessor
+	allowedLogAddresses     [][]byte
+	depositContractCodeHash []byte
+	skipCodeHashCheck       bool
+	depositContractVersion  string
+	decodeConcurrency       int
+	discovery               Discovery
+	rediscoveryThreshold    int
+	rediscoveryCooldown     time.Duration
 }
 
 // Parameter is the interface for service parameters.
@@ -57,6 +89,14 @@ func WithMonitor(monitor metrics.Service) Parameter {
 	})
 }
 
+// WithMetricsRecorder sets the metrics recorder for the module.
+// If supplied it is used in place of the recorder for the monitor.
+func WithMetricsRecorder(recorder MetricsRecorder) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.metricsRecorder = recorder
+	})
+}
+
 // WithChainDB sets the chain database service for this module.
 func WithChainDB(chainDB chaindb.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -85,6 +125,16 @@ func WithConnectionURL(url string) Parameter {
 	})
 }
 
+// WithLogDumpFile serves requests for Ethereum 1 data from a log dump file, as
+// written by ExportLogDump, in place of an Ethereum 1 client.  Only blocks in
+// the range of the dump are available.  This is intended for development and
+// testing.
+func WithLogDumpFile(path string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logDumpFile = path
+	})
+}
+
 // WithStartBlock sets the start block for this module.
 func WithStartBlock(block string) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -92,11 +142,241 @@ func WithStartBlock(block string) Parameter {
 	})
 }
 
+// WithDepositCacheSize sets the number of block ranges for which decoded deposits are cached.
+// A size of 0 disables the cache.
+func WithDepositCacheSize(size int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.depositCacheSize = size
+	})
+}
+
+// WithLogCacheSize sets the number of responses for which logs are cached.
+// A size of 0 disables the cache.
+func WithLogCacheSize(size int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logCacheSize = size
+	})
+}
+
+// WithLogCacheTTL sets the time for which logs are cached.
+func WithLogCacheTTL(ttl time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logCacheTTL = ttl
+	})
+}
+
+// WithMinPollInterval sets the minimum interval between polls for new blocks.
+func WithMinPollInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.minPollInterval = interval
+	})
+}
+
+// WithMaxPollInterval sets the maximum interval between polls for new blocks.
+func WithMaxPollInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxPollInterval = interval
+	})
+}
+
+// WithETH2Client sets the Ethereum 2 client, used to reconcile deposits with the beacon chain.
+// If not supplied deposits are not reconciled.
+func WithETH2Client(client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eth2Client = client
+	})
+}
+
+// WithBeaconStateProvider sets the provider of beacon states used to reconcile deposits.
+// If not supplied states are fetched using the Ethereum 2 client.
+func WithBeaconStateProvider(provider eth2client.BeaconStateProvider) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.beaconStateProvider = provider
+	})
+}
+
+// WithReconcileInterval sets the interval between reconciliations of deposits with the beacon chain.
+// An interval of 0 disables reconciliation.
+func WithReconcileInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.reconcileInterval = interval
+	})
+}
+
+// WithIdempotencyHeader sets the name of a header that carries a key unique to
+// each logical request, and which is the same across retries of the request.
+// If empty no such header is sent.
+func WithIdempotencyHeader(name string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.idempotencyHeader = name
+	})
+}
+
+// WithRequestRetries sets the number of times a failed request to the
+// Ethereum 1 client is retried.
+func WithRequestRetries(retries int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.requestRetries = retries
+	})
+}
+
+// WithDiscovery sets a function to discover Ethereum 1 clients, used to
+// replace the client when requests to it fail persistently.
+func WithDiscovery(discovery Discovery) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.discovery = discovery
+	})
+}
+
+// WithRediscoveryThreshold sets the number of consecutive failed requests to the
+// Ethereum 1 client, after retries, that trigger discovery.
+func WithRediscoveryThreshold(threshold int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rediscoveryThreshold = threshold
+	})
+}
+
+// WithRediscoveryCooldown sets the minimum time between discoveries.
+func WithRediscoveryCooldown(cooldown time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rediscoveryCooldown = cooldown
+	})
+}
+
+// WithGlobalRateLimit sets the maximum number of requests per second made to the
+// Ethereum 1 client, across all activity of the module including retries.
+// A limit of 0 does not limit requests.
+func WithGlobalRateLimit(rps float64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.globalRateLimit = rps
+	})
+}
+
+// WithVerifySignatures sets whether the signatures of deposits are verified.
+// Verification is CPU-intensive, so is off by default.
+func WithVerifySignatures(verify bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.verifySignatures = verify
+	})
+}
+
+// WithDepositAmountThresholds sets the minimum and maximum amounts outside of which
+// a deposit is flagged as anomalous.  A threshold of 0 disables the relevant check.
+func WithDepositAmountThresholds(minimum phase0.Gwei, maximum phase0.Gwei) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.depositThresholds.minimum = minimum
+		p.depositThresholds.maximum = maximum
+	})
+}
+
+// WithDepositAmountGranularity sets the granularity of which a deposit amount must be
+// a multiple to avoid being flagged as anomalous.  A granularity of 0 disables the check.
+func WithDepositAmountGranularity(granularity phase0.Gwei) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.depositThresholds.granularity = granularity
+	})
+}
+
+// WithDepositAnomalyHook sets a function to be called when a deposit is flagged as anomalous.
+func WithDepositAnomalyHook(hook DepositAnomalyHook) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.depositAnomalyHook = hook
+	})
+}
+
+// WithReorgHook sets a function to be called when a reorganisation of the Ethereum 1
+// chain is detected, with the range of blocks whose deposits must be re-indexed.
+func WithReorgHook(hook ReorgHook) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.reorgHook = hook
+	})
+}
+
+// WithLogRangesPerBatch sets the number of block ranges for which logs are requested
+// in a single batch request when catching up.  A value of 1 disables batching.
+func WithLogRangesPerBatch(ranges uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logRangesPerBatch = ranges
+	})
+}
+
+// WithWatchdog sets the watchdog with which the service registers its progress.
+func WithWatchdog(watchdog watchdog.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.watchdog = watchdog
+	})
+}
+
+// WithLogProcessors sets the processors applied to fetched logs.  Processors run in
+// the order supplied, each receiving the output of the previous one, and an error
+// from any processor fails the fetch.
+func WithLogProcessors(processors ...LogProcessor) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logProcessors = processors
+	})
+}
+
+// WithAllowedLogAddresses sets the contract addresses from which logs are accepted.
+// Logs from other addresses are dropped before they are processed.  If not set only
+// logs from the deposit contract are accepted.
+func WithAllowedLogAddresses(addresses [][]byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.allowedLogAddresses = addresses
+	})
+}
+
+// WithDepositContractVersion sets the version of the deposit contract, which
+// determines the ABI with which deposit logs are decoded.  If not set the
+// version of each log is detected from its event signature.
+func WithDepositContractVersion(version string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.depositContractVersion = version
+	})
+}
+
+// WithDepositContractCodeHash sets the expected keccak-256 hash of the code of the
+// deposit contract, overriding the known hash for the chain.
+func WithDepositContractCodeHash(hash []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.depositContractCodeHash = hash
+	})
+}
+
+// WithSkipDepositContractCodeCheck sets if the check of the code of the deposit
+// contract at startup is skipped.  Without this, startup fails if the hash of the
+// code is not known for the chain and is not supplied with WithDepositContractCodeHash.
+func WithSkipDepositContractCodeCheck(skip bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.skipCodeHashCheck = skip
+	})
+}
+
+// WithDecodeConcurrency sets the number of workers that decode the deposits
+// in each range of blocks.  Values above 1 also fetch the logs for the next
+// range of blocks while the current range is being handled.
+func WithDecodeConcurrency(concurrency int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.decodeConcurrency = concurrency
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel:          zerolog.GlobalLevel(),
-		eth1Confirmations: 12, // Default number of confirmations.
+		logLevel:             zerolog.GlobalLevel(),
+		eth1Confirmations:    12, // Default number of confirmations.
+		minPollInterval:      12 * time.Second,
+		maxPollInterval:      2 * time.Minute,
+		reconcileInterval:    time.Hour,
+		logCacheTTL:          time.Hour,
+		logRangesPerBatch:    1,
+		decodeConcurrency:    1,
+		rediscoveryThreshold: 5,
+		rediscoveryCooldown:  5 * time.Minute,
+		depositThresholds: depositThresholds{
+			// The minimum deposit amount accepted by the deposit contract.
+			minimum: 1000000000,
+		},
 	}
 	for _, p := range params {
 		if params != nil {
@@ -110,7 +390,7 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.eth1DepositsSetter == nil {
 		return nil, errors.New("no Ethereum 1 deposits setter specified")
 	}
-	if parameters.connectionURL == "" {
+	if parameters.connectionURL == "" && parameters.logDumpFile == "" {
 		return nil, errors.New("no connection URL specified")
 	}
 	if parameters.startBlock != "" {
@@ -119,6 +399,66 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 			return nil, errors.Wrap(err, "invalid start block specified")
 		}
 	}
+	if parameters.depositCacheSize < 0 {
+		return nil, errors.New("deposit cache size cannot be negative")
+	}
+	if parameters.logCacheSize < 0 {
+		return nil, errors.New("log cache size cannot be negative")
+	}
+	if parameters.logCacheSize > 0 && parameters.logCacheTTL <= 0 {
+		return nil, errors.New("log cache TTL must be positive")
+	}
+	if parameters.minPollInterval <= 0 {
+		return nil, errors.New("minimum poll interval must be positive")
+	}
+	if parameters.maxPollInterval < parameters.minPollInterval {
+		return nil, errors.New("maximum poll interval cannot be less than minimum poll interval")
+	}
+	if len(parameters.depositContractCodeHash) != 0 && len(parameters.depositContractCodeHash) != 32 {
+		return nil, errors.New("deposit contract code hash must be 32 bytes")
+	}
+	if parameters.depositContractVersion != "" {
+		if _, err := depositEventABIForVersion(parameters.depositContractVersion); err != nil {
+			return nil, err
+		}
+	}
+	for _, address := range parameters.allowedLogAddresses {
+		if len(address) != 20 {
+			return nil, errors.Errorf("allowed log address %#x must be 20 bytes", address)
+		}
+	}
+	for _, processor := range parameters.logProcessors {
+		if processor == nil {
+			return nil, errors.New("log processor cannot be nil")
+		}
+	}
+	if parameters.logRangesPerBatch == 0 {
+		return nil, errors.New("log ranges per batch must be at least 1")
+	}
+	if parameters.decodeConcurrency < 1 {
+		return nil, errors.New("decode concurrency must be at least 1")
+	}
+	if parameters.requestRetries < 0 {
+		return nil, errors.New("request retries cannot be negative")
+	}
+	if parameters.discovery != nil && parameters.logDumpFile != "" {
+		return nil, errors.New("discovery cannot be used with a log dump file")
+	}
+	if parameters.rediscoveryThreshold < 1 {
+		return nil, errors.New("rediscovery threshold must be at least 1")
+	}
+	if parameters.rediscoveryCooldown < 0 {
+		return nil, errors.New("rediscovery cooldown cannot be negative")
+	}
+	if parameters.globalRateLimit < 0 {
+		return nil, errors.New("global rate limit cannot be negative")
+	}
+	if parameters.reconcileInterval < 0 {
+		return nil, errors.New("reconcile interval cannot be negative")
+	}
+	if parameters.depositThresholds.maximum > 0 && parameters.depositThresholds.maximum < parameters.depositThresholds.minimum {
+		return nil, errors.New("maximum deposit amount threshold cannot be less than minimum")
+	}
 
 	return &parameters, nil
 }
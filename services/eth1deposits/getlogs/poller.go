@@ -0,0 +1,108 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"sync"
+	"time"
+)
+
+// pollerWindow is the number of recent inter-block times used to set the poll interval.
+const pollerWindow = 8
+
+// pollerBackoff is the factor by which the poll interval increases when a poll finds no new blocks.
+const pollerBackoff = 1.5
+
+// adaptivePoller sets the interval between polls for new blocks to track the
+// rate at which blocks are produced, within bounds.
+type adaptivePoller struct {
+	mu          sync.Mutex
+	minInterval time.Duration
+	maxInterval time.Duration
+	interval    time.Duration
+	lastBlock   uint64
+	lastSeen    time.Time
+	blockTimes  []time.Duration
+}
+
+// newAdaptivePoller creates a new adaptive poller.
+// The poller starts at the maximum interval, and adapts as blocks are observed.
+func newAdaptivePoller(minInterval time.Duration, maxInterval time.Duration) *adaptivePoller {
+	return &adaptivePoller{
+		minInterval: minInterval,
+		maxInterval: maxInterval,
+		interval:    maxInterval,
+		blockTimes:  make([]time.Duration, 0, pollerWindow),
+	}
+}
+
+// current returns the current poll interval.
+func (p *adaptivePoller) current() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.interval
+}
+
+// observe records the head block seen by a poll at the given time, and returns the new poll interval.
+func (p *adaptivePoller) observe(block uint64, at time.Time) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.lastSeen.IsZero() || block < p.lastBlock:
+		// First observation, or the chain has gone backwards; start again.
+		p.blockTimes = p.blockTimes[:0]
+	case block == p.lastBlock:
+		// No new blocks; poll less often.
+		p.setInterval(time.Duration(float64(p.interval) * pollerBackoff))
+		return p.interval
+	default:
+		blockTime := at.Sub(p.lastSeen) / time.Duration(block-p.lastBlock)
+		if len(p.blockTimes) == pollerWindow {
+			p.blockTimes = p.blockTimes[1:]
+		}
+		p.blockTimes = append(p.blockTimes, blockTime)
+
+		total := time.Duration(0)
+		for _, blockTime := range p.blockTimes {
+			total += blockTime
+		}
+		p.setInterval(total / time.Duration(len(p.blockTimes)))
+	}
+	p.lastBlock = block
+	p.lastSeen = at
+
+	return p.interval
+}
+
+// setInterval sets the poll interval, within bounds.
+func (p *adaptivePoller) setInterval(interval time.Duration) {
+	if interval < p.minInterval {
+		interval = p.minInterval
+	}
+	if interval > p.maxInterval {
+		interval = p.maxInterval
+	}
+	p.interval = interval
+	monitorPollInterval(interval)
+}
+
+// head returns the most recent head block observed, or false if no block has been observed.
+func (p *adaptivePoller) head() (uint64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.lastBlock, !p.lastSeen.IsZero()
+}
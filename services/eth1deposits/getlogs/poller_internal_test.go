@@ -0,0 +1,112 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptivePoller(t *testing.T) {
+	minInterval := 5 * time.Second
+	maxInterval := time.Minute
+
+	tests := []struct {
+		name string
+		// blockTime is the simulated time between blocks; 0 means no blocks are produced.
+		blockTime time.Duration
+		polls     int
+		interval  time.Duration
+	}{
+		{
+			name:      "Steady",
+			blockTime: 12 * time.Second,
+			polls:     10,
+			interval:  12 * time.Second,
+		},
+		{
+			name:      "Fast",
+			blockTime: time.Second,
+			polls:     10,
+			interval:  minInterval,
+		},
+		{
+			name:      "Slow",
+			blockTime: 5 * time.Minute,
+			polls:     10,
+			interval:  maxInterval,
+		},
+		{
+			name:     "Stalled",
+			polls:    10,
+			interval: maxInterval,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newAdaptivePoller(minInterval, maxInterval)
+			require.Equal(t, maxInterval, p.current())
+
+			// Simulate polls at the current interval, with the head advancing at the block rate.
+			now := time.Unix(1700000000, 0)
+			elapsed := time.Duration(0)
+			// Start partway in to the simulated chain so the first poll sees a non-zero head.
+			head := uint64(1000)
+			for i := 0; i < test.polls; i++ {
+				block := head
+				if test.blockTime > 0 {
+					block += uint64(elapsed / test.blockTime)
+				}
+				interval := p.observe(block, now.Add(elapsed))
+				require.GreaterOrEqual(t, interval, minInterval)
+				require.LessOrEqual(t, interval, maxInterval)
+				elapsed += interval
+			}
+			require.Equal(t, test.interval, p.current())
+		})
+	}
+}
+
+func TestAdaptivePollerChangingRate(t *testing.T) {
+	p := newAdaptivePoller(time.Second, time.Minute)
+	now := time.Unix(1700000000, 0)
+
+	// Blocks every 12 seconds.
+	block := uint64(100)
+	p.observe(block, now)
+	for i := 0; i < pollerWindow; i++ {
+		block++
+		now = now.Add(12 * time.Second)
+		p.observe(block, now)
+	}
+	require.Equal(t, 12*time.Second, p.current())
+
+	// Block production speeds up to every 2 seconds; the interval follows.
+	for i := 0; i < pollerWindow; i++ {
+		block++
+		now = now.Add(2 * time.Second)
+		p.observe(block, now)
+	}
+	require.Equal(t, 2*time.Second, p.current())
+
+	// Block production stops; the interval backs off.
+	for i := 0; i < 3; i++ {
+		now = now.Add(p.current())
+		p.observe(block, now)
+	}
+	require.Equal(t, 6750*time.Millisecond, p.current())
+}
@@ -0,0 +1,78 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"sync"
+)
+
+// logPrefetch holds the logs for a single range of blocks, fetched in the
+// background while the previous range is handled.
+// The zero value is ready to use.
+type logPrefetch struct {
+	mu      sync.Mutex
+	pending *prefetchedLogs
+}
+
+// prefetchedLogs are the logs for a range of blocks, available once done is closed.
+type prefetchedLogs struct {
+	blockRange blockRange
+	done       chan struct{}
+	logs       []*logResponse
+	err        error
+}
+
+// start starts fetching the logs for the given range of blocks, replacing any
+// logs previously prefetched.
+func (p *logPrefetch) start(ctx context.Context, s *Service, startBlock uint64, endBlock uint64) {
+	pending := &prefetchedLogs{
+		blockRange: blockRange{startBlock: startBlock, endBlock: endBlock},
+		done:       make(chan struct{}),
+	}
+	p.mu.Lock()
+	p.pending = pending
+	p.mu.Unlock()
+
+	go func() {
+		defer close(pending.done)
+		pending.logs, pending.err = s.getLogs(ctx, startBlock, endBlock)
+	}()
+}
+
+// take returns the prefetched logs for the given range, waiting for them if
+// they are still being fetched.  It returns false if the range was not
+// prefetched, or if the prefetch failed.
+func (p *logPrefetch) take(ctx context.Context, startBlock uint64, endBlock uint64) ([]*logResponse, bool) {
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = nil
+	p.mu.Unlock()
+
+	if pending == nil || pending.blockRange != (blockRange{startBlock: startBlock, endBlock: endBlock}) {
+		return nil, false
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, false
+	case <-pending.done:
+	}
+	if pending.err != nil {
+		log.Debug().Uint64("start_block", startBlock).Uint64("end_block", endBlock).Err(pending.err).Msg("Failed to prefetch logs")
+		return nil, false
+	}
+
+	return pending.logs, true
+}
@@ -0,0 +1,42 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// BlockProgress returns the latest Ethereum 1 block processed by the service, and
+// the latest block that it expects to have processed, being the most recently
+// observed head less the required confirmations.
+// It returns false if the head has yet to be observed.
+func (s *Service) BlockProgress(ctx context.Context) (uint64, uint64, bool, error) {
+	head, observed := s.poller.head()
+	if !observed {
+		return 0, 0, false, nil
+	}
+	expected := uint64(0)
+	if head > s.eth1Confirmations {
+		expected = head - s.eth1Confirmations
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return 0, 0, false, errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	return md.LatestBlock, expected, true, nil
+}
@@ -0,0 +1,96 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket that limits the rate of requests to the
+// Ethereum 1 client.  It is shared by all activity of the service, so the
+// limit applies to the total of real-time and backfill requests.
+// A nil limiter is valid, and does not limit.
+type rateLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+// newRateLimiter creates a new rate limiter allowing rps requests per second.
+// The bucket holds up to one second's worth of requests.
+// If rps is 0 no limiter is created.
+func newRateLimiter(rps float64) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	capacity := math.Max(1, rps)
+
+	return &rateLimiter{
+		rate:     rps,
+		capacity: capacity,
+		tokens:   capacity,
+		last:     time.Now(),
+	}
+}
+
+// wait waits until a request can be made, returning the time spent waiting.
+// Each call reserves a token, so waiters are served in the order they arrive.
+func (l *rateLimiter) wait(ctx context.Context) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+
+	l.mu.Lock()
+	l.refill(time.Now())
+	l.tokens--
+	remaining := l.tokens
+	l.mu.Unlock()
+	monitorRateLimitTokens(math.Max(0, remaining))
+
+	if remaining >= 0 {
+		return 0, nil
+	}
+
+	delay := time.Duration(-remaining / l.rate * float64(time.Second))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// Return the unused token.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return 0, ctx.Err()
+	case <-timer.C:
+	}
+	monitorRateLimitWait(delay)
+
+	return delay, nil
+}
+
+// refill adds tokens for the time elapsed since the last refill.
+// This requires the lock to be held.
+func (l *rateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	l.tokens = math.Min(l.capacity, l.tokens+elapsed*l.rate)
+	l.last = now
+}
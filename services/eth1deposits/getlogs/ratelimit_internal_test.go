@@ -0,0 +1,103 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// callRecorder is a server that records the time of each call.
+type callRecorder struct {
+	mu    sync.Mutex
+	calls []time.Time
+}
+
+func (r *callRecorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	r.mu.Lock()
+	r.calls = append(r.calls, time.Now())
+	r.mu.Unlock()
+	fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0x10"}`)
+}
+
+func TestGlobalRateLimit(t *testing.T) {
+	recorder := &callRecorder{}
+	server := httptest.NewServer(recorder)
+	t.Cleanup(server.Close)
+	s := newTestService(t, server.URL)
+	rps := 50.0
+	s.rateLimiter = newRateLimiter(rps)
+
+	// Concurrent fetchers, as from real-time and backfill activity, share the limit.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	started := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				_, _ = s.blockNumber(ctx)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(started).Seconds()
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	// The aggregate rate is at most the limit, plus the initial burst.
+	require.LessOrEqual(t, float64(len(recorder.calls)), rps*elapsed+rps+1)
+	require.Greater(t, float64(len(recorder.calls)), rps)
+
+	// Once the burst is spent calls are spaced according to the limit.
+	burstEnd := recorder.calls[int(rps)-1]
+	steady := 0
+	for _, call := range recorder.calls[int(rps):] {
+		if call.After(burstEnd) {
+			steady++
+		}
+	}
+	window := recorder.calls[len(recorder.calls)-1].Sub(burstEnd).Seconds()
+	require.LessOrEqual(t, float64(steady), rps*window+1)
+}
+
+func TestRateLimiterNil(t *testing.T) {
+	require.Nil(t, newRateLimiter(0))
+	var l *rateLimiter
+	waited, err := l.wait(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, waited)
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	l := newRateLimiter(1)
+	_, err := l.wait(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = l.wait(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	// The cancelled wait returned its token.
+	require.InDelta(t, 0, l.tokens, 0.1)
+}
@@ -0,0 +1,181 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// reconciliation is the result of comparing our deposits with the beacon chain's Ethereum 1 data.
+type reconciliation struct {
+	blockHash   []byte
+	blockNumber uint64
+	chainCount  uint64
+	ourCount    uint64
+	chainRoot   phase0.Root
+	// contractRoot is nil if the root could not be obtained from the Ethereum 1 client.
+	contractRoot *phase0.Root
+}
+
+// difference returns the number of deposits we hold above those of the chain.
+func (r *reconciliation) difference() int64 {
+	return int64(r.ourCount) - int64(r.chainCount)
+}
+
+// reconcilePeriodically reconciles deposits with the beacon chain at the given interval.
+func (s *Service) reconcilePeriodically(ctx context.Context, interval time.Duration) {
+	for {
+		s.reconcile(ctx)
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			log.Debug().Msg("Context done")
+			return
+		}
+	}
+}
+
+// reconcile compares the deposits we hold up to the Ethereum 1 block in the
+// beacon chain's head state against the deposit count and root of that state.
+func (s *Service) reconcile(ctx context.Context) {
+	state, err := s.beaconStateProvider.BeaconState(ctx, "head")
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain beacon state for reconciliation")
+		return
+	}
+	eth1Data, err := stateETH1Data(state)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain Ethereum 1 data for reconciliation")
+		return
+	}
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain metadata for reconciliation")
+		return
+	}
+
+	res, err := s.compareDeposits(ctx, eth1Data, md.LatestBlock)
+	if err != nil {
+		log.Warn().Str("eth1_block_hash", fmt.Sprintf("%#x", eth1Data.BlockHash)).Err(err).Msg("Failed to reconcile deposits")
+		return
+	}
+	if res == nil {
+		return
+	}
+
+	log := log.With().
+		Str("eth1_block_hash", fmt.Sprintf("%#x", res.blockHash)).
+		Uint64("eth1_block_number", res.blockNumber).
+		Uint64("our_count", res.ourCount).
+		Uint64("chain_count", res.chainCount).
+		Logger()
+	monitorDepositCountDifference(res.difference())
+	if res.difference() != 0 {
+		log.Warn().Int64("difference", res.difference()).Msg("Deposit count differs from beacon chain")
+	} else {
+		log.Trace().Msg("Deposit count matches beacon chain")
+	}
+
+	if res.contractRoot == nil {
+		return
+	}
+	rootMatches := *res.contractRoot == res.chainRoot
+	monitorDepositRootMatches(rootMatches)
+	if !rootMatches {
+		log.Warn().
+			Str("contract_root", fmt.Sprintf("%#x", *res.contractRoot)).
+			Str("chain_root", fmt.Sprintf("%#x", res.chainRoot)).
+			Msg("Deposit root differs from beacon chain")
+	}
+}
+
+// compareDeposits compares our deposits with the given Ethereum 1 data.
+// It returns nil if we have yet to process the block referenced by the data.
+func (s *Service) compareDeposits(ctx context.Context, eth1Data *phase0.ETH1Data, latestBlock uint64) (*reconciliation, error) {
+	blockNumber, err := s.blockNumberByHash(ctx, eth1Data.BlockHash)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain block number")
+	}
+	if blockNumber > latestBlock {
+		log.Debug().Uint64("eth1_block_number", blockNumber).Uint64("latest_block", latestBlock).Msg("Block not yet processed; not reconciling")
+		return nil, nil
+	}
+
+	ourCount, err := s.eth1DepositsCountProvider.ETH1DepositsCountToBlock(ctx, blockNumber)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count deposits")
+	}
+
+	res := &reconciliation{
+		blockHash:   eth1Data.BlockHash,
+		blockNumber: blockNumber,
+		chainCount:  eth1Data.DepositCount,
+		ourCount:    ourCount,
+		chainRoot:   eth1Data.DepositRoot,
+	}
+
+	// The deposit root requires historical state, which the client may not have.
+	contractRoot, err := s.depositRootAtBlock(ctx, eth1Data.BlockHash)
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to obtain deposit root from contract; not comparing roots")
+	} else {
+		res.contractRoot = &contractRoot
+	}
+
+	return res, nil
+}
+
+// stateETH1Data returns the Ethereum 1 data from a beacon state.
+func stateETH1Data(state *spec.VersionedBeaconState) (*phase0.ETH1Data, error) {
+	if state == nil {
+		return nil, errors.New("no state")
+	}
+
+	var eth1Data *phase0.ETH1Data
+	switch state.Version {
+	case spec.DataVersionPhase0:
+		if state.Phase0 != nil {
+			eth1Data = state.Phase0.ETH1Data
+		}
+	case spec.DataVersionAltair:
+		if state.Altair != nil {
+			eth1Data = state.Altair.ETH1Data
+		}
+	case spec.DataVersionBellatrix:
+		if state.Bellatrix != nil {
+			eth1Data = state.Bellatrix.ETH1Data
+		}
+	case spec.DataVersionCapella:
+		if state.Capella != nil {
+			eth1Data = state.Capella.ETH1Data
+		}
+	case spec.DataVersionDeneb:
+		if state.Deneb != nil {
+			eth1Data = state.Deneb.ETH1Data
+		}
+	default:
+		return nil, fmt.Errorf("unhandled state version %v", state.Version)
+	}
+	if eth1Data == nil {
+		return nil, errors.New("no Ethereum 1 data in state")
+	}
+
+	return eth1Data, nil
+}
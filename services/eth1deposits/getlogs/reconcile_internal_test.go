@@ -0,0 +1,144 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+// depositCounter provides a fixed count of deposits.
+type depositCounter struct {
+	count uint64
+	block uint64
+}
+
+func (c *depositCounter) ETH1DepositsCountToBlock(_ context.Context, blockNumber uint64) (uint64, error) {
+	c.block = blockNumber
+
+	return c.count, nil
+}
+
+func TestCompareDeposits(t *testing.T) {
+	ctx := context.Background()
+
+	root := phase0.Root{0x01, 0x02}
+	eth1Data := &phase0.ETH1Data{
+		BlockHash:    []byte{0xaa, 0xbb},
+		DepositCount: 100,
+		DepositRoot:  root,
+	}
+
+	tests := []struct {
+		name         string
+		callResult   string
+		count        uint64
+		latestBlock  uint64
+		nilRes       bool
+		difference   int64
+		contractRoot *phase0.Root
+	}{
+		{
+			name:         "Match",
+			callResult:   `"0x0102000000000000000000000000000000000000000000000000000000000000"`,
+			count:        100,
+			latestBlock:  2000,
+			contractRoot: &root,
+		},
+		{
+			name:         "Missing",
+			callResult:   `"0x0102000000000000000000000000000000000000000000000000000000000000"`,
+			count:        98,
+			latestBlock:  2000,
+			difference:   -2,
+			contractRoot: &root,
+		},
+		{
+			name:        "RootUnavailable",
+			count:       101,
+			latestBlock: 2000,
+			difference:  1,
+		},
+		{
+			name:        "NotYetProcessed",
+			latestBlock: 1000,
+			nilRes:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := map[string]string{
+				"eth_getBlockByHash": `{"number":"0x4d2","timestamp":"0x6033cd9f"}`,
+			}
+			if test.callResult != "" {
+				results["eth_call"] = test.callResult
+			}
+			stub := newRPCStub(t, results)
+			s := newTestService(t, stub.server.URL)
+			counter := &depositCounter{count: test.count}
+			s.eth1DepositsCountProvider = counter
+
+			res, err := s.compareDeposits(ctx, eth1Data, test.latestBlock)
+			require.NoError(t, err)
+			if test.nilRes {
+				require.Nil(t, res)
+				return
+			}
+			require.NotNil(t, res)
+			require.Equal(t, uint64(1234), counter.block)
+			require.Equal(t, uint64(1234), res.blockNumber)
+			require.Equal(t, test.difference, res.difference())
+			require.Equal(t, test.contractRoot, res.contractRoot)
+		})
+	}
+}
+
+func TestDepositRootRequest(t *testing.T) {
+	stub := newRPCStub(t, map[string]string{})
+	var params []json.RawMessage
+	stub.setResultFunc("eth_call", func(p []json.RawMessage) string {
+		params = p
+		return `"0x0000000000000000000000000000000000000000000000000000000000000000"`
+	})
+	s := newTestService(t, stub.server.URL)
+
+	_, err := s.depositRootAtBlock(context.Background(), []byte{0xaa, 0xbb})
+	require.NoError(t, err)
+	require.Len(t, params, 2)
+	require.JSONEq(t, `{"to":"0x8c5fecdc472e27bc447696f431e425d02dd46a8c","data":"0xc5f2892f"}`, string(params[0]))
+	require.JSONEq(t, `{"blockHash":"0xaabb"}`, string(params[1]))
+}
+
+func TestStateETH1Data(t *testing.T) {
+	_, err := stateETH1Data(nil)
+	require.EqualError(t, err, "no state")
+
+	_, err = stateETH1Data(&spec.VersionedBeaconState{Version: spec.DataVersionCapella})
+	require.EqualError(t, err, "no Ethereum 1 data in state")
+
+	eth1Data := &phase0.ETH1Data{DepositCount: 5}
+	res, err := stateETH1Data(&spec.VersionedBeaconState{
+		Version: spec.DataVersionCapella,
+		Capella: &capella.BeaconState{ETH1Data: eth1Data},
+	})
+	require.NoError(t, err)
+	require.Equal(t, eth1Data, res)
+}
@@ -0,0 +1,64 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import "time"
+
+// Operations for which latency is recorded.
+const (
+	// OperationDecode is the decoding and verification of a deposit.
+	OperationDecode = "decode"
+	// OperationRateLimitWait is a wait for the global rate limit before a request.
+	OperationRateLimitWait = "rate_limit_wait"
+)
+
+// MetricsRecorder records the metrics of the service.
+// It allows the service to report to metrics systems other than Prometheus.
+type MetricsRecorder interface {
+	// BlockProcessed records that an Ethereum 1 block has been processed.
+	BlockProcessed(block uint64)
+
+	// RecordLatency records the time taken by an operation.
+	RecordLatency(operation string, duration time.Duration)
+
+	// IncCacheLookup records a lookup in the deposit cache.
+	IncCacheLookup(hit bool)
+
+	// IncLogCacheLookup records a lookup in the log cache.
+	IncLogCacheLookup(hit bool)
+
+	// IncAnomaly records an anomalous deposit.
+	IncAnomaly(reason string)
+
+	// IncReorg records a reorganisation of the Ethereum 1 chain.
+	IncReorg()
+
+	// IncDroppedLog records a log dropped as it came from an address not in the allowlist.
+	IncDroppedLog()
+
+	// SetEndpointHealthy records if the most recent request to an endpoint succeeded.
+	SetEndpointHealthy(endpoint string, healthy bool)
+
+	// SetPollInterval records the interval between polls for new blocks.
+	SetPollInterval(interval time.Duration)
+
+	// SetDepositCountDifference records the number of deposits held above those in the beacon chain.
+	SetDepositCountDifference(difference int64)
+
+	// SetDepositRootMatches records if the deposit contract root matches the beacon chain.
+	SetDepositRootMatches(matches bool)
+
+	// SetRateLimitTokens records the number of requests that can be made immediately.
+	SetRateLimitTokens(tokens float64)
+}
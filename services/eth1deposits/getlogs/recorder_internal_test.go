@@ -0,0 +1,235 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// fakeRecorder records the calls made to it.
+type fakeRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *fakeRecorder) record(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, fmt.Sprintf(format, args...))
+}
+
+func (r *fakeRecorder) BlockProcessed(block uint64) {
+	r.record("BlockProcessed(%d)", block)
+}
+
+func (r *fakeRecorder) RecordLatency(operation string, _ time.Duration) {
+	r.record("RecordLatency(%s)", operation)
+}
+
+func (r *fakeRecorder) IncCacheLookup(hit bool) {
+	r.record("IncCacheLookup(%t)", hit)
+}
+
+func (r *fakeRecorder) IncLogCacheLookup(hit bool) {
+	r.record("IncLogCacheLookup(%t)", hit)
+}
+
+func (r *fakeRecorder) IncAnomaly(reason string) {
+	r.record("IncAnomaly(%s)", reason)
+}
+
+func (r *fakeRecorder) IncReorg() {
+	r.record("IncReorg()")
+}
+
+func (r *fakeRecorder) IncDroppedLog() {
+	r.record("IncDroppedLog()")
+}
+
+func (r *fakeRecorder) SetEndpointHealthy(endpoint string, healthy bool) {
+	r.record("SetEndpointHealthy(%s,%t)", endpoint, healthy)
+}
+
+func (r *fakeRecorder) SetPollInterval(interval time.Duration) {
+	r.record("SetPollInterval(%s)", interval)
+}
+
+func (r *fakeRecorder) SetDepositCountDifference(difference int64) {
+	r.record("SetDepositCountDifference(%d)", difference)
+}
+
+func (r *fakeRecorder) SetDepositRootMatches(matches bool) {
+	r.record("SetDepositRootMatches(%t)", matches)
+}
+
+func (r *fakeRecorder) SetRateLimitTokens(tokens float64) {
+	r.record("SetRateLimitTokens(%g)", tokens)
+}
+
+func TestMetricsRecorder(t *testing.T) {
+	fake := &fakeRecorder{}
+	previous := recorder
+	recorder = fake
+	defer func() { recorder = previous }()
+
+	tests := []struct {
+		name  string
+		run   func(t *testing.T)
+		calls []string
+	}{
+		{
+			name: "BlockProcessed",
+			run: func(_ *testing.T) {
+				monitorBlockProcessed(12345)
+			},
+			calls: []string{"BlockProcessed(12345)"},
+		},
+		{
+			name: "Decode",
+			run: func(t *testing.T) {
+				s := testDecodeService(t, 1)
+				require.NotNil(t, s.decodeDeposit(testDepositSource(t, 0)))
+			},
+			calls: []string{"RecordLatency(decode)"},
+		},
+		{
+			name: "DepositCache",
+			run: func(_ *testing.T) {
+				cache := newDepositCache(1)
+				cache.get(1, 2)
+				cache.set(1, 2, []*chaindb.ETH1Deposit{})
+				cache.get(1, 2)
+			},
+			calls: []string{"IncCacheLookup(false)", "IncCacheLookup(true)"},
+		},
+		{
+			name: "LogCache",
+			run: func(_ *testing.T) {
+				filter := &logFilter{name: "test"}
+				cache := newLogCache(1, time.Hour)
+				cache.get(filter, 1, 2)
+				cache.set(filter, 1, 2, []byte("[]"))
+				cache.get(filter, 1, 2)
+			},
+			calls: []string{"IncLogCacheLookup(false)", "IncLogCacheLookup(true)"},
+		},
+		{
+			name: "Anomaly",
+			run: func(_ *testing.T) {
+				s := &Service{
+					depositThresholds: depositThresholds{
+						minimum:     1000000000,
+						maximum:     2048000000000,
+						granularity: 1000000000,
+					},
+				}
+				s.checkDepositAnomalies(&chaindb.ETH1Deposit{Amount: 2049000000000})
+			},
+			calls: []string{"IncAnomaly(" + DepositAnomalyAboveMaximum + ")"},
+		},
+		{
+			name: "Reorg",
+			run: func(_ *testing.T) {
+				s := &Service{}
+				s.reportReorg(context.Background(), []*logResponse{{BlockNumber: 0, Removed: true}})
+			},
+			calls: []string{"IncReorg()"},
+		},
+		{
+			name: "DroppedLog",
+			run: func(_ *testing.T) {
+				s := &Service{logAddressAllowlist: newLogAddressAllowlist(nil)}
+				s.dropDisallowedLogs([]*logResponse{{Address: []byte{0x01}}})
+			},
+			calls: []string{"IncDroppedLog()"},
+		},
+		{
+			name: "Endpoint",
+			run: func(_ *testing.T) {
+				stats := &endpointStats{}
+				stats.recordSuccess("http://localhost:8545")
+				stats.recordError("http://localhost:8545", errors.New("failed"))
+			},
+			calls: []string{"SetEndpointHealthy(http://localhost:8545,true)", "SetEndpointHealthy(http://localhost:8545,false)"},
+		},
+		{
+			name: "PollInterval",
+			run: func(_ *testing.T) {
+				newAdaptivePoller(time.Second, time.Minute).setInterval(5 * time.Second)
+			},
+			calls: []string{"SetPollInterval(5s)"},
+		},
+		{
+			name: "RateLimit",
+			run: func(t *testing.T) {
+				limiter := newRateLimiter(100)
+				limiter.tokens = 0
+				limiter.last = time.Now()
+				_, err := limiter.wait(context.Background())
+				require.NoError(t, err)
+			},
+			calls: []string{"SetRateLimitTokens(0)", "RecordLatency(rate_limit_wait)"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake.mu.Lock()
+			fake.calls = nil
+			fake.mu.Unlock()
+
+			test.run(t)
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			require.Equal(t, test.calls, fake.calls)
+		})
+	}
+}
+
+func TestOTelRecorder(t *testing.T) {
+	r, err := NewOTelRecorder(noop.NewMeterProvider().Meter("test"))
+	require.NoError(t, err)
+
+	r.BlockProcessed(12345)
+	r.RecordLatency(OperationDecode, time.Millisecond)
+	r.IncCacheLookup(true)
+	r.IncLogCacheLookup(true)
+	r.IncAnomaly(DepositAnomalyAboveMaximum)
+	r.IncReorg()
+	r.IncDroppedLog()
+	r.SetEndpointHealthy("http://localhost:8545", true)
+	r.SetPollInterval(5 * time.Second)
+	r.SetDepositCountDifference(-1)
+	r.SetDepositRootMatches(true)
+	r.SetRateLimitTokens(2)
+
+	// Ensure that gauges report their values.
+	otel := r.(*otelRecorder)
+	require.Equal(t, int64(12345), *otel.latestBlock)
+	require.Equal(t, float64(5), *otel.pollInterval)
+	require.Equal(t, int64(-1), *otel.depositCountDifference)
+	require.Equal(t, int64(1), *otel.depositRootMatches)
+	require.Equal(t, float64(2), *otel.rateLimitTokens)
+	require.Equal(t, map[string]int64{"http://localhost:8545": 1}, otel.endpointHealthy)
+}
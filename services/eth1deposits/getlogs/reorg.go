@@ -0,0 +1,185 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ReorgEvent describes a reorganisation of the Ethereum 1 chain detected when fetching logs.
+// Deposits in blocks from StartBlock to EndBlock, inclusive, must be re-indexed.
+type ReorgEvent struct {
+	// AncestorBlock is the number of the highest block common to the old and new chains.
+	AncestorBlock uint64
+	// AncestorHash is the hash of the common ancestor block.
+	AncestorHash [32]byte
+	// OldHeadHash is the hash of the highest reorganised block on the old chain.
+	OldHeadHash [32]byte
+	// NewHeadHash is the hash of the block at the same height on the new chain.
+	NewHeadHash [32]byte
+	// StartBlock is the first block affected by the reorganisation.
+	StartBlock uint64
+	// EndBlock is the last block affected by the reorganisation.
+	EndBlock uint64
+}
+
+// ReorgHook is called when a reorganisation of the Ethereum 1 chain is detected.
+type ReorgHook func(event ReorgEvent)
+
+// blockHashHistory holds the hashes of recently processed blocks containing
+// deposits, from which a common ancestor is found after a reorganisation.
+// Hashes more than the maximum ancestor search depth below the highest block
+// are discarded.
+type blockHashHistory struct {
+	mu      sync.Mutex
+	hashes  map[uint64][32]byte
+	highest uint64
+}
+
+// add records the hash of a block.
+func (h *blockHashHistory) add(blockNumber uint64, hash []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.hashes == nil {
+		h.hashes = make(map[uint64][32]byte)
+	}
+	var blockHash [32]byte
+	copy(blockHash[:], hash)
+	h.hashes[blockNumber] = blockHash
+
+	if blockNumber <= h.highest {
+		return
+	}
+	h.highest = blockNumber
+	if h.highest < maxAncestorSearchDepth {
+		return
+	}
+	for number := range h.hashes {
+		if number < h.highest-maxAncestorSearchDepth {
+			delete(h.hashes, number)
+		}
+	}
+}
+
+// below returns the recorded hashes of blocks below the given block.
+func (h *blockHashHistory) below(blockNumber uint64) map[uint64][32]byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	res := make(map[uint64][32]byte)
+	for number, hash := range h.hashes {
+		if number < blockNumber {
+			res[number] = hash
+		}
+	}
+
+	return res
+}
+
+// discard removes the recorded hashes of blocks at or above the given block.
+func (h *blockHashHistory) discard(blockNumber uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for number := range h.hashes {
+		if number >= blockNumber {
+			delete(h.hashes, number)
+		}
+	}
+	if h.highest >= blockNumber {
+		h.highest = 0
+		for number := range h.hashes {
+			if number > h.highest {
+				h.highest = number
+			}
+		}
+	}
+}
+
+// reportReorg reports a reorganisation indicated by the given removed logs.
+func (s *Service) reportReorg(ctx context.Context, removed []*logResponse) {
+	if len(removed) == 0 {
+		return
+	}
+
+	monitorReorg()
+	event, err := s.reorgEvent(ctx, removed)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain details of Ethereum 1 chain reorganisation")
+		return
+	}
+	// Hashes of blocks above the common ancestor are from the old chain.
+	s.blockHashes.discard(event.StartBlock)
+
+	log.Warn().
+		Uint64("ancestor_block", event.AncestorBlock).
+		Str("old_head_hash", fmt.Sprintf("%#x", event.OldHeadHash)).
+		Str("new_head_hash", fmt.Sprintf("%#x", event.NewHeadHash)).
+		Uint64("start_block", event.StartBlock).
+		Uint64("end_block", event.EndBlock).
+		Msg("Ethereum 1 chain reorganisation detected")
+	if s.reorgHook != nil {
+		s.reorgHook(*event)
+	}
+}
+
+// reorgEvent builds the event for a reorganisation indicated by the given removed logs.
+// If no hashes are known below the lowest removed block its parent is taken to be
+// the common ancestor, as blocks without deposits do not result in removed logs.
+func (s *Service) reorgEvent(ctx context.Context, removed []*logResponse) (*ReorgEvent, error) {
+	lowest := removed[0]
+	highest := removed[0]
+	for _, logEntry := range removed[1:] {
+		if logEntry.BlockNumber < lowest.BlockNumber {
+			lowest = logEntry
+		}
+		if logEntry.BlockNumber > highest.BlockNumber {
+			highest = logEntry
+		}
+	}
+	if lowest.BlockNumber == 0 {
+		return nil, errors.New("genesis block cannot be reorganised")
+	}
+
+	event := &ReorgEvent{
+		AncestorBlock: lowest.BlockNumber - 1,
+		EndBlock:      highest.BlockNumber,
+	}
+	copy(event.OldHeadHash[:], highest.BlockHash)
+
+	var err error
+	if knownHashes := s.blockHashes.below(lowest.BlockNumber); len(knownHashes) > 0 {
+		event.AncestorBlock, err = s.FindCommonAncestor(ctx, event.AncestorBlock, knownHashes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to find common ancestor")
+		}
+	}
+	event.StartBlock = event.AncestorBlock + 1
+
+	event.AncestorHash, err = s.blockHashByNumber(ctx, event.AncestorBlock)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain hash of common ancestor")
+	}
+	event.NewHeadHash, err = s.blockHashByNumber(ctx, event.EndBlock)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain hash of new head")
+	}
+
+	return event, nil
+}
@@ -0,0 +1,129 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReorgHook(t *testing.T) {
+	ctx := context.Background()
+
+	// The node has reorganised to fork 2 from block 0x39e9a8, removing the deposit in block 0x39e9b3.
+	forkBlock := uint64(0x39e9a8)
+	removedLog := strings.Replace(testDepositLog, `"removed":false`, `"removed":true`, 1)
+	removedLog = strings.Replace(removedLog,
+		"0xfa3a6f5e2f5781bbdd4c68aa6ddd9ac3de8523188a9f8a71451007ad7f2c33c4",
+		fmt.Sprintf("%#x", testBlockHash(1, 0x39e9b3)),
+		1,
+	)
+	results := make(map[string]string)
+	for k, v := range testRPCResults {
+		results[k] = v
+	}
+	results["eth_getLogs"] = `[` + removedLog + `]`
+	stub := newRPCStub(t, results)
+	stub.setResultFunc("eth_getBlockByNumber", func(params []json.RawMessage) string {
+		var blockNumberStr string
+		if err := json.Unmarshal(params[0], &blockNumberStr); err != nil {
+			return "null"
+		}
+		blockNumber, err := strconv.ParseUint(strings.TrimPrefix(blockNumberStr, "0x"), 16, 64)
+		if err != nil {
+			return "null"
+		}
+		fork := byte(1)
+		if blockNumber >= forkBlock {
+			fork = 2
+		}
+
+		return fmt.Sprintf(`{"hash":"%#x"}`, testBlockHash(fork, blockNumber))
+	})
+
+	events := make([]ReorgEvent, 0)
+	s := newTestService(t, stub.server.URL)
+	s.reorgHook = func(event ReorgEvent) {
+		events = append(events, event)
+	}
+	// The service has processed deposits in blocks up to 0x39e9b3 on fork 1.
+	for i := uint64(0x39e990); i <= 0x39e9b3; i++ {
+		hash := testBlockHash(1, i)
+		s.blockHashes.add(i, hash[:])
+	}
+
+	deposits, err := s.depositsForBlocks(ctx, 0x39e9b0, 0x39e9bf)
+	require.NoError(t, err)
+	require.Empty(t, deposits)
+
+	require.Equal(t, []ReorgEvent{
+		{
+			AncestorBlock: 0x39e9a7,
+			AncestorHash:  testBlockHash(1, 0x39e9a7),
+			OldHeadHash:   testBlockHash(1, 0x39e9b3),
+			NewHeadHash:   testBlockHash(2, 0x39e9b3),
+			StartBlock:    0x39e9a8,
+			EndBlock:      0x39e9b3,
+		},
+	}, events)
+
+	// Hashes from the old chain should have been discarded.
+	require.Len(t, s.blockHashes.below(0x39e9c0), 0x39e9a8-0x39e990)
+}
+
+func TestReorgHookNoKnownHashes(t *testing.T) {
+	ctx := context.Background()
+
+	stub := newRPCStub(t, map[string]string{})
+	stub.setResultFunc("eth_getBlockByNumber", func(params []json.RawMessage) string {
+		var blockNumberStr string
+		if err := json.Unmarshal(params[0], &blockNumberStr); err != nil {
+			return "null"
+		}
+		blockNumber, err := strconv.ParseUint(strings.TrimPrefix(blockNumberStr, "0x"), 16, 64)
+		if err != nil {
+			return "null"
+		}
+
+		return fmt.Sprintf(`{"hash":"%#x"}`, testBlockHash(2, blockNumber))
+	})
+
+	var event *ReorgEvent
+	s := newTestService(t, stub.server.URL)
+	s.reorgHook = func(e ReorgEvent) {
+		event = &e
+	}
+
+	oldHash1 := testBlockHash(1, 1005)
+	oldHash2 := testBlockHash(1, 1010)
+	s.reportReorg(ctx, []*logResponse{
+		{BlockNumber: 1010, BlockHash: oldHash2[:], Removed: true},
+		{BlockNumber: 1005, BlockHash: oldHash1[:], Removed: true},
+	})
+
+	// Without known hashes the parent of the lowest removed block is the ancestor.
+	require.NotNil(t, event)
+	require.Equal(t, uint64(1004), event.AncestorBlock)
+	require.Equal(t, testBlockHash(2, 1004), event.AncestorHash)
+	require.Equal(t, oldHash2, event.OldHeadHash)
+	require.Equal(t, testBlockHash(2, 1010), event.NewHeadHash)
+	require.Equal(t, uint64(1005), event.StartBlock)
+	require.Equal(t, uint64(1010), event.EndBlock)
+}
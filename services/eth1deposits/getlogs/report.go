@@ -0,0 +1,87 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// networkNames are the names of well-known networks, by chain ID.
+var networkNames = map[uint64]string{
+	1:        "mainnet",
+	5:        "goerli",
+	17000:    "holesky",
+	11155111: "sepolia",
+}
+
+// Report is a summary of the Ethereum 1 client to which the service is connected.
+type Report struct {
+	// ChainID is the chain ID of the client.
+	ChainID uint64
+	// Network is the name of the network, or "unknown" if the chain ID is not well-known.
+	Network string
+	// ClientVersion is the version string reported by the client.
+	ClientVersion string
+	// Archive is true if the client holds historical state.
+	Archive bool
+	// HeadBlock is the number of the latest block known to the client.
+	HeadBlock uint64
+	// Syncing is true if the client is syncing.
+	Syncing bool
+}
+
+// StartupReport gathers information about the Ethereum 1 client, logs it, and returns it.
+func (s *Service) StartupReport(ctx context.Context) (*Report, error) {
+	report := &Report{}
+
+	var err error
+	report.ChainID, err = s.chainID(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain chain ID")
+	}
+	var exists bool
+	report.Network, exists = networkNames[report.ChainID]
+	if !exists {
+		report.Network = "unknown"
+	}
+	report.ClientVersion, err = s.clientVersion(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain client version")
+	}
+	report.Archive, err = s.archive(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain archive status")
+	}
+	report.HeadBlock, err = s.blockNumber(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain head block")
+	}
+	report.Syncing, err = s.syncing(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain sync state")
+	}
+
+	log.Info().
+		Uint64("chain_id", report.ChainID).
+		Str("network", report.Network).
+		Str("client_version", report.ClientVersion).
+		Bool("archive", report.Archive).
+		Uint64("head_block", report.HeadBlock).
+		Bool("syncing", report.Syncing).
+		Msg("Ethereum 1 client")
+
+	return report, nil
+}
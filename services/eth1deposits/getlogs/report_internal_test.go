@@ -0,0 +1,105 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupReport(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		results map[string]string
+		report  *Report
+		err     string
+	}{
+		{
+			name: "ArchiveSyncing",
+			results: map[string]string{
+				"eth_chainId":        `"0x5"`,
+				"web3_clientVersion": `"Geth/v1.12.0-stable/linux-amd64/go1.20.5"`,
+				"eth_getBalance":     `"0x0"`,
+				"eth_blockNumber":    `"0x39e9c0"`,
+				"eth_syncing":        `{"startingBlock":"0x0","currentBlock":"0x39e9c0","highestBlock":"0x39ea00"}`,
+			},
+			report: &Report{
+				ChainID:       5,
+				Network:       "goerli",
+				ClientVersion: "Geth/v1.12.0-stable/linux-amd64/go1.20.5",
+				Archive:       true,
+				HeadBlock:     0x39e9c0,
+				Syncing:       true,
+			},
+		},
+		{
+			name: "PrunedSynced",
+			results: map[string]string{
+				"eth_chainId":        `"0x1"`,
+				"web3_clientVersion": `"Nethermind/v1.19.3"`,
+				"eth_blockNumber":    `"0x1000000"`,
+				"eth_syncing":        `false`,
+			},
+			report: &Report{
+				ChainID:       1,
+				Network:       "mainnet",
+				ClientVersion: "Nethermind/v1.19.3",
+				HeadBlock:     0x1000000,
+			},
+		},
+		{
+			name: "UnknownNetwork",
+			results: map[string]string{
+				"eth_chainId":        `"0x539"`,
+				"web3_clientVersion": `"Geth/v1.12.0-stable/linux-amd64/go1.20.5"`,
+				"eth_blockNumber":    `"0x10"`,
+				"eth_syncing":        `false`,
+			},
+			report: &Report{
+				ChainID:       1337,
+				Network:       "unknown",
+				ClientVersion: "Geth/v1.12.0-stable/linux-amd64/go1.20.5",
+				HeadBlock:     0x10,
+			},
+		},
+		{
+			name: "NoSyncState",
+			results: map[string]string{
+				"eth_chainId":        `"0x1"`,
+				"web3_clientVersion": `"Nethermind/v1.19.3"`,
+				"eth_blockNumber":    `"0x1000000"`,
+			},
+			err: "failed to obtain sync state: eth_syncing returned an error: -32601: method not found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := newRPCStub(t, test.results)
+			s := newTestService(t, stub.server.URL)
+
+			report, err := s.StartupReport(ctx)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.report, report)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// rpcRequestID is the ID sent with each JSON-RPC request.
+const rpcRequestID = 1901
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	ID      int           `json:"id"`
+}
+
+type rpcResponse[T any] struct {
+	Result T         `json:"result"`
+	Error  *rpcError `json:"error"`
+}
+
+// rpcError is an error returned by the Ethereum 1 client.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Message)
+}
+
+// call calls a JSON-RPC method on the Ethereum 1 client, decoding the result into T.
+func call[T any](ctx context.Context, s *Service, method string, params []interface{}) (T, error) {
+	var res T
+
+	if params == nil {
+		params = []interface{}{}
+	}
+	reqBody, err := json.Marshal(&rpcRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      rpcRequestID,
+	})
+	if err != nil {
+		return res, errors.Wrap(err, "failed to create request")
+	}
+
+	respBodyReader, err := s.post(ctx, "", bytes.NewReader(reqBody))
+	if err != nil {
+		log.Trace().Str("method", method).Err(err).Msg("Request failed")
+		return res, errors.Wrap(err, "request failed")
+	}
+	if respBodyReader == nil {
+		return res, errors.New("empty response")
+	}
+
+	var response rpcResponse[T]
+	if err := json.NewDecoder(respBodyReader).Decode(&response); err != nil {
+		return res, errors.Wrap(err, "invalid response")
+	}
+	if response.Error != nil {
+		return res, errors.Wrap(response.Error, fmt.Sprintf("%s returned an error", method))
+	}
+
+	return response.Result, nil
+}
+
+// errBatchUnsupported is returned if the Ethereum 1 client rejects batch requests.
+var errBatchUnsupported = errors.New("batch requests not supported")
+
+type rpcBatchResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// callBatch calls a JSON-RPC method on the Ethereum 1 client once for each set of
+// parameters, sending all of the calls in a single batch request.
+// Results and errors are returned in the order of the parameters, with an error for
+// each call that failed.  If the batch as a whole fails an error is returned, being
+// errBatchUnsupported if the client rejected the batch.
+func callBatch[T any](ctx context.Context, s *Service, method string, params [][]interface{}) ([]T, []error, error) {
+	requests := make([]*rpcRequest, len(params))
+	for i := range params {
+		requests[i] = &rpcRequest{
+			JSONRPC: "2.0",
+			Method:  method,
+			Params:  params[i],
+			ID:      rpcRequestID + i,
+		}
+		if requests[i].Params == nil {
+			requests[i].Params = []interface{}{}
+		}
+	}
+	reqBody, err := json.Marshal(requests)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create request")
+	}
+
+	respBodyReader, err := s.post(ctx, "", bytes.NewReader(reqBody))
+	if err != nil {
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) && statusErr.statusCode/100 == 4 && statusErr.statusCode != http.StatusTooManyRequests {
+			return nil, nil, errors.Wrap(errBatchUnsupported, err.Error())
+		}
+		log.Trace().Str("method", method).Err(err).Msg("Batch request failed")
+		return nil, nil, errors.Wrap(err, "request failed")
+	}
+	if respBodyReader == nil {
+		return nil, nil, errors.New("empty response")
+	}
+	respBody, err := io.ReadAll(respBodyReader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read response")
+	}
+	if trimmed := bytes.TrimSpace(respBody); len(trimmed) == 0 || trimmed[0] != '[' {
+		// Clients that do not support batches return a single response, commonly an error.
+		return nil, nil, errBatchUnsupported
+	}
+
+	var responses []*rpcBatchResponse
+	if err := json.Unmarshal(respBody, &responses); err != nil {
+		return nil, nil, errors.Wrap(err, "invalid response")
+	}
+
+	// Responses can be in any order, so demultiplex them by ID.
+	results := make([]T, len(params))
+	errs := make([]error, len(params))
+	received := make([]bool, len(params))
+	for _, response := range responses {
+		index := response.ID - rpcRequestID
+		if index < 0 || index >= len(params) || received[index] {
+			continue
+		}
+		received[index] = true
+		if response.Error != nil {
+			errs[index] = errors.Wrap(response.Error, fmt.Sprintf("%s returned an error", method))
+			continue
+		}
+		if err := json.Unmarshal(response.Result, &results[index]); err != nil {
+			errs[index] = errors.Wrap(err, "invalid result")
+		}
+	}
+	for i := range received {
+		if !received[i] {
+			errs[i] = errors.New("no response in batch")
+		}
+	}
+
+	return results, errs, nil
+}
+
+// hexUint64 is an unsigned integer that is encoded in JSON as a hex string.
+type hexUint64 uint64
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (h *hexUint64) UnmarshalJSON(input []byte) error {
+	var data string
+	if err := json.Unmarshal(input, &data); err != nil {
+		return errors.Wrap(err, "invalid JSON")
+	}
+	val, err := strconv.ParseUint(strings.TrimPrefix(data, "0x"), 16, 64)
+	if err != nil {
+		return errors.Wrap(err, "invalid value")
+	}
+	*h = hexUint64(val)
+
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCall(t *testing.T) {
+	ctx := context.Background()
+
+	stub := newRPCStub(t, map[string]string{
+		"eth_chainId":        `"0x5"`,
+		"eth_getBlockByHash": `{"number":"0x39e9b3","timestamp":"0x6033cd9f"}`,
+		"eth_getLogs":        `[` + testDepositLog + `]`,
+		"eth_badQuantity":    `"0xzz"`,
+		"eth_null":           `null`,
+	})
+	s := newTestService(t, stub.server.URL)
+
+	t.Run("Quantity", func(t *testing.T) {
+		res, err := call[hexUint64](ctx, s, "eth_chainId", nil)
+		require.NoError(t, err)
+		require.Equal(t, hexUint64(5), res)
+	})
+
+	t.Run("Struct", func(t *testing.T) {
+		res, err := call[*blockByHashBlockResponse](ctx, s, "eth_getBlockByHash", []interface{}{"0x01", false})
+		require.NoError(t, err)
+		require.Equal(t, &blockByHashBlockResponse{Number: 0x39e9b3, Timestamp: 0x6033cd9f}, res)
+	})
+
+	t.Run("Slice", func(t *testing.T) {
+		res, err := call[[]*logResponse](ctx, s, "eth_getLogs", []interface{}{&getLogsParams{}})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, uint64(0x39e9b3), res[0].BlockNumber)
+	})
+
+	t.Run("Null", func(t *testing.T) {
+		res, err := call[*blockByHashBlockResponse](ctx, s, "eth_null", nil)
+		require.NoError(t, err)
+		require.Nil(t, res)
+	})
+
+	t.Run("InvalidResult", func(t *testing.T) {
+		_, err := call[hexUint64](ctx, s, "eth_badQuantity", nil)
+		require.ErrorContains(t, err, "invalid response")
+	})
+
+	t.Run("RPCError", func(t *testing.T) {
+		_, err := call[hexUint64](ctx, s, "eth_unknown", nil)
+		require.EqualError(t, err, "eth_unknown returned an error: -32601: method not found")
+		var rpcErr *rpcError
+		require.ErrorAs(t, err, &rpcErr)
+		require.Equal(t, -32601, rpcErr.Code)
+	})
+}
+
+func TestCallParams(t *testing.T) {
+	ctx := context.Background()
+
+	stub := newRPCStub(t, nil)
+	var params []json.RawMessage
+	stub.setResultFunc("eth_getBlockByNumber", func(p []json.RawMessage) string {
+		params = p
+		return `{"hash":"0xfa3a6f5e2f5781bbdd4c68aa6ddd9ac3de8523188a9f8a71451007ad7f2c33c4"}`
+	})
+	s := newTestService(t, stub.server.URL)
+
+	hash, err := s.blockHashByNumber(ctx, 0x39e9b3)
+	require.NoError(t, err)
+	require.Equal(t, byte(0xfa), hash[0])
+	require.Len(t, params, 2)
+	require.JSONEq(t, `"0x39e9b3"`, string(params[0]))
+	require.JSONEq(t, `false`, string(params[1]))
+}
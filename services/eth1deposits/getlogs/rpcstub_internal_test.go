@@ -0,0 +1,175 @@
+// Copyright © 2023 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testDepositLog is a deposit log from the Goerli deposit contract.
+var testDepositLog = `{"address":"0x8c5fecdc472e27bc447696f431e425d02dd46a8c","topics":["0x649bbc62d0e31342afea4e5cd82d4049e7e1ee912fc0889aa790803be39038c5"],"data":"0x00000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000140000000000000000000000000000000000000000000000000000000000000018000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000030b55446978b2d229265caceb97cb4d59c0187ba91fcf11675330c1a373f137fa3fb553acb663a0d83f5dbcdc17c9f4f92000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000020005db2c8fb17330066824de63245948b3c2077f39a7e6bebb46ae93da8271148000000000000000000000000000000000000000000000000000000000000000800405973070000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000060b896411caf11780020b5656c5ebf0ff3ff245e4d679d9c6860e4ccbc695a672aa59d41c27b42bb9babf4c1b458e773c708fe4fce4cfe8ae43f9630a19c938d4c18165b5a3ff5f5e5dc2bd374a8dcfa531f3e189c1ba341cd511c4cd451c488d60000000000000000000000000000000000000000000000000000000000000008c58f010000000000000000000000000000000000000000000000000000000000","blockNumber":"0x39e9b3","transactionHash":"0x4428f17853c0237564eb7d97651fbb3390f444d223de5459799144cace695f91","transactionIndex":"0x0","blockHash":"0xfa3a6f5e2f5781bbdd4c68aa6ddd9ac3de8523188a9f8a71451007ad7f2c33c4","logIndex":"0x0","removed":false}`
+
+// testRPCResults are the results returned by the stub for each method.
+var testRPCResults = map[string]string{
+	"eth_blockNumber":           `"0x39e9c0"`,
+	"eth_chainId":               `"0x5"`,
+	"eth_getLogs":               `[` + testDepositLog + `]`,
+	"eth_getTransactionByHash":  `{"from":"0x388ea662ef2c223ec0b047d41bf3c0f362142ad5","gasPrice":"0x3b9aca00"}`,
+	"eth_getTransactionReceipt": `{"blockHash":"0xfa3a6f5e2f5781bbdd4c68aa6ddd9ac3de8523188a9f8a71451007ad7f2c33c4","blockNumber":"0x39e9b3","from":"0x388ea662ef2c223ec0b047d41bf3c0f362142ad5","to":"0x8c5fecdc472e27bc447696f431e425d02dd46a8c","cumulativeGasUsed":"0x1a3b6","gasUsed":"0x1a3b6","logs":[]}`,
+	"eth_getBlockByHash":        `{"timestamp":"0x6033cd9f"}`,
+}
+
+// rpcStub is a stub Ethereum 1 JSON-RPC server.
+type rpcStub struct {
+	server  *httptest.Server
+	mu      sync.Mutex
+	results map[string]string
+	// resultFuncs generate results from the request parameters, and take precedence over results.
+	resultFuncs map[string]func(params []json.RawMessage) string
+	calls       map[string]int
+	requests    int
+	// rejectBatches causes batch requests to be rejected.
+	rejectBatches bool
+}
+
+type rpcStubRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// newRPCStub creates a stub server that returns the given result for each method.
+func newRPCStub(t *testing.T, results map[string]string) *rpcStub {
+	t.Helper()
+
+	stub := &rpcStub{
+		results:     results,
+		resultFuncs: make(map[string]func(params []json.RawMessage) string),
+		calls:       make(map[string]int),
+	}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		stub.mu.Lock()
+		stub.requests++
+		rejectBatches := stub.rejectBatches
+		stub.mu.Unlock()
+
+		if len(body) > 0 && body[0] == '[' && !rejectBatches {
+			var reqs []*rpcStubRequest
+			if err := json.Unmarshal(body, &reqs); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			// Respond in reverse order, as clients are free to reorder batch responses.
+			responses := make([]string, len(reqs))
+			for i := range reqs {
+				responses[len(reqs)-1-i] = stub.respond(reqs[i])
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(responses, ","))
+			return
+		}
+
+		var req rpcStubRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, stub.respond(&req))
+	}))
+	t.Cleanup(stub.server.Close)
+
+	return stub
+}
+
+// respond returns the response to a request.
+// A result function can return a result beginning with "error:" to return an error.
+func (s *rpcStub) respond(req *rpcStubRequest) string {
+	s.mu.Lock()
+	s.calls[req.Method]++
+	result, exists := s.results[req.Method]
+	resultFunc, isFunc := s.resultFuncs[req.Method]
+	s.mu.Unlock()
+	// Result functions are called without the lock held, to allow concurrent requests.
+	if isFunc {
+		result, exists = resultFunc(req.Params), true
+	}
+	if !exists {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"method not found"}}`, req.ID)
+	}
+	if strings.HasPrefix(result, "error:") {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"error":{"code":-32000,"message":%q}}`, req.ID, strings.TrimPrefix(result, "error:"))
+	}
+
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+}
+
+// requestCount returns the number of HTTP requests made to the stub.
+func (s *rpcStub) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// setResultFunc sets a function to generate the result for the given method.
+func (s *rpcStub) setResultFunc(method string, resultFunc func(params []json.RawMessage) string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resultFuncs[method] = resultFunc
+}
+
+// callCount returns the number of calls made to the given method.
+func (s *rpcStub) callCount(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[method]
+}
+
+// newTestService creates a minimal service talking to the given URL.
+func newTestService(t *testing.T, address string) *Service {
+	t.Helper()
+
+	base, err := url.Parse(address)
+	require.NoError(t, err)
+
+	return &Service{
+		timeout:          5 * time.Second,
+		base:             base,
+		client:           &http.Client{},
+		blockTimestamps:  make(map[[32]byte]time.Time),
+		blocksPerRequest: 64,
+		poller:           newAdaptivePoller(12*time.Second, 2*time.Minute),
+		depositContractAddress: []byte{
+			0x8c, 0x5f, 0xec, 0xdc, 0x47, 0x2e, 0x27, 0xbc, 0x44, 0x76,
+			0x96, 0xf4, 0x31, 0xe4, 0x25, 0xd0, 0x2d, 0xd4, 0x6a, 0x8c,
+		},
+	}
+}
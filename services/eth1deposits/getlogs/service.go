@@ -21,12 +21,17 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+	"go.uber.org/atomic"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -35,16 +40,60 @@ var log zerolog.Logger
 
 // Service is an Ethereum 1 deposits service that fetches deposits through fetching logs.
 type Service struct {
-	chainDB                chaindb.Service
-	timeout                time.Duration
-	base                   *url.URL
-	client                 *http.Client
-	eth1DepositsSetter     chaindb.ETH1DepositsSetter
-	eth1Confirmations      uint64
-	blockTimestamps        map[[32]byte]time.Time
-	blocksPerRequest       uint64
+	chainDB chaindb.Service
+	timeout time.Duration
+	// base is the URL of the Ethereum 1 client, which can be replaced through rediscovery.
+	base               *url.URL
+	baseMu             sync.RWMutex
+	rediscovery        *rediscovery
+	client             *http.Client
+	eth1DepositsSetter chaindb.ETH1DepositsSetter
+	eth1Confirmations  uint64
+	blockTimestamps    map[[32]byte]time.Time
+	blocksPerRequest   uint64
+	// Batching of log requests; logs are fetched in batches of more than one range.
+	logRangesPerBatch uint64
+	batchUnsupported  atomic.Bool
+	logBatch          logBatch
+	// Decoding of deposits, and prefetching of logs when decoding concurrently.
+	decodeConcurrency      int
+	logPrefetch            logPrefetch
 	depositContractAddress []byte
-	activitySem            *semaphore.Weighted
+	// depositEventABI is the ABI of the configured deposit contract version; nil to detect the version of each log.
+	depositEventABI *depositEventABI
+	// depositContractCodeHash overrides the known hash of the deposit contract code,
+	// and skipCodeHashCheck skips the check of the code altogether.
+	depositContractCodeHash []byte
+	skipCodeHashCheck       bool
+	activitySem             *semaphore.Weighted
+	depositCache            *depositCache
+	logCache                *logCache
+	depositStates           *depositStateCache
+	poller                  *adaptivePoller
+	idempotencyHeader       string
+	requestRetries          int
+	retryBackoff            time.Duration
+	// Retries of log requests for blocks the client has yet to index.
+	blockNotFoundRetries int
+	blockNotFoundBackoff time.Duration
+	rateLimiter          *rateLimiter
+	endpoints            endpointStats
+	// Domain for verification of deposit signatures; nil if not enabled.
+	depositDomain *phase0.Domain
+	// Checks for anomalous deposits.
+	depositThresholds  depositThresholds
+	depositAnomalyHook DepositAnomalyHook
+	// Reporting of chain reorganisations.
+	blockHashes blockHashHistory
+	reorgHook   ReorgHook
+	// Contract addresses from which fetched logs are accepted.
+	logAddressAllowlist logAddressAllowlist
+	// Processors applied in order to fetched logs.
+	logProcessors []LogProcessor
+	// Reconciliation with the beacon chain; nil if not enabled.
+	beaconStateProvider       eth2client.BeaconStateProvider
+	eth1DepositsCountProvider chaindb.ETH1DepositsCountProvider
+	reconcileInterval         time.Duration
 }
 
 // New creates a new Ethereum 1 deposit service.
@@ -55,27 +104,32 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "eth1deposits").Str("impl", "getlogs").Logger()
-	if parameters.logLevel != log.GetLevel() {
-		log = log.Level(parameters.logLevel)
-	}
+	log = util.ServiceLogger(zerologger.With().Str("service", "eth1deposits").Str("impl", "getlogs").Logger(), "eth1deposits", parameters.logLevel)
 
-	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+	if parameters.metricsRecorder != nil {
+		recorder = parameters.metricsRecorder
+	} else if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
 	}
 
 	// Connect to Ethereum 1.
-	connectionURL := parameters.connectionURL
-	if !strings.HasPrefix(connectionURL, "http") {
-		connectionURL = fmt.Sprintf("http://%s", parameters.connectionURL)
-	}
-	base, err := url.Parse(connectionURL)
-	if err != nil {
-		return nil, errors.Wrap(err, "invalid URL")
-	}
-
-	client := &http.Client{
-		Transport: &http.Transport{
+	var base *url.URL
+	var transport http.RoundTripper
+	if parameters.logDumpFile != "" {
+		dump, err := loadLogDump(parameters.logDumpFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load log dump")
+		}
+		log.Info().Str("file", parameters.logDumpFile).Uint64("start_block", dump.header.StartBlock).Uint64("end_block", dump.header.EndBlock).Int("logs", len(dump.logs)).Msg("Serving Ethereum 1 logs from dump")
+		// Requests do not leave the process, so the URL is a placeholder.
+		base = &url.URL{Scheme: "http", Host: "logdump"}
+		transport = newLogDumpTransport(dump, parameters.eth1Confirmations)
+	} else {
+		base, err = parseConnectionURL(parameters.connectionURL)
+		if err != nil {
+			return nil, err
+		}
+		transport = &http.Transport{
 			DialContext: (&net.Dialer{
 				Timeout:   30 * time.Second,
 				KeepAlive: 30 * time.Second,
@@ -84,7 +138,11 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 			MaxIdleConns:        64,
 			MaxIdleConnsPerHost: 64,
 			IdleConnTimeout:     384 * time.Second,
-		},
+		}
+	}
+
+	client := &http.Client{
+		Transport: transport,
 	}
 
 	spec, err := parameters.chainDB.(chaindb.ChainSpecProvider).ChainSpec(ctx)
@@ -97,17 +155,83 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("failed to obtain deposit contract address")
 	}
 
+	var depositEventABI *depositEventABI
+	if parameters.depositContractVersion != "" {
+		depositEventABI, err = depositEventABIForVersion(parameters.depositContractVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	allowedLogAddresses := parameters.allowedLogAddresses
+	if len(allowedLogAddresses) == 0 {
+		allowedLogAddresses = [][]byte{depositContractAddress}
+	}
+
 	s := &Service{
-		chainDB:                parameters.chainDB,
-		timeout:                30 * time.Second,
-		eth1DepositsSetter:     parameters.eth1DepositsSetter,
-		base:                   base,
-		client:                 client,
-		eth1Confirmations:      parameters.eth1Confirmations,
-		blockTimestamps:        make(map[[32]byte]time.Time),
-		blocksPerRequest:       64,
-		depositContractAddress: depositContractAddress,
-		activitySem:            semaphore.NewWeighted(1),
+		chainDB:                 parameters.chainDB,
+		timeout:                 30 * time.Second,
+		eth1DepositsSetter:      parameters.eth1DepositsSetter,
+		base:                    base,
+		rediscovery:             newRediscovery(parameters.discovery, parameters.rediscoveryThreshold, parameters.rediscoveryCooldown),
+		client:                  client,
+		eth1Confirmations:       parameters.eth1Confirmations,
+		blockTimestamps:         make(map[[32]byte]time.Time),
+		blocksPerRequest:        64,
+		logRangesPerBatch:       parameters.logRangesPerBatch,
+		depositContractAddress:  depositContractAddress,
+		activitySem:             semaphore.NewWeighted(1),
+		depositCache:            newDepositCache(parameters.depositCacheSize),
+		logCache:                newLogCache(parameters.logCacheSize, parameters.logCacheTTL),
+		depositStates:           newDepositStateCache(depositStateCacheSize),
+		poller:                  newAdaptivePoller(parameters.minPollInterval, parameters.maxPollInterval),
+		reconcileInterval:       parameters.reconcileInterval,
+		idempotencyHeader:       parameters.idempotencyHeader,
+		requestRetries:          parameters.requestRetries,
+		retryBackoff:            500 * time.Millisecond,
+		blockNotFoundRetries:    5,
+		blockNotFoundBackoff:    time.Second,
+		rateLimiter:             newRateLimiter(parameters.globalRateLimit),
+		depositThresholds:       parameters.depositThresholds,
+		depositAnomalyHook:      parameters.depositAnomalyHook,
+		reorgHook:               parameters.reorgHook,
+		logAddressAllowlist:     newLogAddressAllowlist(allowedLogAddresses),
+		logProcessors:           parameters.logProcessors,
+		depositContractCodeHash: parameters.depositContractCodeHash,
+		skipCodeHashCheck:       parameters.skipCodeHashCheck,
+		depositEventABI:         depositEventABI,
+		decodeConcurrency:       parameters.decodeConcurrency,
+	}
+	if parameters.verifySignatures {
+		domainType, exists := spec["DOMAIN_DEPOSIT"].(phase0.DomainType)
+		if !exists {
+			return nil, errors.New("failed to obtain deposit domain type")
+		}
+		genesisForkVersion, exists := spec["GENESIS_FORK_VERSION"].(phase0.Version)
+		if !exists {
+			return nil, errors.New("failed to obtain genesis fork version")
+		}
+		depositDomain, err := DepositDomain(domainType, genesisForkVersion)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to calculate deposit domain")
+		}
+		s.depositDomain = &depositDomain
+	}
+	if (parameters.eth2Client != nil || parameters.beaconStateProvider != nil) && parameters.reconcileInterval > 0 {
+		beaconStateProvider := parameters.beaconStateProvider
+		if beaconStateProvider == nil {
+			var isProvider bool
+			beaconStateProvider, isProvider = parameters.eth2Client.(eth2client.BeaconStateProvider)
+			if !isProvider {
+				return nil, errors.New("Ethereum 2 client does not provide beacon state")
+			}
+		}
+		eth1DepositsCountProvider, isProvider := parameters.chainDB.(chaindb.ETH1DepositsCountProvider)
+		if !isProvider {
+			return nil, errors.New("chain DB does not support Ethereum 1 deposit counts")
+		}
+		s.beaconStateProvider = beaconStateProvider
+		s.eth1DepositsCountProvider = eth1DepositsCountProvider
 	}
 
 	chainID, err := s.chainID(ctx)
@@ -124,6 +248,14 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		if chainID != depositChainID {
 			return nil, fmt.Errorf("incorrect Ethereum 1 client chain ID %d", chainID)
 		}
+		if err := s.verifyDepositContractCode(ctx, chainID); err != nil {
+			return nil, errors.Wrap(err, "failed to verify deposit contract")
+		}
+	}
+
+	if _, err := s.StartupReport(ctx); err != nil {
+		// The report is informational, so do not fail.
+		log.Warn().Err(err).Msg("Failed to obtain Ethereum 1 client report")
 	}
 
 	startBlock, err := strconv.ParseInt(parameters.startBlock, 10, 64)
@@ -133,9 +265,32 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	go s.updateAfterRestart(ctx, startBlock)
 
+	if parameters.watchdog != nil {
+		blockTime, exists := spec["SECONDS_PER_ETH1_BLOCK"].(time.Duration)
+		if !exists {
+			blockTime = 12 * time.Second
+		}
+		if err := s.registerWatchdog(ctx, parameters.watchdog, blockTime); err != nil {
+			return nil, errors.Wrap(err, "failed to register with watchdog")
+		}
+	}
+
 	return s, nil
 }
 
+// parseConnectionURL parses the URL of the Ethereum 1 client, defaulting to HTTP.
+func parseConnectionURL(connectionURL string) (*url.URL, error) {
+	if !strings.HasPrefix(connectionURL, "http") {
+		connectionURL = fmt.Sprintf("http://%s", connectionURL)
+	}
+	base, err := url.Parse(connectionURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid URL")
+	}
+
+	return base, nil
+}
+
 func (s *Service) updateAfterRestart(ctx context.Context, startBlock int64) {
 	// Work out the block from which to start.
 	md, err := s.getMetadata(ctx)
@@ -161,11 +316,15 @@ func (s *Service) updateAfterRestart(ctx context.Context, startBlock int64) {
 	}
 	log.Info().Msg("Caught up")
 
-	// Run periodically.
+	if s.beaconStateProvider != nil {
+		go s.reconcilePeriodically(ctx, s.reconcileInterval)
+	}
+
+	// Run periodically, at an interval that tracks the rate of block production.
 	go func(ctx context.Context, s *Service) {
 		for {
 			select {
-			case <-time.After(2 * time.Minute):
+			case <-time.After(s.poller.current()):
 				s.checkLatestBlock(ctx)
 			case <-ctx.Done():
 				log.Debug().Msg("Context done")
@@ -180,6 +339,7 @@ func (s *Service) getLatestHeadBlock(ctx context.Context) (uint64, error) {
 	if err != nil {
 		return 0, errors.Wrap(err, "failed to obtain block number")
 	}
+	s.poller.observe(head, time.Now())
 	if head > s.eth1Confirmations {
 		return head - s.eth1Confirmations, nil
 	}
@@ -216,13 +376,28 @@ func (s *Service) parseNewBlocks(ctx context.Context, md *metadata) {
 	}
 
 	log.Trace().Uint64("start_block", md.LatestBlock+1).Uint64("end_block", latestHeadBlock).Msg("Fetching ETH1 logs in batches")
-	for block := md.LatestBlock + 1; block <= latestHeadBlock; block += s.blocksPerRequest {
+	firstBlock := md.LatestBlock + 1
+	for block := firstBlock; block <= latestHeadBlock; block += s.blocksPerRequest {
+		if s.logRangesPerBatch > 1 && ((block-firstBlock)/s.blocksPerRequest)%s.logRangesPerBatch == 0 {
+			s.batchLogs(ctx, block, latestHeadBlock)
+		}
 		startBlock := block
 		endBlock := block + s.blocksPerRequest - 1
 		if endBlock > latestHeadBlock {
 			endBlock = latestHeadBlock
 		}
 
+		if s.decodeConcurrency > 1 && s.logRangesPerBatch == 1 && endBlock < latestHeadBlock {
+			// Fetch the logs for the next range while this range is handled.
+			nextEndBlock := endBlock + s.blocksPerRequest
+			if nextEndBlock > latestHeadBlock {
+				nextEndBlock = latestHeadBlock
+			}
+			if !s.depositCache.contains(endBlock+1, nextEndBlock) {
+				s.logPrefetch.start(ctx, s, endBlock+1, nextEndBlock)
+			}
+		}
+
 		log := log.With().Uint64("start_block", startBlock).Uint64("end_block", endBlock).Logger()
 		// Each update goes in to its own transaction, to make the data available sooner.
 		ctx, cancel, err := s.chainDB.BeginTx(ctx)
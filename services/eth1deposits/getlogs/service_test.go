@@ -56,6 +56,17 @@ func TestService(t *testing.T) {
 			},
 			err: "problem with parameters: no connection URL specified",
 		},
+		{
+			name: "DecodeConcurrencyZero",
+			params: []getlogs.Parameter{
+				getlogs.WithLogLevel(zerolog.Disabled),
+				getlogs.WithChainDB(chainDB),
+				getlogs.WithETH1DepositsSetter(chainDB),
+				getlogs.WithConnectionURL(os.Getenv("EXECCLIENT_URL")),
+				getlogs.WithDecodeConcurrency(0),
+			},
+			err: "problem with parameters: decode concurrency must be at least 1",
+		},
 		{
 			name: "Good",
 			params: []getlogs.Parameter{
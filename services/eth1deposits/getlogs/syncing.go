@@ -0,0 +1,31 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+// syncing fetches the sync state of the Ethereum 1 client.
+// The client returns false if it is not syncing, or an object describing its progress if it is.
+func (s *Service) syncing(ctx context.Context) (bool, error) {
+	result, err := call[json.RawMessage](ctx, s, "eth_syncing", nil)
+	if err != nil {
+		return false, err
+	}
+
+	return !bytes.Equal(result, []byte("false")), nil
+}
@@ -14,6 +14,7 @@
 package getlogs
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -23,11 +24,13 @@ import (
 )
 
 type transaction struct {
+	From     []byte
 	GasPrice uint64
 }
 
 //nolint:tagliatelle
 type transactionJSON struct {
+	From     string `json:"from,omitempty"`
 	GasPrice string `json:"gasPrice"`
 }
 
@@ -39,6 +42,12 @@ func (t *transaction) UnmarshalJSON(input []byte) error {
 		return errors.Wrap(err, "invalid JSON")
 	}
 
+	if transactionJSON.From != "" {
+		t.From, err = hex.DecodeString(strings.TrimPrefix(transactionJSON.From, "0x"))
+		if err != nil {
+			return errors.Wrap(err, "invalid value for from")
+		}
+	}
 	if transactionJSON.GasPrice == "" {
 		return errors.New("gas price missing")
 	}
@@ -52,7 +61,13 @@ func (t *transaction) UnmarshalJSON(input []byte) error {
 
 // MarshalJSON implements json.Marshaler.
 func (t *transaction) MarshalJSON() ([]byte, error) {
+	from := ""
+	if len(t.From) > 0 {
+		from = fmt.Sprintf("%#x", t.From)
+	}
+
 	return json.Marshal(&transactionJSON{
+		From:     from,
 		GasPrice: fmt.Sprintf("%#x", t.GasPrice),
 	})
 }
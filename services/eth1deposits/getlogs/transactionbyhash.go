@@ -14,41 +14,77 @@
 package getlogs
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/url"
 
 	"github.com/pkg/errors"
 )
 
-type transactionByHashResponse struct {
-	Result *transaction `json:"result"`
+// transactionByHash fetches a transaction given its hash.
+// It returns nil if the transaction is unknown.
+func (s *Service) transactionByHash(ctx context.Context, txHash []byte) (*transaction, error) {
+	return call[*transaction](ctx, s, "eth_getTransactionByHash", []interface{}{fmt.Sprintf("%#x", txHash)})
 }
 
-// transactionByHash fetches a transaction receipt given its hash.
-func (s *Service) transactionByHash(ctx context.Context, txHash []byte) (*transaction, error) {
-	reference, err := url.Parse("")
-	if err != nil {
-		return nil, errors.Wrap(err, "invalid endpoint")
+// transactionsByHash fetches a number of transactions given their hashes, returning
+// them keyed by hash.
+// If the Ethereum 1 client supports batch requests all transactions are fetched in a
+// single request, otherwise they are fetched one at a time.  An unknown transaction
+// is an error.
+func (s *Service) transactionsByHash(ctx context.Context, txHashes [][]byte) (map[[32]byte]*transaction, error) {
+	// Multiple deposits can be made in a single transaction, so only fetch each once.
+	hashes := make([][32]byte, 0, len(txHashes))
+	res := make(map[[32]byte]*transaction, len(txHashes))
+	for _, txHash := range txHashes {
+		var hash [32]byte
+		copy(hash[:], txHash)
+		if _, exists := res[hash]; exists {
+			continue
+		}
+		res[hash] = nil
+		hashes = append(hashes, hash)
 	}
-	url := s.base.ResolveReference(reference).String()
 
-	reqBody := bytes.NewBufferString(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getTransactionByHash","params":["%#x"],"id":1901}`, txHash))
-	respBodyReader, err := s.post(ctx, url, reqBody)
-	if err != nil {
-		log.Trace().Str("url", url).Err(err).Msg("Request failed")
-		return nil, errors.Wrap(err, "request failed")
-	}
-	if respBodyReader == nil {
-		return nil, errors.New("empty response")
+	if len(hashes) > 1 && !s.batchUnsupported.Load() {
+		params := make([][]interface{}, len(hashes))
+		for i := range hashes {
+			params[i] = []interface{}{fmt.Sprintf("%#x", hashes[i])}
+		}
+		txs, errs, err := callBatch[*transaction](ctx, s, "eth_getTransactionByHash", params)
+		switch {
+		case errors.Is(err, errBatchUnsupported):
+			log.Info().Err(err).Msg("Ethereum 1 client does not support batch requests; fetching transactions sequentially")
+			s.batchUnsupported.Store(true)
+		case err != nil:
+			return nil, err
+		default:
+			for i := range hashes {
+				if errs[i] != nil {
+					// Fetch any transaction that failed within the batch on its own.
+					log.Debug().Str("tx_hash", fmt.Sprintf("%#x", hashes[i])).Err(errs[i]).Msg("Batched request failed; refetching")
+					continue
+				}
+				if txs[i] == nil {
+					return nil, fmt.Errorf("no transaction returned for hash %#x", hashes[i])
+				}
+				res[hashes[i]] = txs[i]
+			}
+		}
 	}
 
-	var response transactionByHashResponse
-	if err := json.NewDecoder(respBodyReader).Decode(&response); err != nil {
-		return nil, errors.Wrap(err, "invalid response")
+	for _, hash := range hashes {
+		if res[hash] != nil {
+			continue
+		}
+		tx, err := s.transactionByHash(ctx, hash[:])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain transaction from transaction hash")
+		}
+		if tx == nil {
+			return nil, fmt.Errorf("no transaction returned for hash %#x", hash)
+		}
+		res[hash] = tx
 	}
 
-	return response.Result, nil
+	return res, nil
 }
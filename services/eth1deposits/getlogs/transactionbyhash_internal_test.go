@@ -0,0 +1,131 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testTransaction is the transaction containing testDepositLog, in the form returned by eth_getTransactionByHash.
+var testTransaction = `{"blockHash":"0xfa3a6f5e2f5781bbdd4c68aa6ddd9ac3de8523188a9f8a71451007ad7f2c33c4","blockNumber":"0x39e9b3","from":"0x388ea662ef2c223ec0b047d41bf3c0f362142ad5","gas":"0x2dc6c0","gasPrice":"0x3b9aca00","hash":"0x4428f17853c0237564eb7d97651fbb3390f444d223de5459799144cace695f91","input":"0x22895118","nonce":"0x1d","to":"0x8c5fecdc472e27bc447696f431e425d02dd46a8c","transactionIndex":"0x0","value":"0x1bc16d674ec800000","type":"0x0","chainId":"0x5","v":"0x2e","r":"0x6a2dfb8e03d16bb56c8bbf3f8b3cba1a5de4c9ecd0ec3c09dd7e5bd28dd66fc3","s":"0x3c1c84b2d1a3fdf0b58ef9b1df2ba7cf6a8c52a3ed6b72e5f04b3dc0f2c63b09"}`
+
+func TestTransactionSender(t *testing.T) {
+	var tx transaction
+	require.NoError(t, json.Unmarshal([]byte(testTransaction), &tx))
+	require.Equal(t, "388ea662ef2c223ec0b047d41bf3c0f362142ad5", hex.EncodeToString(tx.From))
+	require.Equal(t, uint64(1000000000), tx.GasPrice)
+
+	// The sender is optional.
+	require.NoError(t, json.Unmarshal([]byte(`{"gasPrice":"0x3b9aca00"}`), &tx))
+}
+
+func TestTransactionsByHash(t *testing.T) {
+	ctx := context.Background()
+
+	known := "0x4428f17853c0237564eb7d97651fbb3390f444d223de5459799144cace695f91"
+	unknown := "0x1111111111111111111111111111111111111111111111111111111111111111"
+	txResult := func(params []json.RawMessage) string {
+		var hash string
+		if err := json.Unmarshal(params[0], &hash); err != nil {
+			return `"bad params"`
+		}
+		if hash == unknown {
+			return "null"
+		}
+		return strings.Replace(testTransaction, known, hash, 1)
+	}
+	hashes := func(input ...string) [][]byte {
+		res := make([][]byte, len(input))
+		for i := range input {
+			var err error
+			res[i], err = hex.DecodeString(strings.TrimPrefix(input[i], "0x"))
+			require.NoError(t, err)
+		}
+		return res
+	}
+	other := "0x2222222222222222222222222222222222222222222222222222222222222222"
+
+	tests := []struct {
+		name          string
+		rejectBatches bool
+		hashes        [][]byte
+		requests      int
+		calls         int
+		err           string
+	}{
+		{
+			name:     "Single",
+			hashes:   hashes(known),
+			requests: 1,
+			calls:    1,
+		},
+		{
+			name: "Batched",
+			// Duplicate hashes, from multiple deposits in a transaction, are fetched once.
+			hashes:   hashes(known, other, known),
+			requests: 1,
+			calls:    2,
+		},
+		{
+			name:          "Unsupported",
+			rejectBatches: true,
+			hashes:        hashes(known, other),
+			// The rejected batch, followed by each transaction in turn.
+			requests: 3,
+			calls:    2,
+		},
+		{
+			name:     "Unknown",
+			hashes:   hashes(known, unknown),
+			requests: 1,
+			calls:    2,
+			err:      "no transaction returned for hash " + unknown,
+		},
+		{
+			name:     "UnknownSingle",
+			hashes:   hashes(unknown),
+			requests: 1,
+			calls:    1,
+			err:      "no transaction returned for hash " + unknown,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := newRPCStub(t, map[string]string{})
+			stub.rejectBatches = test.rejectBatches
+			stub.setResultFunc("eth_getTransactionByHash", txResult)
+			s := newTestService(t, stub.server.URL)
+
+			txs, err := s.transactionsByHash(ctx, test.hashes)
+			require.Equal(t, test.requests, stub.requestCount())
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.calls, stub.callCount("eth_getTransactionByHash"))
+			require.Len(t, txs, test.calls)
+			for _, tx := range txs {
+				require.Equal(t, "388ea662ef2c223ec0b047d41bf3c0f362142ad5", hex.EncodeToString(tx.From))
+			}
+		})
+	}
+}
@@ -14,41 +14,11 @@
 package getlogs
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/url"
-
-	"github.com/pkg/errors"
 )
 
-type transactionReceiptByHashResponse struct {
-	Result *transactionReceipt `json:"result"`
-}
-
 // transactionReceiptByHash fetches a transaction receipt given its hash.
 func (s *Service) transactionReceiptByHash(ctx context.Context, txHash []byte) (*transactionReceipt, error) {
-	reference, err := url.Parse("")
-	if err != nil {
-		return nil, errors.Wrap(err, "invalid endpoint")
-	}
-	url := s.base.ResolveReference(reference).String()
-
-	reqBody := bytes.NewBufferString(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getTransactionReceipt","params":["%#x"],"id":1901}`, txHash))
-	respBodyReader, err := s.post(ctx, url, reqBody)
-	if err != nil {
-		log.Trace().Str("url", url).Err(err).Msg("Request failed")
-		return nil, errors.Wrap(err, "request failed")
-	}
-	if respBodyReader == nil {
-		return nil, errors.New("empty response")
-	}
-
-	var response transactionReceiptByHashResponse
-	if err := json.NewDecoder(respBodyReader).Decode(&response); err != nil {
-		return nil, errors.Wrap(err, "invalid response")
-	}
-
-	return response.Result, nil
+	return call[*transactionReceipt](ctx, s, "eth_getTransactionReceipt", []interface{}{fmt.Sprintf("%#x", txHash)})
 }
@@ -0,0 +1,90 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	bls "github.com/kilic/bls12-381"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// signatureDST is the domain separation tag for Ethereum 2 BLS signatures.
+var signatureDST = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+
+// DepositDomain returns the signature domain for deposits on the chain with the given genesis fork version.
+// Deposits are valid across forks, so the domain uses the genesis fork version and an empty genesis validators root.
+func DepositDomain(domainType phase0.DomainType, genesisForkVersion phase0.Version) (phase0.Domain, error) {
+	forkData := &phase0.ForkData{
+		CurrentVersion: genesisForkVersion,
+	}
+	root, err := forkData.HashTreeRoot()
+	if err != nil {
+		return phase0.Domain{}, errors.Wrap(err, "failed to calculate fork data root")
+	}
+
+	var domain phase0.Domain
+	copy(domain[:], domainType[:])
+	copy(domain[4:], root[:])
+
+	return domain, nil
+}
+
+// VerifyDeposit verifies the signature of the deposit against its public key and deposit message.
+// A malformed public key or signature results in the deposit being invalid rather than an error.
+func VerifyDeposit(deposit *chaindb.ETH1Deposit, domain phase0.Domain) (bool, error) {
+	if len(deposit.WithdrawalCredentials) != 32 {
+		return false, errors.New("withdrawal credentials must be 32 bytes")
+	}
+
+	depositMessage := &phase0.DepositMessage{
+		PublicKey:             deposit.ValidatorPubKey,
+		WithdrawalCredentials: deposit.WithdrawalCredentials,
+		Amount:                deposit.Amount,
+	}
+	objectRoot, err := depositMessage.HashTreeRoot()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to calculate deposit message root")
+	}
+	signingData := &phase0.SigningData{
+		ObjectRoot: objectRoot,
+		Domain:     domain,
+	}
+	signingRoot, err := signingData.HashTreeRoot()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to calculate signing root")
+	}
+
+	g1 := bls.NewG1()
+	pubKey, err := g1.FromCompressed(deposit.ValidatorPubKey[:])
+	if err != nil || g1.IsZero(pubKey) {
+		return false, nil
+	}
+	g2 := bls.NewG2()
+	signature, err := g2.FromCompressed(deposit.Signature[:])
+	if err != nil {
+		return false, nil
+	}
+	message, err := g2.HashToCurve(signingRoot[:], signatureDST)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to hash signing root to curve")
+	}
+
+	// Check e(pubkey, H(m)) == e(g1, signature).
+	engine := bls.NewEngine()
+	engine.AddPair(pubKey, message)
+	engine.AddPairInv(g1.One(), signature)
+
+	return engine.Check(), nil
+}
@@ -0,0 +1,130 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func hexBytes(t *testing.T, input string) []byte {
+	t.Helper()
+	res, err := hex.DecodeString(input)
+	require.NoError(t, err)
+	return res
+}
+
+func TestDepositDomain(t *testing.T) {
+	domain, err := DepositDomain(phase0.DomainType{0x03, 0x00, 0x00, 0x00}, phase0.Version{0x00, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+	require.Equal(t, hexBytes(t, "03000000f5a5fd42d16a20302798ef6ed309979b43003d2320d9f0e8ea9831a9"), domain[:])
+}
+
+func TestVerifyDeposit(t *testing.T) {
+	domain, err := DepositDomain(phase0.DomainType{0x03, 0x00, 0x00, 0x00}, phase0.Version{0x00, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+
+	// Deposit signed by the first interop validator key.
+	validDeposit := func() *chaindb.ETH1Deposit {
+		deposit := &chaindb.ETH1Deposit{
+			WithdrawalCredentials: hexBytes(t, "0100000000000000000000000c0d0e0f101112131415161718191a1b1c1d1e1f"),
+			Amount:                32000000000,
+		}
+		copy(deposit.ValidatorPubKey[:], hexBytes(t, "a99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c"))
+		copy(deposit.Signature[:], hexBytes(t, "b5cac37c3b79a514fa3cf0c50fa749c3f9150a609fc0305ff670fd9fca30fd919130e273f61513136f83d23728c0184f138bf615e8421bd6ab51ffdffcaf5b8bbeb914953b153a362de7f5a06de60f2f2717e965aa50068a0e08e59bf828a95f"))
+		return deposit
+	}
+
+	tests := []struct {
+		name    string
+		deposit func() *chaindb.ETH1Deposit
+		domain  phase0.Domain
+		valid   bool
+		err     string
+	}{
+		{
+			name:    "Valid",
+			deposit: validDeposit,
+			domain:  domain,
+			valid:   true,
+		},
+		{
+			name: "AmountChanged",
+			deposit: func() *chaindb.ETH1Deposit {
+				deposit := validDeposit()
+				deposit.Amount = 1000000000
+				return deposit
+			},
+			domain: domain,
+		},
+		{
+			name:    "WrongDomain",
+			deposit: validDeposit,
+			domain:  phase0.Domain{0x03},
+		},
+		{
+			name: "SignatureInvalid",
+			deposit: func() *chaindb.ETH1Deposit {
+				deposit := validDeposit()
+				deposit.Signature[95] ^= 0x01
+				return deposit
+			},
+			domain: domain,
+		},
+		{
+			name: "SignatureZero",
+			deposit: func() *chaindb.ETH1Deposit {
+				deposit := validDeposit()
+				deposit.Signature = phase0.BLSSignature{}
+				return deposit
+			},
+			domain: domain,
+		},
+		{
+			name: "PubKeyZero",
+			deposit: func() *chaindb.ETH1Deposit {
+				deposit := validDeposit()
+				deposit.ValidatorPubKey = phase0.BLSPubKey{}
+				return deposit
+			},
+			domain: domain,
+		},
+		{
+			name: "WithdrawalCredentialsShort",
+			deposit: func() *chaindb.ETH1Deposit {
+				deposit := validDeposit()
+				deposit.WithdrawalCredentials = deposit.WithdrawalCredentials[:31]
+				return deposit
+			},
+			domain: domain,
+			err:    "withdrawal credentials must be 32 bytes",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			valid, err := VerifyDeposit(test.deposit(), test.domain)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.valid, valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"time"
+
+	"github.com/wealdtech/chaind/services/watchdog"
+)
+
+// watchdogThreshold is the number of Ethereum 1 blocks the service can fall
+// behind the head, less confirmations, before the watchdog considers it stale.
+const watchdogThreshold = 64
+
+// registerWatchdog registers the service with the watchdog.
+func (s *Service) registerWatchdog(ctx context.Context, wd watchdog.Service, blockTime time.Duration) error {
+	return wd.Register(ctx, &watchdog.Dataset{
+		Name:      "eth1deposits",
+		Progress:  s.BlockProgress,
+		Cadence:   blockTime,
+		Threshold: watchdogThreshold,
+		Recover: func(_ context.Context) error {
+			// Fetch new blocks in the background using the service's context.
+			go s.checkLatestBlock(ctx)
+			return nil
+		},
+	})
+}
@@ -27,6 +27,21 @@ import (
 	"github.com/wealdtech/chaind/services/chaindb"
 )
 
+// errBeforeOrigin is returned when a block is from before the ingestion origin.
+var errBeforeOrigin = errors.New("block is before ingestion origin")
+
+// finalityStats are the changes made to blocks by the finalizer.
+type finalityStats struct {
+	canonicalized int
+	orphaned      int
+}
+
+// add adds the given stats.
+func (f *finalityStats) add(other *finalityStats) {
+	f.canonicalized += other.canonicalized
+	f.orphaned += other.orphaned
+}
+
 // OnFinalityCheckpointReceived receives finality checkpoint notifications.
 func (s *Service) OnFinalityCheckpointReceived(
 	ctx context.Context,
@@ -38,6 +53,7 @@ func (s *Service) OnFinalityCheckpointReceived(
 		Uint64("justified_epoch", uint64(finality.Justified.Epoch)).
 		Stringer("justified_bock_root", finality.Justified.Root).
 		Msg("Finality checkpoint received")
+	s.setChainFinalizedEpoch(finality.Finalized.Epoch)
 
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
@@ -53,6 +69,13 @@ func (s *Service) OnFinalityCheckpointReceived(
 	}
 	defer s.activitySem.Release(1)
 
+	started := time.Now()
+	stats := &finalityStats{}
+	defer func() {
+		monitorRun(time.Since(started), stats)
+		s.updateFinalizedEpoch(ctx)
+	}()
+
 	// We have been informed that epoch x has finalised.  At this point we can finalise
 	// all blocks up to the justified root, and all attestations within them.
 
@@ -74,10 +97,12 @@ func (s *Service) OnFinalityCheckpointReceived(
 		stack = stack[:index]
 
 		log.Trace().Uint64("update_epoch", uint64(checkpoint.Epoch)).Int("remaining", len(stack)).Msg("Updating to epoch")
-		if err := s.runFinalityTransaction(ctx, checkpoint); err != nil {
+		transactionStats, err := s.runFinalityTransaction(ctx, checkpoint)
+		if err != nil {
 			log.Error().Err(err).Msg("Failed to run finality transaction")
 			return
 		}
+		stats.add(transactionStats)
 		monitorEpochProcessed(checkpoint.Epoch)
 	}
 
@@ -146,19 +171,25 @@ func (s *Service) buildFinalityStack(ctx context.Context,
 	return stack, nil
 }
 
+// runFinalityTransaction finalizes the chain up to the given checkpoint, returning the
+// changes made to blocks.
 func (s *Service) runFinalityTransaction(
 	ctx context.Context,
 	checkpoint *phase0.Checkpoint,
-) error {
+) (
+	*finalityStats,
+	error,
+) {
 	ctx, cancel, err := s.chainDB.BeginTx(ctx)
 	if err != nil {
-		return errors.Wrap(err, "Failed to start transaction on finality")
+		return nil, errors.Wrap(err, "Failed to start transaction on finality")
 	}
 
+	stats := &finalityStats{}
 	log.Trace().Uint64("epoch", uint64(checkpoint.Epoch)).Msg("Updating canonical blocks on finality")
-	if err := s.updateCanonicalBlocks(ctx, checkpoint.Root); err != nil {
+	if err := s.updateCanonicalBlocks(ctx, checkpoint.Root, stats); err != nil {
 		cancel()
-		return errors.Wrap(err, "Failed to update canonical blocks on finality")
+		return nil, errors.Wrap(err, "Failed to update canonical blocks on finality")
 	}
 
 	if err := s.updateAttestations(ctx, checkpoint.Epoch); err != nil {
@@ -171,14 +202,14 @@ func (s *Service) runFinalityTransaction(
 
 	if err := s.chainDB.CommitTx(ctx); err != nil {
 		cancel()
-		return errors.Wrap(err, "Failed to commit transaction on finality")
+		return nil, errors.Wrap(err, "Failed to commit transaction on finality")
 	}
 
-	return nil
+	return stats, nil
 }
 
 // updateCanonicalBlocks updates all canonical blocks given a canonical block root.
-func (s *Service) updateCanonicalBlocks(ctx context.Context, root phase0.Root) error {
+func (s *Service) updateCanonicalBlocks(ctx context.Context, root phase0.Root, stats *finalityStats) error {
 	md, err := s.getMetadata(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain metadata on finality")
@@ -186,6 +217,10 @@ func (s *Service) updateCanonicalBlocks(ctx context.Context, root phase0.Root) e
 
 	// Fetch the block from either the database or the chain.
 	block, err := s.fetchBlock(ctx, root)
+	if errors.Is(err, errBeforeOrigin) {
+		log.Trace().Msg("Canonical block is before ingestion origin; nothing to canonicalize")
+		return nil
+	}
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain block")
 	}
@@ -194,14 +229,25 @@ func (s *Service) updateCanonicalBlocks(ctx context.Context, root phase0.Root) e
 	}
 	log.Trace().Uint64("slot", uint64(block.Slot)).Msg("Canonicalizing up to slot")
 
-	if err := s.canonicalizeBlocks(ctx, root, phase0.Slot(md.LatestCanonicalSlot)); err != nil {
+	recanonicalizedSlots, err := s.canonicalizeBlocks(ctx, root, phase0.Slot(md.LatestCanonicalSlot), stats)
+	if err != nil {
 		return errors.Wrap(err, "failed to update canonical blocks from canonical root")
 	}
+	if md.LastFinalizedEpoch >= 0 {
+		if err := s.updateRecanonicalizedAttestations(ctx, recanonicalizedSlots, phase0.Epoch(md.LastFinalizedEpoch)); err != nil {
+			return errors.Wrap(err, "failed to update attestations for recanonicalized blocks")
+		}
+	}
 
-	if err := s.updateIndeterminateBlocks(ctx, block.Slot); err != nil {
+	if err := s.updateIndeterminateBlocks(ctx, block.Slot, stats); err != nil {
 		return errors.Wrap(err, "failed to update indeterminate blocks from canonical root")
 	}
 
+	// The block is canonical, so any slots between it and the previous canonical slot without a canonical block were skipped.
+	if err := s.updateSkippedSlots(ctx, phase0.Slot(md.LatestCanonicalSlot+1), block.Slot); err != nil {
+		return errors.Wrap(err, "failed to update skipped slots")
+	}
+
 	md.LatestCanonicalSlot = int64(block.Slot)
 	if err := s.setMetadata(ctx, md); err != nil {
 		return errors.Wrap(err, "failed to update metadata on finality")
@@ -211,18 +257,25 @@ func (s *Service) updateCanonicalBlocks(ctx context.Context, root phase0.Root) e
 }
 
 // canonicalizeBlocks marks the given block and all its parents as canonical.
-func (s *Service) canonicalizeBlocks(ctx context.Context, root phase0.Root, limit phase0.Slot) error {
+// It returns the slots of blocks that were previously marked as non-canonical.
+func (s *Service) canonicalizeBlocks(ctx context.Context, root phase0.Root, limit phase0.Slot, stats *finalityStats) ([]phase0.Slot, error) {
 	log.Trace().Str("root", fmt.Sprintf("%#x", root)).Uint64("limit", uint64(limit)).Msg("Canonicalizing blocks")
 
+	recanonicalizedSlots := make([]phase0.Slot, 0)
+
 	for {
 		block, err := s.fetchBlock(ctx, root)
+		if errors.Is(err, errBeforeOrigin) {
+			// Reached the ingestion origin; done.
+			break
+		}
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if block == nil {
 			log.Error().Str("block_root", fmt.Sprintf("%#x", root)).Msg("Block not found for root")
-			return errors.New("block not found for root")
+			return nil, errors.New("block not found for root")
 		}
 
 		if limit != 0 && block.Slot == limit {
@@ -231,11 +284,15 @@ func (s *Service) canonicalizeBlocks(ctx context.Context, root phase0.Root, limi
 
 		// Update if the current status is either indeterminate or non-canonical.
 		if block.Canonical == nil || !*block.Canonical {
+			if block.Canonical != nil {
+				recanonicalizedSlots = append(recanonicalizedSlots, block.Slot)
+			}
 			canonical := true
 			block.Canonical = &canonical
 			if err := s.blocksSetter.SetBlock(ctx, block); err != nil {
-				return errors.Wrap(err, "failed to set block to canonical")
+				return nil, errors.Wrap(err, "failed to set block to canonical")
 			}
+			stats.canonicalized++
 			log.Trace().Uint64("slot", uint64(block.Slot)).Str("root", fmt.Sprintf("%#x", block.Root)).Msg("Block is canonical")
 		}
 
@@ -248,12 +305,35 @@ func (s *Service) canonicalizeBlocks(ctx context.Context, root phase0.Root, limi
 		root = block.ParentRoot
 	}
 
+	return recanonicalizedSlots, nil
+}
+
+// updateRecanonicalizedAttestations updates the attestations in finalized epochs whose canonical
+// status or votes depend on blocks that were previously marked as non-canonical.  Attestations
+// referencing a block are included in blocks up to the end of the following epoch, and may use
+// its epoch as their source a little later, so a few epochs after each block are updated.
+func (s *Service) updateRecanonicalizedAttestations(ctx context.Context, slots []phase0.Slot, lastFinalizedEpoch phase0.Epoch) error {
+	epochs := make(map[phase0.Epoch]struct{})
+	for _, slot := range slots {
+		firstEpoch := s.chainTime.SlotToEpoch(slot)
+		for epoch := firstEpoch; epoch <= firstEpoch+3 && epoch <= lastFinalizedEpoch; epoch++ {
+			epochs[epoch] = struct{}{}
+		}
+	}
+
+	for epoch := range epochs {
+		log.Debug().Uint64("epoch", uint64(epoch)).Msg("Updating attestations after change of canonical status")
+		if err := s.updateAttestationsInEpoch(ctx, epoch); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to update attestations in epoch %d", epoch))
+		}
+	}
+
 	return nil
 }
 
 // updateIndeterminateBlocks marks all indeterminate blocks before the given slot as canonical
 // if they have a canonical child else as non-canonical.
-func (s *Service) updateIndeterminateBlocks(ctx context.Context, slot phase0.Slot) error {
+func (s *Service) updateIndeterminateBlocks(ctx context.Context, slot phase0.Slot, stats *finalityStats) error {
 	nonCanonicalRoots, err := s.blocksProvider.IndeterminateBlocks(ctx, 0, slot)
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain indeterminate blocks")
@@ -280,6 +360,11 @@ func (s *Service) updateIndeterminateBlocks(ctx context.Context, slot phase0.Slo
 		if err := s.blocksSetter.SetBlock(ctx, nonCanonicalBlock); err != nil {
 			return err
 		}
+		if canonical {
+			stats.canonicalized++
+		} else {
+			stats.orphaned++
+		}
 		log.Trace().Str("root", fmt.Sprintf("%#x", nonCanonicalRoot)).Uint64("slot", uint64(nonCanonicalBlock.Slot)).Bool("canonical", *nonCanonicalBlock.Canonical).Msg("Marking block")
 	}
 
@@ -345,7 +430,14 @@ func (s *Service) updateAttestationsInEpoch(ctx context.Context, epoch phase0.Ep
 	toSlot := s.chainTime.FirstSlotOfEpoch(epoch)
 	// Because the epoch is canonical, all of the slots in the epoch are canonical.
 	log.Trace().Uint64("from_slot", uint64(fromSlot)).Uint64("to_slot", uint64(toSlot)).Msg("Updating attestations in slot range")
-	attestations, err := s.chainDB.(chaindb.AttestationsProvider).AttestationsInSlotRange(ctx, fromSlot, toSlot+1)
+	var attestations []*chaindb.Attestation
+	var err error
+	if s.aggregateAttestations {
+		// Attestations stored in aggregate mode have no inclusion slot, so are fetched by the slot they attest.
+		attestations, err = s.chainDB.(chaindb.AttestationsProvider).AttestationsForSlotRange(ctx, fromSlot, toSlot+1)
+	} else {
+		attestations, err = s.chainDB.(chaindb.AttestationsProvider).AttestationsInSlotRange(ctx, fromSlot, toSlot+1)
+	}
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain attestations for epoch")
 	}
@@ -370,6 +462,9 @@ func (s *Service) updateAttestationsInEpoch(ctx context.Context, epoch phase0.Ep
 		if err := s.updateAttestationHeadCorrect(ctx, attestation, headRoots); err != nil {
 			return errors.Wrap(err, "failed to update attestation head vote state")
 		}
+		if err := s.updateAttestationSourceCorrect(ctx, attestation, epochRoots); err != nil {
+			return errors.Wrap(err, "failed to update attestation source vote state")
+		}
 		if err := s.chainDB.(chaindb.AttestationsSetter).SetAttestation(ctx, attestation); err != nil {
 			return errors.Wrap(err, "failed to update attestation")
 		}
@@ -380,6 +475,7 @@ func (s *Service) updateAttestationsInEpoch(ctx context.Context, epoch phase0.Ep
 			Bool("canonical", *attestation.Canonical).
 			Bool("target_correct", *attestation.TargetCorrect).
 			Bool("head_correct", *attestation.HeadCorrect).
+			Bool("source_correct", *attestation.SourceCorrect).
 			Msg("Updated attestation")
 	}
 
@@ -388,7 +484,14 @@ func (s *Service) updateAttestationsInEpoch(ctx context.Context, epoch phase0.Ep
 
 // updateCanonical updates the attestation to confirm if it is canonical.
 // An attestation is canonical if it is in a canonical block.
+// Attestations stored in aggregate mode have no inclusion block, so are canonical once finalized.
 func (s *Service) updateCanonical(ctx context.Context, attestation *chaindb.Attestation, blockCanonicals map[phase0.Slot]bool) error {
+	if s.aggregateAttestations {
+		canonical := true
+		attestation.Canonical = &canonical
+		return nil
+	}
+
 	if canonical, exists := blockCanonicals[attestation.InclusionSlot]; exists {
 		attestation.Canonical = &canonical
 	} else {
@@ -413,42 +516,62 @@ func (s *Service) updateCanonical(ctx context.Context, attestation *chaindb.Atte
 // An attestation has a correct target vote if it matches the root of the latest canonical block
 // since the start of the target epoch.
 func (s *Service) updateAttestationTargetCorrect(ctx context.Context, attestation *chaindb.Attestation, epochRoots map[phase0.Epoch]phase0.Root) error {
-	targetCorrect := false
-	if epochRoot, exists := epochRoots[attestation.TargetEpoch]; exists {
-		targetCorrect = bytes.Equal(attestation.TargetRoot[:], epochRoot[:])
+	epochRoot, err := s.canonicalEpochRoot(ctx, attestation.TargetEpoch, epochRoots)
+	if err != nil {
+		return err
+	}
+	targetCorrect := bytes.Equal(attestation.TargetRoot[:], epochRoot[:])
+	attestation.TargetCorrect = &targetCorrect
+
+	return nil
+}
+
+// updateAttestationSourceCorrect updates the attestation to confirm if its source vote is correct.
+// An attestation has a correct source vote if it matches the root of the latest canonical block
+// since the start of the source epoch.  Until the chain first justifies an epoch the source is
+// epoch 0 with a zero root, which is also correct.
+func (s *Service) updateAttestationSourceCorrect(ctx context.Context, attestation *chaindb.Attestation, epochRoots map[phase0.Epoch]phase0.Root) error {
+	var sourceCorrect bool
+	if attestation.SourceEpoch == 0 && attestation.SourceRoot == (phase0.Root{}) {
+		sourceCorrect = true
 	} else {
-		// Start with first slot of the target epoch.
-		startSlot := s.chainTime.FirstSlotOfEpoch(attestation.TargetEpoch)
-
-		// Work backwards until we find a canonical block.
-		canonicalBlockFound := false
-		for slot := startSlot; !canonicalBlockFound; slot-- {
-			log.Trace().Uint64("slot", uint64(slot)).Msg("Fetching blocks at slot")
-			blocks, err := s.chainDB.(chaindb.BlocksProvider).BlocksBySlot(ctx, slot)
-			if err != nil {
-				return errors.Wrap(err, "failed to obtain block")
-			}
-			for _, block := range blocks {
-				if block.Canonical != nil && *block.Canonical {
-					log.Trace().Uint64("target_epoch", uint64(attestation.TargetEpoch)).Uint64("slot", uint64(block.Slot)).Msg("Found canonical block")
-					canonicalBlockFound = true
-					epochRoots[attestation.TargetEpoch] = block.Root
-					targetCorrect = bytes.Equal(attestation.TargetRoot[:], block.Root[:])
-					break
-				}
-			}
-			if slot == 0 {
-				break
+		epochRoot, err := s.canonicalEpochRoot(ctx, attestation.SourceEpoch, epochRoots)
+		if err != nil {
+			return err
+		}
+		sourceCorrect = bytes.Equal(attestation.SourceRoot[:], epochRoot[:])
+	}
+	attestation.SourceCorrect = &sourceCorrect
+
+	return nil
+}
+
+// canonicalEpochRoot returns the root of the latest canonical block since the start of the given epoch.
+func (s *Service) canonicalEpochRoot(ctx context.Context, epoch phase0.Epoch, epochRoots map[phase0.Epoch]phase0.Root) (phase0.Root, error) {
+	if epochRoot, exists := epochRoots[epoch]; exists {
+		return epochRoot, nil
+	}
+
+	// Start with first slot of the epoch, and work backwards until we find a canonical block.
+	for slot := s.chainTime.FirstSlotOfEpoch(epoch); ; slot-- {
+		log.Trace().Uint64("slot", uint64(slot)).Msg("Fetching blocks at slot")
+		blocks, err := s.chainDB.(chaindb.BlocksProvider).BlocksBySlot(ctx, slot)
+		if err != nil {
+			return phase0.Root{}, errors.Wrap(err, "failed to obtain block")
+		}
+		for _, block := range blocks {
+			if block.Canonical != nil && *block.Canonical {
+				log.Trace().Uint64("epoch", uint64(epoch)).Uint64("slot", uint64(block.Slot)).Msg("Found canonical block")
+				epochRoots[epoch] = block.Root
+				return block.Root, nil
 			}
 		}
-		if !canonicalBlockFound {
-			return errors.New("failed to obtain canonical block")
+		if slot == 0 {
+			break
 		}
 	}
 
-	attestation.TargetCorrect = &targetCorrect
-
-	return nil
+	return phase0.Root{}, errors.New("failed to obtain canonical block")
 }
 
 // updateAttestationHeadCorrect updates the attestation to confirm if its head vote is correct.
@@ -510,7 +633,7 @@ func (s *Service) fetchBlock(ctx context.Context, root phase0.Root) (*chaindb.Bl
 		}
 		// Not found in the database, try fetching it from the chain.
 		log.Debug().Str("block_root", fmt.Sprintf("%#x", root)).Msg("Failed to obtain block from provider; fetching from chain")
-		signedBlock, err := s.eth2Client.(eth2client.SignedBeaconBlockProvider).SignedBeaconBlock(ctx, fmt.Sprintf("%#x", root))
+		signedBlock, err := s.signedBeaconBlockProvider.SignedBeaconBlock(ctx, fmt.Sprintf("%#x", root))
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to obtain block from chain")
 		}
@@ -533,6 +656,10 @@ func (s *Service) fetchBlock(ctx context.Context, root phase0.Root) (*chaindb.Bl
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to obtain slot of block")
 		}
+		if s.origin != nil && earliestAllowableSlot < s.origin.Slot {
+			// Do not store blocks from before the ingestion origin.
+			return nil, errBeforeOrigin
+		}
 		if earliestAllowableSlot < 1024 {
 			earliestAllowableSlot = 0
 		} else {
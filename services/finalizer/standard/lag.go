@@ -0,0 +1,78 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// setChainFinalizedEpoch notes the finalized epoch of the chain, as reported by the beacon node.
+func (s *Service) setChainFinalizedEpoch(epoch phase0.Epoch) {
+	s.chainFinalizedEpoch.Store(int64(epoch))
+	monitorChainFinalizedEpoch(epoch)
+	s.monitorLag()
+}
+
+// updateFinalizedEpoch notes the latest epoch fully processed by the finalizer.
+func (s *Service) updateFinalizedEpoch(ctx context.Context) {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain metadata for finalized epoch")
+		return
+	}
+	s.finalizedEpoch.Store(md.LastFinalizedEpoch)
+	if md.LastFinalizedEpoch >= 0 {
+		monitorFinalizedEpoch(phase0.Epoch(md.LastFinalizedEpoch))
+	}
+	s.monitorLag()
+}
+
+// lag returns the number of epochs by which the finalizer trails the chain's finalized
+// epoch, and false if either is not yet known.
+func (s *Service) lag() (uint64, bool) {
+	chainFinalizedEpoch := s.chainFinalizedEpoch.Load()
+	finalizedEpoch := s.finalizedEpoch.Load()
+	if chainFinalizedEpoch < 0 {
+		return 0, false
+	}
+	if finalizedEpoch >= chainFinalizedEpoch {
+		return 0, true
+	}
+	if finalizedEpoch < 0 {
+		// Nothing has been processed, so the finalizer trails from genesis.
+		return uint64(chainFinalizedEpoch) + 1, true
+	}
+
+	return uint64(chainFinalizedEpoch - finalizedEpoch), true
+}
+
+// monitorLag updates the finality lag metric.
+func (s *Service) monitorLag() {
+	if lag, known := s.lag(); known {
+		monitorFinalityLag(lag)
+	}
+}
+
+// Ready returns true if the finalizer is within the maximum lag of the chain's finalized epoch.
+// It is always ready if there is no maximum lag, or the lag is not yet known.
+func (s *Service) Ready(_ context.Context) bool {
+	if s.maxLag == 0 {
+		return true
+	}
+	lag, known := s.lag()
+
+	return !known || lag <= s.maxLag
+}
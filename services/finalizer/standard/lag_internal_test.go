@@ -0,0 +1,97 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLag(t *testing.T) {
+	tests := []struct {
+		name                string
+		finalizedEpoch      int64
+		chainFinalizedEpoch int64
+		maxLag              uint64
+		lag                 uint64
+		known               bool
+		ready               bool
+	}{
+		{
+			name:                "Unknown",
+			finalizedEpoch:      -1,
+			chainFinalizedEpoch: -1,
+			maxLag:              2,
+			ready:               true,
+		},
+		{
+			name:                "NothingProcessed",
+			finalizedEpoch:      -1,
+			chainFinalizedEpoch: 5,
+			maxLag:              2,
+			lag:                 6,
+			known:               true,
+		},
+		{
+			name:                "Ahead",
+			finalizedEpoch:      11,
+			chainFinalizedEpoch: 10,
+			maxLag:              2,
+			known:               true,
+			ready:               true,
+		},
+		{
+			name:                "WithinMaxLag",
+			finalizedEpoch:      8,
+			chainFinalizedEpoch: 10,
+			maxLag:              2,
+			lag:                 2,
+			known:               true,
+			ready:               true,
+		},
+		{
+			name:                "BeyondMaxLag",
+			finalizedEpoch:      7,
+			chainFinalizedEpoch: 10,
+			maxLag:              2,
+			lag:                 3,
+			known:               true,
+		},
+		{
+			name:                "NoMaxLag",
+			finalizedEpoch:      7,
+			chainFinalizedEpoch: 1000,
+			lag:                 993,
+			known:               true,
+			ready:               true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Service{
+				maxLag: test.maxLag,
+			}
+			s.finalizedEpoch.Store(test.finalizedEpoch)
+			s.chainFinalizedEpoch.Store(test.chainFinalizedEpoch)
+
+			lag, known := s.lag()
+			require.Equal(t, test.known, known)
+			require.Equal(t, test.lag, lag)
+			require.Equal(t, test.ready, s.Ready(context.Background()))
+		})
+	}
+}
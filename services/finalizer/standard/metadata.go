@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 )
 
@@ -27,8 +28,8 @@ type metadata struct {
 	MissedEpochs        []int64 `json:"missed_epochs,omitempty"`
 }
 
-// metadataKey is the key for the metadata.
-var metadataKey = "finalizer.standard"
+// MetadataKey is the key for the metadata of this service, which records the latest finalized epoch.
+const MetadataKey = "finalizer.standard"
 
 // getMetadata gets metadata for this service.
 func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
@@ -36,7 +37,11 @@ func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 		LastFinalizedEpoch:  -1,
 		LatestCanonicalSlot: -1,
 	}
-	mdJSON, err := s.chainDB.Metadata(ctx, metadataKey)
+	if s.origin != nil {
+		// Do not canonicalize blocks from before the ingestion origin.
+		md.LatestCanonicalSlot = int64(s.origin.Slot) - 1
+	}
+	mdJSON, err := s.chainDB.Metadata(ctx, MetadataKey)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to fetch metadata")
 	}
@@ -55,8 +60,21 @@ func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to marshal metadata")
 	}
-	if err := s.chainDB.SetMetadata(ctx, metadataKey, mdJSON); err != nil {
+	if err := s.chainDB.SetMetadata(ctx, MetadataKey, mdJSON); err != nil {
 		return errors.Wrap(err, "failed to update metadata")
 	}
 	return nil
 }
+
+// LatestCompleteEpoch returns the latest epoch for which finality has been processed.
+func (s *Service) LatestCompleteEpoch(ctx context.Context) (phase0.Epoch, bool, error) {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if md.LastFinalizedEpoch < 0 {
+		return 0, false, nil
+	}
+
+	return phase0.Epoch(md.LastFinalizedEpoch), true, nil
+}
@@ -15,6 +15,7 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
@@ -28,6 +29,15 @@ var (
 	highestEpoch    phase0.Epoch
 	latestEpoch     prometheus.Gauge
 	epochsProcessed prometheus.Gauge
+	slotsSkipped    prometheus.Counter
+)
+
+var (
+	finalizedEpoch      prometheus.Gauge
+	chainFinalizedEpoch prometheus.Gauge
+	finalityLag         prometheus.Gauge
+	runDuration         prometheus.Histogram
+	blocksFinalized     *prometheus.CounterVec
 )
 
 func registerMetrics(_ context.Context, monitor metrics.Service) error {
@@ -64,6 +74,61 @@ func registerPrometheusMetrics() error {
 		return errors.Wrap(err, "failed to register epochs_processed")
 	}
 
+	slotsSkipped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "slots_skipped_total",
+		Help:      "Number of finalized slots recorded as skipped",
+	})
+	if err := prometheus.Register(slotsSkipped); err != nil {
+		return errors.Wrap(err, "failed to register slots_skipped_total")
+	}
+
+	finalizedEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "finalized_epoch",
+		Help:      "Latest epoch fully processed by the finalizer",
+	})
+	if err := prometheus.Register(finalizedEpoch); err != nil {
+		return errors.Wrap(err, "failed to register finalized_epoch")
+	}
+
+	chainFinalizedEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "chain_finalized_epoch",
+		Help:      "Latest finalized epoch of the chain, as reported by the beacon node",
+	})
+	if err := prometheus.Register(chainFinalizedEpoch); err != nil {
+		return errors.Wrap(err, "failed to register chain_finalized_epoch")
+	}
+
+	finalityLag = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "lag_epochs",
+		Help:      "Number of epochs by which the finalizer trails the chain's finalized epoch",
+	})
+	if err := prometheus.Register(finalityLag); err != nil {
+		return errors.Wrap(err, "failed to register lag_epochs")
+	}
+
+	runDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "run_duration_seconds",
+		Help:      "Time taken for each run of the finalizer",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 4, 8),
+	})
+	if err := prometheus.Register(runDuration); err != nil {
+		return errors.Wrap(err, "failed to register run_duration_seconds")
+	}
+
+	blocksFinalized = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "blocks_total",
+		Help:      "Number of blocks marked as canonical or orphaned by the finalizer",
+	}, []string{"outcome"})
+	if err := prometheus.Register(blocksFinalized); err != nil {
+		return errors.Wrap(err, "failed to register blocks_total")
+	}
+
 	return nil
 }
 
@@ -85,3 +150,38 @@ func monitorEpochProcessed(epoch phase0.Epoch) {
 		}
 	}
 }
+
+func monitorSlotSkipped() {
+	if slotsSkipped != nil {
+		slotsSkipped.Inc()
+	}
+}
+
+func monitorFinalizedEpoch(epoch phase0.Epoch) {
+	if finalizedEpoch != nil {
+		finalizedEpoch.Set(float64(epoch))
+	}
+}
+
+func monitorChainFinalizedEpoch(epoch phase0.Epoch) {
+	if chainFinalizedEpoch != nil {
+		chainFinalizedEpoch.Set(float64(epoch))
+	}
+}
+
+func monitorFinalityLag(lag uint64) {
+	if finalityLag != nil {
+		finalityLag.Set(float64(lag))
+	}
+}
+
+// monitorRun records the duration of a run of the finalizer, and the changes it made to blocks.
+func monitorRun(duration time.Duration, stats *finalityStats) {
+	if runDuration != nil {
+		runDuration.Observe(duration.Seconds())
+	}
+	if blocksFinalized != nil {
+		blocksFinalized.WithLabelValues("canonical").Add(float64(stats.canonicalized))
+		blocksFinalized.WithLabelValues("orphaned").Add(float64(stats.orphaned))
+	}
+}
@@ -23,18 +23,22 @@ import (
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/watchdog"
 	"golang.org/x/sync/semaphore"
 )
 
 type parameters struct {
-	logLevel         zerolog.Level
-	monitor          metrics.Service
-	eth2Client       eth2client.Service
-	chainDB          chaindb.Service
-	chainTime        chaintime.Service
-	blocks           blocks.Service
-	finalityHandlers []handlers.FinalityHandler
-	activitySem      *semaphore.Weighted
+	logLevel                  zerolog.Level
+	monitor                   metrics.Service
+	eth2Client                eth2client.Service
+	signedBeaconBlockProvider eth2client.SignedBeaconBlockProvider
+	chainDB                   chaindb.Service
+	chainTime                 chaintime.Service
+	blocks                    blocks.Service
+	finalityHandlers          []handlers.FinalityHandler
+	activitySem               *semaphore.Weighted
+	watchdog                  watchdog.Service
+	maxLag                    uint64
 }
 
 // Parameter is the interface for service parameters.
@@ -69,6 +73,14 @@ func WithETH2Client(eth2Client eth2client.Service) Parameter {
 	})
 }
 
+// WithSignedBeaconBlockProvider sets the signed beacon block provider for this module.
+// If not supplied, blocks are fetched using the Ethereum 2 client.
+func WithSignedBeaconBlockProvider(provider eth2client.SignedBeaconBlockProvider) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.signedBeaconBlockProvider = provider
+	})
+}
+
 // WithChainDB sets the chain database for this module.
 func WithChainDB(chainDB chaindb.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -104,6 +116,21 @@ func WithActivitySem(sem *semaphore.Weighted) Parameter {
 	})
 }
 
+// WithWatchdog sets the watchdog with which the service registers its progress.
+func WithWatchdog(watchdog watchdog.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.watchdog = watchdog
+	})
+}
+
+// WithMaxLag sets the number of epochs the finalizer can trail the chain's finalized
+// epoch and remain ready.  A value of 0 means that the lag does not affect readiness.
+func WithMaxLag(maxLag uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxLag = maxLag
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
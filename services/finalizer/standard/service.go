@@ -15,6 +15,7 @@ package standard
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
@@ -27,19 +28,32 @@ import (
 	"github.com/wealdtech/chaind/services/blocks"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a finalizer service.
 type Service struct {
-	eth2Client       eth2client.Service
-	chainDB          chaindb.Service
-	blocksProvider   chaindb.BlocksProvider
-	blocksSetter     chaindb.BlocksSetter
-	chainTime        chaintime.Service
-	blocks           blocks.Service
-	finalityHandlers []handlers.FinalityHandler
-	activitySem      *semaphore.Weighted
+	eth2Client                eth2client.Service
+	signedBeaconBlockProvider eth2client.SignedBeaconBlockProvider
+	chainDB                   chaindb.Service
+	blocksProvider            chaindb.BlocksProvider
+	blocksSetter              chaindb.BlocksSetter
+	chainTime                 chaintime.Service
+	blocks                    blocks.Service
+	finalityHandlers          []handlers.FinalityHandler
+	activitySem               *semaphore.Weighted
+	origin                    *chaindb.Origin
+	// Skipped slots are only recorded if the chain DB supports them.
+	skippedSlotsSetter     chaindb.SkippedSlotsSetter
+	proposerDutiesProvider chaindb.ProposerDutiesProvider
+	// aggregateAttestations is true if attestations are stored in aggregate mode, without their inclusions.
+	aggregateAttestations bool
+	// finalizedEpoch is the latest epoch fully processed by the finalizer, and
+	// chainFinalizedEpoch the finalized epoch of the chain; both are -1 until known.
+	finalizedEpoch      atomic.Int64
+	chainFinalizedEpoch atomic.Int64
+	maxLag              uint64
 }
 
 // module-wide log.
@@ -53,7 +67,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "finalizer").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger(zerologger.With().Str("service", "finalizer").Str("impl", "standard").Logger(), "finalizer", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -69,15 +83,46 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support block setting")
 	}
 
+	var origin *chaindb.Origin
+	if originProvider, isOriginProvider := parameters.chainDB.(chaindb.OriginProvider); isOriginProvider {
+		origin, err = originProvider.Origin(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain origin")
+		}
+	}
+
+	signedBeaconBlockProvider := parameters.signedBeaconBlockProvider
+	if signedBeaconBlockProvider == nil {
+		var isProvider bool
+		signedBeaconBlockProvider, isProvider = parameters.eth2Client.(eth2client.SignedBeaconBlockProvider)
+		if !isProvider {
+			return nil, errors.New("client does not provide signed beacon blocks")
+		}
+	}
+
 	s := &Service{
-		eth2Client:       parameters.eth2Client,
-		chainDB:          parameters.chainDB,
-		blocksProvider:   blocksProvider,
-		blocksSetter:     blocksSetter,
-		chainTime:        parameters.chainTime,
-		blocks:           parameters.blocks,
-		finalityHandlers: parameters.finalityHandlers,
-		activitySem:      parameters.activitySem,
+		eth2Client:                parameters.eth2Client,
+		signedBeaconBlockProvider: signedBeaconBlockProvider,
+		chainDB:                   parameters.chainDB,
+		blocksProvider:            blocksProvider,
+		blocksSetter:              blocksSetter,
+		chainTime:                 parameters.chainTime,
+		blocks:                    parameters.blocks,
+		finalityHandlers:          parameters.finalityHandlers,
+		activitySem:               parameters.activitySem,
+		origin:                    origin,
+		maxLag:                    parameters.maxLag,
+	}
+	s.finalizedEpoch.Store(-1)
+	s.chainFinalizedEpoch.Store(-1)
+	if setter, isSetter := parameters.chainDB.(chaindb.SkippedSlotsSetter); isSetter {
+		s.skippedSlotsSetter = setter
+	}
+	if provider, isProvider := parameters.chainDB.(chaindb.ProposerDutiesProvider); isProvider {
+		s.proposerDutiesProvider = provider
+	}
+	if provider, isProvider := parameters.chainDB.(chaindb.AttestationStorageModeProvider); isProvider {
+		s.aggregateAttestations = provider.AttestationStorageMode() == chaindb.AttestationStorageAggregate
 	}
 
 	// Set up the handler for new finality checkpoint updates.
@@ -110,6 +155,13 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	if md.LastFinalizedEpoch > -1 {
 		monitorLatestEpoch(phase0.Epoch(md.LastFinalizedEpoch))
 	}
+	s.updateFinalizedEpoch(ctx)
+
+	if parameters.watchdog != nil {
+		if err := s.registerWatchdog(ctx, parameters.watchdog); err != nil {
+			return nil, errors.Wrap(err, "failed to register with watchdog")
+		}
+	}
 
 	return s, nil
 }
@@ -0,0 +1,74 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// updateSkippedSlots records the slots in the given range without a canonical block as skipped.
+// Ranges are inclusive of start and exclusive of end.
+// This must only be called once the range is finalized and its canonical
+// blocks have been marked, so that an absent canonical block means that the
+// slot was skipped rather than that the block has yet to be stored.
+func (s *Service) updateSkippedSlots(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	if s.skippedSlotsSetter == nil {
+		return nil
+	}
+	if s.origin != nil && startSlot < s.origin.Slot {
+		startSlot = s.origin.Slot
+	}
+	if startSlot >= endSlot {
+		return nil
+	}
+
+	presence, err := s.blocksProvider.CanonicalBlockPresenceForSlotRange(ctx, startSlot, endSlot)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain canonical block presence")
+	}
+
+	proposers := make(map[phase0.Slot]phase0.ValidatorIndex)
+	if s.proposerDutiesProvider != nil {
+		duties, err := s.proposerDutiesProvider.ProposerDutiesForSlotRange(ctx, startSlot, endSlot)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain proposer duties")
+		}
+		for _, duty := range duties {
+			proposers[duty.Slot] = duty.ValidatorIndex
+		}
+	}
+
+	for i, present := range presence {
+		if present {
+			continue
+		}
+		skippedSlot := &chaindb.SkippedSlot{
+			Slot: startSlot + phase0.Slot(i),
+		}
+		if proposer, exists := proposers[skippedSlot.Slot]; exists {
+			skippedSlot.ProposerIndex = &proposer
+		}
+		if err := s.skippedSlotsSetter.SetSkippedSlot(ctx, skippedSlot); err != nil {
+			return errors.Wrap(err, "failed to set skipped slot")
+		}
+		log.Trace().Uint64("slot", uint64(skippedSlot.Slot)).Msg("Slot skipped")
+		monitorSlotSkipped()
+	}
+
+	return nil
+}
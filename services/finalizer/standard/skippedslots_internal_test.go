@@ -0,0 +1,162 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// skippedSlotsChainDB is a chain database with fixed canonical blocks and proposer duties,
+// recording the skipped slots that it is given.
+type skippedSlotsChainDB struct {
+	chaindb.BlocksProvider
+	chaindb.ProposerDutiesProvider
+	canonical    map[phase0.Slot]bool
+	proposers    map[phase0.Slot]phase0.ValidatorIndex
+	skippedSlots []*chaindb.SkippedSlot
+}
+
+func (c *skippedSlotsChainDB) CanonicalBlockPresenceForSlotRange(_ context.Context,
+	minSlot phase0.Slot,
+	maxSlot phase0.Slot,
+) (
+	[]bool,
+	error,
+) {
+	presence := make([]bool, 0, maxSlot-minSlot)
+	for slot := minSlot; slot < maxSlot; slot++ {
+		presence = append(presence, c.canonical[slot])
+	}
+
+	return presence, nil
+}
+
+func (c *skippedSlotsChainDB) ProposerDutiesForSlotRange(_ context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]*chaindb.ProposerDuty,
+	error,
+) {
+	duties := make([]*chaindb.ProposerDuty, 0)
+	for slot := startSlot; slot < endSlot; slot++ {
+		if proposer, exists := c.proposers[slot]; exists {
+			duties = append(duties, &chaindb.ProposerDuty{
+				Slot:           slot,
+				ValidatorIndex: proposer,
+			})
+		}
+	}
+
+	return duties, nil
+}
+
+func (c *skippedSlotsChainDB) SetSkippedSlot(_ context.Context, skippedSlot *chaindb.SkippedSlot) error {
+	c.skippedSlots = append(c.skippedSlots, skippedSlot)
+
+	return nil
+}
+
+func TestUpdateSkippedSlots(t *testing.T) {
+	ctx := context.Background()
+
+	proposer := func(index phase0.ValidatorIndex) *phase0.ValidatorIndex {
+		return &index
+	}
+
+	tests := []struct {
+		name         string
+		origin       *chaindb.Origin
+		noDuties     bool
+		noSetter     bool
+		startSlot    phase0.Slot
+		endSlot      phase0.Slot
+		skippedSlots []*chaindb.SkippedSlot
+	}{
+		{
+			name:      "NoneSkipped",
+			startSlot: 10,
+			endSlot:   11,
+		},
+		{
+			name:      "Skipped",
+			startSlot: 10,
+			endSlot:   16,
+			skippedSlots: []*chaindb.SkippedSlot{
+				{Slot: 11, ProposerIndex: proposer(111)},
+				{Slot: 12, ProposerIndex: proposer(112)},
+				{Slot: 14},
+			},
+		},
+		{
+			name:      "NoDuties",
+			noDuties:  true,
+			startSlot: 10,
+			endSlot:   13,
+			skippedSlots: []*chaindb.SkippedSlot{
+				{Slot: 11},
+				{Slot: 12},
+			},
+		},
+		{
+			name:      "BeforeOrigin",
+			origin:    &chaindb.Origin{Slot: 12},
+			startSlot: 10,
+			endSlot:   16,
+			skippedSlots: []*chaindb.SkippedSlot{
+				{Slot: 12, ProposerIndex: proposer(112)},
+				{Slot: 14},
+			},
+		},
+		{
+			name:      "EmptyRange",
+			startSlot: 16,
+			endSlot:   16,
+		},
+		{
+			name:      "NoSetter",
+			noSetter:  true,
+			startSlot: 10,
+			endSlot:   16,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Slots 10, 13 and 15 have canonical blocks; 14 has no proposer duty.
+			chainDB := &skippedSlotsChainDB{
+				canonical: map[phase0.Slot]bool{10: true, 13: true, 15: true},
+				proposers: map[phase0.Slot]phase0.ValidatorIndex{10: 110, 11: 111, 12: 112, 13: 113, 15: 115},
+			}
+			s := &Service{
+				blocksProvider: chainDB,
+				origin:         test.origin,
+			}
+			if !test.noSetter {
+				s.skippedSlotsSetter = chainDB
+			}
+			if !test.noDuties {
+				s.proposerDutiesProvider = chainDB
+			}
+
+			require.NoError(t, s.updateSkippedSlots(ctx, test.startSlot, test.endSlot))
+			require.Equal(t, test.skippedSlots, chainDB.skippedSlots)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/watchdog"
+)
+
+// watchdogThreshold is the number of epochs the finalizer can fall behind the
+// chain's finalized checkpoint before the watchdog considers it stale.
+const watchdogThreshold = 2
+
+// registerWatchdog registers the finalizer with the watchdog.
+func (s *Service) registerWatchdog(ctx context.Context, wd watchdog.Service) error {
+	return wd.Register(ctx, &watchdog.Dataset{
+		Name:      "finalizer",
+		Progress:  s.epochProgress,
+		Cadence:   s.chainTime.SlotDuration() * time.Duration(s.chainTime.SlotsPerEpoch()),
+		Threshold: watchdogThreshold,
+		Recover: func(_ context.Context) error {
+			// Process the current finality in the background using the service's context.
+			go func() {
+				finality, err := s.eth2Client.(eth2client.FinalityProvider).Finality(ctx, "head")
+				if err != nil {
+					log.Error().Err(err).Msg("Failed to obtain finality data")
+					return
+				}
+				s.OnFinalityCheckpointReceived(ctx, finality)
+			}()
+			return nil
+		},
+	})
+}
+
+// epochProgress returns the latest epoch finalized by the service, and the
+// finalized epoch of the chain.
+func (s *Service) epochProgress(ctx context.Context) (uint64, uint64, bool, error) {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return 0, 0, false, errors.Wrap(err, "failed to obtain metadata")
+	}
+	if md.LastFinalizedEpoch < 0 {
+		return 0, 0, false, nil
+	}
+
+	finality, err := s.eth2Client.(eth2client.FinalityProvider).Finality(ctx, "head")
+	if err != nil {
+		return 0, 0, false, errors.Wrap(err, "failed to obtain finality")
+	}
+
+	return uint64(md.LastFinalizedEpoch), uint64(finality.Finalized.Epoch), true, nil
+}
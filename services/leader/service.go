@@ -0,0 +1,51 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leader
+
+import (
+	"context"
+	"time"
+)
+
+// State is the leadership state of an instance.
+type State struct {
+	// Instance is the name of this instance.
+	Instance string
+	// Leader is true if this instance currently holds leadership.
+	Leader bool
+	// Acquired is the time at which this instance obtained leadership, if it has.
+	Acquired time.Time
+	// LastHeartbeat is the time at which this instance last confirmed its leadership.
+	LastHeartbeat time.Time
+	// Lost is true if this instance has lost leadership.
+	Lost bool
+}
+
+// Service is a leader election service.
+// Only the leader should write to the database; other instances stand by until
+// they obtain leadership.
+type Service interface {
+	// IsLeader returns true if this instance currently holds leadership.
+	IsLeader(ctx context.Context) bool
+
+	// Acquired returns a channel that is closed when this instance obtains leadership.
+	Acquired() <-chan struct{}
+
+	// Lost returns a channel that is closed when this instance loses leadership.
+	// Leadership is not obtained again once lost.
+	Lost() <-chan struct{}
+
+	// State returns the leadership state of this instance.
+	State(ctx context.Context) *State
+}
@@ -0,0 +1,129 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+// locker holds the lock that confers leadership.
+type locker interface {
+	// tryLock attempts to obtain the lock, returning true if it is held.
+	tryLock(ctx context.Context) (bool, error)
+	// heartbeat confirms that the lock is still held, recording that the instance
+	// is alive.  It returns false if the lock is no longer held.
+	heartbeat(ctx context.Context, instance string) (bool, error)
+	// release releases the lock, if held.
+	release(ctx context.Context)
+}
+
+// advisoryLocker uses a PostgreSQL session-level advisory lock as the leadership lock.
+// The lock is held by a dedicated connection, and is released by the database
+// if the connection is lost.
+type advisoryLocker struct {
+	connectionURL string
+	lockID        int64
+	conn          *pgx.Conn
+}
+
+// newAdvisoryLocker creates a new advisory locker.
+func newAdvisoryLocker(connectionURL string, lockID int64) *advisoryLocker {
+	return &advisoryLocker{
+		connectionURL: connectionURL,
+		lockID:        lockID,
+	}
+}
+
+// tryLock attempts to obtain the lock, returning true if it is held.
+func (l *advisoryLocker) tryLock(ctx context.Context) (bool, error) {
+	if l.conn == nil || l.conn.IsClosed() {
+		conn, err := pgx.Connect(ctx, l.connectionURL)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to connect to database")
+		}
+		l.conn = conn
+	}
+
+	var locked bool
+	if err := l.conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, l.lockID).Scan(&locked); err != nil {
+		return false, errors.Wrap(err, "failed to attempt lock")
+	}
+
+	return locked, nil
+}
+
+// heartbeat confirms that the lock is still held, recording that the instance is alive.
+func (l *advisoryLocker) heartbeat(ctx context.Context, instance string) (bool, error) {
+	if l.conn == nil || l.conn.IsClosed() {
+		// The session has gone, and the lock with it.
+		return false, nil
+	}
+
+	// A bigint advisory lock is shown in pg_locks with its high and low 32 bits
+	// in classid and objid respectively.
+	var held bool
+	err := l.conn.QueryRow(ctx, `
+      SELECT EXISTS(SELECT 1
+                    FROM pg_locks
+                    WHERE locktype = 'advisory'
+                      AND pid = pg_backend_pid()
+                      AND granted
+                      AND objsubid = 1
+                      AND classid::bigint = $1
+                      AND objid::bigint = $2)`,
+		(l.lockID>>32)&0xffffffff,
+		l.lockID&0xffffffff,
+	).Scan(&held)
+	if err != nil {
+		if l.conn.IsClosed() {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "failed to check lock")
+	}
+	if !held {
+		return false, nil
+	}
+
+	// The heartbeat row is informational, allowing operators to see which instance
+	// is the leader, so failure to write it does not affect leadership.
+	if _, err := l.conn.Exec(ctx, `
+      INSERT INTO t_metadata(f_key
+                            ,f_value)
+      VALUES('leader',jsonb_build_object('instance',$1::text,'heartbeat',now()))
+      ON CONFLICT (f_key) DO
+      UPDATE
+      SET f_value = excluded.f_value`,
+		instance,
+	); err != nil {
+		log.Debug().Err(err).Msg("Failed to record heartbeat")
+	}
+
+	return true, nil
+}
+
+// release releases the lock, if held.
+func (l *advisoryLocker) release(ctx context.Context) {
+	if l.conn == nil || l.conn.IsClosed() {
+		return
+	}
+	if _, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, l.lockID); err != nil {
+		log.Debug().Err(err).Msg("Failed to release lock")
+	}
+	if err := l.conn.Close(ctx); err != nil {
+		log.Debug().Err(err).Msg("Failed to close lock connection")
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+// DefaultLockID is the default ID of the advisory lock that confers leadership.
+const DefaultLockID = int64(0x636861696e64) // "chaind"
+
+type parameters struct {
+	logLevel          zerolog.Level
+	monitor           metrics.Service
+	connectionURL     string
+	lockID            int64
+	instance          string
+	pollInterval      time.Duration
+	heartbeatInterval time.Duration
+	heartbeatTimeout  time.Duration
+	locker            locker
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithConnectionURL sets the connection URL for the database that holds the lock.
+func WithConnectionURL(connectionURL string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.connectionURL = connectionURL
+	})
+}
+
+// WithLockID sets the ID of the advisory lock that confers leadership.
+// All instances writing to the same database must use the same ID.
+func WithLockID(lockID int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.lockID = lockID
+	})
+}
+
+// WithInstance sets the name of this instance, as recorded in the heartbeat.
+// If not supplied it defaults to the hostname and process ID.
+func WithInstance(instance string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.instance = instance
+	})
+}
+
+// WithPollInterval sets the interval at which a standby attempts to obtain leadership.
+func WithPollInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.pollInterval = interval
+	})
+}
+
+// WithHeartbeatInterval sets the interval at which the leader confirms it still holds the lock.
+func WithHeartbeatInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.heartbeatInterval = interval
+	})
+}
+
+// WithHeartbeatTimeout sets the time after which a leader that cannot confirm it
+// holds the lock gives up leadership.
+func WithHeartbeatTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.heartbeatTimeout = timeout
+	})
+}
+
+// withLocker sets the locker, replacing the database advisory lock.
+func withLocker(locker locker) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.locker = locker
+	})
+}
+
+// defaultInstance returns a name for this instance based on its host and process.
+func defaultInstance() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:          zerolog.GlobalLevel(),
+		lockID:            DefaultLockID,
+		pollInterval:      5 * time.Second,
+		heartbeatInterval: 5 * time.Second,
+		heartbeatTimeout:  15 * time.Second,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.connectionURL == "" && parameters.locker == nil {
+		return nil, errors.New("no connection URL specified")
+	}
+	if parameters.instance == "" {
+		parameters.instance = defaultInstance()
+	}
+	if parameters.pollInterval <= 0 {
+		return nil, errors.New("poll interval must be greater than 0")
+	}
+	if parameters.heartbeatInterval <= 0 {
+		return nil, errors.New("heartbeat interval must be greater than 0")
+	}
+	if parameters.heartbeatTimeout <= parameters.heartbeatInterval {
+		return nil, errors.New("heartbeat timeout must be greater than heartbeat interval")
+	}
+
+	return &parameters, nil
+}
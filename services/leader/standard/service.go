@@ -0,0 +1,205 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/leader"
+	"github.com/wealdtech/chaind/util"
+	"go.uber.org/atomic"
+)
+
+// releaseTimeout is the time allowed to release the lock on shutdown.
+const releaseTimeout = 5 * time.Second
+
+// Service is a leader election service.
+type Service struct {
+	locker            locker
+	instance          string
+	pollInterval      time.Duration
+	heartbeatInterval time.Duration
+	heartbeatTimeout  time.Duration
+	leader            atomic.Bool
+	lostLeadership    atomic.Bool
+	acquiredAt        atomic.Time
+	lastHeartbeat     atomic.Time
+	acquired          chan struct{}
+	lost              chan struct{}
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger(zerologger.With().Str("service", "leader").Str("impl", "standard").Logger(), "leader", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	s := &Service{
+		locker:            parameters.locker,
+		instance:          parameters.instance,
+		pollInterval:      parameters.pollInterval,
+		heartbeatInterval: parameters.heartbeatInterval,
+		heartbeatTimeout:  parameters.heartbeatTimeout,
+		acquired:          make(chan struct{}),
+		lost:              make(chan struct{}),
+	}
+	if s.locker == nil {
+		s.locker = newAdvisoryLocker(parameters.connectionURL, parameters.lockID)
+	}
+	monitorLeader(false)
+
+	go s.run(ctx)
+
+	return s, nil
+}
+
+// IsLeader returns true if this instance currently holds leadership.
+// Leadership is not considered held if it has not been confirmed within the
+// heartbeat timeout, even if it has yet to be given up.
+func (s *Service) IsLeader(_ context.Context) bool {
+	return s.leader.Load() && time.Since(s.lastHeartbeat.Load()) < s.heartbeatTimeout
+}
+
+// Acquired returns a channel that is closed when this instance obtains leadership.
+func (s *Service) Acquired() <-chan struct{} {
+	return s.acquired
+}
+
+// Lost returns a channel that is closed when this instance loses leadership.
+func (s *Service) Lost() <-chan struct{} {
+	return s.lost
+}
+
+// State returns the leadership state of this instance.
+func (s *Service) State(ctx context.Context) *leader.State {
+	return &leader.State{
+		Instance:      s.instance,
+		Leader:        s.IsLeader(ctx),
+		Acquired:      s.acquiredAt.Load(),
+		LastHeartbeat: s.lastHeartbeat.Load(),
+		Lost:          s.lostLeadership.Load(),
+	}
+}
+
+// run obtains and maintains leadership until the context is done or leadership is lost.
+func (s *Service) run(ctx context.Context) {
+	defer func() {
+		s.leader.Store(false)
+		monitorLeader(false)
+		// The context is done at this point, so the lock is released with a fresh one.
+		//nolint:contextcheck
+		releaseCtx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+		s.locker.release(releaseCtx)
+		cancel()
+	}()
+
+	if !s.awaitLeadership(ctx) {
+		return
+	}
+	s.maintainLeadership(ctx)
+}
+
+// awaitLeadership polls for leadership, returning true when it is obtained or
+// false if the context is done first.
+func (s *Service) awaitLeadership(ctx context.Context) bool {
+	log.Info().Str("instance", s.instance).Msg("Waiting to obtain leadership")
+	for {
+		locked, err := s.locker.tryLock(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to attempt to obtain leadership")
+		}
+		if locked {
+			now := time.Now()
+			s.acquiredAt.Store(now)
+			s.lastHeartbeat.Store(now)
+			s.leader.Store(true)
+			monitorLeader(true)
+			log.Info().Str("instance", s.instance).Msg("Obtained leadership")
+			close(s.acquired)
+			return true
+		}
+		log.Trace().Msg("Leadership held by another instance")
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+// maintainLeadership confirms leadership at each heartbeat until the context is
+// done or leadership is lost.
+func (s *Service) maintainLeadership(ctx context.Context) {
+	ticker := time.NewTicker(s.heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Relinquishing leadership")
+			return
+		case <-ticker.C:
+		}
+
+		if !s.heartbeat(ctx) {
+			s.leader.Store(false)
+			s.lostLeadership.Store(true)
+			monitorLeader(false)
+			log.Error().Str("instance", s.instance).Msg("Lost leadership")
+			close(s.lost)
+			return
+		}
+	}
+}
+
+// heartbeat confirms leadership, returning false if it has been lost.
+func (s *Service) heartbeat(ctx context.Context) bool {
+	heartbeatCtx, cancel := context.WithTimeout(ctx, s.heartbeatInterval)
+	held, err := s.locker.heartbeat(heartbeatCtx, s.instance)
+	cancel()
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutting down.
+			return true
+		}
+		since := time.Since(s.lastHeartbeat.Load())
+		log.Warn().Err(err).Dur("since_last_heartbeat", since).Msg("Failed to confirm leadership")
+		return since < s.heartbeatTimeout
+	}
+	if !held {
+		log.Warn().Msg("Leadership lock is no longer held")
+		return false
+	}
+
+	now := time.Now()
+	s.lastHeartbeat.Store(now)
+	monitorHeartbeat(now)
+
+	return true
+}
@@ -0,0 +1,168 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+// stubLocker is a locker whose lock is controlled by the test.
+type stubLocker struct {
+	available atomic.Bool
+	held      atomic.Bool
+	failing   atomic.Bool
+	released  atomic.Bool
+}
+
+func (l *stubLocker) tryLock(_ context.Context) (bool, error) {
+	if l.available.Load() {
+		l.held.Store(true)
+	}
+	return l.held.Load(), nil
+}
+
+func (l *stubLocker) heartbeat(_ context.Context, _ string) (bool, error) {
+	if l.failing.Load() {
+		return false, errors.New("connection refused")
+	}
+	return l.held.Load(), nil
+}
+
+func (l *stubLocker) release(_ context.Context) {
+	l.released.Store(true)
+}
+
+func TestParameters(t *testing.T) {
+	_, err := parseAndCheckParameters()
+	require.EqualError(t, err, "no connection URL specified")
+
+	_, err = parseAndCheckParameters(WithConnectionURL("postgres://localhost/chain"), WithHeartbeatTimeout(time.Second), WithHeartbeatInterval(time.Second))
+	require.EqualError(t, err, "heartbeat timeout must be greater than heartbeat interval")
+
+	parameters, err := parseAndCheckParameters(WithConnectionURL("postgres://localhost/chain"))
+	require.NoError(t, err)
+	require.NotEmpty(t, parameters.instance)
+	require.Equal(t, DefaultLockID, parameters.lockID)
+}
+
+func TestStandby(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locker := &stubLocker{}
+	s, err := New(ctx,
+		withLocker(locker),
+		WithInstance("test"),
+		WithPollInterval(10*time.Millisecond),
+		WithHeartbeatInterval(10*time.Millisecond),
+		WithHeartbeatTimeout(50*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	// Lock held elsewhere, so remain on standby.
+	time.Sleep(50 * time.Millisecond)
+	require.False(t, s.IsLeader(ctx))
+	select {
+	case <-s.Acquired():
+		t.Fatal("leadership acquired while lock held elsewhere")
+	default:
+	}
+
+	// Lock becomes available.
+	locker.available.Store(true)
+	select {
+	case <-s.Acquired():
+	case <-time.After(time.Second):
+		t.Fatal("leadership not acquired")
+	}
+	require.True(t, s.IsLeader(ctx))
+	state := s.State(ctx)
+	require.Equal(t, "test", state.Instance)
+	require.True(t, state.Leader)
+	require.False(t, state.Acquired.IsZero())
+
+	// Shutting down releases the lock without signalling loss.
+	cancel()
+	require.Eventually(t, locker.released.Load, time.Second, 10*time.Millisecond)
+	require.False(t, s.IsLeader(ctx))
+	select {
+	case <-s.Lost():
+		t.Fatal("leadership lost on shutdown")
+	default:
+	}
+}
+
+func TestLockLost(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locker := &stubLocker{}
+	locker.available.Store(true)
+	s, err := New(ctx,
+		withLocker(locker),
+		WithPollInterval(10*time.Millisecond),
+		WithHeartbeatInterval(10*time.Millisecond),
+		WithHeartbeatTimeout(time.Hour),
+	)
+	require.NoError(t, err)
+	<-s.Acquired()
+
+	// Loss of the lock is acted on at the next heartbeat, regardless of the timeout.
+	locker.held.Store(false)
+	select {
+	case <-s.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("leadership not lost")
+	}
+	require.False(t, s.IsLeader(ctx))
+	require.True(t, s.State(ctx).Lost)
+}
+
+func TestHeartbeatTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locker := &stubLocker{}
+	locker.available.Store(true)
+	s, err := New(ctx,
+		withLocker(locker),
+		WithPollInterval(10*time.Millisecond),
+		WithHeartbeatInterval(10*time.Millisecond),
+		WithHeartbeatTimeout(100*time.Millisecond),
+	)
+	require.NoError(t, err)
+	<-s.Acquired()
+
+	// Transient failures within the timeout retain leadership.
+	locker.failing.Store(true)
+	time.Sleep(30 * time.Millisecond)
+	require.True(t, s.IsLeader(ctx))
+	locker.failing.Store(false)
+	time.Sleep(30 * time.Millisecond)
+
+	// Persistent failures lose it.
+	locker.failing.Store(true)
+	select {
+	case <-s.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("leadership not lost")
+	}
+	require.False(t, s.IsLeader(ctx))
+}
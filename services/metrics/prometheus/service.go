@@ -22,6 +22,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/util"
 )
 
 // Service is a metrics service exposing metrics via prometheus.
@@ -38,10 +39,7 @@ func New(_ context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "metrics").Str("impl", "prometheus").Logger()
-	if parameters.logLevel != log.GetLevel() {
-		log = log.Level(parameters.logLevel)
-	}
+	log = util.ServiceLogger(zerologger.With().Str("service", "metrics").Str("impl", "prometheus").Logger(), "metrics", parameters.logLevel)
 
 	s := &Service{}
 
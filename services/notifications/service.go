@@ -0,0 +1,58 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifications
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Service is a notifications service.
+type Service interface{}
+
+// CompletionProvider is implemented by services that can report the extent of their data.
+type CompletionProvider interface {
+	// LatestCompleteEpoch returns the latest epoch for which the service's data is complete.
+	// It returns false if the service has yet to complete any epoch.
+	LatestCompleteEpoch(ctx context.Context) (phase0.Epoch, bool, error)
+}
+
+// SlotCompletionProvider is implemented by services that can report the extent of their data at slot granularity.
+type SlotCompletionProvider interface {
+	// LatestCompleteSlot returns the latest slot for which the service's data is complete.
+	// It returns false if the service has yet to complete any slot.
+	LatestCompleteSlot(ctx context.Context) (phase0.Slot, bool, error)
+}
+
+// CompletedServicesFunc returns the names of the services whose data for the given epoch is complete.
+type CompletedServicesFunc func(ctx context.Context, epoch phase0.Epoch) []string
+
+// Webhook is the configuration for a webhook.
+type Webhook struct {
+	// Name is the name of the webhook, used to track deliveries.
+	Name string
+	// URL is the URL to which notifications are posted.
+	URL string
+	// AuthHeader is the value of the Authorization header sent with notifications.
+	AuthHeader string
+	// MaxAttempts is the maximum number of attempts to deliver a notification before giving up
+	// until the next finality update.
+	MaxAttempts int
+	// InitialBackoff is the time to wait after the first failed attempt.  Subsequent waits double.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum time to wait between attempts.
+	MaxBackoff time.Duration
+}
@@ -0,0 +1,196 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/notifications"
+)
+
+// notification is the payload sent to webhooks.
+type notification struct {
+	// DeliveryID is the same for every delivery of the notification for an epoch,
+	// allowing the receiver to discard duplicates.
+	DeliveryID       string   `json:"delivery_id"`
+	Attempt          int      `json:"attempt"`
+	Epoch            uint64   `json:"epoch"`
+	BlockRoot        string   `json:"block_root,omitempty"`
+	CompleteServices []string `json:"complete_services"`
+}
+
+// run delivers notifications to a webhook whenever it is signalled.
+func (s *Service) run(ctx context.Context, w *worker) {
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debug().Str("webhook", w.webhook.Name).Msg("Context done")
+			return
+		case <-w.signalCh:
+		}
+
+		w.targetMu.Lock()
+		target := w.target
+		w.targetMu.Unlock()
+		if target < 0 {
+			continue
+		}
+
+		if err := s.deliverPending(ctx, w.webhook, phase0.Epoch(target)); err != nil {
+			log.Error().Str("webhook", w.webhook.Name).Err(err).Msg("Failed to deliver notifications; will retry on next finality update")
+		}
+	}
+}
+
+// deliverPending delivers notifications for all epochs up to and including the
+// target that have yet to be delivered to the webhook.
+// The latest delivered epoch is only updated once a delivery succeeds, so each
+// notification is delivered at least once.
+func (s *Service) deliverPending(ctx context.Context, webhook *notifications.Webhook, target phase0.Epoch) error {
+	md, err := s.getMetadata(ctx, webhook.Name)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	startEpoch := phase0.Epoch(md.LatestEpoch + 1)
+	if md.LatestEpoch < 0 {
+		// A new webhook starts with the current epoch, rather than receiving the full history.
+		startEpoch = target
+	}
+
+	for epoch := startEpoch; epoch <= target; epoch++ {
+		n, err := s.buildNotification(ctx, epoch)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to build notification for epoch %d", epoch))
+		}
+		if err := s.deliverWithRetries(ctx, webhook, n); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to deliver notification for epoch %d", epoch))
+		}
+
+		md.LatestEpoch = int64(epoch)
+		if err := s.setMetadataTx(ctx, webhook.Name, md); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
+		}
+		monitorDeliveredEpoch(webhook.Name, epoch)
+		log.Trace().Str("webhook", webhook.Name).Uint64("epoch", uint64(epoch)).Msg("Delivered notification")
+	}
+
+	return nil
+}
+
+// buildNotification builds the notification for an epoch.
+func (s *Service) buildNotification(ctx context.Context, epoch phase0.Epoch) (*notification, error) {
+	n := &notification{
+		DeliveryID:       fmt.Sprintf("epoch-%d", epoch),
+		Epoch:            uint64(epoch),
+		CompleteServices: make([]string, 0),
+	}
+
+	// The final block root for the epoch is that of the latest canonical block at or before its first slot.
+	slot := s.chainTime.FirstSlotOfEpoch(epoch)
+	canonical := true
+	blocks, err := s.blocksProvider.Blocks(ctx, &chaindb.BlockFilter{
+		Limit:     1,
+		Order:     chaindb.OrderLatest,
+		To:        &slot,
+		Canonical: &canonical,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain block")
+	}
+	if len(blocks) > 0 {
+		n.BlockRoot = fmt.Sprintf("%#x", blocks[0].Root)
+		n.DeliveryID = fmt.Sprintf("epoch-%d-%#x", epoch, blocks[0].Root)
+	}
+
+	if s.completedServices != nil {
+		n.CompleteServices = append(n.CompleteServices, s.completedServices(ctx, epoch)...)
+	}
+
+	return n, nil
+}
+
+// deliverWithRetries delivers a notification, retrying with backoff on failure.
+func (s *Service) deliverWithRetries(ctx context.Context, webhook *notifications.Webhook, n *notification) error {
+	backoff := webhook.InitialBackoff
+	var err error
+	for attempt := 1; attempt <= webhook.MaxAttempts; attempt++ {
+		n.Attempt = attempt
+		err = s.deliver(ctx, webhook, n)
+		monitorDelivery(webhook.Name, err == nil)
+		if err == nil {
+			return nil
+		}
+		log.Debug().Str("webhook", webhook.Name).Uint64("epoch", n.Epoch).Int("attempt", attempt).Err(err).Msg("Delivery attempt failed")
+		if attempt == webhook.MaxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > webhook.MaxBackoff {
+			backoff = webhook.MaxBackoff
+		}
+	}
+
+	return errors.Wrap(err, fmt.Sprintf("failed after %d attempts", webhook.MaxAttempts))
+}
+
+// deliver makes a single attempt to deliver a notification.
+func (s *Service) deliver(ctx context.Context, webhook *notifications.Webhook, n *notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal notification")
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(opCtx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", n.DeliveryID)
+	if webhook.AuthHeader != "" {
+		req.Header.Set("Authorization", webhook.AuthHeader)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+	// Drain the body to allow the connection to be reused.
+	//nolint:errcheck
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
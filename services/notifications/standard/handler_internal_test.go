@@ -0,0 +1,131 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/notifications"
+)
+
+func TestDeliverWithRetries(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		failures    int
+		maxAttempts int
+		err         string
+		attempts    int
+	}{
+		{
+			name:        "Immediate",
+			maxAttempts: 3,
+			attempts:    1,
+		},
+		{
+			name:        "Retried",
+			failures:    2,
+			maxAttempts: 3,
+			attempts:    3,
+		},
+		{
+			name:        "Exhausted",
+			failures:    3,
+			maxAttempts: 3,
+			attempts:    3,
+			err:         "failed after 3 attempts: webhook returned status 503",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mu sync.Mutex
+			received := make([]*notification, 0)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+				require.Equal(t, "epoch-5-0x01", r.Header.Get("Idempotency-Key"))
+				n := &notification{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(n))
+				mu.Lock()
+				received = append(received, n)
+				failed := len(received) <= test.failures
+				mu.Unlock()
+				if failed {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			s := &Service{
+				timeout: time.Second,
+				client:  server.Client(),
+			}
+			webhook := &notifications.Webhook{
+				Name:           "test",
+				URL:            server.URL,
+				AuthHeader:     "Bearer secret",
+				MaxAttempts:    test.maxAttempts,
+				InitialBackoff: time.Millisecond,
+				MaxBackoff:     2 * time.Millisecond,
+			}
+			n := &notification{
+				DeliveryID:       "epoch-5-0x01",
+				Epoch:            5,
+				BlockRoot:        "0x01",
+				CompleteServices: []string{"blocks"},
+			}
+
+			err := s.deliverWithRetries(ctx, webhook, n)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Len(t, received, test.attempts)
+			for i, n := range received {
+				require.Equal(t, i+1, n.Attempt)
+				require.Equal(t, "epoch-5-0x01", n.DeliveryID)
+				require.Equal(t, uint64(5), n.Epoch)
+				require.Equal(t, []string{"blocks"}, n.CompleteServices)
+			}
+		})
+	}
+}
+
+func TestOnFinalityUpdated(t *testing.T) {
+	w := &worker{
+		signalCh: make(chan struct{}, 1),
+		target:   -1,
+	}
+	s := &Service{
+		workers: []*worker{w},
+	}
+
+	// Multiple updates do not block, and the target is the highest epoch seen.
+	s.OnFinalityUpdated(context.Background(), 10)
+	s.OnFinalityUpdated(context.Background(), 12)
+	s.OnFinalityUpdated(context.Background(), 11)
+	require.Equal(t, int64(12), w.target)
+	require.Len(t, w.signalCh, 1)
+}
@@ -0,0 +1,70 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// metadata stored about a webhook.
+type metadata struct {
+	LatestEpoch int64 `json:"latest_epoch"`
+}
+
+// metadataKeyPrefix is the prefix of the key for the metadata of each webhook.
+var metadataKeyPrefix = "notifications.standard."
+
+// getMetadata gets metadata for a webhook.
+func (s *Service) getMetadata(ctx context.Context, webhook string) (*metadata, error) {
+	md := &metadata{
+		LatestEpoch: -1,
+	}
+	mdJSON, err := s.chainDB.Metadata(ctx, metadataKeyPrefix+webhook)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch metadata")
+	}
+	if mdJSON == nil {
+		return md, nil
+	}
+	if err := json.Unmarshal(mdJSON, md); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal metadata")
+	}
+	return md, nil
+}
+
+// setMetadataTx sets metadata for a webhook in its own transaction.
+func (s *Service) setMetadataTx(ctx context.Context, webhook string, md *metadata) error {
+	mdJSON, err := json.Marshal(md)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal metadata")
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.chainDB.SetMetadata(ctx, metadataKeyPrefix+webhook, mdJSON); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to update metadata")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
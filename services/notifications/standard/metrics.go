@@ -0,0 +1,89 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_notifications"
+
+var (
+	deliveriesTotal *prometheus.CounterVec
+	deliveredEpoch  *prometheus.GaugeVec
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if deliveriesTotal != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	deliveriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "deliveries_total",
+		Help:      "Number of attempts to deliver notifications",
+	}, []string{"webhook", "result"})
+	if err := prometheus.Register(deliveriesTotal); err != nil {
+		return errors.Wrap(err, "failed to register deliveries_total")
+	}
+
+	deliveredEpoch = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "delivered_epoch",
+		Help:      "Latest epoch for which a notification has been delivered",
+	}, []string{"webhook"})
+	if err := prometheus.Register(deliveredEpoch); err != nil {
+		return errors.Wrap(err, "failed to register delivered_epoch")
+	}
+
+	return nil
+}
+
+// monitorDelivery is called when an attempt is made to deliver a notification.
+func monitorDelivery(webhook string, succeeded bool) {
+	if deliveriesTotal == nil {
+		return
+	}
+
+	if succeeded {
+		deliveriesTotal.WithLabelValues(webhook, "succeeded").Inc()
+	} else {
+		deliveriesTotal.WithLabelValues(webhook, "failed").Inc()
+	}
+}
+
+// monitorDeliveredEpoch is called when a notification for an epoch has been delivered.
+func monitorDeliveredEpoch(webhook string, epoch phase0.Epoch) {
+	if deliveredEpoch == nil {
+		return
+	}
+
+	deliveredEpoch.WithLabelValues(webhook).Set(float64(epoch))
+}
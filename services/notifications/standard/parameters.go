@@ -0,0 +1,159 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/notifications"
+)
+
+type parameters struct {
+	logLevel          zerolog.Level
+	monitor           metrics.Service
+	chainDB           chaindb.Service
+	chainTime         chaintime.Service
+	webhooks          []*notifications.Webhook
+	completedServices notifications.CompletedServicesFunc
+	timeout           time.Duration
+	client            *http.Client
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithWebhooks sets the webhooks to notify.
+func WithWebhooks(webhooks []*notifications.Webhook) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.webhooks = webhooks
+	})
+}
+
+// WithCompletedServices sets the function to obtain the services whose data for an epoch is complete.
+func WithCompletedServices(completedServices notifications.CompletedServicesFunc) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.completedServices = completedServices
+	})
+}
+
+// WithTimeout sets the timeout for each delivery attempt.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithHTTPClient sets the HTTP client used to deliver notifications.
+func WithHTTPClient(client *http.Client) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.client = client
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		timeout:  10 * time.Second,
+		client:   http.DefaultClient,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if len(parameters.webhooks) == 0 {
+		return nil, errors.New("no webhooks specified")
+	}
+	names := make(map[string]bool)
+	for _, webhook := range parameters.webhooks {
+		if webhook.Name == "" {
+			return nil, errors.New("webhook has no name")
+		}
+		if names[webhook.Name] {
+			return nil, fmt.Errorf("duplicate webhook name %q", webhook.Name)
+		}
+		names[webhook.Name] = true
+		if webhook.URL == "" {
+			return nil, fmt.Errorf("webhook %q has no URL", webhook.Name)
+		}
+		if webhook.MaxAttempts < 1 {
+			return nil, fmt.Errorf("webhook %q must have at least one attempt", webhook.Name)
+		}
+		if webhook.InitialBackoff <= 0 {
+			return nil, fmt.Errorf("webhook %q must have a positive initial backoff", webhook.Name)
+		}
+		if webhook.MaxBackoff < webhook.InitialBackoff {
+			return nil, fmt.Errorf("webhook %q maximum backoff cannot be less than initial backoff", webhook.Name)
+		}
+	}
+	if parameters.timeout <= 0 {
+		return nil, errors.New("timeout must be positive")
+	}
+	if parameters.client == nil {
+		return nil, errors.New("no HTTP client specified")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,113 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/notifications"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a notifications service.
+// It notifies webhooks when the finalizer has completed processing an epoch.
+type Service struct {
+	chainDB           chaindb.Service
+	blocksProvider    chaindb.BlocksProvider
+	chainTime         chaintime.Service
+	completedServices notifications.CompletedServicesFunc
+	timeout           time.Duration
+	client            *http.Client
+	workers           []*worker
+}
+
+// worker delivers notifications to a single webhook, so that a slow or failing
+// webhook does not hold up others.
+type worker struct {
+	webhook  *notifications.Webhook
+	signalCh chan struct{}
+	targetMu sync.Mutex
+	target   int64
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger(zerologger.With().Str("service", "notifications").Str("impl", "standard").Logger(), "notifications", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isBlocksProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isBlocksProvider {
+		return nil, errors.New("chain DB does not support block providing")
+	}
+
+	s := &Service{
+		chainDB:           parameters.chainDB,
+		blocksProvider:    blocksProvider,
+		chainTime:         parameters.chainTime,
+		completedServices: parameters.completedServices,
+		timeout:           parameters.timeout,
+		client:            parameters.client,
+		workers:           make([]*worker, 0, len(parameters.webhooks)),
+	}
+	for _, webhook := range parameters.webhooks {
+		w := &worker{
+			webhook:  webhook,
+			signalCh: make(chan struct{}, 1),
+			target:   -1,
+		}
+		s.workers = append(s.workers, w)
+		go s.run(ctx, w)
+	}
+
+	return s, nil
+}
+
+// OnFinalityUpdated is called when finality has been updated in the database.
+// Delivery takes place in the background, so this does not block the finalizer.
+func (s *Service) OnFinalityUpdated(_ context.Context, epoch phase0.Epoch) {
+	for _, w := range s.workers {
+		w.targetMu.Lock()
+		if int64(epoch) > w.target {
+			w.target = int64(epoch)
+		}
+		w.targetMu.Unlock()
+
+		select {
+		case w.signalCh <- struct{}{}:
+		default:
+			// Worker already signalled; it will pick up the new target.
+		}
+	}
+}
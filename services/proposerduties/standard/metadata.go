@@ -59,3 +59,16 @@ func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
 	}
 	return nil
 }
+
+// LatestCompleteEpoch returns the latest epoch for which proposer duties have been processed.
+func (s *Service) LatestCompleteEpoch(ctx context.Context) (phase0.Epoch, bool, error) {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if md.LatestEpoch < 0 {
+		return 0, false, nil
+	}
+
+	return phase0.Epoch(md.LatestEpoch), true, nil
+}
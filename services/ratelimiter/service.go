@@ -0,0 +1,77 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimiter
+
+import (
+	"context"
+)
+
+// Class is the class of a request to the beacon node, used to weight it
+// according to the load it places on the node.
+type Class string
+
+const (
+	// ClassLight is for small requests, such as headers and finality.
+	ClassLight Class = "light"
+	// ClassBlock is for requests for blocks.
+	ClassBlock Class = "block"
+	// ClassDuties is for requests for proposer duties.
+	ClassDuties Class = "duties"
+	// ClassCommittees is for requests for beacon and sync committees.
+	ClassCommittees Class = "committees"
+	// ClassValidators is for requests for validators and their balances.
+	ClassValidators Class = "validators"
+	// ClassState is for requests for full beacon states.
+	ClassState Class = "state"
+)
+
+// Classes are all of the request classes.
+var Classes = []Class{
+	ClassLight,
+	ClassBlock,
+	ClassDuties,
+	ClassCommittees,
+	ClassValidators,
+	ClassState,
+}
+
+// Priority is the priority of a request to the beacon node.
+type Priority int
+
+const (
+	// PriorityCatchUp is for requests for data well behind the head of the chain.
+	PriorityCatchUp Priority = iota
+	// PriorityHead is for requests that follow the head of the chain.
+	PriorityHead
+)
+
+var priorityStrings = [...]string{
+	"catchup",
+	"head",
+}
+
+// String returns a string representation of the priority.
+func (p Priority) String() string {
+	if int(p) >= len(priorityStrings) || p < 0 {
+		return "unknown"
+	}
+
+	return priorityStrings[p]
+}
+
+// Service is a limiter of requests to a beacon node.
+type Service interface {
+	// Wait waits until a request of the given class and priority can be made.
+	Wait(ctx context.Context, class Class, priority Priority) error
+}
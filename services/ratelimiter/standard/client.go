@@ -0,0 +1,326 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/beaconfetcher"
+	"github.com/wealdtech/chaind/services/ratelimiter"
+)
+
+// client is an Ethereum 2 client whose requests are subject to the rate limiter.
+// Requests for chain configuration, which the underlying client caches, and
+// the event stream are not limited.
+type client struct {
+	limiter *Service
+	next    eth2client.Service
+}
+
+// Client wraps an Ethereum 2 client so that its requests are subject to the rate limiter.
+func (s *Service) Client(next eth2client.Service) eth2client.Service {
+	return &client{
+		limiter: s,
+		next:    next,
+	}
+}
+
+// fetcher is a beacon fetcher whose requests are subject to the rate limiter.
+type fetcher struct {
+	limiter *Service
+	next    beaconfetcher.Service
+}
+
+// BeaconFetcher wraps a beacon fetcher so that its requests are subject to the rate limiter.
+func (s *Service) BeaconFetcher(next beaconfetcher.Service) beaconfetcher.Service {
+	return &fetcher{
+		limiter: s,
+		next:    next,
+	}
+}
+
+// waitForID waits for a request of the given class for a block or state ID.
+func (s *Service) waitForID(ctx context.Context, class ratelimiter.Class, id string) error {
+	return s.Wait(ctx, class, s.idPriority(id))
+}
+
+// waitForEpoch waits for a request of the given class for an epoch.
+func (s *Service) waitForEpoch(ctx context.Context, class ratelimiter.Class, epoch phase0.Epoch) error {
+	return s.Wait(ctx, class, s.priority(s.chainTime.FirstSlotOfEpoch(epoch)))
+}
+
+// idPriority returns the priority of a request for a block or state ID.
+// IDs that are not slots, such as roots, are presumed to follow the head.
+func (s *Service) idPriority(id string) ratelimiter.Priority {
+	if id == "genesis" {
+		return ratelimiter.PriorityCatchUp
+	}
+	slot, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return ratelimiter.PriorityHead
+	}
+
+	return s.priority(phase0.Slot(slot))
+}
+
+// Name returns the name of the client implementation.
+func (c *client) Name() string {
+	return c.next.Name()
+}
+
+// Address returns the address of the client.
+func (c *client) Address() string {
+	return c.next.Address()
+}
+
+// GenesisTime provides the genesis time of the chain.
+func (c *client) GenesisTime(ctx context.Context) (time.Time, error) {
+	provider, isProvider := c.next.(eth2client.GenesisTimeProvider)
+	if !isProvider {
+		return time.Time{}, errors.New("client is not a GenesisTimeProvider")
+	}
+
+	return provider.GenesisTime(ctx)
+}
+
+// Genesis fetches genesis information for the chain.
+func (c *client) Genesis(ctx context.Context) (*apiv1.Genesis, error) {
+	provider, isProvider := c.next.(eth2client.GenesisProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a GenesisProvider")
+	}
+
+	return provider.Genesis(ctx)
+}
+
+// Spec provides the spec information of the chain.
+func (c *client) Spec(ctx context.Context) (map[string]interface{}, error) {
+	provider, isProvider := c.next.(eth2client.SpecProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a SpecProvider")
+	}
+
+	return provider.Spec(ctx)
+}
+
+// SlotsPerEpoch provides the slots per epoch of the chain.
+func (c *client) SlotsPerEpoch(ctx context.Context) (uint64, error) {
+	provider, isProvider := c.next.(eth2client.SlotsPerEpochProvider)
+	if !isProvider {
+		return 0, errors.New("client is not a SlotsPerEpochProvider")
+	}
+
+	return provider.SlotsPerEpoch(ctx)
+}
+
+// ForkSchedule provides details of past and future changes in the chain's fork version.
+func (c *client) ForkSchedule(ctx context.Context) ([]*phase0.Fork, error) {
+	provider, isProvider := c.next.(eth2client.ForkScheduleProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a ForkScheduleProvider")
+	}
+
+	return provider.ForkSchedule(ctx)
+}
+
+// Events feeds requested events with the given topics to the supplied handler.
+func (c *client) Events(ctx context.Context, topics []string, handler eth2client.EventHandlerFunc) error {
+	provider, isProvider := c.next.(eth2client.EventsProvider)
+	if !isProvider {
+		return errors.New("client is not an EventsProvider")
+	}
+
+	return provider.Events(ctx, topics, handler)
+}
+
+// NodeSyncing provides the state of the node's synchronization with the chain.
+func (c *client) NodeSyncing(ctx context.Context) (*apiv1.SyncState, error) {
+	provider, isProvider := c.next.(eth2client.NodeSyncingProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a NodeSyncingProvider")
+	}
+	if err := c.limiter.Wait(ctx, ratelimiter.ClassLight, ratelimiter.PriorityHead); err != nil {
+		return nil, err
+	}
+
+	return provider.NodeSyncing(ctx)
+}
+
+// Finality provides the finality given a state ID.
+func (c *client) Finality(ctx context.Context, stateID string) (*apiv1.Finality, error) {
+	provider, isProvider := c.next.(eth2client.FinalityProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a FinalityProvider")
+	}
+	if err := c.limiter.waitForID(ctx, ratelimiter.ClassLight, stateID); err != nil {
+		return nil, err
+	}
+
+	return provider.Finality(ctx, stateID)
+}
+
+// BeaconBlockHeader provides the block header of a given block ID.
+func (c *client) BeaconBlockHeader(ctx context.Context, blockID string) (*apiv1.BeaconBlockHeader, error) {
+	provider, isProvider := c.next.(eth2client.BeaconBlockHeadersProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a BeaconBlockHeadersProvider")
+	}
+	if err := c.limiter.waitForID(ctx, ratelimiter.ClassLight, blockID); err != nil {
+		return nil, err
+	}
+
+	return provider.BeaconBlockHeader(ctx, blockID)
+}
+
+// SignedBeaconBlock fetches a signed beacon block given a block ID.
+func (c *client) SignedBeaconBlock(ctx context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	provider, isProvider := c.next.(eth2client.SignedBeaconBlockProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a SignedBeaconBlockProvider")
+	}
+	if err := c.limiter.waitForID(ctx, ratelimiter.ClassBlock, blockID); err != nil {
+		return nil, err
+	}
+
+	return provider.SignedBeaconBlock(ctx, blockID)
+}
+
+// BeaconState fetches a beacon state given a state ID.
+func (c *client) BeaconState(ctx context.Context, stateID string) (*spec.VersionedBeaconState, error) {
+	provider, isProvider := c.next.(eth2client.BeaconStateProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a BeaconStateProvider")
+	}
+	if err := c.limiter.waitForID(ctx, ratelimiter.ClassState, stateID); err != nil {
+		return nil, err
+	}
+
+	return provider.BeaconState(ctx, stateID)
+}
+
+// BeaconCommittees fetches all beacon committees for the epoch at the given state.
+func (c *client) BeaconCommittees(ctx context.Context, stateID string) ([]*apiv1.BeaconCommittee, error) {
+	provider, isProvider := c.next.(eth2client.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a BeaconCommitteesProvider")
+	}
+	if err := c.limiter.waitForID(ctx, ratelimiter.ClassCommittees, stateID); err != nil {
+		return nil, err
+	}
+
+	return provider.BeaconCommittees(ctx, stateID)
+}
+
+// BeaconCommitteesAtEpoch fetches all beacon committees for the given epoch at the given state.
+func (c *client) BeaconCommitteesAtEpoch(ctx context.Context, stateID string, epoch phase0.Epoch) ([]*apiv1.BeaconCommittee, error) {
+	provider, isProvider := c.next.(eth2client.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a BeaconCommitteesProvider")
+	}
+	if err := c.limiter.waitForEpoch(ctx, ratelimiter.ClassCommittees, epoch); err != nil {
+		return nil, err
+	}
+
+	return provider.BeaconCommitteesAtEpoch(ctx, stateID, epoch)
+}
+
+// SyncCommittee fetches the sync committee for the given state.
+func (c *client) SyncCommittee(ctx context.Context, stateID string) (*apiv1.SyncCommittee, error) {
+	provider, isProvider := c.next.(eth2client.SyncCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a SyncCommitteesProvider")
+	}
+	if err := c.limiter.waitForID(ctx, ratelimiter.ClassCommittees, stateID); err != nil {
+		return nil, err
+	}
+
+	return provider.SyncCommittee(ctx, stateID)
+}
+
+// SyncCommitteeAtEpoch fetches the sync committee for the given epoch at the given state.
+func (c *client) SyncCommitteeAtEpoch(ctx context.Context, stateID string, epoch phase0.Epoch) (*apiv1.SyncCommittee, error) {
+	provider, isProvider := c.next.(eth2client.SyncCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a SyncCommitteesProvider")
+	}
+	if err := c.limiter.waitForEpoch(ctx, ratelimiter.ClassCommittees, epoch); err != nil {
+		return nil, err
+	}
+
+	return provider.SyncCommitteeAtEpoch(ctx, stateID, epoch)
+}
+
+// ProposerDuties obtains proposer duties for the given epoch.
+func (c *client) ProposerDuties(ctx context.Context, epoch phase0.Epoch, validatorIndices []phase0.ValidatorIndex) ([]*apiv1.ProposerDuty, error) {
+	provider, isProvider := c.next.(eth2client.ProposerDutiesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a ProposerDutiesProvider")
+	}
+	if err := c.limiter.waitForEpoch(ctx, ratelimiter.ClassDuties, epoch); err != nil {
+		return nil, err
+	}
+
+	return provider.ProposerDuties(ctx, epoch, validatorIndices)
+}
+
+// Validators provides the validators, with their balance and status, for a given state.
+func (c *client) Validators(ctx context.Context, stateID string, validatorIndices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	provider, isProvider := c.next.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a ValidatorsProvider")
+	}
+	if err := c.limiter.waitForID(ctx, ratelimiter.ClassValidators, stateID); err != nil {
+		return nil, err
+	}
+
+	return provider.Validators(ctx, stateID, validatorIndices)
+}
+
+// ValidatorsByPubKey provides the validators, with their balance and status, for a given state.
+func (c *client) ValidatorsByPubKey(ctx context.Context, stateID string, validatorPubKeys []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	provider, isProvider := c.next.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a ValidatorsProvider")
+	}
+	if err := c.limiter.waitForID(ctx, ratelimiter.ClassValidators, stateID); err != nil {
+		return nil, err
+	}
+
+	return provider.ValidatorsByPubKey(ctx, stateID, validatorPubKeys)
+}
+
+// SignedBeaconBlock fetches a signed beacon block given a block ID.
+func (f *fetcher) SignedBeaconBlock(ctx context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	if err := f.limiter.waitForID(ctx, ratelimiter.ClassBlock, blockID); err != nil {
+		return nil, err
+	}
+
+	return f.next.SignedBeaconBlock(ctx, blockID)
+}
+
+// BeaconState fetches a beacon state given a state ID.
+func (f *fetcher) BeaconState(ctx context.Context, stateID string) (*spec.VersionedBeaconState, error) {
+	if err := f.limiter.waitForID(ctx, ratelimiter.ClassState, stateID); err != nil {
+		return nil, err
+	}
+
+	return f.next.BeaconState(ctx, stateID)
+}
@@ -0,0 +1,63 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/ratelimiter"
+)
+
+var metricsNamespace = "chaind_ratelimiter"
+
+var waitDuration *prometheus.HistogramVec
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if waitDuration != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	waitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "wait_seconds",
+		Help:      "Time requests to the beacon node have waited for the rate limiter",
+		Buckets:   []float64{0, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60},
+	}, []string{"class", "priority"})
+	if err := prometheus.Register(waitDuration); err != nil {
+		return errors.Wrap(err, "failed to register wait_seconds")
+	}
+
+	return nil
+}
+
+func monitorWait(class ratelimiter.Class, priority ratelimiter.Priority, duration time.Duration) {
+	if waitDuration != nil {
+		waitDuration.WithLabelValues(string(class), priority.String()).Observe(duration.Seconds())
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/ratelimiter"
+)
+
+// defaultWeights are the default weights of each class of request.
+var defaultWeights = map[ratelimiter.Class]float64{
+	ratelimiter.ClassLight:      1,
+	ratelimiter.ClassBlock:      1,
+	ratelimiter.ClassDuties:     2,
+	ratelimiter.ClassCommittees: 4,
+	ratelimiter.ClassValidators: 16,
+	ratelimiter.ClassState:      32,
+}
+
+type parameters struct {
+	logLevel     zerolog.Level
+	monitor      metrics.Service
+	chainTime    chaintime.Service
+	budget       float64
+	weights      map[ratelimiter.Class]float64
+	headDistance phase0.Slot
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithBudget sets the total weight of requests that can be made per second.
+func WithBudget(budget float64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.budget = budget
+	})
+}
+
+// WithWeights sets the weights of classes of request, overriding the defaults.
+func WithWeights(weights map[ratelimiter.Class]float64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		for class, weight := range weights {
+			p.weights[class] = weight
+		}
+	})
+}
+
+// WithHeadDistance sets the distance from the current slot within which
+// requests are considered to be following the head of the chain.
+func WithHeadDistance(distance phase0.Slot) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.headDistance = distance
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:     zerolog.GlobalLevel(),
+		weights:      make(map[ratelimiter.Class]float64, len(defaultWeights)),
+		headDistance: 64,
+	}
+	for class, weight := range defaultWeights {
+		parameters.weights[class] = weight
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.budget <= 0 {
+		return nil, errors.New("budget must be greater than 0")
+	}
+	for class, weight := range parameters.weights {
+		if _, exists := defaultWeights[class]; !exists {
+			return nil, fmt.Errorf("unknown request class %q", class)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("weight for request class %q cannot be negative", class)
+		}
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,237 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/ratelimiter"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a weighted token bucket limiting requests to a beacon node.
+// Requests that follow the head of the chain are served before those that
+// are catching up whenever both are waiting for the budget.
+type Service struct {
+	chainTime    chaintime.Service
+	weights      map[ratelimiter.Class]float64
+	headDistance phase0.Slot
+
+	mu       sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+	// queues are the waiting requests, indexed by priority.
+	queues [2][]*waiter
+	timer  *time.Timer
+}
+
+// waiter is a request waiting for budget.
+type waiter struct {
+	weight  float64
+	granted bool
+	ready   chan struct{}
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new rate limiter.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger(zerologger.With().Str("service", "ratelimiter").Str("impl", "standard").Logger(), "ratelimiter", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	// The bucket holds up to one second's worth of budget.
+	capacity := math.Max(1, parameters.budget)
+
+	s := &Service{
+		chainTime:    parameters.chainTime,
+		weights:      parameters.weights,
+		headDistance: parameters.headDistance,
+		rate:         parameters.budget,
+		capacity:     capacity,
+		tokens:       capacity,
+		last:         time.Now(),
+	}
+	log.Trace().Float64("budget", s.rate).Msg("Created rate limiter")
+
+	return s, nil
+}
+
+// Wait waits until a request of the given class and priority can be made.
+func (s *Service) Wait(ctx context.Context, class ratelimiter.Class, priority ratelimiter.Priority) error {
+	weight := s.weights[class]
+	if weight == 0 {
+		return nil
+	}
+	if priority != ratelimiter.PriorityHead {
+		priority = ratelimiter.PriorityCatchUp
+	}
+
+	started := time.Now()
+	s.mu.Lock()
+	s.refill(started)
+	if s.queued(priority) == 0 && s.tokens >= s.required(weight) {
+		s.tokens -= weight
+		s.mu.Unlock()
+		monitorWait(class, priority, 0)
+		return nil
+	}
+	w := &waiter{
+		weight: weight,
+		ready:  make(chan struct{}),
+	}
+	s.queues[priority] = append(s.queues[priority], w)
+	s.schedule()
+	s.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		s.mu.Lock()
+		if w.granted {
+			// Return the unused budget.
+			s.tokens += w.weight
+		} else {
+			s.remove(priority, w)
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	case <-w.ready:
+	}
+	monitorWait(class, priority, time.Since(started))
+
+	return nil
+}
+
+// queued returns the number of requests waiting that would be served
+// before a request of the given priority.
+// This requires the lock to be held.
+func (s *Service) queued(priority ratelimiter.Priority) int {
+	count := len(s.queues[ratelimiter.PriorityHead])
+	if priority == ratelimiter.PriorityCatchUp {
+		count += len(s.queues[ratelimiter.PriorityCatchUp])
+	}
+
+	return count
+}
+
+// next returns the next waiting request to be served, or nil if there are none.
+// This requires the lock to be held.
+func (s *Service) next() (ratelimiter.Priority, *waiter) {
+	for _, priority := range []ratelimiter.Priority{ratelimiter.PriorityHead, ratelimiter.PriorityCatchUp} {
+		if len(s.queues[priority]) > 0 {
+			return priority, s.queues[priority][0]
+		}
+	}
+
+	return ratelimiter.PriorityCatchUp, nil
+}
+
+// remove removes a waiting request from its queue.
+// This requires the lock to be held.
+func (s *Service) remove(priority ratelimiter.Priority, w *waiter) {
+	queue := s.queues[priority]
+	for i := range queue {
+		if queue[i] == w {
+			s.queues[priority] = append(queue[:i], queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// schedule arranges for the waiting requests to be served when the budget allows.
+// This requires the lock to be held.
+func (s *Service) schedule() {
+	_, w := s.next()
+	if w == nil {
+		return
+	}
+	delay := time.Duration(0)
+	if required := s.required(w.weight); s.tokens < required {
+		delay = time.Duration((required - s.tokens) / s.rate * float64(time.Second))
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(delay, s.dispatch)
+}
+
+// dispatch serves waiting requests for which there is budget, in order of priority.
+func (s *Service) dispatch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refill(time.Now())
+	for {
+		priority, w := s.next()
+		if w == nil {
+			s.timer = nil
+			return
+		}
+		if s.tokens < s.required(w.weight) {
+			s.schedule()
+			return
+		}
+		s.tokens -= w.weight
+		s.queues[priority] = s.queues[priority][1:]
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+// required returns the budget that must be available for a request of the
+// given weight to proceed.  Requests heavier than the bucket proceed when it is
+// full, leaving it in deficit so that the overall rate is still respected.
+func (s *Service) required(weight float64) float64 {
+	return math.Min(weight, s.capacity)
+}
+
+// refill adds budget for the time elapsed since the last refill.
+// This requires the lock to be held.
+func (s *Service) refill(now time.Time) {
+	elapsed := now.Sub(s.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	s.tokens = math.Min(s.capacity, s.tokens+elapsed*s.rate)
+	s.last = now
+}
+
+// priority returns the priority of a request for data at the given slot.
+func (s *Service) priority(slot phase0.Slot) ratelimiter.Priority {
+	currentSlot := s.chainTime.CurrentSlot()
+	if slot+s.headDistance >= currentSlot {
+		return ratelimiter.PriorityHead
+	}
+
+	return ratelimiter.PriorityCatchUp
+}
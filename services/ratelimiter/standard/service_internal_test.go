@@ -0,0 +1,315 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaintime"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/services/ratelimiter"
+	"github.com/wealdtech/chaind/testing/mock"
+)
+
+// currentSlot is the current slot of the test chain.
+const currentSlot = 10000
+
+func testChainTime(t *testing.T) chaintime.Service {
+	t.Helper()
+	slotDuration := 12 * time.Second
+	chainTime, err := standardchaintime.New(context.Background(),
+		standardchaintime.WithLogLevel(zerolog.Disabled),
+		standardchaintime.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(time.Now().Add(-currentSlot*slotDuration))),
+		standardchaintime.WithSpecProvider(mock.NewSpecProvider(slotDuration, 32, 256)),
+		standardchaintime.WithForkScheduleProvider(mock.NewForkScheduleProvider(nil)),
+	)
+	require.NoError(t, err)
+
+	return chainTime
+}
+
+func TestParameters(t *testing.T) {
+	ctx := context.Background()
+	chainTime := testChainTime(t)
+
+	tests := []struct {
+		name   string
+		params []Parameter
+		err    string
+	}{
+		{
+			name: "ChainTimeMissing",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithBudget(10),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "BudgetMissing",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainTime(chainTime),
+			},
+			err: "problem with parameters: budget must be greater than 0",
+		},
+		{
+			name: "WeightNegative",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainTime(chainTime),
+				WithBudget(10),
+				WithWeights(map[ratelimiter.Class]float64{ratelimiter.ClassState: -1}),
+			},
+			err: `problem with parameters: weight for request class "state" cannot be negative`,
+		},
+		{
+			name: "ClassUnknown",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainTime(chainTime),
+				WithBudget(10),
+				WithWeights(map[ratelimiter.Class]float64{"bad": 1}),
+			},
+			err: `problem with parameters: unknown request class "bad"`,
+		},
+		{
+			name: "Good",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainTime(chainTime),
+				WithBudget(10),
+				WithWeights(map[ratelimiter.Class]float64{ratelimiter.ClassState: 64}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, float64(64), s.weights[ratelimiter.ClassState])
+			require.Equal(t, float64(1), s.weights[ratelimiter.ClassBlock])
+		})
+	}
+}
+
+func TestWeights(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainTime(testChainTime(t)),
+		WithBudget(32),
+	)
+	require.NoError(t, err)
+
+	// The initial burst covers one state or 32 blocks.
+	require.NoError(t, s.Wait(ctx, ratelimiter.ClassState, ratelimiter.PriorityHead))
+	require.InDelta(t, 0, s.tokens, 0.1)
+
+	// Another state must wait for a second's worth of budget.
+	started := time.Now()
+	require.NoError(t, s.Wait(ctx, ratelimiter.ClassState, ratelimiter.PriorityHead))
+	require.InDelta(t, time.Second, time.Since(started), float64(200*time.Millisecond))
+
+	// A block only needs a fraction of that.
+	started = time.Now()
+	require.NoError(t, s.Wait(ctx, ratelimiter.ClassBlock, ratelimiter.PriorityHead))
+	require.Less(t, time.Since(started), 200*time.Millisecond)
+}
+
+func TestHeavyRequest(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainTime(testChainTime(t)),
+		WithBudget(16),
+	)
+	require.NoError(t, err)
+
+	// A state is heavier than the bucket, so proceeds when it is full and
+	// leaves it in deficit.
+	require.NoError(t, s.Wait(ctx, ratelimiter.ClassState, ratelimiter.PriorityHead))
+	require.InDelta(t, -16, s.tokens, 0.1)
+
+	// The next request waits for the deficit to be repaid.
+	started := time.Now()
+	require.NoError(t, s.Wait(ctx, ratelimiter.ClassBlock, ratelimiter.PriorityHead))
+	require.InDelta(t, 17*time.Second/16, time.Since(started), float64(200*time.Millisecond))
+}
+
+func TestPriority(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainTime(testChainTime(t)),
+		WithBudget(20),
+	)
+	require.NoError(t, err)
+
+	// Exhaust the budget.
+	for i := 0; i < 20; i++ {
+		require.NoError(t, s.Wait(ctx, ratelimiter.ClassBlock, ratelimiter.PriorityCatchUp))
+	}
+
+	var mu sync.Mutex
+	order := make([]ratelimiter.Priority, 0)
+	var wg sync.WaitGroup
+	wait := func(priority ratelimiter.Priority) {
+		defer wg.Done()
+		require.NoError(t, s.Wait(ctx, ratelimiter.ClassBlock, priority))
+		mu.Lock()
+		order = append(order, priority)
+		mu.Unlock()
+	}
+
+	// Queue catch-up requests, then head requests behind them.
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go wait(ratelimiter.PriorityCatchUp)
+	}
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.queues[ratelimiter.PriorityCatchUp]) == 5
+	}, time.Second, time.Millisecond)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go wait(ratelimiter.PriorityHead)
+	}
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.queues[ratelimiter.PriorityHead]) == 5
+	}, time.Second, time.Millisecond)
+	wg.Wait()
+
+	// The head requests were served first.
+	require.Equal(t, []ratelimiter.Priority{
+		ratelimiter.PriorityHead,
+		ratelimiter.PriorityHead,
+		ratelimiter.PriorityHead,
+		ratelimiter.PriorityHead,
+		ratelimiter.PriorityHead,
+		ratelimiter.PriorityCatchUp,
+		ratelimiter.PriorityCatchUp,
+		ratelimiter.PriorityCatchUp,
+		ratelimiter.PriorityCatchUp,
+		ratelimiter.PriorityCatchUp,
+	}, order)
+}
+
+func TestCancel(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainTime(testChainTime(t)),
+		WithBudget(1),
+	)
+	require.NoError(t, err)
+	require.NoError(t, s.Wait(ctx, ratelimiter.ClassBlock, ratelimiter.PriorityHead))
+
+	cancelCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err = s.Wait(cancelCtx, ratelimiter.ClassBlock, ratelimiter.PriorityHead)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The cancelled request no longer waits.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	require.Empty(t, s.queues[ratelimiter.PriorityHead])
+}
+
+func TestIDPriority(t *testing.T) {
+	s, err := New(context.Background(),
+		WithLogLevel(zerolog.Disabled),
+		WithChainTime(testChainTime(t)),
+		WithBudget(10),
+		WithHeadDistance(64),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		id       string
+		priority ratelimiter.Priority
+	}{
+		{id: "head", priority: ratelimiter.PriorityHead},
+		{id: "finalized", priority: ratelimiter.PriorityHead},
+		{id: "0x0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20", priority: ratelimiter.PriorityHead},
+		{id: "genesis", priority: ratelimiter.PriorityCatchUp},
+		{id: "9990", priority: ratelimiter.PriorityHead},
+		{id: "9900", priority: ratelimiter.PriorityCatchUp},
+		{id: "1", priority: ratelimiter.PriorityCatchUp},
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			require.Equal(t, test.priority, s.idPriority(test.id))
+		})
+	}
+}
+
+// testClient is a client that records the calls made to it.
+type testClient struct {
+	calls int
+}
+
+func (*testClient) Name() string {
+	return "test"
+}
+
+func (*testClient) Address() string {
+	return "localhost"
+}
+
+func (c *testClient) ProposerDuties(_ context.Context, _ phase0.Epoch, _ []phase0.ValidatorIndex) ([]*apiv1.ProposerDuty, error) {
+	c.calls++
+	return []*apiv1.ProposerDuty{}, nil
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainTime(testChainTime(t)),
+		WithBudget(4),
+	)
+	require.NoError(t, err)
+
+	next := &testClient{}
+	c := s.Client(next)
+	require.Equal(t, "test", c.Name())
+
+	// The call passes through, and uses the budget for duties.
+	_, err = c.(eth2client.ProposerDutiesProvider).ProposerDuties(ctx, 10, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, next.calls)
+	require.InDelta(t, 2, s.tokens, 0.1)
+
+	// Interfaces that the underlying client does not provide return an error.
+	_, err = c.(eth2client.FinalityProvider).Finality(ctx, "head")
+	require.EqualError(t, err, "client is not a FinalityProvider")
+}
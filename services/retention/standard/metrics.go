@@ -0,0 +1,95 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_retention"
+
+var (
+	rowsPruned   *prometheus.CounterVec
+	rowsPrunable *prometheus.GaugeVec
+	watermarks   *prometheus.GaugeVec
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if rowsPruned != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	rowsPruned = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rows_pruned_total",
+		Help:      "Number of rows pruned",
+	}, []string{"dataset"})
+	if err := prometheus.Register(rowsPruned); err != nil {
+		return errors.Wrap(err, "failed to register rows_pruned_total")
+	}
+
+	rowsPrunable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "rows_prunable",
+		Help:      "Number of rows that would be pruned, as reported by the last dry run",
+	}, []string{"dataset"})
+	if err := prometheus.Register(rowsPrunable); err != nil {
+		return errors.Wrap(err, "failed to register rows_prunable")
+	}
+
+	watermarks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "watermark",
+		Help:      "Epoch or slot before which data has been pruned",
+	}, []string{"dataset"})
+	if err := prometheus.Register(watermarks); err != nil {
+		return errors.Wrap(err, "failed to register watermark")
+	}
+
+	return nil
+}
+
+func monitorRowsPruned(dataset chaindb.RetentionDataset, rows int64) {
+	if rowsPruned != nil {
+		rowsPruned.WithLabelValues(string(dataset)).Add(float64(rows))
+	}
+}
+
+func monitorRowsPrunable(dataset chaindb.RetentionDataset, rows int64) {
+	if rowsPrunable != nil {
+		rowsPrunable.WithLabelValues(string(dataset)).Set(float64(rows))
+	}
+}
+
+func monitorWatermark(dataset chaindb.RetentionDataset, before uint64) {
+	if watermarks != nil {
+		watermarks.WithLabelValues(string(dataset)).Set(float64(before))
+	}
+}
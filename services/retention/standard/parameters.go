@@ -0,0 +1,160 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+type parameters struct {
+	logLevel      zerolog.Level
+	monitor       metrics.Service
+	chainDB       chaindb.Service
+	chainTime     chaintime.Service
+	scheduler     scheduler.Service
+	policies      []*Policy
+	dryRun        bool
+	interval      time.Duration
+	batchSize     int
+	batchInterval time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithScheduler sets the scheduler for this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithPolicies sets the retention policies for this module.
+func WithPolicies(policies []*Policy) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.policies = policies
+	})
+}
+
+// WithDryRun reports the rows that each policy would prune, rather than pruning them.
+func WithDryRun(dryRun bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.dryRun = dryRun
+	})
+}
+
+// WithInterval sets the interval between runs of each policy.
+func WithInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interval = interval
+	})
+}
+
+// WithBatchSize sets the maximum number of rows pruned in each transaction.
+func WithBatchSize(batchSize int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.batchSize = batchSize
+	})
+}
+
+// WithBatchInterval sets the interval between batches, to limit the load on the database.
+func WithBatchInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.batchInterval = interval
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:      zerolog.GlobalLevel(),
+		interval:      time.Hour,
+		batchSize:     1000,
+		batchInterval: 100 * time.Millisecond,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+	if parameters.interval <= 0 {
+		return nil, errors.New("interval must be greater than 0")
+	}
+	if parameters.batchSize <= 0 {
+		return nil, errors.New("batch size must be greater than 0")
+	}
+	if parameters.batchInterval < 0 {
+		return nil, errors.New("batch interval cannot be negative")
+	}
+	for _, policy := range parameters.policies {
+		if policy.Retention == nil {
+			return nil, errors.New("policy has no retention")
+		}
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,157 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// watermark is the metadata recorded after a dataset has been pruned.
+type watermark struct {
+	// Before is the point (epoch or slot, depending on the dataset) before which all data has been pruned.
+	Before uint64 `json:"before"`
+	// Timestamp is the time of the prune.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// slotDatasets are the datasets whose retention point is a slot rather than an epoch.
+var slotDatasets = map[chaindb.RetentionDataset]bool{
+	chaindb.RetentionDatasetAttestations: true,
+}
+
+// metadataKey returns the metadata key for the watermark of a dataset.
+func metadataKey(dataset chaindb.RetentionDataset) string {
+	return fmt.Sprintf("retention.%s", dataset)
+}
+
+// pruneJob is the scheduler job to enforce a retention policy.
+func (s *Service) pruneJob(ctx context.Context, data interface{}) error {
+	policy, ok := data.(*Policy)
+	if !ok {
+		return errors.New("invalid retention policy")
+	}
+
+	return s.prune(ctx, policy, time.Now())
+}
+
+// prune enforces a retention policy as of the given time.
+func (s *Service) prune(ctx context.Context, policy *Policy, now time.Time) error {
+	cutoff := policy.Retention.Decrement(now)
+	before := uint64(s.chainTime.TimestampToEpoch(cutoff))
+	if slotDatasets[policy.Dataset] {
+		before = uint64(s.chainTime.TimestampToSlot(cutoff))
+	}
+	log := log.With().Str("dataset", string(policy.Dataset)).Uint64("before", before).Logger()
+
+	if s.dryRun {
+		rows, err := s.pruner.PrunableRetentionDatasetRows(ctx, policy.Dataset, before)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain prunable rows")
+		}
+		log.Info().Int64("rows", rows).Msg("Dry run; rows would be pruned")
+		monitorRowsPrunable(policy.Dataset, rows)
+		return nil
+	}
+
+	total := int64(0)
+	batchSize := s.batchSize
+	for {
+		rows, err := s.pruneBatch(ctx, policy.Dataset, before, batchSize)
+		if errors.Is(err, chaindb.ErrStatementTimeout) && batchSize > 1 {
+			// Halve the batch for this and subsequent transactions.
+			batchSize /= 2
+			log.Debug().Int("batch_size", batchSize).Msg("Timed out pruning batch; retrying with smaller batch")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		total += rows
+		monitorRowsPruned(policy.Dataset, rows)
+		log.Trace().Int64("rows", rows).Msg("Pruned batch")
+		if rows < int64(batchSize) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.batchInterval):
+		}
+	}
+
+	if err := s.setWatermark(ctx, policy.Dataset, before, now); err != nil {
+		return err
+	}
+	monitorWatermark(policy.Dataset, before)
+	log.Debug().Int64("rows", total).Msg("Pruned dataset")
+
+	return nil
+}
+
+// pruneBatch prunes a single batch of rows in its own transaction.
+// Pruning is known to be long-running, so the transaction is marked as such.
+func (s *Service) pruneBatch(ctx context.Context, dataset chaindb.RetentionDataset, before uint64, batchSize int) (int64, error) {
+	ctx, cancel, err := s.chainDB.BeginTx(chaindb.WithLongRunning(ctx))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to begin transaction")
+	}
+
+	rows, err := s.pruner.PruneRetentionDataset(ctx, dataset, before, batchSize)
+	if err != nil {
+		cancel()
+		return 0, errors.Wrap(err, "failed to prune rows")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return 0, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return rows, nil
+}
+
+// setWatermark records the point before which a dataset has been pruned.
+func (s *Service) setWatermark(ctx context.Context, dataset chaindb.RetentionDataset, before uint64, now time.Time) error {
+	data, err := json.Marshal(&watermark{
+		Before:    before,
+		Timestamp: now.Unix(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal watermark")
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.chainDB.SetMetadata(ctx, metadataKey(dataset), data); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set watermark")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
+)
+
+// jobClass is the scheduler class of retention jobs.
+const jobClass = "retention"
+
+// Policy is the retention policy for a dataset.
+type Policy struct {
+	// Dataset is the dataset to which the policy applies.
+	Dataset chaindb.RetentionDataset
+	// Retention is the period for which data is retained.
+	Retention *util.CalendarDuration
+	// Disabled is true if the policy should not be enforced.
+	Disabled bool
+}
+
+// Service is a retention service.
+type Service struct {
+	chainDB       chaindb.Service
+	pruner        chaindb.RetentionPruner
+	chainTime     chaintime.Service
+	scheduler     scheduler.Service
+	dryRun        bool
+	interval      time.Duration
+	batchSize     int
+	batchInterval time.Duration
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger(zerologger.With().Str("service", "retention").Str("impl", "standard").Logger(), "retention", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	pruner, isPruner := parameters.chainDB.(chaindb.RetentionPruner)
+	if !isPruner {
+		return nil, errors.New("chain DB does not support pruning")
+	}
+
+	s := &Service{
+		chainDB:       parameters.chainDB,
+		pruner:        pruner,
+		chainTime:     parameters.chainTime,
+		scheduler:     parameters.scheduler,
+		dryRun:        parameters.dryRun,
+		interval:      parameters.interval,
+		batchSize:     parameters.batchSize,
+		batchInterval: parameters.batchInterval,
+	}
+
+	for _, policy := range parameters.policies {
+		if policy.Disabled {
+			log.Info().Str("dataset", string(policy.Dataset)).Msg("Retention policy disabled")
+			continue
+		}
+		if err := s.scheduler.SchedulePeriodicJob(ctx,
+			jobClass,
+			fmt.Sprintf("retention-%s", policy.Dataset),
+			s.nextRuntime,
+			nil,
+			s.pruneJob,
+			policy,
+		); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to schedule retention job for %s", policy.Dataset))
+		}
+		log.Info().Str("dataset", string(policy.Dataset)).Str("retention", policy.Retention.String()).Bool("dry_run", s.dryRun).Msg("Retention policy scheduled")
+	}
+
+	return s, nil
+}
+
+// nextRuntime returns the time at which a retention job next runs.
+func (s *Service) nextRuntime(_ context.Context, _ interface{}) (time.Time, error) {
+	return time.Now().Add(s.interval), nil
+}
@@ -0,0 +1,349 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chaintime"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+	"github.com/wealdtech/chaind/util"
+)
+
+// prunerChainDB is a chain database holding rows in memory, keyed by epoch or slot.
+type prunerChainDB struct {
+	chaindb.Service
+	mu       sync.Mutex
+	rows     map[chaindb.RetentionDataset][]uint64
+	batches  int
+	metadata map[string][]byte
+	// timeoutAbove, if set, is the batch size above which pruning times out.
+	timeoutAbove int
+}
+
+func newPrunerChainDB(rows map[chaindb.RetentionDataset][]uint64) *prunerChainDB {
+	return &prunerChainDB{
+		Service:  mockchaindb.New(),
+		rows:     rows,
+		metadata: make(map[string][]byte),
+	}
+}
+
+func (p *prunerChainDB) PruneRetentionDataset(_ context.Context, dataset chaindb.RetentionDataset, before uint64, limit int) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.timeoutAbove > 0 && limit > p.timeoutAbove {
+		return 0, fmt.Errorf("%w: canceling statement", chaindb.ErrStatementTimeout)
+	}
+	p.batches++
+	pruned := int64(0)
+	remaining := make([]uint64, 0, len(p.rows[dataset]))
+	for _, row := range p.rows[dataset] {
+		if row < before && pruned < int64(limit) {
+			pruned++
+			continue
+		}
+		remaining = append(remaining, row)
+	}
+	p.rows[dataset] = remaining
+
+	return pruned, nil
+}
+
+func (*prunerChainDB) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {}, nil
+}
+
+func (p *prunerChainDB) PrunableRetentionDatasetRows(_ context.Context, dataset chaindb.RetentionDataset, before uint64) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rows := int64(0)
+	for _, row := range p.rows[dataset] {
+		if row < before {
+			rows++
+		}
+	}
+
+	return rows, nil
+}
+
+func (p *prunerChainDB) SetMetadata(_ context.Context, key string, value []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.metadata[key] = value
+
+	return nil
+}
+
+// unixChainTime is a chain time with a genesis of the unix epoch, 1s slots and 10 slots per epoch.
+type unixChainTime struct {
+	chaintime.Service
+}
+
+func (*unixChainTime) TimestampToSlot(timestamp time.Time) phase0.Slot {
+	return phase0.Slot(timestamp.Unix())
+}
+
+func (*unixChainTime) TimestampToEpoch(timestamp time.Time) phase0.Epoch {
+	return phase0.Epoch(timestamp.Unix() / 10)
+}
+
+func TestParameters(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := newPrunerChainDB(nil)
+	chainTime := &unixChainTime{Service: mockchaintime.New()}
+	scheduler, err := standardscheduler.New(ctx)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ChainDBNotPruner",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(mockchaindb.New()),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+			},
+			err: "chain DB does not support pruning",
+		},
+		{
+			name: "ChainTimeMissing",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(chainDB),
+				WithScheduler(scheduler),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "SchedulerMissing",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(chainDB),
+				WithChainTime(chainTime),
+			},
+			err: "problem with parameters: no scheduler specified",
+		},
+		{
+			name: "BatchSizeZero",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(chainDB),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+				WithBatchSize(0),
+			},
+			err: "problem with parameters: batch size must be greater than 0",
+		},
+		{
+			name: "PolicyRetentionMissing",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(chainDB),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+				WithPolicies([]*Policy{{Dataset: chaindb.RetentionDatasetValidatorBalances}}),
+			},
+			err: "problem with parameters: policy has no retention",
+		},
+		{
+			name: "Good",
+			params: []Parameter{
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(chainDB),
+				WithChainTime(chainTime),
+				WithScheduler(scheduler),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDisabledPolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scheduler, err := standardscheduler.New(ctx)
+	require.NoError(t, err)
+
+	_, err = New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(newPrunerChainDB(nil)),
+		WithChainTime(&unixChainTime{Service: mockchaintime.New()}),
+		WithScheduler(scheduler),
+		WithPolicies([]*Policy{
+			{
+				Dataset:   chaindb.RetentionDatasetValidatorBalances,
+				Retention: &util.CalendarDuration{},
+			},
+			{
+				Dataset:   chaindb.RetentionDatasetAttestations,
+				Retention: &util.CalendarDuration{},
+				Disabled:  true,
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	require.True(t, scheduler.JobExists(ctx, "retention-validator-balances"))
+	require.False(t, scheduler.JobExists(ctx, "retention-attestations"))
+}
+
+func TestPrune(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	retention := util.MustParseCalendarDuration("PT100S")
+	// 1000s since genesis, so cutoff at 900s: epoch 90 and slot 900.
+	now := time.Unix(1000, 0)
+
+	tests := []struct {
+		name      string
+		dataset   chaindb.RetentionDataset
+		dryRun    bool
+		rows      []uint64
+		remaining int
+		batches   int
+		watermark *watermark
+	}{
+		{
+			name:      "Epochs",
+			dataset:   chaindb.RetentionDatasetValidatorBalances,
+			rows:      []uint64{10, 20, 30, 40, 50, 89, 90, 91},
+			remaining: 2,
+			batches:   3,
+			watermark: &watermark{Before: 90, Timestamp: 1000},
+		},
+		{
+			name:      "Slots",
+			dataset:   chaindb.RetentionDatasetAttestations,
+			rows:      []uint64{10, 899, 900, 901},
+			remaining: 2,
+			batches:   1,
+			watermark: &watermark{Before: 900, Timestamp: 1000},
+		},
+		{
+			name:      "DryRun",
+			dataset:   chaindb.RetentionDatasetValidatorBalances,
+			dryRun:    true,
+			rows:      []uint64{10, 20, 30, 40, 50, 89, 90, 91},
+			remaining: 8,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler, err := standardscheduler.New(ctx)
+			require.NoError(t, err)
+			chainDB := newPrunerChainDB(map[chaindb.RetentionDataset][]uint64{test.dataset: test.rows})
+
+			s, err := New(ctx,
+				WithLogLevel(zerolog.Disabled),
+				WithChainDB(chainDB),
+				WithChainTime(&unixChainTime{Service: mockchaintime.New()}),
+				WithScheduler(scheduler),
+				WithDryRun(test.dryRun),
+				WithBatchSize(3),
+				WithBatchInterval(0),
+			)
+			require.NoError(t, err)
+
+			policy := &Policy{
+				Dataset:   test.dataset,
+				Retention: retention,
+			}
+			require.NoError(t, s.prune(ctx, policy, now))
+			require.Len(t, chainDB.rows[test.dataset], test.remaining)
+			require.Equal(t, test.batches, chainDB.batches)
+
+			data, exists := chainDB.metadata[metadataKey(test.dataset)]
+			if test.watermark == nil {
+				require.False(t, exists)
+			} else {
+				require.True(t, exists)
+				var wm watermark
+				require.NoError(t, json.Unmarshal(data, &wm))
+				require.Equal(t, test.watermark, &wm)
+			}
+		})
+	}
+}
+
+func TestPruneTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scheduler, err := standardscheduler.New(ctx)
+	require.NoError(t, err)
+	chainDB := newPrunerChainDB(map[chaindb.RetentionDataset][]uint64{
+		chaindb.RetentionDatasetValidatorBalances: {10, 20, 30, 40, 50, 89, 90, 91},
+	})
+	chainDB.timeoutAbove = 2
+
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(chainDB),
+		WithChainTime(&unixChainTime{Service: mockchaintime.New()}),
+		WithScheduler(scheduler),
+		WithBatchSize(4),
+		WithBatchInterval(0),
+	)
+	require.NoError(t, err)
+
+	policy := &Policy{
+		Dataset:   chaindb.RetentionDatasetValidatorBalances,
+		Retention: util.MustParseCalendarDuration("PT100S"),
+	}
+	require.NoError(t, s.prune(ctx, policy, time.Unix(1000, 0)))
+	require.Len(t, chainDB.rows[chaindb.RetentionDatasetValidatorBalances], 2)
+	// The first batch of 4 timed out, so batches of 2 were used: three full and one empty.
+	require.Equal(t, 4, chainDB.batches)
+}
@@ -0,0 +1,66 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// RunRecord is a record of a single run of a job.
+type RunRecord struct {
+	// Name is the name of the job.
+	Name string
+	// Class is the class of the job.
+	Class string
+	// Trigger is the reason the job ran: "timer", "signal" or "replay".
+	Trigger string
+	// Scheduled is the time at which the job was scheduled to run.
+	Scheduled time.Time
+	// Started is the time at which the job started to run.
+	Started time.Time
+	// Finished is the time at which the job finished running.
+	Finished time.Time
+	// Err is the error returned by the job, if any.
+	Err error
+}
+
+// Drift is the difference between the time at which the job started and
+// the time at which it was scheduled to start.
+func (r *RunRecord) Drift() time.Duration {
+	return r.Started.Sub(r.Scheduled)
+}
+
+// Duration is the time the job took to run.
+func (r *RunRecord) Duration() time.Duration {
+	return r.Finished.Sub(r.Started)
+}
+
+// DriftStats are statistics about the drift between scheduled and actual start times.
+type DriftStats struct {
+	// Samples is the number of runs from which the statistics were calculated.
+	Samples int
+	// MeanAbsDrift is the mean absolute drift.
+	MeanAbsDrift time.Duration
+	// MaxAbsDrift is the maximum absolute drift.
+	MaxAbsDrift time.Duration
+}
+
+// HistoryStore persists run history, allowing it to survive restarts.
+type HistoryStore interface {
+	// LoadHistory loads run records, oldest first.
+	LoadHistory(ctx context.Context) ([]*RunRecord, error)
+	// SaveHistory saves run records, oldest first, replacing those previously saved.
+	SaveHistory(ctx context.Context, records []*RunRecord) error
+}
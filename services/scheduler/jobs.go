@@ -0,0 +1,93 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"time"
+)
+
+// JobInfo is information about a job known to the scheduler.
+type JobInfo struct {
+	// Name is the name of the job.
+	Name string
+	// Class is the class of the job.
+	Class string
+	// Periodic is true if the job is periodic.
+	Periodic bool
+	// Pinned is true if the job is pinned.
+	Pinned bool
+	// Timeout is the time after which a run of the job is abandoned, or 0 if runs are not bounded.
+	Timeout time.Duration
+	// Active is true if the job is currently running.
+	Active bool
+	// RunStarted is the time at which the run in progress started.
+	// It is zero if the job is not running.
+	RunStarted time.Time
+	// NextRun is the time at which the job is next scheduled to run.
+	// It is zero if the next runtime of a periodic job has yet to be obtained.
+	NextRun time.Time
+	// LastErr is the error returned by the most recent run of the job, if any.
+	LastErr error
+	// LastRun is the time at which the most recent completed run of the job started.
+	// It is zero if the job has yet to complete a run.
+	LastRun time.Time
+	// LastRunDuration is the duration of the most recent completed run of the job.
+	LastRunDuration time.Duration
+}
+
+// Snapshot is a point-in-time view of the scheduler.
+type Snapshot struct {
+	// Time is the time at which the snapshot was taken.
+	Time time.Time
+	// Jobs are the jobs known to the scheduler, ordered by name.
+	Jobs []JobInfo
+	// DriftStats are the drift statistics for recent runs, keyed by class.
+	DriftStats map[string]*DriftStats
+}
+
+// StopSummary summarises the jobs affected by stopping a scheduler.
+type StopSummary struct {
+	// Cancelled is the number of jobs cancelled without running.
+	Cancelled int
+	// Drained is the number of running jobs that finished within the grace period.
+	Drained int
+	// Abandoned is the number of running jobs yet to finish when the grace period ended.
+	Abandoned int
+}
+
+// State is the state of a job.
+type State int
+
+const (
+	// StateScheduled is the state of a job that is waiting to run.
+	StateScheduled State = iota
+	// StateRunning is the state of a job that is running.
+	StateRunning
+	// StateFinalised is the state of a job that will not run again.
+	StateFinalised
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateScheduled:
+		return "scheduled"
+	case StateRunning:
+		return "running"
+	case StateFinalised:
+		return "finalised"
+	default:
+		return "unknown"
+	}
+}
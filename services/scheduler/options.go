@@ -0,0 +1,224 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// JobOptions are the options for a job.
+type JobOptions struct {
+	// Pinned jobs are not cancelled by sweeping cancellations such as CancelJobs,
+	// only by cancellation of the job by name.
+	Pinned bool
+	// ConfirmCompletion is called after a successful run of the job, and the
+	// run is only considered successful if it returns nil.
+	ConfirmCompletion func(ctx context.Context) error
+	// SnapshotData copies the job data at the start of each run, so that the
+	// run can be replayed with the data as it was.
+	SnapshotData func(data interface{}) interface{}
+	// LeaderCheck is called immediately before each run of the job, and the
+	// run is skipped unless it returns true.
+	LeaderCheck func(ctx context.Context) (bool, error)
+	// TriggerCoalesce is the window within which triggers of the job are
+	// coalesced in to a single run.  0 runs the job on each trigger.
+	TriggerCoalesce time.Duration
+	// Finalizer is called once when the job is finalised, however it ends.
+	Finalizer func()
+	// CancelRunning cancels the context of a running instance of the job when
+	// the job is cancelled, rather than leaving the run to complete.
+	CancelRunning bool
+	// Supersede replaces an existing job of the same name when scheduling,
+	// rather than failing with ErrJobAlreadyExists.
+	Supersede bool
+	// Deadline is the time after which a one-off job whose runtime has passed is
+	// no longer run.  The zero time has no deadline.
+	Deadline time.Time
+	// Timeout is the time after which a run of the job is abandoned.  0 does
+	// not bound runs.
+	Timeout time.Duration
+	// MaxLifetime is the time after scheduling at which a periodic job stops.
+	// 0 does not limit the lifetime of the job.
+	MaxLifetime time.Duration
+	// RunOnResume runs a periodic job once immediately on resume if it missed a run
+	// whilst the scheduler was suspended.
+	RunOnResume bool
+}
+
+// JobOption is the interface for job options.
+type JobOption interface {
+	apply(*JobOptions)
+}
+
+type jobOptionFunc func(*JobOptions)
+
+func (f jobOptionFunc) apply(o *JobOptions) {
+	f(o)
+}
+
+// WithPinned sets if the job is pinned.
+// Pinned jobs are not cancelled by sweeping cancellations such as CancelJobs,
+// only by cancellation of the job by name.
+func WithPinned(pinned bool) JobOption {
+	return jobOptionFunc(func(o *JobOptions) {
+		o.Pinned = pinned
+	})
+}
+
+// WithConfirmCompletion sets a function to confirm the effects of the job.
+// The function is called after each run of the job that returns without error,
+// and before the run is recorded.  If the function returns an error the run is
+// recorded as failed, with an error wrapping ErrCompletionNotConfirmed.
+func WithConfirmCompletion(confirm func(ctx context.Context) error) JobOption {
+	return jobOptionFunc(func(o *JobOptions) {
+		o.ConfirmCompletion = confirm
+	})
+}
+
+// WithSnapshotData sets a function to copy the job's data at the start of each run.
+// The copy is held so that the run can be replayed with ReplayLastRun, even if the
+// job has since altered its data.  Without this the data itself is held, which is
+// sufficient for jobs that do not alter their data.
+func WithSnapshotData(snapshot func(data interface{}) interface{}) JobOption {
+	return jobOptionFunc(func(o *JobOptions) {
+		o.SnapshotData = snapshot
+	})
+}
+
+// WithLeaderCheck sets a function to confirm that this instance should run the job.
+// The function is called immediately before each run of the job, rather than when the
+// job is scheduled, so leadership can change while the job is scheduled.  If the function
+// returns false, or an error, the run is skipped without error; for periodic jobs the
+// loop continues to the next run.  This allows a passive instance to schedule jobs but
+// only run them once it becomes the leader.
+func WithLeaderCheck(check func(ctx context.Context) (bool, error)) JobOption {
+	return jobOptionFunc(func(o *JobOptions) {
+		o.LeaderCheck = check
+	})
+}
+
+// WithTriggerCoalesce sets a window within which triggers of the job are coalesced.
+// The first trigger, through RunJob or RunJobIfExists, opens the window and further
+// triggers within it are absorbed; the job then runs once when the window closes.  The
+// window is not extended by later triggers, so a steady stream of triggers results in
+// a run every window rather than none at all.  If the job is still running when the
+// window closes the run is deferred by a further window, rather than being lost.
+//
+// This delays every triggered run by the window, including the first after a quiet
+// period.  It differs from a minimum gap between runs, which would run the first trigger
+// immediately and only hold back those that follow; coalescing is intended for jobs that
+// are triggered in bursts, where only the final state after the burst is of interest.
+// Timer-triggered runs are not affected.
+func WithTriggerCoalesce(window time.Duration) JobOption {
+	return jobOptionFunc(func(o *JobOptions) {
+		o.TriggerCoalesce = window
+	})
+}
+
+// WithFinalizer sets a function to release the job's resources when the job is finalised.
+// The function is called exactly once, whether the job ends by completing, by being
+// cancelled, by its parent context being done or, for periodic jobs, by running out of
+// instances.  It is called after the job's final run, including a run that panicked.
+// A panic in the function itself is recovered and logged.
+func WithFinalizer(finalizer func()) JobOption {
+	return jobOptionFunc(func(o *JobOptions) {
+		o.Finalizer = finalizer
+	})
+}
+
+// WithCancelRunning sets if a running instance of the job is cancelled along with the job.
+// Each job runs with a context that is cancelled when the job is cancelled, with a cause
+// that can be obtained with context.Cause: ErrJobCancelledByUser if the job is cancelled
+// by name, prefix or class, ErrSchedulerShutdown if the scheduler is stopped, and
+// ErrSuperseded if the job is replaced.  Without this option the job's runs are given
+// the context with which it was scheduled, so a run in progress is left to complete.
+func WithCancelRunning(cancelRunning bool) JobOption {
+	return jobOptionFunc(func(o *JobOptions) {
+		o.CancelRunning = cancelRunning
+	})
+}
+
+// WithSupersede sets if the job replaces an existing job of the same name.
+// The existing job is cancelled, with a cause of ErrSuperseded, and the new job is
+// scheduled in its place.  Without this option scheduling a job with the name of an
+// existing job fails with ErrJobAlreadyExists.
+func WithSupersede(supersede bool) JobOption {
+	return jobOptionFunc(func(o *JobOptions) {
+		o.Supersede = supersede
+	})
+}
+
+// WithDeadline sets a deadline for a one-off job.
+// If the job's timer fires after the deadline, as happens if the process is paused
+// past the job's runtime, the job is finalised without running and counted as expired.
+// If the timer fires before the deadline the job runs once as usual, even if its
+// runtime has already passed; this includes a runtime that has passed when the job is
+// scheduled.  The deadline is compared with the wall clock, so time for which the
+// machine was suspended counts towards it.  Runs triggered by RunJob are not affected.
+// This is for jobs that are meaningless if run late, such as those for a specific slot.
+func WithDeadline(deadline time.Time) JobOption {
+	return jobOptionFunc(func(o *JobOptions) {
+		o.Deadline = deadline
+	})
+}
+
+// WithTimeout sets a timeout for each run of the job.
+// The context passed to the job is cancelled when the timeout expires, and the run is
+// considered finished with an error wrapping ErrJobTimedOut whether or not the job
+// function has returned: the job is no longer active, a one-off job is finalised and a
+// periodic job continues to its next run.  The job function is left to return in its
+// own time, and its result is discarded, so it should respect its context.  The timeout
+// covers the completion check set by WithConfirmCompletion, if present.
+func WithTimeout(timeout time.Duration) JobOption {
+	return jobOptionFunc(func(o *JobOptions) {
+		o.Timeout = timeout
+	})
+}
+
+// WithMaxLifetime sets the maximum lifetime of a periodic job.
+// Once the time since the job was scheduled exceeds the lifetime the job stops and
+// is finalised, as if its runtime function had no more instances, and is counted as
+// cancelled with a reason of "lifetime".  The lifetime is checked before each instance
+// is scheduled, and an instance whose runtime falls after the end of the lifetime is
+// not run.  A run in progress when the lifetime ends is left to complete.
+// This is for temporary jobs, such as a monitoring sweep that runs for a fixed time.
+func WithMaxLifetime(lifetime time.Duration) JobOption {
+	return jobOptionFunc(func(o *JobOptions) {
+		o.MaxLifetime = lifetime
+	})
+}
+
+// WithRunOnResume sets if a periodic job catches up on a missed run when the scheduler resumes.
+// Whilst the scheduler is suspended or quiesced timer-triggered runs of periodic jobs are missed.  With
+// this option a job that missed one or more runs runs once as soon as the scheduler is
+// resumed, then continues on its usual schedule.  Without it the missed runs are skipped and
+// the job waits for its next runtime, which can leave a gap after a maintenance window.
+func WithRunOnResume(runOnResume bool) JobOption {
+	return jobOptionFunc(func(o *JobOptions) {
+		o.RunOnResume = runOnResume
+	})
+}
+
+// ParseJobOptions parses job options.
+func ParseJobOptions(opts ...JobOption) *JobOptions {
+	options := &JobOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(options)
+		}
+	}
+
+	return options
+}
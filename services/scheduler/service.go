@@ -16,11 +16,13 @@ package scheduler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 )
 
 // JobFunc is the type for jobs.
-type JobFunc func(context.Context, interface{})
+// An error returned by the job is recorded, and can be obtained with LastError.
+type JobFunc func(context.Context, interface{}) error
 
 // RuntimeFunc is the type of a function that generates the next runtime.
 type RuntimeFunc func(context.Context, interface{}) (time.Time, error)
@@ -46,6 +48,33 @@ var ErrNoJobName = errors.New("no job name")
 // ErrNoJobFunc is returned when an attempt is made to run a nil job.
 var ErrNoJobFunc = errors.New("no job function")
 
+// ErrJobPanicked is returned as the error of a job run that panicked.
+var ErrJobPanicked = errors.New("job panicked")
+
+// ErrCompletionNotConfirmed is returned as the error of a job run whose completion was not confirmed.
+var ErrCompletionNotConfirmed = errors.New("completion not confirmed")
+
+// ErrNoPriorRun is returned when an attempt is made to replay a job that has yet to run.
+var ErrNoPriorRun = errors.New("no prior run")
+
+// ErrSchedulerStopped is returned when an attempt is made to schedule or run a job after the scheduler has stopped.
+var ErrSchedulerStopped = errors.New("scheduler stopped")
+
+// ErrJobTimedOut is returned as the error of a job run that did not finish within the job's timeout.
+var ErrJobTimedOut = errors.New("job timed out")
+
+// ErrJobCancelledByUser is the cause of the cancellation of a job's context when the job is cancelled.
+var ErrJobCancelledByUser = errors.New("job cancelled by user")
+
+// ErrSchedulerShutdown is the cause of the cancellation of a job's context when the scheduler is stopped.
+var ErrSchedulerShutdown = fmt.Errorf("%w: shut down", ErrSchedulerStopped)
+
+// ErrSuperseded is the cause of the cancellation of a job's context when the job is replaced by another of the same name.
+var ErrSuperseded = errors.New("job superseded")
+
+// ErrJobNotPeriodic is returned when the scheduler is asked to act upon a periodic job, but the job is not periodic.
+var ErrJobNotPeriodic = errors.New("job not periodic")
+
 // ErrNoRuntimeFunc is returned when an attempt is made to run a periodic job without a runtime function.
 var ErrNoRuntimeFunc = errors.New("no runtime function")
 
@@ -55,13 +84,13 @@ type Service interface {
 	// This function returns two cancel funcs.  If the first is triggered the job will not run.  If the second is triggered the job
 	// runs immediately.
 	// Note that if the parent context is cancelled the job wil not run.
-	ScheduleJob(ctx context.Context, class string, name string, runtime time.Time, job JobFunc, data interface{}) error
+	ScheduleJob(ctx context.Context, class string, name string, runtime time.Time, job JobFunc, data interface{}, opts ...JobOption) error
 
 	// SchedulePeriodicJob schedules a job to run in a loop.
 	// The loop starts by calling runtimeFunc, which sets the time for the first run.
 	// Once the time as specified by runtimeFunc is met, jobFunc is called.
 	// Once jobFunc returns, go back to the beginning of the loop.
-	SchedulePeriodicJob(ctx context.Context, class string, name string, runtime RuntimeFunc, runtimeData interface{}, job JobFunc, jobData interface{}) error
+	SchedulePeriodicJob(ctx context.Context, class string, name string, runtime RuntimeFunc, runtimeData interface{}, job JobFunc, jobData interface{}, opts ...JobOption) error
 
 	// CancelJob cancels a known job.
 	// If this is a period job then all future instances are cancelled.
@@ -71,12 +100,13 @@ type Service interface {
 	// If this is a period job then all future instances are cancelled.
 	CancelJobIfExists(ctx context.Context, name string)
 
-	// CancelJobs cancels all jobs with the given prefix.
+	// CancelJobs cancels all jobs with the given prefix, other than pinned jobs.
 	// If the prefix matches a period job then all future instances are cancelled.
 	CancelJobs(ctx context.Context, prefix string)
 
 	// RunJob runs a known job.
 	// If this is a period job then the next instance will be scheduled.
+	// If the job coalesces triggers the run starts at the end of the coalescing window.
 	RunJob(ctx context.Context, name string) error
 
 	// JobExists returns true if a job exists.
@@ -89,3 +119,141 @@ type Service interface {
 	// ListJobs returns the names of all jobs.
 	ListJobs(ctx context.Context) []string
 }
+
+// DriftStatsProvider provides statistics about how accurately jobs start at their scheduled time.
+type DriftStatsProvider interface {
+	// DriftStats returns drift statistics for recent timer-triggered runs, keyed by class.
+	DriftStats(ctx context.Context) map[string]*DriftStats
+}
+
+// LastErrorProvider provides the errors returned by jobs.
+type LastErrorProvider interface {
+	// LastError returns the error returned by the most recent run of the named job,
+	// or nil if the job succeeded or has yet to run.
+	// It returns ErrNoSuchJob if there is no information about the job.
+	LastError(ctx context.Context, name string) (error, error)
+}
+
+// CompletedRunProvider reports if jobs have completed a successful run.
+type CompletedRunProvider interface {
+	// HasCompletedRun returns true once the named job has completed its first successful run,
+	// and remains true thereafter.
+	// It returns ErrNoSuchJob if there is no information about the job.
+	HasCompletedRun(ctx context.Context, name string) (bool, error)
+}
+
+// JobInfoProvider provides structured information about jobs.
+type JobInfoProvider interface {
+	// Jobs returns information about all jobs, ordered by name.
+	Jobs(ctx context.Context) []JobInfo
+
+	// Snapshot returns a point-in-time view of the scheduler.
+	Snapshot(ctx context.Context) *Snapshot
+}
+
+// JobsMarshaler provides the jobs known to schedulers as JSON.
+type JobsMarshaler interface {
+	// MarshalJobs returns the jobs known to the scheduler as JSON, ordered by name.
+	MarshalJobs(ctx context.Context) ([]byte, error)
+}
+
+// IdleProvider reports when the scheduler is idle.
+type IdleProvider interface {
+	// IsIdle returns true if no jobs are active and none are due to run within the given duration.
+	IsIdle(ctx context.Context, within time.Duration) bool
+}
+
+// JobStateProvider provides the state of jobs.
+type JobStateProvider interface {
+	// JobState returns the state of the named job.
+	// It returns ErrNoSuchJob if there is no information about the job.
+	JobState(ctx context.Context, name string) (State, error)
+}
+
+// TimeoutScheduler schedules jobs whose runs are bounded by a timeout.
+type TimeoutScheduler interface {
+	// ScheduleJobWithTimeout schedules a one-off job, as ScheduleJob, with each run bounded by the timeout.
+	ScheduleJobWithTimeout(ctx context.Context, class string, name string, runtime time.Time, timeout time.Duration, job JobFunc, data interface{}, opts ...JobOption) error
+
+	// SchedulePeriodicJobWithTimeout schedules a periodic job, as SchedulePeriodicJob, with each run bounded by the timeout.
+	SchedulePeriodicJobWithTimeout(ctx context.Context, class string, name string, runtime RuntimeFunc, runtimeData interface{}, timeout time.Duration, job JobFunc, jobData interface{}, opts ...JobOption) error
+}
+
+// LongestRunningJobProvider reports the longest-running active job.
+type LongestRunningJobProvider interface {
+	// LongestRunningJob returns the name of the active job that has been running
+	// longest, and the time for which it has been running.
+	// It returns false if no job is active.
+	LongestRunningJob(ctx context.Context) (string, time.Duration, bool)
+}
+
+// Suspender suspends and resumes schedulers.
+type Suspender interface {
+	// Suspend holds timer-triggered runs of periodic jobs until Resume is called.
+	// Runs triggered by RunJob, and one-off jobs, are not affected.
+	Suspend(ctx context.Context)
+
+	// QuiesceUntil suspends the scheduler, as Suspend, until the given time at which it resumes.
+	QuiesceUntil(ctx context.Context, until time.Time)
+
+	// Resume resumes a suspended scheduler.
+	Resume(ctx context.Context)
+}
+
+// JobRenamer renames jobs.
+type JobRenamer interface {
+	// RenameJob renames a job, preserving its schedule and state.
+	// It returns ErrNoSuchJob if oldName is not known, and ErrJobAlreadyExists if newName is in use.
+	RenameJob(ctx context.Context, oldName string, newName string) error
+}
+
+// NextRunAdvancer advances the next run of periodic jobs.
+type NextRunAdvancer interface {
+	// AdvanceNextRun advances the next run of the named periodic job to the given time,
+	// if that is earlier than its currently scheduled run.  The job then continues on
+	// its usual schedule.
+	// It returns ErrNoSuchJob if the job is not known, and ErrJobNotPeriodic if it is not periodic.
+	AdvanceNextRun(ctx context.Context, name string, to time.Time) error
+}
+
+// Replayer replays previous runs of jobs.
+type Replayer interface {
+	// ReplayLastRun runs the named job immediately with the data used by its most recent run,
+	// independent of its schedule, and returns the error returned by the job.
+	// It returns ErrNoSuchJob if the job is not known, and ErrNoPriorRun if it has yet to run.
+	ReplayLastRun(ctx context.Context, name string) error
+}
+
+// SlotJobScheduler schedules jobs for each slot of an epoch.
+type SlotJobScheduler interface {
+	// ScheduleSlotJobsForEpoch schedules a one-off job for each slot of the given epoch,
+	// to run at the start of the slot.
+	// Jobs are named <class>-slot-<slot>, and are all scheduled or none are.
+	// It returns the names of the scheduled jobs.
+	ScheduleSlotJobsForEpoch(ctx context.Context,
+		class string,
+		epoch uint64,
+		genesisTime time.Time,
+		slotDuration time.Duration,
+		jobFuncFor func(slot uint64) JobFunc,
+	) (
+		[]string,
+		error,
+	)
+}
+
+// Stopper stops schedulers.
+type Stopper interface {
+	// Stop cancels all jobs that are not running, including pinned jobs, and prevents
+	// further jobs from being scheduled or run.  It then waits for running jobs to
+	// finish, up to the grace period or until the context is done.
+	// It returns a summary of the jobs cancelled, drained and abandoned.
+	Stop(ctx context.Context, grace time.Duration) *StopSummary
+}
+
+// ClassCanceller cancels jobs by class.
+type ClassCanceller interface {
+	// CancelJobsInClass cancels all jobs in the given class, other than pinned jobs.
+	// If the class contains periodic jobs then all future instances are cancelled.
+	CancelJobsInClass(ctx context.Context, class string)
+}
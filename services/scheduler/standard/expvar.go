@@ -0,0 +1,76 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"expvar"
+	"fmt"
+)
+
+// expvarStats are the scheduler statistics published over expvar.
+type expvarStats struct {
+	// Jobs is the number of scheduled jobs.
+	Jobs int `json:"jobs"`
+	// Active is the number of scheduled jobs that are running.
+	Active int `json:"active"`
+	// Running is the number of job functions running, including one-off jobs.
+	Running int64 `json:"running"`
+	// Classes are the statistics for each class of job.
+	Classes map[string]*expvarClassStats `json:"classes"`
+}
+
+// expvarClassStats are the statistics for a class of job published over expvar.
+type expvarClassStats struct {
+	Jobs   int `json:"jobs"`
+	Active int `json:"active"`
+}
+
+// publishExpvar publishes the scheduler statistics over expvar with the given name.
+func (s *Service) publishExpvar(name string) error {
+	// expvar panics if a name is published twice, so check first.
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar %s already published", name)
+	}
+	expvar.Publish(name, expvar.Func(func() any {
+		return s.expvarStats()
+	}))
+
+	return nil
+}
+
+// expvarStats computes the scheduler statistics.
+func (s *Service) expvarStats() *expvarStats {
+	stats := &expvarStats{
+		Running: s.running.Load(),
+		Classes: make(map[string]*expvarClassStats),
+	}
+
+	s.jobsMutex.RLock()
+	defer s.jobsMutex.RUnlock()
+	stats.Jobs = len(s.jobs)
+	for _, job := range s.jobs {
+		class, exists := stats.Classes[job.class]
+		if !exists {
+			class = &expvarClassStats{}
+			stats.Classes[job.class] = class
+		}
+		class.Jobs++
+		if job.active.Load() {
+			stats.Active++
+			class.Active++
+		}
+	}
+
+	return stats
+}
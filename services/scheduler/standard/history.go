@@ -0,0 +1,176 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+// maxHistoryJobs is the maximum number of jobs for which run history is held.
+// One-off jobs commonly have unique names, so without this the history would grow
+// without bound.
+const maxHistoryJobs = 1024
+
+// runHistory holds the most recent run records for each job.
+type runHistory struct {
+	mu      sync.RWMutex
+	size    int
+	records map[string][]*scheduler.RunRecord
+	// order holds job names, most recently run first.
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+// newRunHistory creates a run history holding up to size records per job.
+func newRunHistory(size int) *runHistory {
+	return &runHistory{
+		size:     size,
+		records:  make(map[string][]*scheduler.RunRecord),
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+// add adds a record to the history, dropping the oldest record for the job if required.
+func (h *runHistory) add(record *scheduler.RunRecord) {
+	if h.size == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records := append(h.records[record.Name], record)
+	if len(records) > h.size {
+		records = records[len(records)-h.size:]
+	}
+	h.records[record.Name] = records
+
+	if element, exists := h.elements[record.Name]; exists {
+		h.order.MoveToFront(element)
+	} else {
+		h.elements[record.Name] = h.order.PushFront(record.Name)
+	}
+	for h.order.Len() > maxHistoryJobs {
+		oldest := h.order.Back()
+		h.order.Remove(oldest)
+		delete(h.elements, oldest.Value.(string))
+		delete(h.records, oldest.Value.(string))
+	}
+}
+
+// snapshot returns the records in the history, with the records for the least
+// recently run jobs first.  Adding the records in order to an empty history
+// recreates this history.
+func (h *runHistory) snapshot() []*scheduler.RunRecord {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	res := make([]*scheduler.RunRecord, 0)
+	for element := h.order.Back(); element != nil; element = element.Prev() {
+		res = append(res, h.records[element.Value.(string)]...)
+	}
+
+	return res
+}
+
+// loadHistory adds the records held in the history store to the run history.
+func (s *Service) loadHistory(ctx context.Context) error {
+	records, err := s.historyStore.LoadHistory(ctx)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		s.history.add(record)
+	}
+	log.Trace().Int("records", len(records)).Msg("Loaded run history")
+
+	return nil
+}
+
+// markHistoryChanged notes that the run history has changed and should be persisted.
+func (s *Service) markHistoryChanged() {
+	if s.historyStore == nil {
+		return
+	}
+	select {
+	case s.historyChanged <- struct{}{}:
+	default:
+		// A save is already pending, and will include this change.
+	}
+}
+
+// persistHistory saves the run history to the history store whenever it changes,
+// until the context is done.
+func (s *Service) persistHistory(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.historyChanged:
+			s.saveHistory(ctx)
+		}
+	}
+}
+
+// saveHistory saves the run history to the history store.
+func (s *Service) saveHistory(ctx context.Context) {
+	s.historySaveMu.Lock()
+	defer s.historySaveMu.Unlock()
+
+	if err := s.historyStore.SaveHistory(ctx, s.history.snapshot()); err != nil {
+		log.Warn().Err(err).Msg("Failed to save run history")
+	}
+}
+
+// DriftStats returns drift statistics for recent timer-triggered runs, keyed by class.
+// Runs triggered by signal are excluded, as they are not expected to start on schedule.
+func (s *Service) DriftStats(_ context.Context) map[string]*scheduler.DriftStats {
+	s.history.mu.RLock()
+	defer s.history.mu.RUnlock()
+
+	totals := make(map[string]int64)
+	res := make(map[string]*scheduler.DriftStats)
+	for _, records := range s.history.records {
+		for _, record := range records {
+			if record.Trigger != "timer" {
+				continue
+			}
+			drift := record.Drift()
+			if drift < 0 {
+				drift = -drift
+			}
+			stats, exists := res[record.Class]
+			if !exists {
+				stats = &scheduler.DriftStats{}
+				res[record.Class] = stats
+			}
+			stats.Samples++
+			totals[record.Class] += int64(drift)
+			if drift > stats.MaxAbsDrift {
+				stats.MaxAbsDrift = drift
+			}
+		}
+	}
+	for class, stats := range res {
+		stats.MeanAbsDrift = time.Duration(totals[class] / int64(stats.Samples))
+	}
+
+	return res
+}
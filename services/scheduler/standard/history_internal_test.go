@@ -0,0 +1,150 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+func TestDriftStats(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx, WithLogLevel(zerolog.Disabled), WithRunHistorySize(3))
+	require.NoError(t, err)
+
+	base := time.Now()
+	addRun := func(name string, class string, trigger string, drift time.Duration) {
+		s.history.add(&scheduler.RunRecord{
+			Name:      name,
+			Class:     class,
+			Trigger:   trigger,
+			Scheduled: base,
+			Started:   base.Add(drift),
+			Finished:  base.Add(drift + time.Millisecond),
+		})
+	}
+
+	// Class A: drifts of 10ms, -30ms and 20ms across two jobs.
+	addRun("a1", "A", "timer", 10*time.Millisecond)
+	addRun("a1", "A", "timer", -30*time.Millisecond)
+	addRun("a2", "A", "timer", 20*time.Millisecond)
+	// Class B: one timer run, and one signal run that should be ignored.
+	addRun("b1", "B", "timer", 5*time.Millisecond)
+	addRun("b1", "B", "signal", time.Hour)
+
+	stats := s.DriftStats(ctx)
+	require.Len(t, stats, 2)
+	require.Equal(t, &scheduler.DriftStats{
+		Samples:      3,
+		MeanAbsDrift: 20 * time.Millisecond,
+		MaxAbsDrift:  30 * time.Millisecond,
+	}, stats["A"])
+	require.Equal(t, &scheduler.DriftStats{
+		Samples:      1,
+		MeanAbsDrift: 5 * time.Millisecond,
+		MaxAbsDrift:  5 * time.Millisecond,
+	}, stats["B"])
+
+	// Adding further runs should push the oldest out of the sample window,
+	// leaving a1 with -30ms, 40ms and 40ms.
+	addRun("a1", "A", "timer", 40*time.Millisecond)
+	addRun("a1", "A", "timer", 40*time.Millisecond)
+	stats = s.DriftStats(ctx)
+	require.Equal(t, &scheduler.DriftStats{
+		Samples:      4,
+		MeanAbsDrift: 32500 * time.Microsecond,
+		MaxAbsDrift:  40 * time.Millisecond,
+	}, stats["A"])
+}
+
+func TestRunHistoryJobLimit(t *testing.T) {
+	h := newRunHistory(1)
+	for i := 0; i < maxHistoryJobs+10; i++ {
+		h.add(&scheduler.RunRecord{Name: fmt.Sprintf("job %d", i)})
+	}
+	require.Len(t, h.records, maxHistoryJobs)
+	require.NotContains(t, h.records, "job 0")
+	require.Contains(t, h.records, fmt.Sprintf("job %d", maxHistoryJobs+9))
+}
+
+func TestHistoryStoreRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	store, err := NewFileHistoryStore(path, 2)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := New(ctx, WithLogLevel(zerolog.Disabled), WithHistoryStore(store))
+	require.NoError(t, err)
+
+	runs := 0
+	runFunc := func(_ context.Context, _ interface{}) error {
+		runs++
+		if runs == 3 {
+			return errors.New("failed")
+		}
+		return nil
+	}
+	runtimeFunc := func(_ context.Context, _ interface{}) (time.Time, error) {
+		return time.Now().Add(time.Hour), nil
+	}
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Periodic job", runtimeFunc, nil, runFunc, nil))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.RunJob(ctx, "Periodic job"))
+		time.Sleep(50 * time.Millisecond)
+	}
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "One-off job", time.Now(), func(_ context.Context, _ interface{}) error {
+		return nil
+	}, nil))
+	time.Sleep(50 * time.Millisecond)
+
+	// Simulate a restart.
+	s.Stop(ctx, time.Second)
+	cancel()
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	s, err = New(ctx, WithLogLevel(zerolog.Disabled), WithHistoryStore(store))
+	require.NoError(t, err)
+
+	// Only the two most recent runs of the periodic job are kept.
+	require.Len(t, s.history.records["Periodic job"], 2)
+	require.NoError(t, s.history.records["Periodic job"][0].Err)
+	require.EqualError(t, s.history.records["Periodic job"][1].Err, "failed")
+	require.Len(t, s.history.records["One-off job"], 1)
+	require.Equal(t, "Test", s.history.records["One-off job"][0].Class)
+
+	// The history is available through the service.
+	completed, err := s.HasCompletedRun(ctx, "One-off job")
+	require.NoError(t, err)
+	require.True(t, completed)
+	stats := s.DriftStats(ctx)
+	require.Equal(t, 1, stats["Test"].Samples)
+}
+
+func TestFileHistoryStoreMissing(t *testing.T) {
+	store, err := NewFileHistoryStore(filepath.Join(t.TempDir(), "history.json"), 2)
+	require.NoError(t, err)
+
+	records, err := store.LoadHistory(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, records)
+}
@@ -0,0 +1,166 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+// FileHistoryStore is a history store that holds run history in a JSON file.
+type FileHistoryStore struct {
+	path          string
+	recordsPerJob int
+}
+
+// fileRunRecord is the stored form of a run record.
+type fileRunRecord struct {
+	Name      string    `json:"name"`
+	Class     string    `json:"class,omitempty"`
+	Trigger   string    `json:"trigger"`
+	Scheduled time.Time `json:"scheduled"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+	Err       string    `json:"error,omitempty"`
+}
+
+// NewFileHistoryStore creates a history store that holds up to recordsPerJob of the
+// most recent records for each job in the file at the given path.
+func NewFileHistoryStore(path string, recordsPerJob int) (*FileHistoryStore, error) {
+	if path == "" {
+		return nil, errors.New("no path specified")
+	}
+	if recordsPerJob <= 0 {
+		return nil, errors.New("records per job must be positive")
+	}
+
+	return &FileHistoryStore{
+		path:          path,
+		recordsPerJob: recordsPerJob,
+	}, nil
+}
+
+// LoadHistory loads run records, oldest first.
+// If the file does not exist no records are returned.
+func (f *FileHistoryStore) LoadHistory(_ context.Context) ([]*scheduler.RunRecord, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to read history file")
+	}
+
+	stored := make([]*fileRunRecord, 0)
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, errors.Wrap(err, "failed to parse history file")
+	}
+
+	records := make([]*scheduler.RunRecord, 0, len(stored))
+	for _, record := range f.bound(stored) {
+		runRecord := &scheduler.RunRecord{
+			Name:      record.Name,
+			Class:     record.Class,
+			Trigger:   record.Trigger,
+			Scheduled: record.Scheduled,
+			Started:   record.Started,
+			Finished:  record.Finished,
+		}
+		if record.Err != "" {
+			runRecord.Err = errors.New(record.Err)
+		}
+		records = append(records, runRecord)
+	}
+
+	return records, nil
+}
+
+// SaveHistory saves run records, oldest first, replacing those previously saved.
+// The file is replaced atomically, so a failed write leaves the previous history intact.
+func (f *FileHistoryStore) SaveHistory(_ context.Context, records []*scheduler.RunRecord) error {
+	stored := make([]*fileRunRecord, 0, len(records))
+	for _, record := range records {
+		storedRecord := &fileRunRecord{
+			Name:      record.Name,
+			Class:     record.Class,
+			Trigger:   record.Trigger,
+			Scheduled: record.Scheduled,
+			Started:   record.Started,
+			Finished:  record.Finished,
+		}
+		if record.Err != nil {
+			storedRecord.Err = record.Err.Error()
+		}
+		stored = append(stored, storedRecord)
+	}
+
+	data, err := json.Marshal(f.bound(stored))
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal history")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary history file")
+	}
+	defer func() {
+		// No-op once the file has been renamed.
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "failed to write temporary history file")
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "failed to sync temporary history file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to close temporary history file")
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return errors.Wrap(err, "failed to replace history file")
+	}
+
+	return nil
+}
+
+// bound returns the most recent records for each job, retaining their order.
+func (f *FileHistoryStore) bound(records []*fileRunRecord) []*fileRunRecord {
+	counts := make(map[string]int)
+	keep := make([]bool, len(records))
+	kept := 0
+	for i := len(records) - 1; i >= 0; i-- {
+		if counts[records[i].Name] < f.recordsPerJob {
+			counts[records[i].Name]++
+			keep[i] = true
+			kept++
+		}
+	}
+
+	res := make([]*fileRunRecord, 0, kept)
+	for i, record := range records {
+		if keep[i] {
+			res = append(res, record)
+		}
+	}
+
+	return res
+}
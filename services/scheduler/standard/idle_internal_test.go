@@ -0,0 +1,111 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsIdle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The fake clock is ahead of real time, so that no job fires during the test.
+	clock := time.Now().Add(time.Hour)
+	noop := func(_ context.Context, _ interface{}) error { return nil }
+
+	tests := []struct {
+		name     string
+		runtimes []time.Duration
+		within   time.Duration
+		idle     bool
+	}{
+		{
+			name:   "NoJobs",
+			within: 10 * time.Minute,
+			idle:   true,
+		},
+		{
+			name:     "JustInsideWindow",
+			runtimes: []time.Duration{10*time.Minute - time.Second},
+			within:   10 * time.Minute,
+			idle:     false,
+		},
+		{
+			name:     "JustOutsideWindow",
+			runtimes: []time.Duration{10*time.Minute + time.Second},
+			within:   10 * time.Minute,
+			idle:     true,
+		},
+		{
+			name:     "Mixed",
+			runtimes: []time.Duration{time.Hour, 10*time.Minute - time.Second},
+			within:   10 * time.Minute,
+			idle:     false,
+		},
+		{
+			name:     "Overdue",
+			runtimes: []time.Duration{-time.Minute},
+			within:   0,
+			idle:     false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := New(ctx, WithLogLevel(zerolog.Disabled))
+			require.NoError(t, err)
+			s.now = func() time.Time { return clock }
+
+			for i, runtime := range test.runtimes {
+				require.NoError(t, s.ScheduleJob(ctx, "Test", string(rune('a'+i)), clock.Add(runtime), noop, nil))
+			}
+			require.Equal(t, test.idle, s.IsIdle(ctx, test.within))
+		})
+	}
+}
+
+func TestIsIdleActiveJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := New(ctx, WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+	clock := time.Now().Add(time.Hour)
+	s.now = func() time.Time { return clock }
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Long job", clock.Add(time.Hour), func(_ context.Context, _ interface{}) error {
+		close(started)
+		<-release
+		return nil
+	}, nil))
+	require.True(t, s.IsIdle(ctx, time.Minute))
+
+	// Run the job now; it is not due but is active, so the scheduler is not idle.
+	require.NoError(t, s.RunJob(ctx, "Long job"))
+	<-started
+	require.False(t, s.IsIdle(ctx, time.Minute))
+
+	close(release)
+	require.Eventually(t, func() bool {
+		return s.IsIdle(ctx, time.Minute)
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,64 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"time"
+
+	"github.com/sasha-s/go-deadlock"
+)
+
+// instrumentedRWMutex is a read/write mutex that can record the time spent
+// waiting for and holding its locks.
+type instrumentedRWMutex struct {
+	deadlock.RWMutex
+	// instrumented is set if lock metrics are to be recorded.
+	instrumented bool
+	// lockedAt is the time at which the write lock was obtained; it requires the write lock.
+	lockedAt time.Time
+}
+
+// Lock obtains the write lock.
+func (m *instrumentedRWMutex) Lock() {
+	if !m.instrumented {
+		m.RWMutex.Lock()
+		return
+	}
+
+	started := time.Now()
+	m.RWMutex.Lock()
+	m.lockedAt = time.Now()
+	lockWaited("write", m.lockedAt.Sub(started))
+}
+
+// Unlock releases the write lock.
+func (m *instrumentedRWMutex) Unlock() {
+	if m.instrumented {
+		lockHeld("write", time.Since(m.lockedAt))
+	}
+	m.RWMutex.Unlock()
+}
+
+// RLock obtains a read lock.
+// Read locks can be held concurrently so only the time spent waiting is recorded.
+func (m *instrumentedRWMutex) RLock() {
+	if !m.instrumented {
+		m.RWMutex.RLock()
+		return
+	}
+
+	started := time.Now()
+	m.RWMutex.RLock()
+	lockWaited("read", time.Since(started))
+}
@@ -0,0 +1,94 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// jobsJSON is the JSON representation of the scheduler's jobs.
+type jobsJSON struct {
+	// Time is the time at which the jobs were obtained.
+	Time string `json:"time"`
+	// Jobs are the jobs, ordered by name.
+	Jobs []*jobJSON `json:"jobs"`
+}
+
+// jobJSON is the JSON representation of a job.
+type jobJSON struct {
+	Name     string `json:"name"`
+	Class    string `json:"class"`
+	Periodic bool   `json:"periodic"`
+	Pinned   bool   `json:"pinned"`
+	// Timeout is omitted if runs of the job are not bounded.
+	Timeout string `json:"timeout,omitempty"`
+	Active  bool   `json:"active"`
+	// Runtime is the time for which the run in progress has been running or, if
+	// the job is not running, the duration of its most recent completed run.  It
+	// is omitted if the job is not running and has yet to complete a run.
+	Runtime string `json:"runtime,omitempty"`
+	// Tags are always empty, as the scheduler does not tag jobs; they are present
+	// so that consumers can rely on the field.
+	Tags []string `json:"tags"`
+	// NextRun is omitted if the next runtime of a periodic job has yet to be obtained.
+	NextRun   string `json:"next_run,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+	// LastRun and LastRunDuration are omitted if the job has yet to complete a run.
+	LastRun         string `json:"last_run,omitempty"`
+	LastRunDuration string `json:"last_run_duration,omitempty"`
+}
+
+// MarshalJobs returns the jobs known to the scheduler as JSON, for external monitoring.
+// Jobs are ordered by name, and times are in RFC 3339 format.
+func (s *Service) MarshalJobs(ctx context.Context) ([]byte, error) {
+	snapshot := s.Snapshot(ctx)
+
+	res := &jobsJSON{
+		Time: snapshot.Time.Format(time.RFC3339Nano),
+		Jobs: make([]*jobJSON, len(snapshot.Jobs)),
+	}
+	for i, info := range snapshot.Jobs {
+		res.Jobs[i] = &jobJSON{
+			Name:     info.Name,
+			Class:    info.Class,
+			Periodic: info.Periodic,
+			Pinned:   info.Pinned,
+			Active:   info.Active,
+			Tags:     []string{},
+		}
+		if info.Timeout > 0 {
+			res.Jobs[i].Timeout = info.Timeout.String()
+		}
+		if !info.NextRun.IsZero() {
+			res.Jobs[i].NextRun = info.NextRun.Format(time.RFC3339Nano)
+		}
+		if info.LastErr != nil {
+			res.Jobs[i].LastError = info.LastErr.Error()
+		}
+		if !info.LastRun.IsZero() {
+			res.Jobs[i].LastRun = info.LastRun.Format(time.RFC3339Nano)
+			res.Jobs[i].LastRunDuration = info.LastRunDuration.String()
+		}
+		switch {
+		case !info.RunStarted.IsZero():
+			res.Jobs[i].Runtime = snapshot.Time.Sub(info.RunStarted).String()
+		case !info.LastRun.IsZero():
+			res.Jobs[i].Runtime = info.LastRunDuration.String()
+		}
+	}
+
+	return json.Marshal(res)
+}
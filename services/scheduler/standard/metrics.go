@@ -15,6 +15,7 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/wealdtech/chaind/services/metrics"
@@ -26,6 +27,14 @@ var (
 	schedulerJobsScheduled *prometheus.CounterVec
 	schedulerJobsCancelled *prometheus.CounterVec
 	schedulerJobsStarted   *prometheus.CounterVec
+	schedulerJobsFailed    *prometheus.CounterVec
+	schedulerJobResults    *prometheus.CounterVec
+	schedulerCoalesced     *prometheus.CounterVec
+	schedulerLockWait      *prometheus.HistogramVec
+	schedulerScheduleWait  prometheus.Counter
+	schedulerClassExceeded *prometheus.CounterVec
+	schedulerJobsExpired   *prometheus.CounterVec
+	schedulerJobsTimedOut  *prometheus.CounterVec
 )
 
 func registerMetrics(ctx context.Context, monitor metrics.Service) error {
@@ -59,7 +68,7 @@ func registerPrometheusMetrics(_ context.Context) error {
 		Subsystem: "jobs",
 		Name:      "cancelled_total",
 		Help:      "The number of scheduled jobs cancelled.",
-	}, []string{"class"})
+	}, []string{"class", "reason"})
 	if err := prometheus.Register(schedulerJobsCancelled); err != nil {
 		return err
 	}
@@ -70,7 +79,84 @@ func registerPrometheusMetrics(_ context.Context) error {
 		Name:      "started_total",
 		Help:      "The number of scheduled jobs started.",
 	}, []string{"class", "trigger"})
-	return prometheus.Register(schedulerJobsStarted)
+	if err := prometheus.Register(schedulerJobsStarted); err != nil {
+		return err
+	}
+
+	schedulerJobsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "jobs",
+		Name:      "failed_total",
+		Help:      "The number of scheduled jobs that returned an error.",
+	}, []string{"class"})
+	if err := prometheus.Register(schedulerJobsFailed); err != nil {
+		return err
+	}
+
+	schedulerJobResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "jobs",
+		Name:      "results_total",
+		Help:      "The number of job runs, by result.",
+	}, []string{"class", "result"})
+	if err := prometheus.Register(schedulerJobResults); err != nil {
+		return err
+	}
+
+	schedulerCoalesced = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "triggers_coalesced_total",
+		Help:      "The number of job triggers absorbed by a pending coalesced run.",
+	}, []string{"class"})
+	if err := prometheus.Register(schedulerCoalesced); err != nil {
+		return err
+	}
+
+	schedulerLockWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "lock_wait_seconds",
+		Help:      "The time spent waiting for and holding the scheduler's jobs lock.",
+		Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 10),
+	}, []string{"mode", "stage"})
+	if err := prometheus.Register(schedulerLockWait); err != nil {
+		return err
+	}
+
+	schedulerScheduleWait = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "schedule_rate_limit_wait_seconds_total",
+		Help:      "The total time calls to schedule jobs have waited for the schedule rate limit.",
+	})
+	if err := prometheus.Register(schedulerScheduleWait); err != nil {
+		return err
+	}
+
+	schedulerClassExceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "class_threshold_exceeded_total",
+		Help:      "The number of times the number of jobs in a class has exceeded its warn threshold.",
+	}, []string{"class"})
+	if err := prometheus.Register(schedulerClassExceeded); err != nil {
+		return err
+	}
+
+	schedulerJobsExpired = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "jobs",
+		Name:      "expired_total",
+		Help:      "The number of one-off jobs not run as their timer fired after their deadline.",
+	}, []string{"class"})
+	if err := prometheus.Register(schedulerJobsExpired); err != nil {
+		return err
+	}
+
+	schedulerJobsTimedOut = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "jobs",
+		Name:      "timed_out_total",
+		Help:      "The number of job runs abandoned as they did not finish within their timeout.",
+	}, []string{"class"})
+	return prometheus.Register(schedulerJobsTimedOut)
 }
 
 // jobScheduled is called when a job is scheduled.
@@ -80,10 +166,25 @@ func jobScheduled(class string) {
 	}
 }
 
-// jobCancelled is called when a scheduled job is cancelled.
-func jobCancelled(class string) {
+// jobCancelled is called when a scheduled job is cancelled, with a reason of "cancelled",
+// "context", "no_more_instances", "runtime_error" or "lifetime".
+func jobCancelled(class string, reason string) {
 	if schedulerJobsCancelled != nil {
-		schedulerJobsCancelled.WithLabelValues(class).Inc()
+		schedulerJobsCancelled.WithLabelValues(class, reason).Inc()
+	}
+}
+
+// jobExpired is called when a one-off job is not run as its timer fired after its deadline.
+func jobExpired(class string) {
+	if schedulerJobsExpired != nil {
+		schedulerJobsExpired.WithLabelValues(class).Inc()
+	}
+}
+
+// jobTimedOut is called when a job run is abandoned as it did not finish within its timeout.
+func jobTimedOut(class string) {
+	if schedulerJobsTimedOut != nil {
+		schedulerJobsTimedOut.WithLabelValues(class).Inc()
 	}
 }
 
@@ -100,3 +201,54 @@ func jobStartedOnSignal(class string) {
 		schedulerJobsStarted.WithLabelValues(class, "signal").Inc()
 	}
 }
+
+// jobFailed is called when a scheduled job returns an error.
+func jobFailed(class string) {
+	if schedulerJobsFailed != nil {
+		schedulerJobsFailed.WithLabelValues(class).Inc()
+	}
+}
+
+// jobResult is called when a job run completes, with a result of "success", "error" or "panic",
+// or when a run is not made, with a result of "skipped", as the instance is not the leader or the
+// scheduler has stopped.
+func jobResult(class string, result string) {
+	if schedulerJobResults != nil {
+		schedulerJobResults.WithLabelValues(class, result).Inc()
+	}
+}
+
+// jobTriggerCoalesced is called when a trigger is absorbed by a pending coalesced run.
+func jobTriggerCoalesced(class string) {
+	if schedulerCoalesced != nil {
+		schedulerCoalesced.WithLabelValues(class).Inc()
+	}
+}
+
+// lockWaited is called when the jobs lock is obtained, with the time spent waiting for it.
+func lockWaited(mode string, duration time.Duration) {
+	if schedulerLockWait != nil {
+		schedulerLockWait.WithLabelValues(mode, "wait").Observe(duration.Seconds())
+	}
+}
+
+// lockHeld is called when the jobs lock is released, with the time for which it was held.
+func lockHeld(mode string, duration time.Duration) {
+	if schedulerLockWait != nil {
+		schedulerLockWait.WithLabelValues(mode, "hold").Observe(duration.Seconds())
+	}
+}
+
+// scheduleRateLimitWaited is called when a call to schedule jobs has waited for the schedule rate limit.
+func scheduleRateLimitWaited(duration time.Duration) {
+	if schedulerScheduleWait != nil {
+		schedulerScheduleWait.Add(duration.Seconds())
+	}
+}
+
+// classThresholdExceeded is called when the number of jobs in a class exceeds its warn threshold.
+func classThresholdExceeded(class string) {
+	if schedulerClassExceeded != nil {
+		schedulerClassExceeded.WithLabelValues(class).Inc()
+	}
+}
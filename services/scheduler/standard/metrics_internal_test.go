@@ -0,0 +1,374 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"go.uber.org/atomic"
+)
+
+func TestJobResults(t *testing.T) {
+	ctx := context.Background()
+	if schedulerJobResults == nil {
+		require.NoError(t, registerPrometheusMetrics(ctx))
+	}
+	s, err := New(ctx, WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+
+	results := func(result string) float64 {
+		return testutil.ToFloat64(schedulerJobResults.WithLabelValues("results", result))
+	}
+	successes := results("success")
+	errs := results("error")
+	panics := results("panic")
+
+	job := &job{
+		class: "results",
+	}
+	job.name.Store("Test job")
+	s.runJobFunc(ctx, job, time.Now(), "signal", func(_ context.Context, _ interface{}) error {
+		return nil
+	}, nil)
+	s.runJobFunc(ctx, job, time.Now(), "signal", func(_ context.Context, _ interface{}) error {
+		return errors.New("failed")
+	}, nil)
+	s.runJobFunc(ctx, job, time.Now(), "signal", func(_ context.Context, _ interface{}) error {
+		panic("boom")
+	}, nil)
+	lastErr, err := s.LastError(ctx, job.name.Load())
+	require.NoError(t, err)
+	require.ErrorIs(t, lastErr, scheduler.ErrJobPanicked)
+
+	require.Equal(t, successes+1, results("success"))
+	require.Equal(t, errs+1, results("error"))
+	require.Equal(t, panics+1, results("panic"))
+}
+
+// lockSamples returns the number of samples recorded for the jobs lock, by mode and stage.
+func lockSamples(t *testing.T) map[string]uint64 {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	samples := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetName() != "scheduler_lock_wait_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			samples[fmt.Sprintf("%s/%s", labels["mode"], labels["stage"])] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+
+	return samples
+}
+
+func TestLockMetrics(t *testing.T) {
+	ctx := context.Background()
+	if schedulerLockWait == nil {
+		require.NoError(t, registerPrometheusMetrics(ctx))
+	}
+
+	// Without lock metrics nothing is recorded.
+	s, err := New(ctx, WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+	before := lockSamples(t)
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Uninstrumented job", time.Now().Add(time.Hour), func(_ context.Context, _ interface{}) error {
+		return nil
+	}, nil))
+	require.True(t, s.JobExists(ctx, "Uninstrumented job"))
+	require.Equal(t, before, lockSamples(t))
+
+	// With lock metrics concurrent scheduling is recorded.
+	s, err = New(ctx, WithLogLevel(zerolog.Disabled), WithLockMetrics(true))
+	require.NoError(t, err)
+	before = lockSamples(t)
+	jobs := 16
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("Job %d", i)
+			assert.NoError(t, s.ScheduleJob(ctx, "Test", name, time.Now().Add(time.Hour), func(_ context.Context, _ interface{}) error {
+				return nil
+			}, nil))
+			assert.True(t, s.JobExists(ctx, name))
+		}(i)
+	}
+	wg.Wait()
+
+	after := lockSamples(t)
+	require.GreaterOrEqual(t, after["write/wait"]-before["write/wait"], uint64(jobs))
+	require.GreaterOrEqual(t, after["write/hold"]-before["write/hold"], uint64(jobs))
+	require.GreaterOrEqual(t, after["read/wait"]-before["read/wait"], uint64(jobs))
+}
+
+func TestClassWarnThreshold(t *testing.T) {
+	ctx := context.Background()
+	if schedulerClassExceeded == nil {
+		require.NoError(t, registerPrometheusMetrics(ctx))
+	}
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithClassWarnThreshold(map[string]int{"Leaky": 3}),
+	)
+	require.NoError(t, err)
+
+	exceeded := func() float64 {
+		return testutil.ToFloat64(schedulerClassExceeded.WithLabelValues("Leaky"))
+	}
+	initial := exceeded()
+	schedule := func(class string, name string) {
+		require.NoError(t, s.ScheduleJob(ctx, class, name, time.Now().Add(time.Hour), func(_ context.Context, _ interface{}) error {
+			return nil
+		}, nil))
+	}
+
+	// Up to the threshold is fine.
+	for i := 0; i < 3; i++ {
+		schedule("Leaky", fmt.Sprintf("Job %d", i))
+	}
+	require.Equal(t, initial, exceeded())
+
+	// Crossing the threshold fires once, however far past it the class goes.
+	for i := 3; i < 6; i++ {
+		schedule("Leaky", fmt.Sprintf("Job %d", i))
+	}
+	require.Equal(t, initial+1, exceeded())
+
+	// Other classes do not count towards the threshold.
+	schedule("Other", "Other job")
+	require.Equal(t, initial+1, exceeded())
+
+	// Falling back within the threshold and crossing it again fires again.
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.CancelJob(ctx, fmt.Sprintf("Job %d", i)))
+	}
+	schedule("Leaky", "Job 6")
+	require.Equal(t, initial+2, exceeded())
+
+	// Slot jobs count as they are added.
+	s.CancelJobs(ctx, "Job")
+	_, err = s.ScheduleSlotJobsForEpoch(ctx, "Leaky", 1000000, time.Now(), time.Second, func(_ uint64) scheduler.JobFunc {
+		return func(_ context.Context, _ interface{}) error {
+			return nil
+		}
+	})
+	require.NoError(t, err)
+	require.Equal(t, initial+3, exceeded())
+}
+
+func TestJobDeadline(t *testing.T) {
+	ctx := context.Background()
+	if schedulerJobsExpired == nil {
+		require.NoError(t, registerPrometheusMetrics(ctx))
+	}
+	s, err := New(ctx, WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+
+	expired := func() float64 {
+		return testutil.ToFloat64(schedulerJobsExpired.WithLabelValues("Deadline"))
+	}
+	initial := expired()
+
+	tests := []struct {
+		name     string
+		runtime  time.Duration
+		deadline time.Duration
+		ran      bool
+	}{
+		{
+			name:     "BeforeDeadline",
+			runtime:  10 * time.Millisecond,
+			deadline: time.Hour,
+			ran:      true,
+		},
+		{
+			name:     "RuntimePassedBeforeDeadline",
+			runtime:  -time.Minute,
+			deadline: time.Hour,
+			ran:      true,
+		},
+		{
+			name:     "DeadlinePassed",
+			runtime:  -time.Minute,
+			deadline: -time.Second,
+		},
+		{
+			name:     "DeadlineBeforeRuntime",
+			runtime:  10 * time.Millisecond,
+			deadline: 5 * time.Millisecond,
+		},
+	}
+
+	expiries := 0
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ran := make(chan struct{})
+			now := time.Now()
+			require.NoError(t, s.ScheduleJob(ctx, "Deadline", test.name, now.Add(test.runtime), func(_ context.Context, _ interface{}) error {
+				close(ran)
+				return nil
+			}, nil, scheduler.WithDeadline(now.Add(test.deadline))))
+			require.Eventually(t, func() bool {
+				return !s.JobExists(ctx, test.name)
+			}, time.Second, time.Millisecond)
+
+			if test.ran {
+				<-ran
+			} else {
+				expiries++
+				select {
+				case <-ran:
+					require.Fail(t, "job ran after its deadline")
+				case <-time.After(20 * time.Millisecond):
+				}
+			}
+			require.Equal(t, initial+float64(expiries), expired())
+		})
+	}
+}
+
+func TestJobTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if schedulerJobsTimedOut == nil {
+		require.NoError(t, registerPrometheusMetrics(ctx))
+	}
+	s, err := New(ctx, WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+
+	timedOut := func() float64 {
+		return testutil.ToFloat64(schedulerJobsTimedOut.WithLabelValues("Timeout"))
+	}
+	initial := timedOut()
+
+	// The first run sleeps well past its timeout, ignoring its context; later runs return at once.
+	var runs atomic.Int32
+	secondRun := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	runtimeFunc := func(_ context.Context, _ interface{}) (time.Time, error) {
+		return time.Now().Add(10 * time.Millisecond), nil
+	}
+	require.NoError(t, s.SchedulePeriodicJobWithTimeout(ctx, "Timeout", "Periodic job", runtimeFunc, nil, 20*time.Millisecond, func(_ context.Context, _ interface{}) error {
+		switch runs.Inc() {
+		case 1:
+			select {
+			case <-release:
+			case <-time.After(time.Minute):
+			}
+		case 2:
+			close(secondRun)
+		}
+		return nil
+	}, nil))
+
+	jobs := s.Jobs(ctx)
+	require.Len(t, jobs, 1)
+	require.Equal(t, 20*time.Millisecond, jobs[0].Timeout)
+
+	// The next instance fires even though the first run has yet to return.
+	select {
+	case <-secondRun:
+	case <-time.After(time.Second):
+		require.Fail(t, "next instance of periodic job did not fire")
+	}
+	require.Equal(t, initial+1, timedOut())
+	require.NoError(t, s.CancelJob(ctx, "Periodic job"))
+
+	// A one-off job is finalised when it times out.
+	require.NoError(t, s.ScheduleJobWithTimeout(ctx, "Timeout", "One-off job", time.Now(), 20*time.Millisecond, func(_ context.Context, _ interface{}) error {
+		<-release
+		return nil
+	}, nil))
+	require.Eventually(t, func() bool {
+		lastErr, err := s.LastError(ctx, "One-off job")
+		return err == nil && errors.Is(lastErr, scheduler.ErrJobTimedOut)
+	}, time.Second, 5*time.Millisecond)
+	require.False(t, s.JobExists(ctx, "One-off job"))
+	require.Equal(t, initial+2, timedOut())
+}
+
+func TestMaxLifetime(t *testing.T) {
+	ctx := context.Background()
+	if schedulerJobsCancelled == nil {
+		require.NoError(t, registerPrometheusMetrics(ctx))
+	}
+	s, err := New(ctx, WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+
+	expired := func() float64 {
+		return testutil.ToFloat64(schedulerJobsCancelled.WithLabelValues("Lifetime", "lifetime"))
+	}
+	initial := expired()
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		ran      bool
+	}{
+		{
+			name:     "Frequent",
+			interval: 10 * time.Millisecond,
+			ran:      true,
+		},
+		{
+			// The first instance is beyond the end of the lifetime, so never runs.
+			name:     "Infrequent",
+			interval: time.Hour,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var runs atomic.Int32
+			// The runtime function would continue indefinitely.
+			runtimeFunc := func(_ context.Context, _ interface{}) (time.Time, error) {
+				return time.Now().Add(test.interval), nil
+			}
+			require.NoError(t, s.SchedulePeriodicJob(ctx, "Lifetime", test.name, runtimeFunc, nil, func(_ context.Context, _ interface{}) error {
+				runs.Inc()
+				return nil
+			}, nil, scheduler.WithMaxLifetime(100*time.Millisecond)))
+
+			require.Eventually(t, func() bool {
+				return !s.JobExists(ctx, test.name) && expired() == initial+float64(i+1)
+			}, time.Second, 5*time.Millisecond)
+			ran := runs.Load()
+			require.Equal(t, test.ran, ran > 0)
+
+			// No further runs once stopped.
+			time.Sleep(50 * time.Millisecond)
+			require.Equal(t, ran, runs.Load())
+		})
+	}
+}
@@ -14,14 +14,32 @@
 package standard
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/rs/zerolog"
 	"github.com/wealdtech/chaind/services/metrics"
 	nullmetrics "github.com/wealdtech/chaind/services/metrics/null"
+	"github.com/wealdtech/chaind/services/scheduler"
 )
 
 type parameters struct {
-	logLevel zerolog.Level
-	monitor  metrics.Service
+	logLevel      zerolog.Level
+	monitor       metrics.Service
+	historySize   int
+	historyStore  scheduler.HistoryStore
+	slotsPerEpoch uint64
+	leaderCheck   func(context.Context) (bool, error)
+	lockMetrics   bool
+	scheduleRate  float64
+	expvarName    string
+	// classWarnThresholds are the numbers of jobs in a class above which a warning is raised.
+	classWarnThresholds     map[string]int
+	stateTransitionHook     func(busy bool)
+	stateTransitionDebounce time.Duration
 }
 
 // Parameter is the interface for service parameters.
@@ -49,12 +67,102 @@ func WithMonitor(monitor metrics.Service) Parameter {
 	})
 }
 
+// WithRunHistorySize sets the number of runs recorded for each job.
+func WithRunHistorySize(size int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.historySize = size
+	})
+}
+
+// WithHistoryStore sets a store to persist run history, so that it survives restarts.
+// History in the store is loaded when the service starts, and saved as jobs run.
+func WithHistoryStore(store scheduler.HistoryStore) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.historyStore = store
+	})
+}
+
+// WithSlotsPerEpoch sets the number of slots in an epoch, for scheduling slot jobs.
+func WithSlotsPerEpoch(slotsPerEpoch uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slotsPerEpoch = slotsPerEpoch
+	})
+}
+
+// WithLeaderCheck sets a function to confirm that this instance should run jobs.
+// It applies to all jobs that do not supply their own leader check, and is called
+// immediately before each run; see scheduler.WithLeaderCheck.
+func WithLeaderCheck(check func(ctx context.Context) (bool, error)) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.leaderCheck = check
+	})
+}
+
+// WithLockMetrics records the time spent waiting for and holding the jobs lock.
+// This is diagnostic, and adds overhead to every scheduler operation.
+func WithLockMetrics(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.lockMetrics = enabled
+	})
+}
+
+// WithScheduleRateLimit sets the maximum rate, in jobs per second, at which jobs can be
+// scheduled.  Calls to schedule jobs above this rate block until they are within it.
+// This does not limit the rate at which jobs run.  0 means no limit.
+func WithScheduleRateLimit(rps float64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduleRate = rps
+	})
+}
+
+// WithExpvar publishes job counts over expvar with the given name, for example to
+// be served at /debug/vars.  The name must not already be published.
+func WithExpvar(name string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.expvarName = name
+	})
+}
+
+// WithClassWarnThreshold sets, for each class, a number of jobs above which a
+// warning is logged and scheduler_class_threshold_exceeded_total is
+// incremented.  This gives early warning of runaway job creation.  The warning is
+// raised once each time the count crosses the threshold.  Classes are matched
+// without regard to case, as configuration keys are case-insensitive.
+func WithClassWarnThreshold(thresholds map[string]int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.classWarnThresholds = make(map[string]int, len(thresholds))
+		for class, threshold := range thresholds {
+			p.classWarnThresholds[strings.ToLower(class)] = threshold
+		}
+	})
+}
+
+// WithStateTransitionHook sets a function to be called when the scheduler moves
+// between being idle, with no jobs running, and busy, with at least one job running.
+// The function is called asynchronously, with calls made in order and never
+// concurrently.  Transitions are debounced, so a state is only reported once it
+// has held for the period set by WithStateTransitionDebounce.
+func WithStateTransitionHook(hook func(busy bool)) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.stateTransitionHook = hook
+	})
+}
+
+// WithStateTransitionDebounce sets the period for which the scheduler must remain
+// idle or busy before the transition is passed to the state transition hook.
+func WithStateTransitionDebounce(debounce time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.stateTransitionDebounce = debounce
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
-//
-//nolint:unparam
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel: zerolog.GlobalLevel(),
+		logLevel:                zerolog.GlobalLevel(),
+		historySize:             64,
+		slotsPerEpoch:           32,
+		stateTransitionDebounce: 100 * time.Millisecond,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -62,6 +170,23 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 		}
 	}
 
+	if parameters.historySize < 0 {
+		return nil, errors.New("run history size cannot be negative")
+	}
+	if parameters.slotsPerEpoch == 0 {
+		return nil, errors.New("slots per epoch must be positive")
+	}
+	if parameters.stateTransitionDebounce < 0 {
+		return nil, errors.New("state transition debounce cannot be negative")
+	}
+	if parameters.scheduleRate < 0 {
+		return nil, errors.New("schedule rate limit cannot be negative")
+	}
+	for class, threshold := range parameters.classWarnThresholds {
+		if threshold <= 0 {
+			return nil, fmt.Errorf("warn threshold for class %q must be positive", class)
+		}
+	}
 	if parameters.monitor == nil {
 		parameters.monitor = &nullmetrics.Service{}
 	}
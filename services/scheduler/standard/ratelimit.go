@@ -0,0 +1,94 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// scheduleRateLimiter is a token bucket that limits the rate at which jobs are
+// scheduled.  It does not limit the rate at which jobs run.
+// A nil limiter is valid, and does not limit.
+type scheduleRateLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+// newScheduleRateLimiter creates a new rate limiter allowing rps schedules per second.
+// The bucket holds up to one second's worth of schedules.
+// If rps is 0 no limiter is created.
+func newScheduleRateLimiter(rps float64) *scheduleRateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	capacity := math.Max(1, rps)
+
+	return &scheduleRateLimiter{
+		rate:     rps,
+		capacity: capacity,
+		tokens:   capacity,
+		last:     time.Now(),
+	}
+}
+
+// wait waits until n jobs can be scheduled, returning the time spent waiting.
+// Each call reserves its tokens, so waiters are served in the order they arrive.
+func (l *scheduleRateLimiter) wait(ctx context.Context, n int) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+
+	l.mu.Lock()
+	l.refill(time.Now())
+	l.tokens -= float64(n)
+	remaining := l.tokens
+	l.mu.Unlock()
+
+	if remaining >= 0 {
+		return 0, nil
+	}
+
+	delay := time.Duration(-remaining / l.rate * float64(time.Second))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// Return the unused tokens.
+		l.mu.Lock()
+		l.tokens += float64(n)
+		l.mu.Unlock()
+		return 0, ctx.Err()
+	case <-timer.C:
+	}
+	scheduleRateLimitWaited(delay)
+
+	return delay, nil
+}
+
+// refill adds tokens for the time elapsed since the last refill.
+// This requires the lock to be held.
+func (l *scheduleRateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	l.tokens = math.Min(l.capacity, l.tokens+elapsed*l.rate)
+	l.last = now
+}
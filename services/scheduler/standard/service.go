@@ -15,7 +15,10 @@ package standard
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -23,6 +26,7 @@ import (
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/sasha-s/go-deadlock"
 	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
 	"go.uber.org/atomic"
 )
 
@@ -31,21 +35,122 @@ var log zerolog.Logger
 
 // job contains control points for a job.
 type job struct {
+	// name is held atomically as it can change if the job is renamed.
+	name  atomic.String
+	class string
 	// stateLock is required for active or finalised.
 	stateLock deadlock.Mutex
 	active    atomic.Bool
 	finalised atomic.Bool
 	periodic  bool
-	cancelCh  chan struct{}
-	runCh     chan struct{}
+	pinned    bool
+	// confirmCompletion, if present, confirms the effects of a successful run.
+	confirmCompletion func(context.Context) error
+	// snapshotData, if present, copies the job data for replay.
+	snapshotData func(interface{}) interface{}
+	// leaderCheck, if present, confirms that this instance should run the job.
+	leaderCheck func(context.Context) (bool, error)
+	// triggerCoalesce is the window within which triggers are coalesced.
+	triggerCoalesce time.Duration
+	// pendingTrigger is the timer for a coalesced trigger; it requires stateLock.
+	pendingTrigger *time.Timer
+	cancelCh       chan struct{}
+	runCh          chan struct{}
+	lastErr        atomic.Error
+	nextRun        atomic.Time
+	// completedRun is set once the job has completed a successful run.
+	completedRun atomic.Bool
+	// lastRun holds the function and data of the most recent run, for replay.
+	lastRun atomic.Pointer[replay]
+	// lastRunTiming holds the start and duration of the most recent completed run.
+	lastRunTiming atomic.Pointer[runTiming]
+	// finalizer, if present, releases the job's resources once it is finalised.
+	finalizer     func()
+	finalizerOnce sync.Once
+	// ctx is cancelled, with a cause, when the job is cancelled or finalised.
+	ctx         context.Context
+	cancelCause context.CancelCauseFunc
+	// cancelRunning runs the job with ctx, so that a run in progress is cancelled along with the job.
+	cancelRunning bool
+	// advanceTo is the time to which the next run of a periodic job has been advanced,
+	// or zero if it has not been advanced; it requires stateLock.
+	advanceTo time.Time
+	advanceCh chan struct{}
+	// deadline is the time after which a timer-triggered run of a one-off job expires,
+	// or zero if there is no deadline.
+	deadline time.Time
+	// timeout is the time after which a run of the job is abandoned, or 0 if runs are not bounded.
+	timeout time.Duration
+	// runOnResume runs the job once on resume if it missed a run whilst suspended.
+	runOnResume bool
+}
+
+// setContext sets the context of the job, derived from the context with which it was scheduled.
+func (j *job) setContext(ctx context.Context) {
+	j.ctx, j.cancelCause = context.WithCancelCause(ctx)
+}
+
+// runContext returns the context for a run of the job.
+func (j *job) runContext(ctx context.Context) context.Context {
+	if j.cancelRunning {
+		return j.ctx
+	}
+
+	return ctx
+}
+
+// replay holds the information required to replay a run of a job.
+type replay struct {
+	jobFunc scheduler.JobFunc
+	data    interface{}
+}
+
+// runTiming holds the timing of a run of a job.
+type runTiming struct {
+	started  time.Time
+	duration time.Duration
 }
 
 // Service is a scheduler service.  It uses additional per-job information to manage
 // the state of each job, in an attempt to ensure additional robustness in the face
 // of high concurrent load.
 type Service struct {
-	jobs      map[string]*job
-	jobsMutex deadlock.RWMutex
+	jobs          map[string]*job
+	jobsMutex     instrumentedRWMutex
+	history       *runHistory
+	slotsPerEpoch uint64
+	// historyStore persists the run history; it can be nil.
+	historyStore scheduler.HistoryStore
+	// historyChanged is signalled when the run history changes.
+	historyChanged chan struct{}
+	// historySaveMu serialises saves of the run history.
+	historySaveMu sync.Mutex
+	// running is the number of job functions currently running.  One-off jobs
+	// are removed from jobs when they start, so are only visible here.
+	running atomic.Int64
+	// cancellableRuns are the jobs with runs in progress whose context is cancelled
+	// along with the job, so that stopping the scheduler can cancel them.
+	cancellableRuns sync.Map
+	// runStarts are the start times of the runs in progress, keyed by job.
+	runStarts sync.Map
+	// stopped is set once the scheduler has stopped; it is changed under jobsMutex.
+	stopped atomic.Bool
+	// now provides the current time when checking for idleness.
+	now func() time.Time
+	// leaderCheck is the leader check for jobs without their own.
+	leaderCheck func(context.Context) (bool, error)
+	// scheduleLimiter limits the rate at which jobs are scheduled.
+	scheduleLimiter *scheduleRateLimiter
+	// classWarnThresholds are the numbers of jobs in a class above which a warning is raised.
+	classWarnThresholds map[string]int
+	// stateTransitions reports transitions between idle and busy; it can be nil.
+	stateTransitions *stateTransitions
+	// resumeCh is closed when a suspended scheduler resumes; it is nil if the
+	// scheduler is not suspended.
+	resumeCh chan struct{}
+	// quiesceTimer resumes a quiesced scheduler; it is nil if the scheduler is not quiesced.
+	quiesceTimer *time.Timer
+	resumeChMu   sync.Mutex
 }
 
 // New creates a new scheduling service.
@@ -56,18 +161,43 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "scheduler").Str("impl", "advanced").Logger()
-	if parameters.logLevel != log.GetLevel() {
-		log = log.Level(parameters.logLevel)
-	}
+	log = util.ServiceLogger(zerologger.With().Str("service", "scheduler").Str("impl", "advanced").Logger(), "scheduler", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
 	}
 
-	return &Service{
-		jobs: make(map[string]*job),
-	}, nil
+	s := &Service{
+		jobs:                make(map[string]*job),
+		jobsMutex:           instrumentedRWMutex{instrumented: parameters.lockMetrics},
+		history:             newRunHistory(parameters.historySize),
+		slotsPerEpoch:       parameters.slotsPerEpoch,
+		now:                 time.Now,
+		leaderCheck:         parameters.leaderCheck,
+		scheduleLimiter:     newScheduleRateLimiter(parameters.scheduleRate),
+		classWarnThresholds: parameters.classWarnThresholds,
+		historyStore:        parameters.historyStore,
+		historyChanged:      make(chan struct{}, 1),
+	}
+	s.stateTransitions = newStateTransitions(parameters.stateTransitionHook,
+		parameters.stateTransitionDebounce,
+		func() bool { return s.running.Load() > 0 },
+	)
+
+	if s.historyStore != nil {
+		if err := s.loadHistory(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to load run history")
+		}
+		go s.persistHistory(ctx)
+	}
+
+	if parameters.expvarName != "" {
+		if err := s.publishExpvar(parameters.expvarName); err != nil {
+			return nil, errors.Wrap(err, "failed to publish expvar")
+		}
+	}
+
+	return s, nil
 }
 
 // ScheduleJob schedules a one-off job for a given time.
@@ -78,6 +208,7 @@ func (s *Service) ScheduleJob(ctx context.Context,
 	runtime time.Time,
 	jobFunc scheduler.JobFunc,
 	data interface{},
+	opts ...scheduler.JobOption,
 ) error {
 	if name == "" {
 		return scheduler.ErrNoJobName
@@ -85,66 +216,203 @@ func (s *Service) ScheduleJob(ctx context.Context,
 	if jobFunc == nil {
 		return scheduler.ErrNoJobFunc
 	}
+	if _, err := s.scheduleLimiter.wait(ctx, 1); err != nil {
+		return errors.Wrap(err, "failed to wait for schedule rate limit")
+	}
 
 	s.jobsMutex.Lock()
-	if _, exists := s.jobs[name]; exists {
+	if s.stopped.Load() {
 		s.jobsMutex.Unlock()
-		return scheduler.ErrJobAlreadyExists
+		return scheduler.ErrSchedulerStopped
+	}
+	options := scheduler.ParseJobOptions(opts...)
+	if existing, exists := s.jobs[name]; exists {
+		if !options.Supersede {
+			s.jobsMutex.Unlock()
+			return scheduler.ErrJobAlreadyExists
+		}
+		delete(s.jobs, name)
+		s.cancelJob(existing, scheduler.ErrSuperseded)
+		log.Trace().Str("job", name).Msg("Superseded job")
 	}
 
 	job := &job{
-		cancelCh: make(chan struct{}, 1),
-		runCh:    make(chan struct{}, 1),
+		class:             class,
+		pinned:            options.Pinned,
+		confirmCompletion: options.ConfirmCompletion,
+		snapshotData:      options.SnapshotData,
+		leaderCheck:       s.jobLeaderCheck(options),
+		triggerCoalesce:   options.TriggerCoalesce,
+		finalizer:         options.Finalizer,
+		cancelRunning:     options.CancelRunning,
+		timeout:           options.Timeout,
+		deadline:          options.Deadline,
+		cancelCh:          make(chan struct{}, 1),
+		runCh:             make(chan struct{}, 1),
 	}
+	job.name.Store(name)
+	job.setContext(ctx)
+	job.nextRun.Store(runtime)
 	s.jobs[name] = job
+	count, exceeded := s.checkClassThreshold(class, 1)
 	s.jobsMutex.Unlock()
 	jobScheduled(class)
+	if exceeded {
+		s.warnClassThreshold(class, count)
+	}
 
 	log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Scheduled job")
-	go func() {
-		select {
-		case <-ctx.Done():
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Parent context done; job not running")
-			s.jobsMutex.Lock()
-			delete(s.jobs, name)
-			s.jobsMutex.Unlock()
-			finaliseJob(job)
-			jobCancelled(class)
-		case <-job.cancelCh:
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Cancel triggered; job not running")
-			// If we receive this signal the job has already been deleted from the jobs list so no need to
-			// do so again here.
-			finaliseJob(job)
-			jobCancelled(class)
-		case <-job.runCh:
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Run triggered; job running")
-			// If we receive this signal the job has already been deleted from the jobs list so no need to
-			// do so again here.
-			jobStartedOnSignal(class)
-			jobFunc(ctx, data)
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
-			finaliseJob(job)
-			job.active.Store(false)
-		case <-time.After(time.Until(runtime)):
-			// It is possible that the job is already active, so check that first before proceeding.
-			if job.active.Load() {
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Already running; job not running")
-				break
-			}
-			s.jobsMutex.Lock()
-			delete(s.jobs, name)
+	go s.runOneOff(ctx, job, runtime, jobFunc, data)
+
+	return nil
+}
+
+// ScheduleJobWithTimeout schedules a one-off job, as ScheduleJob, with each run bounded by the timeout.
+func (s *Service) ScheduleJobWithTimeout(ctx context.Context,
+	class string,
+	name string,
+	runtime time.Time,
+	timeout time.Duration,
+	jobFunc scheduler.JobFunc,
+	data interface{},
+	opts ...scheduler.JobOption,
+) error {
+	return s.ScheduleJob(ctx, class, name, runtime, jobFunc, data, append(opts[:len(opts):len(opts)], scheduler.WithTimeout(timeout))...)
+}
+
+// ScheduleSlotJobsForEpoch schedules a one-off job for each slot of the given epoch,
+// to run at the start of the slot.
+// Jobs are named <class>-slot-<slot>, and are all scheduled or none are.
+func (s *Service) ScheduleSlotJobsForEpoch(ctx context.Context,
+	class string,
+	epoch uint64,
+	genesisTime time.Time,
+	slotDuration time.Duration,
+	jobFuncFor func(slot uint64) scheduler.JobFunc,
+) (
+	[]string,
+	error,
+) {
+	if jobFuncFor == nil {
+		return nil, scheduler.ErrNoJobFunc
+	}
+
+	firstSlot := epoch * s.slotsPerEpoch
+	jobs := make([]*job, s.slotsPerEpoch)
+	jobFuncs := make([]scheduler.JobFunc, s.slotsPerEpoch)
+	runtimes := make([]time.Time, s.slotsPerEpoch)
+	names := make([]string, s.slotsPerEpoch)
+	for i := range jobs {
+		slot := firstSlot + uint64(i)
+		jobFuncs[i] = jobFuncFor(slot)
+		if jobFuncs[i] == nil {
+			return nil, scheduler.ErrNoJobFunc
+		}
+		names[i] = fmt.Sprintf("%s-slot-%d", class, slot)
+		runtimes[i] = genesisTime.Add(time.Duration(slot) * slotDuration)
+		jobs[i] = &job{
+			class:       class,
+			leaderCheck: s.leaderCheck,
+			cancelCh:    make(chan struct{}, 1),
+			runCh:       make(chan struct{}, 1),
+		}
+		jobs[i].name.Store(names[i])
+		jobs[i].setContext(ctx)
+		jobs[i].nextRun.Store(runtimes[i])
+	}
+	if _, err := s.scheduleLimiter.wait(ctx, len(jobs)); err != nil {
+		return nil, errors.Wrap(err, "failed to wait for schedule rate limit")
+	}
+
+	s.jobsMutex.Lock()
+	if s.stopped.Load() {
+		s.jobsMutex.Unlock()
+		return nil, scheduler.ErrSchedulerStopped
+	}
+	for _, name := range names {
+		if _, exists := s.jobs[name]; exists {
 			s.jobsMutex.Unlock()
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Timer triggered; job running")
-			job.active.Store(true)
-			jobStartedOnTimer(class)
-			jobFunc(ctx, data)
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
-			job.active.Store(false)
+			return nil, errors.Wrap(scheduler.ErrJobAlreadyExists, name)
+		}
+	}
+	for i := range jobs {
+		s.jobs[names[i]] = jobs[i]
+	}
+	count, exceeded := s.checkClassThreshold(class, len(jobs))
+	s.jobsMutex.Unlock()
+	if exceeded {
+		s.warnClassThreshold(class, count)
+	}
+
+	for i := range jobs {
+		jobScheduled(class)
+		log.Trace().Str("job", names[i]).Time("scheduled", runtimes[i]).Msg("Scheduled job")
+		go s.runOneOff(ctx, jobs[i], runtimes[i], jobFuncs[i], nil)
+	}
+
+	return names, nil
+}
+
+// runOneOff waits for a one-off job to be triggered, and runs it.
+func (s *Service) runOneOff(ctx context.Context,
+	job *job,
+	runtime time.Time,
+	jobFunc scheduler.JobFunc,
+	data interface{},
+) {
+	class := job.class
+	select {
+	case <-ctx.Done():
+		log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Parent context done; job not running")
+		s.removeJob(job)
+		finaliseJob(job)
+		jobCancelled(class, "context")
+	case <-job.cancelCh:
+		log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Cancel triggered; job not running")
+		// If we receive this signal the job has already been deleted from the jobs list so no need to
+		// do so again here.
+		finaliseJob(job)
+		jobCancelled(class, "cancelled")
+	case <-job.runCh:
+		log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Run triggered; job running")
+		// If we receive this signal the job has already been deleted from the jobs list so no need to
+		// do so again here.
+		jobStartedOnSignal(class)
+		s.runJobFunc(ctx, job, runtime, "signal", jobFunc, data)
+		log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Job complete")
+		finaliseJob(job)
+		job.active.Store(false)
+	case <-time.After(time.Until(runtime)):
+		// It is possible that the job is already active, so check that first before proceeding.
+		if job.active.Load() {
+			log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Already running; job not running")
+			break
+		}
+		s.removeJob(job)
+		if job.expired() {
+			log.Debug().Str("job", job.name.Load()).Time("scheduled", runtime).Time("deadline", job.deadline).Msg("Timer triggered after deadline; job expired")
 			finaliseJob(job)
+			jobExpired(class)
+			break
 		}
-	}()
+		log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Timer triggered; job running")
+		job.active.Store(true)
+		jobStartedOnTimer(class)
+		s.runJobFunc(ctx, job, runtime, "timer", jobFunc, data)
+		log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Job complete")
+		job.active.Store(false)
+		finaliseJob(job)
+	}
+}
 
-	return nil
+// expired returns true if the job's deadline has passed.
+func (j *job) expired() bool {
+	if j.deadline.IsZero() {
+		return false
+	}
+	// Strip the monotonic clock reading, which does not advance whilst the machine
+	// is suspended, so that the comparison uses the wall clock.
+	return time.Now().Round(0).After(j.deadline)
 }
 
 // SchedulePeriodicJob schedules a job to run in a loop.
@@ -158,6 +426,7 @@ func (s *Service) SchedulePeriodicJob(ctx context.Context,
 	runtimeData interface{},
 	jobFunc scheduler.JobFunc,
 	jobData interface{},
+	opts ...scheduler.JobOption,
 ) error {
 	if name == "" {
 		return scheduler.ErrNoJobName
@@ -168,82 +437,248 @@ func (s *Service) SchedulePeriodicJob(ctx context.Context,
 	if jobFunc == nil {
 		return scheduler.ErrNoJobFunc
 	}
+	if _, err := s.scheduleLimiter.wait(ctx, 1); err != nil {
+		return errors.Wrap(err, "failed to wait for schedule rate limit")
+	}
 
 	s.jobsMutex.Lock()
-	if _, exists := s.jobs[name]; exists {
+	if s.stopped.Load() {
 		s.jobsMutex.Unlock()
-		return scheduler.ErrJobAlreadyExists
+		return scheduler.ErrSchedulerStopped
+	}
+	options := scheduler.ParseJobOptions(opts...)
+	if existing, exists := s.jobs[name]; exists {
+		if !options.Supersede {
+			s.jobsMutex.Unlock()
+			return scheduler.ErrJobAlreadyExists
+		}
+		delete(s.jobs, name)
+		s.cancelJob(existing, scheduler.ErrSuperseded)
+		log.Trace().Str("job", name).Msg("Superseded job")
 	}
 
 	job := &job{
-		cancelCh: make(chan struct{}, 1),
-		runCh:    make(chan struct{}, 1),
-		periodic: true,
+		class:             class,
+		pinned:            options.Pinned,
+		confirmCompletion: options.ConfirmCompletion,
+		snapshotData:      options.SnapshotData,
+		leaderCheck:       s.jobLeaderCheck(options),
+		triggerCoalesce:   options.TriggerCoalesce,
+		finalizer:         options.Finalizer,
+		cancelRunning:     options.CancelRunning,
+		timeout:           options.Timeout,
+		runOnResume:       options.RunOnResume,
+		cancelCh:          make(chan struct{}, 1),
+		runCh:             make(chan struct{}, 1),
+		advanceCh:         make(chan struct{}, 1),
+		periodic:          true,
 	}
+	job.name.Store(name)
+	job.setContext(ctx)
 	s.jobs[name] = job
+	count, exceeded := s.checkClassThreshold(class, 1)
 	s.jobsMutex.Unlock()
 	jobScheduled(class)
+	if exceeded {
+		s.warnClassThreshold(class, count)
+	}
+
+	var expiry time.Time
+	if options.MaxLifetime > 0 {
+		expiry = time.Now().Add(options.MaxLifetime)
+	}
 
 	go func() {
 		for {
+			if !expiry.IsZero() && time.Now().After(expiry) {
+				log.Trace().Str("job", job.name.Load()).Msg("Lifetime exceeded; periodic job stopping")
+				s.removeJob(job)
+				finaliseJob(job)
+				jobCancelled(class, "lifetime")
+				return
+			}
 			runtime, err := runtimeFunc(ctx, runtimeData)
 			if errors.Is(err, scheduler.ErrNoMoreInstances) {
-				log.Trace().Str("job", name).Msg("No more instances; period job stopping")
-				s.jobsMutex.Lock()
-				delete(s.jobs, name)
-				s.jobsMutex.Unlock()
+				log.Trace().Str("job", job.name.Load()).Msg("No more instances; period job stopping")
+				s.removeJob(job)
 				finaliseJob(job)
-				jobCancelled(class)
+				jobCancelled(class, "no_more_instances")
 				return
 			}
 			if err != nil {
-				log.Error().Str("job", name).Err(err).Msg("Failed to obtain runtime; periodic job stopping")
-				s.jobsMutex.Lock()
-				delete(s.jobs, name)
-				s.jobsMutex.Unlock()
+				log.Error().Str("job", job.name.Load()).Err(err).Msg("Failed to obtain runtime; periodic job stopping")
+				s.removeJob(job)
 				finaliseJob(job)
-				jobCancelled(class)
+				jobCancelled(class, "runtime_error")
 				return
 			}
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Scheduled job")
-			select {
-			case <-ctx.Done():
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Parent context done; job not running")
-				s.jobsMutex.Lock()
-				delete(s.jobs, name)
-				s.jobsMutex.Unlock()
-				finaliseJob(job)
-				jobCancelled(class)
-				return
-			case <-job.cancelCh:
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Cancel triggered; job not running")
+			if !expiry.IsZero() && runtime.After(expiry) {
+				log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Next run after end of lifetime; periodic job stopping")
+				s.removeJob(job)
 				finaliseJob(job)
-				jobCancelled(class)
+				jobCancelled(class, "lifetime")
 				return
-			case <-job.runCh:
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Run triggered; job running")
-				jobStartedOnSignal(class)
-				jobFunc(ctx, jobData)
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
-				job.active.Store(false)
-			case <-time.After(time.Until(runtime)):
-				if job.active.Load() {
-					log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Already running; job not running")
-					continue
+			}
+			job.nextRun.Store(runtime)
+			log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Scheduled job")
+			timer := time.NewTimer(time.Until(runtime))
+			// missed is the suspension during which the timer fired, if any; it is closed on resume.
+			var missed chan struct{}
+			// Wait for the next run, repeating the wait if the run is advanced or missed.
+			for advanced := true; advanced; {
+				advanced = false
+				select {
+				case <-job.advanceCh:
+					if to := job.takeAdvance(); !to.IsZero() && to.Before(runtime) {
+						runtime = to
+						job.nextRun.Store(runtime)
+						timer.Stop()
+						timer = time.NewTimer(time.Until(runtime))
+						log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Advanced job")
+					}
+					advanced = true
+				case <-ctx.Done():
+					log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Parent context done; job not running")
+					s.removeJob(job)
+					finaliseJob(job)
+					jobCancelled(class, "context")
+					return
+				case <-job.cancelCh:
+					log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Cancel triggered; job not running")
+					finaliseJob(job)
+					jobCancelled(class, "cancelled")
+					return
+				case <-job.runCh:
+					log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Run triggered; job running")
+					jobStartedOnSignal(class)
+					s.runJobFunc(ctx, job, runtime, "signal", jobFunc, jobData)
+					log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Job complete")
+					job.active.Store(false)
+				case <-timer.C:
+					if job.active.Load() {
+						log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Already running; job not running")
+						continue
+					}
+					if suspension := s.suspension(); suspension != nil {
+						// Note the missed run and carry on waiting, so that cancellations and
+						// runs triggered by RunJob are still handled whilst suspended.
+						log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Scheduler suspended; run missed")
+						missed = suspension
+						advanced = true
+						continue
+					}
+					job.active.Store(true)
+					log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Timer triggered; job running")
+					jobStartedOnTimer(class)
+					s.runJobFunc(ctx, job, runtime, "timer", jobFunc, jobData)
+					log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Job complete")
+					job.active.Store(false)
+				case <-missed:
+					missed = nil
+					if !job.runOnResume || job.active.Load() {
+						log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Scheduler resumed; missed run skipped")
+						continue
+					}
+					job.active.Store(true)
+					log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Scheduler resumed; running missed run")
+					jobStartedOnTimer(class)
+					s.runJobFunc(ctx, job, runtime, "timer", jobFunc, jobData)
+					log.Trace().Str("job", job.name.Load()).Time("scheduled", runtime).Msg("Job complete")
+					job.active.Store(false)
 				}
-				job.active.Store(true)
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Timer triggered; job running")
-				jobStartedOnTimer(class)
-				jobFunc(ctx, jobData)
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
-				job.active.Store(false)
 			}
+			timer.Stop()
 		}
 	}()
 
 	return nil
 }
 
+// SchedulePeriodicJobWithTimeout schedules a periodic job, as SchedulePeriodicJob, with each run bounded by the timeout.
+func (s *Service) SchedulePeriodicJobWithTimeout(ctx context.Context,
+	class string,
+	name string,
+	runtimeFunc scheduler.RuntimeFunc,
+	runtimeData interface{},
+	timeout time.Duration,
+	jobFunc scheduler.JobFunc,
+	jobData interface{},
+	opts ...scheduler.JobOption,
+) error {
+	return s.SchedulePeriodicJob(ctx, class, name, runtimeFunc, runtimeData, jobFunc, jobData, append(opts[:len(opts):len(opts)], scheduler.WithTimeout(timeout))...)
+}
+
+// Suspend holds timer-triggered runs of periodic jobs until Resume is called.
+// A periodic job whose runtime passes whilst the scheduler is suspended misses
+// that run, and on resume either runs once immediately, if it was scheduled with
+// WithRunOnResume, or waits for its next runtime.  A run triggered by RunJob whilst
+// suspended takes the place of a missed run.  Runs triggered by RunJob, and one-off
+// jobs, are not affected.
+func (s *Service) Suspend(_ context.Context) {
+	s.resumeChMu.Lock()
+	defer s.resumeChMu.Unlock()
+	s.suspend()
+}
+
+// QuiesceUntil suspends the scheduler, as Suspend, until the given time at which it resumes.
+// Calling Resume ends the quiescence early, and calling QuiesceUntil again replaces the
+// time at which the scheduler resumes.
+func (s *Service) QuiesceUntil(_ context.Context, until time.Time) {
+	s.resumeChMu.Lock()
+	defer s.resumeChMu.Unlock()
+	s.suspend()
+	if s.quiesceTimer != nil {
+		s.quiesceTimer.Stop()
+	}
+	suspension := s.resumeCh
+	s.quiesceTimer = time.AfterFunc(time.Until(until), func() {
+		s.resumeChMu.Lock()
+		defer s.resumeChMu.Unlock()
+		if s.resumeCh == suspension {
+			s.resume()
+		}
+	})
+	log.Debug().Time("until", until).Msg("Quiesced")
+}
+
+// Resume resumes a suspended scheduler.
+func (s *Service) Resume(_ context.Context) {
+	s.resumeChMu.Lock()
+	defer s.resumeChMu.Unlock()
+	s.resume()
+}
+
+// suspend suspends the scheduler if it is not already suspended.
+// This requires resumeChMu.
+func (s *Service) suspend() {
+	if s.resumeCh == nil {
+		s.resumeCh = make(chan struct{})
+		log.Debug().Msg("Suspended")
+	}
+}
+
+// resume resumes the scheduler if it is suspended.
+// This requires resumeChMu.
+func (s *Service) resume() {
+	if s.quiesceTimer != nil {
+		s.quiesceTimer.Stop()
+		s.quiesceTimer = nil
+	}
+	if s.resumeCh != nil {
+		close(s.resumeCh)
+		s.resumeCh = nil
+		log.Debug().Msg("Resumed")
+	}
+}
+
+// suspension returns a channel that is closed when the scheduler resumes, or nil
+// if the scheduler is not suspended.
+func (s *Service) suspension() chan struct{} {
+	s.resumeChMu.Lock()
+	defer s.resumeChMu.Unlock()
+	return s.resumeCh
+}
+
 // RunJob runs a named job immediately.
 // If the job does not exist it will return an appropriate error.
 func (s *Service) RunJob(ctx context.Context, name string) error {
@@ -254,6 +689,10 @@ func (s *Service) RunJob(ctx context.Context, name string) error {
 		s.jobsMutex.Unlock()
 		return scheduler.ErrNoSuchJob
 	}
+	if job.triggerCoalesce > 0 {
+		s.jobsMutex.Unlock()
+		return s.coalesceTrigger(ctx, job)
+	}
 	if !job.periodic {
 		// Because this job only runs once we remove it from the jobs list immediately.
 		delete(s.jobs, name)
@@ -273,6 +712,12 @@ func (s *Service) RunJobIfExists(ctx context.Context, name string) {
 		s.jobsMutex.Unlock()
 		return
 	}
+	if job.triggerCoalesce > 0 {
+		s.jobsMutex.Unlock()
+		//nolint
+		s.coalesceTrigger(ctx, job)
+		return
+	}
 	if !job.periodic {
 		// Because this job only runs once we remove it from the jobs list immediately.
 		delete(s.jobs, name)
@@ -292,17 +737,119 @@ func (s *Service) JobExists(_ context.Context, name string) bool {
 }
 
 // ListJobs returns the names of all jobs.
-func (s *Service) ListJobs(_ context.Context) []string {
-	s.jobsMutex.RLock()
-	names := make([]string, 0, len(s.jobs))
-	for name := range s.jobs {
-		names = append(names, name)
+func (s *Service) ListJobs(ctx context.Context) []string {
+	infos := s.Jobs(ctx)
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name
 	}
-	s.jobsMutex.RUnlock()
 
 	return names
 }
 
+// RenameJob renames a job, preserving its schedule and state.
+func (s *Service) RenameJob(_ context.Context, oldName string, newName string) error {
+	if newName == "" {
+		return scheduler.ErrNoJobName
+	}
+
+	s.jobsMutex.Lock()
+	defer s.jobsMutex.Unlock()
+	job, exists := s.jobs[oldName]
+	if !exists {
+		return scheduler.ErrNoSuchJob
+	}
+	if oldName == newName {
+		return nil
+	}
+	if _, exists := s.jobs[newName]; exists {
+		return scheduler.ErrJobAlreadyExists
+	}
+	delete(s.jobs, oldName)
+	s.jobs[newName] = job
+	job.name.Store(newName)
+	log.Trace().Str("job", newName).Str("old_name", oldName).Msg("Renamed job")
+
+	return nil
+}
+
+// AdvanceNextRun advances the next run of a periodic job to the given time.
+// This has no effect if the job is already due to run before the given time.  If the job is
+// running the advance applies to the run that follows.
+// It returns ErrNoSuchJob if the job is not known, and ErrJobNotPeriodic if it is a one-off job.
+func (s *Service) AdvanceNextRun(_ context.Context, name string, to time.Time) error {
+	s.jobsMutex.Lock()
+	job, exists := s.jobs[name]
+	s.jobsMutex.Unlock()
+	if !exists {
+		return scheduler.ErrNoSuchJob
+	}
+	if !job.periodic {
+		return scheduler.ErrJobNotPeriodic
+	}
+
+	job.stateLock.Lock()
+	if job.advanceTo.IsZero() || to.Before(job.advanceTo) {
+		job.advanceTo = to
+	}
+	job.stateLock.Unlock()
+	select {
+	case job.advanceCh <- struct{}{}:
+	default:
+		// An advance is already pending, and will pick up the new time.
+	}
+	log.Trace().Str("job", name).Time("to", to).Msg("Advancing job")
+
+	return nil
+}
+
+// takeAdvance returns and clears the time to which the next run of the job has been advanced.
+func (j *job) takeAdvance() time.Time {
+	j.stateLock.Lock()
+	defer j.stateLock.Unlock()
+	to := j.advanceTo
+	j.advanceTo = time.Time{}
+
+	return to
+}
+
+// checkClassThreshold checks the number of jobs in a class, having just added
+// the given number, against its warn threshold.  It returns the number of jobs
+// and true if the additions took the class over its threshold, so that the
+// warning is raised once each time the threshold is crossed.
+// This requires jobsMutex to be held.
+func (s *Service) checkClassThreshold(class string, added int) (int, bool) {
+	threshold, exists := s.classWarnThresholds[strings.ToLower(class)]
+	if !exists {
+		return 0, false
+	}
+
+	count := 0
+	for _, job := range s.jobs {
+		if strings.EqualFold(job.class, class) {
+			count++
+		}
+	}
+
+	return count, count > threshold && count-added <= threshold
+}
+
+// warnClassThreshold warns that the number of jobs in a class has exceeded its threshold.
+func (s *Service) warnClassThreshold(class string, count int) {
+	log.Warn().Str("class", class).Int("jobs", count).Int("threshold", s.classWarnThresholds[strings.ToLower(class)]).Msg("Number of jobs in class exceeds warn threshold; jobs may be leaking")
+	classThresholdExceeded(class)
+}
+
+// removeJob removes a job from the jobs list, if it is present under its current name.
+func (s *Service) removeJob(job *job) {
+	s.jobsMutex.Lock()
+	name := job.name.Load()
+	if s.jobs[name] == job {
+		delete(s.jobs, name)
+	}
+	s.jobsMutex.Unlock()
+}
+
 // CancelJob removes a named job.
 // If the job does not exist it will return an appropriate error.
 func (s *Service) CancelJob(_ context.Context, name string) error {
@@ -315,17 +862,85 @@ func (s *Service) CancelJob(_ context.Context, name string) error {
 	delete(s.jobs, name)
 	s.jobsMutex.Unlock()
 
+	s.cancelJob(job, scheduler.ErrJobCancelledByUser)
+
+	return nil
+}
+
+// cancelJob cancels a job that has been removed from the jobs list, cancelling
+// its context with the given cause.
+func (*Service) cancelJob(job *job, cause error) {
 	job.stateLock.Lock()
+	defer job.stateLock.Unlock()
 	if job.finalised.Load() {
 		// Already marked to be cancelled.
-		job.stateLock.Unlock()
-		return nil
+		return
 	}
 	job.finalised.Store(true)
+	job.cancelCause(cause)
 	job.cancelCh <- struct{}{}
-	job.stateLock.Unlock()
+}
 
-	return nil
+// Stop cancels all jobs that are not running, including pinned jobs, and prevents
+// further jobs from being scheduled or run.  It then waits for running jobs to
+// finish, up to the grace period or until the context is done.
+// It returns a summary of the jobs cancelled, drained and abandoned.
+func (s *Service) Stop(ctx context.Context, grace time.Duration) *scheduler.StopSummary {
+	s.jobsMutex.Lock()
+	s.stopped.Store(true)
+	jobs := make([]*job, 0, len(s.jobs))
+	for name, job := range s.jobs {
+		jobs = append(jobs, job)
+		delete(s.jobs, name)
+	}
+	s.jobsMutex.Unlock()
+
+	summary := &scheduler.StopSummary{}
+	for _, job := range jobs {
+		job.stateLock.Lock()
+		if !job.finalised.Load() {
+			job.finalised.Store(true)
+			job.cancelCause(scheduler.ErrSchedulerShutdown)
+			job.cancelCh <- struct{}{}
+			if !job.active.Load() {
+				summary.Cancelled++
+			}
+		}
+		job.stateLock.Unlock()
+	}
+	// Running one-off jobs are no longer in the jobs list, so cancel runs in progress directly.
+	s.cancellableRuns.Range(func(key any, _ any) bool {
+		key.(*job).cancelCause(scheduler.ErrSchedulerShutdown)
+		return true
+	})
+
+	running := int(s.running.Load())
+	log.Trace().Int("cancelled", summary.Cancelled).Int("running", running).Msg("Scheduler stopped; waiting for running jobs")
+	deadline := time.NewTimer(grace)
+	defer deadline.Stop()
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for waiting := true; waiting && s.running.Load() > 0; {
+		select {
+		case <-ctx.Done():
+			waiting = false
+		case <-deadline.C:
+			waiting = false
+		case <-ticker.C:
+		}
+	}
+
+	summary.Abandoned = int(s.running.Load())
+	if running > summary.Abandoned {
+		summary.Drained = running - summary.Abandoned
+	}
+
+	if s.historyStore != nil {
+		// Save the history now, so that it includes the runs that drained.
+		s.saveHistory(ctx)
+	}
+
+	return summary
 }
 
 // CancelJobIfExists cancels a job that may or may not exist.
@@ -335,13 +950,17 @@ func (s *Service) CancelJobIfExists(ctx context.Context, name string) {
 	s.CancelJob(ctx, name)
 }
 
-// CancelJobs cancels all jobs with the given prefix.
+// CancelJobs cancels all jobs with the given prefix, other than pinned jobs.
 // If the prefix matches a period job then all future instances are cancelled.
 func (s *Service) CancelJobs(ctx context.Context, prefix string) {
 	names := make([]string, 0)
 	s.jobsMutex.Lock()
-	for name := range s.jobs {
+	for name, job := range s.jobs {
 		if strings.HasPrefix(name, prefix) {
+			if job.pinned {
+				log.Debug().Str("job", name).Str("prefix", prefix).Msg("Pinned job not cancelled by prefix")
+				continue
+			}
 			names = append(names, name)
 		}
 	}
@@ -353,16 +972,497 @@ func (s *Service) CancelJobs(ctx context.Context, prefix string) {
 	}
 }
 
+// CancelJobsInClass cancels all jobs in the given class, other than pinned jobs.
+// If the class contains periodic jobs then all future instances are cancelled.
+func (s *Service) CancelJobsInClass(ctx context.Context, class string) {
+	names := make([]string, 0)
+	s.jobsMutex.RLock()
+	for name, job := range s.jobs {
+		if job.class == class {
+			if job.pinned {
+				log.Debug().Str("job", name).Str("class", class).Msg("Pinned job not cancelled by class")
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	s.jobsMutex.RUnlock()
+
+	for _, name := range names {
+		// It is possible that the job has been removed whist we were iterating, so use the non-erroring version of cancel.
+		s.CancelJobIfExists(ctx, name)
+	}
+}
+
+// Jobs returns information about all jobs, ordered by name.
+// The information is a copy taken at the time of the call, so is not altered by
+// subsequent runs or changes to the jobs.
+func (s *Service) Jobs(_ context.Context) []scheduler.JobInfo {
+	s.jobsMutex.RLock()
+	infos := make([]scheduler.JobInfo, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		info := scheduler.JobInfo{
+			Name:     job.name.Load(),
+			Class:    job.class,
+			Periodic: job.periodic,
+			Pinned:   job.pinned,
+			Timeout:  job.timeout,
+			Active:   job.active.Load(),
+			NextRun:  job.nextRun.Load(),
+			LastErr:  job.lastErr.Load(),
+		}
+		if started, running := s.runStarts.Load(job); running {
+			info.RunStarted = started.(time.Time)
+		}
+		if timing := job.lastRunTiming.Load(); timing != nil {
+			info.LastRun = timing.started
+			info.LastRunDuration = timing.duration
+		}
+		infos = append(infos, info)
+	}
+	s.jobsMutex.RUnlock()
+
+	sort.Slice(infos, func(i int, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return infos
+}
+
+// IsIdle returns true if no jobs are active and none are due to run within the given duration.
+func (s *Service) IsIdle(_ context.Context, within time.Duration) bool {
+	if s.running.Load() > 0 {
+		return false
+	}
+	horizon := s.now().Add(within)
+
+	s.jobsMutex.RLock()
+	defer s.jobsMutex.RUnlock()
+	for _, job := range s.jobs {
+		if job.active.Load() {
+			return false
+		}
+		if job.nextRun.Load().Before(horizon) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// JobState returns the state of the named job.
+// A periodic job is running whilst an instance of it is running.  One-off jobs are
+// removed from the jobs list when they start, so a running one-off job is found
+// through its run; once it has finished there is no information about it.
+func (s *Service) JobState(_ context.Context, name string) (scheduler.State, error) {
+	s.jobsMutex.RLock()
+	found, exists := s.jobs[name]
+	s.jobsMutex.RUnlock()
+	if !exists {
+		s.runStarts.Range(func(key any, _ any) bool {
+			if key.(*job).name.Load() == name {
+				found = key.(*job)
+				return false
+			}
+			return true
+		})
+		if found == nil {
+			return 0, scheduler.ErrNoSuchJob
+		}
+	}
+
+	// Taken so that the state is consistent with runJob and finaliseJob.
+	found.stateLock.Lock()
+	defer found.stateLock.Unlock()
+	switch {
+	case found.active.Load():
+		return scheduler.StateRunning, nil
+	case found.finalised.Load():
+		return scheduler.StateFinalised, nil
+	default:
+		return scheduler.StateScheduled, nil
+	}
+}
+
+// LongestRunningJob returns the name of the active job that has been running
+// longest, and the time for which it has been running.
+// It returns false if no job is active.
+func (s *Service) LongestRunningJob(_ context.Context) (string, time.Duration, bool) {
+	var longest *job
+	var started time.Time
+	s.runStarts.Range(func(key any, value any) bool {
+		runStart := value.(time.Time)
+		if longest == nil || runStart.Before(started) {
+			longest = key.(*job)
+			started = runStart
+		}
+		return true
+	})
+	if longest == nil {
+		return "", 0, false
+	}
+
+	return longest.name.Load(), time.Since(started), true
+}
+
+// Snapshot returns a point-in-time view of the scheduler.
+func (s *Service) Snapshot(ctx context.Context) *scheduler.Snapshot {
+	// Jobs are obtained first, so that no run they report started after the snapshot time.
+	jobs := s.Jobs(ctx)
+
+	return &scheduler.Snapshot{
+		Time:       time.Now(),
+		Jobs:       jobs,
+		DriftStats: s.DriftStats(ctx),
+	}
+}
+
 // finaliseJob tidies up a job that is no longer in use.
 func finaliseJob(job *job) {
 	job.stateLock.Lock()
 	job.finalised.Store(true)
+	if job.pendingTrigger != nil {
+		job.pendingTrigger.Stop()
+		job.pendingTrigger = nil
+	}
 
 	// Close the channels for the job to ensure that nothing is hanging on sending a message.
 	close(job.cancelCh)
 	close(job.runCh)
+	// Release the job's context; this has no effect if it was cancelled with a cause.
+	job.cancelCause(scheduler.ErrJobFinalised)
 
 	job.stateLock.Unlock()
+
+	runFinalizer(job)
+}
+
+// runFinalizer calls the job's finalizer, if it has one and it has yet to be called.
+func runFinalizer(job *job) {
+	if job.finalizer == nil {
+		return
+	}
+	job.finalizerOnce.Do(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().Str("job", job.name.Load()).Interface("panic", r).Msg("Job finalizer panicked")
+			}
+		}()
+		job.finalizer()
+	})
+}
+
+// runJobFunc runs the function for a job, recording details of the run.
+// It returns the error returned by the job.
+func (s *Service) runJobFunc(ctx context.Context,
+	job *job,
+	scheduled time.Time,
+	trigger string,
+	jobFunc scheduler.JobFunc,
+	data interface{},
+) error {
+	if trigger != "replay" {
+		ctx = job.runContext(ctx)
+	}
+	if trigger != "replay" && !isLeader(ctx, job) {
+		log.Trace().Str("job", job.name.Load()).Msg("Not leader; run skipped")
+		jobResult(job.class, "skipped")
+		return nil
+	}
+
+	record := &scheduler.RunRecord{
+		Name:      job.name.Load(),
+		Class:     job.class,
+		Trigger:   trigger,
+		Scheduled: scheduled,
+		Started:   time.Now(),
+	}
+	if s.running.Inc() == 1 {
+		s.stateTransitions.update()
+	}
+	defer func() {
+		if s.running.Dec() == 0 {
+			s.stateTransitions.update()
+		}
+	}()
+	s.runStarts.Store(job, record.Started)
+	defer s.runStarts.Delete(job)
+	if job.cancelRunning {
+		s.cancellableRuns.Store(job, struct{}{})
+		defer s.cancellableRuns.Delete(job)
+	}
+	// Checked after incrementing running, so that Stop either sees this run or prevents it.
+	if s.stopped.Load() {
+		log.Trace().Str("job", job.name.Load()).Msg("Scheduler stopped; run skipped")
+		jobResult(job.class, "skipped")
+		return scheduler.ErrSchedulerStopped
+	}
+	if trigger != "replay" {
+		snapshot := data
+		if job.snapshotData != nil {
+			snapshot = job.snapshotData(data)
+		}
+		job.lastRun.Store(&replay{jobFunc: jobFunc, data: snapshot})
+	}
+	panicked, err := callJobWithTimeout(ctx, job, jobFunc, data)
+	record.Err = err
+	record.Finished = time.Now()
+	job.lastRunTiming.Store(&runTiming{started: record.Started, duration: record.Finished.Sub(record.Started)})
+	switch {
+	case errors.Is(record.Err, scheduler.ErrJobTimedOut):
+		log.Warn().Str("job", job.name.Load()).Dur("timeout", job.timeout).Msg("Job timed out")
+		jobTimedOut(job.class)
+		jobFailed(job.class)
+		jobResult(job.class, "error")
+	case panicked:
+		log.Error().Str("job", job.name.Load()).Err(record.Err).Msg("Job panicked")
+		jobFailed(job.class)
+		jobResult(job.class, "panic")
+	case record.Err != nil:
+		log.Debug().Str("job", job.name.Load()).Err(record.Err).Msg("Job returned error")
+		jobFailed(job.class)
+		jobResult(job.class, "error")
+	default:
+		jobResult(job.class, "success")
+		job.completedRun.Store(true)
+	}
+	job.lastErr.Store(record.Err)
+	s.history.add(record)
+	s.markHistoryChanged()
+
+	return record.Err
+}
+
+// jobLeaderCheck returns the leader check for a job with the given options.
+func (s *Service) jobLeaderCheck(options *scheduler.JobOptions) func(context.Context) (bool, error) {
+	if options.LeaderCheck != nil {
+		return options.LeaderCheck
+	}
+
+	return s.leaderCheck
+}
+
+// isLeader returns true if this instance should run the job.
+func isLeader(ctx context.Context, job *job) bool {
+	if job.leaderCheck == nil {
+		return true
+	}
+
+	leader, err := job.leaderCheck(ctx)
+	if err != nil {
+		log.Warn().Str("job", job.name.Load()).Err(err).Msg("Failed to check leadership; run skipped")
+		return false
+	}
+
+	return leader
+}
+
+// callJobWithTimeout calls the job function and then, if it succeeds, the job's
+// completion check.  If the job has a timeout and it expires first, the context
+// of the calls is cancelled and ErrJobTimedOut is returned without waiting for
+// them to return.
+func callJobWithTimeout(ctx context.Context,
+	job *job,
+	jobFunc scheduler.JobFunc,
+	data interface{},
+) (
+	bool,
+	error,
+) {
+	if job.timeout <= 0 {
+		return callJob(ctx, job, jobFunc, data)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, job.timeout)
+	defer cancel()
+	type result struct {
+		panicked bool
+		err      error
+	}
+	// Buffered, so that an abandoned call can send its result and exit.
+	done := make(chan *result, 1)
+	go func() {
+		panicked, err := callJob(ctx, job, jobFunc, data)
+		done <- &result{panicked: panicked, err: err}
+	}()
+
+	timer := time.NewTimer(job.timeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res.panicked, res.err
+	case <-timer.C:
+		return false, fmt.Errorf("%w after %v", scheduler.ErrJobTimedOut, job.timeout)
+	}
+}
+
+// callJob calls the job function and then, if it succeeds, the job's completion check.
+func callJob(ctx context.Context,
+	job *job,
+	jobFunc scheduler.JobFunc,
+	data interface{},
+) (
+	bool,
+	error,
+) {
+	panicked, err := callJobFunc(ctx, jobFunc, data)
+	if !panicked && err == nil && job.confirmCompletion != nil {
+		panicked, err = callJobFunc(ctx, func(ctx context.Context, _ interface{}) error {
+			return job.confirmCompletion(ctx)
+		}, nil)
+		if err != nil {
+			err = fmt.Errorf("%w: %w", scheduler.ErrCompletionNotConfirmed, err)
+		}
+	}
+
+	return panicked, err
+}
+
+// callJobFunc calls the job function, recovering from any panic.
+// If the function panics the returned error wraps scheduler.ErrJobPanicked.
+func callJobFunc(ctx context.Context,
+	jobFunc scheduler.JobFunc,
+	data interface{},
+) (
+	panicked bool,
+	err error,
+) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			err = fmt.Errorf("%w: %v", scheduler.ErrJobPanicked, r)
+		}
+	}()
+
+	return false, jobFunc(ctx, data)
+}
+
+// LastError returns the error returned by the most recent run of the named job,
+// or nil if the job succeeded or has yet to run.
+// One-off jobs are removed once they have run, so for these the run history is consulted.
+func (s *Service) LastError(_ context.Context, name string) (error, error) {
+	s.jobsMutex.RLock()
+	job, exists := s.jobs[name]
+	s.jobsMutex.RUnlock()
+	if exists {
+		return job.lastErr.Load(), nil
+	}
+
+	s.history.mu.RLock()
+	defer s.history.mu.RUnlock()
+	records, exists := s.history.records[name]
+	if !exists || len(records) == 0 {
+		return nil, scheduler.ErrNoSuchJob
+	}
+
+	return records[len(records)-1].Err, nil
+}
+
+// HasCompletedRun returns true if the named job has completed at least one successful run.
+// One-off jobs are removed once they have run, so for these the run history is consulted.
+func (s *Service) HasCompletedRun(_ context.Context, name string) (bool, error) {
+	s.jobsMutex.RLock()
+	job, exists := s.jobs[name]
+	s.jobsMutex.RUnlock()
+	if exists {
+		return job.completedRun.Load(), nil
+	}
+
+	s.history.mu.RLock()
+	defer s.history.mu.RUnlock()
+	records, exists := s.history.records[name]
+	if !exists || len(records) == 0 {
+		return false, scheduler.ErrNoSuchJob
+	}
+	for _, record := range records {
+		if record.Err == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ReplayLastRun runs the named job immediately with the data used by its most recent run,
+// independent of its schedule, and returns the error returned by the job.
+// The replay is recorded in the run history with the trigger "replay".
+// Replays are explicit requests, so are not subject to the job's leader check.
+func (s *Service) ReplayLastRun(ctx context.Context, name string) error {
+	s.jobsMutex.RLock()
+	job, exists := s.jobs[name]
+	s.jobsMutex.RUnlock()
+	if !exists {
+		return scheduler.ErrNoSuchJob
+	}
+
+	lastRun := job.lastRun.Load()
+	if lastRun == nil {
+		return scheduler.ErrNoPriorRun
+	}
+
+	job.stateLock.Lock()
+	if job.active.Load() {
+		job.stateLock.Unlock()
+		return scheduler.ErrJobRunning
+	}
+	if job.finalised.Load() {
+		job.stateLock.Unlock()
+		return scheduler.ErrJobFinalised
+	}
+	job.active.Store(true)
+	job.stateLock.Unlock()
+	defer job.active.Store(false)
+
+	// Copy the snapshot in turn, so that the replay cannot alter it.
+	data := lastRun.data
+	if job.snapshotData != nil {
+		data = job.snapshotData(data)
+	}
+
+	log.Trace().Str("job", name).Msg("Replaying last run")
+	jobStartedOnSignal(job.class)
+
+	return s.runJobFunc(ctx, job, time.Now(), "replay", lastRun.jobFunc, data)
+}
+
+// coalesceTrigger arranges for the job to run at the end of its coalescing window,
+// absorbing the trigger if a run is already pending.
+func (s *Service) coalesceTrigger(ctx context.Context, job *job) error {
+	job.stateLock.Lock()
+	defer job.stateLock.Unlock()
+	if job.finalised.Load() {
+		return scheduler.ErrJobFinalised
+	}
+	if job.pendingTrigger != nil {
+		log.Trace().Str("job", job.name.Load()).Msg("Run already pending; trigger coalesced")
+		jobTriggerCoalesced(job.class)
+		return nil
+	}
+	job.pendingTrigger = time.AfterFunc(job.triggerCoalesce, func() {
+		s.runCoalesced(ctx, job)
+	})
+
+	return nil
+}
+
+// runCoalesced runs a job at the end of its coalescing window.
+func (s *Service) runCoalesced(ctx context.Context, job *job) {
+	job.stateLock.Lock()
+	job.pendingTrigger = nil
+	job.stateLock.Unlock()
+
+	if !job.periodic {
+		// Because this job only runs once we remove it from the jobs list immediately.
+		s.removeJob(job)
+	}
+
+	err := s.runJob(ctx, job)
+	if errors.Is(err, scheduler.ErrJobRunning) {
+		// Defer the run rather than lose the trigger.
+		log.Trace().Str("job", job.name.Load()).Msg("Job running; coalesced run deferred")
+		//nolint
+		s.coalesceTrigger(ctx, job)
+	}
 }
 
 // runJob runs the given job.
@@ -15,7 +15,9 @@ package standard_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
 	"math/rand"
 	"sync"
@@ -48,6 +50,14 @@ func TestNew(t *testing.T) {
 				standard.WithLogLevel(zerolog.Disabled),
 			},
 		},
+		{
+			name: "ClassWarnThresholdZero",
+			options: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithClassWarnThreshold(map[string]int{"Test": 0}),
+			},
+			err: `problem with parameters: warn threshold for class "test" must be positive`,
+		},
 	}
 
 	for _, test := range tests {
@@ -70,8 +80,9 @@ func TestJob(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(20*time.Millisecond), runFunc, nil))
@@ -88,8 +99,9 @@ func TestJobExists(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(10*time.Second), runFunc, nil))
@@ -109,8 +121,9 @@ func TestCancelJob(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(100*time.Millisecond), runFunc, nil))
@@ -138,8 +151,9 @@ func TestCancelJobs(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job 1", time.Now().Add(100*time.Millisecond), runFunc, nil))
@@ -161,8 +175,9 @@ func TestCancelJobIfExists(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(100*time.Millisecond), runFunc, nil))
@@ -183,8 +198,9 @@ func TestCancelParentContext(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(100*time.Millisecond), runFunc, nil))
@@ -203,8 +219,9 @@ func TestRunJob(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(time.Second), runFunc, nil))
@@ -223,8 +240,9 @@ func TestRunJobIfExists(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(time.Second), runFunc, nil))
@@ -252,8 +270,9 @@ func TestPeriodicJob(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
@@ -283,8 +302,9 @@ func TestCancelPeriodicJob(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
@@ -307,8 +327,9 @@ func TestCancelPeriodicParentContext(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
@@ -324,6 +345,96 @@ func TestCancelPeriodicParentContext(t *testing.T) {
 	require.Len(t, s.ListJobs(ctx), 0)
 }
 
+func TestRenameJob(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	var run atomic.Int32
+	runFunc := func(ctx context.Context, data interface{}) error {
+		run.Add(1)
+		return nil
+	}
+
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		return time.Now().Add(100 * time.Millisecond), nil
+	}
+
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test periodic job", runtimeFunc, nil, runFunc, nil))
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test other job", runtimeFunc, nil, runFunc, nil))
+	time.Sleep(time.Duration(110) * time.Millisecond)
+	assert.Equal(t, int32(2), run.Load())
+
+	require.ErrorIs(t, s.RenameJob(ctx, "Unknown job", "Test renamed job"), scheduler.ErrNoSuchJob)
+	require.ErrorIs(t, s.RenameJob(ctx, "Test periodic job", "Test other job"), scheduler.ErrJobAlreadyExists)
+	require.NoError(t, s.CancelJob(ctx, "Test other job"))
+
+	require.NoError(t, s.RenameJob(ctx, "Test periodic job", "Test renamed job"))
+	require.False(t, s.JobExists(ctx, "Test periodic job"))
+	require.True(t, s.JobExists(ctx, "Test renamed job"))
+
+	// Job continues to fire on its existing schedule.
+	time.Sleep(time.Duration(100) * time.Millisecond)
+	assert.Equal(t, int32(3), run.Load())
+	require.NoError(t, s.RunJob(ctx, "Test renamed job"))
+	time.Sleep(time.Duration(10) * time.Millisecond)
+	assert.Equal(t, int32(4), run.Load())
+
+	require.NoError(t, s.CancelJob(ctx, "Test renamed job"))
+	require.Len(t, s.ListJobs(ctx), 0)
+}
+
+func TestAdvanceNextRun(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	ran := make(chan time.Time, 4)
+	runFunc := func(ctx context.Context, data interface{}) error {
+		ran <- time.Now()
+		return nil
+	}
+
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		return time.Now().Add(time.Hour), nil
+	}
+
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test periodic job", runtimeFunc, nil, runFunc, nil))
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test one-off job", time.Now().Add(time.Hour), runFunc, nil))
+
+	require.ErrorIs(t, s.AdvanceNextRun(ctx, "Unknown job", time.Now()), scheduler.ErrNoSuchJob)
+	require.ErrorIs(t, s.AdvanceNextRun(ctx, "Test one-off job", time.Now()), scheduler.ErrJobNotPeriodic)
+
+	// A later time has no effect.
+	require.NoError(t, s.AdvanceNextRun(ctx, "Test periodic job", time.Now().Add(2*time.Hour)))
+
+	// The next run fires at the advanced time.
+	advanced := time.Now().Add(50 * time.Millisecond)
+	require.NoError(t, s.AdvanceNextRun(ctx, "Test periodic job", advanced))
+	select {
+	case runAt := <-ran:
+		require.False(t, runAt.Before(advanced))
+		require.WithinDuration(t, advanced, runAt, 40*time.Millisecond)
+	case <-time.After(time.Second):
+		require.Fail(t, "advanced job did not run")
+	}
+
+	// The job then returns to its usual schedule.
+	time.Sleep(100 * time.Millisecond)
+	require.Len(t, ran, 0)
+	jobs := s.Jobs(ctx)
+	for _, job := range jobs {
+		if job.Name == "Test periodic job" {
+			require.WithinDuration(t, time.Now().Add(time.Hour), job.NextRun, time.Second)
+		}
+	}
+
+	require.NoError(t, s.CancelJob(ctx, "Test periodic job"))
+	require.NoError(t, s.CancelJob(ctx, "Test one-off job"))
+}
+
 func TestLimitedPeriodicJob(t *testing.T) {
 	ctx := context.Background()
 	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
@@ -331,8 +442,9 @@ func TestLimitedPeriodicJob(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
@@ -357,8 +469,9 @@ func TestBadPeriodicJob(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
@@ -383,8 +496,9 @@ func TestDuplicateJobName(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
@@ -414,8 +528,9 @@ func TestBadJobs(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
@@ -442,8 +557,9 @@ func TestManyJobs(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := uint32(0)
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		atomic.AddUint32(&run, 1)
+		return nil
 	}
 
 	runTime := time.Now().Add(200 * time.Millisecond)
@@ -477,8 +593,9 @@ func TestListJobs(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		run++
+		return nil
 	}
 
 	jobs := s.ListJobs(ctx)
@@ -512,9 +629,10 @@ func TestLongRunningPeriodicJob(t *testing.T) {
 
 	// Job takes 100 ms.
 	run := uint32(0)
-	jobFunc := func(ctx context.Context, data interface{}) {
+	jobFunc := func(ctx context.Context, data interface{}) error {
 		time.Sleep(100 * time.Millisecond)
 		atomic.AddUint32(&run, 1)
+		return nil
 	}
 
 	// Job runs every 50 ms.
@@ -543,9 +661,10 @@ func TestOverlappingJobs(t *testing.T) {
 
 	// Job takes 200ms.
 	run := uint32(0)
-	jobFunc := func(ctx context.Context, data interface{}) {
+	jobFunc := func(ctx context.Context, data interface{}) error {
 		time.Sleep(200 * time.Millisecond)
 		atomic.AddUint32(&run, 1)
+		return nil
 	}
 
 	now := time.Now()
@@ -569,8 +688,9 @@ func TestMulti(t *testing.T) {
 
 	// Create a job for the future.
 	run := uint32(0)
-	jobFunc := func(ctx context.Context, data interface{}) {
+	jobFunc := func(ctx context.Context, data interface{}) error {
 		atomic.AddUint32(&run, 1)
+		return nil
 	}
 	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(10*time.Second), jobFunc, nil))
 	require.Len(t, s.ListJobs(ctx), 1)
@@ -610,9 +730,10 @@ func TestCancelWhilstRunning(t *testing.T) {
 	require.NotNil(t, s)
 
 	run := 0
-	runFunc := func(ctx context.Context, data interface{}) {
+	runFunc := func(ctx context.Context, data interface{}) error {
 		time.Sleep(50 * time.Millisecond)
 		run++
+		return nil
 	}
 
 	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
@@ -636,3 +757,1316 @@ func TestCancelWhilstRunning(t *testing.T) {
 	time.Sleep(time.Duration(120) * time.Millisecond)
 	assert.Equal(t, 1, run)
 }
+
+func TestCancelCause(t *testing.T) {
+	ctx := context.Background()
+
+	// runtimeFunc runs the job immediately, and subsequently in the distant future.
+	var scheduled sync.Map
+	runtimeFunc := func(_ context.Context, data interface{}) (time.Time, error) {
+		if _, exists := scheduled.LoadOrStore(data, true); exists {
+			return time.Now().Add(time.Hour), nil
+		}
+		return time.Now(), nil
+	}
+
+	tests := []struct {
+		name   string
+		opts   []scheduler.JobOption
+		cancel func(s *standard.Service)
+		cause  error
+	}{
+		{
+			name: "User",
+			opts: []scheduler.JobOption{scheduler.WithCancelRunning(true)},
+			cancel: func(s *standard.Service) {
+				require.NoError(t, s.CancelJob(ctx, "Test job"))
+			},
+			cause: scheduler.ErrJobCancelledByUser,
+		},
+		{
+			name: "Class",
+			opts: []scheduler.JobOption{scheduler.WithCancelRunning(true)},
+			cancel: func(s *standard.Service) {
+				s.CancelJobsInClass(ctx, "Test")
+			},
+			cause: scheduler.ErrJobCancelledByUser,
+		},
+		{
+			name: "Shutdown",
+			opts: []scheduler.JobOption{scheduler.WithCancelRunning(true)},
+			cancel: func(s *standard.Service) {
+				s.Stop(ctx, 0)
+			},
+			cause: scheduler.ErrSchedulerShutdown,
+		},
+		{
+			name: "Superseded",
+			opts: []scheduler.JobOption{scheduler.WithCancelRunning(true)},
+			cancel: func(s *standard.Service) {
+				require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test job", runtimeFunc, "Superseding",
+					func(_ context.Context, _ interface{}) error { return nil },
+					nil,
+					scheduler.WithSupersede(true),
+				))
+				require.True(t, s.JobExists(ctx, "Test job"))
+			},
+			cause: scheduler.ErrSuperseded,
+		},
+		{
+			name: "NotCancelRunning",
+			cancel: func(s *standard.Service) {
+				require.NoError(t, s.CancelJob(ctx, "Test job"))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+			require.NoError(t, err)
+
+			started := make(chan struct{})
+			causes := make(chan error, 1)
+			var once sync.Once
+			jobFunc := func(ctx context.Context, _ interface{}) error {
+				once.Do(func() {
+					close(started)
+					select {
+					case <-ctx.Done():
+						causes <- context.Cause(ctx)
+					case <-time.After(100 * time.Millisecond):
+						causes <- nil
+					}
+				})
+				return nil
+			}
+			require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test job", runtimeFunc, test.name, jobFunc, nil, test.opts...))
+			<-started
+			test.cancel(s)
+
+			cause := <-causes
+			s.Stop(ctx, 0)
+			if test.cause == nil {
+				require.NoError(t, cause)
+			} else {
+				require.ErrorIs(t, cause, test.cause)
+			}
+		})
+	}
+}
+
+func TestSupersede(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+
+	var runs atomic.Int32
+	runFunc := func(_ context.Context, _ interface{}) error {
+		runs.Add(1)
+		return nil
+	}
+
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(50*time.Millisecond), runFunc, nil))
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(50*time.Millisecond), runFunc, nil, scheduler.WithSupersede(true)))
+	require.Len(t, s.ListJobs(ctx), 1)
+
+	// Only the superseding job runs.
+	time.Sleep(150 * time.Millisecond)
+	require.Equal(t, int32(1), runs.Load())
+	require.Len(t, s.ListJobs(ctx), 0)
+}
+
+func TestLastError(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	jobErr := errors.New("job failed")
+	failFunc := func(ctx context.Context, data interface{}) error {
+		return jobErr
+	}
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		return time.Now().Add(time.Hour), nil
+	}
+
+	// Unknown job.
+	_, err = s.LastError(ctx, "Unknown job")
+	require.EqualError(t, err, scheduler.ErrNoSuchJob.Error())
+
+	// Periodic job that has not yet run.
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test periodic job", runtimeFunc, nil, failFunc, nil))
+	lastErr, err := s.LastError(ctx, "Test periodic job")
+	require.NoError(t, err)
+	require.NoError(t, lastErr)
+
+	// Periodic job that has failed.
+	require.NoError(t, s.RunJob(ctx, "Test periodic job"))
+	time.Sleep(10 * time.Millisecond)
+	lastErr, err = s.LastError(ctx, "Test periodic job")
+	require.NoError(t, err)
+	require.Equal(t, jobErr, lastErr)
+
+	// One-off job that has failed, and so been removed.
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now(), failFunc, nil))
+	time.Sleep(10 * time.Millisecond)
+	require.False(t, s.JobExists(ctx, "Test job"))
+	lastErr, err = s.LastError(ctx, "Test job")
+	require.NoError(t, err)
+	require.Equal(t, jobErr, lastErr)
+}
+
+func TestFinalizer(t *testing.T) {
+	jobFunc := func(ctx context.Context, data interface{}) error {
+		return nil
+	}
+	panicFunc := func(ctx context.Context, data interface{}) error {
+		panic("job panicked")
+	}
+	hourFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		return time.Now().Add(time.Hour), nil
+	}
+	onceFunc := func() scheduler.RuntimeFunc {
+		var calls atomic.Int32
+		return func(ctx context.Context, data interface{}) (time.Time, error) {
+			if calls.Add(1) > 1 {
+				return time.Time{}, scheduler.ErrNoMoreInstances
+			}
+			return time.Now(), nil
+		}
+	}
+
+	tests := []struct {
+		name string
+		// schedule schedules the job, and terminate ends it.
+		schedule  func(ctx context.Context, s *standard.Service, opt scheduler.JobOption) error
+		terminate func(ctx context.Context, s *standard.Service, cancel context.CancelFunc)
+	}{
+		{
+			name: "OneOffTimer",
+			schedule: func(ctx context.Context, s *standard.Service, opt scheduler.JobOption) error {
+				return s.ScheduleJob(ctx, "Test", "Test job", time.Now(), jobFunc, nil, opt)
+			},
+		},
+		{
+			name: "OneOffPanic",
+			schedule: func(ctx context.Context, s *standard.Service, opt scheduler.JobOption) error {
+				return s.ScheduleJob(ctx, "Test", "Test job", time.Now(), panicFunc, nil, opt)
+			},
+		},
+		{
+			name: "OneOffRun",
+			schedule: func(ctx context.Context, s *standard.Service, opt scheduler.JobOption) error {
+				return s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(time.Hour), jobFunc, nil, opt)
+			},
+			terminate: func(ctx context.Context, s *standard.Service, _ context.CancelFunc) {
+				require.NoError(t, s.RunJob(ctx, "Test job"))
+			},
+		},
+		{
+			name: "OneOffCancel",
+			schedule: func(ctx context.Context, s *standard.Service, opt scheduler.JobOption) error {
+				return s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(time.Hour), jobFunc, nil, opt)
+			},
+			terminate: func(ctx context.Context, s *standard.Service, _ context.CancelFunc) {
+				require.NoError(t, s.CancelJob(ctx, "Test job"))
+			},
+		},
+		{
+			name: "OneOffParentContext",
+			schedule: func(ctx context.Context, s *standard.Service, opt scheduler.JobOption) error {
+				return s.ScheduleJob(ctx, "Test", "Test job", time.Now().Add(time.Hour), jobFunc, nil, opt)
+			},
+			terminate: func(_ context.Context, _ *standard.Service, cancel context.CancelFunc) {
+				cancel()
+			},
+		},
+		{
+			name: "PeriodicNoMoreInstances",
+			schedule: func(ctx context.Context, s *standard.Service, opt scheduler.JobOption) error {
+				return s.SchedulePeriodicJob(ctx, "Test", "Test job", onceFunc(), nil, jobFunc, nil, opt)
+			},
+		},
+		{
+			name: "PeriodicPanic",
+			schedule: func(ctx context.Context, s *standard.Service, opt scheduler.JobOption) error {
+				return s.SchedulePeriodicJob(ctx, "Test", "Test job", onceFunc(), nil, panicFunc, nil, opt)
+			},
+		},
+		{
+			name: "PeriodicCancel",
+			schedule: func(ctx context.Context, s *standard.Service, opt scheduler.JobOption) error {
+				return s.SchedulePeriodicJob(ctx, "Test", "Test job", hourFunc, nil, jobFunc, nil, opt)
+			},
+			terminate: func(ctx context.Context, s *standard.Service, _ context.CancelFunc) {
+				require.NoError(t, s.RunJob(ctx, "Test job"))
+				time.Sleep(10 * time.Millisecond)
+				require.NoError(t, s.CancelJob(ctx, "Test job"))
+			},
+		},
+		{
+			name: "PeriodicParentContext",
+			schedule: func(ctx context.Context, s *standard.Service, opt scheduler.JobOption) error {
+				return s.SchedulePeriodicJob(ctx, "Test", "Test job", hourFunc, nil, jobFunc, nil, opt)
+			},
+			terminate: func(_ context.Context, _ *standard.Service, cancel context.CancelFunc) {
+				cancel()
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+			require.NoError(t, err)
+
+			var finalized atomic.Int32
+			require.NoError(t, test.schedule(ctx, s, scheduler.WithFinalizer(func() {
+				finalized.Add(1)
+			})))
+			time.Sleep(10 * time.Millisecond)
+			if test.terminate != nil {
+				require.Equal(t, int32(0), finalized.Load())
+				test.terminate(ctx, s, cancel)
+				time.Sleep(10 * time.Millisecond)
+			}
+			require.Equal(t, int32(1), finalized.Load())
+			require.False(t, s.JobExists(ctx, "Test job"))
+
+			// Further attempts to end the job do not call the finalizer again.
+			require.ErrorIs(t, s.CancelJob(ctx, "Test job"), scheduler.ErrNoSuchJob)
+			cancel()
+			time.Sleep(10 * time.Millisecond)
+			require.Equal(t, int32(1), finalized.Load())
+		})
+	}
+}
+
+func TestFinalizerPanic(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+
+	var finalized atomic.Int32
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now(), func(ctx context.Context, data interface{}) error {
+		return nil
+	}, nil, scheduler.WithFinalizer(func() {
+		finalized.Add(1)
+		panic("finalizer panicked")
+	})))
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, int32(1), finalized.Load())
+
+	// The scheduler continues to operate.
+	var ran atomic.Bool
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Second job", time.Now(), func(ctx context.Context, data interface{}) error {
+		ran.Store(true)
+		return nil
+	}, nil))
+	time.Sleep(10 * time.Millisecond)
+	require.True(t, ran.Load())
+}
+
+func TestStop(t *testing.T) {
+	tests := []struct {
+		name      string
+		jobTime   time.Duration
+		grace     time.Duration
+		drained   int
+		abandoned int
+	}{
+		{
+			name:    "Drained",
+			jobTime: 100 * time.Millisecond,
+			grace:   time.Second,
+			drained: 1,
+		},
+		{
+			name:      "Abandoned",
+			jobTime:   time.Second,
+			grace:     50 * time.Millisecond,
+			abandoned: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+			require.NoError(t, err)
+
+			var completed atomic.Bool
+			slowFunc := func(ctx context.Context, data interface{}) error {
+				time.Sleep(test.jobTime)
+				completed.Store(true)
+				return nil
+			}
+			var runs atomic.Int32
+			jobFunc := func(ctx context.Context, data interface{}) error {
+				runs.Add(1)
+				return nil
+			}
+			runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+				return time.Now().Add(time.Hour), nil
+			}
+			var finalized atomic.Int32
+			finalizer := scheduler.WithFinalizer(func() {
+				finalized.Add(1)
+			})
+
+			require.NoError(t, s.ScheduleJob(ctx, "Test", "Slow job", time.Now(), slowFunc, nil))
+			require.NoError(t, s.ScheduleJob(ctx, "Test", "Pending job", time.Now().Add(time.Hour), jobFunc, nil, finalizer))
+			require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Pinned job", runtimeFunc, nil, jobFunc, nil, scheduler.WithPinned(true), finalizer))
+			time.Sleep(20 * time.Millisecond)
+
+			summary := s.Stop(ctx, test.grace)
+			require.Equal(t, &scheduler.StopSummary{
+				Cancelled: 2,
+				Drained:   test.drained,
+				Abandoned: test.abandoned,
+			}, summary)
+			require.Equal(t, test.drained == 1, completed.Load())
+			require.Empty(t, s.ListJobs(ctx))
+			time.Sleep(10 * time.Millisecond)
+			require.Equal(t, int32(2), finalized.Load())
+			require.Equal(t, int32(0), runs.Load())
+
+			// No further jobs can be scheduled.
+			require.ErrorIs(t, s.ScheduleJob(ctx, "Test", "New job", time.Now(), jobFunc, nil), scheduler.ErrSchedulerStopped)
+			require.ErrorIs(t, s.SchedulePeriodicJob(ctx, "Test", "New periodic job", runtimeFunc, nil, jobFunc, nil), scheduler.ErrSchedulerStopped)
+		})
+	}
+}
+
+func TestHasCompletedRun(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	var fail atomic.Bool
+	fail.Store(true)
+	jobErr := errors.New("job failed")
+	jobFunc := func(ctx context.Context, data interface{}) error {
+		if fail.Load() {
+			return jobErr
+		}
+		return nil
+	}
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		return time.Now().Add(time.Hour), nil
+	}
+
+	// Unknown job.
+	_, err = s.HasCompletedRun(ctx, "Unknown job")
+	require.EqualError(t, err, scheduler.ErrNoSuchJob.Error())
+
+	// Periodic job that has not yet run.
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test periodic job", runtimeFunc, nil, jobFunc, nil))
+	completed, err := s.HasCompletedRun(ctx, "Test periodic job")
+	require.NoError(t, err)
+	require.False(t, completed)
+
+	// Periodic job that has failed.
+	require.NoError(t, s.RunJob(ctx, "Test periodic job"))
+	time.Sleep(10 * time.Millisecond)
+	completed, err = s.HasCompletedRun(ctx, "Test periodic job")
+	require.NoError(t, err)
+	require.False(t, completed)
+
+	// Periodic job that has succeeded.
+	fail.Store(false)
+	require.NoError(t, s.RunJob(ctx, "Test periodic job"))
+	time.Sleep(10 * time.Millisecond)
+	completed, err = s.HasCompletedRun(ctx, "Test periodic job")
+	require.NoError(t, err)
+	require.True(t, completed)
+
+	// Periodic job that has failed after succeeding.
+	fail.Store(true)
+	require.NoError(t, s.RunJob(ctx, "Test periodic job"))
+	time.Sleep(10 * time.Millisecond)
+	completed, err = s.HasCompletedRun(ctx, "Test periodic job")
+	require.NoError(t, err)
+	require.True(t, completed)
+
+	// One-off job that has succeeded, and so been removed.
+	fail.Store(false)
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job", time.Now(), jobFunc, nil))
+	time.Sleep(10 * time.Millisecond)
+	require.False(t, s.JobExists(ctx, "Test job"))
+	completed, err = s.HasCompletedRun(ctx, "Test job")
+	require.NoError(t, err)
+	require.True(t, completed)
+}
+
+func TestJobs(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	runFunc := func(ctx context.Context, data interface{}) error {
+		return nil
+	}
+	runtime := time.Now().Add(time.Hour)
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		return runtime, nil
+	}
+
+	require.Len(t, s.Jobs(ctx), 0)
+
+	require.NoError(t, s.ScheduleJob(ctx, "One-off", "Test job 2", runtime, runFunc, nil))
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Periodic", "Test job 1", runtimeFunc, nil, runFunc, nil))
+	time.Sleep(10 * time.Millisecond)
+
+	jobs := s.Jobs(ctx)
+	require.Len(t, jobs, 2)
+	require.Equal(t, scheduler.JobInfo{
+		Name:     "Test job 1",
+		Class:    "Periodic",
+		Periodic: true,
+		NextRun:  runtime,
+	}, jobs[0])
+	require.Equal(t, scheduler.JobInfo{
+		Name:    "Test job 2",
+		Class:   "One-off",
+		NextRun: runtime,
+	}, jobs[1])
+
+	snapshot := s.Snapshot(ctx)
+	require.Equal(t, jobs, snapshot.Jobs)
+	require.NotNil(t, snapshot.DriftStats)
+}
+
+func TestLongestRunningJob(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	_, _, ok := s.LongestRunningJob(ctx)
+	require.False(t, ok)
+
+	release := make(chan struct{})
+	runtime := time.Now().Add(time.Hour)
+	for _, name := range []string{"Test job 1", "Test job 2", "Test job 3"} {
+		started := make(chan struct{})
+		require.NoError(t, s.ScheduleJob(ctx, "Test", name, runtime, func(_ context.Context, _ interface{}) error {
+			close(started)
+			<-release
+			return nil
+		}, nil))
+		require.NoError(t, s.RunJob(ctx, name))
+		<-started
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	name, duration, ok := s.LongestRunningJob(ctx)
+	require.True(t, ok)
+	require.Equal(t, "Test job 1", name)
+	require.GreaterOrEqual(t, duration, 20*time.Millisecond)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		_, _, ok := s.LongestRunningJob(ctx)
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestJobState(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	_, err = s.JobState(ctx, "Unknown job")
+	require.ErrorIs(t, err, scheduler.ErrNoSuchJob)
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	runFunc := func(_ context.Context, _ interface{}) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+	runtime := time.Now().Add(time.Hour)
+	runtimeFunc := func(_ context.Context, _ interface{}) (time.Time, error) {
+		return runtime, nil
+	}
+	require.NoError(t, s.ScheduleJob(ctx, "One-off", "One-off job", runtime, runFunc, nil))
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Periodic", "Periodic job", runtimeFunc, nil, runFunc, nil))
+	time.Sleep(10 * time.Millisecond)
+
+	for _, name := range []string{"One-off job", "Periodic job"} {
+		state, err := s.JobState(ctx, name)
+		require.NoError(t, err)
+		require.Equal(t, scheduler.StateScheduled, state)
+	}
+
+	// Running jobs are reported as such, including one-off jobs that have left the jobs list.
+	for _, name := range []string{"One-off job", "Periodic job"} {
+		require.NoError(t, s.RunJob(ctx, name))
+		<-started
+		state, err := s.JobState(ctx, name)
+		require.NoError(t, err)
+		require.Equal(t, scheduler.StateRunning, state)
+	}
+	close(release)
+
+	// Once run the one-off job is gone, and the periodic job is scheduled again.
+	require.Eventually(t, func() bool {
+		_, err := s.JobState(ctx, "One-off job")
+		return errors.Is(err, scheduler.ErrNoSuchJob)
+	}, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		state, err := s.JobState(ctx, "Periodic job")
+		return err == nil && state == scheduler.StateScheduled
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestJobsLastRun(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	ran := make(chan struct{}, 1)
+	runFunc := func(_ context.Context, _ interface{}) error {
+		time.Sleep(10 * time.Millisecond)
+		ran <- struct{}{}
+		return nil
+	}
+	runtime := time.Now().Add(time.Hour)
+	runtimeFunc := func(_ context.Context, _ interface{}) (time.Time, error) {
+		return runtime, nil
+	}
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Periodic", "Test job 2", runtimeFunc, nil, runFunc, nil))
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Periodic", "Test job 1", runtimeFunc, nil, runFunc, nil))
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, []string{"Test job 1", "Test job 2"}, s.ListJobs(ctx))
+
+	jobs := s.Jobs(ctx)
+	require.True(t, jobs[0].LastRun.IsZero())
+	require.Zero(t, jobs[0].LastRunDuration)
+
+	started := time.Now()
+	require.NoError(t, s.RunJob(ctx, "Test job 1"))
+	<-ran
+	var info scheduler.JobInfo
+	require.Eventually(t, func() bool {
+		info = s.Jobs(ctx)[0]
+		return !info.LastRun.IsZero()
+	}, time.Second, time.Millisecond)
+	require.False(t, info.LastRun.Before(started))
+	require.GreaterOrEqual(t, info.LastRunDuration, 10*time.Millisecond)
+
+	// Information already obtained is not altered by later runs.
+	lastRun := info.LastRun
+	require.NoError(t, s.RunJob(ctx, "Test job 1"))
+	<-ran
+	require.Eventually(t, func() bool {
+		return s.Jobs(ctx)[0].LastRun.After(lastRun)
+	}, time.Second, time.Millisecond)
+	require.Equal(t, lastRun, info.LastRun)
+	require.True(t, jobs[0].LastRun.IsZero())
+}
+
+func TestMarshalJobs(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+
+	runFunc := func(ctx context.Context, data interface{}) error {
+		return nil
+	}
+	runtime := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		return runtime, nil
+	}
+
+	data, err := s.MarshalJobs(ctx)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"jobs":[]`)
+
+	require.NoError(t, s.ScheduleJob(ctx, "One-off", "Test job 2", runtime, runFunc, nil, scheduler.WithPinned(true)))
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Periodic", "Test job 1", runtimeFunc, nil, runFunc, nil))
+	time.Sleep(10 * time.Millisecond)
+
+	data, err = s.MarshalJobs(ctx)
+	require.NoError(t, err)
+
+	type jobJSON struct {
+		Name     string   `json:"name"`
+		Class    string   `json:"class"`
+		Periodic bool     `json:"periodic"`
+		Pinned   bool     `json:"pinned"`
+		Active   bool     `json:"active"`
+		Runtime  string   `json:"runtime"`
+		Tags     []string `json:"tags"`
+		NextRun  string   `json:"next_run"`
+	}
+	var res struct {
+		Time string     `json:"time"`
+		Jobs []*jobJSON `json:"jobs"`
+	}
+	require.NoError(t, json.Unmarshal(data, &res))
+	_, err = time.Parse(time.RFC3339Nano, res.Time)
+	require.NoError(t, err)
+	require.Equal(t, []*jobJSON{
+		{
+			Name:     "Test job 1",
+			Class:    "Periodic",
+			Periodic: true,
+			Tags:     []string{},
+			NextRun:  "2030-01-02T03:04:05Z",
+		},
+		{
+			Name:    "Test job 2",
+			Class:   "One-off",
+			Pinned:  true,
+			Tags:    []string{},
+			NextRun: "2030-01-02T03:04:05Z",
+		},
+	}, res.Jobs)
+
+	// Fields are in a stable order.
+	require.Contains(t, string(data), `{"name":"Test job 1","class":"Periodic","periodic":true,"pinned":false,"active":false,"tags":[],"next_run":"2030-01-02T03:04:05Z"}`)
+
+	// The runtime of a job is that of its run in progress, or else of its last run.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Periodic", "Test job 3", runtimeFunc, nil, func(_ context.Context, _ interface{}) error {
+		close(started)
+		<-release
+		return nil
+	}, nil))
+	require.NoError(t, s.RunJob(ctx, "Test job 3"))
+	<-started
+	time.Sleep(20 * time.Millisecond)
+	data, err = s.MarshalJobs(ctx)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &res))
+	require.Len(t, res.Jobs, 3)
+	require.True(t, res.Jobs[2].Active)
+	elapsed, err := time.ParseDuration(res.Jobs[2].Runtime)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, elapsed, 20*time.Millisecond)
+
+	close(release)
+	var lastRun struct {
+		Jobs []*struct {
+			Active          bool   `json:"active"`
+			Runtime         string `json:"runtime"`
+			LastRunDuration string `json:"last_run_duration"`
+		} `json:"jobs"`
+	}
+	require.Eventually(t, func() bool {
+		data, err := s.MarshalJobs(ctx)
+		if err != nil || json.Unmarshal(data, &lastRun) != nil {
+			return false
+		}
+		return !lastRun.Jobs[2].Active && lastRun.Jobs[2].LastRunDuration != ""
+	}, time.Second, time.Millisecond)
+	require.Equal(t, lastRun.Jobs[2].LastRunDuration, lastRun.Jobs[2].Runtime)
+	require.NoError(t, s.CancelJob(ctx, "Test job 3"))
+
+	// Marshalling is safe alongside changes to the jobs.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("Concurrent job %d", i)
+			assert.NoError(t, s.ScheduleJob(ctx, "Concurrent", name, runtime, runFunc, nil))
+			_, err := s.MarshalJobs(ctx)
+			assert.NoError(t, err)
+			s.CancelJobIfExists(ctx, name)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestCancelJobsInClass(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	runFunc := func(ctx context.Context, data interface{}) error {
+		return nil
+	}
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		return time.Now().Add(time.Hour), nil
+	}
+
+	require.NoError(t, s.ScheduleJob(ctx, "Catchup", "Test job 1", time.Now().Add(time.Hour), runFunc, nil))
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Catchup", "Test job 2", runtimeFunc, nil, runFunc, nil))
+	require.NoError(t, s.ScheduleJob(ctx, "Other", "Test job 3", time.Now().Add(time.Hour), runFunc, nil))
+	require.Len(t, s.ListJobs(ctx), 3)
+
+	s.CancelJobsInClass(ctx, "Catchup")
+	jobs := s.ListJobs(ctx)
+	require.Len(t, jobs, 1)
+	require.Contains(t, jobs, "Test job 3")
+
+	// Unknown class.
+	s.CancelJobsInClass(ctx, "Unknown")
+	require.Len(t, s.ListJobs(ctx), 1)
+}
+
+func TestPinnedJob(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	runFunc := func(ctx context.Context, data interface{}) error {
+		return nil
+	}
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		return time.Now().Add(time.Hour), nil
+	}
+
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job 1", time.Now().Add(time.Hour), runFunc, nil))
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test job 2", time.Now().Add(time.Hour), runFunc, nil, scheduler.WithPinned(true)))
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test job 3", runtimeFunc, nil, runFunc, nil, scheduler.WithPinned(true)))
+	require.Len(t, s.ListJobs(ctx), 3)
+
+	// Sweeping cancellations leave pinned jobs in place.
+	s.CancelJobs(ctx, "Test job")
+	jobs := s.ListJobs(ctx)
+	require.Len(t, jobs, 2)
+	require.Contains(t, jobs, "Test job 2")
+	require.Contains(t, jobs, "Test job 3")
+	s.CancelJobsInClass(ctx, "Test")
+	require.Len(t, s.ListJobs(ctx), 2)
+	require.True(t, s.Jobs(ctx)[0].Pinned)
+
+	// Cancelling by name cancels pinned jobs.
+	require.NoError(t, s.CancelJob(ctx, "Test job 2"))
+	require.NoError(t, s.CancelJob(ctx, "Test job 3"))
+	require.Len(t, s.ListJobs(ctx), 0)
+}
+
+func TestScheduleSlotJobsForEpoch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	genesisTime := time.Now().Add(time.Hour).Truncate(time.Second)
+	slotDuration := 12 * time.Second
+	var ran uint64
+	jobFuncFor := func(slot uint64) scheduler.JobFunc {
+		return func(ctx context.Context, data interface{}) error {
+			atomic.StoreUint64(&ran, slot)
+			return nil
+		}
+	}
+
+	names, err := s.ScheduleSlotJobsForEpoch(ctx, "Slots", 2, genesisTime, slotDuration, jobFuncFor)
+	require.NoError(t, err)
+	require.Len(t, names, 32)
+	require.Equal(t, "Slots-slot-64", names[0])
+	require.Equal(t, "Slots-slot-95", names[31])
+
+	jobs := make(map[string]scheduler.JobInfo)
+	for _, job := range s.Jobs(ctx) {
+		jobs[job.Name] = job
+	}
+	require.Len(t, jobs, 32)
+	for i, name := range names {
+		require.Contains(t, jobs, name)
+		require.Equal(t, "Slots", jobs[name].Class)
+		require.Equal(t, genesisTime.Add(time.Duration(64+i)*slotDuration), jobs[name].NextRun)
+	}
+
+	// Overlapping epoch fails without scheduling anything.
+	_, err = s.ScheduleSlotJobsForEpoch(ctx, "Slots", 2, genesisTime, slotDuration, jobFuncFor)
+	require.ErrorIs(t, err, scheduler.ErrJobAlreadyExists)
+	require.Len(t, s.ListJobs(ctx), 32)
+
+	// Jobs run as usual.
+	require.NoError(t, s.RunJob(ctx, "Slots-slot-70"))
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, uint64(70), atomic.LoadUint64(&ran))
+	require.Len(t, s.ListJobs(ctx), 31)
+
+	// Nil job functions are rejected.
+	_, err = s.ScheduleSlotJobsForEpoch(ctx, "Slots", 3, genesisTime, slotDuration, func(slot uint64) scheduler.JobFunc { return nil })
+	require.ErrorIs(t, err, scheduler.ErrNoJobFunc)
+	require.Len(t, s.ListJobs(ctx), 31)
+}
+
+func TestConfirmCompletion(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	confirmErr := errors.New("commit not acknowledged")
+	confirmations := uint32(0)
+	failConfirm := func(ctx context.Context) error {
+		atomic.AddUint32(&confirmations, 1)
+		return confirmErr
+	}
+	succeedConfirm := func(ctx context.Context) error {
+		atomic.AddUint32(&confirmations, 1)
+		return nil
+	}
+	runFunc := func(ctx context.Context, data interface{}) error {
+		return nil
+	}
+	jobErr := errors.New("job failed")
+	failFunc := func(ctx context.Context, data interface{}) error {
+		return jobErr
+	}
+
+	// Job that runs but whose completion is not confirmed.
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Unconfirmed job", time.Now(), runFunc, nil, scheduler.WithConfirmCompletion(failConfirm)))
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, uint32(1), atomic.LoadUint32(&confirmations))
+	lastErr, err := s.LastError(ctx, "Unconfirmed job")
+	require.NoError(t, err)
+	require.ErrorIs(t, lastErr, scheduler.ErrCompletionNotConfirmed)
+	require.ErrorIs(t, lastErr, confirmErr)
+
+	// Job that runs and whose completion is confirmed.
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Confirmed job", time.Now(), runFunc, nil, scheduler.WithConfirmCompletion(succeedConfirm)))
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, uint32(2), atomic.LoadUint32(&confirmations))
+	lastErr, err = s.LastError(ctx, "Confirmed job")
+	require.NoError(t, err)
+	require.NoError(t, lastErr)
+
+	// Job that fails, so completion is not confirmed.
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Failed job", time.Now(), failFunc, nil, scheduler.WithConfirmCompletion(succeedConfirm)))
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, uint32(2), atomic.LoadUint32(&confirmations))
+	lastErr, err = s.LastError(ctx, "Failed job")
+	require.NoError(t, err)
+	require.Equal(t, jobErr, lastErr)
+}
+
+func TestReplayLastRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	type jobData struct {
+		epoch int
+	}
+
+	// The job moves its data on to the next epoch each time it runs.
+	var mu sync.Mutex
+	seen := make([]int, 0)
+	runFunc := func(_ context.Context, data interface{}) error {
+		d := data.(*jobData)
+		mu.Lock()
+		seen = append(seen, d.epoch)
+		mu.Unlock()
+		d.epoch++
+		return nil
+	}
+	runtimeFunc := func(_ context.Context, _ interface{}) (time.Time, error) {
+		return time.Now().Add(time.Hour), nil
+	}
+	snapshotFunc := func(data interface{}) interface{} {
+		d := *(data.(*jobData))
+		return &d
+	}
+
+	require.ErrorIs(t, s.ReplayLastRun(ctx, "Unknown job"), scheduler.ErrNoSuchJob)
+
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test job", runtimeFunc, nil, runFunc, &jobData{epoch: 5},
+		scheduler.WithSnapshotData(snapshotFunc),
+	))
+	require.ErrorIs(t, s.ReplayLastRun(ctx, "Test job"), scheduler.ErrNoPriorRun)
+
+	require.NoError(t, s.RunJob(ctx, "Test job"))
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, s.RunJob(ctx, "Test job"))
+	time.Sleep(100 * time.Millisecond)
+
+	// Replays use the data as it was at the start of the last run, each time.
+	require.NoError(t, s.ReplayLastRun(ctx, "Test job"))
+	require.NoError(t, s.ReplayLastRun(ctx, "Test job"))
+
+	mu.Lock()
+	require.Equal(t, []int{5, 6, 6, 6}, seen)
+	mu.Unlock()
+}
+
+func TestLeaderCheck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	var leader atomic.Bool
+	leader.Store(true)
+	leaderCheck := func(_ context.Context) (bool, error) {
+		return leader.Load(), nil
+	}
+
+	var run atomic.Int32
+	runFunc := func(_ context.Context, _ interface{}) error {
+		run.Add(1)
+		return nil
+	}
+	runtimeFunc := func(_ context.Context, _ interface{}) (time.Time, error) {
+		return time.Now().Add(100 * time.Millisecond), nil
+	}
+
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test leader job", runtimeFunc, nil, runFunc, nil,
+		scheduler.WithLeaderCheck(leaderCheck),
+	))
+	time.Sleep(150 * time.Millisecond)
+	require.Equal(t, int32(1), run.Load())
+
+	// Lose leadership; runs are skipped but the job remains scheduled.
+	leader.Store(false)
+	time.Sleep(200 * time.Millisecond)
+	require.Equal(t, int32(1), run.Load())
+	require.NoError(t, s.RunJob(ctx, "Test leader job"))
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, int32(1), run.Load())
+	require.Contains(t, s.ListJobs(ctx), "Test leader job")
+
+	// Regain leadership; runs resume.
+	leader.Store(true)
+	time.Sleep(200 * time.Millisecond)
+	require.GreaterOrEqual(t, run.Load(), int32(2))
+
+	require.NoError(t, s.CancelJob(ctx, "Test leader job"))
+}
+
+func TestTriggerCoalesce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	var run atomic.Int32
+	runFunc := func(_ context.Context, _ interface{}) error {
+		run.Add(1)
+		return nil
+	}
+	runtimeFunc := func(_ context.Context, _ interface{}) (time.Time, error) {
+		return time.Now().Add(time.Hour), nil
+	}
+
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test coalesced periodic job", runtimeFunc, nil, runFunc, nil,
+		scheduler.WithTriggerCoalesce(100*time.Millisecond),
+	))
+
+	// A burst of triggers results in a single run at the end of the window.
+	for i := 0; i < 10; i++ {
+		require.NoError(t, s.RunJob(ctx, "Test coalesced periodic job"))
+		s.RunJobIfExists(ctx, "Test coalesced periodic job")
+	}
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, int32(0), run.Load())
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, int32(1), run.Load())
+
+	// A later trigger opens a new window.
+	require.NoError(t, s.RunJob(ctx, "Test coalesced periodic job"))
+	time.Sleep(150 * time.Millisecond)
+	require.Equal(t, int32(2), run.Load())
+
+	// Cancelling the job drops a pending run.
+	require.NoError(t, s.RunJob(ctx, "Test coalesced periodic job"))
+	require.NoError(t, s.CancelJob(ctx, "Test coalesced periodic job"))
+	time.Sleep(150 * time.Millisecond)
+	require.Equal(t, int32(2), run.Load())
+
+	// One-off jobs remain until the window closes, so later triggers are coalesced rather than rejected.
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "Test coalesced job", time.Now().Add(time.Hour), runFunc, nil,
+		scheduler.WithTriggerCoalesce(100*time.Millisecond),
+	))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, s.RunJob(ctx, "Test coalesced job"))
+	}
+	time.Sleep(150 * time.Millisecond)
+	require.Equal(t, int32(3), run.Load())
+	require.False(t, s.JobExists(ctx, "Test coalesced job"))
+}
+
+func TestScheduleRateLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rate := 200.0
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithMonitor(&nullmetrics.Service{}),
+		standard.WithScheduleRateLimit(rate),
+	)
+	require.NoError(t, err)
+
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		return time.Now().Add(time.Hour), nil
+	}
+	jobFunc := func(ctx context.Context, data interface{}) error { return nil }
+
+	// Schedule from a number of goroutines, as in a reconcile storm.
+	jobs := 300
+	started := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				assert.NoError(t, s.ScheduleJob(ctx, "Test", fmt.Sprintf("Job %d", i), time.Now().Add(time.Hour), jobFunc, nil))
+			} else {
+				assert.NoError(t, s.SchedulePeriodicJob(ctx, "Test", fmt.Sprintf("Job %d", i), runtimeFunc, nil, jobFunc, nil))
+			}
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(started)
+
+	// No jobs are dropped.
+	require.Len(t, s.ListJobs(ctx), jobs)
+	// One second's worth of jobs can be scheduled immediately, the rest no faster than the rate.
+	require.LessOrEqual(t, float64(jobs-int(rate))/elapsed.Seconds(), rate*1.1)
+
+	// Slot jobs use a token for each job.
+	started = time.Now()
+	_, err = s.ScheduleSlotJobsForEpoch(ctx, "Slots", 1, time.Now().Add(time.Hour), 12*time.Second, func(slot uint64) scheduler.JobFunc { return jobFunc })
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(started), 32*time.Second/time.Duration(rate)*9/10)
+
+	// A call waiting for the limit respects its context.
+	s, err = standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithMonitor(&nullmetrics.Service{}),
+		standard.WithScheduleRateLimit(1),
+	)
+	require.NoError(t, err)
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "First job", time.Now().Add(time.Hour), jobFunc, nil))
+	waitCtx, waitCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer waitCancel()
+	err = s.ScheduleJob(waitCtx, "Test", "Second job", time.Now().Add(time.Hour), jobFunc, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.False(t, s.JobExists(ctx, "Second job"))
+}
+
+func TestExpvar(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// expvar names cannot be unpublished, so use a unique name for repeated runs.
+	name := fmt.Sprintf("test_scheduler_%d", time.Now().UnixNano())
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithMonitor(&nullmetrics.Service{}),
+		standard.WithExpvar(name),
+	)
+	require.NoError(t, err)
+
+	// The name cannot be published twice.
+	_, err = standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithMonitor(&nullmetrics.Service{}),
+		standard.WithExpvar(name),
+	)
+	require.EqualError(t, err, fmt.Sprintf("failed to publish expvar: expvar %s already published", name))
+
+	jobFunc := func(ctx context.Context, data interface{}) error { return nil }
+	require.NoError(t, s.ScheduleJob(ctx, "Class A", "Job 1", time.Now().Add(time.Hour), jobFunc, nil))
+	require.NoError(t, s.ScheduleJob(ctx, "Class A", "Job 2", time.Now().Add(time.Hour), jobFunc, nil))
+
+	// A periodic job that runs immediately and blocks, so is active.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var runs atomic.Int32
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		if runs.Load() == 0 {
+			return time.Now(), nil
+		}
+		return time.Now().Add(time.Hour), nil
+	}
+	blockingFunc := func(ctx context.Context, data interface{}) error {
+		if runs.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		return nil
+	}
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Class B", "Job 3", runtimeFunc, nil, blockingFunc, nil))
+	<-started
+	defer close(release)
+
+	type classStats struct {
+		Jobs   int `json:"jobs"`
+		Active int `json:"active"`
+	}
+	var stats struct {
+		Jobs    int                    `json:"jobs"`
+		Active  int                    `json:"active"`
+		Running int64                  `json:"running"`
+		Classes map[string]*classStats `json:"classes"`
+	}
+	v := expvar.Get(name)
+	require.NotNil(t, v)
+	require.NoError(t, json.Unmarshal([]byte(v.String()), &stats))
+	require.Equal(t, 3, stats.Jobs)
+	require.Equal(t, 1, stats.Active)
+	require.Equal(t, int64(1), stats.Running)
+	require.Equal(t, map[string]*classStats{
+		"Class A": {Jobs: 2},
+		"Class B": {Jobs: 1, Active: 1},
+	}, stats.Classes)
+}
+
+func TestRunOnResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name        string
+		runOnResume bool
+		runs        int32
+	}{
+		{
+			name: "Skipped",
+		},
+		{
+			name:        "RunOnResume",
+			runOnResume: true,
+			runs:        1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+			require.NoError(t, err)
+
+			// The first run is due shortly; later runs are well in the future.
+			var instances atomic.Int32
+			runtimeFunc := func(_ context.Context, _ interface{}) (time.Time, error) {
+				if instances.Add(1) == 1 {
+					return time.Now().Add(50 * time.Millisecond), nil
+				}
+				return time.Now().Add(time.Hour), nil
+			}
+			var runs atomic.Int32
+			runFunc := func(_ context.Context, _ interface{}) error {
+				runs.Add(1)
+				return nil
+			}
+
+			// Suspend across the scheduled runtime.
+			s.Suspend(ctx)
+			require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test job", runtimeFunc, nil, runFunc, nil,
+				scheduler.WithRunOnResume(test.runOnResume),
+			))
+			time.Sleep(150 * time.Millisecond)
+			require.Equal(t, int32(0), runs.Load())
+
+			s.Resume(ctx)
+			require.Eventually(t, func() bool {
+				return instances.Load() == 2
+			}, time.Second, 5*time.Millisecond)
+			time.Sleep(50 * time.Millisecond)
+			require.Equal(t, test.runs, runs.Load())
+			require.True(t, s.JobExists(ctx, "Test job"))
+
+			require.NoError(t, s.CancelJob(ctx, "Test job"))
+		})
+	}
+}
+
+func TestSuspendedRunJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+
+	// The first run is due shortly; later runs are well in the future.
+	var instances atomic.Int32
+	runtimeFunc := func(_ context.Context, _ interface{}) (time.Time, error) {
+		if instances.Add(1) == 1 {
+			return time.Now().Add(50 * time.Millisecond), nil
+		}
+		return time.Now().Add(time.Hour), nil
+	}
+	var runs atomic.Int32
+	runFunc := func(_ context.Context, _ interface{}) error {
+		runs.Add(1)
+		return nil
+	}
+
+	// Suspend across the scheduled runtime, so that the run is missed.
+	s.Suspend(ctx)
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test job", runtimeFunc, nil, runFunc, nil,
+		scheduler.WithRunOnResume(true),
+	))
+	time.Sleep(150 * time.Millisecond)
+	require.Equal(t, int32(0), runs.Load())
+
+	// A triggered run goes ahead whilst suspended, and takes the place of the missed run.
+	require.NoError(t, s.RunJob(ctx, "Test job"))
+	require.Eventually(t, func() bool {
+		return runs.Load() == 1 && instances.Load() == 2
+	}, time.Second, 5*time.Millisecond)
+
+	s.Resume(ctx)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, int32(1), runs.Load())
+
+	// Cancellation is also handled whilst suspended.
+	s.Suspend(ctx)
+	require.NoError(t, s.CancelJob(ctx, "Test job"))
+	require.False(t, s.JobExists(ctx, "Test job"))
+}
+
+func TestQuiesceUntil(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := standard.New(ctx, standard.WithLogLevel(zerolog.Disabled), standard.WithMonitor(&nullmetrics.Service{}))
+	require.NoError(t, err)
+
+	var instances atomic.Int32
+	runtimeFunc := func(_ context.Context, _ interface{}) (time.Time, error) {
+		if instances.Add(1) == 1 {
+			return time.Now().Add(50 * time.Millisecond), nil
+		}
+		return time.Now().Add(time.Hour), nil
+	}
+	var runs atomic.Int32
+	runFunc := func(_ context.Context, _ interface{}) error {
+		runs.Add(1)
+		return nil
+	}
+
+	// Quiesce across the scheduled runtime.
+	s.QuiesceUntil(ctx, time.Now().Add(200*time.Millisecond))
+	require.NoError(t, s.SchedulePeriodicJob(ctx, "Test", "Test job", runtimeFunc, nil, runFunc, nil,
+		scheduler.WithRunOnResume(true),
+	))
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, int32(0), runs.Load())
+
+	// The scheduler resumes by itself, and the missed run catches up.
+	require.Eventually(t, func() bool {
+		return runs.Load() == 1 && instances.Load() == 2
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, s.CancelJob(ctx, "Test job"))
+}
@@ -0,0 +1,80 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"sync"
+	"time"
+)
+
+// stateTransitions reports transitions of the scheduler between idle, with no
+// job functions running, and busy, with at least one job function running.
+// Transitions are debounced, so that a state must hold for the debounce period
+// before it is reported, and are reported asynchronously and in order.
+type stateTransitions struct {
+	hook     func(busy bool)
+	debounce time.Duration
+	// busy returns true if the scheduler is busy.
+	busy func() bool
+	// mu protects reported and timer.
+	mu       sync.Mutex
+	reported bool
+	timer    *time.Timer
+	// hookMu serialises calls to the hook.
+	hookMu sync.Mutex
+}
+
+func newStateTransitions(hook func(busy bool), debounce time.Duration, busy func() bool) *stateTransitions {
+	if hook == nil {
+		return nil
+	}
+
+	return &stateTransitions{
+		hook:     hook,
+		debounce: debounce,
+		busy:     busy,
+	}
+}
+
+// update notes a possible change of state.  It must be called after each change
+// to the number of running job functions that could alter the state.
+func (t *stateTransitions) update() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.timer = time.AfterFunc(t.debounce, t.report)
+}
+
+// report calls the hook if the state has changed since it was last reported.
+func (t *stateTransitions) report() {
+	t.hookMu.Lock()
+	defer t.hookMu.Unlock()
+
+	t.mu.Lock()
+	busy := t.busy()
+	changed := busy != t.reported
+	t.reported = busy
+	t.mu.Unlock()
+
+	if changed {
+		log.Trace().Bool("busy", busy).Msg("Scheduler state changed")
+		t.hook(busy)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateTransitionHook(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transitions := make(chan bool, 16)
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithStateTransitionHook(func(busy bool) { transitions <- busy }),
+		WithStateTransitionDebounce(50*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	expect := func(busy bool) {
+		select {
+		case transition := <-transitions:
+			require.Equal(t, busy, transition)
+		case <-time.After(time.Second):
+			require.Fail(t, "no state transition", "expected busy=%t", busy)
+		}
+	}
+	expectNone := func() {
+		select {
+		case transition := <-transitions:
+			require.Fail(t, "unexpected state transition", "busy=%t", transition)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+
+	// A long-running job makes the scheduler busy, and idle once it completes.
+	release := make(chan struct{})
+	blocking := func(_ context.Context, _ interface{}) error {
+		<-release
+		return nil
+	}
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "long", time.Now(), blocking, nil))
+	expect(true)
+	// A second job starting and finishing while busy does not cause a transition.
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "overlap", time.Now(), func(_ context.Context, _ interface{}) error { return nil }, nil))
+	expectNone()
+	close(release)
+	expect(false)
+
+	// Jobs that start and finish within the debounce period do not cause transitions.
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.ScheduleJob(ctx, "Test", string(rune('a'+i)), time.Now(), func(_ context.Context, _ interface{}) error { return nil }, nil))
+		time.Sleep(5 * time.Millisecond)
+	}
+	expectNone()
+
+	// The scheduler can become busy again.
+	release = make(chan struct{})
+	require.NoError(t, s.ScheduleJob(ctx, "Test", "long2", time.Now(), blocking, nil))
+	expect(true)
+	close(release)
+	expect(false)
+}
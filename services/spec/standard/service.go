@@ -22,6 +22,8 @@ import (
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
 )
 
 // Service is a spec service.
@@ -44,10 +46,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "spec").Str("impl", "standard").Logger()
-	if parameters.logLevel != log.GetLevel() {
-		log = log.Level(parameters.logLevel)
-	}
+	log = util.ServiceLogger(zerologger.With().Str("service", "spec").Str("impl", "standard").Logger(), "spec", parameters.logLevel)
 
 	chainSpecSetter, isChainSpecSetter := parameters.chainDB.(chaindb.ChainSpecSetter)
 	if !isChainSpecSetter {
@@ -81,16 +80,18 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		// Run daily.
 		return time.Now().AddDate(0, 0, 1), nil
 	}
-	jobFunc := func(ctx context.Context, data interface{}) {
+	jobFunc := func(ctx context.Context, data interface{}) error {
 		log.Trace().Msg("Updating spec")
 		s := data.(*Service)
 		s.updateSpec(ctx)
+		return nil
 	}
 	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "spec", "update spec",
 		runtimeFunc,
 		nil,
 		jobFunc,
 		s,
+		scheduler.WithPinned(true),
 	); err != nil {
 		return nil, errors.Wrap(err, "failed to set up periodic refresh of spec")
 	}
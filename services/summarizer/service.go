@@ -13,5 +13,22 @@
 
 package summarizer
 
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
 // Service is a summarizer service.
 type Service interface{}
+
+// Resummarizer is the interface for a summarizer that can recalculate existing summaries.
+type Resummarizer interface {
+	// Resummarize recalculates the summaries for the given range of epochs, inclusive.
+	Resummarize(ctx context.Context, from phase0.Epoch, to phase0.Epoch) error
+
+	// ResummarizeDays recalculates the validator day summaries for the days starting
+	// within the given range of times, inclusive.
+	ResummarizeDays(ctx context.Context, from time.Time, to time.Time) error
+}
@@ -62,17 +62,45 @@ func (s *Service) summarizeBlocksInEpoch(ctx context.Context,
 
 // summarizeBlock summarizes the block at the given slot.
 func (s *Service) summarizeBlock(ctx context.Context, slot phase0.Slot) error {
+	summary, err := s.blockSummary(ctx, slot)
+	if err != nil {
+		return err
+	}
+	if summary == nil {
+		return nil
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set block summary")
+	}
+	if err := s.chainDB.(chaindb.BlockSummariesSetter).SetBlockSummary(ctx, summary); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set block summary")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set commit transaction to set block summary")
+	}
+
+	return nil
+}
+
+// blockSummary calculates the summary for the canonical block at the given slot.
+// Returns nil if there is no canonical block at the slot.
+func (s *Service) blockSummary(ctx context.Context, slot phase0.Slot) (*chaindb.BlockSummary, error) {
 	summary := &chaindb.BlockSummary{
-		Slot: slot,
+		Slot:              slot,
+		SummarizerVersion: summarizerVersion,
 	}
 
 	blocks, err := s.blocksProvider.BlocksBySlot(ctx, slot)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain blocks for slot")
+		return nil, errors.Wrap(err, "failed to obtain blocks for slot")
 	}
 	if len(blocks) == 0 {
 		// No block for this slot.
-		return nil
+		return nil, nil
 	}
 
 	var block *chaindb.Block
@@ -84,32 +112,19 @@ func (s *Service) summarizeBlock(ctx context.Context, slot phase0.Slot) error {
 	}
 	if block == nil {
 		// No canonical block for this slot.
-		return nil
+		return nil, nil
 	}
 	log.Trace().Uint64("slot", uint64(slot)).Msg("Summarising block")
 
 	if err := s.attestationStatsForBlock(ctx, slot, summary, block); err != nil {
-		return errors.Wrap(err, "failed to calculate block attestation summary statistics for epoch")
+		return nil, errors.Wrap(err, "failed to calculate block attestation summary statistics for epoch")
 	}
 
 	if err := s.parentDistanceForBlock(ctx, slot, summary, block); err != nil {
-		return errors.Wrap(err, "failed to calculate parent distance summary statistics for epoch")
-	}
-
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to begin transaction to set block summary")
-	}
-	if err := s.chainDB.(chaindb.BlockSummariesSetter).SetBlockSummary(ctx, summary); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set block summary")
-	}
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set commit transaction to set block summary")
+		return nil, errors.Wrap(err, "failed to calculate parent distance summary statistics for epoch")
 	}
 
-	return nil
+	return summary, nil
 }
 
 func (s *Service) attestationStatsForBlock(ctx context.Context,
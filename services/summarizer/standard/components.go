@@ -0,0 +1,78 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"fmt"
+
+	"github.com/wealdtech/chaind/services/blocks"
+)
+
+// requiredBlockComponents returns the stored block components required by each
+// of the enabled summaries.
+func (p *parameters) requiredBlockComponents() []summaryRequirement {
+	requirements := make([]summaryRequirement, 0, 3)
+	if p.epochSummaries {
+		requirements = append(requirements, summaryRequirement{
+			summaries: "epoch",
+			components: []blocks.Component{
+				blocks.ComponentAttestations,
+				blocks.ComponentDeposits,
+				blocks.ComponentSlashings,
+				blocks.ComponentWithdrawals,
+			},
+		})
+	}
+	if p.blockSummaries {
+		requirements = append(requirements, summaryRequirement{
+			summaries: "block",
+			components: []blocks.Component{
+				blocks.ComponentAttestations,
+			},
+		})
+	}
+	if p.validatorSummaries {
+		requirements = append(requirements, summaryRequirement{
+			summaries: "validator",
+			components: []blocks.Component{
+				blocks.ComponentAttestations,
+				blocks.ComponentDeposits,
+				blocks.ComponentSyncAggregates,
+				blocks.ComponentWithdrawals,
+			},
+		})
+	}
+
+	return requirements
+}
+
+// summaryRequirement is the set of stored block components required by a type of summary.
+type summaryRequirement struct {
+	summaries  string
+	components []blocks.Component
+}
+
+// checkBlockComponents ensures that the block components required by the enabled
+// summaries are stored.
+func (p *parameters) checkBlockComponents() error {
+	for _, requirement := range p.requiredBlockComponents() {
+		for _, component := range requirement.components {
+			if !p.storedBlockComponents.Stored(component) {
+				return fmt.Errorf("%s summaries require block %s to be stored, but storage of block %s is disabled", requirement.summaries, component, component)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/blocks"
+)
+
+func TestCheckBlockComponents(t *testing.T) {
+	tests := []struct {
+		name               string
+		epochSummaries     bool
+		blockSummaries     bool
+		validatorSummaries bool
+		stored             blocks.StoredComponents
+		err                string
+	}{
+		{
+			name:               "AllStored",
+			epochSummaries:     true,
+			blockSummaries:     true,
+			validatorSummaries: true,
+		},
+		{
+			name: "NoSummaries",
+			stored: blocks.StoredComponents{
+				blocks.ComponentAttestations: false,
+				blocks.ComponentWithdrawals:  false,
+			},
+		},
+		{
+			name:           "UnrequiredDisabled",
+			epochSummaries: true,
+			blockSummaries: true,
+			stored: blocks.StoredComponents{
+				blocks.ComponentVoluntaryExits: false,
+				blocks.ComponentSyncAggregates: false,
+			},
+		},
+		{
+			name:           "BlockAttestationsDisabled",
+			blockSummaries: true,
+			stored: blocks.StoredComponents{
+				blocks.ComponentAttestations: false,
+			},
+			err: "block summaries require block attestations to be stored, but storage of block attestations is disabled",
+		},
+		{
+			name:               "ValidatorSyncAggregatesDisabled",
+			validatorSummaries: true,
+			stored: blocks.StoredComponents{
+				blocks.ComponentSyncAggregates: false,
+			},
+			err: "validator summaries require block sync aggregates to be stored, but storage of block sync aggregates is disabled",
+		},
+		{
+			name:           "EpochWithdrawalsDisabled",
+			epochSummaries: true,
+			stored: blocks.StoredComponents{
+				blocks.ComponentWithdrawals: false,
+			},
+			err: "epoch summaries require block withdrawals to be stored, but storage of block withdrawals is disabled",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := &parameters{
+				epochSummaries:        test.epochSummaries,
+				blockSummaries:        test.blockSummaries,
+				validatorSummaries:    test.validatorSummaries,
+				storedBlockComponents: test.stored,
+			}
+			err := p.checkBlockComponents()
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
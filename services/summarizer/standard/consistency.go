@@ -0,0 +1,132 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// consistencyCheckJobClass is the scheduler class of the consistency check job.
+const consistencyCheckJobClass = "summarizer"
+
+// scheduleConsistencyCheck schedules the periodic epoch summary consistency check.
+func (s *Service) scheduleConsistencyCheck(ctx context.Context) error {
+	log.Info().Dur("interval", s.consistencyCheckInterval).Msg("Scheduling epoch summary consistency check")
+
+	return s.scheduler.SchedulePeriodicJob(ctx,
+		consistencyCheckJobClass,
+		"summarizer-consistency-check",
+		s.nextConsistencyCheck,
+		nil,
+		s.checkConsistency,
+		nil,
+	)
+}
+
+// nextConsistencyCheck returns the time of the next consistency check.
+func (s *Service) nextConsistencyCheck(_ context.Context, _ interface{}) (time.Time, error) {
+	return time.Now().Add(s.consistencyCheckInterval), nil
+}
+
+// checkConsistency recomputes the summary for a random past epoch from the full
+// validator set, and compares it with the summary stored by the incremental summarizer.
+func (s *Service) checkConsistency(ctx context.Context, _ interface{}) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for consistency check")
+	}
+
+	minEpoch := phase0.Epoch(1)
+	if s.origin != nil && s.origin.Epoch > minEpoch {
+		minEpoch = s.origin.Epoch
+	}
+	if md.LastEpoch < minEpoch {
+		log.Trace().Msg("No epoch summaries to check")
+		return nil
+	}
+	epoch := minEpoch + phase0.Epoch(rand.Int63n(int64(md.LastEpoch-minEpoch+1)))
+
+	return s.checkEpochConsistency(ctx, epoch)
+}
+
+// checkEpochConsistency recomputes the summary for the given epoch and compares it with the stored summary.
+func (s *Service) checkEpochConsistency(ctx context.Context, epoch phase0.Epoch) error {
+	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+
+	summaries, err := s.chainDB.(chaindb.EpochSummariesProvider).EpochSummaries(ctx, &chaindb.EpochSummaryFilter{
+		Limit: 1,
+		From:  &epoch,
+		To:    &epoch,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain stored epoch summary")
+	}
+	if len(summaries) == 0 {
+		log.Debug().Msg("No stored epoch summary; skipping consistency check")
+		monitorConsistencyCheck("skipped")
+		return nil
+	}
+
+	aggregates, err := s.fetchValidatorAggregates(ctx, epoch)
+	if err != nil {
+		return err
+	}
+	summary, calculated, err := s.calculateEpochSummary(ctx, epoch, aggregates)
+	if err != nil {
+		return errors.Wrap(err, "failed to recalculate epoch summary")
+	}
+	if !calculated {
+		// Most likely the balances for the epoch have been pruned.
+		log.Debug().Msg("Not enough data to recalculate epoch summary; skipping consistency check")
+		monitorConsistencyCheck("skipped")
+		return nil
+	}
+
+	differences := epochSummaryDifferences(summaries[0], summary)
+	if len(differences) > 0 {
+		log.Warn().Strs("fields", differences).Msg("Stored epoch summary differs from recalculated summary")
+		monitorConsistencyCheck("mismatch")
+		return nil
+	}
+
+	log.Trace().Msg("Stored epoch summary matches recalculated summary")
+	monitorConsistencyCheck("match")
+
+	return nil
+}
+
+// epochSummaryDifferences returns the names of the fields that differ between two epoch summaries.
+// The version of the summarizer that produced the summaries is not considered.
+func epochSummaryDifferences(a *chaindb.EpochSummary, b *chaindb.EpochSummary) []string {
+	differences := make([]string, 0)
+	av := reflect.ValueOf(a).Elem()
+	bv := reflect.ValueOf(b).Elem()
+	for i := 0; i < av.NumField(); i++ {
+		if av.Type().Field(i).Name == "SummarizerVersion" {
+			continue
+		}
+		if !reflect.DeepEqual(av.Field(i).Interface(), bv.Field(i).Interface()) {
+			differences = append(differences, av.Type().Field(i).Name)
+		}
+	}
+
+	return differences
+}
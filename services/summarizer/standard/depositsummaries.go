@@ -0,0 +1,356 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// depositSummaryEpochsPerTx is the number of epochs of deposit summaries calculated and written in each transaction.
+const depositSummaryEpochsPerTx = 256
+
+// depositClass is the effect of a deposit on the beacon state.
+type depositClass int
+
+const (
+	// depositNewValidator is a deposit that created a validator.
+	depositNewValidator depositClass = iota
+	// depositTopUp is a deposit to an existing validator.
+	depositTopUp
+	// depositInvalid is a deposit that did not create a validator, due to an invalid signature.
+	depositInvalid
+)
+
+// depositKey identifies a deposit within a block.
+type depositKey struct {
+	root  phase0.Root
+	index uint64
+}
+
+// classifiedDeposit is a deposit with its effect on the beacon state, and the Ethereum 1 deposit that made it if known.
+type classifiedDeposit struct {
+	class       depositClass
+	eth1Deposit *chaindb.ETH1Deposit
+}
+
+// summarizeDeposits summarizes the deposits processed in each epoch up to the summary epoch,
+// starting from the earliest epoch if none have been summarized.
+func (s *Service) summarizeDeposits(ctx context.Context, summaryEpoch phase0.Epoch) error {
+	if !s.epochSummaries {
+		return nil
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for deposit summarizer")
+	}
+
+	firstEpoch := md.LastDepositEpoch
+	if firstEpoch != 0 {
+		firstEpoch++
+	}
+	if s.origin != nil && firstEpoch < s.origin.Epoch {
+		firstEpoch = s.origin.Epoch
+	}
+	if firstEpoch > summaryEpoch {
+		return nil
+	}
+	log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("summary_epoch", uint64(summaryEpoch)).Msg("Deposits catchup bounds")
+
+	for startEpoch := firstEpoch; startEpoch <= summaryEpoch; startEpoch += depositSummaryEpochsPerTx {
+		endEpoch := startEpoch + depositSummaryEpochsPerTx - 1
+		if endEpoch > summaryEpoch {
+			endEpoch = summaryEpoch
+		}
+		summaries, err := s.depositSummariesForEpochs(ctx, startEpoch, endEpoch)
+		if err != nil {
+			if errors.Is(err, errIndeterminateBlock) {
+				// The finalizer has yet to reach this epoch; try again on a later pass.
+				log.Debug().Uint64("epoch", uint64(startEpoch)).Err(err).Msg("Blocks not yet finalized; not summarizing deposits")
+				return nil
+			}
+			return errors.Wrapf(err, "failed to calculate deposit summaries for epochs %d-%d", startEpoch, endEpoch)
+		}
+		if err := s.storeDepositSummaries(ctx, md, summaries); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// depositSummariesForEpochs calculates the deposit summaries for the given range of epochs, inclusive.
+// Summaries are calculated solely from data in the database, so can be recalculated at any time.
+func (s *Service) depositSummariesForEpochs(ctx context.Context,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]*chaindb.EpochDepositSummary,
+	error,
+) {
+	minSlot := s.chainTime.FirstSlotOfEpoch(startEpoch)
+	maxSlot := s.chainTime.LastSlotOfEpoch(endEpoch)
+
+	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx, minSlot, maxSlot+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+	canonicalRoots := make(map[phase0.Root]bool, len(blocks))
+	for _, block := range blocks {
+		if block.Canonical == nil {
+			return nil, errors.Wrapf(errIndeterminateBlock, "slot %d", block.Slot)
+		}
+		canonicalRoots[block.Root] = *block.Canonical
+	}
+
+	deposits, err := s.depositsProvider.DepositsForSlotRange(ctx, minSlot, maxSlot+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain deposits")
+	}
+	canonicalDeposits := make([]*chaindb.Deposit, 0, len(deposits))
+	for _, deposit := range deposits {
+		if canonicalRoots[deposit.InclusionBlockRoot] {
+			canonicalDeposits = append(canonicalDeposits, deposit)
+		}
+	}
+	sortDeposits(canonicalDeposits)
+
+	classified, err := s.classifyDepositsByPublicKey(ctx, canonicalDeposits, canonicalRoots)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*chaindb.EpochDepositSummary, 0, int(endEpoch-startEpoch)+1)
+	for epoch := startEpoch; epoch <= endEpoch; epoch++ {
+		summaries = append(summaries, &chaindb.EpochDepositSummary{
+			Epoch:        epoch,
+			ETH1TxHashes: make([][]byte, 0),
+		})
+	}
+	for _, deposit := range canonicalDeposits {
+		summary := summaries[s.chainTime.SlotToEpoch(deposit.InclusionSlot)-startEpoch]
+		addDepositToSummary(summary, deposit, classified[depositKey{root: deposit.InclusionBlockRoot, index: deposit.InclusionIndex}])
+	}
+
+	return summaries, nil
+}
+
+// classifyDepositsByPublicKey classifies the given deposits, taking in to account all canonical
+// deposits for their public keys.
+func (s *Service) classifyDepositsByPublicKey(ctx context.Context,
+	deposits []*chaindb.Deposit,
+	canonicalRoots map[phase0.Root]bool,
+) (
+	map[depositKey]*classifiedDeposit,
+	error,
+) {
+	res := make(map[depositKey]*classifiedDeposit, len(deposits))
+	if len(deposits) == 0 {
+		return res, nil
+	}
+
+	pubKeys := make([]phase0.BLSPubKey, 0, len(deposits))
+	seen := make(map[phase0.BLSPubKey]struct{}, len(deposits))
+	for _, deposit := range deposits {
+		if _, exists := seen[deposit.ValidatorPubKey]; !exists {
+			seen[deposit.ValidatorPubKey] = struct{}{}
+			pubKeys = append(pubKeys, deposit.ValidatorPubKey)
+		}
+	}
+
+	allDeposits, err := s.depositsProvider.DepositsByPublicKey(ctx, pubKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain deposits for public keys")
+	}
+	validators, err := s.validatorsProvider.ValidatorsByPublicKey(ctx, pubKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validators for public keys")
+	}
+	eth1Deposits := make(map[phase0.BLSPubKey][]*chaindb.ETH1Deposit)
+	if eth1DepositsProvider, isProvider := s.chainDB.(chaindb.ETH1DepositsProvider); isProvider {
+		deposits, err := eth1DepositsProvider.ETH1DepositsByPublicKey(ctx, pubKeys)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain Ethereum 1 deposits for public keys")
+		}
+		for _, deposit := range deposits {
+			eth1Deposits[deposit.ValidatorPubKey] = append(eth1Deposits[deposit.ValidatorPubKey], deposit)
+		}
+	}
+
+	for _, pubKey := range pubKeys {
+		pubKeyDeposits := make([]*chaindb.Deposit, 0, len(allDeposits[pubKey]))
+		for _, deposit := range allDeposits[pubKey] {
+			canonical, err := s.depositCanonical(ctx, deposit, canonicalRoots)
+			if err != nil {
+				return nil, err
+			}
+			if canonical {
+				pubKeyDeposits = append(pubKeyDeposits, deposit)
+			}
+		}
+		sortDeposits(pubKeyDeposits)
+		pubKeyETH1Deposits := eth1Deposits[pubKey]
+		sort.Slice(pubKeyETH1Deposits, func(i int, j int) bool {
+			return pubKeyETH1Deposits[i].DepositIndex < pubKeyETH1Deposits[j].DepositIndex
+		})
+		for i, classification := range classifyDeposits(pubKeyDeposits, pubKeyETH1Deposits, validators[pubKey]) {
+			res[depositKey{root: pubKeyDeposits[i].InclusionBlockRoot, index: pubKeyDeposits[i].InclusionIndex}] = classification
+		}
+	}
+
+	return res, nil
+}
+
+// depositCanonical returns true if the deposit is in a canonical block.
+// Blocks not in the supplied roots are fetched, and added to the roots.
+func (s *Service) depositCanonical(ctx context.Context,
+	deposit *chaindb.Deposit,
+	canonicalRoots map[phase0.Root]bool,
+) (
+	bool,
+	error,
+) {
+	if canonical, exists := canonicalRoots[deposit.InclusionBlockRoot]; exists {
+		return canonical, nil
+	}
+	block, err := s.blocksProvider.BlockByRoot(ctx, deposit.InclusionBlockRoot)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to obtain block for deposit")
+	}
+	if block.Canonical == nil {
+		return false, errors.Wrapf(errIndeterminateBlock, "slot %d", block.Slot)
+	}
+	canonicalRoots[deposit.InclusionBlockRoot] = *block.Canonical
+
+	return *block.Canonical, nil
+}
+
+// classifyDeposits classifies the canonical deposits for a single public key, supplied in the
+// order in which they were processed, linking each to the Ethereum 1 deposit that made it where possible.
+//
+// The first deposit for a public key with a valid signature creates the validator, and all
+// later deposits top it up.  Deposits before that do not create a validator.  Signature validity
+// is taken from the Ethereum 1 deposit if known; otherwise the first deposit is presumed to have
+// created the validator if it exists, and all deposits are presumed invalid if it does not.
+// Validators present at genesis were created by genesis deposits, so all of their deposits are top ups.
+func classifyDeposits(deposits []*chaindb.Deposit,
+	eth1Deposits []*chaindb.ETH1Deposit,
+	validator *chaindb.Validator,
+) []*classifiedDeposit {
+	res := make([]*classifiedDeposit, len(deposits))
+	created := validator != nil && validator.ActivationEpoch == 0
+	for i, deposit := range deposits {
+		classification := &classifiedDeposit{}
+		// Deposits are processed in order, so the nth deposit for a public key was made by the nth
+		// Ethereum 1 deposit for it.  Only link them if they agree, in case Ethereum 1 deposits are missing.
+		if i < len(eth1Deposits) &&
+			eth1Deposits[i].Amount == deposit.Amount &&
+			bytes.Equal(eth1Deposits[i].WithdrawalCredentials, deposit.WithdrawalCredentials) {
+			classification.eth1Deposit = eth1Deposits[i]
+		}
+
+		switch {
+		case created:
+			classification.class = depositTopUp
+		case classification.eth1Deposit != nil && classification.eth1Deposit.SignatureValid != nil:
+			if *classification.eth1Deposit.SignatureValid {
+				classification.class = depositNewValidator
+				created = true
+			} else {
+				classification.class = depositInvalid
+			}
+		case validator != nil:
+			classification.class = depositNewValidator
+			created = true
+		default:
+			classification.class = depositInvalid
+		}
+		res[i] = classification
+	}
+
+	return res
+}
+
+// addDepositToSummary adds a classified deposit to a summary.
+func addDepositToSummary(summary *chaindb.EpochDepositSummary, deposit *chaindb.Deposit, classification *classifiedDeposit) {
+	summary.Deposits++
+	summary.Amount += deposit.Amount
+	if classification == nil {
+		// Should not happen, as all deposits are classified.
+		return
+	}
+	switch classification.class {
+	case depositNewValidator:
+		summary.NewValidators++
+		summary.NewValidatorsAmount += deposit.Amount
+	case depositTopUp:
+		summary.TopUps++
+		summary.TopUpsAmount += deposit.Amount
+	case depositInvalid:
+		summary.InvalidDeposits++
+		summary.InvalidDepositsAmount += deposit.Amount
+	}
+	if classification.eth1Deposit != nil {
+		// A single transaction can make multiple deposits.
+		for _, txHash := range summary.ETH1TxHashes {
+			if bytes.Equal(txHash, classification.eth1Deposit.ETH1TxHash) {
+				return
+			}
+		}
+		summary.ETH1TxHashes = append(summary.ETH1TxHashes, classification.eth1Deposit.ETH1TxHash)
+	}
+}
+
+// sortDeposits sorts deposits in to the order in which they were processed.
+func sortDeposits(deposits []*chaindb.Deposit) {
+	sort.SliceStable(deposits, func(i int, j int) bool {
+		if deposits[i].InclusionSlot != deposits[j].InclusionSlot {
+			return deposits[i].InclusionSlot < deposits[j].InclusionSlot
+		}
+		return deposits[i].InclusionIndex < deposits[j].InclusionIndex
+	})
+}
+
+// storeDepositSummaries stores deposit summaries, and notes the last of them in metadata.
+func (s *Service) storeDepositSummaries(ctx context.Context, md *metadata, summaries []*chaindb.EpochDepositSummary) error {
+	if len(summaries) == 0 {
+		return nil
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set epoch deposit summaries")
+	}
+	if err := s.chainDB.(chaindb.EpochDepositSummariesSetter).SetEpochDepositSummaries(ctx, summaries); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set epoch deposit summaries")
+	}
+	md.LastDepositEpoch = summaries[len(summaries)-1].Epoch
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set summarizer metadata for epoch deposit summaries")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set commit transaction to set epoch deposit summaries")
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestClassifyDeposits(t *testing.T) {
+	valid := true
+	invalid := false
+	farFutureEpoch := phase0.Epoch(0xffffffffffffffff)
+
+	deposit := func(slot phase0.Slot, amount phase0.Gwei) *chaindb.Deposit {
+		return &chaindb.Deposit{
+			InclusionSlot:         slot,
+			WithdrawalCredentials: []byte{0x01},
+			Amount:                amount,
+		}
+	}
+	eth1Deposit := func(index uint64, amount phase0.Gwei, signatureValid *bool) *chaindb.ETH1Deposit {
+		return &chaindb.ETH1Deposit{
+			ETH1TxHash:            []byte{byte(index)},
+			DepositIndex:          index,
+			WithdrawalCredentials: []byte{0x01},
+			Amount:                amount,
+			SignatureValid:        signatureValid,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		deposits     []*chaindb.Deposit
+		eth1Deposits []*chaindb.ETH1Deposit
+		validator    *chaindb.Validator
+		classes      []depositClass
+		linked       []bool
+	}{
+		{
+			name:    "Empty",
+			classes: []depositClass{},
+			linked:  []bool{},
+		},
+		{
+			name:      "NewValidatorWithTopUps",
+			deposits:  []*chaindb.Deposit{deposit(10, 32000000000), deposit(20, 1000000000), deposit(30, 2000000000)},
+			validator: &chaindb.Validator{ActivationEpoch: 5},
+			classes:   []depositClass{depositNewValidator, depositTopUp, depositTopUp},
+			linked:    []bool{false, false, false},
+		},
+		{
+			name:     "NoValidator",
+			deposits: []*chaindb.Deposit{deposit(10, 32000000000), deposit(20, 1000000000)},
+			classes:  []depositClass{depositInvalid, depositInvalid},
+			linked:   []bool{false, false},
+		},
+		{
+			name:      "GenesisValidator",
+			deposits:  []*chaindb.Deposit{deposit(10, 1000000000)},
+			validator: &chaindb.Validator{ActivationEpoch: 0},
+			classes:   []depositClass{depositTopUp},
+			linked:    []bool{false},
+		},
+		{
+			name:         "InvalidThenValid",
+			deposits:     []*chaindb.Deposit{deposit(10, 32000000000), deposit(20, 32000000000), deposit(30, 1000000000)},
+			eth1Deposits: []*chaindb.ETH1Deposit{eth1Deposit(1, 32000000000, &invalid), eth1Deposit(2, 32000000000, &valid), eth1Deposit(3, 1000000000, &invalid)},
+			validator:    &chaindb.Validator{ActivationEpoch: farFutureEpoch},
+			classes:      []depositClass{depositInvalid, depositNewValidator, depositTopUp},
+			linked:       []bool{true, true, true},
+		},
+		{
+			name:         "SignatureUnknown",
+			deposits:     []*chaindb.Deposit{deposit(10, 32000000000)},
+			eth1Deposits: []*chaindb.ETH1Deposit{eth1Deposit(1, 32000000000, nil)},
+			validator:    &chaindb.Validator{ActivationEpoch: 5},
+			classes:      []depositClass{depositNewValidator},
+			linked:       []bool{true},
+		},
+		{
+			name:     "ETH1DepositMismatch",
+			deposits: []*chaindb.Deposit{deposit(10, 32000000000), deposit(20, 1000000000)},
+			// The first Ethereum 1 deposit is missing, so the remaining one does not line up.
+			eth1Deposits: []*chaindb.ETH1Deposit{eth1Deposit(2, 1000000000, &valid)},
+			validator:    &chaindb.Validator{ActivationEpoch: 5},
+			classes:      []depositClass{depositNewValidator, depositTopUp},
+			linked:       []bool{false, false},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := classifyDeposits(test.deposits, test.eth1Deposits, test.validator)
+			classes := make([]depositClass, len(res))
+			linked := make([]bool, len(res))
+			for i := range res {
+				classes[i] = res[i].class
+				linked[i] = res[i].eth1Deposit != nil
+			}
+			require.Equal(t, test.classes, classes)
+			require.Equal(t, test.linked, linked)
+		})
+	}
+}
+
+func TestAddDepositToSummary(t *testing.T) {
+	summary := &chaindb.EpochDepositSummary{ETH1TxHashes: make([][]byte, 0)}
+	tx := &chaindb.ETH1Deposit{ETH1TxHash: []byte{0x01}}
+
+	addDepositToSummary(summary, &chaindb.Deposit{Amount: 32000000000}, &classifiedDeposit{class: depositNewValidator, eth1Deposit: tx})
+	// Second deposit in the same transaction.
+	addDepositToSummary(summary, &chaindb.Deposit{Amount: 1000000000}, &classifiedDeposit{class: depositTopUp, eth1Deposit: tx})
+	addDepositToSummary(summary, &chaindb.Deposit{Amount: 2000000000}, &classifiedDeposit{class: depositInvalid})
+
+	require.Equal(t, &chaindb.EpochDepositSummary{
+		Deposits:              3,
+		Amount:                35000000000,
+		NewValidators:         1,
+		NewValidatorsAmount:   32000000000,
+		TopUps:                1,
+		TopUpsAmount:          1000000000,
+		InvalidDeposits:       1,
+		InvalidDepositsAmount: 2000000000,
+		ETH1TxHashes:          [][]byte{{0x01}},
+	}, summary)
+}
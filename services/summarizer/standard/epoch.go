@@ -45,31 +45,78 @@ func (s *Service) summarizeEpoch(ctx context.Context,
 		return true, nil
 	}
 
-	summary := &chaindb.EpochSummary{
-		Epoch: epoch,
+	summary, updated, err := s.calculateEpochSummary(ctx, epoch, s.validatorAggregates)
+	if err != nil {
+		return false, err
+	}
+	if !updated {
+		return false, nil
 	}
 
-	activeValidators, err := s.validatorSummaryStatsForEpoch(ctx, epoch, summary)
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate validator summary statistics for epoch")
+		return false, errors.Wrap(err, "failed to begin transaction to set epoch summary")
+	}
+	if err := s.chainDB.(chaindb.EpochSummariesSetter).SetEpochSummary(ctx, summary); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to set epoch summary")
+	}
+	log.Trace().Uint64("md.lastEpoch", uint64(epoch)).Msg("Updated last epoch")
+	md.LastEpoch = epoch
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to set summarizer metadata for epoch summary")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to set commit transaction to set epoch summary")
 	}
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
+	monitorEpochSummarized(time.Since(started))
+
+	return true, nil
+}
+
+// calculateEpochSummary calculates the summary for a given epoch, using the supplied validator aggregates.
+// Returns false if there is not enough data to calculate the summary.
+func (s *Service) calculateEpochSummary(ctx context.Context,
+	epoch phase0.Epoch,
+	aggregates *validatorAggregates,
+) (
+	*chaindb.EpochSummary,
+	bool,
+	error,
+) {
+	started := time.Now()
+	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+
+	summary := &chaindb.EpochSummary{
+		Epoch:             epoch,
+		SummarizerVersion: summarizerVersion,
+	}
+
+	if !aggregates.covers(epoch) {
+		return nil, false, errors.New("validator aggregates do not cover epoch")
+	}
+	activeValidators := aggregates.statsForEpoch(epoch, summary)
 	if summary.ActiveValidators == 0 {
-		return false, errors.New("no active validators to summarize for epoch")
+		return nil, false, errors.New("no active validators to summarize for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set validator summary stats")
 
 	// Active balance and active effective balance.
-	balances, err := s.validatorsProvider.ValidatorBalancesByEpoch(ctx, epoch)
+	balances, err := s.validatorBalancesByEpoch(ctx, epoch)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to obtain validator balances")
+		return nil, false, errors.Wrap(err, "failed to obtain validator balances")
 	}
 	if len(balances) == 0 {
 		// This can happen if chaind does not have validator balances enabled, or has not yet obtained
 		// the balances.  We return false but no error.
-		return false, nil
+		return nil, false, nil
 	}
 	for i, balance := range balances {
-		if activeValidators[i] {
+		// Validators beyond the aggregates arrived after the epoch, so cannot be active in it.
+		if i < len(activeValidators) && activeValidators[i] {
 			summary.ActiveRealBalance += balance.Balance
 			summary.ActiveBalance += balance.EffectiveBalance
 		}
@@ -78,90 +125,35 @@ func (s *Service) summarizeEpoch(ctx context.Context,
 
 	err = s.blockStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate block summary statistics for epoch")
+		return nil, false, errors.Wrap(err, "failed to calculate block summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set block summary stats")
 
 	err = s.slashingsStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate slashings summary statistics for epoch")
+		return nil, false, errors.Wrap(err, "failed to calculate slashings summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set slashing stats")
 
 	err = s.attestationStatsForEpoch(ctx, epoch, balances, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate attestation summary statistics for epoch")
+		return nil, false, errors.Wrap(err, "failed to calculate attestation summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set attestation stats")
 
 	err = s.depositStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate deposit summary statistics for epoch")
+		return nil, false, errors.Wrap(err, "failed to calculate deposit summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set deposit stats")
 
 	err = s.withdrawalStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate withdrawal summary statistics for epoch")
+		return nil, false, errors.Wrap(err, "failed to calculate withdrawal summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set withdrawal stats")
 
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
-	if err != nil {
-		return false, errors.Wrap(err, "failed to begin transaction to set epoch summary")
-	}
-	if err := s.chainDB.(chaindb.EpochSummariesSetter).SetEpochSummary(ctx, summary); err != nil {
-		cancel()
-		return false, errors.Wrap(err, "failed to set epoch summary")
-	}
-	log.Trace().Uint64("md.lastEpoch", uint64(epoch)).Msg("Updated last epoch")
-	md.LastEpoch = epoch
-	if err := s.setMetadata(ctx, md); err != nil {
-		cancel()
-		return false, errors.Wrap(err, "failed to set summarizer metadata for epoch summary")
-	}
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		return false, errors.Wrap(err, "failed to set commit transaction to set epoch summary")
-	}
-	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
-
-	return true, nil
-}
-
-func (s *Service) validatorSummaryStatsForEpoch(ctx context.Context,
-	epoch phase0.Epoch,
-	summary *chaindb.EpochSummary,
-) (
-	[]bool,
-	error,
-) {
-	// Number of validators that are active, became active, and exited in this epoch.
-	validators, err := s.validatorsProvider.Validators(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to obtain validators")
-	}
-
-	activeValidators := make([]bool, len(validators))
-	for i, validator := range validators {
-		switch {
-		case validator.ActivationEpoch == epoch:
-			summary.ActiveValidators++
-			summary.ActivatingValidators++
-			activeValidators[i] = true
-		case validator.ExitEpoch == epoch:
-			summary.ExitingValidators++
-		case validator.ActivationEpoch <= epoch &&
-			validator.ExitEpoch > epoch:
-			summary.ActiveValidators++
-			activeValidators[i] = true
-		case validator.ActivationEligibilityEpoch <= epoch &&
-			validator.ActivationEpoch != s.farFutureEpoch &&
-			validator.ActivationEpoch > epoch:
-			summary.ActivationQueueLength++
-		}
-	}
-	return activeValidators, nil
+	return summary, true, nil
 }
 
 func (s *Service) blockStatsForEpoch(ctx context.Context,
@@ -183,6 +175,15 @@ func (s *Service) blockStatsForEpoch(ctx context.Context,
 		}
 		summary.CanonicalBlocks++
 	}
+
+	if skippedSlotsProvider, isProvider := s.chainDB.(chaindb.SkippedSlotsProvider); isProvider {
+		skippedSlots, err := skippedSlotsProvider.SkippedSlotsForSlotRange(ctx, minSlot, maxSlot+1)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain skipped slots")
+		}
+		summary.SkippedSlots = len(skippedSlots)
+	}
+
 	return nil
 }
 
@@ -291,11 +292,14 @@ func (s *Service) attestationStatsForEpoch(ctx context.Context,
 	}
 
 	// Fetch all attestations in the epoch for a simple count.
-	attestationsInEpoch, err := s.attestationsProvider.AttestationsInSlotRange(ctx, minSlot, maxSlot+1)
-	if err != nil {
-		return errors.Wrap(err, "failed to obtain attestations in epoch")
+	// Inclusions are not stored in aggregate mode, so there is nothing to count.
+	if !s.aggregateAttestations {
+		attestationsInEpoch, err := s.attestationsProvider.AttestationsInSlotRange(ctx, minSlot, maxSlot+1)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain attestations in epoch")
+		}
+		summary.AttestationsInEpoch = len(attestationsInEpoch)
 	}
-	summary.AttestationsInEpoch = len(attestationsInEpoch)
 
 	// epochAttestations contains the list of attestations we need to process.
 	attestingValidatorBalances := make(map[phase0.ValidatorIndex]phase0.Gwei)
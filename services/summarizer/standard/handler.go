@@ -49,6 +49,14 @@ func (s *Service) OnFinalityUpdated(
 		log.Warn().Err(err).Msg("Failed to update epochs")
 		return
 	}
+	if err := s.summarizeQueues(ctx, summaryEpoch); err != nil {
+		log.Warn().Err(err).Msg("Failed to update queues")
+		return
+	}
+	if err := s.summarizeDeposits(ctx, summaryEpoch); err != nil {
+		log.Warn().Err(err).Msg("Failed to update deposits")
+		return
+	}
 	if err := s.summarizeBlocks(ctx, summaryEpoch); err != nil {
 		log.Warn().Err(err).Msg("Failed to update blocks")
 		return
@@ -92,6 +100,10 @@ func (s *Service) summarizeEpochs(ctx context.Context, summaryEpoch phase0.Epoch
 	if lastEpoch != 0 {
 		lastEpoch++
 	}
+	if s.origin != nil && lastEpoch < s.origin.Epoch {
+		// There is no data from before the ingestion origin to summarize.
+		lastEpoch = s.origin.Epoch
+	}
 
 	// Limit the number of epochs summarised per pass, if we are also pruning.
 	maxEpochsPerRun := phase0.Epoch(s.maxDaysPerRun) * s.epochsPerDay()
@@ -101,6 +113,15 @@ func (s *Service) summarizeEpochs(ctx context.Context, summaryEpoch phase0.Epoch
 
 	log.Trace().Uint64("last_epoch", uint64(lastEpoch)).Uint64("summary_epoch", uint64(summaryEpoch)).Msg("Epochs catchup bounds")
 
+	if !s.validatorAggregates.covers(summaryEpoch) {
+		// Fetch the validator set once for this pass, rather than for each epoch.
+		aggregates, err := s.fetchValidatorAggregates(ctx, summaryEpoch)
+		if err != nil {
+			return err
+		}
+		s.validatorAggregates = aggregates
+	}
+
 	for epoch := lastEpoch; epoch <= summaryEpoch; epoch++ {
 		updated, err := s.summarizeEpoch(ctx, md, epoch)
 		if err != nil {
@@ -131,6 +152,10 @@ func (s *Service) summarizeBlocks(ctx context.Context,
 	if lastBlockEpoch != 0 {
 		lastBlockEpoch++
 	}
+	if s.origin != nil && lastBlockEpoch < s.origin.Epoch {
+		// There is no data from before the ingestion origin to summarize.
+		lastBlockEpoch = s.origin.Epoch
+	}
 	log.Trace().Uint64("last_epoch", uint64(lastBlockEpoch)).Uint64("summary_epoch", uint64(summaryEpoch)).Msg("Blocks catchup bounds")
 
 	// The last epoch updated in the metadata tells us how far we can summarize,
@@ -174,6 +199,10 @@ func (s *Service) summarizeValidators(ctx context.Context, summaryEpoch phase0.E
 	if lastValidatorEpoch != 0 {
 		lastValidatorEpoch++
 	}
+	if s.origin != nil && lastValidatorEpoch < s.origin.Epoch {
+		// There is no data from before the ingestion origin to summarize.
+		lastValidatorEpoch = s.origin.Epoch
+	}
 
 	// Limit the number of epochs summarised per pass, if we are also pruning.
 	maxEpochsPerRun := phase0.Epoch(s.maxDaysPerRun) * s.epochsPerDay()
@@ -182,8 +211,17 @@ func (s *Service) summarizeValidators(ctx context.Context, summaryEpoch phase0.E
 	}
 	log.Trace().Uint64("last_epoch", uint64(lastValidatorEpoch)).Uint64("summary_epoch", uint64(summaryEpoch)).Msg("Validators catchup bounds")
 
+	if err := s.updateWatchlist(ctx, md); err != nil {
+		return errors.Wrap(err, "failed to update watchlist")
+	}
+
 	for epoch := lastValidatorEpoch; epoch <= summaryEpoch; epoch++ {
 		if err := s.summarizeValidatorsInEpoch(ctx, md, epoch); err != nil {
+			if errors.Is(err, errIndeterminateBlock) {
+				// The finalizer has yet to reach this epoch; try again on a later pass.
+				log.Debug().Uint64("epoch", uint64(epoch)).Err(err).Msg("Blocks not yet finalized; not summarizing validators")
+				return nil
+			}
 			return errors.Wrap(err, fmt.Sprintf("failed to update validator summaries in epoch %d", epoch))
 		}
 	}
@@ -203,11 +241,19 @@ func (s *Service) summarizeValidatorDays(ctx context.Context) error {
 	if epochSummariesTime.After(daySummariesTime.AddDate(0, 0, 1)) {
 		// We have updates.
 		var startTime time.Time
-		if md.LastValidatorDay == -1 {
+		switch {
+		case md.LastValidatorDay == -1 && s.origin != nil:
+			// Start at the beginning of the first full day after the ingestion origin.
+			origin := s.chainTime.StartOfEpoch(s.origin.Epoch).In(time.UTC)
+			startTime = time.Date(origin.Year(), origin.Month(), origin.Day(), 0, 0, 0, 0, time.UTC)
+			if startTime.Before(origin) {
+				startTime = startTime.AddDate(0, 0, 1)
+			}
+		case md.LastValidatorDay == -1:
 			// Start at the beginning of the day in which genesis occurred.
 			genesis := s.chainTime.GenesisTime().In(time.UTC)
 			startTime = time.Date(genesis.Year(), genesis.Month(), genesis.Day(), 0, 0, 0, 0, time.UTC)
-		} else {
+		default:
 			startTime = daySummariesTime.AddDate(0, 0, 1)
 		}
 		endTimestamp := epochSummariesTime.AddDate(0, 0, -1)
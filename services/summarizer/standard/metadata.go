@@ -26,8 +26,16 @@ type metadata struct {
 	LastValidatorEpoch       phase0.Epoch `json:"latest_validator_epoch"`
 	LastBlockEpoch           phase0.Epoch `json:"latest_block_epoch"`
 	LastEpoch                phase0.Epoch `json:"latest_epoch"`
+	LastQueueEpoch           phase0.Epoch `json:"latest_queue_epoch"`
+	LastDepositEpoch         phase0.Epoch `json:"latest_deposit_epoch"`
 	LastValidatorDay         int64        `json:"last_validator_day"`
 	PeriodicValidatorRollups bool         `json:"periodic_validator_rollups"`
+	// QueueTrackingEpoch is the epoch from which exit queues are tracked.  Exit queues
+	// cannot be calculated for earlier epochs, so are not stored for them.
+	QueueTrackingEpoch *phase0.Epoch `json:"queue_tracking_epoch,omitempty"`
+	// ValidatorWatchlist is the watchlist for which validator summaries have been
+	// generated, if a watchlist is in use.
+	ValidatorWatchlist *[]phase0.ValidatorIndex `json:"validator_watchlist,omitempty"`
 }
 
 // metadataKey is the key for the metadata.
@@ -62,3 +70,17 @@ func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
 	}
 	return nil
 }
+
+// LatestCompleteEpoch returns the latest epoch for which summaries have been processed.
+func (s *Service) LatestCompleteEpoch(ctx context.Context) (phase0.Epoch, bool, error) {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if md.LastEpoch == 0 {
+		// Metadata does not distinguish between epoch 0 and no epoch.
+		return 0, false, nil
+	}
+
+	return md.LastEpoch, true, nil
+}
@@ -15,10 +15,12 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/metrics"
 )
 
@@ -36,6 +38,19 @@ var (
 	daysProcessed prometheus.Counter
 )
 
+var (
+	epochSummaryDuration prometheus.Gauge
+	consistencyChecks    *prometheus.CounterVec
+	resummarizations     *prometheus.CounterVec
+)
+
+var (
+	activationQueueLength prometheus.Gauge
+	exitQueueLength       prometheus.Gauge
+)
+
+var epochMissedProposals prometheus.Gauge
+
 var (
 	lastEpochPrune   prometheus.Gauge
 	lastBalancePrune prometheus.Gauge
@@ -75,6 +90,60 @@ func registerPrometheusMetrics() error {
 		return errors.Wrap(err, "failed to register epochs_processed_total")
 	}
 
+	epochSummaryDuration = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "epoch_summary_duration_seconds",
+		Help:      "Time taken to summarize the most recent epoch",
+	})
+	if err := prometheus.Register(epochSummaryDuration); err != nil {
+		return errors.Wrap(err, "failed to register epoch_summary_duration_seconds")
+	}
+
+	consistencyChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "consistency_checks_total",
+		Help:      "Number of epoch summary consistency checks",
+	}, []string{"result"})
+	if err := prometheus.Register(consistencyChecks); err != nil {
+		return errors.Wrap(err, "failed to register consistency_checks_total")
+	}
+
+	resummarizations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "resummarizations_total",
+		Help:      "Number of epochs and days resummarized",
+	}, []string{"result"})
+	if err := prometheus.Register(resummarizations); err != nil {
+		return errors.Wrap(err, "failed to register resummarizations_total")
+	}
+
+	activationQueueLength = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "activation_queue_length",
+		Help:      "Number of validators awaiting activation at the latest queue summary",
+	})
+	if err := prometheus.Register(activationQueueLength); err != nil {
+		return errors.Wrap(err, "failed to register activation_queue_length")
+	}
+
+	exitQueueLength = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "exit_queue_length",
+		Help:      "Number of validators awaiting exit at the latest queue summary",
+	})
+	if err := prometheus.Register(exitQueueLength); err != nil {
+		return errors.Wrap(err, "failed to register exit_queue_length")
+	}
+
+	epochMissedProposals = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "epoch_missed_proposals",
+		Help:      "Number of proposer duties without a canonical block in the latest validator epoch summary",
+	})
+	if err := prometheus.Register(epochMissedProposals); err != nil {
+		return errors.Wrap(err, "failed to register epoch_missed_proposals")
+	}
+
 	latestDay = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "latest_day",
@@ -164,3 +233,36 @@ func monitorEpochPruned() {
 		lastEpochPrune.SetToCurrentTime()
 	}
 }
+
+func monitorEpochSummarized(duration time.Duration) {
+	if epochSummaryDuration != nil {
+		epochSummaryDuration.Set(duration.Seconds())
+	}
+}
+
+func monitorConsistencyCheck(result string) {
+	if consistencyChecks != nil {
+		consistencyChecks.WithLabelValues(result).Inc()
+	}
+}
+
+func monitorResummarized(result string) {
+	if resummarizations != nil {
+		resummarizations.WithLabelValues(result).Inc()
+	}
+}
+
+func monitorQueues(summary *chaindb.EpochQueueSummary) {
+	if activationQueueLength != nil {
+		activationQueueLength.Set(float64(summary.ActivationQueueLength))
+	}
+	if exitQueueLength != nil && summary.ExitQueueLength != nil {
+		exitQueueLength.Set(float64(*summary.ExitQueueLength))
+	}
+}
+
+func monitorMissedProposals(missed int) {
+	if epochMissedProposals != nil {
+		epochMissedProposals.Set(float64(missed))
+	}
+}
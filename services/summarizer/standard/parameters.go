@@ -15,12 +15,16 @@ package standard
 
 import (
 	"errors"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/blocks"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/services/watchlist"
 )
 
 type parameters struct {
@@ -35,6 +39,12 @@ type parameters struct {
 	validatorEpochRetention   string
 	maxDaysPerRun             uint64
 	validatorBalanceRetention string
+	scheduler                 scheduler.Service
+	consistencyCheckInterval  time.Duration
+	storedBlockComponents     blocks.StoredComponents
+	watchlist                 watchlist.Service
+	resummarizeInterval       time.Duration
+	resummarizeOutdated       bool
 }
 
 // Parameter is the interface for service parameters.
@@ -125,10 +135,58 @@ func WithValidatorBalanceRetention(retention string) Parameter {
 	})
 }
 
+// WithScheduler sets the scheduler for this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithConsistencyCheckInterval sets the interval between consistency checks of epoch summaries.
+// A value of 0 disables the checks.
+func WithConsistencyCheckInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.consistencyCheckInterval = interval
+	})
+}
+
+// WithStoredBlockComponents sets the components of blocks that are stored in the database.
+// Components that are not present are stored.
+func WithStoredBlockComponents(components blocks.StoredComponents) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.storedBlockComponents = components
+	})
+}
+
+// WithWatchlist sets a watchlist, restricting the validator summaries generated to
+// those of the validators on the watchlist.
+func WithWatchlist(watchlist watchlist.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.watchlist = watchlist
+	})
+}
+
+// WithResummarizeInterval sets the interval between resummarizing each epoch or day,
+// limiting the load placed on the database by resummarization.
+func WithResummarizeInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.resummarizeInterval = interval
+	})
+}
+
+// WithResummarizeOutdated states if the module should resummarize summaries produced
+// by earlier versions of the summarizer when it starts.
+func WithResummarizeOutdated(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.resummarizeOutdated = enabled
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel: zerolog.GlobalLevel(),
+		logLevel:            zerolog.GlobalLevel(),
+		resummarizeInterval: time.Second,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -148,6 +206,21 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.maxDaysPerRun == 0 {
 		return nil, errors.New("no max days per run specified")
 	}
+	if parameters.consistencyCheckInterval < 0 {
+		return nil, errors.New("consistency check interval cannot be negative")
+	}
+	if parameters.consistencyCheckInterval > 0 && parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified for consistency checks")
+	}
+	if parameters.resummarizeInterval < 0 {
+		return nil, errors.New("resummarize interval cannot be negative")
+	}
+	if parameters.resummarizeOutdated && parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified for resummarization")
+	}
+	if err := parameters.checkBlockComponents(); err != nil {
+		return nil, err
+	}
 
 	return &parameters, nil
 }
@@ -0,0 +1,80 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// errIndeterminateBlock is returned when a block in an epoch has yet to have its canonical status set.
+var errIndeterminateBlock = errors.New("indeterminate block")
+
+// slotProposalsForEpoch works out the outcome of each proposer duty in the epoch.
+func (s *Service) slotProposalsForEpoch(ctx context.Context,
+	epoch phase0.Epoch,
+	proposerDuties []*chaindb.ProposerDuty,
+) (
+	[]*chaindb.SlotProposal,
+	error,
+) {
+	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+	maxSlot := s.chainTime.LastSlotOfEpoch(epoch)
+	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx, minSlot, maxSlot+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+
+	return slotProposals(proposerDuties, blocks)
+}
+
+// slotProposals works out the outcome of each proposer duty given the blocks for its slots.
+// It returns errIndeterminateBlock if the canonical status of any block is unknown.
+func slotProposals(proposerDuties []*chaindb.ProposerDuty,
+	blocks []*chaindb.Block,
+) (
+	[]*chaindb.SlotProposal,
+	error,
+) {
+	outcomes := make(map[phase0.Slot]chaindb.ProposalOutcome, len(blocks))
+	for _, block := range blocks {
+		if block.Canonical == nil {
+			return nil, errors.Wrapf(errIndeterminateBlock, "slot %d", block.Slot)
+		}
+		switch {
+		case *block.Canonical:
+			outcomes[block.Slot] = chaindb.ProposalOutcomeCanonical
+		case outcomes[block.Slot] != chaindb.ProposalOutcomeCanonical:
+			outcomes[block.Slot] = chaindb.ProposalOutcomeOrphaned
+		}
+	}
+
+	proposals := make([]*chaindb.SlotProposal, 0, len(proposerDuties))
+	for _, proposerDuty := range proposerDuties {
+		if proposerDuty.Slot == 0 {
+			// Genesis has no proposal.
+			continue
+		}
+		proposals = append(proposals, &chaindb.SlotProposal{
+			Slot:          proposerDuty.Slot,
+			ProposerIndex: proposerDuty.ValidatorIndex,
+			Outcome:       outcomes[proposerDuty.Slot],
+		})
+	}
+
+	return proposals, nil
+}
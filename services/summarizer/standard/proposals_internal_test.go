@@ -0,0 +1,107 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestSlotProposals(t *testing.T) {
+	canonical := true
+	nonCanonical := false
+
+	duties := func(slots ...phase0.Slot) []*chaindb.ProposerDuty {
+		res := make([]*chaindb.ProposerDuty, len(slots))
+		for i, slot := range slots {
+			res[i] = &chaindb.ProposerDuty{
+				Slot:           slot,
+				ValidatorIndex: phase0.ValidatorIndex(100 + slot),
+			}
+		}
+		return res
+	}
+	block := func(slot phase0.Slot, canonical *bool) *chaindb.Block {
+		return &chaindb.Block{
+			Slot:          slot,
+			ProposerIndex: phase0.ValidatorIndex(100 + slot),
+			Canonical:     canonical,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		duties    []*chaindb.ProposerDuty
+		blocks    []*chaindb.Block
+		proposals []*chaindb.SlotProposal
+		err       string
+	}{
+		{
+			name:      "Empty",
+			proposals: []*chaindb.SlotProposal{},
+		},
+		{
+			name:   "Outcomes",
+			duties: duties(32, 33, 34, 35),
+			blocks: []*chaindb.Block{
+				block(32, &canonical),
+				block(33, &nonCanonical),
+				// Orphaned and canonical blocks in the same slot.
+				block(35, &nonCanonical),
+				block(35, &canonical),
+			},
+			proposals: []*chaindb.SlotProposal{
+				{Slot: 32, ProposerIndex: 132, Outcome: chaindb.ProposalOutcomeCanonical},
+				{Slot: 33, ProposerIndex: 133, Outcome: chaindb.ProposalOutcomeOrphaned},
+				{Slot: 34, ProposerIndex: 134, Outcome: chaindb.ProposalOutcomeMissed},
+				{Slot: 35, ProposerIndex: 135, Outcome: chaindb.ProposalOutcomeCanonical},
+			},
+		},
+		{
+			name:   "Genesis",
+			duties: duties(0, 1),
+			blocks: []*chaindb.Block{
+				block(0, &canonical),
+			},
+			proposals: []*chaindb.SlotProposal{
+				{Slot: 1, ProposerIndex: 101, Outcome: chaindb.ProposalOutcomeMissed},
+			},
+		},
+		{
+			name:   "Indeterminate",
+			duties: duties(32, 33),
+			blocks: []*chaindb.Block{
+				block(32, &canonical),
+				block(33, nil),
+			},
+			err: "slot 33: indeterminate block",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proposals, err := slotProposals(test.duties, test.blocks)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				require.ErrorIs(t, err, errIndeterminateBlock)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.proposals, proposals)
+			}
+		})
+	}
+}
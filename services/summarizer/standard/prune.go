@@ -23,6 +23,9 @@ import (
 )
 
 func (s *Service) prune(ctx context.Context, summaryEpoch phase0.Epoch) error {
+	// Pruning deletes large numbers of rows, so is known to be long-running.
+	ctx = chaindb.WithLongRunning(ctx)
+
 	if s.validatorBalanceRetention != nil {
 		if err := s.pruneBalances(ctx, summaryEpoch); err != nil {
 			return err
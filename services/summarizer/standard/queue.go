@@ -0,0 +1,159 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// queueSummariesPerTx is the number of epoch queue summaries written in each transaction.
+const queueSummariesPerTx = 1024
+
+// summarizeQueues summarizes the validator activation and exit queues for each epoch
+// up to the summary epoch, starting from the earliest epoch if none have been summarized.
+func (s *Service) summarizeQueues(ctx context.Context, summaryEpoch phase0.Epoch) error {
+	if !s.epochSummaries {
+		return nil
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for queue summarizer")
+	}
+
+	firstEpoch := md.LastQueueEpoch
+	if firstEpoch != 0 {
+		firstEpoch++
+	} else {
+		firstEpoch = 1
+	}
+	if s.origin != nil && firstEpoch < s.origin.Epoch {
+		firstEpoch = s.origin.Epoch
+	}
+	if firstEpoch > summaryEpoch {
+		return nil
+	}
+	log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("summary_epoch", uint64(summaryEpoch)).Msg("Queues catchup bounds")
+
+	if md.QueueTrackingEpoch == nil {
+		// Validator records do not hold the epoch at which an exit was initiated, so
+		// exit queues can only be tracked from the current epoch onwards.  This is
+		// stored in metadata along with the first summaries.
+		trackingEpoch := s.chainTime.CurrentEpoch()
+		md.QueueTrackingEpoch = &trackingEpoch
+		log.Debug().Uint64("tracking_epoch", uint64(trackingEpoch)).Msg("Tracking exit queues")
+	}
+
+	if !s.validatorAggregates.covers(summaryEpoch) {
+		aggregates, err := s.fetchValidatorAggregates(ctx, summaryEpoch)
+		if err != nil {
+			return err
+		}
+		s.validatorAggregates = aggregates
+	}
+
+	summaries := make([]*chaindb.EpochQueueSummary, 0, queueSummariesPerTx)
+	for epoch := firstEpoch; epoch <= summaryEpoch; epoch++ {
+		summaries = append(summaries, s.queueSummaryForEpoch(epoch, s.validatorAggregates, *md.QueueTrackingEpoch))
+		if len(summaries) == queueSummariesPerTx || epoch == summaryEpoch {
+			if err := s.storeQueueSummaries(ctx, md, summaries); err != nil {
+				return err
+			}
+			summaries = summaries[:0]
+		}
+	}
+
+	return nil
+}
+
+// queueSummaryForEpoch calculates the queue summary for the given epoch.
+// Exit queues are only calculated for epochs from the tracking epoch onwards.
+func (s *Service) queueSummaryForEpoch(epoch phase0.Epoch,
+	aggregates *validatorAggregates,
+	trackingEpoch phase0.Epoch,
+) *chaindb.EpochQueueSummary {
+	stats := &chaindb.EpochSummary{}
+	aggregates.statsForEpoch(epoch, stats)
+
+	churnLimit := s.churnLimit(stats.ActiveValidators)
+	activationChurnLimit := s.activationChurnLimit(epoch, churnLimit)
+
+	summary := &chaindb.EpochQueueSummary{
+		Epoch:                 epoch,
+		ActivationQueueLength: stats.ActivationQueueLength,
+		ChurnLimit:            churnLimit,
+		ActivationChurnLimit:  activationChurnLimit,
+		ActivationWait:        phase0.Epoch(uint64(stats.ActivationQueueLength)/activationChurnLimit) + 1 + s.maxSeedLookahead,
+	}
+	if epoch >= trackingEpoch {
+		exitQueueLength, exitEpoch := aggregates.exitQueue(churnLimit, s.maxSeedLookahead)
+		exitWait := exitEpoch - epoch
+		summary.ExitQueueLength = &exitQueueLength
+		summary.ExitWait = &exitWait
+	}
+
+	return summary
+}
+
+// churnLimit returns the validator churn limit for the given number of active validators.
+func (s *Service) churnLimit(activeValidators int) uint64 {
+	churnLimit := uint64(activeValidators) / s.churnLimitQuotient
+	if churnLimit < s.minPerEpochChurnLimit {
+		churnLimit = s.minPerEpochChurnLimit
+	}
+
+	return churnLimit
+}
+
+// activationChurnLimit returns the validator activation churn limit at the given epoch.
+// From Deneb activations are additionally capped (EIP-7514).
+func (s *Service) activationChurnLimit(epoch phase0.Epoch, churnLimit uint64) uint64 {
+	if epoch >= s.chainTime.DenebInitialEpoch() && churnLimit > s.maxPerEpochActivationChurnLimit {
+		return s.maxPerEpochActivationChurnLimit
+	}
+
+	return churnLimit
+}
+
+// storeQueueSummaries stores queue summaries, and notes the last of them in metadata.
+func (s *Service) storeQueueSummaries(ctx context.Context, md *metadata, summaries []*chaindb.EpochQueueSummary) error {
+	if len(summaries) == 0 {
+		return nil
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set epoch queue summaries")
+	}
+	if err := s.chainDB.(chaindb.EpochQueueSummariesSetter).SetEpochQueueSummaries(ctx, summaries); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set epoch queue summaries")
+	}
+	md.LastQueueEpoch = summaries[len(summaries)-1].Epoch
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set summarizer metadata for epoch queue summaries")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set commit transaction to set epoch queue summaries")
+	}
+	monitorQueues(summaries[len(summaries)-1])
+
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestQueueSummaryForEpoch(t *testing.T) {
+	farFutureEpoch := phase0.Epoch(0xffffffffffffffff)
+	s := &Service{
+		chainTime:                       mockchaintime.New(),
+		churnLimitQuotient:              4,
+		minPerEpochChurnLimit:           2,
+		maxPerEpochActivationChurnLimit: 3,
+		maxSeedLookahead:                4,
+	}
+
+	// 20 active validators, 10 in the activation queue.
+	validators := make([]*chaindb.Validator, 0)
+	for i := 0; i < 30; i++ {
+		validator := &chaindb.Validator{
+			Index:                      phase0.ValidatorIndex(i),
+			ActivationEligibilityEpoch: 0,
+			ActivationEpoch:            0,
+			ExitEpoch:                  farFutureEpoch,
+		}
+		if i >= 20 {
+			validator.ActivationEligibilityEpoch = 5
+			validator.ActivationEpoch = 50
+		}
+		validators = append(validators, validator)
+	}
+	aggregates := newValidatorAggregates(validators, farFutureEpoch, 20)
+
+	exitQueueLength := 0
+	exitWait := phase0.Epoch(5)
+	summary := s.queueSummaryForEpoch(10, aggregates, 10)
+	require.Equal(t, &chaindb.EpochQueueSummary{
+		Epoch:                 10,
+		ActivationQueueLength: 10,
+		ExitQueueLength:       &exitQueueLength,
+		ChurnLimit:            5,
+		// Deneb is active from genesis in the mock, so activations are capped.
+		ActivationChurnLimit: 3,
+		ActivationWait:       8,
+		ExitWait:             &exitWait,
+	}, summary)
+
+	// Exit queues are not calculated before the tracking epoch.
+	summary = s.queueSummaryForEpoch(10, aggregates, 11)
+	require.Equal(t, &chaindb.EpochQueueSummary{
+		Epoch:                 10,
+		ActivationQueueLength: 10,
+		ChurnLimit:            5,
+		ActivationChurnLimit:  3,
+		ActivationWait:        8,
+	}, summary)
+}
+
+func TestChurnLimit(t *testing.T) {
+	s := &Service{
+		churnLimitQuotient:    65536,
+		minPerEpochChurnLimit: 4,
+	}
+	require.Equal(t, uint64(4), s.churnLimit(0))
+	require.Equal(t, uint64(4), s.churnLimit(300000))
+	require.Equal(t, uint64(15), s.churnLimit(1000000))
+}
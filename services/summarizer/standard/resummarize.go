@@ -0,0 +1,500 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+// summarizerVersion is the version of the summarizer calculations.  It is stored with
+// each summary, and must be incremented whenever a change alters the summaries produced,
+// so that summaries produced by earlier versions can be found and resummarized.
+const summarizerVersion = 1
+
+// resummarizeJobClass is the scheduler class of resummarization jobs.
+const resummarizeJobClass = "maintenance"
+
+// resummarizeState is the state of a resummarization job.
+// Epoch jobs use the epoch fields, day jobs the day fields.
+type resummarizeState struct {
+	nextEpoch  phase0.Epoch
+	toEpoch    phase0.Epoch
+	aggregates *validatorAggregates
+	nextDay    time.Time
+	toDay      time.Time
+	days       bool
+	started    bool
+	failed     bool
+}
+
+// done returns true if there is nothing more to resummarize.
+func (r *resummarizeState) done() bool {
+	if r.failed {
+		return true
+	}
+	if r.days {
+		return r.nextDay.After(r.toDay)
+	}
+
+	return r.nextEpoch > r.toEpoch
+}
+
+// Resummarize recalculates the epoch, block and validator epoch summaries for the given
+// range of epochs, inclusive, replacing those already stored.  Only summaries that have
+// already been generated are recalculated.  Resummarization runs in the background as a
+// job in the maintenance class, one epoch at a time.
+func (s *Service) Resummarize(ctx context.Context, from phase0.Epoch, to phase0.Epoch) error {
+	if err := s.checkResummarize(); err != nil {
+		return err
+	}
+	if from > to {
+		return errors.New("from epoch must not be after to epoch")
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+	lastEpoch := md.LastEpoch
+	if md.LastBlockEpoch > lastEpoch {
+		lastEpoch = md.LastBlockEpoch
+	}
+	if md.LastValidatorEpoch > lastEpoch {
+		lastEpoch = md.LastValidatorEpoch
+	}
+	if to > lastEpoch {
+		return fmt.Errorf("epoch %d has not been summarized", to)
+	}
+
+	return s.scheduleResummarize(fmt.Sprintf("summarizer-resummarize-epochs-%d-%d", from, to), &resummarizeState{
+		nextEpoch: from,
+		toEpoch:   to,
+	})
+}
+
+// ResummarizeDays recalculates the validator day summaries for the days starting
+// within the given range of times, inclusive, replacing those already stored.
+// Resummarization runs in the background as a job in the maintenance class, one day
+// at a time.
+func (s *Service) ResummarizeDays(ctx context.Context, from time.Time, to time.Time) error {
+	if err := s.checkResummarize(); err != nil {
+		return err
+	}
+	from = startOfDay(from)
+	to = startOfDay(to)
+	if from.After(to) {
+		return errors.New("from day must not be after to day")
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+	if md.LastValidatorDay == -1 || to.Unix() > md.LastValidatorDay {
+		return fmt.Errorf("day %s has not been summarized", to.Format("2006-01-02"))
+	}
+
+	return s.scheduleResummarize(fmt.Sprintf("summarizer-resummarize-days-%s-%s", from.Format("2006-01-02"), to.Format("2006-01-02")), &resummarizeState{
+		nextDay: from,
+		toDay:   to,
+		days:    true,
+	})
+}
+
+// checkResummarize checks that the service is able to resummarize.
+func (s *Service) checkResummarize() error {
+	if s.scheduler == nil {
+		return errors.New("no scheduler specified for resummarization")
+	}
+	if _, isDeleter := s.chainDB.(chaindb.SummariesDeleter); !isDeleter {
+		return errors.New("chain DB does not delete summaries")
+	}
+
+	return nil
+}
+
+// scheduleResummarize schedules a resummarization job.
+func (s *Service) scheduleResummarize(name string, state *resummarizeState) error {
+	log.Info().Str("job", name).Msg("Scheduling resummarization")
+
+	// The job outlives the request that created it, so is scheduled with the service's context.
+	return s.scheduler.SchedulePeriodicJob(s.ctx,
+		resummarizeJobClass,
+		name,
+		s.nextResummarize,
+		state,
+		s.resummarize,
+		state,
+		scheduler.WithCancelRunning(true),
+	)
+}
+
+// nextResummarize returns the time of the next resummarization run.
+func (s *Service) nextResummarize(_ context.Context, data interface{}) (time.Time, error) {
+	state, ok := data.(*resummarizeState)
+	if !ok {
+		return time.Time{}, errors.New("resummarization state of unexpected type")
+	}
+	if state.done() {
+		return time.Time{}, scheduler.ErrNoMoreInstances
+	}
+	if !state.started {
+		state.started = true
+		return time.Now(), nil
+	}
+
+	return time.Now().Add(s.resummarizeInterval), nil
+}
+
+// resummarize resummarizes the next epoch or day of a resummarization job.
+func (s *Service) resummarize(ctx context.Context, data interface{}) error {
+	state, ok := data.(*resummarizeState)
+	if !ok {
+		return errors.New("resummarization state of unexpected type")
+	}
+
+	// Resummarization shares the summary tables with the handler, so waits for it to finish.
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		return errors.Wrap(err, "failed to acquire activity semaphore")
+	}
+	defer s.activitySem.Release(1)
+
+	var err error
+	if state.days {
+		err = s.resummarizeDay(ctx, state.nextDay)
+	} else {
+		err = s.resummarizeNextEpoch(ctx, state)
+	}
+	if err != nil {
+		// Stop the job rather than repeatedly failing on the same epoch or day.
+		state.failed = true
+		monitorResummarized("failed")
+		return err
+	}
+	monitorResummarized("succeeded")
+
+	if state.days {
+		state.nextDay = state.nextDay.AddDate(0, 0, 1)
+	} else {
+		state.nextEpoch++
+	}
+
+	return nil
+}
+
+// resummarizeNextEpoch resummarizes the next epoch of an epoch resummarization job.
+func (s *Service) resummarizeNextEpoch(ctx context.Context, state *resummarizeState) error {
+	if state.aggregates == nil {
+		// Fetch the validator set once for the job, rather than for each epoch.
+		aggregates, err := s.fetchValidatorAggregates(ctx, state.toEpoch)
+		if err != nil {
+			return err
+		}
+		state.aggregates = aggregates
+	}
+
+	return s.resummarizeEpoch(ctx, state.nextEpoch, state.aggregates)
+}
+
+// resummarizeEpoch recalculates and replaces the summaries for the given epoch.
+func (s *Service) resummarizeEpoch(ctx context.Context,
+	epoch phase0.Epoch,
+	aggregates *validatorAggregates,
+) error {
+	started := time.Now()
+	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+	log.Trace().Msg("Resummarizing epoch")
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for resummarization")
+	}
+
+	// All summaries are calculated before any are replaced, so that a failure leaves
+	// the existing summaries in place.
+	var epochSummary *chaindb.EpochSummary
+	if s.epochSummaries && epoch != 0 && epoch <= md.LastEpoch {
+		summary, calculated, err := s.calculateEpochSummary(ctx, epoch, aggregates)
+		if err != nil {
+			return errors.Wrap(err, "failed to recalculate epoch summary")
+		}
+		if calculated {
+			epochSummary = summary
+		} else {
+			// Most likely the balances for the epoch have been pruned.
+			log.Debug().Msg("Not enough data to recalculate epoch summary; retaining existing summary")
+		}
+	}
+
+	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+	maxSlot := s.chainTime.LastSlotOfEpoch(epoch)
+	resummarizeBlocks := s.blockSummaries && epoch <= md.LastBlockEpoch
+	blockSummaries := make([]*chaindb.BlockSummary, 0)
+	if resummarizeBlocks {
+		for slot := minSlot; slot <= maxSlot; slot++ {
+			summary, err := s.blockSummary(ctx, slot)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to recalculate summary for block %d", slot))
+			}
+			if summary != nil {
+				blockSummaries = append(blockSummaries, summary)
+			}
+		}
+	}
+
+	resummarizeValidators := false
+	var validatorSummaries []*chaindb.ValidatorEpochSummary
+	var proposals []*chaindb.SlotProposal
+	if s.validatorSummaries && epoch <= md.LastValidatorEpoch {
+		// Do not replace validator summaries that have been pruned.
+		resummarizeValidators, err = s.hasValidatorEpochSummaries(ctx, epoch)
+		if err != nil {
+			return err
+		}
+		if resummarizeValidators {
+			validatorSummaries, proposals, _, err = s.validatorEpochSummaries(ctx, epoch)
+			if err != nil {
+				return errors.Wrap(err, "failed to recalculate validator epoch summaries")
+			}
+			if md.ValidatorWatchlist != nil {
+				validatorSummaries = watchedValidatorEpochSummaries(validatorSummaries, *md.ValidatorWatchlist)
+			}
+		} else {
+			log.Debug().Msg("Validator epoch summaries have been pruned; not recalculating")
+		}
+	}
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Recalculated summaries")
+
+	deleter := s.chainDB.(chaindb.SummariesDeleter)
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to resummarize epoch")
+	}
+	if epochSummary != nil {
+		if err := deleter.DeleteEpochSummaries(ctx, epoch, epoch); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to delete epoch summary")
+		}
+		if err := s.chainDB.(chaindb.EpochSummariesSetter).SetEpochSummary(ctx, epochSummary); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set epoch summary")
+		}
+	}
+	if resummarizeBlocks {
+		if err := deleter.DeleteBlockSummaries(ctx, minSlot, maxSlot); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to delete block summaries")
+		}
+		for _, summary := range blockSummaries {
+			if err := s.chainDB.(chaindb.BlockSummariesSetter).SetBlockSummary(ctx, summary); err != nil {
+				cancel()
+				return errors.Wrap(err, "failed to set block summary")
+			}
+		}
+	}
+	if resummarizeValidators {
+		if err := deleter.DeleteValidatorEpochSummaries(ctx, epoch, epoch); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to delete validator epoch summaries")
+		}
+		if err := s.chainDB.(chaindb.ValidatorEpochSummariesSetter).SetValidatorEpochSummaries(ctx, validatorSummaries); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set validator epoch summaries")
+		}
+		if err := s.chainDB.(chaindb.SlotProposalsSetter).SetSlotProposals(ctx, proposals); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set slot proposals")
+		}
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction to resummarize epoch")
+	}
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Resummarized epoch")
+
+	return nil
+}
+
+// resummarizeDay recalculates and replaces the validator day summaries for the given day.
+func (s *Service) resummarizeDay(ctx context.Context, startTime time.Time) error {
+	// Rolling up a day across all validators is known to be long-running.
+	ctx = chaindb.WithLongRunning(ctx)
+	log := log.With().Str("date", startTime.Format("2006-01-02")).Logger()
+	log.Trace().Msg("Resummarizing validator day")
+
+	// Day summaries are calculated from validator epoch summaries, so cannot be
+	// recalculated once those have been pruned.
+	endTime := startTime.AddDate(0, 0, 1)
+	for _, epoch := range []phase0.Epoch{
+		s.chainTime.TimestampToEpoch(startTime),
+		s.chainTime.TimestampToEpoch(endTime) - 1,
+	} {
+		present, err := s.hasValidatorEpochSummaries(ctx, epoch)
+		if err != nil {
+			return err
+		}
+		if !present {
+			log.Debug().Msg("Validator epoch summaries have been pruned; not recalculating day summaries")
+			return nil
+		}
+	}
+
+	summaries, found, err := s.validatorDaySummaries(ctx, startTime, endTime)
+	if err != nil {
+		return errors.Wrap(err, "failed to recalculate validator day summaries")
+	}
+	if !found {
+		// Most likely the balances for the day have been pruned.
+		log.Debug().Msg("Validator balances not available; not recalculating day summaries")
+		return nil
+	}
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for resummarization")
+	}
+	if md.ValidatorWatchlist != nil {
+		summaries = watchedValidatorDaySummaries(summaries, *md.ValidatorWatchlist)
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to resummarize validator day")
+	}
+	if err := s.chainDB.(chaindb.SummariesDeleter).DeleteValidatorDaySummaries(ctx, startTime, startTime); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to delete validator day summaries")
+	}
+	if err := s.chainDB.(chaindb.ValidatorDaySummariesSetter).SetValidatorDaySummaries(ctx, summaries); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set validator day summaries")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction to resummarize validator day")
+	}
+	log.Trace().Msg("Resummarized validator day")
+
+	return nil
+}
+
+// hasValidatorEpochSummaries returns true if validator epoch summaries are stored for the given epoch.
+func (s *Service) hasValidatorEpochSummaries(ctx context.Context, epoch phase0.Epoch) (bool, error) {
+	summaries, err := s.chainDB.(chaindb.ValidatorEpochSummariesProvider).ValidatorSummaries(ctx, &chaindb.ValidatorSummaryFilter{
+		Limit: 1,
+		From:  &epoch,
+		To:    &epoch,
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to obtain validator epoch summaries")
+	}
+
+	return len(summaries) > 0, nil
+}
+
+// resummarizeOutdated schedules resummarization of the summaries produced by earlier
+// versions of the summarizer.
+func (s *Service) resummarizeOutdated(ctx context.Context) error {
+	provider, isProvider := s.chainDB.(chaindb.OutdatedSummariesProvider)
+	if !isProvider {
+		return errors.New("chain DB does not provide outdated summaries")
+	}
+
+	from, to, found, err := s.outdatedEpochs(ctx, provider)
+	if err != nil {
+		return err
+	}
+	if found {
+		log.Info().Uint64("from", uint64(from)).Uint64("to", uint64(to)).Msg("Found outdated epoch summaries")
+		if err := s.Resummarize(ctx, from, to); err != nil {
+			return errors.Wrap(err, "failed to resummarize outdated epochs")
+		}
+	}
+
+	fromDay, toDay, found, err := provider.OutdatedValidatorDaySummaries(ctx, summarizerVersion)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain outdated validator day summaries")
+	}
+	if found {
+		log.Info().Time("from", fromDay).Time("to", toDay).Msg("Found outdated validator day summaries")
+		if err := s.ResummarizeDays(ctx, fromDay, toDay); err != nil {
+			return errors.Wrap(err, "failed to resummarize outdated days")
+		}
+	}
+
+	return nil
+}
+
+// outdatedEpochs returns the range of epochs covered by outdated epoch, block and
+// validator epoch summaries.
+func (s *Service) outdatedEpochs(ctx context.Context,
+	provider chaindb.OutdatedSummariesProvider,
+) (
+	phase0.Epoch,
+	phase0.Epoch,
+	bool,
+	error,
+) {
+	var from phase0.Epoch
+	var to phase0.Epoch
+	found := false
+	include := func(rangeFrom phase0.Epoch, rangeTo phase0.Epoch) {
+		if !found || rangeFrom < from {
+			from = rangeFrom
+		}
+		if !found || rangeTo > to {
+			to = rangeTo
+		}
+		found = true
+	}
+
+	epochFrom, epochTo, epochFound, err := provider.OutdatedEpochSummaries(ctx, summarizerVersion)
+	if err != nil {
+		return 0, 0, false, errors.Wrap(err, "failed to obtain outdated epoch summaries")
+	}
+	if epochFound {
+		include(epochFrom, epochTo)
+	}
+
+	slotFrom, slotTo, slotFound, err := provider.OutdatedBlockSummaries(ctx, summarizerVersion)
+	if err != nil {
+		return 0, 0, false, errors.Wrap(err, "failed to obtain outdated block summaries")
+	}
+	if slotFound {
+		include(s.chainTime.SlotToEpoch(slotFrom), s.chainTime.SlotToEpoch(slotTo))
+	}
+
+	validatorFrom, validatorTo, validatorFound, err := provider.OutdatedValidatorEpochSummaries(ctx, summarizerVersion)
+	if err != nil {
+		return 0, 0, false, errors.Wrap(err, "failed to obtain outdated validator epoch summaries")
+	}
+	if validatorFound {
+		include(validatorFrom, validatorTo)
+	}
+
+	return from, to, found, nil
+}
+
+// startOfDay returns the start of the UTC day of the given time.
+func startOfDay(t time.Time) time.Time {
+	t = t.In(time.UTC)
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
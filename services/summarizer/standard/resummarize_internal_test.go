@@ -0,0 +1,95 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+func TestNextResummarize(t *testing.T) {
+	ctx := context.Background()
+	s := &Service{
+		resummarizeInterval: time.Minute,
+	}
+
+	state := &resummarizeState{
+		nextEpoch: 10,
+		toEpoch:   11,
+	}
+
+	// First run is immediate.
+	runtime, err := s.nextResummarize(ctx, state)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), runtime, time.Second)
+
+	// Subsequent runs are separated by the interval.
+	state.nextEpoch++
+	runtime, err = s.nextResummarize(ctx, state)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Minute), runtime, time.Second)
+
+	// Stops once the range is complete.
+	state.nextEpoch++
+	_, err = s.nextResummarize(ctx, state)
+	require.ErrorIs(t, err, scheduler.ErrNoMoreInstances)
+}
+
+func TestNextResummarizeFailed(t *testing.T) {
+	s := &Service{}
+	state := &resummarizeState{
+		nextEpoch: 10,
+		toEpoch:   20,
+		failed:    true,
+	}
+
+	_, err := s.nextResummarize(context.Background(), state)
+	require.ErrorIs(t, err, scheduler.ErrNoMoreInstances)
+}
+
+func TestResummarizeStateDays(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := &resummarizeState{
+		nextDay: from,
+		toDay:   from.AddDate(0, 0, 1),
+		days:    true,
+	}
+	require.False(t, state.done())
+	state.nextDay = state.nextDay.AddDate(0, 0, 1)
+	require.False(t, state.done())
+	state.nextDay = state.nextDay.AddDate(0, 0, 1)
+	require.True(t, state.done())
+}
+
+func TestResummarizeNoScheduler(t *testing.T) {
+	s := &Service{}
+	require.EqualError(t, s.Resummarize(context.Background(), 1, 2), "no scheduler specified for resummarization")
+	require.EqualError(t, s.ResummarizeDays(context.Background(), time.Now(), time.Now()), "no scheduler specified for resummarization")
+}
+
+func TestStartOfDay(t *testing.T) {
+	require.Equal(t,
+		time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+		startOfDay(time.Date(2023, 6, 1, 23, 59, 59, 0, time.UTC)),
+	)
+	// Times are converted to UTC before truncation.
+	require.Equal(t,
+		time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC),
+		startOfDay(time.Date(2023, 6, 1, 23, 0, 0, 0, time.FixedZone("UTC-2", -2*60*60))),
+	)
+}
@@ -16,6 +16,7 @@ package standard
 import (
 	"context"
 	"math"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -24,6 +25,8 @@ import (
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/services/watchlist"
 	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
@@ -45,6 +48,10 @@ type Service struct {
 	maxTimelyAttestationSourceDelay uint64
 	maxTimelyAttestationTargetDelay uint64
 	maxTimelyAttestationHeadDelay   uint64
+	churnLimitQuotient              uint64
+	minPerEpochChurnLimit           uint64
+	maxPerEpochActivationChurnLimit uint64
+	maxSeedLookahead                phase0.Epoch
 	epochSummaries                  bool
 	blockSummaries                  bool
 	validatorSummaries              bool
@@ -52,6 +59,17 @@ type Service struct {
 	validatorEpochRetention         *util.CalendarDuration
 	validatorBalanceRetention       *util.CalendarDuration
 	activitySem                     *semaphore.Weighted
+	origin                          *chaindb.Origin
+	validatorAggregates             *validatorAggregates
+	scheduler                       scheduler.Service
+	consistencyCheckInterval        time.Duration
+	// watchlist restricts the validator summaries generated; it can be nil.
+	watchlist           watchlist.Service
+	resummarizeInterval time.Duration
+	// aggregateAttestations is true if attestations are stored in aggregate mode, without their inclusions.
+	aggregateAttestations bool
+	// ctx is the context with which the service was created, used to schedule jobs on demand.
+	ctx context.Context
 }
 
 // module-wide log.
@@ -65,7 +83,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "summarizer").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger(zerologger.With().Str("service", "summarizer").Str("impl", "standard").Logger(), "summarizer", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -111,6 +129,13 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not provide proposer slashings")
 	}
 
+	if parameters.watchlist != nil {
+		// Balances of all validators are not stored with a watchlist, so are obtained from the client.
+		if _, isProvider := parameters.eth2Client.(eth2client.ValidatorsProvider); !isProvider {
+			return nil, errors.New("client does not provide validators")
+		}
+	}
+
 	spec, err := parameters.eth2Client.(eth2client.SpecProvider).Spec(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain spec")
@@ -134,6 +159,42 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("SLOTS_PER_EPOCH of unexpected type")
 	}
 
+	tmp, exists = spec["CHURN_LIMIT_QUOTIENT"]
+	if !exists {
+		return nil, errors.New("CHURN_LIMIT_QUOTIENT not found in spec")
+	}
+	churnLimitQuotient, ok := tmp.(uint64)
+	if !ok || churnLimitQuotient == 0 {
+		return nil, errors.New("CHURN_LIMIT_QUOTIENT of unexpected type or value")
+	}
+
+	tmp, exists = spec["MIN_PER_EPOCH_CHURN_LIMIT"]
+	if !exists {
+		return nil, errors.New("MIN_PER_EPOCH_CHURN_LIMIT not found in spec")
+	}
+	minPerEpochChurnLimit, ok := tmp.(uint64)
+	if !ok {
+		return nil, errors.New("MIN_PER_EPOCH_CHURN_LIMIT of unexpected type")
+	}
+
+	tmp, exists = spec["MAX_SEED_LOOKAHEAD"]
+	if !exists {
+		return nil, errors.New("MAX_SEED_LOOKAHEAD not found in spec")
+	}
+	maxSeedLookahead, ok := tmp.(uint64)
+	if !ok {
+		return nil, errors.New("MAX_SEED_LOOKAHEAD of unexpected type")
+	}
+
+	// The activation churn limit was introduced in Deneb, so may not be present.
+	maxPerEpochActivationChurnLimit := uint64(8)
+	if tmp, exists = spec["MAX_PER_EPOCH_ACTIVATION_CHURN_LIMIT"]; exists {
+		maxPerEpochActivationChurnLimit, ok = tmp.(uint64)
+		if !ok || maxPerEpochActivationChurnLimit == 0 {
+			return nil, errors.New("MAX_PER_EPOCH_ACTIVATION_CHURN_LIMIT of unexpected type or value")
+		}
+	}
+
 	var validatorEpochRetention *util.CalendarDuration
 	if parameters.validatorEpochRetention != "" {
 		validatorEpochRetention, err = util.ParseCalendarDuration(parameters.validatorEpochRetention)
@@ -150,6 +211,21 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		}
 	}
 
+	var origin *chaindb.Origin
+	if originProvider, isOriginProvider := parameters.chainDB.(chaindb.OriginProvider); isOriginProvider {
+		origin, err = originProvider.Origin(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain origin")
+		}
+	}
+
+	aggregateAttestations := false
+	if provider, isProvider := parameters.chainDB.(chaindb.AttestationStorageModeProvider); isProvider &&
+		provider.AttestationStorageMode() == chaindb.AttestationStorageAggregate {
+		log.Info().Msg("Attestations are stored in aggregate mode; inclusion delays and timeliness will not be summarized")
+		aggregateAttestations = true
+	}
+
 	s := &Service{
 		eth2Client:                      parameters.eth2Client,
 		chainDB:                         parameters.chainDB,
@@ -166,6 +242,10 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		maxTimelyAttestationSourceDelay: uint64(math.Sqrt(float64(slotsPerEpoch))),
 		maxTimelyAttestationTargetDelay: slotsPerEpoch,
 		maxTimelyAttestationHeadDelay:   minAttestationInclusionDelay,
+		churnLimitQuotient:              churnLimitQuotient,
+		minPerEpochChurnLimit:           minPerEpochChurnLimit,
+		maxPerEpochActivationChurnLimit: maxPerEpochActivationChurnLimit,
+		maxSeedLookahead:                phase0.Epoch(maxSeedLookahead),
 		epochSummaries:                  parameters.epochSummaries,
 		blockSummaries:                  parameters.blockSummaries,
 		validatorSummaries:              parameters.validatorSummaries,
@@ -173,6 +253,13 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		validatorEpochRetention:         validatorEpochRetention,
 		validatorBalanceRetention:       validatorBalanceRetention,
 		activitySem:                     semaphore.NewWeighted(1),
+		origin:                          origin,
+		scheduler:                       parameters.scheduler,
+		consistencyCheckInterval:        parameters.consistencyCheckInterval,
+		watchlist:                       parameters.watchlist,
+		resummarizeInterval:             parameters.resummarizeInterval,
+		aggregateAttestations:           aggregateAttestations,
+		ctx:                             ctx,
 	}
 
 	// Note the current highest summarized epoch for the monitor.
@@ -187,6 +274,21 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		s.catchup(ctx)
 	}
 
+	if s.epochSummaries && s.consistencyCheckInterval > 0 {
+		if _, isProvider := s.chainDB.(chaindb.EpochSummariesProvider); !isProvider {
+			return nil, errors.New("chain DB does not provide epoch summaries")
+		}
+		if err := s.scheduleConsistencyCheck(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to schedule consistency check")
+		}
+	}
+
+	if parameters.resummarizeOutdated {
+		if err := s.resummarizeOutdated(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to resummarize outdated summaries")
+		}
+	}
+
 	return s, nil
 }
 
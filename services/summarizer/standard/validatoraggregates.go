@@ -0,0 +1,199 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// validatorAggregates holds running validator aggregates for epoch summaries.
+// It is built from a single fetch of the validator set, and then advanced an
+// epoch at a time touching only those validators whose state changes in that
+// epoch, rather than re-scanning the full validator set for every epoch.
+type validatorAggregates struct {
+	farFutureEpoch phase0.Epoch
+	// maxEpoch is the highest epoch for which the validator set is settled.
+	maxEpoch phase0.Epoch
+	// epoch is the epoch to which the aggregates currently apply.
+	epoch       phase0.Epoch
+	initialised bool
+	validators  []*chaindb.Validator
+
+	active                []bool
+	activeValidators      int
+	activationQueueLength int
+
+	// Positions of validators whose state changes, keyed by the epoch of change.
+	activations   map[phase0.Epoch][]int
+	exits         map[phase0.Epoch][]int
+	eligibilities map[phase0.Epoch][]int
+}
+
+// newValidatorAggregates creates running aggregates for the supplied validators,
+// which are valid for epochs up to and including maxEpoch.
+func newValidatorAggregates(validators []*chaindb.Validator,
+	farFutureEpoch phase0.Epoch,
+	maxEpoch phase0.Epoch,
+) *validatorAggregates {
+	return &validatorAggregates{
+		farFutureEpoch: farFutureEpoch,
+		maxEpoch:       maxEpoch,
+		validators:     validators,
+	}
+}
+
+// covers returns true if the aggregates can provide statistics for the epoch.
+func (a *validatorAggregates) covers(epoch phase0.Epoch) bool {
+	return a != nil && epoch <= a.maxEpoch
+}
+
+// statsForEpoch adds the validator statistics for the given epoch to the summary,
+// and returns the active state of each validator.
+// The returned slice is owned by the aggregates, and is only valid until the next call.
+func (a *validatorAggregates) statsForEpoch(epoch phase0.Epoch,
+	summary *chaindb.EpochSummary,
+) []bool {
+	if !a.initialised || epoch < a.epoch || epoch > a.epoch+1 {
+		a.scan(epoch)
+	} else if epoch == a.epoch+1 {
+		a.advance(epoch)
+	}
+
+	summary.ActiveValidators = a.activeValidators
+	summary.ActivationQueueLength = a.activationQueueLength
+	summary.ActivatingValidators = len(a.activations[epoch])
+	summary.ExitingValidators = 0
+	for _, i := range a.exits[epoch] {
+		if a.validators[i].ActivationEpoch != epoch {
+			summary.ExitingValidators++
+		}
+	}
+
+	return a.active
+}
+
+// scan calculates the aggregates for the given epoch from the full validator set.
+func (a *validatorAggregates) scan(epoch phase0.Epoch) {
+	a.epoch = epoch
+	a.initialised = true
+	a.active = make([]bool, len(a.validators))
+	a.activeValidators = 0
+	a.activationQueueLength = 0
+	a.activations = make(map[phase0.Epoch][]int)
+	a.exits = make(map[phase0.Epoch][]int)
+	a.eligibilities = make(map[phase0.Epoch][]int)
+
+	for i, validator := range a.validators {
+		switch {
+		case validator.ActivationEpoch == epoch:
+			a.activeValidators++
+			a.active[i] = true
+		case validator.ExitEpoch == epoch:
+		case validator.ActivationEpoch <= epoch &&
+			validator.ExitEpoch > epoch:
+			a.activeValidators++
+			a.active[i] = true
+		case validator.ActivationEligibilityEpoch <= epoch &&
+			validator.ActivationEpoch != a.farFutureEpoch &&
+			validator.ActivationEpoch > epoch:
+			a.activationQueueLength++
+		}
+
+		// Note changes from this epoch onwards, for use when advancing.
+		if validator.ActivationEpoch >= epoch && validator.ActivationEpoch <= a.maxEpoch {
+			a.activations[validator.ActivationEpoch] = append(a.activations[validator.ActivationEpoch], i)
+		}
+		// Exits are noted beyond maxEpoch, as they have been initiated and so make up the exit queue.
+		if validator.ExitEpoch >= epoch && validator.ExitEpoch != a.farFutureEpoch {
+			a.exits[validator.ExitEpoch] = append(a.exits[validator.ExitEpoch], i)
+		}
+		if validator.ActivationEligibilityEpoch > epoch && validator.ActivationEligibilityEpoch <= a.maxEpoch &&
+			validator.ActivationEpoch != a.farFutureEpoch {
+			a.eligibilities[validator.ActivationEligibilityEpoch] = append(a.eligibilities[validator.ActivationEligibilityEpoch], i)
+		}
+	}
+}
+
+// advance moves the aggregates on to the given epoch, which must be the epoch after the current epoch.
+func (a *validatorAggregates) advance(epoch phase0.Epoch) {
+	// Changes for the prior epoch are no longer required.
+	delete(a.activations, a.epoch)
+	delete(a.exits, a.epoch)
+	delete(a.eligibilities, a.epoch)
+	a.epoch = epoch
+
+	for _, i := range a.activations[epoch] {
+		if !a.active[i] {
+			a.active[i] = true
+			a.activeValidators++
+		}
+		if a.validators[i].ActivationEligibilityEpoch < epoch {
+			// Leaving the activation queue.
+			a.activationQueueLength--
+		}
+	}
+	for _, i := range a.exits[epoch] {
+		if a.validators[i].ActivationEpoch == epoch {
+			// Activation takes precedence, as per a full scan.
+			continue
+		}
+		if a.active[i] {
+			a.active[i] = false
+			a.activeValidators--
+		}
+	}
+	for _, i := range a.eligibilities[epoch] {
+		if a.validators[i].ActivationEpoch > epoch {
+			// Joining the activation queue.
+			a.activationQueueLength++
+		}
+	}
+}
+
+// exitQueue returns the estimated number of validators awaiting exit at the current
+// epoch, and the epoch at which a validator initiating exit in the current epoch would exit.
+// Exits are allocated to epochs in order, so the queue is taken to run from the earliest
+// possible exit epoch through all subsequent epochs that are full to the churn limit.
+// The validator records hold the current exit epoch of each validator, with no record
+// of when the exit was initiated, so this is only meaningful for recent epochs.
+func (a *validatorAggregates) exitQueue(churnLimit uint64, maxSeedLookahead phase0.Epoch) (int, phase0.Epoch) {
+	if churnLimit == 0 {
+		churnLimit = 1
+	}
+	exitEpoch := a.epoch + 1 + maxSeedLookahead
+	for uint64(len(a.exits[exitEpoch])) >= churnLimit {
+		exitEpoch++
+	}
+
+	length := 0
+	for epoch := a.epoch + 1; epoch <= exitEpoch; epoch++ {
+		length += len(a.exits[epoch])
+	}
+
+	return length, exitEpoch
+}
+
+// fetchValidatorAggregates fetches the validator set and creates running aggregates valid up to maxEpoch.
+func (s *Service) fetchValidatorAggregates(ctx context.Context, maxEpoch phase0.Epoch) (*validatorAggregates, error) {
+	validators, err := s.validatorsProvider.Validators(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validators")
+	}
+
+	return newValidatorAggregates(validators, s.farFutureEpoch, maxEpoch), nil
+}
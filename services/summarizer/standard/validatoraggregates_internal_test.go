@@ -0,0 +1,159 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestValidatorAggregates(t *testing.T) {
+	farFutureEpoch := phase0.Epoch(0xffffffffffffffff)
+	maxEpoch := phase0.Epoch(200)
+
+	rng := rand.New(rand.NewSource(1))
+	validators := make([]*chaindb.Validator, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		validator := &chaindb.Validator{
+			Index:                      phase0.ValidatorIndex(i),
+			ActivationEligibilityEpoch: farFutureEpoch,
+			ActivationEpoch:            farFutureEpoch,
+			ExitEpoch:                  farFutureEpoch,
+		}
+		if rng.Intn(10) > 0 {
+			validator.ActivationEligibilityEpoch = phase0.Epoch(rng.Intn(int(maxEpoch)))
+			if rng.Intn(10) > 0 {
+				validator.ActivationEpoch = validator.ActivationEligibilityEpoch + phase0.Epoch(1+rng.Intn(20))
+				if rng.Intn(4) == 0 {
+					validator.ExitEpoch = validator.ActivationEpoch + phase0.Epoch(1+rng.Intn(100))
+				}
+			}
+		}
+		validators = append(validators, validator)
+	}
+
+	incremental := newValidatorAggregates(validators, farFutureEpoch, maxEpoch)
+	for epoch := phase0.Epoch(5); epoch <= maxEpoch; epoch++ {
+		incrementalSummary := &chaindb.EpochSummary{}
+		incrementalActive := incremental.statsForEpoch(epoch, incrementalSummary)
+
+		full := newValidatorAggregates(validators, farFutureEpoch, maxEpoch)
+		fullSummary := &chaindb.EpochSummary{}
+		fullActive := full.statsForEpoch(epoch, fullSummary)
+
+		require.Equal(t, fullSummary, incrementalSummary, "summary mismatch at epoch %d", epoch)
+		require.Equal(t, fullActive, incrementalActive, "active mismatch at epoch %d", epoch)
+	}
+}
+
+func TestValidatorAggregatesCovers(t *testing.T) {
+	var aggregates *validatorAggregates
+	require.False(t, aggregates.covers(1))
+
+	aggregates = newValidatorAggregates(nil, phase0.Epoch(0xffffffffffffffff), 10)
+	require.True(t, aggregates.covers(10))
+	require.False(t, aggregates.covers(11))
+}
+
+func TestEpochSummaryDifferences(t *testing.T) {
+	a := &chaindb.EpochSummary{Epoch: 5, ActiveValidators: 10, ActiveBalance: 320}
+	b := &chaindb.EpochSummary{Epoch: 5, ActiveValidators: 10, ActiveBalance: 320}
+	require.Empty(t, epochSummaryDifferences(a, b))
+
+	// The summarizer version is not a difference in the summary itself.
+	b.SummarizerVersion = summarizerVersion
+	require.Empty(t, epochSummaryDifferences(a, b))
+
+	b.ActiveValidators = 11
+	b.Withdrawals = 1
+	require.Equal(t, []string{"ActiveValidators", "Withdrawals"}, epochSummaryDifferences(a, b))
+}
+
+// benchmarkValidators creates a mainnet-sized validator set.
+func benchmarkValidators() []*chaindb.Validator {
+	farFutureEpoch := phase0.Epoch(0xffffffffffffffff)
+	rng := rand.New(rand.NewSource(1))
+	validators := make([]*chaindb.Validator, 0, 1000000)
+	for i := 0; i < 1000000; i++ {
+		activationEpoch := phase0.Epoch(rng.Intn(250000))
+		validators = append(validators, &chaindb.Validator{
+			Index:                      phase0.ValidatorIndex(i),
+			ActivationEligibilityEpoch: activationEpoch - 5,
+			ActivationEpoch:            activationEpoch,
+			ExitEpoch:                  farFutureEpoch,
+		})
+	}
+
+	return validators
+}
+
+// BenchmarkValidatorStatsScan measures calculating validator statistics from the full validator set each epoch.
+func BenchmarkValidatorStatsScan(b *testing.B) {
+	validators := benchmarkValidators()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		aggregates := newValidatorAggregates(validators, phase0.Epoch(0xffffffffffffffff), 250000)
+		aggregates.statsForEpoch(200000+phase0.Epoch(i%1000), &chaindb.EpochSummary{})
+	}
+}
+
+// BenchmarkValidatorStatsIncremental measures advancing running validator statistics by an epoch.
+func BenchmarkValidatorStatsIncremental(b *testing.B) {
+	validators := benchmarkValidators()
+	aggregates := newValidatorAggregates(validators, phase0.Epoch(0xffffffffffffffff), 0xfffffffffffffffe)
+	aggregates.statsForEpoch(200000, &chaindb.EpochSummary{})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		aggregates.statsForEpoch(200001+phase0.Epoch(i), &chaindb.EpochSummary{})
+	}
+}
+
+func TestExitQueue(t *testing.T) {
+	farFutureEpoch := phase0.Epoch(0xffffffffffffffff)
+	validators := make([]*chaindb.Validator, 0)
+	addExits := func(exitEpoch phase0.Epoch, count int) {
+		for i := 0; i < count; i++ {
+			validators = append(validators, &chaindb.Validator{
+				Index:                      phase0.ValidatorIndex(len(validators)),
+				ActivationEligibilityEpoch: 0,
+				ActivationEpoch:            0,
+				ExitEpoch:                  exitEpoch,
+			})
+		}
+	}
+	// Epochs 15-17 are full at a churn limit of 4, epoch 18 is partially full.
+	addExits(12, 2)
+	addExits(15, 4)
+	addExits(16, 4)
+	addExits(17, 4)
+	addExits(18, 1)
+	addExits(farFutureEpoch, 10)
+
+	aggregates := newValidatorAggregates(validators, farFutureEpoch, 20)
+	aggregates.statsForEpoch(10, &chaindb.EpochSummary{})
+	length, exitEpoch := aggregates.exitQueue(4, 4)
+	require.Equal(t, 15, length)
+	require.Equal(t, phase0.Epoch(18), exitEpoch)
+
+	// Once the backlog has cleared, the exit epoch is the earliest possible.
+	aggregates.statsForEpoch(11, &chaindb.EpochSummary{})
+	aggregates.statsForEpoch(18, &chaindb.EpochSummary{})
+	length, exitEpoch = aggregates.exitQueue(4, 4)
+	require.Equal(t, 0, length)
+	require.Equal(t, phase0.Epoch(23), exitEpoch)
+}
@@ -19,7 +19,6 @@ import (
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
-	"github.com/prysmaticlabs/go-bitfield"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -35,6 +34,8 @@ func (s *Service) summarizeValidatorsInDay(ctx context.Context,
 			attribute.Int64("star time", startTime.Unix()),
 		))
 	defer span.End()
+	// Rolling up a day across all validators is known to be long-running.
+	ctx = chaindb.WithLongRunning(ctx)
 
 	// Ensure the timestamp points to the start of a day.
 	startTime = time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, time.UTC)
@@ -62,32 +63,109 @@ func (s *Service) summarizeValidatorsInDay(ctx context.Context,
 
 	log.Trace().Msg("Summarising validator day")
 
+	summaries, found, err := s.validatorDaySummaries(ctx, startTime, endTime)
+	if err != nil {
+		return err
+	}
+	if !found {
+		log.Debug().Time("startTime", startTime).Msg("Validator balances not yet ready to be rolled up to a daily entry")
+		return nil
+	}
+
+	if err := s.setValidatorDaySummaries(ctx, summaries, startTime); err != nil {
+		return err
+	}
+
+	monitorDayProcessed(startTime.Unix())
+	return nil
+}
+
+// validatorDaySummaries calculates the validator summaries for the day between the given times.
+// Returns false if the validator balances for the day are not available.
+func (s *Service) validatorDaySummaries(ctx context.Context,
+	startTime time.Time,
+	endTime time.Time,
+) (
+	[]*chaindb.ValidatorDaySummary,
+	bool,
+	error,
+) {
+	span := trace.SpanFromContext(ctx)
+
 	// Generate and populate the day summaries map.
 	daySummaries := make(map[phase0.ValidatorIndex]*chaindb.ValidatorDaySummary)
 	if err := s.addValidatorEpochSummaries(ctx, daySummaries, startTime, endTime); err != nil {
-		return err
+		return nil, false, err
 	}
 	span.AddEvent("Set epoch information")
 	if err := s.addValidatorSyncCommitteeSummaries(ctx, daySummaries, startTime, endTime); err != nil {
-		return err
+		return nil, false, err
 	}
 	span.AddEvent("Set sync committee information")
 	found, err := s.addValidatorBalanceSummaries(ctx, daySummaries, startTime, endTime)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
 	if !found {
-		log.Debug().Time("startTime", startTime).Msg("Validator balances not yet ready to be rolled up to a daily entry")
-		return nil
+		return nil, false, nil
 	}
 	span.AddEvent("Set balance information")
 
 	// Turn in to array.
 	summaries := make([]*chaindb.ValidatorDaySummary, 0, len(daySummaries))
 	for _, daySummary := range daySummaries {
+		daySummary.SummarizerVersion = summarizerVersion
 		summaries = append(summaries, daySummary)
 	}
 
+	return summaries, true, nil
+}
+
+// minValidatorDaySummariesBatchSize is the smallest batch in which validator day
+// summaries are set after a statement timeout.
+const minValidatorDaySummariesBatchSize = 1024
+
+// setValidatorDaySummaries sets the validator day summaries and marks the day as summarized.
+// If setting the summaries hits a statement timeout they are set in progressively smaller
+// batches, each in its own transaction, with the day marked as summarized along with the
+// final batch.
+func (s *Service) setValidatorDaySummaries(ctx context.Context,
+	summaries []*chaindb.ValidatorDaySummary,
+	startTime time.Time,
+) error {
+	batchSize := len(summaries)
+	for {
+		batch := summaries
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+		final := len(batch) == len(summaries)
+		err := s.setValidatorDaySummariesBatch(ctx, batch, final, startTime)
+		if errors.Is(err, chaindb.ErrStatementTimeout) && batchSize > minValidatorDaySummariesBatchSize {
+			batchSize /= 2
+			if batchSize < minValidatorDaySummariesBatchSize {
+				batchSize = minValidatorDaySummariesBatchSize
+			}
+			log.Debug().Int("batch_size", batchSize).Msg("Timed out setting validator day summaries; retrying with smaller batches")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+		summaries = summaries[len(batch):]
+	}
+}
+
+// setValidatorDaySummariesBatch sets a batch of validator day summaries in its own
+// transaction, marking the day as summarized if this is the final batch.
+func (s *Service) setValidatorDaySummariesBatch(ctx context.Context,
+	summaries []*chaindb.ValidatorDaySummary,
+	final bool,
+	startTime time.Time,
+) error {
 	ctx, cancel, err := s.chainDB.BeginTx(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to begin transaction to set validator day summaries")
@@ -100,23 +178,25 @@ func (s *Service) summarizeValidatorsInDay(ctx context.Context,
 
 	log.Trace().Msg("Set summaries")
 
-	// Fetch updated metadata as it may have changed since we last obtained it.
-	md, err = s.getMetadata(ctx)
-	if err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to obtain metadata for validator day summarizer")
-	}
-	md.LastValidatorDay = startTime.Unix()
-	if err := s.setMetadata(ctx, md); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set summarizer metadata for validator day summary")
+	if final {
+		// Fetch updated metadata as it may have changed since we last obtained it.
+		md, err := s.getMetadata(ctx)
+		if err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to obtain metadata for validator day summarizer")
+		}
+		md.LastValidatorDay = startTime.Unix()
+		if err := s.setMetadata(ctx, md); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set summarizer metadata for validator day summary")
+		}
 	}
+
 	if err := s.chainDB.CommitTx(ctx); err != nil {
 		cancel()
 		return errors.Wrap(err, "failed to set commit transaction to set validator day summary")
 	}
 
-	monitorDayProcessed(startTime.Unix())
 	return nil
 }
 
@@ -190,23 +270,68 @@ func (s *Service) addValidatorSyncCommitteeSummaries(ctx context.Context,
 	startEpoch := s.chainTime.TimestampToEpoch(startTime)
 	// The end epoch should be the last epoch that has finished at the given time, not the epoch in progress
 	// at the given time, so this is always reduced by 1.
-	endEpoch := s.chainTime.TimestampToEpoch(endTime)
+	endEpoch := s.chainTime.TimestampToEpoch(endTime) - 1
 
 	syncCommitteeSummary, err := s.syncCommitteeSummary(ctx, startEpoch, endEpoch)
 	if err != nil {
 		return err
 	}
 
+	indices := make([]phase0.ValidatorIndex, 0, len(syncCommitteeSummary))
 	for index, daySummary := range daySummaries {
 		if scSummary, exists := syncCommitteeSummary[index]; exists {
 			daySummary.SyncCommitteeMessages = scSummary.messages
 			daySummary.SyncCommitteeMessagesIncluded = scSummary.messagesIncluded
+			if scSummary.messages > 0 {
+				indices = append(indices, index)
+			}
 		}
 	}
+	if len(indices) == 0 {
+		return nil
+	}
+
+	// Obtain prior day summaries for the sync committee members to provide lifetime participation.
+	priorTime := startTime.AddDate(0, 0, -1)
+	priorSummaries, err := s.chainDB.(chaindb.ValidatorDaySummariesProvider).ValidatorDaySummaries(ctx, &chaindb.ValidatorDaySummaryFilter{
+		To:               &priorTime,
+		ValidatorIndices: &indices,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain prior validator day summaries")
+	}
+	addLifetimeSyncCommitteeParticipation(daySummaries, priorSummaries)
 
 	return nil
 }
 
+// addLifetimeSyncCommitteeParticipation sets the lifetime sync committee participation
+// for day summaries with sync committee messages, combining them with the prior summaries.
+func addLifetimeSyncCommitteeParticipation(daySummaries map[phase0.ValidatorIndex]*chaindb.ValidatorDaySummary,
+	priorSummaries []*chaindb.ValidatorDaySummary,
+) {
+	lifetime := make(map[phase0.ValidatorIndex]*scSummary)
+	for index, daySummary := range daySummaries {
+		if daySummary.SyncCommitteeMessages == 0 {
+			continue
+		}
+		lifetime[index] = &scSummary{
+			messages:         daySummary.SyncCommitteeMessages,
+			messagesIncluded: daySummary.SyncCommitteeMessagesIncluded,
+		}
+	}
+	for _, priorSummary := range priorSummaries {
+		if scSummary, exists := lifetime[priorSummary.Index]; exists {
+			scSummary.messages += priorSummary.SyncCommitteeMessages
+			scSummary.messagesIncluded += priorSummary.SyncCommitteeMessagesIncluded
+		}
+	}
+	for index, scSummary := range lifetime {
+		participation := scSummary.participation()
+		daySummaries[index].SyncCommitteeLifetimeParticipation = &participation
+	}
+}
+
 func (s *Service) addValidatorBalanceSummaries(ctx context.Context,
 	daySummaries map[phase0.ValidatorIndex]*chaindb.ValidatorDaySummary,
 	startTime time.Time,
@@ -326,6 +451,12 @@ type scSummary struct {
 	messagesIncluded int
 }
 
+// participation returns the percentage of sync committee messages included.
+// It must only be called if there is at least one message.
+func (s *scSummary) participation() float64 {
+	return 100 * float64(s.messagesIncluded) / float64(s.messages)
+}
+
 func (s *Service) syncCommitteeSummary(ctx context.Context,
 	startEpoch phase0.Epoch,
 	endEpoch phase0.Epoch,
@@ -358,34 +489,51 @@ func (s *Service) syncCommitteeSummary(ctx context.Context,
 	startSlot := s.chainTime.FirstSlotOfEpoch(startEpoch)
 	endSlot := s.chainTime.FirstSlotOfEpoch(endEpoch+1) - 1
 
+	// Only canonical blocks are considered, so slots without a canonical block do not count against
+	// the sync committee.
+	canonical := true
 	syncAggregates, err := s.chainDB.(chaindb.SyncAggregateProvider).SyncAggregates(ctx, &chaindb.SyncAggregateFilter{
-		From: &startSlot,
-		To:   &endSlot,
+		From:      &startSlot,
+		To:        &endSlot,
+		Canonical: &canonical,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain sync aggregates")
 	}
 
+	return syncCommitteeParticipation(syncAggregates, syncCommitteeMap, s.chainTime.SlotToSyncCommitteePeriod)
+}
+
+// syncCommitteeParticipation summarises sync committee messages by validator for the given sync aggregates.
+// The aggregate in a block is checked against the sync committee for the period of the block, which is
+// the committee responsible for the messages even though they were for the prior slot.
+func syncCommitteeParticipation(syncAggregates []*chaindb.SyncAggregate,
+	syncCommittees map[uint64][]phase0.ValidatorIndex,
+	slotToPeriod func(phase0.Slot) uint64,
+) (
+	map[phase0.ValidatorIndex]*scSummary,
+	error,
+) {
+	res := make(map[phase0.ValidatorIndex]*scSummary)
 	for _, aggregate := range syncAggregates {
 		if aggregate.InclusionSlot == 0 {
 			log.Trace().Msg("Aggregate for slot 0 ignored")
 			continue
 		}
-		period := s.chainTime.SlotToSyncCommitteePeriod(aggregate.InclusionSlot - 1)
-		syncCommittee, exists := syncCommitteeMap[period]
+		period := slotToPeriod(aggregate.InclusionSlot)
+		syncCommittee, exists := syncCommittees[period]
 		if !exists {
-			log.Warn().Uint64("start_epoch", uint64(startEpoch)).Uint64("end_epoch", uint64(endEpoch)).Uint64("inclusion_slot", uint64(aggregate.InclusionSlot)).Uint64("period", period).Uint64("slot", uint64(aggregate.InclusionSlot-1)).Msg("No sync committee found for block, cannot progress")
+			log.Warn().Uint64("inclusion_slot", uint64(aggregate.InclusionSlot)).Uint64("period", period).Msg("No sync committee found for block, cannot progress")
 			return nil, errors.New("no sync committee for block")
 		}
 
-		aggregateBits := bitfield.Bitlist(aggregate.Bits)
-		for i := uint64(0); i < aggregateBits.Len(); i++ {
-			if _, exists := res[syncCommittee[i]]; !exists {
-				res[syncCommittee[i]] = &scSummary{}
+		for i, index := range syncCommittee {
+			if _, exists := res[index]; !exists {
+				res[index] = &scSummary{}
 			}
-			res[syncCommittee[i]].messages++
-			if aggregateBits.BitAt(i) {
-				res[syncCommittee[i]].messagesIncluded++
+			res[index].messages++
+			if i/8 < len(aggregate.Bits) && aggregate.Bits[i/8]&(1<<(i%8)) != 0 {
+				res[index].messagesIncluded++
 			}
 		}
 	}
@@ -401,10 +549,6 @@ func (s *Service) syncCommitteesForEpochs(ctx context.Context,
 	error,
 ) {
 	startPeriod := s.chainTime.EpochToSyncCommitteePeriod(startEpoch)
-	if startPeriod > 0 {
-		// We could be on the first epoch of a period, so also grab the prior period.
-		startPeriod--
-	}
 	endPeriod := s.chainTime.EpochToSyncCommitteePeriod(endEpoch)
 
 	syncCommittees := make([]*chaindb.SyncCommittee, 0, endPeriod+1-startPeriod)
@@ -0,0 +1,139 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestSyncCommitteeParticipation(t *testing.T) {
+	// Periods of 8 slots, with a committee of 4.
+	slotToPeriod := func(slot phase0.Slot) uint64 {
+		return uint64(slot) / 8
+	}
+	syncCommittees := map[uint64][]phase0.ValidatorIndex{
+		1: {1, 2, 3, 4},
+		2: {3, 4, 5, 6},
+	}
+
+	tests := []struct {
+		name       string
+		aggregates []*chaindb.SyncAggregate
+		res        map[phase0.ValidatorIndex]*scSummary
+		err        string
+	}{
+		{
+			name: "Empty",
+			res:  map[phase0.ValidatorIndex]*scSummary{},
+		},
+		{
+			name: "Single",
+			aggregates: []*chaindb.SyncAggregate{
+				{InclusionSlot: 9, Bits: []byte{0x05}},
+			},
+			res: map[phase0.ValidatorIndex]*scSummary{
+				1: {messages: 1, messagesIncluded: 1},
+				2: {messages: 1},
+				3: {messages: 1, messagesIncluded: 1},
+				4: {messages: 1},
+			},
+		},
+		{
+			name: "PeriodBoundary",
+			aggregates: []*chaindb.SyncAggregate{
+				// Last slot of period 1.
+				{InclusionSlot: 15, Bits: []byte{0x0f}},
+				// First slot of period 2, which is the responsibility of the period 2 committee.
+				{InclusionSlot: 16, Bits: []byte{0x03}},
+			},
+			res: map[phase0.ValidatorIndex]*scSummary{
+				1: {messages: 1, messagesIncluded: 1},
+				2: {messages: 1, messagesIncluded: 1},
+				3: {messages: 2, messagesIncluded: 2},
+				4: {messages: 2, messagesIncluded: 2},
+				5: {messages: 1},
+				6: {messages: 1},
+			},
+		},
+		{
+			name: "MissedSlots",
+			aggregates: []*chaindb.SyncAggregate{
+				// Slots 10 and 11 have no canonical block, so are not counted.
+				{InclusionSlot: 9, Bits: []byte{0x01}},
+				{InclusionSlot: 12, Bits: []byte{0x01}},
+			},
+			res: map[phase0.ValidatorIndex]*scSummary{
+				1: {messages: 2, messagesIncluded: 2},
+				2: {messages: 2},
+				3: {messages: 2},
+				4: {messages: 2},
+			},
+		},
+		{
+			name: "SlotZero",
+			aggregates: []*chaindb.SyncAggregate{
+				{InclusionSlot: 0, Bits: []byte{0x0f}},
+			},
+			res: map[phase0.ValidatorIndex]*scSummary{},
+		},
+		{
+			name: "NoCommittee",
+			aggregates: []*chaindb.SyncAggregate{
+				{InclusionSlot: 24, Bits: []byte{0x0f}},
+			},
+			err: "no sync committee for block",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := syncCommitteeParticipation(test.aggregates, syncCommittees, slotToPeriod)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.res, res)
+			}
+		})
+	}
+}
+
+func TestAddLifetimeSyncCommitteeParticipation(t *testing.T) {
+	day := time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)
+	daySummaries := map[phase0.ValidatorIndex]*chaindb.ValidatorDaySummary{
+		// Sync committee member with prior history.
+		1: {Index: 1, StartTimestamp: day, SyncCommitteeMessages: 100, SyncCommitteeMessagesIncluded: 90},
+		// Sync committee member without prior history.
+		2: {Index: 2, StartTimestamp: day, SyncCommitteeMessages: 50, SyncCommitteeMessagesIncluded: 25},
+		// Not a sync committee member.
+		3: {Index: 3, StartTimestamp: day},
+	}
+	priorSummaries := []*chaindb.ValidatorDaySummary{
+		{Index: 1, StartTimestamp: day.AddDate(0, 0, -2), SyncCommitteeMessages: 60, SyncCommitteeMessagesIncluded: 60},
+		{Index: 1, StartTimestamp: day.AddDate(0, 0, -1), SyncCommitteeMessages: 40, SyncCommitteeMessagesIncluded: 30},
+		{Index: 3, StartTimestamp: day.AddDate(0, 0, -1), SyncCommitteeMessages: 10, SyncCommitteeMessagesIncluded: 10},
+	}
+
+	addLifetimeSyncCommitteeParticipation(daySummaries, priorSummaries)
+	require.NotNil(t, daySummaries[1].SyncCommitteeLifetimeParticipation)
+	require.InDelta(t, 90.0, *daySummaries[1].SyncCommitteeLifetimeParticipation, 0.0001)
+	require.NotNil(t, daySummaries[2].SyncCommitteeLifetimeParticipation)
+	require.InDelta(t, 50.0, *daySummaries[2].SyncCommitteeLifetimeParticipation, 0.0001)
+	require.Nil(t, daySummaries[3].SyncCommitteeLifetimeParticipation)
+}
@@ -36,24 +36,105 @@ func (s *Service) summarizeValidatorsInEpoch(ctx context.Context,
 	}
 	log.Trace().Msg("Summarizing validator epoch")
 
-	proposerDuties, validatorProposerDuties, err := s.validatorProposerDutiesForEpoch(ctx, epoch)
+	summaries, proposals, missedProposals, err := s.validatorEpochSummaries(ctx, epoch)
 	if err != nil {
 		return err
 	}
+	if md.ValidatorWatchlist != nil {
+		summaries = watchedValidatorEpochSummaries(summaries, *md.ValidatorWatchlist)
+	}
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Calculated summaries")
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set validator epoch summary")
+	}
+
+	if err := s.chainDB.(chaindb.ValidatorEpochSummariesSetter).SetValidatorEpochSummaries(ctx, summaries); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set validator epoch summary")
+	}
+	if err := s.chainDB.(chaindb.SlotProposalsSetter).SetSlotProposals(ctx, proposals); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set slot proposals")
+	}
+
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
+	md.LastValidatorEpoch = epoch
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set summarizer metadata for validator epoch summary")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set commit transaction to set validator epoch summary")
+	}
+	monitorMissedProposals(missedProposals)
+
+	return nil
+}
+
+// validatorEpochSummaries calculates the validator summaries, and slot proposals, for a given epoch.
+// It also returns the number of missed proposals.
+func (s *Service) validatorEpochSummaries(ctx context.Context,
+	epoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorEpochSummary,
+	[]*chaindb.SlotProposal,
+	int,
+	error,
+) {
+	started := time.Now()
+	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+
+	proposerDuties, validatorProposerDuties, err := s.validatorProposerDutiesForEpoch(ctx, epoch)
+	if err != nil {
+		return nil, nil, 0, err
+	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched proposer duties")
 
-	validatorProposals, err := s.validatorProposalsForEpoch(ctx, epoch, proposerDuties, validatorProposerDuties)
+	proposals, err := s.slotProposalsForEpoch(ctx, epoch, proposerDuties)
 	if err != nil {
-		return err
+		return nil, nil, 0, err
+	}
+	validatorProposals := make(map[phase0.ValidatorIndex]map[chaindb.ProposalOutcome]int)
+	missedProposals := 0
+	for _, proposal := range proposals {
+		if _, exists := validatorProposals[proposal.ProposerIndex]; !exists {
+			validatorProposals[proposal.ProposerIndex] = make(map[chaindb.ProposalOutcome]int)
+		}
+		validatorProposals[proposal.ProposerIndex][proposal.Outcome]++
+		if proposal.Outcome != chaindb.ProposalOutcomeCanonical {
+			missedProposals++
+		}
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched proposals")
 
-	attestationsIncluded, attestationsTargetCorrect, attestationsHeadCorrect, attestationsInclusionDelay, attestationsSourceTimely, attestationsTargetTimely, attestationsHeadTimely, err := s.attestationsForEpoch(ctx, epoch)
+	attesterDuties, err := s.attesterDutiesForEpoch(ctx, epoch)
 	if err != nil {
-		return err
+		return nil, nil, 0, err
+	}
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched attester duties")
+
+	attestationsIncluded, attestationsTargetCorrect, attestationsHeadCorrect, attestationsInclusionDelay, attestationsSourceTimely, attestationsTargetTimely, attestationsHeadTimely, attestationsOrphaned, err := s.attestationsForEpoch(ctx, epoch)
+	if err != nil {
+		return nil, nil, 0, err
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched attestations")
 
+	syncCommitteeSummary, err := s.syncCommitteeSummary(ctx, epoch, epoch)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched sync committee participation")
+
+	// Add in any validators that had a duty but did not attest.
+	for index := range attesterDuties {
+		if _, exists := attestationsIncluded[index]; !exists {
+			attestationsIncluded[index] = false
+		}
+	}
+
 	// Store the data.
 	summaries := make([]*chaindb.ValidatorEpochSummary, 0, len(attestationsIncluded))
 	for index := range attestationsIncluded {
@@ -61,14 +142,28 @@ func (s *Service) summarizeValidatorsInEpoch(ctx context.Context,
 			Index:               index,
 			Epoch:               epoch,
 			ProposerDuties:      validatorProposerDuties[index],
-			ProposalsIncluded:   validatorProposals[index],
+			ProposalsIncluded:   validatorProposals[index][chaindb.ProposalOutcomeCanonical],
+			ProposalsOrphaned:   validatorProposals[index][chaindb.ProposalOutcomeOrphaned],
+			ProposalsMissed:     validatorProposals[index][chaindb.ProposalOutcomeMissed],
+			AttesterDuty:        attesterDuties[index],
 			AttestationIncluded: attestationsIncluded[index],
+			SummarizerVersion:   summarizerVersion,
+		}
+		if scSummary, exists := syncCommitteeSummary[index]; exists && scSummary.messages > 0 {
+			syncCommitteeParticipation := scSummary.participation()
+			summary.SyncCommitteeParticipation = &syncCommitteeParticipation
+		}
+		if !summary.AttestationIncluded {
+			attestationOrphaned := attestationsOrphaned[index]
+			summary.AttestationOrphaned = &attestationOrphaned
 		}
 		if summary.AttestationIncluded {
 			attestationTargetCorrect := attestationsTargetCorrect[index]
 			summary.AttestationTargetCorrect = &attestationTargetCorrect
 			attestationHeadCorrect := attestationsHeadCorrect[index]
 			summary.AttestationHeadCorrect = &attestationHeadCorrect
+		}
+		if summary.AttestationIncluded && !s.aggregateAttestations {
 			attestationInclusionDelay := int(attestationsInclusionDelay[index])
 			summary.AttestationInclusionDelay = &attestationInclusionDelay
 			if epoch >= s.chainTime.AltairInitialEpoch() {
@@ -86,28 +181,7 @@ func (s *Service) summarizeValidatorsInEpoch(ctx context.Context,
 		summaries = append(summaries, summary)
 	}
 
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to begin transaction to set validator epoch summary")
-	}
-
-	if err := s.chainDB.(chaindb.ValidatorEpochSummariesSetter).SetValidatorEpochSummaries(ctx, summaries); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set validator epoch summary")
-	}
-
-	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
-	md.LastValidatorEpoch = epoch
-	if err := s.setMetadata(ctx, md); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set summarizer metadata for validator epoch summary")
-	}
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set commit transaction to set validator epoch summary")
-	}
-
-	return nil
+	return summaries, proposals, missedProposals, nil
 }
 
 func (s *Service) validatorProposerDutiesForEpoch(ctx context.Context,
@@ -149,38 +223,6 @@ func (s *Service) validatorProposerDutiesForEpoch(ctx context.Context,
 	return proposerDuties, validatorProposerDuties, nil
 }
 
-func (s *Service) validatorProposalsForEpoch(ctx context.Context,
-	epoch phase0.Epoch,
-	proposerDuties []*chaindb.ProposerDuty,
-	validatorProposerDuties map[phase0.ValidatorIndex]int,
-) (
-	map[phase0.ValidatorIndex]int,
-	error,
-) {
-	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
-	maxSlot := s.chainTime.LastSlotOfEpoch(epoch)
-	// Fetch the block presence for the epoch.
-	presence, err := s.blocksProvider.CanonicalBlockPresenceForSlotRange(ctx, minSlot, maxSlot+1)
-	if err != nil {
-		return nil, err
-	}
-	validatorProposals := make(map[phase0.ValidatorIndex]int)
-	for i, present := range presence {
-		if proposerDuties[i].Slot == 0 {
-			// Not a real proposer duty; ignore.
-			continue
-		}
-		proposerIndex := proposerDuties[i].ValidatorIndex
-		if _, exists := validatorProposerDuties[proposerIndex]; !exists {
-			validatorProposerDuties[proposerIndex] = 0
-		}
-		if present {
-			validatorProposals[proposerIndex]++
-		}
-	}
-	return validatorProposals, nil
-}
-
 func (s *Service) attestationsForEpoch(ctx context.Context,
 	epoch phase0.Epoch,
 ) (
@@ -191,6 +233,7 @@ func (s *Service) attestationsForEpoch(ctx context.Context,
 	map[phase0.ValidatorIndex]bool,
 	map[phase0.ValidatorIndex]bool,
 	map[phase0.ValidatorIndex]bool,
+	map[phase0.ValidatorIndex]bool,
 	error,
 ) {
 	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
@@ -198,7 +241,7 @@ func (s *Service) attestationsForEpoch(ctx context.Context,
 	// Fetch all attestations for the epoch.
 	attestations, err := s.attestationsProvider.AttestationsForSlotRange(ctx, minSlot, maxSlot+1)
 	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, errors.Wrap(err, "failed to obtain attestations for slot range")
+		return nil, nil, nil, nil, nil, nil, nil, nil, errors.Wrap(err, "failed to obtain attestations for slot range")
 	}
 	log.Trace().Int("attestations", len(attestations)).Uint64("epoch", uint64(epoch)).Uint64("first_slot", uint64(s.chainTime.FirstSlotOfEpoch(epoch))).Uint64("last_slot", uint64(s.chainTime.FirstSlotOfEpoch(epoch+1)-1)).Msg("Fetched attestations")
 
@@ -210,6 +253,7 @@ func (s *Service) attestationsForEpoch(ctx context.Context,
 	attestationsSourceTimely := make(map[phase0.ValidatorIndex]bool)
 	attestationsTargetTimely := make(map[phase0.ValidatorIndex]bool)
 	attestationsHeadTimely := make(map[phase0.ValidatorIndex]bool)
+	attestationsOrphaned := make(map[phase0.ValidatorIndex]bool)
 	attestationsForSlots := make(map[phase0.Slot]struct{})
 	attestationsInSlots := make(map[phase0.Slot]struct{})
 	for _, attestation := range attestations {
@@ -219,11 +263,28 @@ func (s *Service) attestationsForEpoch(ctx context.Context,
 			continue
 		}
 		if !*attestation.Canonical {
-			// This commonly happens when the block in which the attestation is included is non-canonical, so note it but no more.
-			log.Trace().Uint64("slot", uint64(attestation.Slot)).Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).Msg("Non-canonical attestation; ignoring")
+			// This commonly happens when the block in which the attestation is included is non-canonical, so note
+			// the validators that attested in case their attestation is not included elsewhere.
+			log.Trace().Uint64("slot", uint64(attestation.Slot)).Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).Msg("Non-canonical attestation")
+			for _, index := range attestation.AggregationIndices {
+				attestationsOrphaned[index] = true
+			}
 			continue
 		}
 		attestationsForSlots[attestation.Slot] = struct{}{}
+		if s.aggregateAttestations {
+			// Inclusions are not stored, so neither inclusion delays nor timeliness can be calculated.
+			for _, index := range attestation.AggregationIndices {
+				attestationsIncluded[index] = true
+				if *attestation.TargetCorrect {
+					attestationsTargetCorrect[index] = true
+				}
+				if *attestation.HeadCorrect {
+					attestationsHeadCorrect[index] = true
+				}
+			}
+			continue
+		}
 		attestationsInSlots[attestation.InclusionSlot] = struct{}{}
 		inclusionDelay := attestation.InclusionSlot - attestation.Slot
 		attestationSourceTimely := uint64(inclusionDelay) <= s.maxTimelyAttestationSourceDelay
@@ -249,19 +310,80 @@ func (s *Service) attestationsForEpoch(ctx context.Context,
 		}
 	}
 
-	// Add in any validators that did not attest.
+	return attestationsIncluded, attestationsTargetCorrect, attestationsHeadCorrect, attestationsInclusionDelay, attestationsSourceTimely, attestationsTargetTimely, attestationsHeadTimely, attestationsOrphaned, nil
+}
+
+// attesterDutiesForEpoch returns the validators with an attester duty in the given epoch.
+// Duties are taken from the stored beacon committees where they are complete for the epoch,
+// otherwise from the validators that were active in the epoch.
+func (s *Service) attesterDutiesForEpoch(ctx context.Context,
+	epoch phase0.Epoch,
+) (
+	map[phase0.ValidatorIndex]bool,
+	error,
+) {
+	if provider, isProvider := s.chainDB.(chaindb.BeaconCommitteesProvider); isProvider {
+		minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+		maxSlot := s.chainTime.LastSlotOfEpoch(epoch)
+		committees, err := provider.BeaconCommittees(ctx, &chaindb.BeaconCommitteeFilter{
+			From: &minSlot,
+			To:   &maxSlot,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain beacon committees")
+		}
+		if duties, complete := attesterDutiesFromCommittees(committees, minSlot, maxSlot); complete {
+			return duties, nil
+		}
+		log.Trace().Uint64("epoch", uint64(epoch)).Msg("Beacon committees incomplete for epoch; using active validators for attester duties")
+	}
+
 	validators, err := s.chainDB.(chaindb.ValidatorsProvider).Validators(ctx)
 	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, errors.Wrap(err, "failed to obtain validators")
+		return nil, errors.Wrap(err, "failed to obtain validators")
 	}
+
+	return attesterDutiesFromValidators(validators, epoch), nil
+}
+
+// attesterDutiesFromCommittees returns the validators present in the beacon committees,
+// and whether the committees cover every slot from minSlot to maxSlot inclusive.
+// Committees are authoritative, so cover validators exiting with residual duties.
+func attesterDutiesFromCommittees(committees []*chaindb.BeaconCommittee,
+	minSlot phase0.Slot,
+	maxSlot phase0.Slot,
+) (
+	map[phase0.ValidatorIndex]bool,
+	bool,
+) {
+	duties := make(map[phase0.ValidatorIndex]bool)
+	slots := make(map[phase0.Slot]struct{})
+	for _, committee := range committees {
+		if committee.Slot < minSlot || committee.Slot > maxSlot {
+			continue
+		}
+		slots[committee.Slot] = struct{}{}
+		for _, index := range committee.Committee {
+			duties[index] = true
+		}
+	}
+
+	return duties, len(slots) == int(maxSlot-minSlot+1)
+}
+
+// attesterDutiesFromValidators returns the validators active in the given epoch,
+// all of which have an attester duty.
+func attesterDutiesFromValidators(validators []*chaindb.Validator,
+	epoch phase0.Epoch,
+) map[phase0.ValidatorIndex]bool {
+	duties := make(map[phase0.ValidatorIndex]bool)
 	for _, validator := range validators {
 		// Confirm active.
 		if validator.ActivationEpoch > epoch || validator.ExitEpoch <= epoch {
 			continue
 		}
-		if _, exists := attestationsIncluded[validator.Index]; !exists {
-			attestationsIncluded[validator.Index] = false
-		}
+		duties[validator.Index] = true
 	}
-	return attestationsIncluded, attestationsTargetCorrect, attestationsHeadCorrect, attestationsInclusionDelay, attestationsSourceTimely, attestationsTargetTimely, attestationsHeadTimely, nil
+
+	return duties
 }
@@ -0,0 +1,102 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestAttesterDutiesFromCommittees(t *testing.T) {
+	tests := []struct {
+		name       string
+		committees []*chaindb.BeaconCommittee
+		minSlot    phase0.Slot
+		maxSlot    phase0.Slot
+		duties     map[phase0.ValidatorIndex]bool
+		complete   bool
+	}{
+		{
+			name:     "Empty",
+			minSlot:  32,
+			maxSlot:  33,
+			duties:   map[phase0.ValidatorIndex]bool{},
+			complete: false,
+		},
+		{
+			name: "Complete",
+			committees: []*chaindb.BeaconCommittee{
+				{Slot: 32, Index: 0, Committee: []phase0.ValidatorIndex{1, 2}},
+				{Slot: 32, Index: 1, Committee: []phase0.ValidatorIndex{3}},
+				{Slot: 33, Index: 0, Committee: []phase0.ValidatorIndex{4}},
+			},
+			minSlot:  32,
+			maxSlot:  33,
+			duties:   map[phase0.ValidatorIndex]bool{1: true, 2: true, 3: true, 4: true},
+			complete: true,
+		},
+		{
+			name: "Incomplete",
+			committees: []*chaindb.BeaconCommittee{
+				{Slot: 32, Index: 0, Committee: []phase0.ValidatorIndex{1, 2}},
+			},
+			minSlot:  32,
+			maxSlot:  33,
+			duties:   map[phase0.ValidatorIndex]bool{1: true, 2: true},
+			complete: false,
+		},
+		{
+			name: "OutOfRange",
+			committees: []*chaindb.BeaconCommittee{
+				{Slot: 31, Index: 0, Committee: []phase0.ValidatorIndex{9}},
+				{Slot: 32, Index: 0, Committee: []phase0.ValidatorIndex{1}},
+				{Slot: 33, Index: 0, Committee: []phase0.ValidatorIndex{2}},
+				{Slot: 34, Index: 0, Committee: []phase0.ValidatorIndex{9}},
+			},
+			minSlot:  32,
+			maxSlot:  33,
+			duties:   map[phase0.ValidatorIndex]bool{1: true, 2: true},
+			complete: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			duties, complete := attesterDutiesFromCommittees(test.committees, test.minSlot, test.maxSlot)
+			require.Equal(t, test.duties, duties)
+			require.Equal(t, test.complete, complete)
+		})
+	}
+}
+
+func TestAttesterDutiesFromValidators(t *testing.T) {
+	validators := []*chaindb.Validator{
+		// Active throughout.
+		{Index: 1, ActivationEpoch: 0, ExitEpoch: 0xffffffffffffffff},
+		// Activated at the epoch.
+		{Index: 2, ActivationEpoch: 10, ExitEpoch: 0xffffffffffffffff},
+		// Activated after the epoch.
+		{Index: 3, ActivationEpoch: 11, ExitEpoch: 0xffffffffffffffff},
+		// Exiting after the epoch.
+		{Index: 4, ActivationEpoch: 0, ExitEpoch: 11},
+		// Exited at the epoch.
+		{Index: 5, ActivationEpoch: 0, ExitEpoch: 10},
+	}
+
+	duties := attesterDutiesFromValidators(validators, 10)
+	require.Equal(t, map[phase0.ValidatorIndex]bool{1: true, 2: true, 4: true}, duties)
+}
@@ -0,0 +1,269 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/watchlist"
+)
+
+// validatorBalancesByEpoch returns the balances of all validators at the start of
+// the given epoch, ordered by validator index.  With a watchlist only the balances
+// of watched validators are stored, so the balances are obtained from the client.
+func (s *Service) validatorBalancesByEpoch(ctx context.Context,
+	epoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorBalance,
+	error,
+) {
+	if s.watchlist == nil {
+		return s.validatorsProvider.ValidatorBalancesByEpoch(ctx, epoch)
+	}
+
+	stateID := fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch))
+	validators, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, stateID, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validators from client")
+	}
+	balances := make([]*chaindb.ValidatorBalance, 0, len(validators))
+	for index, validator := range validators {
+		balances = append(balances, &chaindb.ValidatorBalance{
+			Index:            index,
+			Epoch:            epoch,
+			Balance:          validator.Balance,
+			EffectiveBalance: validator.Validator.EffectiveBalance,
+		})
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Index < balances[j].Index })
+
+	return balances, nil
+}
+
+// updateWatchlist brings the watchlist in the metadata up to date with the current
+// watchlist, backfilling the validator summaries of any validators added to the
+// watchlist.  Validators on the watchlist when it is first used are not backfilled.
+func (s *Service) updateWatchlist(ctx context.Context, md *metadata) error {
+	if s.watchlist == nil {
+		md.ValidatorWatchlist = nil
+		return nil
+	}
+
+	current := s.watchlist.Indices()
+	if md.ValidatorWatchlist != nil {
+		additions := watchlist.Additions(*md.ValidatorWatchlist, current)
+		if len(additions) > 0 {
+			if err := s.backfillValidatorSummaries(ctx, md, additions); err != nil {
+				return err
+			}
+		}
+	}
+	md.ValidatorWatchlist = &current
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction for watchlist")
+	}
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set summarizer metadata for watchlist")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction for watchlist")
+	}
+
+	return nil
+}
+
+// backfillValidatorSummaries generates the epoch and day summaries of the given
+// validators for the epochs and days that have already been summarized.
+func (s *Service) backfillValidatorSummaries(ctx context.Context,
+	md *metadata,
+	indices []phase0.ValidatorIndex,
+) error {
+	startEpoch, found, err := s.backfillStartEpoch(ctx, md, indices)
+	if err != nil {
+		return err
+	}
+	if !found || md.LastValidatorEpoch == 0 || startEpoch > md.LastValidatorEpoch {
+		log.Trace().Int("validators", len(indices)).Msg("No summaries to backfill for validators added to watchlist")
+		return nil
+	}
+
+	log.Info().Int("validators", len(indices)).Uint64("start_epoch", uint64(startEpoch)).Uint64("end_epoch", uint64(md.LastValidatorEpoch)).Msg("Backfilling validator summaries for validators added to watchlist")
+	for epoch := startEpoch; epoch <= md.LastValidatorEpoch; epoch++ {
+		summaries, _, _, err := s.validatorEpochSummaries(ctx, epoch)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to calculate validator summaries for epoch %d", epoch))
+		}
+		summaries = watchedValidatorEpochSummaries(summaries, indices)
+		if len(summaries) == 0 {
+			continue
+		}
+
+		ctx, cancel, err := s.chainDB.BeginTx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction to set backfilled validator epoch summaries")
+		}
+		if err := s.chainDB.(chaindb.ValidatorEpochSummariesSetter).SetValidatorEpochSummaries(ctx, summaries); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set backfilled validator epoch summaries")
+		}
+		if err := s.chainDB.CommitTx(ctx); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to commit transaction to set backfilled validator epoch summaries")
+		}
+	}
+
+	if md.PeriodicValidatorRollups && md.LastValidatorDay != -1 {
+		if err := s.backfillValidatorDaySummaries(ctx, md, startEpoch, indices); err != nil {
+			return err
+		}
+	}
+	log.Info().Int("validators", len(indices)).Msg("Backfilled validator summaries for validators added to watchlist")
+
+	return nil
+}
+
+// backfillValidatorDaySummaries generates the day summaries of the given validators
+// for the days from the start epoch that have already been summarized.
+func (s *Service) backfillValidatorDaySummaries(ctx context.Context,
+	md *metadata,
+	startEpoch phase0.Epoch,
+	indices []phase0.ValidatorIndex,
+) error {
+	// Start at the first full day from the start epoch.
+	start := s.chainTime.StartOfEpoch(startEpoch).In(time.UTC)
+	startTime := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	if startTime.Before(start) {
+		startTime = startTime.AddDate(0, 0, 1)
+	}
+	lastDay := time.Unix(md.LastValidatorDay, 0).In(time.UTC)
+
+	for timestamp := startTime; !timestamp.After(lastDay); timestamp = timestamp.AddDate(0, 0, 1) {
+		summaries, found, err := s.validatorDaySummaries(ctx, timestamp, timestamp.AddDate(0, 0, 1))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to calculate validator summaries for day %s", timestamp.Format("2006-01-02")))
+		}
+		if !found {
+			// Most likely the balances for the day have been pruned.
+			log.Debug().Time("day", timestamp).Msg("Validator balances not available; not backfilling day summaries")
+			continue
+		}
+		summaries = watchedValidatorDaySummaries(summaries, indices)
+		if len(summaries) == 0 {
+			continue
+		}
+
+		ctx, cancel, err := s.chainDB.BeginTx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction to set backfilled validator day summaries")
+		}
+		// Summaries are set individually, as some may already be present.
+		for _, summary := range summaries {
+			if err := s.chainDB.(chaindb.ValidatorDaySummariesSetter).SetValidatorDaySummary(ctx, summary); err != nil {
+				cancel()
+				return errors.Wrap(err, "failed to set backfilled validator day summary")
+			}
+		}
+		if err := s.chainDB.CommitTx(ctx); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to commit transaction to set backfilled validator day summaries")
+		}
+	}
+
+	return nil
+}
+
+// backfillStartEpoch returns the first epoch for which any of the given validators
+// could have a summary, and false if none of them are known.
+func (s *Service) backfillStartEpoch(ctx context.Context,
+	md *metadata,
+	indices []phase0.ValidatorIndex,
+) (
+	phase0.Epoch,
+	bool,
+	error,
+) {
+	validators, err := s.validatorsProvider.ValidatorsByIndex(ctx, indices)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to obtain validators")
+	}
+
+	found := false
+	startEpoch := s.farFutureEpoch
+	for _, validator := range validators {
+		found = true
+		// A validator has no duties before it is activated.
+		if validator.ActivationEpoch < startEpoch {
+			startEpoch = validator.ActivationEpoch
+		}
+	}
+	if s.origin != nil && startEpoch < s.origin.Epoch {
+		startEpoch = s.origin.Epoch
+	}
+	if s.validatorEpochRetention != nil {
+		// Do not backfill summaries that would be pruned.
+		retainedEpoch := s.chainTime.TimestampToEpoch(s.validatorEpochRetention.Decrement(s.chainTime.StartOfEpoch(md.LastValidatorEpoch)))
+		if startEpoch < retainedEpoch {
+			startEpoch = retainedEpoch
+		}
+	}
+
+	return startEpoch, found, nil
+}
+
+// watchedValidatorEpochSummaries returns the summaries for validators in the watchlist.
+func watchedValidatorEpochSummaries(summaries []*chaindb.ValidatorEpochSummary,
+	watchlist []phase0.ValidatorIndex,
+) []*chaindb.ValidatorEpochSummary {
+	watched := make(map[phase0.ValidatorIndex]struct{}, len(watchlist))
+	for _, index := range watchlist {
+		watched[index] = struct{}{}
+	}
+	res := make([]*chaindb.ValidatorEpochSummary, 0, len(watchlist))
+	for _, summary := range summaries {
+		if _, exists := watched[summary.Index]; exists {
+			res = append(res, summary)
+		}
+	}
+
+	return res
+}
+
+// watchedValidatorDaySummaries returns the summaries for validators in the watchlist.
+func watchedValidatorDaySummaries(summaries []*chaindb.ValidatorDaySummary,
+	watchlist []phase0.ValidatorIndex,
+) []*chaindb.ValidatorDaySummary {
+	watched := make(map[phase0.ValidatorIndex]struct{}, len(watchlist))
+	for _, index := range watchlist {
+		watched[index] = struct{}{}
+	}
+	res := make([]*chaindb.ValidatorDaySummary, 0, len(watchlist))
+	for _, summary := range summaries {
+		if _, exists := watched[summary.Index]; exists {
+			res = append(res, summary)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,90 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// defaultEpochsPerSlashingsVector is the number of epochs between a validator
+// being slashed and becoming withdrawable, if not available from the chain spec.
+const defaultEpochsPerSlashingsVector = 8192
+
+// Service is a validator set snapshot service.
+// It reconstructs the validator set at a given epoch from stored data.
+type Service struct {
+	chainTime                    chaintime.Service
+	validatorsProvider           chaindb.ValidatorsProvider
+	balanceEpochsProvider        chaindb.ValidatorBalanceEpochsProvider
+	originProvider               chaindb.OriginProvider
+	blsToExecutionChangeProvider chaindb.BLSToExecutionChangesProvider
+	epochsPerSlashingsVector     phase0.Epoch
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger(zerologger.With().Str("service", "validators").Str("impl", "snapshot").Logger(), "validators", parameters.logLevel)
+
+	validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not support validator providing")
+	}
+	balanceEpochsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorBalanceEpochsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not support validator balance epoch providing")
+	}
+
+	s := &Service{
+		chainTime:                parameters.chainTime,
+		validatorsProvider:       validatorsProvider,
+		balanceEpochsProvider:    balanceEpochsProvider,
+		epochsPerSlashingsVector: defaultEpochsPerSlashingsVector,
+	}
+	if provider, isProvider := parameters.chainDB.(chaindb.OriginProvider); isProvider {
+		s.originProvider = provider
+	}
+	if provider, isProvider := parameters.chainDB.(chaindb.BLSToExecutionChangesProvider); isProvider {
+		s.blsToExecutionChangeProvider = provider
+	}
+	if provider, isProvider := parameters.chainDB.(chaindb.ChainSpecProvider); isProvider {
+		spec, err := provider.ChainSpec(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain chain spec")
+		}
+		if tmp, exists := spec["EPOCHS_PER_SLASHINGS_VECTOR"]; exists {
+			if epochs, isUint64 := tmp.(uint64); isUint64 {
+				s.epochsPerSlashingsVector = phase0.Epoch(epochs)
+			}
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,219 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"context"
+	"fmt"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+var farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
+
+// ErrNoBalances is returned when no validator balances are held at all.
+var ErrNoBalances = errors.New("no validator balances held")
+
+// ErrEpochUnavailable is returned when the requested epoch is outside of the data held.
+var ErrEpochUnavailable = errors.New("epoch unavailable")
+
+// Snapshot is the validator set as of an epoch.
+type Snapshot struct {
+	// Epoch is the epoch requested.
+	Epoch phase0.Epoch
+	// BalanceEpoch is the epoch from which balances were obtained.
+	BalanceEpoch phase0.Epoch
+	// Exact is true if balances were held for the requested epoch.
+	Exact bool
+	// Notes are human-readable caveats about the data.
+	Notes []string
+	// Entries are the validators, ordered by index.
+	Entries []*Entry
+}
+
+// Entry is a single validator in a snapshot.
+type Entry struct {
+	Index                 phase0.ValidatorIndex
+	PublicKey             phase0.BLSPubKey
+	Status                apiv1.ValidatorState
+	Balance               phase0.Gwei
+	EffectiveBalance      phase0.Gwei
+	WithdrawalCredentials [32]byte
+	// CredentialsChanged is true if the withdrawal credentials were changed
+	// after the epoch, in which case the credentials are the current value
+	// rather than that as of the epoch.
+	CredentialsChanged bool
+}
+
+// Snapshot creates a snapshot of the validator set as of the given epoch.
+// If balances for the epoch are not held, for example because they have been
+// pruned, balances from the closest held epoch are used and the snapshot is
+// marked as inexact.
+func (s *Service) Snapshot(ctx context.Context, epoch phase0.Epoch) (*Snapshot, error) {
+	balanceEpoch, exact, err := s.balanceEpoch(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Epoch:        epoch,
+		BalanceEpoch: balanceEpoch,
+		Exact:        exact,
+		Notes:        make([]string, 0),
+	}
+	if !exact {
+		direction := "earlier"
+		distance := epoch - balanceEpoch
+		if balanceEpoch > epoch {
+			direction = "later"
+			distance = balanceEpoch - epoch
+		}
+		snapshot.Notes = append(snapshot.Notes, fmt.Sprintf("balances for epoch %d are not held; balances are from epoch %d, %d epochs %s", epoch, balanceEpoch, distance, direction))
+	}
+
+	balances, err := s.validatorsProvider.ValidatorBalancesByEpoch(ctx, balanceEpoch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator balances")
+	}
+	validators, err := s.validatorsProvider.Validators(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validators")
+	}
+	validatorsByIndex := make(map[phase0.ValidatorIndex]*chaindb.Validator, len(validators))
+	for _, validator := range validators {
+		validatorsByIndex[validator.Index] = validator
+	}
+	changed, err := s.credentialsChangedAfter(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Entries = make([]*Entry, 0, len(balances))
+	for _, balance := range balances {
+		validator, exists := validatorsByIndex[balance.Index]
+		if !exists {
+			log.Debug().Uint64("index", uint64(balance.Index)).Msg("No validator for balance; skipping")
+			continue
+		}
+		if balanceEpoch > epoch &&
+			validator.ActivationEligibilityEpoch != farFutureEpoch &&
+			validator.ActivationEligibilityEpoch > epoch {
+			// Validator joined between the requested epoch and the balance epoch.
+			continue
+		}
+		_, credentialsChanged := changed[validator.Index]
+		snapshot.Entries = append(snapshot.Entries, &Entry{
+			Index:                 validator.Index,
+			PublicKey:             validator.PublicKey,
+			Status:                s.status(validator, balance.Balance, epoch),
+			Balance:               balance.Balance,
+			EffectiveBalance:      balance.EffectiveBalance,
+			WithdrawalCredentials: validator.WithdrawalCredentials,
+			CredentialsChanged:    credentialsChanged,
+		})
+	}
+	if len(changed) > 0 {
+		snapshot.Notes = append(snapshot.Notes, fmt.Sprintf("%d validators changed withdrawal credentials after epoch %d; their current credentials are shown", len(changed), epoch))
+	}
+
+	return snapshot, nil
+}
+
+// balanceEpoch returns the epoch from which to obtain balances for a snapshot
+// at the given epoch, and whether it is the epoch itself.
+func (s *Service) balanceEpoch(ctx context.Context, epoch phase0.Epoch) (phase0.Epoch, bool, error) {
+	if s.originProvider != nil {
+		origin, err := s.originProvider.Origin(ctx)
+		if err != nil {
+			return 0, false, errors.Wrap(err, "failed to obtain origin")
+		}
+		if origin != nil && epoch < origin.Epoch {
+			return 0, false, errors.Wrapf(ErrEpochUnavailable, "epoch %d is before the ingestion origin at epoch %d", epoch, origin.Epoch)
+		}
+	}
+
+	before, after, err := s.balanceEpochsProvider.NeighbouringValidatorBalanceEpochs(ctx, epoch)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to obtain balance epochs")
+	}
+
+	switch {
+	case before == nil && after == nil:
+		return 0, false, ErrNoBalances
+	case after == nil:
+		return 0, false, errors.Wrapf(ErrEpochUnavailable, "epoch %d is after the latest epoch %d for which balances are held", epoch, *before)
+	case before == nil:
+		return *after, false, nil
+	case *before == epoch:
+		return epoch, true, nil
+	case *after-epoch < epoch-*before:
+		return *after, false, nil
+	default:
+		return *before, false, nil
+	}
+}
+
+// credentialsChangedAfter returns the validators that changed their
+// withdrawal credentials after the given epoch.
+func (s *Service) credentialsChangedAfter(ctx context.Context, epoch phase0.Epoch) (map[phase0.ValidatorIndex]struct{}, error) {
+	res := make(map[phase0.ValidatorIndex]struct{})
+	if s.blsToExecutionChangeProvider == nil {
+		return res, nil
+	}
+
+	from := s.chainTime.FirstSlotOfEpoch(epoch + 1)
+	changes, err := s.blsToExecutionChangeProvider.BLSToExecutionChanges(ctx, &chaindb.BLSToExecutionChangeFilter{
+		From: &from,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain credential changes")
+	}
+	for _, change := range changes {
+		res[change.ValidatorIndex] = struct{}{}
+	}
+
+	return res, nil
+}
+
+// status returns the status of the validator as of the given epoch.
+func (s *Service) status(validator *chaindb.Validator, balance phase0.Gwei, epoch phase0.Epoch) apiv1.ValidatorState {
+	// The slashed flag is the current value; a slashed validator becomes
+	// withdrawable a fixed number of epochs after being slashed, which allows
+	// us to work out if it had been slashed as of the epoch.
+	slashed := validator.Slashed
+	if slashed &&
+		validator.WithdrawableEpoch >= s.epochsPerSlashingsVector &&
+		epoch < validator.WithdrawableEpoch-s.epochsPerSlashingsVector {
+		slashed = false
+	}
+
+	state := apiv1.ValidatorToState(&phase0.Validator{
+		PublicKey:                  validator.PublicKey,
+		WithdrawalCredentials:      validator.WithdrawalCredentials[:],
+		EffectiveBalance:           validator.EffectiveBalance,
+		Slashed:                    slashed,
+		ActivationEligibilityEpoch: validator.ActivationEligibilityEpoch,
+		ActivationEpoch:            validator.ActivationEpoch,
+		ExitEpoch:                  validator.ExitEpoch,
+		WithdrawableEpoch:          validator.WithdrawableEpoch,
+	}, epoch, farFutureEpoch)
+	if state == apiv1.ValidatorStateWithdrawalPossible && balance == 0 {
+		state = apiv1.ValidatorStateWithdrawalDone
+	}
+
+	return state
+}
@@ -0,0 +1,243 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// validatorsProvider provides fixed validators and balances.
+type validatorsProvider struct {
+	chaindb.ValidatorsProvider
+	validators []*chaindb.Validator
+	balances   map[phase0.Epoch][]*chaindb.ValidatorBalance
+	origin     *chaindb.Origin
+}
+
+func (p *validatorsProvider) Validators(_ context.Context) ([]*chaindb.Validator, error) {
+	return p.validators, nil
+}
+
+func (p *validatorsProvider) ValidatorBalancesByEpoch(_ context.Context, epoch phase0.Epoch) ([]*chaindb.ValidatorBalance, error) {
+	return p.balances[epoch], nil
+}
+
+func (p *validatorsProvider) NeighbouringValidatorBalanceEpochs(_ context.Context, epoch phase0.Epoch) (*phase0.Epoch, *phase0.Epoch, error) {
+	var before *phase0.Epoch
+	var after *phase0.Epoch
+	for held := range p.balances {
+		held := held
+		if held <= epoch && (before == nil || held > *before) {
+			before = &held
+		}
+		if held >= epoch && (after == nil || held < *after) {
+			after = &held
+		}
+	}
+
+	return before, after, nil
+}
+
+func (p *validatorsProvider) Origin(_ context.Context) (*chaindb.Origin, error) {
+	return p.origin, nil
+}
+
+func balancesAt(epoch phase0.Epoch, count int) []*chaindb.ValidatorBalance {
+	res := make([]*chaindb.ValidatorBalance, count)
+	for i := range res {
+		res[i] = &chaindb.ValidatorBalance{
+			Index:            phase0.ValidatorIndex(i),
+			Epoch:            epoch,
+			Balance:          phase0.Gwei(32000000000 + uint64(epoch)),
+			EffectiveBalance: 32000000000,
+		}
+	}
+
+	return res
+}
+
+func testProvider() *validatorsProvider {
+	return &validatorsProvider{
+		validators: []*chaindb.Validator{
+			{
+				Index:                      0,
+				ActivationEligibilityEpoch: 0,
+				ActivationEpoch:            0,
+				ExitEpoch:                  farFutureEpoch,
+				WithdrawableEpoch:          farFutureEpoch,
+			},
+			{
+				// Slashed at epoch 150.
+				Index:                      1,
+				Slashed:                    true,
+				ActivationEligibilityEpoch: 0,
+				ActivationEpoch:            0,
+				ExitEpoch:                  200,
+				WithdrawableEpoch:          150 + defaultEpochsPerSlashingsVector,
+			},
+			{
+				// Joined at epoch 250.
+				Index:                      2,
+				ActivationEligibilityEpoch: 250,
+				ActivationEpoch:            255,
+				ExitEpoch:                  farFutureEpoch,
+				WithdrawableEpoch:          farFutureEpoch,
+			},
+		},
+		balances: map[phase0.Epoch][]*chaindb.ValidatorBalance{
+			200: balancesAt(200, 2),
+			300: balancesAt(300, 3),
+		},
+		origin: &chaindb.Origin{Epoch: 10},
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	provider := testProvider()
+	s := &Service{
+		validatorsProvider:       provider,
+		balanceEpochsProvider:    provider,
+		originProvider:           provider,
+		epochsPerSlashingsVector: defaultEpochsPerSlashingsVector,
+	}
+
+	tests := []struct {
+		name         string
+		epoch        phase0.Epoch
+		err          error
+		balanceEpoch phase0.Epoch
+		exact        bool
+		statuses     []apiv1.ValidatorState
+	}{
+		{
+			name:         "Exact",
+			epoch:        300,
+			balanceEpoch: 300,
+			exact:        true,
+			statuses:     []apiv1.ValidatorState{apiv1.ValidatorStateActiveOngoing, apiv1.ValidatorStateExitedSlashed, apiv1.ValidatorStateActiveOngoing},
+		},
+		{
+			name:         "ClosestBefore",
+			epoch:        240,
+			balanceEpoch: 200,
+			statuses:     []apiv1.ValidatorState{apiv1.ValidatorStateActiveOngoing, apiv1.ValidatorStateExitedSlashed},
+		},
+		{
+			name:         "ClosestAfter",
+			epoch:        260,
+			balanceEpoch: 300,
+			statuses:     []apiv1.ValidatorState{apiv1.ValidatorStateActiveOngoing, apiv1.ValidatorStateExitedSlashed, apiv1.ValidatorStateActiveOngoing},
+		},
+		{
+			name:         "AfterExcludesNewValidators",
+			epoch:        120,
+			balanceEpoch: 200,
+			statuses:     []apiv1.ValidatorState{apiv1.ValidatorStateActiveOngoing, apiv1.ValidatorStateActiveExiting},
+		},
+		{
+			name:  "BeforeOrigin",
+			epoch: 5,
+			err:   ErrEpochUnavailable,
+		},
+		{
+			name:  "AfterLatest",
+			epoch: 301,
+			err:   ErrEpochUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snapshot, err := s.Snapshot(ctx, test.epoch)
+			if test.err != nil {
+				require.True(t, errors.Is(err, test.err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.epoch, snapshot.Epoch)
+			require.Equal(t, test.balanceEpoch, snapshot.BalanceEpoch)
+			require.Equal(t, test.exact, snapshot.Exact)
+			if !test.exact {
+				require.NotEmpty(t, snapshot.Notes)
+			}
+			statuses := make([]apiv1.ValidatorState, len(snapshot.Entries))
+			for i, entry := range snapshot.Entries {
+				statuses[i] = entry.Status
+			}
+			require.Equal(t, test.statuses, statuses)
+		})
+	}
+}
+
+func TestSnapshotNoBalances(t *testing.T) {
+	provider := testProvider()
+	provider.balances = nil
+	s := &Service{
+		validatorsProvider:    provider,
+		balanceEpochsProvider: provider,
+	}
+
+	_, err := s.Snapshot(context.Background(), 100)
+	require.True(t, errors.Is(err, ErrNoBalances))
+}
+
+func TestWriters(t *testing.T) {
+	provider := testProvider()
+	s := &Service{
+		validatorsProvider:       provider,
+		balanceEpochsProvider:    provider,
+		epochsPerSlashingsVector: defaultEpochsPerSlashingsVector,
+	}
+	snapshot, err := s.Snapshot(context.Background(), 240)
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, snapshot.WriteCSV(buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Equal(t, strings.Join(csvHeader, ","), lines[0])
+	require.True(t, strings.HasPrefix(lines[1], "0,0x"))
+	require.True(t, strings.HasSuffix(lines[2], ",exited_slashed,32000000200,32000000000,0x0000000000000000000000000000000000000000000000000000000000000000,false,200"))
+
+	buf.Reset()
+	require.NoError(t, snapshot.WriteJSON(buf))
+	res := &struct {
+		headerJSON
+		Validators []*entryJSON `json:"validators"`
+	}{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), res))
+	require.Equal(t, uint64(240), res.Epoch)
+	require.Equal(t, uint64(200), res.BalanceEpoch)
+	require.False(t, res.Exact)
+	require.Len(t, res.Notes, 1)
+	require.Len(t, res.Validators, 2)
+	require.Equal(t, "exited_slashed", res.Validators[1].Status)
+
+	// Empty snapshot is still valid JSON.
+	buf.Reset()
+	require.NoError(t, (&Snapshot{Notes: []string{}}).WriteJSON(buf))
+	require.True(t, json.Valid(buf.Bytes()))
+}
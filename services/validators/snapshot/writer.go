@@ -0,0 +1,127 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// csvHeader is the header row for CSV output.
+var csvHeader = []string{
+	"index",
+	"public_key",
+	"status",
+	"balance",
+	"effective_balance",
+	"withdrawal_credentials",
+	"credentials_changed",
+	"balance_epoch",
+}
+
+// entryJSON is the JSON representation of a snapshot entry.
+type entryJSON struct {
+	Index                 uint64 `json:"index"`
+	PublicKey             string `json:"public_key"`
+	Status                string `json:"status"`
+	Balance               uint64 `json:"balance"`
+	EffectiveBalance      uint64 `json:"effective_balance"`
+	WithdrawalCredentials string `json:"withdrawal_credentials"`
+	CredentialsChanged    bool   `json:"credentials_changed"`
+}
+
+// headerJSON is the JSON representation of the snapshot labelling.
+type headerJSON struct {
+	Epoch        uint64   `json:"epoch"`
+	BalanceEpoch uint64   `json:"balance_epoch"`
+	Exact        bool     `json:"exact"`
+	Notes        []string `json:"notes"`
+}
+
+// WriteCSV writes the snapshot as CSV, one row per validator.
+// Each row carries the epoch from which its balance was obtained, so that
+// inexact data remains labelled when rows are separated from the snapshot.
+func (s *Snapshot) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return errors.Wrap(err, "failed to write header")
+	}
+	balanceEpoch := strconv.FormatUint(uint64(s.BalanceEpoch), 10)
+	for _, entry := range s.Entries {
+		if err := writer.Write([]string{
+			strconv.FormatUint(uint64(entry.Index), 10),
+			fmt.Sprintf("%#x", entry.PublicKey),
+			entry.Status.String(),
+			strconv.FormatUint(uint64(entry.Balance), 10),
+			strconv.FormatUint(uint64(entry.EffectiveBalance), 10),
+			fmt.Sprintf("%#x", entry.WithdrawalCredentials),
+			strconv.FormatBool(entry.CredentialsChanged),
+			balanceEpoch,
+		}); err != nil {
+			return errors.Wrap(err, "failed to write row")
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// WriteJSON writes the snapshot as a JSON object, streaming validators one at a time.
+func (s *Snapshot) WriteJSON(w io.Writer) error {
+	header, err := json.Marshal(&headerJSON{
+		Epoch:        uint64(s.Epoch),
+		BalanceEpoch: uint64(s.BalanceEpoch),
+		Exact:        s.Exact,
+		Notes:        s.Notes,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal header")
+	}
+	// Open the object with the header fields, leaving it open for the validators.
+	if _, err := fmt.Fprintf(w, `%s,"validators":[`, header[:len(header)-1]); err != nil {
+		return errors.Wrap(err, "failed to write header")
+	}
+	for i, entry := range s.Entries {
+		data, err := json.Marshal(&entryJSON{
+			Index:                 uint64(entry.Index),
+			PublicKey:             fmt.Sprintf("%#x", entry.PublicKey),
+			Status:                entry.Status.String(),
+			Balance:               uint64(entry.Balance),
+			EffectiveBalance:      uint64(entry.EffectiveBalance),
+			WithdrawalCredentials: fmt.Sprintf("%#x", entry.WithdrawalCredentials),
+			CredentialsChanged:    entry.CredentialsChanged,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal entry")
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return errors.Wrap(err, "failed to write entry")
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return errors.Wrap(err, "failed to write entry")
+		}
+	}
+	if _, err := io.WriteString(w, "]}\n"); err != nil {
+		return errors.Wrap(err, "failed to write trailer")
+	}
+
+	return nil
+}
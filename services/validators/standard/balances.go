@@ -0,0 +1,157 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+// fetchValidators fetches the validators, with their balances, for the given state.
+//
+// If a batch size is configured and the number of validators is known, the
+// validators are fetched in pages of indices with bounded concurrency.  Each page
+// is retried independently, so a timeout or other failure affects only that page.
+// Otherwise all validators are fetched in a single request.
+func (s *Service) fetchValidators(ctx context.Context,
+	stateID string,
+) (
+	map[phase0.ValidatorIndex]*apiv1.Validator,
+	error,
+) {
+	provider := s.eth2Client.(eth2client.ValidatorsProvider)
+
+	validatorCount := s.validatorCount.Load()
+	if s.balancesBatchSize == 0 || validatorCount == 0 {
+		return provider.Validators(ctx, stateID, nil)
+	}
+
+	pages := make([][]phase0.ValidatorIndex, 0, validatorCount/s.balancesBatchSize+1)
+	for start := uint64(0); start < validatorCount; start += s.balancesBatchSize {
+		end := start + s.balancesBatchSize
+		if end > validatorCount {
+			end = validatorCount
+		}
+		indices := make([]phase0.ValidatorIndex, 0, end-start)
+		for index := start; index < end; index++ {
+			indices = append(indices, phase0.ValidatorIndex(index))
+		}
+		pages = append(pages, indices)
+	}
+
+	return s.fetchValidatorsPages(ctx, provider, stateID, pages)
+}
+
+// fetchValidatorsByIndex fetches the validators with the given indices, with their
+// balances, for the given state.  The validators are fetched in pages as per
+// fetchValidators.
+func (s *Service) fetchValidatorsByIndex(ctx context.Context,
+	stateID string,
+	indices []phase0.ValidatorIndex,
+) (
+	map[phase0.ValidatorIndex]*apiv1.Validator,
+	error,
+) {
+	provider := s.eth2Client.(eth2client.ValidatorsProvider)
+
+	if len(indices) == 0 {
+		return make(map[phase0.ValidatorIndex]*apiv1.Validator), nil
+	}
+	if s.balancesBatchSize == 0 {
+		return s.fetchValidatorsPage(ctx, provider, stateID, indices)
+	}
+
+	pages := make([][]phase0.ValidatorIndex, 0, uint64(len(indices))/s.balancesBatchSize+1)
+	for start := uint64(0); start < uint64(len(indices)); start += s.balancesBatchSize {
+		end := start + s.balancesBatchSize
+		if end > uint64(len(indices)) {
+			end = uint64(len(indices))
+		}
+		pages = append(pages, indices[start:end])
+	}
+
+	return s.fetchValidatorsPages(ctx, provider, stateID, pages)
+}
+
+// fetchValidatorsPages fetches pages of validators with bounded concurrency.
+func (s *Service) fetchValidatorsPages(ctx context.Context,
+	provider eth2client.ValidatorsProvider,
+	stateID string,
+	pages [][]phase0.ValidatorIndex,
+) (
+	map[phase0.ValidatorIndex]*apiv1.Validator,
+	error,
+) {
+	var resMu sync.Mutex
+	res := make(map[phase0.ValidatorIndex]*apiv1.Validator)
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.balancesConcurrency)
+	for _, indices := range pages {
+		indices := indices
+		g.Go(func() error {
+			validators, err := s.fetchValidatorsPage(ctx, provider, stateID, indices)
+			if err != nil {
+				return err
+			}
+			resMu.Lock()
+			for index, validator := range validators {
+				res[index] = validator
+			}
+			resMu.Unlock()
+
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// fetchValidatorsPage fetches a single page of validators, retrying on failure.
+func (s *Service) fetchValidatorsPage(ctx context.Context,
+	provider eth2client.ValidatorsProvider,
+	stateID string,
+	indices []phase0.ValidatorIndex,
+) (
+	map[phase0.ValidatorIndex]*apiv1.Validator,
+	error,
+) {
+	var err error
+	for attempt := 0; attempt <= s.balancesPageRetries; attempt++ {
+		if attempt > 0 {
+			log.Debug().Str("state_id", stateID).Uint64("first_index", uint64(indices[0])).Int("attempt", attempt).Err(err).Msg("Retrying page of validators")
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt) * s.balancesRetryBackoff):
+			}
+		}
+		var validators map[phase0.ValidatorIndex]*apiv1.Validator
+		validators, err = provider.Validators(ctx, stateID, indices)
+		if err == nil {
+			return validators, nil
+		}
+	}
+
+	return nil, errors.Wrapf(err, "failed to obtain validators %d-%d", indices[0], indices[len(indices)-1])
+}
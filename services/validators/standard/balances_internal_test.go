@@ -0,0 +1,154 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedClient provides validators, failing the first request for the given page.
+type pagedClient struct {
+	mu        sync.Mutex
+	failFirst phase0.ValidatorIndex
+	failures  int
+	requests  map[phase0.ValidatorIndex]int
+	all       int
+}
+
+func (*pagedClient) Name() string    { return "paged" }
+func (*pagedClient) Address() string { return "paged" }
+
+func (c *pagedClient) Validators(_ context.Context,
+	_ string,
+	indices []phase0.ValidatorIndex,
+) (
+	map[phase0.ValidatorIndex]*apiv1.Validator,
+	error,
+) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(indices) == 0 {
+		c.all++
+		return map[phase0.ValidatorIndex]*apiv1.Validator{}, nil
+	}
+	c.requests[indices[0]]++
+	if indices[0] == c.failFirst && c.failures > 0 {
+		c.failures--
+		return nil, errors.New("timeout")
+	}
+	res := make(map[phase0.ValidatorIndex]*apiv1.Validator, len(indices))
+	for _, index := range indices {
+		res[index] = &apiv1.Validator{Index: index, Balance: phase0.Gwei(index)}
+	}
+
+	return res, nil
+}
+
+func (*pagedClient) ValidatorsByPubKey(_ context.Context,
+	_ string,
+	_ []phase0.BLSPubKey,
+) (
+	map[phase0.ValidatorIndex]*apiv1.Validator,
+	error,
+) {
+	return nil, errors.New("not implemented")
+}
+
+func TestFetchValidators(t *testing.T) {
+	tests := []struct {
+		name      string
+		count     uint64
+		batchSize uint64
+		failures  int
+		retries   int
+		err       string
+		all       int
+		requests  map[phase0.ValidatorIndex]int
+	}{
+		{
+			name:      "Unpaged",
+			count:     25,
+			batchSize: 0,
+			all:       1,
+			requests:  map[phase0.ValidatorIndex]int{},
+		},
+		{
+			name:      "CountUnknown",
+			batchSize: 10,
+			all:       1,
+			requests:  map[phase0.ValidatorIndex]int{},
+		},
+		{
+			name:      "Paged",
+			count:     25,
+			batchSize: 10,
+			requests:  map[phase0.ValidatorIndex]int{0: 1, 10: 1, 20: 1},
+		},
+		{
+			name:      "PageRetried",
+			count:     25,
+			batchSize: 10,
+			failures:  2,
+			retries:   2,
+			requests:  map[phase0.ValidatorIndex]int{0: 1, 10: 3, 20: 1},
+		},
+		{
+			name:      "PageFails",
+			count:     25,
+			batchSize: 10,
+			failures:  3,
+			retries:   2,
+			err:       "failed to obtain validators 10-19: timeout",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &pagedClient{
+				failFirst: 10,
+				failures:  test.failures,
+				requests:  make(map[phase0.ValidatorIndex]int),
+			}
+			s := &Service{
+				eth2Client:          client,
+				balancesBatchSize:   test.batchSize,
+				balancesConcurrency: 2,
+				balancesPageRetries: test.retries,
+			}
+			s.validatorCount.Store(test.count)
+
+			validators, err := s.fetchValidators(context.Background(), "head")
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.all, client.all)
+			require.Equal(t, test.requests, client.requests)
+			if test.all == 0 {
+				require.Len(t, validators, int(test.count))
+				for index, validator := range validators {
+					require.Equal(t, phase0.Gwei(index), validator.Balance)
+				}
+			}
+		})
+	}
+}
@@ -17,8 +17,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
@@ -90,6 +92,7 @@ func (s *Service) onEpochTransitionValidators(ctx context.Context,
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain validators")
 	}
+	s.validatorCount.Store(uint64(len(validators)))
 
 	// Fetch our current validators from the database.
 	dbVs, err := s.validatorsSetter.(chaindb.ValidatorsProvider).Validators(ctx)
@@ -106,10 +109,16 @@ func (s *Service) onEpochTransitionValidators(ctx context.Context,
 	if err != nil {
 		return errors.Wrap(err, "failed to begin transaction for validators")
 	}
+	slashedValidators := make(map[phase0.ValidatorIndex]*phase0.Validator)
 	for index, validator := range validators {
 		if !needsUpdate(validator.Validator, index, dbValidators) {
 			continue
 		}
+		if validator.Validator.Slashed {
+			if dbValidator, exists := dbValidators[index]; !exists || !dbValidator.Slashed {
+				slashedValidators[index] = validator.Validator
+			}
+		}
 
 		withdrawalCredentials := [32]byte{}
 		copy(withdrawalCredentials[:], validator.Validator.WithdrawalCredentials)
@@ -129,6 +138,12 @@ func (s *Service) onEpochTransitionValidators(ctx context.Context,
 			return errors.Wrap(err, "failed to set validator")
 		}
 	}
+	if s.slashingsProviders != nil && len(slashedValidators) > 0 {
+		if err := s.recordSlashings(ctx, slashedValidators); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to record validator slashings")
+		}
+	}
 	md.LatestEpoch = transitionedEpoch
 	if err := s.setMetadata(ctx, md); err != nil {
 		cancel()
@@ -160,6 +175,13 @@ func (s *Service) onEpochTransitionValidatorBalances(ctx context.Context,
 	if firstEpoch > 0 {
 		firstEpoch++
 	}
+	if s.origin != nil && firstEpoch < s.origin.Epoch {
+		// Do not fetch balances from before the ingestion origin.
+		firstEpoch = s.origin.Epoch
+	}
+	if err := s.updateWatchlist(ctx, md); err != nil {
+		return errors.Wrap(err, "failed to update watchlist")
+	}
 	for epoch := firstEpoch; epoch <= transitionedEpoch; epoch++ {
 		if err := s.onEpochTransitionValidatorBalancesForEpoch(ctx, md, epoch); err != nil {
 			return err
@@ -179,10 +201,18 @@ func (s *Service) onEpochTransitionValidatorBalancesForEpoch(ctx context.Context
 	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
 	stateID := fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch))
 	log.Trace().Uint64("slot", uint64(s.chainTime.FirstSlotOfEpoch(epoch))).Msg("Fetching validators")
-	validators, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, stateID, nil)
+	started := time.Now()
+	var validators map[phase0.ValidatorIndex]*apiv1.Validator
+	var err error
+	if md.Watchlist != nil {
+		validators, err = s.fetchValidatorsByIndex(ctx, stateID, *md.Watchlist)
+	} else {
+		validators, err = s.fetchValidators(ctx, stateID)
+	}
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain validators for validator balances")
 	}
+	monitorBalancesFetched(time.Since(started))
 	span.AddEvent("Obtained validators", trace.WithAttributes(
 		attribute.Int("slot", int(s.chainTime.FirstSlotOfEpoch(epoch))),
 	))
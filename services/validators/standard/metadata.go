@@ -26,6 +26,8 @@ type metadata struct {
 	LatestEpoch         phase0.Epoch   `json:"latest_epoch"`
 	LatestBalancesEpoch phase0.Epoch   `json:"latest_balances_epoch"`
 	MissedEpochs        []phase0.Epoch `json:"missed_epochs,omitempty"`
+	// Watchlist is the watchlist for which balances have been stored, if a watchlist is in use.
+	Watchlist *[]phase0.ValidatorIndex `json:"watchlist,omitempty"`
 }
 
 // metadataKey is the key for the metadata.
@@ -58,3 +60,22 @@ func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
 	}
 	return nil
 }
+
+// LatestCompleteEpoch returns the latest epoch for which validators, and balances
+// if enabled, have been processed.
+func (s *Service) LatestCompleteEpoch(ctx context.Context) (phase0.Epoch, bool, error) {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	epoch := md.LatestEpoch
+	if s.balances && md.LatestBalancesEpoch < epoch {
+		epoch = md.LatestBalancesEpoch
+	}
+	if epoch == 0 {
+		// Metadata does not distinguish between epoch 0 and no epoch.
+		return 0, false, nil
+	}
+
+	return epoch, true, nil
+}
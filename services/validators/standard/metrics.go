@@ -15,10 +15,12 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/metrics"
 )
 
@@ -34,8 +36,11 @@ var (
 	balancesHighestEpoch    phase0.Epoch
 	balancesLatestEpoch     prometheus.Gauge
 	balancesEpochsProcessed prometheus.Gauge
+	balancesFetchDuration   prometheus.Gauge
 )
 
+var slashingsRecorded *prometheus.CounterVec
+
 func registerMetrics(_ context.Context, monitor metrics.Service) error {
 	if latestEpoch != nil {
 		// Already registered.
@@ -88,6 +93,24 @@ func registerPrometheusMetrics() error {
 		return errors.Wrap(err, "failed to register balances_epochs_processed")
 	}
 
+	balancesFetchDuration = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "balances_fetch_duration_seconds",
+		Help:      "Time taken to fetch validator balances for the most recent epoch",
+	})
+	if err := prometheus.Register(balancesFetchDuration); err != nil {
+		return errors.Wrap(err, "failed to register balances_fetch_duration_seconds")
+	}
+
+	slashingsRecorded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "slashings_recorded_total",
+		Help:      "Number of validator slashings recorded",
+	}, []string{"type"})
+	if err := prometheus.Register(slashingsRecorded); err != nil {
+		return errors.Wrap(err, "failed to register slashings_recorded_total")
+	}
+
 	return nil
 }
 
@@ -110,3 +133,22 @@ func monitorBalancesEpochProcessed(epoch phase0.Epoch) {
 		}
 	}
 }
+
+func monitorBalancesFetched(duration time.Duration) {
+	if balancesFetchDuration != nil {
+		balancesFetchDuration.Set(duration.Seconds())
+	}
+}
+
+func monitorSlashingRecorded(slashingType chaindb.SlashingType) {
+	if slashingsRecorded != nil {
+		switch slashingType {
+		case chaindb.SlashingTypeProposer:
+			slashingsRecorded.WithLabelValues("proposer").Inc()
+		case chaindb.SlashingTypeAttester:
+			slashingsRecorded.WithLabelValues("attester").Inc()
+		default:
+			slashingsRecorded.WithLabelValues("unknown").Inc()
+		}
+	}
+}
@@ -15,22 +15,29 @@ package standard
 
 import (
 	"errors"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/rs/zerolog"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/watchlist"
 )
 
 type parameters struct {
-	logLevel   zerolog.Level
-	monitor    metrics.Service
-	eth2Client eth2client.Service
-	chainDB    chaindb.Service
-	chainTime  chaintime.Service
-	balances   bool
-	startEpoch int64
+	logLevel             zerolog.Level
+	monitor              metrics.Service
+	eth2Client           eth2client.Service
+	chainDB              chaindb.Service
+	chainTime            chaintime.Service
+	balances             bool
+	startEpoch           int64
+	balancesBatchSize    uint64
+	balancesConcurrency  int
+	balancesPageRetries  int
+	balancesRetryBackoff time.Duration
+	watchlist            watchlist.Service
 }
 
 // Parameter is the interface for service parameters.
@@ -93,12 +100,46 @@ func WithBalances(balances bool) Parameter {
 	})
 }
 
+// WithBalancesBatchSize sets the number of validators to fetch in each request for balances.
+// A batch size of 0 fetches all validators in a single request.
+func WithBalancesBatchSize(size uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.balancesBatchSize = size
+	})
+}
+
+// WithBalancesConcurrency sets the maximum number of concurrent requests for balances.
+func WithBalancesConcurrency(concurrency int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.balancesConcurrency = concurrency
+	})
+}
+
+// WithBalancesPageRetries sets the number of times a failed request for a batch of balances is retried.
+func WithBalancesPageRetries(retries int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.balancesPageRetries = retries
+	})
+}
+
+// WithWatchlist sets a watchlist, restricting the validator balances stored to
+// those of the validators on the watchlist.
+func WithWatchlist(watchlist watchlist.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.watchlist = watchlist
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel:   zerolog.GlobalLevel(),
-		startEpoch: -1,
-		balances:   false,
+		logLevel:             zerolog.GlobalLevel(),
+		startEpoch:           -1,
+		balances:             false,
+		balancesBatchSize:    1000,
+		balancesConcurrency:  8,
+		balancesPageRetries:  3,
+		balancesRetryBackoff: time.Second,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -115,6 +156,12 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.chainTime == nil {
 		return nil, errors.New("no chain time specified")
 	}
+	if parameters.balancesConcurrency < 1 {
+		return nil, errors.New("balances concurrency must be at least 1")
+	}
+	if parameters.balancesPageRetries < 0 {
+		return nil, errors.New("balances page retries cannot be negative")
+	}
 
 	return &parameters, nil
 }
@@ -15,14 +15,19 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/watchlist"
+	"github.com/wealdtech/chaind/util"
+	"go.uber.org/atomic"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -35,8 +40,25 @@ type Service struct {
 	chainTime          chaintime.Service
 	balances           bool
 	activitySem        *semaphore.Weighted
+	origin             *chaindb.Origin
+	// validatorCount is the number of validators at the chain head, used to
+	// page through validators when fetching balances.
+	validatorCount       atomic.Uint64
+	balancesBatchSize    uint64
+	balancesConcurrency  int
+	balancesPageRetries  int
+	balancesRetryBackoff time.Duration
+	// slashingsProviders is nil if the database does not record validator slashings.
+	slashingsProviders       slashingsProviders
+	epochsPerSlashingsVector phase0.Epoch
+	// watchlist restricts the balances stored; it can be nil.
+	watchlist watchlist.Service
 }
 
+// defaultEpochsPerSlashingsVector is the number of epochs between a validator
+// being slashed and becoming withdrawable, if not available from the chain spec.
+const defaultEpochsPerSlashingsVector = 8192
+
 // module-wide log.
 var log zerolog.Logger
 
@@ -48,7 +70,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "validators").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log = util.ServiceLogger(zerologger.With().Str("service", "validators").Str("impl", "standard").Logger(), "validators", parameters.logLevel)
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -64,14 +86,43 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support validator setting")
 	}
 
+	var origin *chaindb.Origin
+	if originProvider, isOriginProvider := parameters.chainDB.(chaindb.OriginProvider); isOriginProvider {
+		origin, err = originProvider.Origin(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain origin")
+		}
+	}
+
 	s := &Service{
-		eth2Client:         parameters.eth2Client,
-		chainDB:            parameters.chainDB,
-		validatorsProvider: validatorsProvider,
-		validatorsSetter:   validatorsSetter,
-		chainTime:          parameters.chainTime,
-		balances:           parameters.balances,
-		activitySem:        semaphore.NewWeighted(1),
+		eth2Client:               parameters.eth2Client,
+		chainDB:                  parameters.chainDB,
+		validatorsProvider:       validatorsProvider,
+		validatorsSetter:         validatorsSetter,
+		chainTime:                parameters.chainTime,
+		balances:                 parameters.balances,
+		activitySem:              semaphore.NewWeighted(1),
+		origin:                   origin,
+		balancesBatchSize:        parameters.balancesBatchSize,
+		balancesConcurrency:      parameters.balancesConcurrency,
+		balancesPageRetries:      parameters.balancesPageRetries,
+		balancesRetryBackoff:     parameters.balancesRetryBackoff,
+		epochsPerSlashingsVector: defaultEpochsPerSlashingsVector,
+		watchlist:                parameters.watchlist,
+	}
+	if providers, isProviders := parameters.chainDB.(slashingsProviders); isProviders {
+		s.slashingsProviders = providers
+	}
+	if provider, isProvider := parameters.chainDB.(chaindb.ChainSpecProvider); isProvider {
+		spec, err := provider.ChainSpec(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain chain spec")
+		}
+		if tmp, exists := spec["EPOCHS_PER_SLASHINGS_VECTOR"]; exists {
+			if epochs, isUint64 := tmp.(uint64); isUint64 {
+				s.epochsPerSlashingsVector = phase0.Epoch(epochs)
+			}
+		}
 	}
 
 	// Update to current epoch (in the background).
@@ -120,6 +171,9 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 	if err := s.onEpochTransitionValidatorBalances(ctx, md, currentEpoch); err != nil {
 		log.Warn().Err(err).Msg("Failed to update validators")
 	}
+	if err := s.backfillSlashings(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to backfill validator slashings")
+	}
 	s.activitySem.Release(1)
 
 	log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Caught up")
@@ -0,0 +1,228 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel"
+)
+
+// slashingsProviders are the database functions required to record validator slashings.
+type slashingsProviders interface {
+	chaindb.ValidatorSlashingsProvider
+	chaindb.ValidatorSlashingsSetter
+	chaindb.ProposerSlashingsProvider
+	chaindb.AttesterSlashingsProvider
+}
+
+// slashingEpoch returns the epoch at which the validator was slashed.
+// Slashing sets the withdrawable epoch to a fixed number of epochs after the
+// slashing epoch, unless the validator was already due to become withdrawable
+// later, so this is exact for all but validators slashed well after exiting.
+func (s *Service) slashingEpoch(withdrawableEpoch phase0.Epoch) phase0.Epoch {
+	if withdrawableEpoch < s.epochsPerSlashingsVector {
+		return 0
+	}
+
+	return withdrawableEpoch - s.epochsPerSlashingsVector
+}
+
+// recordSlashings records the slashings of the given validators, linking each
+// to the slashing operation that caused it if the operation is in the database.
+// This must be called within a transaction.
+func (s *Service) recordSlashings(ctx context.Context,
+	validators map[phase0.ValidatorIndex]*phase0.Validator,
+) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.validators.standard").Start(ctx, "recordSlashings")
+	defer span.End()
+
+	// Group validators by the epoch of their slashing, as the operation will be
+	// included in a block in that epoch.
+	epochIndices := make(map[phase0.Epoch][]phase0.ValidatorIndex)
+	for index, validator := range validators {
+		epoch := s.slashingEpoch(validator.WithdrawableEpoch)
+		epochIndices[epoch] = append(epochIndices[epoch], index)
+	}
+
+	for epoch, indices := range epochIndices {
+		minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+		maxSlot := s.chainTime.FirstSlotOfEpoch(epoch + 1)
+		proposerSlashings, err := s.slashingsProviders.ProposerSlashingsForSlotRange(ctx, minSlot, maxSlot)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain proposer slashings")
+		}
+		attesterSlashings, err := s.slashingsProviders.AttesterSlashingsForSlotRange(ctx, minSlot, maxSlot)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain attester slashings")
+		}
+
+		for _, slashing := range linkSlashings(epoch, indices, proposerSlashings, attesterSlashings) {
+			if slashing.Type == chaindb.SlashingTypeUnknown {
+				log.Debug().Uint64("index", uint64(slashing.Index)).Uint64("epoch", uint64(epoch)).Msg("Slashing operation not found for slashed validator")
+			}
+			if err := s.slashingsProviders.SetValidatorSlashing(ctx, slashing); err != nil {
+				return errors.Wrap(err, "failed to set validator slashing")
+			}
+			monitorSlashingRecorded(slashing.Type)
+		}
+	}
+
+	return nil
+}
+
+// linkSlashings creates slashing records for the given validators slashed in the
+// given epoch, referencing the slashing operations that caused them.
+// If a validator is in more than one operation then the first processed is the
+// one that slashed it; proposer slashings are processed before attester
+// slashings in the same block.
+func linkSlashings(epoch phase0.Epoch,
+	indices []phase0.ValidatorIndex,
+	proposerSlashings []*chaindb.ProposerSlashing,
+	attesterSlashings []*chaindb.AttesterSlashing,
+) []*chaindb.ValidatorSlashing {
+	slashings := make(map[phase0.ValidatorIndex]*chaindb.ValidatorSlashing, len(indices))
+	for _, index := range indices {
+		slashings[index] = &chaindb.ValidatorSlashing{
+			Index: index,
+			Epoch: epoch,
+			Type:  chaindb.SlashingTypeUnknown,
+		}
+	}
+
+	link := func(index phase0.ValidatorIndex,
+		slashingType chaindb.SlashingType,
+		inclusionSlot phase0.Slot,
+		inclusionBlockRoot phase0.Root,
+		inclusionIndex uint64,
+	) {
+		slashing, exists := slashings[index]
+		if !exists {
+			return
+		}
+		if slashing.InclusionSlot != nil {
+			if *slashing.InclusionSlot < inclusionSlot {
+				return
+			}
+			if *slashing.InclusionSlot == inclusionSlot {
+				if slashing.Type < slashingType {
+					return
+				}
+				if slashing.Type == slashingType && *slashing.InclusionIndex < inclusionIndex {
+					return
+				}
+			}
+		}
+		slashing.Type = slashingType
+		slashing.InclusionSlot = &inclusionSlot
+		slashing.InclusionBlockRoot = &inclusionBlockRoot
+		slashing.InclusionIndex = &inclusionIndex
+	}
+
+	for _, proposerSlashing := range proposerSlashings {
+		link(proposerSlashing.Header1ProposerIndex,
+			chaindb.SlashingTypeProposer,
+			proposerSlashing.InclusionSlot,
+			proposerSlashing.InclusionBlockRoot,
+			proposerSlashing.InclusionIndex,
+		)
+	}
+
+	for _, attesterSlashing := range attesterSlashings {
+		// Only validators in both attestations are slashed.
+		attestation2Indices := make(map[phase0.ValidatorIndex]bool, len(attesterSlashing.Attestation2Indices))
+		for _, index := range attesterSlashing.Attestation2Indices {
+			attestation2Indices[index] = true
+		}
+		for _, index := range attesterSlashing.Attestation1Indices {
+			if !attestation2Indices[index] {
+				continue
+			}
+			link(index,
+				chaindb.SlashingTypeAttester,
+				attesterSlashing.InclusionSlot,
+				attesterSlashing.InclusionBlockRoot,
+				attesterSlashing.InclusionIndex,
+			)
+		}
+	}
+
+	res := make([]*chaindb.ValidatorSlashing, 0, len(indices))
+	for _, index := range indices {
+		res = append(res, slashings[index])
+	}
+
+	return res
+}
+
+// backfillSlashings records slashings for validators that were slashed before
+// slashings were recorded, and retries linking slashings whose operation was
+// not found when they were recorded.
+func (s *Service) backfillSlashings(ctx context.Context) error {
+	ctx, span := otel.Tracer("wealdtech.chaind.services.validators.standard").Start(ctx, "backfillSlashings")
+	defer span.End()
+
+	if s.slashingsProviders == nil {
+		return nil
+	}
+
+	dbValidators, err := s.validatorsProvider.Validators(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain validators")
+	}
+	slashings, err := s.slashingsProviders.ValidatorSlashings(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain validator slashings")
+	}
+	linked := make(map[phase0.ValidatorIndex]bool, len(slashings))
+	for _, slashing := range slashings {
+		if slashing.Type != chaindb.SlashingTypeUnknown {
+			linked[slashing.Index] = true
+		}
+	}
+
+	validators := make(map[phase0.ValidatorIndex]*phase0.Validator)
+	for _, dbValidator := range dbValidators {
+		if !dbValidator.Slashed || linked[dbValidator.Index] {
+			continue
+		}
+		validators[dbValidator.Index] = &phase0.Validator{
+			PublicKey:         dbValidator.PublicKey,
+			Slashed:           dbValidator.Slashed,
+			WithdrawableEpoch: dbValidator.WithdrawableEpoch,
+		}
+	}
+	if len(validators) == 0 {
+		return nil
+	}
+	log.Trace().Int("validators", len(validators)).Msg("Backfilling validator slashings")
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction for validator slashings")
+	}
+	if err := s.recordSlashings(ctx, validators); err != nil {
+		cancel()
+		return err
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction for validator slashings")
+	}
+
+	return nil
+}
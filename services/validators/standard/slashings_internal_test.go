@@ -0,0 +1,149 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestSlashingEpoch(t *testing.T) {
+	s := &Service{epochsPerSlashingsVector: 8192}
+	require.Equal(t, phase0.Epoch(100), s.slashingEpoch(8292))
+	require.Equal(t, phase0.Epoch(0), s.slashingEpoch(100))
+}
+
+func TestLinkSlashings(t *testing.T) {
+	root1 := phase0.Root{0x01}
+	root2 := phase0.Root{0x02}
+
+	slot := func(slot phase0.Slot) *phase0.Slot { return &slot }
+	root := func(root phase0.Root) *phase0.Root { return &root }
+	index := func(index uint64) *uint64 { return &index }
+
+	tests := []struct {
+		name              string
+		indices           []phase0.ValidatorIndex
+		proposerSlashings []*chaindb.ProposerSlashing
+		attesterSlashings []*chaindb.AttesterSlashing
+		expected          []*chaindb.ValidatorSlashing
+	}{
+		{
+			name:    "NotFound",
+			indices: []phase0.ValidatorIndex{1},
+			expected: []*chaindb.ValidatorSlashing{
+				{Index: 1, Epoch: 10, Type: chaindb.SlashingTypeUnknown},
+			},
+		},
+		{
+			name:    "Proposer",
+			indices: []phase0.ValidatorIndex{1},
+			proposerSlashings: []*chaindb.ProposerSlashing{
+				{InclusionSlot: 321, InclusionBlockRoot: root1, InclusionIndex: 0, Header1ProposerIndex: 2},
+				{InclusionSlot: 322, InclusionBlockRoot: root2, InclusionIndex: 1, Header1ProposerIndex: 1},
+			},
+			expected: []*chaindb.ValidatorSlashing{
+				{Index: 1, Epoch: 10, Type: chaindb.SlashingTypeProposer, InclusionSlot: slot(322), InclusionBlockRoot: root(root2), InclusionIndex: index(1)},
+			},
+		},
+		{
+			name:    "Attester",
+			indices: []phase0.ValidatorIndex{1, 2},
+			attesterSlashings: []*chaindb.AttesterSlashing{
+				{
+					InclusionSlot:       321,
+					InclusionBlockRoot:  root1,
+					InclusionIndex:      0,
+					Attestation1Indices: []phase0.ValidatorIndex{1, 2, 3},
+					Attestation2Indices: []phase0.ValidatorIndex{1, 3},
+				},
+			},
+			expected: []*chaindb.ValidatorSlashing{
+				{Index: 1, Epoch: 10, Type: chaindb.SlashingTypeAttester, InclusionSlot: slot(321), InclusionBlockRoot: root(root1), InclusionIndex: index(0)},
+				{Index: 2, Epoch: 10, Type: chaindb.SlashingTypeUnknown},
+			},
+		},
+		{
+			name:    "EarliestSlot",
+			indices: []phase0.ValidatorIndex{1},
+			proposerSlashings: []*chaindb.ProposerSlashing{
+				{InclusionSlot: 323, InclusionBlockRoot: root2, InclusionIndex: 0, Header1ProposerIndex: 1},
+			},
+			attesterSlashings: []*chaindb.AttesterSlashing{
+				{
+					InclusionSlot:       321,
+					InclusionBlockRoot:  root1,
+					InclusionIndex:      0,
+					Attestation1Indices: []phase0.ValidatorIndex{1},
+					Attestation2Indices: []phase0.ValidatorIndex{1},
+				},
+			},
+			expected: []*chaindb.ValidatorSlashing{
+				{Index: 1, Epoch: 10, Type: chaindb.SlashingTypeAttester, InclusionSlot: slot(321), InclusionBlockRoot: root(root1), InclusionIndex: index(0)},
+			},
+		},
+		{
+			name:    "ProposerFirstInBlock",
+			indices: []phase0.ValidatorIndex{1},
+			attesterSlashings: []*chaindb.AttesterSlashing{
+				{
+					InclusionSlot:       321,
+					InclusionBlockRoot:  root1,
+					InclusionIndex:      0,
+					Attestation1Indices: []phase0.ValidatorIndex{1},
+					Attestation2Indices: []phase0.ValidatorIndex{1},
+				},
+			},
+			proposerSlashings: []*chaindb.ProposerSlashing{
+				{InclusionSlot: 321, InclusionBlockRoot: root1, InclusionIndex: 0, Header1ProposerIndex: 1},
+			},
+			expected: []*chaindb.ValidatorSlashing{
+				{Index: 1, Epoch: 10, Type: chaindb.SlashingTypeProposer, InclusionSlot: slot(321), InclusionBlockRoot: root(root1), InclusionIndex: index(0)},
+			},
+		},
+		{
+			name:    "FirstInBlock",
+			indices: []phase0.ValidatorIndex{1},
+			attesterSlashings: []*chaindb.AttesterSlashing{
+				{
+					InclusionSlot:       321,
+					InclusionBlockRoot:  root1,
+					InclusionIndex:      1,
+					Attestation1Indices: []phase0.ValidatorIndex{1},
+					Attestation2Indices: []phase0.ValidatorIndex{1},
+				},
+				{
+					InclusionSlot:       321,
+					InclusionBlockRoot:  root1,
+					InclusionIndex:      0,
+					Attestation1Indices: []phase0.ValidatorIndex{1},
+					Attestation2Indices: []phase0.ValidatorIndex{1},
+				},
+			},
+			expected: []*chaindb.ValidatorSlashing{
+				{Index: 1, Epoch: 10, Type: chaindb.SlashingTypeAttester, InclusionSlot: slot(321), InclusionBlockRoot: root(root1), InclusionIndex: index(0)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slashings := linkSlashings(10, test.indices, test.proposerSlashings, test.attesterSlashings)
+			require.Equal(t, test.expected, slashings)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/watchlist"
+)
+
+// farFutureEpoch is the epoch used by the chain for events that have not yet been scheduled.
+const farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
+
+// updateWatchlist brings the watchlist in the metadata up to date with the current
+// watchlist, backfilling the balances of any validators added to the watchlist.
+// Validators on the watchlist when it is first used are not backfilled.
+func (s *Service) updateWatchlist(ctx context.Context, md *metadata) error {
+	if s.watchlist == nil {
+		md.Watchlist = nil
+		return nil
+	}
+
+	current := s.watchlist.Indices()
+	if md.Watchlist != nil {
+		additions := watchlist.Additions(*md.Watchlist, current)
+		if len(additions) > 0 {
+			if err := s.backfillBalances(ctx, md, additions); err != nil {
+				return err
+			}
+		}
+	}
+	md.Watchlist = &current
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction for watchlist")
+	}
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata for watchlist")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction for watchlist")
+	}
+
+	return nil
+}
+
+// backfillBalances stores the balances of the given validators for the epochs for
+// which balances have already been stored.
+func (s *Service) backfillBalances(ctx context.Context,
+	md *metadata,
+	indices []phase0.ValidatorIndex,
+) error {
+	startEpoch, found, err := s.backfillStartEpoch(ctx, indices)
+	if err != nil {
+		return err
+	}
+	if !found || startEpoch > md.LatestBalancesEpoch {
+		log.Trace().Int("validators", len(indices)).Msg("No balances to backfill for validators added to watchlist")
+		return nil
+	}
+
+	log.Info().Int("validators", len(indices)).Uint64("start_epoch", uint64(startEpoch)).Uint64("end_epoch", uint64(md.LatestBalancesEpoch)).Msg("Backfilling balances for validators added to watchlist")
+	for epoch := startEpoch; epoch <= md.LatestBalancesEpoch; epoch++ {
+		stateID := fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch))
+		validators, err := s.fetchValidatorsByIndex(ctx, stateID, indices)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to obtain validators for epoch %d", epoch))
+		}
+		if len(validators) == 0 {
+			continue
+		}
+		dbValidatorBalances := make([]*chaindb.ValidatorBalance, 0, len(validators))
+		for index, validator := range validators {
+			dbValidatorBalances = append(dbValidatorBalances, &chaindb.ValidatorBalance{
+				Index:            index,
+				Epoch:            epoch,
+				Balance:          validator.Balance,
+				EffectiveBalance: validator.Validator.EffectiveBalance,
+			})
+		}
+
+		dbCtx, cancel, err := s.chainDB.BeginTx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction for backfilled validator balances")
+		}
+		// Balances are set individually, as some may already be present.
+		for _, dbValidatorBalance := range dbValidatorBalances {
+			if err := s.validatorsSetter.SetValidatorBalance(dbCtx, dbValidatorBalance); err != nil {
+				cancel()
+				return errors.Wrap(err, "failed to set backfilled validator balance")
+			}
+		}
+		if err := s.chainDB.CommitTx(dbCtx); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to commit transaction for backfilled validator balances")
+		}
+	}
+	log.Info().Int("validators", len(indices)).Msg("Backfilled balances for validators added to watchlist")
+
+	return nil
+}
+
+// backfillStartEpoch returns the first epoch for which any of the given validators
+// could have a balance, and false if none of them are known.
+func (s *Service) backfillStartEpoch(ctx context.Context,
+	indices []phase0.ValidatorIndex,
+) (
+	phase0.Epoch,
+	bool,
+	error,
+) {
+	validators, err := s.validatorsProvider.ValidatorsByIndex(ctx, indices)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to obtain validators")
+	}
+
+	found := false
+	startEpoch := farFutureEpoch
+	for _, validator := range validators {
+		found = true
+		// The validator is present from at most an epoch before it is eligible for activation.
+		epoch := validator.ActivationEligibilityEpoch
+		if epoch > 0 && epoch != farFutureEpoch {
+			epoch--
+		}
+		if epoch < startEpoch {
+			startEpoch = epoch
+		}
+	}
+	if s.origin != nil && startEpoch < s.origin.Epoch {
+		startEpoch = s.origin.Epoch
+	}
+
+	return startEpoch, found, nil
+}
@@ -0,0 +1,33 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verifier
+
+import (
+	"context"
+	"errors"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// ErrDiscrepancies is returned when verification finds discrepancies.
+var ErrDiscrepancies = errors.New("discrepancies found")
+
+// Service is a service that verifies the database against the chain.
+type Service interface {
+	// Verify verifies the data stored for the given range of epochs against the
+	// chain, writing a report.  If toEpoch is negative, data is verified up to the
+	// latest finalized epoch.
+	// It returns ErrDiscrepancies if any discrepancies are found.
+	Verify(ctx context.Context, fromEpoch phase0.Epoch, toEpoch int64) error
+}
@@ -0,0 +1,135 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/beaconfetcher"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+)
+
+type parameters struct {
+	logLevel          zerolog.Level
+	chainDB           chaindb.Service
+	eth2Client        eth2client.Service
+	beaconFetcher     beaconfetcher.Service
+	chainTime         chaintime.Service
+	storeAttestations bool
+	output            io.Writer
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithETH2Client sets the Ethereum 2 client for this module.
+func WithETH2Client(eth2Client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eth2Client = eth2Client
+	})
+}
+
+// WithBeaconFetcher sets the beacon fetcher for this module.
+func WithBeaconFetcher(beaconFetcher beaconfetcher.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.beaconFetcher = beaconFetcher
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithStoreAttestations sets whether the attestations in blocks are stored.
+// If not, attestations are not verified.
+func WithStoreAttestations(storeAttestations bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.storeAttestations = storeAttestations
+	})
+}
+
+// WithOutput sets the writer to which the verification report is written.
+func WithOutput(output io.Writer) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.output = output
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:          zerolog.GlobalLevel(),
+		storeAttestations: true,
+		output:            os.Stdout,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.eth2Client == nil {
+		return nil, errors.New("no Ethereum 2 client specified")
+	}
+	if _, isProvider := parameters.eth2Client.(eth2client.FinalityProvider); !isProvider {
+		return nil, errors.New("Ethereum 2 client does not provide finality") // skipcq: SCC-ST1005
+	}
+	if _, isProvider := parameters.eth2Client.(eth2client.BeaconBlockHeadersProvider); !isProvider {
+		return nil, errors.New("Ethereum 2 client does not provide beacon block headers") // skipcq: SCC-ST1005
+	}
+	if parameters.beaconFetcher == nil {
+		return nil, errors.New("no beacon fetcher specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.output == nil {
+		return nil, errors.New("no output specified")
+	}
+
+	return &parameters, nil
+}
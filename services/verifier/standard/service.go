@@ -0,0 +1,186 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/beaconfetcher"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	finalizer "github.com/wealdtech/chaind/services/finalizer/standard"
+	"github.com/wealdtech/chaind/services/verifier"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a service that verifies the database against the chain.
+type Service struct {
+	chainDB                chaindb.Service
+	blocksProvider         chaindb.BlocksProvider
+	attestationsProvider   chaindb.AttestationsProvider
+	eth2Client             eth2client.Service
+	beaconFetcher          beaconfetcher.Service
+	chainTime              chaintime.Service
+	storeAttestations      bool
+	attestationStorageMode chaindb.AttestationStorageMode
+	output                 io.Writer
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger(zerologger.With().Str("service", "verifier").Str("impl", "standard").Logger(), "verifier", parameters.logLevel)
+
+	blocksProvider, isProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isProvider {
+		return nil, errors.New("chain database does not provide blocks")
+	}
+	attestationsProvider, isProvider := parameters.chainDB.(chaindb.AttestationsProvider)
+	if !isProvider {
+		return nil, errors.New("chain database does not provide attestations")
+	}
+	// Databases that do not report their attestation storage mode store attestations in full.
+	attestationStorageMode := chaindb.AttestationStorageFull
+	if modeProvider, isProvider := parameters.chainDB.(chaindb.AttestationStorageModeProvider); isProvider {
+		attestationStorageMode = modeProvider.AttestationStorageMode()
+	}
+
+	return &Service{
+		chainDB:                parameters.chainDB,
+		blocksProvider:         blocksProvider,
+		attestationsProvider:   attestationsProvider,
+		eth2Client:             parameters.eth2Client,
+		beaconFetcher:          parameters.beaconFetcher,
+		chainTime:              parameters.chainTime,
+		storeAttestations:      parameters.storeAttestations,
+		attestationStorageMode: attestationStorageMode,
+		output:                 parameters.output,
+	}, nil
+}
+
+// verification is a single run of verification.
+type verification struct {
+	*Service
+	discrepancies int
+	checkedSlots  int
+	checkedBlocks int
+}
+
+// Verify verifies the data stored for the given range of epochs against the
+// chain, writing a report.  If toEpoch is negative, data is verified up to the
+// latest finalized epoch.
+// It returns verifier.ErrDiscrepancies if any discrepancies are found.
+func (s *Service) Verify(ctx context.Context, fromEpoch phase0.Epoch, toEpoch int64) error {
+	v := &verification{Service: s}
+
+	finality, err := v.eth2Client.(eth2client.FinalityProvider).Finality(ctx, "head")
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain finality from beacon node")
+	}
+	dbFinalizedEpoch, err := v.finalizedEpoch(ctx)
+	if err != nil {
+		return err
+	}
+	v.verifyFinality(finality.Finalized.Epoch, dbFinalizedEpoch)
+
+	if toEpoch < 0 {
+		toEpoch = int64(finality.Finalized.Epoch)
+	}
+	if phase0.Epoch(toEpoch) < fromEpoch {
+		return fmt.Errorf("to epoch %d before from epoch %d", toEpoch, fromEpoch)
+	}
+
+	// Canonical status is only decided for epochs that have been finalized both on
+	// the chain and in the database.
+	verifyTo := toEpoch
+	if verifyTo > int64(finality.Finalized.Epoch) {
+		verifyTo = int64(finality.Finalized.Epoch)
+	}
+	if verifyTo > dbFinalizedEpoch {
+		verifyTo = dbFinalizedEpoch
+	}
+	if verifyTo >= int64(fromEpoch) {
+		if err := v.verifySlots(ctx, v.chainTime.FirstSlotOfEpoch(fromEpoch), v.chainTime.FirstSlotOfEpoch(phase0.Epoch(verifyTo+1))-1); err != nil {
+			return err
+		}
+	} else {
+		verifyTo = int64(fromEpoch) - 1
+	}
+	unfinalized := toEpoch - verifyTo
+
+	fmt.Fprintf(v.output, "Verified epochs %d-%d: %d slots, %d blocks, %d discrepancies\n", fromEpoch, toEpoch, v.checkedSlots, v.checkedBlocks, v.discrepancies)
+	if unfinalized > 0 {
+		fmt.Fprintf(v.output, "%d epochs not verified as they have yet to be finalized\n", unfinalized)
+	}
+	if v.discrepancies > 0 {
+		return verifier.ErrDiscrepancies
+	}
+
+	return nil
+}
+
+// finalizedEpoch returns the latest epoch finalized in the database, or -1 if none.
+func (v *verification) finalizedEpoch(ctx context.Context) (int64, error) {
+	md := struct {
+		LastFinalizedEpoch int64 `json:"latest_epoch"`
+	}{
+		LastFinalizedEpoch: -1,
+	}
+	mdJSON, err := v.chainDB.Metadata(ctx, finalizer.MetadataKey)
+	if err != nil {
+		return -1, errors.Wrap(err, "failed to obtain finalizer metadata")
+	}
+	if mdJSON == nil {
+		return -1, nil
+	}
+	if err := json.Unmarshal(mdJSON, &md); err != nil {
+		return -1, errors.Wrap(err, "failed to unmarshal finalizer metadata")
+	}
+
+	return md.LastFinalizedEpoch, nil
+}
+
+// verifyFinality verifies the finality marker in the database against the chain.
+func (v *verification) verifyFinality(chainFinalizedEpoch phase0.Epoch, dbFinalizedEpoch int64) {
+	switch {
+	case dbFinalizedEpoch < 0:
+		fmt.Fprintf(v.output, "finality: database has yet to finalize an epoch; chain finalized epoch %d\n", chainFinalizedEpoch)
+	case phase0.Epoch(dbFinalizedEpoch) > chainFinalizedEpoch:
+		v.report("finality", "database finalized epoch %d is ahead of chain finalized epoch %d", dbFinalizedEpoch, chainFinalizedEpoch)
+	default:
+		fmt.Fprintf(v.output, "finality: database finalized epoch %d, chain finalized epoch %d\n", dbFinalizedEpoch, chainFinalizedEpoch)
+	}
+}
+
+// report reports a discrepancy.
+func (v *verification) report(kind string, format string, args ...any) {
+	v.discrepancies++
+	fmt.Fprintf(v.output, "%s: %s\n", kind, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,366 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	finalizer "github.com/wealdtech/chaind/services/finalizer/standard"
+	"github.com/wealdtech/chaind/services/verifier"
+	"github.com/wealdtech/chaind/services/verifier/standard"
+)
+
+// verifyChainTime is a chain time with 32 slots per epoch.
+type verifyChainTime struct {
+	chaintime.Service
+}
+
+func (*verifyChainTime) FirstSlotOfEpoch(epoch phase0.Epoch) phase0.Slot {
+	return phase0.Slot(epoch) * 32
+}
+
+// verifyChain is a beacon node with a single canonical block.
+type verifyChain struct {
+	eth2client.Service
+	eth2client.BeaconStateProvider
+	finalizedEpoch phase0.Epoch
+	blockSlot      phase0.Slot
+	blockRoot      phase0.Root
+	attestations   []*phase0.Attestation
+	err            error
+}
+
+func (c *verifyChain) Finality(_ context.Context, _ string) (*api.Finality, error) {
+	return &api.Finality{
+		Finalized: &phase0.Checkpoint{Epoch: c.finalizedEpoch},
+	}, nil
+}
+
+func (c *verifyChain) BeaconBlockHeader(_ context.Context, blockID string) (*api.BeaconBlockHeader, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if blockID != fmt.Sprintf("%d", c.blockSlot) {
+		return nil, nil
+	}
+
+	return &api.BeaconBlockHeader{
+		Root:      c.blockRoot,
+		Canonical: true,
+		Header: &phase0.SignedBeaconBlockHeader{
+			Message: &phase0.BeaconBlockHeader{Slot: c.blockSlot},
+		},
+	}, nil
+}
+
+func (c *verifyChain) SignedBeaconBlock(_ context.Context, _ string) (*spec.VersionedSignedBeaconBlock, error) {
+	return &spec.VersionedSignedBeaconBlock{
+		Version: spec.DataVersionPhase0,
+		Phase0: &phase0.SignedBeaconBlock{
+			Message: &phase0.BeaconBlock{
+				Slot: c.blockSlot,
+				Body: &phase0.BeaconBlockBody{
+					Attestations: c.attestations,
+				},
+			},
+		},
+	}, nil
+}
+
+// verifyChainDB is a chain database with a single block.
+type verifyChainDB struct {
+	chaindb.Service
+	chaindb.BlocksProvider
+	chaindb.AttestationsProvider
+	mode           chaindb.AttestationStorageMode
+	finalizedEpoch int64
+	blockSlot      phase0.Slot
+	blockRoot      phase0.Root
+	canonical      bool
+	attestations   []*chaindb.Attestation
+}
+
+func (c *verifyChainDB) AttestationStorageMode() chaindb.AttestationStorageMode {
+	return c.mode
+}
+
+func (c *verifyChainDB) Metadata(_ context.Context, key string) ([]byte, error) {
+	if key != finalizer.MetadataKey {
+		return nil, nil
+	}
+	return []byte(fmt.Sprintf(`{"latest_epoch":%d}`, c.finalizedEpoch)), nil
+}
+
+func (*verifyChainDB) EmptySlots(_ context.Context, _ phase0.Slot, _ phase0.Slot) ([]phase0.Slot, error) {
+	return nil, nil
+}
+
+func (*verifyChainDB) IndeterminateBlocks(_ context.Context, _ phase0.Slot, _ phase0.Slot) ([]phase0.Root, error) {
+	return nil, nil
+}
+
+func (*verifyChainDB) IndeterminateAttestationSlots(_ context.Context, _ phase0.Slot, _ phase0.Slot) ([]phase0.Slot, error) {
+	return nil, nil
+}
+
+func (c *verifyChainDB) CanonicalBlockPresenceForSlotRange(_ context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]bool, error) {
+	res := make([]bool, maxSlot-minSlot)
+	if c.blockSlot >= minSlot && c.blockSlot < maxSlot {
+		res[c.blockSlot-minSlot] = c.canonical
+	}
+	return res, nil
+}
+
+func (c *verifyChainDB) BlocksBySlot(_ context.Context, slot phase0.Slot) ([]*chaindb.Block, error) {
+	if slot != c.blockSlot {
+		return nil, nil
+	}
+	return []*chaindb.Block{{Slot: c.blockSlot, Root: c.blockRoot, Canonical: &c.canonical}}, nil
+}
+
+func (c *verifyChainDB) AttestationsInBlock(_ context.Context, _ phase0.Root) ([]*chaindb.Attestation, error) {
+	if c.mode == chaindb.AttestationStorageAggregate {
+		return nil, errors.New("attestation inclusions are not stored in aggregate mode")
+	}
+	return c.attestations, nil
+}
+
+func (c *verifyChainDB) AttestationsForSlotRange(_ context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.Attestation, error) {
+	res := make([]*chaindb.Attestation, 0)
+	for _, attestation := range c.attestations {
+		if attestation.Slot >= minSlot && attestation.Slot < maxSlot {
+			res = append(res, attestation)
+		}
+	}
+	return res, nil
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(&verifyChain{}),
+				standard.WithBeaconFetcher(&verifyChain{}),
+				standard.WithChainTime(&verifyChainTime{}),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ChainTimeMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(&verifyChainDB{}),
+				standard.WithETH2Client(&verifyChain{}),
+				standard.WithBeaconFetcher(&verifyChain{}),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(&verifyChainDB{}),
+				standard.WithETH2Client(&verifyChain{}),
+				standard.WithBeaconFetcher(&verifyChain{}),
+				standard.WithChainTime(&verifyChainTime{}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+
+	attestationData := &phase0.AttestationData{
+		Slot:            1,
+		Index:           2,
+		BeaconBlockRoot: phase0.Root{0x01},
+		Source:          &phase0.Checkpoint{Epoch: 0, Root: phase0.Root{0x03}},
+		Target:          &phase0.Checkpoint{Epoch: 0, Root: phase0.Root{0x04}},
+	}
+	// Two attestations from different validators in the same committee, included separately.
+	chainAttestations := []*phase0.Attestation{
+		{AggregationBits: []byte{0x11}, Data: attestationData},
+		{AggregationBits: []byte{0x12}, Data: attestationData},
+	}
+	dbAttestation := func(aggregationBits []byte) *chaindb.Attestation {
+		return &chaindb.Attestation{
+			Slot:            attestationData.Slot,
+			CommitteeIndex:  attestationData.Index,
+			AggregationBits: aggregationBits,
+			BeaconBlockRoot: attestationData.BeaconBlockRoot,
+			SourceEpoch:     attestationData.Source.Epoch,
+			SourceRoot:      attestationData.Source.Root,
+			TargetEpoch:     attestationData.Target.Epoch,
+			TargetRoot:      attestationData.Target.Root,
+		}
+	}
+
+	tests := []struct {
+		name              string
+		chain             *verifyChain
+		chainDB           *verifyChainDB
+		storeAttestations bool
+		err               string
+		discrepancies     string
+	}{
+		{
+			name:              "Pass",
+			chain:             &verifyChain{finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}},
+			chainDB:           &verifyChainDB{finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}, canonical: true},
+			storeAttestations: true,
+		},
+		{
+			name:              "BlockNotCanonical",
+			chain:             &verifyChain{finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}},
+			chainDB:           &verifyChainDB{finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}},
+			storeAttestations: true,
+			err:               verifier.ErrDiscrepancies.Error(),
+			discrepancies:     "blocks: slot 1: block 0x0100000000000000000000000000000000000000000000000000000000000000 not marked canonical in database\n",
+		},
+		{
+			name:              "BlockMismatch",
+			chain:             &verifyChain{finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}},
+			chainDB:           &verifyChainDB{finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x02}, canonical: true},
+			storeAttestations: true,
+			err:               verifier.ErrDiscrepancies.Error(),
+			discrepancies:     "blocks: slot 1: database canonical block 0x0200000000000000000000000000000000000000000000000000000000000000 does not match chain block 0x0100000000000000000000000000000000000000000000000000000000000000\n",
+		},
+		{
+			name:              "FinalityAhead",
+			chain:             &verifyChain{finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}},
+			chainDB:           &verifyChainDB{finalizedEpoch: 1, blockSlot: 1, blockRoot: phase0.Root{0x01}, canonical: true},
+			storeAttestations: true,
+			err:               verifier.ErrDiscrepancies.Error(),
+			discrepancies:     "finality: database finalized epoch 1 is ahead of chain finalized epoch 0\n",
+		},
+		{
+			name:              "BeaconNodeError",
+			chain:             &verifyChain{finalizedEpoch: 0, err: errors.New("mock error")},
+			chainDB:           &verifyChainDB{finalizedEpoch: 0},
+			storeAttestations: true,
+			err:               "failed to obtain header for slot 0 from beacon node: mock error",
+		},
+		{
+			name:  "FullAttestationsMatch",
+			chain: &verifyChain{finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}, attestations: chainAttestations},
+			chainDB: &verifyChainDB{
+				mode:           chaindb.AttestationStorageFull,
+				finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}, canonical: true,
+				attestations: []*chaindb.Attestation{dbAttestation([]byte{0x11}), dbAttestation([]byte{0x12})},
+			},
+			storeAttestations: true,
+		},
+		{
+			name:  "FullAttestationsMissing",
+			chain: &verifyChain{finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}, attestations: chainAttestations},
+			chainDB: &verifyChainDB{
+				mode:           chaindb.AttestationStorageFull,
+				finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}, canonical: true,
+				attestations: []*chaindb.Attestation{dbAttestation([]byte{0x11})},
+			},
+			storeAttestations: true,
+			err:               verifier.ErrDiscrepancies.Error(),
+			discrepancies:     "attestations: slot 1: database has 1 attestations for block 0x0100000000000000000000000000000000000000000000000000000000000000, chain has 2\n",
+		},
+		{
+			name:  "AggregateAttestationsMatch",
+			chain: &verifyChain{finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}, attestations: chainAttestations},
+			chainDB: &verifyChainDB{
+				mode:           chaindb.AttestationStorageAggregate,
+				finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}, canonical: true,
+				attestations: []*chaindb.Attestation{dbAttestation([]byte{0x13})},
+			},
+			storeAttestations: true,
+		},
+		{
+			name:  "AggregateAttestationsMissing",
+			chain: &verifyChain{finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}, attestations: chainAttestations},
+			chainDB: &verifyChainDB{
+				mode:           chaindb.AttestationStorageAggregate,
+				finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}, canonical: true,
+				attestations: []*chaindb.Attestation{dbAttestation([]byte{0x11})},
+			},
+			storeAttestations: true,
+			err:               verifier.ErrDiscrepancies.Error(),
+			discrepancies:     "attestations: slot 1: attestation 1 of block 0x0100000000000000000000000000000000000000000000000000000000000000 is not held in any aggregate for slot 1 committee 2\n",
+		},
+		{
+			name:  "AttestationsNotStored",
+			chain: &verifyChain{finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}, attestations: chainAttestations},
+			chainDB: &verifyChainDB{
+				mode:           chaindb.AttestationStorageFull,
+				finalizedEpoch: 0, blockSlot: 1, blockRoot: phase0.Root{0x01}, canonical: true,
+			},
+			storeAttestations: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := new(bytes.Buffer)
+			s, err := standard.New(ctx,
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(test.chainDB),
+				standard.WithETH2Client(test.chain),
+				standard.WithBeaconFetcher(test.chain),
+				standard.WithChainTime(&verifyChainTime{}),
+				standard.WithStoreAttestations(test.storeAttestations),
+				standard.WithOutput(output),
+			)
+			require.NoError(t, err)
+
+			err = s.Verify(ctx, 0, 0)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+			if test.discrepancies != "" {
+				require.Contains(t, output.String(), test.discrepancies)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	bitfield "github.com/prysmaticlabs/go-bitfield"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// verifySlots verifies the blocks and attestations in the given slot range, inclusive.
+func (v *verification) verifySlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error {
+	// Use the gap detection functions of the database to find what is missing or undecided.
+	emptySlots, err := v.blocksProvider.EmptySlots(ctx, minSlot, maxSlot)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain empty slots")
+	}
+	empty := make(map[phase0.Slot]bool, len(emptySlots))
+	for _, slot := range emptySlots {
+		empty[slot] = true
+	}
+	indeterminateBlocks, err := v.blocksProvider.IndeterminateBlocks(ctx, minSlot, maxSlot)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain indeterminate blocks")
+	}
+	for _, root := range indeterminateBlocks {
+		v.report("blocks", "block %#x in finalized range has no canonical status", root)
+	}
+	if v.storeAttestations {
+		indeterminateAttestationSlots, err := v.attestationsProvider.IndeterminateAttestationSlots(ctx, minSlot, maxSlot)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain indeterminate attestation slots")
+		}
+		for _, slot := range indeterminateAttestationSlots {
+			v.report("attestations", "slot %d in finalized range has attestations with no canonical status", slot)
+		}
+	}
+	presence, err := v.blocksProvider.CanonicalBlockPresenceForSlotRange(ctx, minSlot, maxSlot+1)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain canonical block presence")
+	}
+
+	for slot := minSlot; slot <= maxSlot; slot++ {
+		v.checkedSlots++
+		header, err := v.eth2Client.(eth2client.BeaconBlockHeadersProvider).BeaconBlockHeader(ctx, fmt.Sprintf("%d", slot))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to obtain header for slot %d from beacon node", slot))
+		}
+		chainHasBlock := header != nil && header.Canonical && header.Header.Message.Slot == slot
+		dbHasBlock := int(slot-minSlot) < len(presence) && presence[slot-minSlot]
+
+		switch {
+		case chainHasBlock && empty[slot]:
+			v.report("blocks", "slot %d: block %#x missing from database", slot, header.Root)
+		case chainHasBlock && !dbHasBlock:
+			v.report("blocks", "slot %d: block %#x not marked canonical in database", slot, header.Root)
+		case !chainHasBlock && dbHasBlock:
+			v.report("blocks", "slot %d: database has canonical block but chain does not", slot)
+		case chainHasBlock:
+			if err := v.verifyBlock(ctx, slot, header.Root); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifyBlock verifies a canonical block, and its attestations, against the chain.
+func (v *verification) verifyBlock(ctx context.Context, slot phase0.Slot, root phase0.Root) error {
+	v.checkedBlocks++
+	blocks, err := v.blocksProvider.BlocksBySlot(ctx, slot)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to obtain blocks for slot %d", slot))
+	}
+	var dbBlock *chaindb.Block
+	for _, block := range blocks {
+		if block.Canonical != nil && *block.Canonical {
+			dbBlock = block
+			break
+		}
+	}
+	if dbBlock == nil {
+		v.report("blocks", "slot %d: block %#x not marked canonical in database", slot, root)
+		return nil
+	}
+	if dbBlock.Root != root {
+		v.report("blocks", "slot %d: database canonical block %#x does not match chain block %#x", slot, dbBlock.Root, root)
+		return nil
+	}
+
+	if !v.storeAttestations {
+		// Attestations are not stored, so there is nothing more to verify.
+		return nil
+	}
+
+	signedBlock, err := v.beaconFetcher.SignedBeaconBlock(ctx, fmt.Sprintf("%#x", root))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to obtain block %#x from beacon node", root))
+	}
+	if signedBlock == nil {
+		v.report("blocks", "slot %d: block %#x not available from beacon node", slot, root)
+		return nil
+	}
+	chainAttestations, err := signedBlock.Attestations()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to obtain attestations for block %#x", root))
+	}
+
+	if v.attestationStorageMode == chaindb.AttestationStorageAggregate {
+		return v.verifyAggregateAttestations(ctx, slot, root, chainAttestations)
+	}
+
+	dbAttestations, err := v.attestationsProvider.AttestationsInBlock(ctx, root)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to obtain attestations for block %#x from database", root))
+	}
+	if len(dbAttestations) != len(chainAttestations) {
+		v.report("attestations", "slot %d: database has %d attestations for block %#x, chain has %d", slot, len(dbAttestations), root, len(chainAttestations))
+	}
+
+	return nil
+}
+
+// verifyAggregateAttestations verifies that the attestations of a block are held in
+// aggregate.  The block in which an attestation was included is not stored in this
+// mode, so each attestation is checked against the aggregates of its slot instead.
+func (v *verification) verifyAggregateAttestations(ctx context.Context,
+	slot phase0.Slot,
+	root phase0.Root,
+	chainAttestations []*phase0.Attestation,
+) error {
+	aggregates := make(map[phase0.Slot][]*chaindb.Attestation)
+	for i, attestation := range chainAttestations {
+		attestationSlot := attestation.Data.Slot
+		if _, exists := aggregates[attestationSlot]; !exists {
+			dbAttestations, err := v.attestationsProvider.AttestationsForSlotRange(ctx, attestationSlot, attestationSlot+1)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to obtain attestations for slot %d from database", attestationSlot))
+			}
+			aggregates[attestationSlot] = dbAttestations
+		}
+		if !aggregated(attestation, aggregates[attestationSlot]) {
+			v.report("attestations", "slot %d: attestation %d of block %#x is not held in any aggregate for slot %d committee %d", slot, i, root, attestationSlot, attestation.Data.Index)
+		}
+	}
+
+	return nil
+}
+
+// aggregated returns true if the attestation is part of one of the aggregates.
+func aggregated(attestation *phase0.Attestation, aggregates []*chaindb.Attestation) bool {
+	for _, aggregate := range aggregates {
+		if aggregate.CommitteeIndex != attestation.Data.Index ||
+			aggregate.BeaconBlockRoot != attestation.Data.BeaconBlockRoot ||
+			aggregate.SourceEpoch != attestation.Data.Source.Epoch ||
+			aggregate.SourceRoot != attestation.Data.Source.Root ||
+			aggregate.TargetEpoch != attestation.Data.Target.Epoch ||
+			aggregate.TargetRoot != attestation.Data.Target.Root {
+			continue
+		}
+		if bytes.Equal(aggregate.AggregationBits, attestation.AggregationBits) {
+			return true
+		}
+		contains, err := bitfield.Bitlist(aggregate.AggregationBits).Contains(attestation.AggregationBits)
+		if err == nil && contains {
+			return true
+		}
+	}
+
+	return false
+}
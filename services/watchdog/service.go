@@ -0,0 +1,51 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchdog
+
+import (
+	"context"
+	"time"
+)
+
+// ProgressFunc returns the latest marker reached by a dataset, and the marker it
+// is expected to have reached, in the same units.
+// It returns false if the dataset has yet to report its progress.
+type ProgressFunc func(ctx context.Context) (uint64, uint64, bool, error)
+
+// Dataset is a dataset whose progress is watched.
+type Dataset struct {
+	// Name is the name of the dataset.
+	Name string
+	// Progress provides the progress of the dataset.
+	Progress ProgressFunc
+	// Cadence is the expected time between increments of the dataset's marker,
+	// for example a slot for blocks.
+	Cadence time.Duration
+	// Threshold is the distance, in the units of the marker, that the dataset can fall
+	// behind its expected marker before it is considered stale.
+	Threshold uint64
+	// Job is the name of the scheduler job that updates the dataset.  If present, and the
+	// job exists, it is run to recover a stale dataset.
+	Job string
+	// Recover is called to recover a stale dataset if there is no job to run, for example
+	// to schedule the job again or to restart the process that updates the dataset.
+	// It should return promptly, carrying out any lengthy work in the background.
+	Recover func(ctx context.Context) error
+}
+
+// Service is a watchdog service.
+type Service interface {
+	// Register registers a dataset to be watched.
+	Register(ctx context.Context, dataset *Dataset) error
+}
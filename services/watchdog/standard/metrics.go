@@ -0,0 +1,101 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_watchdog"
+
+var (
+	staleness  *prometheus.GaugeVec
+	stale      *prometheus.GaugeVec
+	recoveries *prometheus.CounterVec
+)
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if staleness != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	staleness = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "staleness_seconds",
+		Help:      "Approximate time by which a dataset is behind its expected progress",
+	}, []string{"dataset"})
+	if err := prometheus.Register(staleness); err != nil {
+		return errors.Wrap(err, "failed to register staleness_seconds")
+	}
+
+	stale = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "stale",
+		Help:      "1 if a dataset is stale and recovery has failed, otherwise 0",
+	}, []string{"dataset"})
+	if err := prometheus.Register(stale); err != nil {
+		return errors.Wrap(err, "failed to register stale")
+	}
+
+	recoveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "recoveries_total",
+		Help:      "Number of attempts to recover a stale dataset",
+	}, []string{"dataset", "method"})
+	if err := prometheus.Register(recoveries); err != nil {
+		return errors.Wrap(err, "failed to register recoveries_total")
+	}
+
+	return nil
+}
+
+func monitorStaleness(dataset string, duration time.Duration) {
+	if staleness != nil {
+		staleness.WithLabelValues(dataset).Set(duration.Seconds())
+	}
+}
+
+func monitorStale(dataset string, isStale bool) {
+	if stale != nil {
+		if isStale {
+			stale.WithLabelValues(dataset).Set(1)
+		} else {
+			stale.WithLabelValues(dataset).Set(0)
+		}
+	}
+}
+
+// monitorRecovery is called when recovery of a dataset is attempted, with a method
+// of "job", "recover", "failed" or "none".
+func monitorRecovery(dataset string, method string) {
+	if recoveries != nil {
+		recoveries.WithLabelValues(dataset, method).Inc()
+	}
+}
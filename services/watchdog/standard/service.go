@@ -0,0 +1,223 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/services/watchdog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// jobClass is the scheduler class of the watchdog job.
+const jobClass = "watchdog"
+
+// jobName is the name of the watchdog job.
+const jobName = "watchdog"
+
+// datasetState is the state of a watched dataset.
+type datasetState struct {
+	dataset *watchdog.Dataset
+	// recovering is true if recovery has been attempted since the dataset became stale.
+	recovering bool
+	// recoveryMarker is the marker of the dataset at the most recent recovery attempt.
+	recoveryMarker uint64
+	// escalated is true if recovery has failed since the dataset became stale.
+	escalated bool
+}
+
+// Service is a watchdog service.
+type Service struct {
+	scheduler  scheduler.Service
+	interval   time.Duration
+	datasetsMu sync.Mutex
+	datasets   map[string]*datasetState
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger(zerologger.With().Str("service", "watchdog").Str("impl", "standard").Logger(), "watchdog", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	s := &Service{
+		scheduler: parameters.scheduler,
+		interval:  parameters.interval,
+		datasets:  make(map[string]*datasetState),
+	}
+
+	if err := s.scheduler.SchedulePeriodicJob(ctx,
+		jobClass,
+		jobName,
+		s.nextRuntime,
+		nil,
+		s.checkJob,
+		nil,
+		scheduler.WithPinned(true),
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to schedule watchdog job")
+	}
+
+	return s, nil
+}
+
+// Register registers a dataset to be watched.
+func (s *Service) Register(_ context.Context, dataset *watchdog.Dataset) error {
+	if dataset == nil {
+		return errors.New("no dataset supplied")
+	}
+	if dataset.Name == "" {
+		return errors.New("dataset has no name")
+	}
+	if dataset.Progress == nil {
+		return errors.New("dataset has no progress function")
+	}
+
+	s.datasetsMu.Lock()
+	defer s.datasetsMu.Unlock()
+	if _, exists := s.datasets[dataset.Name]; exists {
+		return errors.New("dataset already registered")
+	}
+	s.datasets[dataset.Name] = &datasetState{
+		dataset: dataset,
+	}
+	log.Trace().Str("dataset", dataset.Name).Uint64("threshold", dataset.Threshold).Str("job", dataset.Job).Msg("Watching dataset")
+
+	return nil
+}
+
+// nextRuntime returns the time at which the watchdog job next runs.
+func (s *Service) nextRuntime(_ context.Context, _ interface{}) (time.Time, error) {
+	return time.Now().Add(s.interval), nil
+}
+
+// checkJob checks all registered datasets.
+func (s *Service) checkJob(ctx context.Context, _ interface{}) error {
+	s.datasetsMu.Lock()
+	states := make([]*datasetState, 0, len(s.datasets))
+	for _, state := range s.datasets {
+		states = append(states, state)
+	}
+	s.datasetsMu.Unlock()
+	sort.Slice(states, func(i int, j int) bool {
+		return states[i].dataset.Name < states[j].dataset.Name
+	})
+
+	for _, state := range states {
+		s.checkDataset(ctx, state)
+	}
+
+	return nil
+}
+
+// checkDataset checks the progress of a dataset, attempting recovery if it is stale
+// and escalating if recovery fails.
+// States are only accessed by the watchdog job, so do not require locking.
+func (s *Service) checkDataset(ctx context.Context, state *datasetState) {
+	dataset := state.dataset
+	log := log.With().Str("dataset", dataset.Name).Logger()
+
+	latest, expected, known, err := dataset.Progress(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain progress")
+		return
+	}
+	if !known {
+		log.Trace().Msg("Progress not yet known")
+		return
+	}
+
+	lag := uint64(0)
+	if expected > latest {
+		lag = expected - latest
+	}
+	monitorStaleness(dataset.Name, time.Duration(lag)*dataset.Cadence)
+
+	if lag <= dataset.Threshold {
+		if state.recovering {
+			log.Info().Uint64("latest", latest).Msg("Dataset recovered")
+		}
+		state.recovering = false
+		state.escalated = false
+		monitorStale(dataset.Name, false)
+		return
+	}
+
+	if state.recovering && latest > state.recoveryMarker {
+		// The dataset has progressed since the last recovery attempt, so allow it to continue.
+		log.Debug().Uint64("latest", latest).Uint64("expected", expected).Msg("Stale dataset progressing")
+		state.recoveryMarker = latest
+		return
+	}
+
+	if state.recovering && !state.escalated {
+		log.Error().Uint64("latest", latest).Uint64("expected", expected).Uint64("threshold", dataset.Threshold).Msg("Dataset stale and recovery failed")
+		state.escalated = true
+		monitorStale(dataset.Name, true)
+	}
+
+	s.recover(ctx, state, latest, expected)
+}
+
+// recover attempts to recover a stale dataset.
+func (s *Service) recover(ctx context.Context, state *datasetState, latest uint64, expected uint64) {
+	dataset := state.dataset
+	log := log.With().Str("dataset", dataset.Name).Uint64("latest", latest).Uint64("expected", expected).Logger()
+
+	state.recovering = true
+	state.recoveryMarker = latest
+
+	if dataset.Job != "" && s.scheduler.JobExists(ctx, dataset.Job) {
+		log.Warn().Str("job", dataset.Job).Msg("Dataset stale; running job")
+		if err := s.scheduler.RunJob(ctx, dataset.Job); err != nil {
+			log.Warn().Str("job", dataset.Job).Err(err).Msg("Failed to run job")
+			monitorRecovery(dataset.Name, "failed")
+			return
+		}
+		monitorRecovery(dataset.Name, "job")
+		return
+	}
+
+	if dataset.Recover != nil {
+		log.Warn().Msg("Dataset stale; attempting recovery")
+		if err := dataset.Recover(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to recover")
+			monitorRecovery(dataset.Name, "failed")
+			return
+		}
+		monitorRecovery(dataset.Name, "recover")
+		return
+	}
+
+	log.Warn().Msg("Dataset stale with no means of recovery")
+	monitorRecovery(dataset.Name, "none")
+}
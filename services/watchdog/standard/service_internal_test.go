@@ -0,0 +1,149 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+	"github.com/wealdtech/chaind/services/watchdog"
+	"go.uber.org/atomic"
+)
+
+func TestRegister(t *testing.T) {
+	ctx := context.Background()
+
+	scheduler, err := standardscheduler.New(ctx)
+	require.NoError(t, err)
+	s, err := New(ctx, WithScheduler(scheduler))
+	require.NoError(t, err)
+
+	progress := func(_ context.Context) (uint64, uint64, bool, error) {
+		return 0, 0, true, nil
+	}
+
+	require.EqualError(t, s.Register(ctx, nil), "no dataset supplied")
+	require.EqualError(t, s.Register(ctx, &watchdog.Dataset{Progress: progress}), "dataset has no name")
+	require.EqualError(t, s.Register(ctx, &watchdog.Dataset{Name: "test"}), "dataset has no progress function")
+	require.NoError(t, s.Register(ctx, &watchdog.Dataset{Name: "test", Progress: progress}))
+	require.EqualError(t, s.Register(ctx, &watchdog.Dataset{Name: "test", Progress: progress}), "dataset already registered")
+}
+
+func TestCheckDataset(t *testing.T) {
+	ctx := context.Background()
+
+	scheduler, err := standardscheduler.New(ctx)
+	require.NoError(t, err)
+	s, err := New(ctx, WithScheduler(scheduler))
+	require.NoError(t, err)
+
+	// A job that only runs when kicked by the watchdog.
+	jobRuns := atomic.NewUint64(0)
+	require.NoError(t, scheduler.SchedulePeriodicJob(ctx,
+		"test",
+		"test job",
+		func(_ context.Context, _ interface{}) (time.Time, error) { return time.Now().Add(time.Hour), nil },
+		nil,
+		func(_ context.Context, _ interface{}) error { jobRuns.Inc(); return nil },
+		nil,
+	))
+
+	latest := atomic.NewUint64(100)
+	expected := atomic.NewUint64(100)
+	recoveries := atomic.NewUint64(0)
+	state := &datasetState{
+		dataset: &watchdog.Dataset{
+			Name: "test",
+			Progress: func(_ context.Context) (uint64, uint64, bool, error) {
+				return latest.Load(), expected.Load(), true, nil
+			},
+			Cadence:   time.Second,
+			Threshold: 5,
+			Job:       "test job",
+			Recover: func(_ context.Context) error {
+				recoveries.Inc()
+				return nil
+			},
+		},
+	}
+
+	// Within threshold.
+	expected.Store(105)
+	s.checkDataset(ctx, state)
+	require.False(t, state.recovering)
+	require.Equal(t, uint64(0), jobRuns.Load())
+
+	// Stale; job is run.
+	expected.Store(110)
+	s.checkDataset(ctx, state)
+	require.True(t, state.recovering)
+	require.False(t, state.escalated)
+	require.Eventually(t, func() bool { return jobRuns.Load() == 1 }, time.Second, 10*time.Millisecond)
+
+	// Stale but progressing; nothing further.
+	latest.Store(102)
+	s.checkDataset(ctx, state)
+	require.True(t, state.recovering)
+	require.False(t, state.escalated)
+	require.Equal(t, uint64(102), state.recoveryMarker)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, uint64(1), jobRuns.Load())
+
+	// Stale and not progressing; escalated and job run again.
+	s.checkDataset(ctx, state)
+	require.True(t, state.escalated)
+	require.Eventually(t, func() bool { return jobRuns.Load() == 2 }, time.Second, 10*time.Millisecond)
+
+	// Job no longer exists; recover function is called instead.
+	require.NoError(t, scheduler.CancelJob(ctx, "test job"))
+	s.checkDataset(ctx, state)
+	require.True(t, state.escalated)
+	require.Equal(t, uint64(1), recoveries.Load())
+
+	// Caught up.
+	latest.Store(110)
+	s.checkDataset(ctx, state)
+	require.False(t, state.recovering)
+	require.False(t, state.escalated)
+}
+
+func TestCheckDatasetUnknown(t *testing.T) {
+	ctx := context.Background()
+
+	scheduler, err := standardscheduler.New(ctx)
+	require.NoError(t, err)
+	s, err := New(ctx, WithScheduler(scheduler))
+	require.NoError(t, err)
+
+	recoveries := atomic.NewUint64(0)
+	state := &datasetState{
+		dataset: &watchdog.Dataset{
+			Name: "test",
+			Progress: func(_ context.Context) (uint64, uint64, bool, error) {
+				return 0, 1000, false, nil
+			},
+			Recover: func(_ context.Context) error {
+				recoveries.Inc()
+				return nil
+			},
+		},
+	}
+
+	s.checkDataset(ctx, state)
+	require.False(t, state.recovering)
+	require.Equal(t, uint64(0), recoveries.Load())
+}
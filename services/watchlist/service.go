@@ -0,0 +1,48 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchlist
+
+import (
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Service is a watchlist service, providing the set of validators for which
+// per-validator data is stored.
+type Service interface {
+	// Watched returns true if the validator with the given index is on the watchlist.
+	Watched(index phase0.ValidatorIndex) bool
+
+	// Indices returns the indices of the validators on the watchlist, in increasing order.
+	// Validators configured by public key or withdrawal address are only present once
+	// they are known to the chain.
+	Indices() []phase0.ValidatorIndex
+}
+
+// Additions returns the indices present in current but not in previous.
+// Both must be in increasing order.
+func Additions(previous []phase0.ValidatorIndex, current []phase0.ValidatorIndex) []phase0.ValidatorIndex {
+	additions := make([]phase0.ValidatorIndex, 0)
+	i := 0
+	for _, index := range current {
+		for i < len(previous) && previous[i] < index {
+			i++
+		}
+		if i < len(previous) && previous[i] == index {
+			continue
+		}
+		additions = append(additions, index)
+	}
+
+	return additions
+}
@@ -0,0 +1,99 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// entries are the entries in a watchlist.
+type entries struct {
+	indices   map[phase0.ValidatorIndex]struct{}
+	pubKeys   map[phase0.BLSPubKey]struct{}
+	addresses map[bellatrix.ExecutionAddress]struct{}
+}
+
+// parseEntries parses a watchlist.  Each line holds a single entry, which is one of
+// a validator index, a 0x-prefixed validator public key, or a 0x-prefixed withdrawal
+// address.  Blank lines, and anything following a #, are ignored.
+func parseEntries(r io.Reader) (*entries, error) {
+	res := &entries{
+		indices:   make(map[phase0.ValidatorIndex]struct{}),
+		pubKeys:   make(map[phase0.BLSPubKey]struct{}),
+		addresses: make(map[bellatrix.ExecutionAddress]struct{}),
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry := scanner.Text()
+		if pos := strings.Index(entry, "#"); pos != -1 {
+			entry = entry[:pos]
+		}
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(entry, "0x") {
+			index, err := strconv.ParseUint(entry, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid validator index %q on line %d", entry, line)
+			}
+			res.indices[phase0.ValidatorIndex(index)] = struct{}{}
+			continue
+		}
+
+		data, err := hex.DecodeString(entry[2:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex %q on line %d", entry, line)
+		}
+		switch len(data) {
+		case phase0.PublicKeyLength:
+			var pubKey phase0.BLSPubKey
+			copy(pubKey[:], data)
+			res.pubKeys[pubKey] = struct{}{}
+		case bellatrix.ExecutionAddressLength:
+			var address bellatrix.ExecutionAddress
+			copy(address[:], data)
+			res.addresses[address] = struct{}{}
+		default:
+			return nil, fmt.Errorf("entry %q on line %d is neither a public key nor a withdrawal address", entry, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// withdrawalAddress returns the execution address in the withdrawal credentials,
+// and true, if the credentials are execution withdrawal credentials.
+func withdrawalAddress(credentials [32]byte) (bellatrix.ExecutionAddress, bool) {
+	var address bellatrix.ExecutionAddress
+	if credentials[0] != 0x01 {
+		return address, false
+	}
+	copy(address[:], credentials[12:])
+
+	return address, true
+}
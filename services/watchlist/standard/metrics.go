@@ -0,0 +1,60 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_watchlist"
+
+var validatorsWatched prometheus.Gauge
+
+func registerMetrics(_ context.Context, monitor metrics.Service) error {
+	if validatorsWatched != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics()
+	}
+	return nil
+}
+
+func registerPrometheusMetrics() error {
+	validatorsWatched = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "validators",
+		Help:      "Number of validators on the watchlist",
+	})
+	if err := prometheus.Register(validatorsWatched); err != nil {
+		return errors.Wrap(err, "failed to register validators")
+	}
+
+	return nil
+}
+
+func monitorValidators(validators int) {
+	if validatorsWatched != nil {
+		validatorsWatched.Set(float64(validators))
+	}
+}
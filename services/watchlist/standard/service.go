@@ -0,0 +1,208 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
+)
+
+// jobClass is the scheduler class of watchlist jobs.
+const jobClass = "watchlist"
+
+// Service is a watchlist service, with the watchlist held in a file.
+type Service struct {
+	validatorsProvider chaindb.ValidatorsProvider
+	scheduler          scheduler.Service
+	file               string
+	reloadInterval     time.Duration
+	// fileModTime is the modification time of the file when its entries were read.
+	fileModTime time.Time
+	entries     *entries
+	// mu protects indices and watched.
+	mu      sync.RWMutex
+	indices []phase0.ValidatorIndex
+	watched map[phase0.ValidatorIndex]struct{}
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger(zerologger.With().Str("service", "watchlist").Str("impl", "standard").Logger(), "watchlist", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validators")
+	}
+
+	s := &Service{
+		validatorsProvider: validatorsProvider,
+		scheduler:          parameters.scheduler,
+		file:               parameters.file,
+		reloadInterval:     parameters.reloadInterval,
+		watched:            make(map[phase0.ValidatorIndex]struct{}),
+	}
+
+	// Load the watchlist up front, so that an invalid watchlist fails at startup.
+	if err := s.reload(ctx, nil); err != nil {
+		return nil, errors.Wrap(err, "failed to load watchlist")
+	}
+
+	if err := s.scheduler.SchedulePeriodicJob(ctx,
+		jobClass,
+		"watchlist-reload",
+		s.nextReload,
+		nil,
+		s.reload,
+		nil,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to schedule watchlist reload")
+	}
+
+	return s, nil
+}
+
+// Watched returns true if the validator with the given index is on the watchlist.
+func (s *Service) Watched(index phase0.ValidatorIndex) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, exists := s.watched[index]
+
+	return exists
+}
+
+// Indices returns the indices of the validators on the watchlist, in increasing order.
+func (s *Service) Indices() []phase0.ValidatorIndex {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	indices := make([]phase0.ValidatorIndex, len(s.indices))
+	copy(indices, s.indices)
+
+	return indices
+}
+
+// nextReload returns the time at which the watchlist is next reloaded.
+func (s *Service) nextReload(_ context.Context, _ interface{}) (time.Time, error) {
+	return time.Now().Add(s.reloadInterval), nil
+}
+
+// reload reads the watchlist file if it has changed, and resolves its entries to
+// validator indices.  Entries are resolved on every reload, so that validators
+// configured by public key or withdrawal address are picked up as they appear.
+func (s *Service) reload(ctx context.Context, _ interface{}) error {
+	info, err := os.Stat(s.file)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain watchlist file information")
+	}
+	if s.entries == nil || !info.ModTime().Equal(s.fileModTime) {
+		f, err := os.Open(s.file)
+		if err != nil {
+			return errors.Wrap(err, "failed to open watchlist file")
+		}
+		entries, err := parseEntries(f)
+		f.Close()
+		if err != nil {
+			// Retain the existing entries, if any.
+			return errors.Wrap(err, "failed to parse watchlist file")
+		}
+		s.entries = entries
+		s.fileModTime = info.ModTime()
+		log.Info().Int("indices", len(entries.indices)).Int("public_keys", len(entries.pubKeys)).Int("withdrawal_addresses", len(entries.addresses)).Msg("Loaded watchlist")
+	}
+
+	watched, err := s.resolve(ctx, s.entries)
+	if err != nil {
+		return err
+	}
+	indices := make([]phase0.ValidatorIndex, 0, len(watched))
+	for index := range watched {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+	s.mu.Lock()
+	changed := len(indices) != len(s.indices)
+	s.indices = indices
+	s.watched = watched
+	s.mu.Unlock()
+	if changed {
+		log.Debug().Int("validators", len(indices)).Msg("Watchlist updated")
+	}
+	monitorValidators(len(indices))
+
+	return nil
+}
+
+// resolve resolves the entries of the watchlist to validator indices.
+func (s *Service) resolve(ctx context.Context, entries *entries) (map[phase0.ValidatorIndex]struct{}, error) {
+	watched := make(map[phase0.ValidatorIndex]struct{}, len(entries.indices))
+	for index := range entries.indices {
+		watched[index] = struct{}{}
+	}
+
+	if len(entries.pubKeys) > 0 {
+		pubKeys := make([]phase0.BLSPubKey, 0, len(entries.pubKeys))
+		for pubKey := range entries.pubKeys {
+			pubKeys = append(pubKeys, pubKey)
+		}
+		validators, err := s.validatorsProvider.ValidatorsByPublicKey(ctx, pubKeys)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators by public key")
+		}
+		for _, validator := range validators {
+			watched[validator.Index] = struct{}{}
+		}
+	}
+
+	if len(entries.addresses) > 0 {
+		validators, err := s.validatorsProvider.Validators(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators")
+		}
+		for _, validator := range validators {
+			address, isAddress := withdrawalAddress(validator.WithdrawalCredentials)
+			if !isAddress {
+				continue
+			}
+			if _, exists := entries.addresses[address]; exists {
+				watched[validator.Index] = struct{}{}
+			}
+		}
+	}
+
+	return watched, nil
+}
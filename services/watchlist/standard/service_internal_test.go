@@ -0,0 +1,167 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+)
+
+// validatorsChainDB is a chain database holding validators in memory.
+type validatorsChainDB struct {
+	chaindb.Service
+	chaindb.ValidatorsProvider
+	validators []*chaindb.Validator
+}
+
+func (v *validatorsChainDB) Validators(_ context.Context) ([]*chaindb.Validator, error) {
+	return v.validators, nil
+}
+
+func (v *validatorsChainDB) ValidatorsByPublicKey(_ context.Context,
+	pubKeys []phase0.BLSPubKey,
+) (
+	map[phase0.BLSPubKey]*chaindb.Validator,
+	error,
+) {
+	res := make(map[phase0.BLSPubKey]*chaindb.Validator)
+	for _, pubKey := range pubKeys {
+		for _, validator := range v.validators {
+			if validator.PublicKey == pubKey {
+				res[pubKey] = validator
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func TestParseEntries(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		indices   int
+		pubKeys   int
+		addresses int
+		err       string
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name: "Mixed",
+			input: `# Our validators.
+1
+2 # Second validator.
+
+0x` + strings.Repeat("ab", 48) + `
+0x` + strings.Repeat("cd", 20) + `
+`,
+			indices:   2,
+			pubKeys:   1,
+			addresses: 1,
+		},
+		{
+			name:  "InvalidIndex",
+			input: "1\n-2\n",
+			err:   `invalid validator index "-2" on line 2`,
+		},
+		{
+			name:  "InvalidHex",
+			input: "0xzz\n",
+			err:   `invalid hex "0xzz" on line 1`,
+		},
+		{
+			name:  "WrongLength",
+			input: "0x0102\n",
+			err:   `entry "0x0102" on line 1 is neither a public key nor a withdrawal address`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := parseEntries(strings.NewReader(test.input))
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, entries.indices, test.indices)
+			require.Len(t, entries.pubKeys, test.pubKeys)
+			require.Len(t, entries.addresses, test.addresses)
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pubKey := phase0.BLSPubKey{0x01}
+	address := [20]byte{0x02}
+	credentials := [32]byte{0x01}
+	copy(credentials[12:], address[:])
+	chainDB := &validatorsChainDB{
+		Service: mockchaindb.New(),
+		validators: []*chaindb.Validator{
+			{Index: 10, PublicKey: pubKey},
+			{Index: 11, WithdrawalCredentials: credentials},
+		},
+	}
+	scheduler, err := standardscheduler.New(ctx, standardscheduler.WithLogLevel(zerolog.Disabled))
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "watchlist")
+	require.NoError(t, os.WriteFile(file, []byte("5\n0x01"+strings.Repeat("00", 47)+"\n0x"+strings.Repeat("00", 20)+"\n"), 0o600))
+
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(chainDB),
+		WithScheduler(scheduler),
+		WithFile(file),
+		WithReloadInterval(time.Hour),
+	)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.ValidatorIndex{5, 10}, s.Indices())
+	require.True(t, s.Watched(10))
+	require.False(t, s.Watched(11))
+
+	// Updating the file and reloading adds validators, including those resolved by withdrawal address.
+	require.NoError(t, os.WriteFile(file, []byte("5\n0x02"+strings.Repeat("00", 19)+"\n"), 0o600))
+	require.NoError(t, os.Chtimes(file, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	require.NoError(t, s.reload(ctx, nil))
+	require.Equal(t, []phase0.ValidatorIndex{5, 11}, s.Indices())
+
+	// Validators appearing on the chain are resolved on reload.
+	chainDB.validators = append(chainDB.validators, &chaindb.Validator{Index: 12, WithdrawalCredentials: credentials})
+	require.NoError(t, s.reload(ctx, nil))
+	require.Equal(t, []phase0.ValidatorIndex{5, 11, 12}, s.Indices())
+
+	// An invalid file leaves the existing watchlist in place.
+	require.NoError(t, os.WriteFile(file, []byte("bad\n"), 0o600))
+	require.NoError(t, os.Chtimes(file, time.Now().Add(2*time.Minute), time.Now().Add(2*time.Minute)))
+	require.Error(t, s.reload(ctx, nil))
+	require.Equal(t, []phase0.ValidatorIndex{5, 11, 12}, s.Indices())
+}
@@ -0,0 +1,70 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+// shutdownServices are the services that are stopped in order when chaind shuts down.
+type shutdownServices struct {
+	scheduler scheduler.Service
+	chainDB   chaindb.Service
+}
+
+// shutdown stops chaind's services.  The root context is cancelled to stop the
+// services that fetch data, and chaind is marked as not ready.  The scheduler is
+// then stopped, giving running jobs the grace period to finish.  The database is
+// closed last, so that draining jobs can continue to use it.
+func shutdown(ctx context.Context,
+	cancel context.CancelFunc,
+	services *shutdownServices,
+	grace time.Duration,
+) *scheduler.StopSummary {
+	started := time.Now()
+
+	cancel()
+	setReady(ctx, false)
+
+	summary := &scheduler.StopSummary{}
+	if stopper, isStopper := services.scheduler.(scheduler.Stopper); isStopper {
+		log.Trace().Dur("grace_period", grace).Msg("Stopping scheduler")
+		// The root context is done, so the scheduler is given its own.
+		//nolint:contextcheck
+		summary = stopper.Stop(context.Background(), grace)
+	}
+
+	if closer, isCloser := services.chainDB.(chaindb.Closer); isCloser {
+		if summary.Abandoned == 0 {
+			log.Trace().Msg("Closing database")
+			closer.Close()
+		} else {
+			// Closing the database waits for connections in use, which abandoned jobs may hold.
+			log.Warn().Int("abandoned_jobs", summary.Abandoned).Msg("Jobs still running; not waiting to close database")
+		}
+	}
+
+	log.Info().
+		Int("cancelled_jobs", summary.Cancelled).
+		Int("drained_jobs", summary.Drained).
+		Int("abandoned_jobs", summary.Abandoned).
+		Dur("duration", time.Since(started)).
+		Msg("Shutdown complete")
+
+	return summary
+}
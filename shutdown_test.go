@@ -0,0 +1,133 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	nullmetrics "github.com/wealdtech/chaind/services/metrics/null"
+	"github.com/wealdtech/chaind/services/scheduler"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+)
+
+// closingChainDB is a chain database that records when it is closed.
+type closingChainDB struct {
+	closed atomic.Bool
+	// jobDone, if set when the database is closed, records if the job had finished.
+	jobDone         *atomic.Bool
+	closedAfterDone atomic.Bool
+}
+
+func (*closingChainDB) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {}, nil
+}
+
+func (*closingChainDB) CommitTx(_ context.Context) error {
+	return nil
+}
+
+func (*closingChainDB) BeginROTx(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (*closingChainDB) CommitROTx(_ context.Context) {}
+
+func (*closingChainDB) SetMetadata(_ context.Context, _ string, _ []byte) error {
+	return nil
+}
+
+func (*closingChainDB) Metadata(_ context.Context, _ string) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *closingChainDB) Close() {
+	c.closedAfterDone.Store(c.jobDone.Load())
+	c.closed.Store(true)
+}
+
+func TestShutdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		jobTime   time.Duration
+		grace     time.Duration
+		drained   int
+		abandoned int
+		closed    bool
+	}{
+		{
+			name:    "Drained",
+			jobTime: 200 * time.Millisecond,
+			grace:   time.Second,
+			drained: 1,
+			closed:  true,
+		},
+		{
+			name:      "Abandoned",
+			jobTime:   time.Second,
+			grace:     50 * time.Millisecond,
+			abandoned: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			schedulerSvc, err := standardscheduler.New(ctx,
+				standardscheduler.WithLogLevel(zerolog.Disabled),
+				standardscheduler.WithMonitor(&nullmetrics.Service{}),
+			)
+			require.NoError(t, err)
+
+			// The slow job ignores its context, as a database transaction being committed would.
+			var jobDone atomic.Bool
+			require.NoError(t, schedulerSvc.ScheduleJob(ctx, "Test", "Slow job", time.Now(), func(_ context.Context, _ interface{}) error {
+				time.Sleep(test.jobTime)
+				jobDone.Store(true)
+				return nil
+			}, nil))
+			// The fetch loop runs until the root context is done.
+			var loopDone atomic.Bool
+			go func() {
+				<-ctx.Done()
+				loopDone.Store(true)
+			}()
+			time.Sleep(20 * time.Millisecond)
+
+			chainDB := &closingChainDB{jobDone: &jobDone}
+			summary := shutdown(ctx, cancel, &shutdownServices{
+				scheduler: schedulerSvc,
+				chainDB:   chainDB,
+			}, test.grace)
+
+			require.Equal(t, test.drained, summary.Drained)
+			require.Equal(t, test.abandoned, summary.Abandoned)
+			require.Equal(t, test.drained == 1, jobDone.Load())
+			require.True(t, loopDone.Load())
+			require.Equal(t, test.closed, chainDB.closed.Load())
+			if test.closed {
+				require.True(t, chainDB.closedAfterDone.Load())
+			}
+			require.ErrorIs(t, schedulerSvc.ScheduleJob(context.Background(), "Test", "New job", time.Now(), func(_ context.Context, _ interface{}) error {
+				return nil
+			}, nil), scheduler.ErrSchedulerStopped)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// singleServices are the services that can be run alone with single-service.
+// Each is enabled by its <service>.enable key, and needs nothing beyond the
+// database, scheduler and metrics that are always started.
+var singleServices = []string{
+	"beacon-committees",
+	"blocks",
+	"chainstats",
+	"consistency",
+	"eth1deposits",
+	"proposer-duties",
+	"retention",
+	"sync-committees",
+	"validators",
+}
+
+// singleServiceDependencies are the other services on which a service relies, which
+// are also run in single-service mode.
+var singleServiceDependencies = map[string][]string{
+	// Blocks obtains sync committees from the database to decode sync aggregates.
+	"blocks": {"sync-committees"},
+}
+
+// singleServiceRefusals are the services that cannot run alone, with the reason.
+var singleServiceRefusals = map[string]string{
+	"backfill":      "it stores blocks through the blocks service",
+	"finalizer":     "it marks blocks through the blocks service",
+	"notifications": "it is driven by the finalizer",
+	"summarizer":    "it is driven by the finalizer, which requires the blocks service",
+}
+
+// singleServiceAbsentFeatures are the enable keys of services and features, other than
+// those in singleServices, that are disabled in single-service mode.
+var singleServiceAbsentFeatures = []string{
+	"backfill.enable",
+	"chaindb.maintenance.enable",
+	"finalizer.enable",
+	"freshness.enable",
+	"notifications.enable",
+	"summarizer.enable",
+	"watchdog.enable",
+}
+
+// singleServiceAbsentMigrations are the batch size keys of background migrations,
+// which are disabled in single-service mode.
+var singleServiceAbsentMigrations = []string{
+	"chaindb.attestation-votes-migration.slots-per-batch",
+	"chaindb.compression-migration.slots-per-batch",
+}
+
+// applySingleService configures v to run only the service named by single-service and
+// the services on which it relies, disabling all others.  It returns the services on
+// which it relies, and the services and features that were enabled but are now
+// intentionally absent.  It returns an error if the service cannot run alone.
+func applySingleService(v *viper.Viper) ([]string, []string, error) {
+	service := v.GetString("single-service")
+	if service == "" {
+		return nil, nil, nil
+	}
+	if reason, refused := singleServiceRefusals[service]; refused {
+		return nil, nil, fmt.Errorf("service %s cannot run alone as %s", service, reason)
+	}
+	known := false
+	for _, singleService := range singleServices {
+		if singleService == service {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, nil, fmt.Errorf("unknown service %s; services that can run alone are %s", service, strings.Join(singleServices, ", "))
+	}
+	if v.GetBool("read-only") {
+		return nil, nil, errors.New("single-service cannot be used in read-only mode")
+	}
+
+	dependencies := singleServiceDependencies[service]
+	running := map[string]bool{service: true}
+	for _, dependency := range dependencies {
+		running[dependency] = true
+	}
+
+	enableKeys := make([]string, 0, len(singleServices)+len(singleServiceAbsentFeatures))
+	for _, singleService := range singleServices {
+		if !running[singleService] {
+			enableKeys = append(enableKeys, fmt.Sprintf("%s.enable", singleService))
+		}
+	}
+	enableKeys = append(enableKeys, singleServiceAbsentFeatures...)
+
+	absent := make([]string, 0)
+	for _, key := range enableKeys {
+		if v.GetBool(key) {
+			absent = append(absent, strings.TrimSuffix(key, ".enable"))
+		}
+		v.Set(key, false)
+	}
+	for _, key := range singleServiceAbsentMigrations {
+		if v.GetUint64(key) > 0 {
+			absent = append(absent, strings.TrimSuffix(key, ".slots-per-batch"))
+		}
+		v.Set(key, 0)
+	}
+	for singleService := range running {
+		v.Set(fmt.Sprintf("%s.enable", singleService), true)
+	}
+	sort.Strings(absent)
+
+	return dependencies, absent, nil
+}
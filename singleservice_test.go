@@ -0,0 +1,109 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySingleService(t *testing.T) {
+	tests := []struct {
+		name         string
+		settings     map[string]any
+		dependencies []string
+		absent       []string
+		enabled      []string
+		disabled     []string
+		err          string
+	}{
+		{
+			name:     "None",
+			settings: map[string]any{"blocks.enable": true},
+			enabled:  []string{"blocks"},
+		},
+		{
+			name: "ETH1Deposits",
+			settings: map[string]any{
+				"single-service":    "eth1deposits",
+				"blocks.enable":     true,
+				"finalizer.enable":  true,
+				"validators.enable": true,
+				"chaindb.compression-migration.slots-per-batch": 100,
+			},
+			absent:   []string{"blocks", "chaindb.compression-migration", "finalizer", "validators"},
+			enabled:  []string{"eth1deposits"},
+			disabled: []string{"blocks", "finalizer", "summarizer", "sync-committees", "validators"},
+		},
+		{
+			name: "Blocks",
+			settings: map[string]any{
+				"single-service":         "blocks",
+				"summarizer.enable":      true,
+				"sync-committees.enable": false,
+			},
+			dependencies: []string{"sync-committees"},
+			absent:       []string{"summarizer"},
+			enabled:      []string{"blocks", "sync-committees"},
+			disabled:     []string{"eth1deposits", "finalizer", "summarizer", "validators"},
+		},
+		{
+			name:     "Refused",
+			settings: map[string]any{"single-service": "summarizer"},
+			err:      "service summarizer cannot run alone as it is driven by the finalizer, which requires the blocks service",
+		},
+		{
+			name:     "Unknown",
+			settings: map[string]any{"single-service": "unknown"},
+			err:      "unknown service unknown; services that can run alone are beacon-committees, blocks, chainstats, consistency, eth1deposits, proposer-duties, retention, sync-committees, validators",
+		},
+		{
+			name: "ReadOnly",
+			settings: map[string]any{
+				"single-service": "validators",
+				"read-only":      true,
+			},
+			err: "single-service cannot be used in read-only mode",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := viper.New()
+			for key, value := range test.settings {
+				v.Set(key, value)
+			}
+			dependencies, absent, err := applySingleService(v)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.dependencies, dependencies)
+			if test.absent == nil {
+				require.Empty(t, absent)
+			} else {
+				require.Equal(t, test.absent, absent)
+			}
+			for _, service := range test.enabled {
+				require.True(t, v.GetBool(service+".enable"), service)
+			}
+			for _, service := range test.disabled {
+				require.False(t, v.GetBool(service+".enable"), service)
+			}
+		})
+	}
+}
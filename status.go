@@ -0,0 +1,58 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// statusFunc provides the status of a service, suitable for encoding as JSON.
+type statusFunc func(ctx context.Context) any
+
+var (
+	statusFuncsMu sync.Mutex
+	statusFuncs   = make(map[string]statusFunc)
+)
+
+// registerStatus registers a service to be included in the status.
+func registerStatus(name string, status statusFunc) {
+	statusFuncsMu.Lock()
+	statusFuncs[name] = status
+	statusFuncsMu.Unlock()
+}
+
+// status returns the status of all registered services.
+func status(ctx context.Context) map[string]any {
+	statusFuncsMu.Lock()
+	defer statusFuncsMu.Unlock()
+
+	res := make(map[string]any, len(statusFuncs))
+	for name, status := range statusFuncs {
+		res[name] = status(ctx)
+	}
+
+	return res
+}
+
+// handleStatus serves the status of all registered services.
+func handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeAdminJSON(w, status(r.Context()))
+}
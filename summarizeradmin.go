@@ -0,0 +1,77 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/services/summarizer"
+)
+
+// registerSummarizerAdmin registers admin handlers to resummarize ranges of epochs and days.
+func registerSummarizerAdmin(s summarizer.Service) {
+	resummarizer, isResummarizer := s.(summarizer.Resummarizer)
+	if !isResummarizer {
+		return
+	}
+
+	registerAdminHandler("/summarizer/resummarize", postOnly(func(w http.ResponseWriter, r *http.Request) {
+		from, err := strconv.ParseUint(r.URL.Query().Get("from"), 10, 64)
+		if err != nil {
+			http.Error(w, "from must be supplied as an epoch", http.StatusBadRequest)
+			return
+		}
+		to, err := strconv.ParseUint(r.URL.Query().Get("to"), 10, 64)
+		if err != nil {
+			http.Error(w, "to must be supplied as an epoch", http.StatusBadRequest)
+			return
+		}
+
+		err = resummarizer.Resummarize(r.Context(), phase0.Epoch(from), phase0.Epoch(to))
+		writeResummarizeResponse(w, err)
+	}))
+
+	registerAdminHandler("/summarizer/resummarize-days", postOnly(func(w http.ResponseWriter, r *http.Request) {
+		from, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
+		if err != nil {
+			http.Error(w, "from must be supplied as a date in the format YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		to, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
+		if err != nil {
+			http.Error(w, "to must be supplied as a date in the format YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		err = resummarizer.ResummarizeDays(r.Context(), from, to)
+		writeResummarizeResponse(w, err)
+	}))
+}
+
+// writeResummarizeResponse writes the response to a resummarization request.
+func writeResummarizeResponse(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusAccepted)
+	case errors.Is(err, scheduler.ErrJobAlreadyExists):
+		http.Error(w, "resummarization of this range is already in progress", http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+)
+
+// CompressionFormat is the format of a compressed column value.
+type CompressionFormat byte
+
+const (
+	// CompressionNone stores values uncompressed.
+	CompressionNone CompressionFormat = 0x00
+	// CompressionDeflate stores values compressed with DEFLATE.
+	CompressionDeflate CompressionFormat = 0x01
+	// CompressionSnappy stores values compressed with snappy.
+	CompressionSnappy CompressionFormat = 0x02
+)
+
+// compressedMarker is the final byte of all compressed values.
+const compressedMarker = 0x00
+
+// ParseCompressionFormat parses the name of a compression format.
+func ParseCompressionFormat(input string) (CompressionFormat, error) {
+	switch strings.ToLower(input) {
+	case "", "none":
+		return CompressionNone, nil
+	case "deflate":
+		return CompressionDeflate, nil
+	case "snappy":
+		return CompressionSnappy, nil
+	default:
+		return CompressionNone, errors.Errorf("unsupported compression format %q", input)
+	}
+}
+
+// columnCompressorPool holds compressors for reuse, as they are expensive to create.
+var columnCompressorPool = sync.Pool{
+	New: func() interface{} {
+		// Error is only returned for an invalid compression level.
+		writer, _ := flate.NewWriter(nil, flate.BestCompression)
+		return writer
+	},
+}
+
+// columnDecompressorPool holds decompressors for reuse.
+var columnDecompressorPool = sync.Pool{
+	New: func() interface{} {
+		return flate.NewReader(nil)
+	},
+}
+
+// IsCompressedColumn returns true if the value is compressed.
+//
+// Compressed values are a format byte, followed by the compressed data,
+// followed by a zero byte.  Compressed and raw values of a column can be told
+// apart as long as raw values either have a fixed length, given by rawLength,
+// or never end in a zero byte, as is the case for SSZ bitlists, in which case
+// rawLength is 0.
+func IsCompressedColumn(data []byte, rawLength int) bool {
+	if len(data) < 2 || data[len(data)-1] != compressedMarker {
+		return false
+	}
+
+	return rawLength == 0 || len(data) != rawLength
+}
+
+// CompressColumn compresses a column value with the given format.
+// The raw value is returned if the format is CompressionNone, or if
+// compression would not make the value smaller.
+func CompressColumn(data []byte, rawLength int, format CompressionFormat) ([]byte, error) {
+	if rawLength == 0 && len(data) > 0 && data[len(data)-1] == compressedMarker {
+		return nil, errors.New("variable-length value ends in a zero byte")
+	}
+	if rawLength != 0 && len(data) != rawLength {
+		return nil, errors.Errorf("value has length %d; expected %d", len(data), rawLength)
+	}
+	if format == CompressionNone || len(data) == 0 {
+		return data, nil
+	}
+
+	var buf *bytes.Buffer
+	switch format {
+	case CompressionDeflate:
+		buf = bytes.NewBuffer(make([]byte, 0, len(data)+2))
+		buf.WriteByte(byte(format))
+		writer := columnCompressorPool.Get().(*flate.Writer)
+		defer columnCompressorPool.Put(writer)
+		writer.Reset(buf)
+		if _, err := writer.Write(data); err != nil {
+			return nil, errors.Wrap(err, "failed to compress value")
+		}
+		if err := writer.Close(); err != nil {
+			return nil, errors.Wrap(err, "failed to complete compression")
+		}
+	case CompressionSnappy:
+		buf = bytes.NewBuffer(make([]byte, 0, snappy.MaxEncodedLen(len(data))+2))
+		buf.WriteByte(byte(format))
+		buf.Write(snappy.Encode(nil, data))
+	default:
+		return nil, errors.Errorf("unsupported compression format %d", format)
+	}
+	buf.WriteByte(compressedMarker)
+
+	compressed := buf.Bytes()
+	if len(compressed) >= len(data) {
+		// Not worth it.
+		return data, nil
+	}
+
+	return compressed, nil
+}
+
+// DecompressColumn returns the raw value of a column value, decompressing it if required.
+func DecompressColumn(data []byte, rawLength int) ([]byte, error) {
+	if !IsCompressedColumn(data, rawLength) {
+		return data, nil
+	}
+
+	switch CompressionFormat(data[0]) {
+	case CompressionDeflate:
+		reader := columnDecompressorPool.Get().(io.ReadCloser)
+		defer columnDecompressorPool.Put(reader)
+		if err := reader.(flate.Resetter).Reset(bytes.NewReader(data[1:len(data)-1]), nil); err != nil {
+			return nil, errors.Wrap(err, "failed to reset decompressor")
+		}
+		raw, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress value")
+		}
+		return raw, nil
+	case CompressionSnappy:
+		raw, err := snappy.Decode(nil, data[1:len(data)-1])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress value")
+		}
+		return raw, nil
+	default:
+		return nil, errors.Errorf("unsupported compression format %d", data[0])
+	}
+}
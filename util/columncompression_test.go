@@ -0,0 +1,394 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+// aggregationBits creates an SSZ bitlist of the given size, with each bit set
+// with the given probability.
+func aggregationBits(size int, participation float64) []byte {
+	r := rand.New(rand.NewSource(int64(size)))
+	bits := make([]byte, size/8+1)
+	for i := 0; i < size; i++ {
+		if r.Float64() < participation {
+			bits[i/8] |= 1 << (i % 8)
+		}
+	}
+	// Sentinel bit.
+	bits[size/8] |= 1 << (size % 8)
+	return bits
+}
+
+// logsBloom creates a logs bloom with the given number of entries.
+func logsBloom(entries int) []byte {
+	r := rand.New(rand.NewSource(int64(entries)))
+	bloom := make([]byte, 256)
+	// Each entry sets three bits.
+	for i := 0; i < entries*3; i++ {
+		bit := r.Intn(2048)
+		bloom[bit/8] |= 1 << (bit % 8)
+	}
+	return bloom
+}
+
+// columnSamples are values of compressible columns used to check round trips.
+var columnSamples = []struct {
+	name      string
+	data      []byte
+	rawLength int
+}{
+	{
+		name: "AggregationBitsFull",
+		data: aggregationBits(450, 1),
+	},
+	{
+		name: "AggregationBitsTypical",
+		data: aggregationBits(450, 0.97),
+	},
+	{
+		name: "AggregationBitsSingle",
+		data: aggregationBits(450, 0.003),
+	},
+	{
+		name:      "LogsBloomEmpty",
+		data:      logsBloom(0),
+		rawLength: 256,
+	},
+	{
+		name:      "LogsBloomSparse",
+		data:      logsBloom(20),
+		rawLength: 256,
+	},
+	{
+		name:      "LogsBloomDense",
+		data:      logsBloom(1000),
+		rawLength: 256,
+	},
+}
+
+// columnFormats are the compression formats that are tested and benchmarked.
+var columnFormats = []struct {
+	name   string
+	format util.CompressionFormat
+}{
+	{
+		name:   "Deflate",
+		format: util.CompressionDeflate,
+	},
+	{
+		name:   "Snappy",
+		format: util.CompressionSnappy,
+	},
+}
+
+func TestColumnCompressionRoundTrip(t *testing.T) {
+	for _, format := range columnFormats {
+		for _, sample := range columnSamples {
+			t.Run(format.name+sample.name, func(t *testing.T) {
+				compressed, err := util.CompressColumn(sample.data, sample.rawLength, format.format)
+				require.NoError(t, err)
+				require.LessOrEqual(t, len(compressed), len(sample.data))
+				t.Logf("%d bytes stored as %d", len(sample.data), len(compressed))
+
+				raw, err := util.DecompressColumn(compressed, sample.rawLength)
+				require.NoError(t, err)
+				require.Equal(t, sample.data, raw)
+
+				// Raw values must be read back unaltered.
+				raw, err = util.DecompressColumn(sample.data, sample.rawLength)
+				require.NoError(t, err)
+				require.Equal(t, sample.data, raw)
+			})
+		}
+	}
+}
+
+func TestDecompressColumnMixedFormats(t *testing.T) {
+	// Values written with one format must be readable after the format is changed.
+	sample := aggregationBits(450, 1)
+	deflated, err := util.CompressColumn(sample, 0, util.CompressionDeflate)
+	require.NoError(t, err)
+	require.Equal(t, byte(util.CompressionDeflate), deflated[0])
+	snapped, err := util.CompressColumn(sample, 0, util.CompressionSnappy)
+	require.NoError(t, err)
+	require.Equal(t, byte(util.CompressionSnappy), snapped[0])
+
+	for _, compressed := range [][]byte{deflated, snapped} {
+		raw, err := util.DecompressColumn(compressed, 0)
+		require.NoError(t, err)
+		require.Equal(t, sample, raw)
+	}
+
+	_, err = util.DecompressColumn([]byte{byte(util.CompressionSnappy), 0xff, 0xff, 0x00}, 0)
+	require.ErrorContains(t, err, "failed to decompress value")
+}
+
+func TestCompressColumn(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		rawLength int
+		format    util.CompressionFormat
+		res       []byte
+		err       string
+	}{
+		{
+			name:   "None",
+			data:   aggregationBits(450, 1),
+			format: util.CompressionNone,
+			res:    aggregationBits(450, 1),
+		},
+		{
+			name:   "Empty",
+			data:   []byte{},
+			format: util.CompressionDeflate,
+			res:    []byte{},
+		},
+		{
+			name:   "Incompressible",
+			data:   []byte{0x01, 0x02, 0x03},
+			format: util.CompressionDeflate,
+			res:    []byte{0x01, 0x02, 0x03},
+		},
+		{
+			name:   "TrailingZero",
+			data:   []byte{0x01, 0x00},
+			format: util.CompressionDeflate,
+			err:    "variable-length value ends in a zero byte",
+		},
+		{
+			name:      "WrongLength",
+			data:      []byte{0x01},
+			rawLength: 256,
+			format:    util.CompressionDeflate,
+			err:       "value has length 1; expected 256",
+		},
+		{
+			name:   "UnknownFormat",
+			data:   aggregationBits(450, 1),
+			format: 0xff,
+			err:    "unsupported compression format 255",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := util.CompressColumn(test.data, test.rawLength, test.format)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.res, res)
+			}
+		})
+	}
+}
+
+func TestParseCompressionFormat(t *testing.T) {
+	format, err := util.ParseCompressionFormat("")
+	require.NoError(t, err)
+	require.Equal(t, util.CompressionNone, format)
+
+	format, err = util.ParseCompressionFormat("Deflate")
+	require.NoError(t, err)
+	require.Equal(t, util.CompressionDeflate, format)
+
+	format, err = util.ParseCompressionFormat("snappy")
+	require.NoError(t, err)
+	require.Equal(t, util.CompressionSnappy, format)
+
+	_, err = util.ParseCompressionFormat("zstd")
+	require.EqualError(t, err, `unsupported compression format "zstd"`)
+}
+
+// mainnetBlocksEnv names the environment variable holding the directory of mainnet
+// blocks against which column compression is benchmarked.  Each file in the
+// directory is a block as returned by the beacon node's /eth/v2/beacon/blocks
+// endpoint.
+const mainnetBlocksEnv = "CHAIND_MAINNET_BLOCKS"
+
+// mainnetBlock holds the compressible values of a block.
+type mainnetBlock struct {
+	Data struct {
+		Message struct {
+			Body struct {
+				RANDAOReveal      string `json:"randao_reveal"`
+				ProposerSlashings []struct {
+					SignedHeader1 struct {
+						Signature string `json:"signature"`
+					} `json:"signed_header_1"`
+					SignedHeader2 struct {
+						Signature string `json:"signature"`
+					} `json:"signed_header_2"`
+				} `json:"proposer_slashings"`
+				AttesterSlashings []struct {
+					Attestation1 struct {
+						Signature string `json:"signature"`
+					} `json:"attestation_1"`
+					Attestation2 struct {
+						Signature string `json:"signature"`
+					} `json:"attestation_2"`
+				} `json:"attester_slashings"`
+				Attestations []struct {
+					AggregationBits string `json:"aggregation_bits"`
+				} `json:"attestations"`
+				ExecutionPayload *struct {
+					LogsBloom string `json:"logs_bloom"`
+				} `json:"execution_payload"`
+			} `json:"body"`
+		} `json:"message"`
+	} `json:"data"`
+}
+
+// mainnetColumn holds the values of a compressible column from a sample of mainnet blocks.
+type mainnetColumn struct {
+	name      string
+	rawLength int
+	values    [][]byte
+}
+
+// mainnetColumns loads the compressible columns of the blocks in the directory
+// named by mainnetBlocksEnv, skipping the benchmark if there are none.
+func mainnetColumns(b *testing.B) []*mainnetColumn {
+	b.Helper()
+
+	dir := os.Getenv(mainnetBlocksEnv)
+	if dir == "" {
+		b.Skipf("%s not set", mainnetBlocksEnv)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(files) == 0 {
+		b.Skipf("no blocks in %s", dir)
+	}
+
+	aggregationBits := &mainnetColumn{name: "AggregationBits"}
+	logsBlooms := &mainnetColumn{name: "LogsBloom", rawLength: 256}
+	randaoReveals := &mainnetColumn{name: "RANDAOReveal", rawLength: 96}
+	slashingSignatures := &mainnetColumn{name: "SlashingSignature", rawLength: 96}
+	add := func(column *mainnetColumn, input string) {
+		value, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		column.values = append(column.values, value)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		block := &mainnetBlock{}
+		if err := json.Unmarshal(data, block); err != nil {
+			b.Fatalf("failed to parse %s: %v", file, err)
+		}
+		body := block.Data.Message.Body
+		add(randaoReveals, body.RANDAOReveal)
+		for _, slashing := range body.ProposerSlashings {
+			add(slashingSignatures, slashing.SignedHeader1.Signature)
+			add(slashingSignatures, slashing.SignedHeader2.Signature)
+		}
+		for _, slashing := range body.AttesterSlashings {
+			add(slashingSignatures, slashing.Attestation1.Signature)
+			add(slashingSignatures, slashing.Attestation2.Signature)
+		}
+		for _, attestation := range body.Attestations {
+			add(aggregationBits, attestation.AggregationBits)
+		}
+		if body.ExecutionPayload != nil {
+			add(logsBlooms, body.ExecutionPayload.LogsBloom)
+		}
+	}
+
+	columns := make([]*mainnetColumn, 0, 4)
+	for _, column := range []*mainnetColumn{aggregationBits, logsBlooms, randaoReveals, slashingSignatures} {
+		if len(column.values) > 0 {
+			columns = append(columns, column)
+		}
+	}
+
+	return columns
+}
+
+// BenchmarkMainnetColumnCompress reports the bytes stored for each column of a
+// sample of mainnet blocks, and the time taken to compress each value.
+func BenchmarkMainnetColumnCompress(b *testing.B) {
+	columns := mainnetColumns(b)
+	for _, format := range columnFormats {
+		for _, column := range columns {
+			b.Run(format.name+column.name, func(b *testing.B) {
+				rawBytes := 0
+				storedBytes := 0
+				for i := 0; i < b.N; i++ {
+					rawBytes = 0
+					storedBytes = 0
+					for _, value := range column.values {
+						compressed, err := util.CompressColumn(value, column.rawLength, format.format)
+						if err != nil {
+							b.Fatal(err)
+						}
+						rawBytes += len(value)
+						storedBytes += len(compressed)
+					}
+				}
+				b.ReportMetric(float64(len(column.values)), "values")
+				b.ReportMetric(float64(rawBytes), "raw-bytes")
+				b.ReportMetric(float64(storedBytes), "stored-bytes")
+				b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(column.values)), "ns/value")
+			})
+		}
+	}
+}
+
+// BenchmarkMainnetColumnDecompress reports the time taken to read back each
+// value of each column of a sample of mainnet blocks.
+func BenchmarkMainnetColumnDecompress(b *testing.B) {
+	columns := mainnetColumns(b)
+	for _, format := range columnFormats {
+		for _, column := range columns {
+			b.Run(format.name+column.name, func(b *testing.B) {
+				stored := make([][]byte, len(column.values))
+				for i, value := range column.values {
+					var err error
+					stored[i], err = util.CompressColumn(value, column.rawLength, format.format)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					for _, value := range stored {
+						if _, err := util.DecompressColumn(value, column.rawLength); err != nil {
+							b.Fatal(err)
+						}
+					}
+				}
+				b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(column.values)), "ns/value")
+			})
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// compactCommitteeVersion is the version of the compact committee encoding.
+const compactCommitteeVersion = 0x01
+
+// compressorPool holds compressors for reuse, as they are expensive to create.
+var compressorPool = sync.Pool{
+	New: func() interface{} {
+		// Error is only returned for an invalid compression level.
+		writer, _ := flate.NewWriter(nil, flate.BestCompression)
+		return writer
+	},
+}
+
+// EncodeCommittee encodes a committee in compact form.
+// Committee members are stored as zigzag-encoded variable-length deltas
+// from the previous member, preserving their order, and the result is
+// compressed.  The first byte of the output is the encoding version.
+func EncodeCommittee(committee []phase0.ValidatorIndex) ([]byte, error) {
+	raw := make([]byte, 0, binary.MaxVarintLen64*(len(committee)+1))
+	raw = binary.AppendUvarint(raw, uint64(len(committee)))
+	previous := int64(0)
+	for _, index := range committee {
+		raw = binary.AppendVarint(raw, int64(index)-previous)
+		previous = int64(index)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(raw)+1))
+	buf.WriteByte(compactCommitteeVersion)
+	writer := compressorPool.Get().(*flate.Writer)
+	defer compressorPool.Put(writer)
+	writer.Reset(buf)
+	if _, err := writer.Write(raw); err != nil {
+		return nil, errors.Wrap(err, "failed to compress committee")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to complete compression")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DecodeCommittee decodes a committee encoded with EncodeCommittee.
+func DecodeCommittee(data []byte) ([]phase0.ValidatorIndex, error) {
+	if len(data) == 0 {
+		return nil, errors.New("no data")
+	}
+	if data[0] != compactCommitteeVersion {
+		return nil, errors.Errorf("unsupported compact committee version %d", data[0])
+	}
+
+	reader := flate.NewReader(bytes.NewReader(data[1:]))
+	defer reader.Close()
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decompress committee")
+	}
+
+	count, n := binary.Uvarint(raw)
+	if n <= 0 {
+		return nil, errors.New("invalid committee length")
+	}
+	raw = raw[n:]
+	// Each member takes at least one byte, which bounds the allocation.
+	if count > uint64(len(raw)) {
+		return nil, errors.New("committee length exceeds data")
+	}
+
+	committee := make([]phase0.ValidatorIndex, count)
+	previous := int64(0)
+	for i := range committee {
+		delta, n := binary.Varint(raw)
+		if n <= 0 {
+			return nil, errors.Errorf("invalid delta for member %d", i)
+		}
+		raw = raw[n:]
+		previous += delta
+		if previous < 0 {
+			return nil, errors.Errorf("negative index for member %d", i)
+		}
+		committee[i] = phase0.ValidatorIndex(previous)
+	}
+	if len(raw) != 0 {
+		return nil, errors.New("trailing data after committee")
+	}
+
+	return committee, nil
+}
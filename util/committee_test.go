@@ -0,0 +1,135 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+// randomCommittee creates a committee of the given size, with members drawn
+// from the given number of validators in shuffled order, as on mainnet.
+func randomCommittee(size int, validators int) []phase0.ValidatorIndex {
+	r := rand.New(rand.NewSource(int64(size)))
+	committee := make([]phase0.ValidatorIndex, size)
+	for i := range committee {
+		committee[i] = phase0.ValidatorIndex(r.Intn(validators))
+	}
+	return committee
+}
+
+func TestCommitteeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		committee []phase0.ValidatorIndex
+	}{
+		{
+			name:      "Empty",
+			committee: []phase0.ValidatorIndex{},
+		},
+		{
+			name:      "Single",
+			committee: []phase0.ValidatorIndex{12345},
+		},
+		{
+			name:      "Descending",
+			committee: []phase0.ValidatorIndex{9, 8, 7, 0},
+		},
+		{
+			name:      "Large",
+			committee: []phase0.ValidatorIndex{0xffffffffffff, 0, 0xffffffffffff},
+		},
+		{
+			name:      "Mainnet",
+			committee: randomCommittee(450, 900000),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := util.EncodeCommittee(test.committee)
+			require.NoError(t, err)
+			committee, err := util.DecodeCommittee(data)
+			require.NoError(t, err)
+			require.Equal(t, test.committee, committee)
+		})
+	}
+}
+
+func TestCommitteeCompact(t *testing.T) {
+	committee := randomCommittee(450, 900000)
+	data, err := util.EncodeCommittee(committee)
+	require.NoError(t, err)
+	// BIGINT[] stores 8 bytes per member; expect at least a 2x saving.
+	require.Less(t, len(data), len(committee)*8/2)
+}
+
+func TestDecodeCommitteeBad(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{
+			name: "Nil",
+			err:  "no data",
+		},
+		{
+			name: "BadVersion",
+			data: []byte{0x02},
+			err:  "unsupported compact committee version 2",
+		},
+		{
+			name: "BadCompression",
+			data: []byte{0x01, 0xff},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := util.DecodeCommittee(test.data)
+			require.Error(t, err)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func BenchmarkEncodeCommittee(b *testing.B) {
+	committee := randomCommittee(450, 900000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := util.EncodeCommittee(committee); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeCommittee(b *testing.B) {
+	data, err := util.EncodeCommittee(randomCommittee(450, 900000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := util.DecodeCommittee(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
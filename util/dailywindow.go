@@ -0,0 +1,96 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"time"
+)
+
+// DailyWindow is a window of time each day, in UTC.
+// The window may wrap around midnight.  A window that starts and ends at
+// the same time covers the entire day.
+type DailyWindow struct {
+	start time.Duration
+	end   time.Duration
+}
+
+// ParseDailyWindow parses the start and end of a daily window, each in the form HH:MM.
+func ParseDailyWindow(start string, end string) (*DailyWindow, error) {
+	startOffset, err := parseTimeOfDay(start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window start: %w", err)
+	}
+	endOffset, err := parseTimeOfDay(end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window end: %w", err)
+	}
+
+	return &DailyWindow{
+		start: startOffset,
+		end:   endOffset,
+	}, nil
+}
+
+// parseTimeOfDay parses a time of day in the form HH:MM, returning the offset from midnight.
+func parseTimeOfDay(input string) (time.Duration, error) {
+	tod, err := time.Parse("15:04", input)
+	if err != nil {
+		return 0, fmt.Errorf("malformed time of day %q", input)
+	}
+
+	return time.Duration(tod.Hour())*time.Hour + time.Duration(tod.Minute())*time.Minute, nil
+}
+
+// Contains returns true if the given time is within the window.
+func (w *DailyWindow) Contains(t time.Time) bool {
+	if w.start == w.end {
+		return true
+	}
+	offset := t.Sub(startOfDay(t))
+	if w.start < w.end {
+		return offset >= w.start && offset < w.end
+	}
+
+	// Window wraps around midnight.
+	return offset >= w.start || offset < w.end
+}
+
+// Next returns the given time if it is within the window, otherwise the start of the next window.
+func (w *DailyWindow) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	next := startOfDay(t).Add(w.start)
+	if next.Before(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+}
+
+// String returns a string representation of the window.
+func (w *DailyWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d",
+		int(w.start.Hours()), int(w.start.Minutes())%60,
+		int(w.end.Hours()), int(w.end.Minutes())%60,
+	)
+}
+
+// startOfDay returns midnight UTC at the start of the day of the given time.
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,167 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestParseDailyWindow(t *testing.T) {
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		res   string
+		err   string
+	}{
+		{
+			name:  "Good",
+			start: "02:00",
+			end:   "05:30",
+			res:   "02:00-05:30",
+		},
+		{
+			name:  "Wrapping",
+			start: "22:15",
+			end:   "04:00",
+			res:   "22:15-04:00",
+		},
+		{
+			name:  "StartInvalid",
+			start: "25:00",
+			end:   "04:00",
+			err:   `invalid window start: malformed time of day "25:00"`,
+		},
+		{
+			name:  "EndInvalid",
+			start: "02:00",
+			end:   "4pm",
+			err:   `invalid window end: malformed time of day "4pm"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			window, err := util.ParseDailyWindow(test.start, test.end)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.res, window.String())
+			}
+		})
+	}
+}
+
+func TestDailyWindow(t *testing.T) {
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour int, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	tests := []struct {
+		name     string
+		start    string
+		end      string
+		t        time.Time
+		contains bool
+		next     time.Time
+	}{
+		{
+			name:     "AllDay",
+			start:    "00:00",
+			end:      "00:00",
+			t:        at(13, 0),
+			contains: true,
+			next:     at(13, 0),
+		},
+		{
+			name:     "Inside",
+			start:    "02:00",
+			end:      "05:00",
+			t:        at(3, 0),
+			contains: true,
+			next:     at(3, 0),
+		},
+		{
+			name:     "AtStart",
+			start:    "02:00",
+			end:      "05:00",
+			t:        at(2, 0),
+			contains: true,
+			next:     at(2, 0),
+		},
+		{
+			name:     "AtEnd",
+			start:    "02:00",
+			end:      "05:00",
+			t:        at(5, 0),
+			contains: false,
+			next:     at(26, 0),
+		},
+		{
+			name:     "Before",
+			start:    "02:00",
+			end:      "05:00",
+			t:        at(1, 0),
+			contains: false,
+			next:     at(2, 0),
+		},
+		{
+			name:     "WrappingLate",
+			start:    "22:00",
+			end:      "04:00",
+			t:        at(23, 0),
+			contains: true,
+			next:     at(23, 0),
+		},
+		{
+			name:     "WrappingEarly",
+			start:    "22:00",
+			end:      "04:00",
+			t:        at(3, 0),
+			contains: true,
+			next:     at(3, 0),
+		},
+		{
+			name:     "WrappingOutside",
+			start:    "22:00",
+			end:      "04:00",
+			t:        at(12, 0),
+			contains: false,
+			next:     at(22, 0),
+		},
+		{
+			name:     "OtherTimezone",
+			start:    "02:00",
+			end:      "05:00",
+			t:        at(3, 0).In(time.FixedZone("UTC+10", 10*60*60)),
+			contains: true,
+			next:     at(3, 0),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			window, err := util.ParseDailyWindow(test.start, test.end)
+			require.NoError(t, err)
+			require.Equal(t, test.contains, window.Contains(test.t))
+			require.True(t, test.next.Equal(window.Next(test.t)), "expected %v, got %v", test.next, window.Next(test.t))
+		})
+	}
+}
@@ -43,22 +43,32 @@ func LogLevel(path string) zerolog.Level {
 // stringtoLevel converts a string to a log level.
 // It returns the user-supplied level by default.
 func stringToLevel(input string) zerolog.Level {
+	level, err := ParseLogLevel(input)
+	if err != nil {
+		return zerologger.Logger.GetLevel()
+	}
+
+	return level
+}
+
+// ParseLogLevel parses a log level, returning an error if it is not recognised.
+func ParseLogLevel(input string) (zerolog.Level, error) {
 	switch strings.ToLower(input) {
 	case "none":
-		return zerolog.Disabled
+		return zerolog.Disabled, nil
 	case "trace":
-		return zerolog.TraceLevel
+		return zerolog.TraceLevel, nil
 	case "debug":
-		return zerolog.DebugLevel
+		return zerolog.DebugLevel, nil
 	case "warn", "warning":
-		return zerolog.WarnLevel
+		return zerolog.WarnLevel, nil
 	case "info", "information":
-		return zerolog.InfoLevel
+		return zerolog.InfoLevel, nil
 	case "err", "error":
-		return zerolog.ErrorLevel
+		return zerolog.ErrorLevel, nil
 	case "fatal":
-		return zerolog.FatalLevel
+		return zerolog.FatalLevel, nil
 	default:
-		return zerologger.Logger.GetLevel()
+		return zerolog.NoLevel, fmt.Errorf("unrecognised log level %q", input)
 	}
 }
@@ -0,0 +1,84 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/atomic"
+)
+
+// serviceLevels holds the runtime log levels of services, keyed by service name.
+var (
+	serviceLevelsMu sync.RWMutex
+	serviceLevels   = make(map[string]*atomic.Int32)
+)
+
+// runtimeLevelSampler passes events at or above a level that can be changed at runtime.
+// A sampler is consulted before an event is created, so events below the level
+// cost no more than they would with a fixed logger level.
+type runtimeLevelSampler struct {
+	level *atomic.Int32
+}
+
+// Sample returns true if the event should be logged.
+func (s *runtimeLevelSampler) Sample(lvl zerolog.Level) bool {
+	return lvl >= zerolog.Level(s.level.Load())
+}
+
+// ServiceLogger returns a logger for the named service, logging at the given level.
+// The level can be changed while the process is running with SetServiceLogLevel.
+// Services with more than one implementation share a single level.
+func ServiceLogger(logger zerolog.Logger, service string, level zerolog.Level) zerolog.Logger {
+	serviceLevelsMu.Lock()
+	runtimeLevel, exists := serviceLevels[service]
+	if exists {
+		runtimeLevel.Store(int32(level))
+	} else {
+		runtimeLevel = atomic.NewInt32(int32(level))
+		serviceLevels[service] = runtimeLevel
+	}
+	serviceLevelsMu.Unlock()
+
+	// The logger passes all levels, leaving the sampler to apply the runtime level.
+	return logger.Level(zerolog.TraceLevel).Sample(&runtimeLevelSampler{level: runtimeLevel})
+}
+
+// SetServiceLogLevel changes the log level of the named service.
+func SetServiceLogLevel(service string, level zerolog.Level) error {
+	serviceLevelsMu.RLock()
+	runtimeLevel, exists := serviceLevels[service]
+	serviceLevelsMu.RUnlock()
+	if !exists {
+		return fmt.Errorf("unknown service %q", service)
+	}
+	runtimeLevel.Store(int32(level))
+
+	return nil
+}
+
+// ServiceLogLevels returns the current log levels of services, keyed by service name.
+func ServiceLogLevels() map[string]zerolog.Level {
+	serviceLevelsMu.RLock()
+	defer serviceLevelsMu.RUnlock()
+
+	res := make(map[string]zerolog.Level, len(serviceLevels))
+	for service, runtimeLevel := range serviceLevels {
+		res[service] = zerolog.Level(runtimeLevel.Load())
+	}
+
+	return res
+}
@@ -0,0 +1,80 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestServiceLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log := util.ServiceLogger(zerolog.New(&buf), "test", zerolog.InfoLevel)
+	child := log.With().Str("child", "true").Logger()
+
+	log.Debug().Msg("hidden")
+	require.False(t, log.Debug().Enabled())
+	require.Empty(t, buf.String())
+	log.Info().Msg("shown")
+	require.Contains(t, buf.String(), "shown")
+	buf.Reset()
+
+	// Lower the level.
+	require.NoError(t, util.SetServiceLogLevel("test", zerolog.TraceLevel))
+	require.Equal(t, zerolog.TraceLevel, util.ServiceLogLevels()["test"])
+	require.True(t, log.Trace().Enabled())
+	child.Trace().Msg("child trace")
+	require.Contains(t, buf.String(), "child trace")
+	buf.Reset()
+
+	// Disable logging.
+	require.NoError(t, util.SetServiceLogLevel("test", zerolog.Disabled))
+	log.Error().Msg("hidden")
+	require.Empty(t, buf.String())
+
+	require.EqualError(t, util.SetServiceLogLevel("unknown", zerolog.InfoLevel), `unknown service "unknown"`)
+}
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		input string
+		level zerolog.Level
+		err   string
+	}{
+		{input: "none", level: zerolog.Disabled},
+		{input: "TRACE", level: zerolog.TraceLevel},
+		{input: "debug", level: zerolog.DebugLevel},
+		{input: "information", level: zerolog.InfoLevel},
+		{input: "warning", level: zerolog.WarnLevel},
+		{input: "err", level: zerolog.ErrorLevel},
+		{input: "fatal", level: zerolog.FatalLevel},
+		{input: "verbose", err: `unrecognised log level "verbose"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			level, err := util.ParseLogLevel(test.input)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.level, level)
+		})
+	}
+}
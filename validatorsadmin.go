@@ -0,0 +1,73 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/validators/snapshot"
+)
+
+// registerValidatorsAdmin registers admin handlers to export the validator set.
+func registerValidatorsAdmin(s *snapshot.Service) {
+	registerAdminHandler("/validators/snapshot", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		epoch, err := strconv.ParseUint(r.URL.Query().Get("epoch"), 10, 64)
+		if err != nil {
+			http.Error(w, "epoch must be supplied as a number", http.StatusBadRequest)
+			return
+		}
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "json"
+		}
+		if format != "json" && format != "csv" {
+			http.Error(w, "format must be one of json or csv", http.StatusBadRequest)
+			return
+		}
+
+		res, err := s.Snapshot(r.Context(), phase0.Epoch(epoch))
+		if err != nil {
+			switch {
+			case errors.Is(err, snapshot.ErrEpochUnavailable), errors.Is(err, snapshot.ErrNoBalances):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			default:
+				log.Warn().Uint64("epoch", epoch).Err(err).Msg("Failed to create validator snapshot")
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("X-Snapshot-Epoch", strconv.FormatUint(uint64(res.Epoch), 10))
+		w.Header().Set("X-Snapshot-Balance-Epoch", strconv.FormatUint(uint64(res.BalanceEpoch), 10))
+		w.Header().Set("X-Snapshot-Exact", strconv.FormatBool(res.Exact))
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			err = res.WriteCSV(w)
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			err = res.WriteJSON(w)
+		}
+		if err != nil {
+			log.Warn().Uint64("epoch", epoch).Err(err).Msg("Failed to write validator snapshot")
+		}
+	})
+}
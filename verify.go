@@ -0,0 +1,79 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	standardverifier "github.com/wealdtech/chaind/services/verifier/standard"
+	"github.com/wealdtech/chaind/util"
+)
+
+// runVerify verifies stored data for a range of epochs against the chain.
+func runVerify(ctx context.Context) error {
+	pflag.Uint64("from-epoch", 0, "Epoch from which to verify data")
+	pflag.Int64("to-epoch", -1, "Epoch to which to verify data (defaults to the latest finalized epoch)")
+	if err := fetchConfig(); err != nil {
+		return errors.Wrap(err, "failed to fetch configuration")
+	}
+	if err := initLogging(); err != nil {
+		return errors.Wrap(err, "failed to initialise logging")
+	}
+
+	// Verification only reads, so the database is opened read-only.
+	chainDB, err := startDatabase(ctx, postgresqlchaindb.WithReadOnly(true))
+	if err != nil {
+		return err
+	}
+
+	eth2Client, err := fetchClient(ctx, viper.GetString("eth2client.address"))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("eth2client.address")))
+	}
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(util.LogLevel("chaintime")),
+		standardchaintime.WithGenesisTimeProvider(eth2Client.(eth2client.GenesisTimeProvider)),
+		standardchaintime.WithSpecProvider(eth2Client.(eth2client.SpecProvider)),
+		standardchaintime.WithForkScheduleProvider(eth2Client.(eth2client.ForkScheduleProvider)),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to start chain time service")
+	}
+	beaconFetcher, err := fetchBeaconFetcher(ctx, viper.GetString("eth2client.address"), chainTime)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch beacon fetcher")
+	}
+
+	verifier, err := standardverifier.New(ctx,
+		standardverifier.WithLogLevel(util.LogLevel("verifier")),
+		standardverifier.WithChainDB(chainDB),
+		standardverifier.WithETH2Client(eth2Client),
+		standardverifier.WithBeaconFetcher(beaconFetcher),
+		standardverifier.WithChainTime(chainTime),
+		standardverifier.WithStoreAttestations(viper.GetBool("blocks.store.attestations")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to start verifier service")
+	}
+
+	return verifier.Verify(ctx, phase0.Epoch(viper.GetUint64("from-epoch")), viper.GetInt64("to-epoch"))
+}
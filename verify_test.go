@@ -0,0 +1,57 @@
+// Copyright © 2023 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/verifier"
+)
+
+func TestVerifyExit(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		exit int
+	}{
+		{
+			name: "Pass",
+			exit: 0,
+		},
+		{
+			name: "Discrepancies",
+			err:  verifier.ErrDiscrepancies,
+			exit: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			savedArgs := os.Args
+			savedVerify := subcommands["verify"]
+			os.Args = []string{"chaind", "verify"}
+			subcommands["verify"] = func(_ context.Context) error {
+				return test.err
+			}
+			defer func() {
+				os.Args = savedArgs
+				subcommands["verify"] = savedVerify
+			}()
+			require.Equal(t, test.exit, main2())
+		})
+	}
+}